@@ -0,0 +1,161 @@
+// drivertest 脱离真实设备测试 WASM 驱动：回放收发记录并校验测点，或在真实总线上录制收发记录。
+//
+//	回放: drivertest -wasm meter.wasm -transcript meter.json
+//	录制: drivertest -wasm meter.wasm -transcript meter.json -record -serial /dev/ttyS1 -baud 9600 -config slave_id=1
+//	      drivertest -wasm meter.wasm -transcript meter.json -record -tcp 192.168.1.10:502
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/driver"
+)
+
+type options struct {
+	wasmPath       string
+	transcriptPath string
+	record         bool
+	realtime       bool
+	timeout        time.Duration
+
+	serialPath string
+	serialCfg  driver.SerialConfig
+	tcpAddr    string
+
+	function     string
+	config       map[string]string
+	deviceConfig string
+}
+
+func main() {
+	opts, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	wasmData, err := os.ReadFile(opts.wasmPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "read wasm:", err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	if opts.record {
+		err = record(ctx, wasmData, opts)
+	} else {
+		err = replay(ctx, wasmData, opts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func parseFlags(args []string) (*options, error) {
+	opts := &options{config: map[string]string{}}
+	fs := flag.NewFlagSet("drivertest", flag.ContinueOnError)
+	fs.StringVar(&opts.wasmPath, "wasm", "", "driver wasm file")
+	fs.StringVar(&opts.transcriptPath, "transcript", "", "transcript file (replay input / record output)")
+	fs.BoolVar(&opts.record, "record", false, "record a transcript from a real serial port or TCP device")
+	fs.BoolVar(&opts.realtime, "realtime", false, "replay responses with recorded delays")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "overall timeout")
+	fs.StringVar(&opts.serialPath, "serial", "", "serial port path (record)")
+	fs.IntVar(&opts.serialCfg.BaudRate, "baud", 9600, "serial baud rate")
+	fs.IntVar(&opts.serialCfg.DataBits, "data-bits", 8, "serial data bits")
+	fs.StringVar(&opts.serialCfg.Parity, "parity", "N", "serial parity N/E/O")
+	fs.IntVar(&opts.serialCfg.StopBits, "stop-bits", 1, "serial stop bits")
+	fs.StringVar(&opts.tcpAddr, "tcp", "", "TCP device address host:port (record)")
+	fs.StringVar(&opts.function, "function", "", "driver function (record, default handle)")
+	fs.StringVar(&opts.deviceConfig, "device-config", "", "device_config JSON (record)")
+	fs.Func("config", "device config key=value, repeatable (record)", func(value string) error {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("config must be key=value")
+		}
+		opts.config[strings.TrimSpace(key)] = val
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.wasmPath == "" || opts.transcriptPath == "" {
+		return nil, fmt.Errorf("-wasm and -transcript are required")
+	}
+	if opts.record && (opts.serialPath == "") == (opts.tcpAddr == "") {
+		return nil, fmt.Errorf("record mode requires exactly one of -serial or -tcp")
+	}
+	return opts, nil
+}
+
+func replay(ctx context.Context, wasmData []byte, opts *options) error {
+	tr, err := driver.LoadTranscript(opts.transcriptPath)
+	if err != nil {
+		return err
+	}
+	report := driver.ReplayTranscript(ctx, wasmData, tr, opts.realtime)
+	printJSON(report)
+	if !report.Passed() {
+		return fmt.Errorf("FAIL: %d assertion(s) failed", len(report.Failures))
+	}
+	fmt.Printf("PASS: %d exchange(s) replayed\n", len(tr.Exchanges))
+	return nil
+}
+
+func record(ctx context.Context, wasmData []byte, opts *options) error {
+	tr := &driver.Transcript{
+		Function:     opts.function,
+		Config:       opts.config,
+		DeviceConfig: opts.deviceConfig,
+	}
+
+	var bus driver.SerialPort
+	if opts.serialPath != "" {
+		tr.ResourceType = "serial"
+		tr.Address = opts.serialPath
+		port, err := driver.OpenSerial(opts.serialPath, opts.serialCfg)
+		if err != nil {
+			return fmt.Errorf("open serial %s: %w", opts.serialPath, err)
+		}
+		bus = port
+	} else {
+		tr.ResourceType = "net"
+		tr.Address = opts.tcpAddr
+		conn, err := net.DialTimeout("tcp", opts.tcpAddr, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("dial %s: %w", opts.tcpAddr, err)
+		}
+		bus = conn
+	}
+	defer bus.Close()
+
+	result, runErr := driver.RecordTranscript(ctx, wasmData, tr, bus)
+	if result != nil {
+		printJSON(result)
+	}
+	if err := driver.SaveTranscript(opts.transcriptPath, tr); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %d exchange(s) to %s\n", len(tr.Exchanges), opts.transcriptPath)
+	if runErr != nil {
+		return fmt.Errorf("driver error (transcript saved without expectations): %w", runErr)
+	}
+	return nil
+}
+
+func printJSON(v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Println(string(data))
+}
@@ -4,6 +4,7 @@ import "net/http"
 
 func registerAlarmRoutes(api *http.ServeMux, apiDeps *apiRouteDeps) {
 	api.HandleFunc("GET /alarms", apiDeps.alarm.GetAlarmLogs)
+	api.HandleFunc("GET /alarms/active", apiDeps.alarm.GetActiveAlarms)
 	api.HandleFunc("DELETE /alarms", apiDeps.alarm.ClearAlarms)
 	api.HandleFunc("POST /alarms/batch-delete", apiDeps.alarm.BatchDeleteAlarms)
 	api.HandleFunc("DELETE /alarms/{id}", apiDeps.alarm.DeleteAlarm)
@@ -0,0 +1,10 @@
+package app
+
+import "net/http"
+
+func registerBackupRoutes(api *http.ServeMux, apiDeps *apiRouteDeps) {
+	api.HandleFunc("GET /backups", apiDeps.backup.ListBackups)
+	api.HandleFunc("POST /backups", apiDeps.backup.CreateBackup)
+	api.HandleFunc("GET /backups/{name}/download", apiDeps.backup.DownloadBackup)
+	api.HandleFunc("POST /backups/{name}/restore", apiDeps.backup.RestoreBackup)
+}
@@ -0,0 +1,8 @@
+package app
+
+import "net/http"
+
+func registerConfigRoutes(api *http.ServeMux, apiDeps *apiRouteDeps) {
+	api.HandleFunc("GET /config/export", apiDeps.config.ExportConfig)
+	api.HandleFunc("POST /config/import", apiDeps.config.ImportConfig)
+}
@@ -6,5 +6,10 @@ func registerDataRoutes(api *http.ServeMux, apiDeps *apiRouteDeps) {
 	api.HandleFunc("GET /data", apiDeps.data.GetDataCache)
 	api.HandleFunc("GET /data/cache/{id}", apiDeps.data.GetDataCacheByDeviceID)
 	api.HandleFunc("GET /data/history", apiDeps.data.GetHistoryData)
+	api.HandleFunc("POST /data/query", apiDeps.data.QueryHistorySeries)
+	api.HandleFunc("GET /data/export", apiDeps.data.ExportHistoryData)
 	api.HandleFunc("DELETE /data/history", apiDeps.data.ClearHistoryData)
+	api.HandleFunc("GET /data/storage", apiDeps.data.GetDataStorage)
+	api.HandleFunc("PUT /data/storage/devices/{id}", apiDeps.data.UpdateDeviceRetentionPolicy)
+	api.HandleFunc("DELETE /data/storage/devices/{id}", apiDeps.data.DeleteDeviceRetentionPolicy)
 }
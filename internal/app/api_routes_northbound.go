@@ -7,6 +7,7 @@ func registerNorthboundRoutes(api *http.ServeMux, apiDeps *apiRouteDeps) {
 	api.HandleFunc("GET /northbound/status", apiDeps.northbound.GetNorthboundStatus)
 	api.HandleFunc("GET /northbound/schema", apiDeps.northbound.GetNorthboundSchema)
 	api.HandleFunc("POST /northbound", apiDeps.northbound.CreateNorthboundConfig)
+	api.HandleFunc("POST /northbound/preview", apiDeps.northbound.PreviewNorthboundConfig)
 	api.HandleFunc("PUT /northbound/{id}", apiDeps.northbound.UpdateNorthboundConfig)
 	api.HandleFunc("DELETE /northbound/{id}", apiDeps.northbound.DeleteNorthboundConfig)
 	api.HandleFunc("POST /northbound/{id}/toggle", apiDeps.northbound.ToggleNorthboundEnable)
@@ -19,6 +19,7 @@ import (
 )
 
 const retentionCleanupInterval = 24 * time.Hour
+const dataRollupInterval = time.Minute
 
 // Run boots the application and blocks until shutdown completes.
 func Run(cfg *config.Config) error {
@@ -39,12 +40,16 @@ func Run(cfg *config.Config) error {
 	driverManager := driver.NewDriverManager()
 	driverExecutor := driver.NewDriverExecutor(driverManager)
 	driverManager.SetCallTimeout(cfg.DriverCallTimeout)
+	driverManager.SetInstanceIdleTimeout(cfg.DriverInstanceIdleTimeout)
+	driverManager.SetSandboxLimits(cfg.DriverMaxMemoryPages, cfg.DriverMaxCallBudget)
+	driverManager.SetQuarantinePolicy(cfg.DriverQuarantineCrashes, cfg.DriverQuarantineWindow)
 
 	if err := loadEnabledDrivers(cfg, driverManager); err != nil {
 		slog.Warn("Failed to load drivers", "error", err)
 	}
 
 	northboundMgr := northbound.NewNorthboundManager()
+	applyNorthboundSpoolConfig(cfg, northboundMgr)
 	loadEnabledNorthboundConfigs(northboundMgr)
 	startNorthboundSchedulers(northboundMgr)
 	northboundMgr.Start()
@@ -80,6 +85,11 @@ func Run(cfg *config.Config) error {
 }
 
 func startBackgroundTasks(cfg *config.Config) {
+	slog.Info("Opening data journal...")
+	if err := database.OpenDataJournal(); err != nil {
+		slog.Error("Failed to open data journal", "error", err)
+	}
+
 	slog.Info("Starting collect data writer...")
 	database.StartCollectDataWriter()
 
@@ -89,6 +99,14 @@ func startBackgroundTasks(cfg *config.Config) {
 	slog.Info("Starting retention cleanup task...")
 	database.StartRetentionCleanup(retentionCleanupInterval)
 
+	slog.Info("Starting data rollup task...")
+	database.StartDataRollup(dataRollupInterval)
+
+	if cfg != nil {
+		slog.Info("Starting backup schedule...")
+		database.StartBackupSchedule(cfg.BackupInterval)
+	}
+
 	if cfg != nil && cfg.ThresholdCacheEnabled {
 		slog.Info("Starting threshold cache...")
 		collector.StartThresholdCache()
@@ -177,14 +195,33 @@ func registerShutdown(gracefulMgr *graceful.GracefulShutdown, collect *collector
 		return nil
 	})
 
+	gracefulMgr.AddShutdownFunc(func(ctx context.Context) error {
+		slog.Info("Stopping data rollup...")
+		database.StopDataRollup()
+		return nil
+	})
+
+	gracefulMgr.AddShutdownFunc(func(ctx context.Context) error {
+		slog.Info("Stopping backup schedule...")
+		database.StopBackupSchedule()
+		return nil
+	})
+
 	gracefulMgr.AddShutdownFunc(func(ctx context.Context) error {
 		slog.Info("Final sync to disk...")
 		return database.SyncDataToDisk()
 	})
 
+	gracefulMgr.AddShutdownFunc(func(ctx context.Context) error {
+		slog.Info("Closing data journal...")
+		database.CloseDataJournal()
+		return nil
+	})
+
 	gracefulMgr.AddShutdownFunc(func(ctx context.Context) error {
 		slog.Info("Stopping northbound manager...")
 		northMgr.Stop()
+		database.CloseNorthboundSpool()
 		return nil
 	})
 
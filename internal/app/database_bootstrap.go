@@ -31,6 +31,11 @@ func applyDatabaseRuntimeConfig(cfg *config.Config) {
 
 	database.ApplyRuntimeLimits(cfg.MaxDataPoints, cfg.MaxDataCache)
 	database.ApplySyncInterval(cfg.SyncInterval)
+	database.ApplyRollupRetention(cfg.RollupMinuteRetentionDays, cfg.RollupHourRetentionDays)
+	database.ApplyDataDiskBudget(int64(cfg.DataMaxDiskMB) * 1024 * 1024)
+	database.ApplyDiskPressureThresholds(cfg.DataDiskEmergencyPercent, cfg.DataDiskResumePercent)
+	database.ApplyDataJournalConfig(cfg.DataJournalEnabled, cfg.DataJournalFsyncInterval, cfg.DataJournalFsyncBatch)
+	database.ApplyBackupConfig(cfg.BackupDir, cfg.BackupKeep)
 }
 
 func initParamDatabase(cfg *config.Config) error {
@@ -56,9 +61,6 @@ func initSchemasAndDefaultData() error {
 	if err := initGatewayDatabaseTables(); err != nil {
 		return err
 	}
-	if err := initDeviceDatabaseTables(); err != nil {
-		return err
-	}
 	if err := initDataDatabaseSchema(); err != nil {
 		return err
 	}
@@ -78,12 +80,6 @@ func initParamDatabaseSchema() error {
 }
 
 func initGatewayDatabaseTables() error {
-	slog.Info("Initializing resource table...")
-	if err := database.InitResourceTable(); err != nil {
-		return fmt.Errorf("failed to initialize resource table: %w", err)
-	}
-	database.EnsureDeviceResourceColumn()
-
 	slog.Info("Initializing gateway config table...")
 	if err := database.InitGatewayConfigTable(); err != nil {
 		return fmt.Errorf("failed to initialize gateway config table: %w", err)
@@ -96,12 +92,12 @@ func initGatewayDatabaseTables() error {
 	return nil
 }
 
-func initDeviceDatabaseTables() error {
-	slog.Info("Initializing device table...")
-	if err := database.InitDeviceTable(); err != nil {
-		return fmt.Errorf("failed to initialize device table: %w", err)
+// reloadParamDatabaseTables 恢复备份后补齐 param.db 的表结构与默认数据（schema 迁移已在恢复时执行）
+func reloadParamDatabaseTables() error {
+	if err := initGatewayDatabaseTables(); err != nil {
+		return err
 	}
-	return nil
+	return initDefaultGatewayData()
 }
 
 func initDataDatabaseSchema() error {
@@ -11,8 +11,25 @@ import (
 	"github.com/gonglijing/xunjiFsu/internal/models"
 	"github.com/gonglijing/xunjiFsu/internal/northbound"
 	"github.com/gonglijing/xunjiFsu/internal/northbound/adapters"
+	"github.com/gonglijing/xunjiFsu/internal/platform/config"
 )
 
+// applyNorthboundSpoolConfig 启用北向断线/熔断暂存（落盘 data.db）
+func applyNorthboundSpoolConfig(cfg *config.Config, northboundMgr *northbound.NorthboundManager) {
+	if cfg == nil || northboundMgr == nil || !cfg.NorthboundSpoolEnabled {
+		return
+	}
+	northboundMgr.SetSpool(database.NewNorthboundSpoolStore(), northbound.SpoolConfig{
+		MaxEntries:  cfg.NorthboundSpoolMaxEntries,
+		MaxAge:      cfg.NorthboundSpoolMaxAge,
+		ReplayBatch: cfg.NorthboundSpoolReplayBatch,
+	})
+	slog.Info("Northbound spool enabled",
+		"max_entries", cfg.NorthboundSpoolMaxEntries,
+		"max_age", cfg.NorthboundSpoolMaxAge,
+		"replay_batch", cfg.NorthboundSpoolReplayBatch)
+}
+
 func loadEnabledNorthboundConfigs(northboundMgr *northbound.NorthboundManager) {
 	slog.Info("Loading enabled northbound configs...")
 	configs, err := database.ListNorthboundConfigs()
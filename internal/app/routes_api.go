@@ -21,6 +21,8 @@ func registerAPIRoutes(r *http.ServeMux, apiDeps *apiRouteDeps, authManager *aut
 	registerUserRoutes(api, apiDeps)
 	registerResourceRoutes(api, apiDeps)
 	registerGatewayRoutes(api, apiDeps)
+	registerConfigRoutes(api, apiDeps)
+	registerBackupRoutes(api, apiDeps)
 	registerDebugRoutes(api, apiDeps)
 
 	r.Handle("/api/", authManager.RequireAuth(http.StripPrefix("/api", api)))
@@ -29,6 +29,8 @@ type apiRouteDeps struct {
 	user          *httpapi.UserAPI
 	threshold     *httpapi.ThresholdAPI
 	alarm         *httpapi.AlarmAPI
+	config        *httpapi.ConfigAPI
+	backup        *httpapi.BackupAPI
 }
 
 func newAPIRouteDeps(
@@ -51,7 +53,7 @@ func newAPIRouteDeps(
 		data:          httpapi.NewDataAPI(service.NewDataService()),
 		driver:        httpapi.NewDriverAPI(service.NewDriverService(driverManager, driverManager, cfg.DriversDir)),
 		northbound:    httpapi.NewNorthboundAPI(service.NewNorthboundService(northboundMgr, service.NorthboundRuntimeHooks{Rebuild: newNorthboundRuntimeRebuilder(northboundMgr)}), northboundMgr),
-		device:        httpapi.NewDeviceAPI(service.NewDeviceService(collect)),
+		device:        httpapi.NewDeviceAPI(service.NewDeviceService(collect, driverManager)),
 		deviceExec:    httpapi.NewDeviceExecAPI(service.NewDeviceExecService(driverManager)),
 		deviceRuntime: httpapi.NewDeviceRuntimeAPI(service.NewDeviceRuntimeService(collect)),
 		debugModbus:   httpapi.NewDebugModbusAPI(),
@@ -61,8 +63,13 @@ func newAPIRouteDeps(
 		),
 		resource:  httpapi.NewResourceAPI(service.NewResourceService(executor)),
 		user:      httpapi.NewUserAPI(service.NewUserService(), authManager),
-		threshold: httpapi.NewThresholdAPI(service.NewThresholdService()),
+		threshold: httpapi.NewThresholdAPI(service.NewThresholdService(collect)),
 		alarm:     httpapi.NewAlarmAPI(service.NewAlarmService()),
+		config:    httpapi.NewConfigAPI(service.NewConfigBundleService(cfg.DriversDir, collect)),
+		backup: httpapi.NewBackupAPI(service.NewBackupService(collect, service.BackupRuntimeHooks{
+			StopNorthbound: northboundMgr.Stop,
+			ReloadSchema:   reloadParamDatabaseTables,
+		})),
 	}
 }
 
@@ -32,6 +32,9 @@ func TestRegisterAPIRoutes_Patterns(t *testing.T) {
 		{method: http.MethodPost, path: "/api/debug/modbus/tcp", wantPattern: "/api/"},
 		{method: http.MethodGet, path: "/api/gateway/config", wantPattern: "/api/"},
 		{method: http.MethodPut, path: "/api/gateway/runtime", wantPattern: "/api/"},
+		{method: http.MethodGet, path: "/api/config/export", wantPattern: "/api/"},
+		{method: http.MethodGet, path: "/api/backups", wantPattern: "/api/"},
+		{method: http.MethodPost, path: "/api/data/query", wantPattern: "/api/"},
 		{method: http.MethodGet, path: "/api/resources", wantPattern: "/api/"},
 		{method: http.MethodGet, path: "/api/users", wantPattern: "/api/"},
 		{method: http.MethodGet, path: "/api/thresholds", wantPattern: "/api/"},
@@ -25,6 +25,7 @@ func buildHandlerChain(cfg *config.Config, router *http.ServeMux) http.Handler {
 	timeoutConfig.ReadTimeout = cfg.HTTPReadTimeout
 	timeoutConfig.WriteTimeout = cfg.HTTPWriteTimeout
 	timeoutConfig.IdleTimeout = cfg.HTTPIdleTimeout
+	timeoutConfig.StreamingPaths = []string{"/api/data/export"}
 
 	return httpapi.TimeoutMiddleware(timeoutConfig)(corsHandler)
 }
@@ -125,6 +126,10 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (r *statusRecorder) Write(p []byte) (int, error) {
 	if r.statusCode == 0 {
 		r.statusCode = http.StatusOK
@@ -270,6 +270,8 @@ func shouldEmitAlarmForIDKey(key alarmStateIDKey, now time.Time, repeatInterval
 }
 
 func clearAlarmStateForDevice(deviceID int64) {
+	clearAlarmLifecyclesForDevice(deviceID)
+
 	alarmStates.mu.Lock()
 	defer alarmStates.mu.Unlock()
 
@@ -284,3 +286,210 @@ func clearAlarmStateForDevice(deviceID int64) {
 		}
 	}
 }
+
+// ==================== 报警生命周期（产生 / 恢复） ====================
+
+type alarmPhase uint8
+
+const (
+	alarmPhaseNormal   alarmPhase = iota
+	alarmPhasePending             // 已满足条件，等待触发延时
+	alarmPhaseActive              // 报警中
+	alarmPhaseClearing            // 已不满足保持条件，等待恢复延时
+)
+
+type alarmTransition uint8
+
+const (
+	alarmTransitionNone alarmTransition = iota
+	alarmTransitionRaise
+	alarmTransitionClear
+)
+
+// alarmLifecycle 单个（设备, 阈值）的报警状态机
+type alarmLifecycle struct {
+	phase       alarmPhase
+	since       time.Time // 进入 pending / clearing 的时间
+	triggeredAt time.Time
+	alarmLogID  int64
+}
+
+var alarmLifecycles = struct {
+	mu     sync.Mutex
+	data   map[alarmStateIDKey]*alarmLifecycle
+	loaded map[int64]struct{}
+}{
+	data:   make(map[alarmStateIDKey]*alarmLifecycle),
+	loaded: make(map[int64]struct{}),
+}
+
+// ensureAlarmLifecyclesLoaded 首次检查设备时从 active_alarms 恢复报警中状态（重启后不重复产生）
+func ensureAlarmLifecyclesLoaded(deviceID int64) {
+	alarmLifecycles.mu.Lock()
+	_, loaded := alarmLifecycles.loaded[deviceID]
+	alarmLifecycles.mu.Unlock()
+	if loaded || database.ParamDB == nil {
+		return
+	}
+
+	active, err := database.ListActiveAlarmsByDevice(deviceID)
+	if err != nil {
+		slog.Warn("Failed to load active alarms", "device_id", deviceID, "error", err)
+		return
+	}
+
+	alarmLifecycles.mu.Lock()
+	defer alarmLifecycles.mu.Unlock()
+	if _, loaded := alarmLifecycles.loaded[deviceID]; loaded {
+		return
+	}
+	for _, alarm := range active {
+		key := alarmStateIDKey{DeviceID: alarm.DeviceID, ThresholdID: alarm.ThresholdID}
+		alarmLifecycles.data[key] = &alarmLifecycle{
+			phase:       alarmPhaseActive,
+			triggeredAt: alarm.TriggeredAt,
+			alarmLogID:  alarm.AlarmLogID,
+		}
+		markAlarmTriggeredForIDKey(key, alarm.TriggeredAt)
+	}
+	alarmLifecycles.loaded[deviceID] = struct{}{}
+}
+
+// advanceAlarmLifecycle 推进状态机并返回本次是否产生 / 恢复报警。
+// raise 为触发条件是否满足，hold 为考虑回差后报警是否仍应保持。
+func advanceAlarmLifecycle(key alarmStateIDKey, raise, hold bool, now time.Time, triggerDelay, clearDelay time.Duration) alarmTransition {
+	alarmLifecycles.mu.Lock()
+	defer alarmLifecycles.mu.Unlock()
+
+	state, exists := alarmLifecycles.data[key]
+	if !exists {
+		if !raise {
+			return alarmTransitionNone
+		}
+		state = &alarmLifecycle{}
+		alarmLifecycles.data[key] = state
+	}
+
+	switch state.phase {
+	case alarmPhaseNormal, alarmPhasePending:
+		if !raise {
+			delete(alarmLifecycles.data, key)
+			return alarmTransitionNone
+		}
+		if state.phase == alarmPhaseNormal {
+			state.phase = alarmPhasePending
+			state.since = now
+		}
+		if now.Sub(state.since) < triggerDelay {
+			return alarmTransitionNone
+		}
+		state.phase = alarmPhaseActive
+		state.triggeredAt = now
+		state.alarmLogID = 0
+		return alarmTransitionRaise
+	default: // active / clearing
+		if hold {
+			state.phase = alarmPhaseActive
+			return alarmTransitionNone
+		}
+		if state.phase == alarmPhaseActive {
+			state.phase = alarmPhaseClearing
+			state.since = now
+		}
+		if now.Sub(state.since) < clearDelay {
+			return alarmTransitionNone
+		}
+		delete(alarmLifecycles.data, key)
+		return alarmTransitionClear
+	}
+}
+
+func isAlarmLifecycleActive(key alarmStateIDKey) bool {
+	alarmLifecycles.mu.Lock()
+	defer alarmLifecycles.mu.Unlock()
+	state, exists := alarmLifecycles.data[key]
+	return exists && (state.phase == alarmPhaseActive || state.phase == alarmPhaseClearing)
+}
+
+func setAlarmLifecycleLogID(key alarmStateIDKey, alarmLogID int64) {
+	alarmLifecycles.mu.Lock()
+	defer alarmLifecycles.mu.Unlock()
+	if state, exists := alarmLifecycles.data[key]; exists {
+		state.alarmLogID = alarmLogID
+	}
+}
+
+// markAlarmTriggeredForIDKey 记录报警触发时间（新产生的报警不受重复间隔限制）
+func markAlarmTriggeredForIDKey(key alarmStateIDKey, now time.Time) {
+	alarmStates.mu.Lock()
+	defer alarmStates.mu.Unlock()
+	state := alarmStates.byID[key]
+	state.LastTriggered = now
+	alarmStates.byID[key] = state
+}
+
+func clearAlarmLifecyclesForDevice(deviceID int64) {
+	alarmLifecycles.mu.Lock()
+	defer alarmLifecycles.mu.Unlock()
+	for key := range alarmLifecycles.data {
+		if key.DeviceID == deviceID {
+			delete(alarmLifecycles.data, key)
+		}
+	}
+	delete(alarmLifecycles.loaded, deviceID)
+}
+
+// ReleaseThresholdAlarms 阈值被修改、屏蔽或删除时结束其当前报警，并向北向上报恢复事件
+func (c *Collector) ReleaseThresholdAlarms(thresholdID int64) error {
+	if thresholdID <= 0 {
+		return nil
+	}
+	active, err := database.ListActiveAlarmsByThreshold(thresholdID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, alarm := range active {
+		if alarm.AlarmLogID > 0 {
+			if err := database.MarkAlarmLogCleared(alarm.AlarmLogID, now); err != nil {
+				return err
+			}
+		}
+		if err := database.DeleteActiveAlarm(alarm.DeviceID, alarm.ThresholdID); err != nil {
+			return err
+		}
+		if c != nil && c.northboundMgr != nil {
+			c.northboundMgr.SendAlarm(newReleasedAlarmPayload(alarm, now))
+		}
+	}
+
+	alarmLifecycles.mu.Lock()
+	for key := range alarmLifecycles.data {
+		if key.ThresholdID == thresholdID {
+			delete(alarmLifecycles.data, key)
+		}
+	}
+	alarmLifecycles.mu.Unlock()
+	return nil
+}
+
+// newReleasedAlarmPayload 按当前报警记录构造恢复事件（阈值可能已删除，报警内容取自报警产生时）
+func newReleasedAlarmPayload(alarm *models.ActiveAlarm, clearedAt time.Time) *models.AlarmPayload {
+	payload := &models.AlarmPayload{
+		DeviceID:    alarm.DeviceID,
+		FieldName:   alarm.FieldName,
+		ActualValue: alarm.ActualValue,
+		Threshold:   alarm.ThresholdValue,
+		Operator:    alarm.Operator,
+		Severity:    alarm.Severity,
+		Message:     alarm.Message,
+		State:       models.AlarmStateCleared,
+		Timestamp:   clearedAt,
+	}
+	if device, err := database.LoadDevice(alarm.DeviceID); err == nil && device != nil {
+		payload.DeviceName = device.Name
+		payload.ProductKey = device.ProductKey
+		payload.DeviceKey = device.DeviceKey
+	}
+	return payload
+}
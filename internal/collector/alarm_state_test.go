@@ -148,3 +148,56 @@ func TestInvalidateAlarmRepeatIntervalCache(t *testing.T) {
 		t.Fatalf("expected expiresAtNS=0, got %d", got)
 	}
 }
+
+func TestAdvanceAlarmLifecycle_DelaysAndHysteresis(t *testing.T) {
+	key := alarmStateIDKey{DeviceID: 501, ThresholdID: 7}
+	clearAlarmLifecyclesForDevice(key.DeviceID)
+	defer clearAlarmLifecyclesForDevice(key.DeviceID)
+
+	start := time.Unix(1700000000, 0)
+	triggerDelay, clearDelay := 10*time.Second, 5*time.Second
+	steps := []struct {
+		offset      time.Duration
+		raise, hold bool
+		want        alarmTransition
+	}{
+		{0, true, true, alarmTransitionNone},                 // 进入 pending
+		{5 * time.Second, false, false, alarmTransitionNone}, // 延时内恢复，回到 normal
+		{6 * time.Second, true, true, alarmTransitionNone},   // 重新 pending
+		{16 * time.Second, true, true, alarmTransitionRaise}, // 满足触发延时
+		{17 * time.Second, false, true, alarmTransitionNone}, // 回差内保持
+		{18 * time.Second, false, false, alarmTransitionNone},
+		{20 * time.Second, true, true, alarmTransitionNone}, // 恢复延时内再次满足，继续报警
+		{21 * time.Second, false, false, alarmTransitionNone},
+		{26 * time.Second, false, false, alarmTransitionClear},
+		{27 * time.Second, false, false, alarmTransitionNone},
+	}
+	for i, step := range steps {
+		got := advanceAlarmLifecycle(key, step.raise, step.hold, start.Add(step.offset), triggerDelay, clearDelay)
+		if got != step.want {
+			t.Fatalf("step %d: transition = %v, want %v", i, got, step.want)
+		}
+	}
+	if isAlarmLifecycleActive(key) {
+		t.Fatal("lifecycle should be inactive after clear")
+	}
+}
+
+func TestThresholdHold(t *testing.T) {
+	cases := []struct {
+		value    float64
+		operator string
+		want     bool
+	}{
+		{29, ">", true},
+		{27.9, ">", false},
+		{31, "<", true},
+		{32.1, "<=", false},
+		{29, "==", false},
+	}
+	for _, tc := range cases {
+		if got := thresholdHold(tc.value, tc.operator, 30, 2); got != tc.want {
+			t.Fatalf("thresholdHold(%v %s 30, deadband 2) = %v, want %v", tc.value, tc.operator, got, tc.want)
+		}
+	}
+}
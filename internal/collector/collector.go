@@ -70,6 +70,7 @@ type collectTask struct {
 	lastError           string
 	lastErrorKind       collectErrorKind
 	consecutiveFailures int
+	deadband            *deadbandFilter
 	index               int
 }
 
@@ -124,7 +125,7 @@ func NewCollectorWithIntervals(driverExecutor *driver.DriverExecutor, northbound
 
 	h := &taskHeap{}
 	heap.Init(h)
-	return &Collector{
+	c := &Collector{
 		driverExecutor:        driverExecutor,
 		northboundMgr:         northboundMgr,
 		driverProductKeys:     make(map[int64]string),
@@ -139,6 +140,11 @@ func NewCollectorWithIntervals(driverExecutor *driver.DriverExecutor, northbound
 		tasks:                 make(map[int64]*collectTask),
 		taskHeap:              h,
 	}
+	if northboundMgr != nil {
+		northboundMgr.SetCommandExecutor(c.ExecuteCommandNow)
+		northboundMgr.SetDevicePointResolver(driver.NativeModbusDevicePoints)
+	}
+	return c
 }
 
 func (c *Collector) GetMaxConcurrentCollects() int {
@@ -194,6 +200,9 @@ func (c *Collector) Start() error {
 	// 北向下发命令轮询
 	c.startAdjustableTickerWorker(c.commandPollInterval, c.commandPollResetChan, c.processNorthboundCommands)
 
+	// stale() 表达式阈值定时检查
+	c.startAdjustableTickerWorker(staleThresholdCheckInterval, nil, c.checkStaleThresholds)
+
 	// 主循环
 	c.wg.Add(1)
 	go c.executeLoop()
@@ -351,6 +360,10 @@ func (c *Collector) SyncDeviceStatus() {
 		slog.Error("Failed to sync device status", "error", err)
 		return
 	}
+	// 先同步北向设备节点，避免新设备首次采集值因节点未建立被丢弃
+	if c.northboundMgr != nil {
+		c.northboundMgr.ApplyDeviceCatalog(devices)
+	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -488,6 +501,9 @@ func sameTaskDeviceConfig(current, next *models.Device) bool {
 		current.CollectInterval == next.CollectInterval &&
 		current.StorageInterval == next.StorageInterval &&
 		current.Timeout == next.Timeout &&
+		current.PointTable == next.PointTable &&
+		current.Deadbands == next.Deadbands &&
+		current.DeviceConfig == next.DeviceConfig &&
 		sameOptionalInt64(current.DriverID, next.DriverID) &&
 		sameOptionalInt64(current.ResourceID, next.ResourceID) &&
 		current.Enabled == next.Enabled
@@ -612,6 +628,11 @@ func newCollectTask(device *models.Device, previous *collectTask) *collectTask {
 		task.lastErrorKind = previous.lastErrorKind
 		task.consecutiveFailures = previous.consecutiveFailures
 	}
+	var previousDeadband *deadbandFilter
+	if previous != nil {
+		previousDeadband = previous.deadband
+	}
+	task.deadband = resolveDeadbandFilter(device, previousDeadband)
 	return task
 }
 
@@ -626,6 +647,7 @@ func (c *Collector) refreshTaskLocked(task *collectTask, device *models.Device)
 	task.deviceKey = trimCollectorText(device.DeviceKey)
 	task.interval = resolveCollectInterval(device.CollectInterval)
 	task.storageInterval = resolveStorageInterval(device.StorageInterval)
+	task.deadband = resolveDeadbandFilter(device, task.deadband)
 	task.nextRun = time.Now().Add(task.interval)
 
 	if task.index >= 0 {
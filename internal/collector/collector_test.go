@@ -3,6 +3,7 @@ package collector
 import (
 	"container/heap"
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -355,6 +356,9 @@ func TestClassifyCollectError(t *testing.T) {
 	if got := classifyCollectError(assertErr("driver plugin error")); got != collectErrorKindDriver {
 		t.Fatalf("driver string kind = %s, want %s", got, collectErrorKindDriver)
 	}
+	if got := classifyCollectError(fmt.Errorf("execute: %w", driver.ErrDriverQuarantined)); got != collectErrorKindQuarantined {
+		t.Fatalf("quarantined error kind = %s, want %s", got, collectErrorKindQuarantined)
+	}
 	if got := classifyCollectError(assertErr("unexpected bad state")); got != collectErrorKindUnknown {
 		t.Fatalf("unknown string kind = %s, want %s", got, collectErrorKindUnknown)
 	}
@@ -780,25 +784,35 @@ func TestBuildNorthboundCommandResult(t *testing.T) {
 	}
 }
 
-func TestValidateNorthboundCommandExecutionResult(t *testing.T) {
-	if err := validateNorthboundCommandExecutionResult(nil); err != nil {
-		t.Fatalf("nil result returned error: %v", err)
-	}
-	if err := validateNorthboundCommandExecutionResult(&driver.DriverResult{Success: true}); err != nil {
-		t.Fatalf("successful result returned error: %v", err)
+func TestApplyWriteBatchResult(t *testing.T) {
+	command := &models.NorthboundCommand{
+		RequestID:  "r1",
+		ProductKey: "pk",
+		DeviceKey:  "dk",
+		Fields:     []models.NorthboundCommandField{{FieldName: "a", Value: "1"}, {FieldName: "b", Value: "2"}},
+	}
+	batch := &driver.WriteBatchResult{
+		Partial: true,
+		Error:   "1/2 fields failed: b: busy",
+		Writes: []driver.WriteItemResult{
+			{FieldName: "a", Value: "1", Success: true},
+			{FieldName: "b", Value: "2", Error: "busy"},
+		},
 	}
 
-	err := validateNorthboundCommandExecutionResult(&driver.DriverResult{
-		Success: false,
-		Error:   " write failed ",
-	})
-	if err == nil || err.Error() != "write failed" {
-		t.Fatalf("error = %v, want trimmed write failed", err)
+	result := buildNorthboundCommandResult(command, assertErr(batch.Error))
+	applyWriteBatchResult(result, batch)
+	if result.Success || !result.Partial || result.Code != 207 || len(result.Fields) != 2 {
+		t.Fatalf("partial result mismatch: %+v", result)
+	}
+	if !result.Fields[0].Success || result.Fields[1].Success || result.Fields[1].Message != "busy" {
+		t.Fatalf("field results mismatch: %+v", result.Fields)
 	}
 
-	err = validateNorthboundCommandExecutionResult(&driver.DriverResult{Success: false})
-	if err == nil || err.Error() != "driver write returned success=false" {
-		t.Fatalf("error = %v, want default failure message", err)
+	single := buildNorthboundCommandResult(command, nil)
+	applyWriteBatchResult(single, &driver.WriteBatchResult{Success: true, Writes: batch.Writes[:1]})
+	if single.Fields != nil || single.Partial {
+		t.Fatalf("single-field result should keep legacy shape: %+v", single)
 	}
 }
 
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -13,6 +14,7 @@ import (
 const commandDriverFunction = "handle"
 const (
 	commandResultCodeSuccess = 200
+	commandResultCodePartial = 207
 	commandResultCodeFailure = 500
 )
 
@@ -34,26 +36,52 @@ func (c *Collector) processNorthboundCommands() {
 		if command == nil {
 			continue
 		}
-		err := c.executeNorthboundCommand(command)
+		batch, err := c.executeNorthboundCommand(context.Background(), command)
 		if err != nil {
 			slog.Error("execute northbound command failed",
 				"source", command.Source, "request_id", command.RequestID,
 				"product_key", command.ProductKey, "device_key", command.DeviceKey,
-				"field", command.FieldName, "error", err)
+				"field", command.FieldName, "fields", len(command.WriteFields()), "error", err)
 		}
-		c.reportCommandResult(command, err)
+		c.reportCommandResult(command, batch, err)
 	}
 }
 
-func (c *Collector) reportCommandResult(command *models.NorthboundCommand, execErr error) {
+func (c *Collector) reportCommandResult(command *models.NorthboundCommand, batch *driver.WriteBatchResult, execErr error) {
 	if c.northboundMgr == nil || command == nil {
 		return
 	}
 
 	result := buildNorthboundCommandResult(command, execErr)
+	applyWriteBatchResult(result, batch)
 	c.northboundMgr.ReportCommandResult(result)
 }
 
+// ExecuteCommandNow 供服务端型北向（OPC UA / Modbus 从站）同步执行客户端写入：
+// 直接调用驱动执行器，不经过命令轮询，多字段命令返回逐字段结果
+func (c *Collector) ExecuteCommandNow(ctx context.Context, command *models.NorthboundCommand) *models.NorthboundCommandResult {
+	if command == nil {
+		return nil
+	}
+	var (
+		batch *driver.WriteBatchResult
+		err   error
+	)
+	if c.IsRunning() {
+		batch, err = c.executeNorthboundCommand(ctx, command)
+	} else {
+		err = fmt.Errorf("collector is not running")
+	}
+	if err != nil {
+		slog.Warn("northbound write failed",
+			"source", command.Source, "product_key", command.ProductKey, "device_key", command.DeviceKey,
+			"field", command.FieldName, "fields", len(command.WriteFields()), "error", err)
+	}
+	result := buildNorthboundCommandResult(command, err)
+	applyWriteBatchResult(result, batch)
+	return result
+}
+
 func buildNorthboundCommandResult(command *models.NorthboundCommand, execErr error) *models.NorthboundCommandResult {
 	if command == nil {
 		return nil
@@ -77,35 +105,63 @@ func buildNorthboundCommandResult(command *models.NorthboundCommand, execErr err
 	return result
 }
 
-func (c *Collector) executeNorthboundCommand(command *models.NorthboundCommand) error {
+// applyWriteBatchResult 多字段命令按字段回报结果，仅部分成功时标记 Partial
+func applyWriteBatchResult(result *models.NorthboundCommandResult, batch *driver.WriteBatchResult) {
+	if result == nil || batch == nil || len(batch.Writes) < 2 {
+		return
+	}
+
+	result.Fields = make([]models.NorthboundCommandFieldResult, 0, len(batch.Writes))
+	for _, item := range batch.Writes {
+		result.Fields = append(result.Fields, models.NorthboundCommandFieldResult{
+			FieldName: item.FieldName,
+			Value:     item.Value,
+			Success:   item.Success,
+			Message:   item.Error,
+		})
+	}
+	result.Partial = batch.Partial
+	if batch.Partial {
+		result.Code = commandResultCodePartial
+	}
+}
+
+func (c *Collector) executeNorthboundCommand(ctx context.Context, command *models.NorthboundCommand) (*driver.WriteBatchResult, error) {
 	if c.driverExecutor == nil {
-		return fmt.Errorf("driver executor is nil")
+		return nil, fmt.Errorf("driver executor is nil")
 	}
 
 	normalizedCommand, err := normalizeNorthboundCommand(command)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	device, err := loadNorthboundCommandDevice(normalizedCommand)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	config := buildNorthboundCommandConfig(normalizedCommand, device)
+	fields := normalizedCommand.WriteFields()
+	items := make([]driver.WriteItem, 0, len(fields))
+	for _, field := range fields {
+		items = append(items, driver.WriteItem{FieldName: field.FieldName, Value: field.Value})
+	}
 
-	result, err := c.driverExecutor.ExecuteCommand(device, commandDriverFunction, config)
+	batch, err := driver.ExecuteWriteBatch(func(config map[string]string) (*driver.DriverResult, error) {
+		return c.driverExecutor.ExecuteCommandWithContext(ctx, device, commandDriverFunction, config)
+	}, config, items, c.driverExecutor.SupportsBatchWrite(device))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := validateNorthboundCommandExecutionResult(result); err != nil {
-		return err
+	if !batch.Success {
+		return batch, fmt.Errorf("%s", batch.Error)
 	}
 
 	slog.Info("northbound command executed",
 		"source", normalizedCommand.Source, "request_id", normalizedCommand.RequestID,
-		"device_id", device.ID, "field", normalizedCommand.FieldName, "value", normalizedCommand.Value)
-	return nil
+		"device_id", device.ID, "field", normalizedCommand.FieldName, "value", normalizedCommand.Value, "fields", len(items))
+	return batch, nil
 }
 
 func loadNorthboundCommandDevice(command *models.NorthboundCommand) (*models.Device, error) {
@@ -113,22 +169,12 @@ func loadNorthboundCommandDevice(command *models.NorthboundCommand) (*models.Dev
 	if err != nil || device == nil {
 		return nil, fmt.Errorf("device not found by identity")
 	}
-	if device.DriverID == nil {
+	if device.DriverID == nil && !driver.IsNativeModbusDevice(device) {
 		return nil, fmt.Errorf("device has no driver")
 	}
 	return device, nil
 }
 
-func validateNorthboundCommandExecutionResult(result *driver.DriverResult) error {
-	if result == nil || result.Success {
-		return nil
-	}
-	if errText := strings.TrimSpace(result.Error); errText != "" {
-		return fmt.Errorf("%s", errText)
-	}
-	return fmt.Errorf("driver write returned success=false")
-}
-
 func normalizeNorthboundCommand(command *models.NorthboundCommand) (*models.NorthboundCommand, error) {
 	if command == nil {
 		return nil, fmt.Errorf("northbound command is nil")
@@ -142,6 +188,20 @@ func normalizeNorthboundCommand(command *models.NorthboundCommand) (*models.Nort
 		Value:      strings.TrimSpace(command.Value),
 		Source:     strings.TrimSpace(command.Source),
 	}
+	for _, field := range command.Fields {
+		name := strings.TrimSpace(field.FieldName)
+		if name == "" {
+			continue
+		}
+		normalizedCommand.Fields = append(normalizedCommand.Fields, models.NorthboundCommandField{
+			FieldName: name,
+			Value:     strings.TrimSpace(field.Value),
+		})
+	}
+	if len(normalizedCommand.Fields) > 0 {
+		normalizedCommand.FieldName = normalizedCommand.Fields[0].FieldName
+		normalizedCommand.Value = normalizedCommand.Fields[0].Value
+	}
 
 	if normalizedCommand.ProductKey == "" || normalizedCommand.DeviceKey == "" {
 		return nil, fmt.Errorf("missing product_key/device_key")
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// deadbandFilter 按字段死区 / 最长静默规则做变化上报（report-by-exception）。
+// 配置了规则的字段只在超出死区或静默超时时写入历史；其余字段保持原行为。
+type deadbandFilter struct {
+	mu         sync.Mutex
+	raw        string
+	tracker    *models.DeadbandTracker
+	reported   uint64
+	suppressed uint64
+}
+
+type deadbandEntry struct {
+	point models.CollectPoint
+	rule  *models.DeadbandRule
+}
+
+func newDeadbandFilter(raw string) *deadbandFilter {
+	raw = strings.TrimSpace(raw)
+	rules, err := models.ParseDeadbandRules(raw)
+	if err != nil {
+		slog.Warn("Invalid device deadbands, report-by-exception disabled", "error", err)
+		return nil
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &deadbandFilter{raw: raw, tracker: models.NewDeadbandTracker(rules)}
+}
+
+// resolveDeadbandFilter 设备死区配置未变化时沿用原过滤器（保留上次上报值与计数）
+func resolveDeadbandFilter(device *models.Device, previous *deadbandFilter) *deadbandFilter {
+	raw := ""
+	if device != nil {
+		raw = strings.TrimSpace(device.Deadbands)
+	}
+	if previous != nil && previous.raw == raw {
+		return previous
+	}
+	return newDeadbandFilter(raw)
+}
+
+// apply 筛选本轮采集数据中需写入历史的字段。
+// 未配置规则的字段由 intervalDue（storage_interval）决定。
+// 返回 data 本身表示全部字段，nil 表示没有字段。
+func (f *deadbandFilter) apply(data *models.CollectData, intervalDue bool) *models.CollectData {
+	if data == nil {
+		return nil
+	}
+	if f == nil {
+		if intervalDue {
+			return data
+		}
+		return nil
+	}
+
+	collectedAt := data.Timestamp
+	if collectedAt.IsZero() {
+		collectedAt = time.Now()
+	}
+	entries := f.collectEntries(data)
+
+	historyPoints := make([]models.CollectPoint, 0, len(entries))
+
+	f.mu.Lock()
+	for _, entry := range entries {
+		if entry.rule == nil {
+			if intervalDue {
+				historyPoints = append(historyPoints, entry.point)
+			}
+			continue
+		}
+		if !f.tracker.Report(entry.point.FieldName, entry.point.Value, entry.rule, collectedAt) {
+			f.suppressed++
+			continue
+		}
+		f.reported++
+		historyPoints = append(historyPoints, entry.point)
+	}
+	f.mu.Unlock()
+
+	return deadbandSubset(data, historyPoints, len(entries))
+}
+
+// collectEntries 按落库规则展开字段：points 优先，fields 中未被 points 覆盖的字段补在其后
+func (f *deadbandFilter) collectEntries(data *models.CollectData) []deadbandEntry {
+	entries := make([]deadbandEntry, 0, len(data.Points)+len(data.Fields))
+	seen := make(map[string]struct{}, len(data.Points))
+	for _, point := range data.Points {
+		name := strings.TrimSpace(point.FieldName)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		point.FieldName = name
+		entries = append(entries, deadbandEntry{point: point, rule: f.tracker.Rule(name)})
+	}
+	for name, value := range data.Fields {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		entries = append(entries, deadbandEntry{
+			point: models.CollectPoint{FieldName: name, Value: value},
+			rule:  f.tracker.Rule(name),
+		})
+	}
+	return entries
+}
+
+func deadbandSubset(data *models.CollectData, points []models.CollectPoint, total int) *models.CollectData {
+	if len(points) == 0 {
+		return nil
+	}
+	if len(points) == total {
+		return data
+	}
+	return &models.CollectData{
+		DeviceID:   data.DeviceID,
+		DeviceName: data.DeviceName,
+		ProductKey: data.ProductKey,
+		DeviceKey:  data.DeviceKey,
+		Timestamp:  data.Timestamp,
+		Points:     points,
+	}
+}
+
+// counters 返回已上报与被抑制的采样数
+func (f *deadbandFilter) counters() (reported, suppressed uint64) {
+	if f == nil {
+		return 0, 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reported, f.suppressed
+}
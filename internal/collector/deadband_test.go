@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/adapters"
+)
+
+func deadbandTestData(at time.Time, temp any, status string) *models.CollectData {
+	return &models.CollectData{
+		DeviceID:  1,
+		Timestamp: at,
+		Points:    []models.CollectPoint{{FieldName: "temp", Value: temp}},
+		Fields:    map[string]string{"status": status},
+	}
+}
+
+func deadbandFieldValue(data *models.CollectData, field string) (any, bool) {
+	if data == nil {
+		return nil, false
+	}
+	for _, point := range data.Points {
+		if point.FieldName == field {
+			return point.Value, true
+		}
+	}
+	value, ok := data.Fields[field]
+	return value, ok
+}
+
+func deadbandFieldCount(data *models.CollectData) int {
+	if data == nil {
+		return 0
+	}
+	count := 0
+	for _, field := range []string{"temp", "status"} {
+		if _, ok := deadbandFieldValue(data, field); ok {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDeadbandFilter_AbsoluteDeadbandAndMaxSilence(t *testing.T) {
+	f := newDeadbandFilter(`[{"field_name":"temp","deadband":0.5,"max_silence":60}]`)
+	if f == nil {
+		t.Fatalf("expected filter")
+	}
+	now := time.Now()
+
+	// 首个值必定上报；未配置规则的 status 历史按 intervalDue
+	history := f.apply(deadbandTestData(now, 20.0, "ok"), false)
+	if _, ok := deadbandFieldValue(history, "temp"); !ok {
+		t.Fatalf("first sample should be stored, history=%+v", history)
+	}
+	if _, ok := deadbandFieldValue(history, "status"); ok {
+		t.Fatalf("status should follow storage interval, history=%+v", history)
+	}
+
+	// 变化未超死区：temp 被抑制
+	if history = f.apply(deadbandTestData(now.Add(10*time.Second), 20.4, "ok"), false); history != nil {
+		t.Fatalf("history=%+v, want nil", history)
+	}
+
+	// 与上次上报值比较，累计漂移超出死区后上报
+	history = f.apply(deadbandTestData(now.Add(20*time.Second), 20.6, "ok"), true)
+	if deadbandFieldCount(history) != 2 {
+		t.Fatalf("history=%+v, want temp and status", history)
+	}
+
+	// 静默超时即使未变化也上报
+	history = f.apply(deadbandTestData(now.Add(80*time.Second), 20.6, "ok"), false)
+	if v, ok := deadbandFieldValue(history, "temp"); !ok || v != 20.6 {
+		t.Fatalf("max silence should force report, history=%+v", history)
+	}
+
+	reported, suppressed := f.counters()
+	if reported != 3 || suppressed != 1 {
+		t.Fatalf("counters reported=%d suppressed=%d, want 3/1", reported, suppressed)
+	}
+}
+
+func TestDeadbandFilter_PercentWildcardAndText(t *testing.T) {
+	f := newDeadbandFilter(`[{"field_name":"*","deadband_percent":10}]`)
+	now := time.Now()
+
+	data := deadbandTestData(now, 100, "ok")
+	if history := f.apply(data, false); history != data {
+		t.Fatalf("first sample should return full data")
+	}
+	if history := f.apply(deadbandTestData(now.Add(time.Second), 109, "ok"), true); history != nil {
+		t.Fatalf("9%% change should be suppressed, history=%+v", history)
+	}
+	history := f.apply(deadbandTestData(now.Add(2*time.Second), 111, "alarm"), false)
+	if deadbandFieldCount(history) != 2 {
+		t.Fatalf("history=%+v, want temp and status changes", history)
+	}
+}
+
+func TestDeadbandFilter_NilKeepsStorageInterval(t *testing.T) {
+	var f *deadbandFilter
+	data := deadbandTestData(time.Now(), 1, "ok")
+	if history := f.apply(data, false); history != nil {
+		t.Fatalf("nil filter history=%v, want nil before interval", history)
+	}
+	if history := f.apply(data, true); history != data {
+		t.Fatalf("nil filter should store full data when interval due")
+	}
+	if newDeadbandFilter(`[{"field_name":""}]`) != nil || newDeadbandFilter("") != nil {
+		t.Fatalf("invalid or empty deadbands should disable filter")
+	}
+}
+
+func TestResolveDeadbandFilter_KeepsStateWhenUnchanged(t *testing.T) {
+	device := &models.Device{ID: 1, Deadbands: `[{"field_name":"temp","deadband":1}]`}
+	task := newCollectTask(device, nil)
+	task.deadband.apply(deadbandTestData(time.Now(), 1, "ok"), false)
+
+	next := newCollectTask(&models.Device{ID: 1, Deadbands: device.Deadbands}, task)
+	if next.deadband != task.deadband {
+		t.Fatalf("unchanged deadbands should keep filter state")
+	}
+	changed := newCollectTask(&models.Device{ID: 1, Deadbands: `[{"field_name":"temp","deadband":2}]`}, task)
+	if changed.deadband == task.deadband {
+		t.Fatalf("changed deadbands should rebuild filter")
+	}
+	if status := buildDeviceRuntimeStatus(task); status.DeadbandReported != 1 {
+		t.Fatalf("runtime status reported=%d, want 1", status.DeadbandReported)
+	}
+}
+
+// liveDataRecorder 记录实时值北向收到的采集数据，其余方法不应被调用
+type liveDataRecorder struct {
+	adapters.NorthboundAdapter
+	mu   sync.Mutex
+	data []*models.CollectData
+}
+
+func (r *liveDataRecorder) Name() string { return "live-recorder" }
+
+func (r *liveDataRecorder) ApplyLiveData(data *models.CollectData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = append(r.data, data)
+}
+
+func (r *liveDataRecorder) last() *models.CollectData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.data) == 0 {
+		return nil
+	}
+	return r.data[len(r.data)-1]
+}
+
+func TestPersistCollectData_DeadbandFiltersHistoryOnly(t *testing.T) {
+	prepareSystemCollectorTestDB(t)
+
+	recorder := &liveDataRecorder{}
+	mgr := northbound.NewNorthboundManager()
+	mgr.RegisterAdapter(recorder.Name(), recorder)
+	c := NewCollector(nil, mgr)
+	device := &models.Device{ID: 1, Name: "d1", StorageInterval: 3600, Deadbands: `[{"field_name":"temp","deadband":1}]`}
+	task := newCollectTask(device, nil)
+
+	now := time.Now()
+	c.persistCollectData(task, deadbandTestData(now, 20.0, "ok"))
+	// 死区内的变化不写历史，但实时缓存与实时值北向仍拿到全部字段的最新值
+	latest := deadbandTestData(now.Add(time.Second), 20.5, "ok")
+	c.persistCollectData(task, latest)
+
+	if got := recorder.last(); got != latest {
+		t.Fatalf("live data = %+v, want full collect", got)
+	}
+	var historyRows int
+	if err := database.DataDB.QueryRow(`SELECT COUNT(*) FROM data_points WHERE device_id = 1 AND field_name = 'temp'`).Scan(&historyRows); err != nil {
+		t.Fatalf("count history: %v", err)
+	}
+	if historyRows != 1 {
+		t.Fatalf("temp history rows = %d, want 1", historyRows)
+	}
+	var cached float64
+	if err := database.DataDB.QueryRow(`SELECT value_num FROM data_cache WHERE device_id = 1 AND field_name = 'temp'`).Scan(&cached); err != nil {
+		t.Fatalf("query cache: %v", err)
+	}
+	if cached != 20.5 {
+		t.Fatalf("cached temp = %v, want 20.5", cached)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"log/slog"
 	"strings"
+
+	"github.com/gonglijing/xunjiFsu/internal/driver"
 )
 
 type collectErrorKind string
@@ -16,7 +18,9 @@ const (
 	collectErrorKindResource collectErrorKind = "resource"
 	collectErrorKindDriver   collectErrorKind = "driver"
 	collectErrorKindCanceled collectErrorKind = "canceled"
-	collectErrorKindUnknown  collectErrorKind = "unknown"
+	// 驱动因反复崩溃被隔离，设备暂停执行直到驱动重载
+	collectErrorKindQuarantined collectErrorKind = "quarantined"
+	collectErrorKindUnknown     collectErrorKind = "unknown"
 )
 
 func classifyCollectError(err error) collectErrorKind {
@@ -26,6 +30,9 @@ func classifyCollectError(err error) collectErrorKind {
 	if errors.Is(err, context.Canceled) {
 		return collectErrorKindCanceled
 	}
+	if errors.Is(err, driver.ErrDriverQuarantined) {
+		return collectErrorKindQuarantined
+	}
 	if errors.Is(err, context.DeadlineExceeded) {
 		return collectErrorKindTimeout
 	}
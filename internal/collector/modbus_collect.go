@@ -39,12 +39,18 @@ func (c *Collector) persistCollectData(task *collectTask, collect *models.Collec
 		return
 	}
 
-	storeHistory := shouldStoreHistory(task, collect.Timestamp)
-	// 实时缓存始终写入，历史按设备 storage_interval 控制。
-	if err := database.EnqueueCollectDataWrite(collect, storeHistory); err != nil {
+	intervalDue := shouldStoreHistory(task, collect.Timestamp)
+	// 死区在此筛选历史写入，北向周期上报在各适配器上送前按同一规则另行筛选；
+	// 实时缓存 data_cache 与实时值北向（OPC UA、Modbus 从站）始终使用全部字段与采集时间。
+	// 未配置死区的字段历史另按设备 storage_interval 筛选。
+	history := task.deadband.apply(collect, intervalDue)
+	if err := database.EnqueueCollectDataWriteWithHistory(collect, history); err != nil {
 		slog.Error("Failed to insert data points", "error", err)
 	}
-	c.markTaskCollected(task, collect.Timestamp, storeHistory)
+	c.markTaskCollected(task, collect.Timestamp, intervalDue)
+	if c.northboundMgr != nil {
+		c.northboundMgr.ApplyLiveData(collect)
+	}
 }
 
 // handleThresholdForDevice 仅检查阈值（用于采集时触发报警）
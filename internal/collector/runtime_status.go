@@ -16,6 +16,8 @@ type DeviceRuntimeStatus struct {
 	LastError           string    `json:"last_error,omitempty"`
 	LastErrorKind       string    `json:"last_error_kind,omitempty"`
 	LastErrorAt         time.Time `json:"-"`
+	DeadbandReported    uint64    `json:"deadband_reported"`
+	DeadbandSuppressed  uint64    `json:"deadband_suppressed"`
 }
 
 // ListDeviceRuntimeStatus 返回设备采集状态快照（按设备 ID 索引）。
@@ -71,6 +73,7 @@ func buildDeviceRuntimeStatus(task *collectTask) DeviceRuntimeStatus {
 	if task.lastErrorKind != collectErrorKindNone {
 		status.LastErrorKind = string(task.lastErrorKind)
 	}
+	status.DeadbandReported, status.DeadbandSuppressed = task.deadband.counters()
 	return status
 }
 
@@ -87,6 +90,8 @@ func (s DeviceRuntimeStatus) MarshalJSON() ([]byte, error) {
 		LastError           string     `json:"last_error,omitempty"`
 		LastErrorKind       string     `json:"last_error_kind,omitempty"`
 		LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+		DeadbandReported    uint64     `json:"deadband_reported,omitempty"`
+		DeadbandSuppressed  uint64     `json:"deadband_suppressed,omitempty"`
 	}
 
 	payload := runtimeStatusJSON{
@@ -97,6 +102,8 @@ func (s DeviceRuntimeStatus) MarshalJSON() ([]byte, error) {
 		ConsecutiveFailures: s.ConsecutiveFailures,
 		LastError:           s.LastError,
 		LastErrorKind:       s.LastErrorKind,
+		DeadbandReported:    s.DeadbandReported,
+		DeadbandSuppressed:  s.DeadbandSuppressed,
 	}
 	if !s.NextRunAt.IsZero() {
 		payload.NextRunAt = &s.NextRunAt
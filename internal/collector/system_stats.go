@@ -139,6 +139,7 @@ func (c *SystemStatsCollector) run() {
 func (c *SystemStatsCollector) collect() {
 	stats := c.collectSystemStats()
 	c.setLastStats(stats)
+	database.UpdateDiskUsage(stats.DiskUsage)
 	data := c.statsToCollectData(stats)
 
 	// 发送到北向
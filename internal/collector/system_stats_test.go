@@ -40,6 +40,7 @@ func prepareSystemCollectorTestDB(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -53,6 +54,7 @@ func prepareSystemCollectorTestDB(t *testing.T) {
 		device_id INTEGER NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -2,10 +2,14 @@ package collector
 
 import (
 	"database/sql"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gonglijing/xunjiFsu/internal/database"
 	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/adapters"
 )
 
 func setupCollectorAlarmBehaviorTestDB(t *testing.T) *sql.DB {
@@ -34,6 +38,9 @@ func setupCollectorAlarmBehaviorTestDB(t *testing.T) *sql.DB {
 		collect_interval INTEGER,
 		storage_interval INTEGER,
 		timeout INTEGER,
+		point_table TEXT,
+		deadbands TEXT,
+		device_config TEXT,
 		driver_id INTEGER,
 		enabled INTEGER,
 		resource_id INTEGER,
@@ -51,9 +58,12 @@ func setupCollectorAlarmBehaviorTestDB(t *testing.T) *sql.DB {
 		operator TEXT NOT NULL,
 		value REAL NOT NULL,
 		severity TEXT DEFAULT 'warning',
-		enabled INTEGER DEFAULT 1,
 		shielded INTEGER DEFAULT 0,
 		message TEXT,
+		expression TEXT,
+		deadband REAL DEFAULT 0,
+		trigger_delay_seconds INTEGER DEFAULT 0,
+		clear_delay_seconds INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
@@ -74,12 +84,30 @@ func setupCollectorAlarmBehaviorTestDB(t *testing.T) *sql.DB {
 		triggered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		acknowledged INTEGER DEFAULT 0,
 		acknowledged_by TEXT,
-		acknowledged_at TIMESTAMP
+		acknowledged_at TIMESTAMP,
+		cleared_at TIMESTAMP
 	)`)
 	if err != nil {
 		t.Fatalf("create alarm_logs table failed: %v", err)
 	}
 
+	_, err = db.Exec(`CREATE TABLE active_alarms (
+		device_id INTEGER NOT NULL,
+		threshold_id INTEGER NOT NULL,
+		alarm_log_id INTEGER,
+		field_name TEXT,
+		actual_value REAL,
+		threshold_value REAL,
+		operator TEXT,
+		severity TEXT,
+		message TEXT,
+		triggered_at DATETIME NOT NULL,
+		PRIMARY KEY (device_id, threshold_id)
+	)`)
+	if err != nil {
+		t.Fatalf("create active_alarms table failed: %v", err)
+	}
+
 	_, err = db.Exec(`CREATE TABLE gateway_config (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		product_key TEXT NOT NULL,
@@ -182,3 +210,227 @@ func TestCheckThresholds_ShieldAndRepeatInterval(t *testing.T) {
 		t.Fatalf("expected 1 alarm with repeat suppression, got %d", got)
 	}
 }
+
+func TestCheckThresholds_AlarmLifecycleRaiseAndClear(t *testing.T) {
+	oldDB := database.ParamDB
+	db := setupCollectorAlarmBehaviorTestDB(t)
+	database.ParamDB = db
+	t.Cleanup(func() {
+		database.ParamDB = oldDB
+		_ = db.Close()
+	})
+
+	resetThresholdCache()
+	clearAlarmStateForDevice(1)
+	InvalidateAlarmRepeatIntervalCache()
+	t.Cleanup(func() { clearAlarmStateForDevice(1) })
+
+	thresholdID, err := database.CreateThreshold(&models.Threshold{
+		DeviceID:   1,
+		FieldName:  "temp",
+		Operator:   ">",
+		Value:      30,
+		Severity:   "warning",
+		Deadband:   2,
+		ClearDelay: 10,
+	})
+	if err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+
+	collector := NewCollector(nil, nil)
+	device := &models.Device{ID: 1, Name: "d1"}
+	start := time.Now()
+	check := func(value string, offset time.Duration) {
+		t.Helper()
+		data := &models.CollectData{DeviceID: 1, Timestamp: start.Add(offset), Fields: map[string]string{"temp": value}}
+		if err := collector.checkThresholds(device, data); err != nil {
+			t.Fatalf("checkThresholds(%s): %v", value, err)
+		}
+	}
+
+	check("35", 0)
+	active, err := database.ListActiveAlarms()
+	if err != nil || len(active) != 1 || active[0].ThresholdID != thresholdID || active[0].AlarmLogID <= 0 {
+		t.Fatalf("active alarms = %+v, err = %v", active, err)
+	}
+
+	// 回差内抖动不恢复也不重复产生
+	check("29", time.Second)
+	check("31", 2*time.Second)
+	if got := countAlarmLogsFromDB(t, db); got != 1 {
+		t.Fatalf("expected 1 alarm log while oscillating, got %d", got)
+	}
+
+	// 模拟重启：内存状态丢失后从 active_alarms 恢复，不重复产生
+	clearAlarmStateForDevice(1)
+	check("33", 3*time.Second)
+	if got := countAlarmLogsFromDB(t, db); got != 1 {
+		t.Fatalf("expected restored alarm not to re-raise, got %d logs", got)
+	}
+
+	check("20", 4*time.Second)
+	check("20", 15*time.Second)
+	active, err = database.ListActiveAlarms()
+	if err != nil || len(active) != 0 {
+		t.Fatalf("active alarms after clear = %+v, err = %v", active, err)
+	}
+	var clearedCount int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM alarm_logs WHERE cleared_at IS NOT NULL`).Scan(&clearedCount); err != nil {
+		t.Fatalf("query cleared logs: %v", err)
+	}
+	if clearedCount != 1 {
+		t.Fatalf("cleared alarm logs = %d, want 1", clearedCount)
+	}
+
+	check("35", 16*time.Second)
+	if got := countAlarmLogsFromDB(t, db); got != 2 {
+		t.Fatalf("expected new alarm after clear, got %d logs", got)
+	}
+}
+
+func TestCheckThresholds_AlarmLifecycleRepeatKeepsSingleLog(t *testing.T) {
+	oldDB := database.ParamDB
+	db := setupCollectorAlarmBehaviorTestDB(t)
+	database.ParamDB = db
+	t.Cleanup(func() {
+		database.ParamDB = oldDB
+		_ = db.Close()
+	})
+
+	resetThresholdCache()
+	clearAlarmStateForDevice(1)
+	InvalidateAlarmRepeatIntervalCache()
+	t.Cleanup(func() { clearAlarmStateForDevice(1) })
+
+	thresholdID, err := database.CreateThreshold(&models.Threshold{
+		DeviceID:   1,
+		FieldName:  "temp",
+		Operator:   ">",
+		Value:      30,
+		Severity:   "warning",
+		ClearDelay: 1,
+	})
+	if err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+
+	collector := NewCollector(nil, nil)
+	device := &models.Device{ID: 1, Name: "d1"}
+	start := time.Now()
+	check := func(value string, offset time.Duration) {
+		t.Helper()
+		data := &models.CollectData{DeviceID: 1, Timestamp: start.Add(offset), Fields: map[string]string{"temp": value}}
+		if err := collector.checkThresholds(device, data); err != nil {
+			t.Fatalf("checkThresholds(%s): %v", value, err)
+		}
+	}
+
+	check("35", 0)
+	// 模拟重复间隔已过：保持期间的重复上报不新增报警日志
+	key := alarmStateIDKey{DeviceID: 1, ThresholdID: thresholdID}
+	for i := 1; i <= 2; i++ {
+		markAlarmTriggeredForIDKey(key, time.Now().Add(-24*time.Hour))
+		check("36", time.Duration(i)*time.Second)
+		if shouldEmitAlarmForIDKey(key, time.Now(), time.Hour) {
+			t.Fatalf("repeat #%d was not emitted", i)
+		}
+	}
+	if got := countAlarmLogsFromDB(t, db); got != 1 {
+		t.Fatalf("expected repeats to keep 1 alarm log, got %d", got)
+	}
+
+	check("20", 3*time.Second)
+	check("20", 5*time.Second)
+	var open int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM alarm_logs WHERE cleared_at IS NULL`).Scan(&open); err != nil {
+		t.Fatalf("query open logs: %v", err)
+	}
+	if open != 0 {
+		t.Fatalf("alarm logs left uncleared after recovery = %d, want 0", open)
+	}
+}
+
+// alarmRecorder 记录北向报警的适配器，其余方法不应被调用
+type alarmRecorder struct {
+	adapters.NorthboundAdapter
+	mu     sync.Mutex
+	alarms []*models.AlarmPayload
+}
+
+func (r *alarmRecorder) Name() string { return "recorder" }
+
+func (r *alarmRecorder) SendAlarm(alarm *models.AlarmPayload) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alarms = append(r.alarms, alarm)
+	return nil
+}
+
+func (r *alarmRecorder) sent() []*models.AlarmPayload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*models.AlarmPayload(nil), r.alarms...)
+}
+
+func TestReleaseThresholdAlarms_SendsClearEvent(t *testing.T) {
+	oldDB := database.ParamDB
+	db := setupCollectorAlarmBehaviorTestDB(t)
+	database.ParamDB = db
+	t.Cleanup(func() {
+		database.ParamDB = oldDB
+		_ = db.Close()
+	})
+
+	resetThresholdCache()
+	clearAlarmStateForDevice(1)
+	InvalidateAlarmRepeatIntervalCache()
+	t.Cleanup(func() { clearAlarmStateForDevice(1) })
+
+	thresholdID, err := database.CreateThreshold(&models.Threshold{
+		DeviceID:  1,
+		FieldName: "temp",
+		Operator:  ">",
+		Value:     30,
+		Severity:  "critical",
+		Message:   "over temp",
+	})
+	if err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+
+	recorder := &alarmRecorder{}
+	mgr := northbound.NewNorthboundManager()
+	mgr.RegisterAdapter(recorder.Name(), recorder)
+	collector := NewCollector(nil, mgr)
+	device := &models.Device{ID: 1, Name: "d1"}
+	data := &models.CollectData{DeviceID: 1, Timestamp: time.Now(), Fields: map[string]string{"temp": "35"}}
+	if err := collector.checkThresholds(device, data); err != nil {
+		t.Fatalf("checkThresholds: %v", err)
+	}
+	if sent := recorder.sent(); len(sent) != 1 || sent[0].State != models.AlarmStateActive {
+		t.Fatalf("raised alarms = %+v", sent)
+	}
+
+	// 阈值删除后报警必须随恢复事件结束，否则上游一直显示报警中
+	if err := database.DeleteThreshold(thresholdID); err != nil {
+		t.Fatalf("DeleteThreshold: %v", err)
+	}
+	if err := collector.ReleaseThresholdAlarms(thresholdID); err != nil {
+		t.Fatalf("ReleaseThresholdAlarms: %v", err)
+	}
+
+	sent := recorder.sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent alarms = %d, want raise + clear", len(sent))
+	}
+	cleared := sent[1]
+	if cleared.State != models.AlarmStateCleared || cleared.DeviceID != 1 || cleared.DeviceName != "d1" ||
+		cleared.FieldName != "temp" || cleared.Threshold != 30 || cleared.Severity != "critical" ||
+		cleared.Message != "over temp" || cleared.Timestamp.IsZero() {
+		t.Fatalf("clear event = %+v", cleared)
+	}
+	if active, err := database.ListActiveAlarms(); err != nil || len(active) != 0 {
+		t.Fatalf("active alarms after release = %+v, err = %v", active, err)
+	}
+}
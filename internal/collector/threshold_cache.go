@@ -31,6 +31,10 @@ type thresholdEvalRule struct {
 	hasAlarmIDKey       bool
 	shielded            bool
 	thresholdValue      float64
+	expr                *thresholdExpr // operator 为 expr 时的编译结果
+	deadband            float64
+	triggerDelay        time.Duration
+	clearDelay          time.Duration
 }
 
 // 缓存实例
@@ -117,6 +121,8 @@ func (c *thresholdCache) Refresh() {
 	}
 	next := make(map[int64][]*models.Threshold, len(validDeviceIDs))
 	nextRules := make(map[int64][]thresholdEvalRule, len(validDeviceIDs))
+	nextWatches := make(map[int64][]exprFieldRef)
+	liveThresholds := make(map[int64]struct{}, len(thresholds))
 
 	for _, threshold := range thresholds {
 		if threshold == nil || threshold.DeviceID == 0 {
@@ -126,9 +132,14 @@ func (c *thresholdCache) Refresh() {
 			continue
 		}
 		normalizeThresholdForRuntime(threshold)
+		liveThresholds[threshold.ID] = struct{}{}
+		rule := buildThresholdEvalRule(threshold)
+		collectExprWatches(nextWatches, threshold.DeviceID, rule.expr)
 		next[threshold.DeviceID] = append(next[threshold.DeviceID], threshold)
-		nextRules[threshold.DeviceID] = append(nextRules[threshold.DeviceID], buildThresholdEvalRule(threshold))
+		nextRules[threshold.DeviceID] = append(nextRules[threshold.DeviceID], rule)
 	}
+	exprSamples.replaceWatches(nextWatches, time.Now())
+	retainThresholdExprs(liveThresholds)
 
 	c.mu.Lock()
 	c.thresholds = next
@@ -173,6 +184,7 @@ func getThresholdCacheEntry(deviceID int64) ([]*models.Threshold, []thresholdEva
 				normalizeThresholdForRuntime(threshold)
 				loadedRules = append(loadedRules, buildThresholdEvalRule(threshold))
 			}
+			addExprRuleWatches(deviceID, loadedRules)
 			cache.mu.Lock()
 			cache.thresholds[deviceID] = loaded
 			cache.rules[deviceID] = loadedRules
@@ -191,6 +203,7 @@ func getThresholdCacheEntry(deviceID int64) ([]*models.Threshold, []thresholdEva
 			normalizeThresholdForRuntime(threshold)
 			rebuiltRules = append(rebuiltRules, buildThresholdEvalRule(threshold))
 		}
+		addExprRuleWatches(deviceID, rebuiltRules)
 		cache.mu.Lock()
 		cache.rules[deviceID] = rebuiltRules
 		cache.mu.Unlock()
@@ -206,6 +219,7 @@ func InvalidateDeviceCache(deviceID int64) {
 	delete(cache.thresholds, deviceID)
 	delete(cache.rules, deviceID)
 	cache.mu.Unlock()
+	forgetDeviceThresholdExprs(deviceID)
 }
 
 func normalizeThresholdForRuntime(threshold *models.Threshold) {
@@ -213,6 +227,15 @@ func normalizeThresholdForRuntime(threshold *models.Threshold) {
 		return
 	}
 	threshold.FieldName = strings.TrimSpace(threshold.FieldName)
+	threshold.Expression = strings.TrimSpace(threshold.Expression)
+}
+
+func addExprRuleWatches(deviceID int64, rules []thresholdEvalRule) {
+	watches := make(map[int64][]exprFieldRef)
+	for _, rule := range rules {
+		collectExprWatches(watches, deviceID, rule.expr)
+	}
+	exprSamples.addWatches(watches, time.Now())
 }
 
 func buildThresholdEvalRule(threshold *models.Threshold) thresholdEvalRule {
@@ -221,12 +244,25 @@ func buildThresholdEvalRule(threshold *models.Threshold) thresholdEvalRule {
 	operator := ""
 	thresholdValue := 0.0
 	shielded := false
+	deadband := 0.0
+	var triggerDelay, clearDelay time.Duration
 	if threshold != nil {
 		fieldName = strings.TrimSpace(threshold.FieldName)
 		normalized = normalizeFieldName(fieldName)
 		operator = strings.TrimSpace(threshold.Operator)
 		thresholdValue = threshold.Value
 		shielded = threshold.Shielded == 1
+		deadband = threshold.Deadband
+		triggerDelay = time.Duration(threshold.TriggerDelay) * time.Second
+		clearDelay = time.Duration(threshold.ClearDelay) * time.Second
+	}
+	var expr *thresholdExpr
+	if operator == ThresholdOperatorExpr {
+		compiled, err := compileThresholdExprFor(threshold)
+		if err != nil {
+			slog.Warn("Invalid threshold expression, rule skipped", "threshold_id", threshold.ID, "error", err)
+		}
+		expr = compiled
 	}
 	alarmKey := buildAlarmStateKey(0, threshold)
 	alarmIDKey, hasAlarmIDKey := alarmStateIDFromKey(alarmKey)
@@ -240,5 +276,9 @@ func buildThresholdEvalRule(threshold *models.Threshold) thresholdEvalRule {
 		hasAlarmIDKey:       hasAlarmIDKey,
 		shielded:            shielded,
 		thresholdValue:      thresholdValue,
+		expr:                expr,
+		deadband:            deadband,
+		triggerDelay:        triggerDelay,
+		clearDelay:          clearDelay,
 	}
 }
@@ -35,6 +35,9 @@ func setupThresholdCacheTestDB(t *testing.T) *sql.DB {
 		collect_interval INTEGER,
 		storage_interval INTEGER,
 		timeout INTEGER,
+		point_table TEXT,
+		deadbands TEXT,
+		device_config TEXT,
 		driver_id INTEGER,
 		enabled INTEGER,
 		resource_id INTEGER,
@@ -52,8 +55,12 @@ func setupThresholdCacheTestDB(t *testing.T) *sql.DB {
 		operator TEXT,
 		value REAL,
 		severity TEXT,
-		enabled INTEGER,
+		shielded INTEGER DEFAULT 0,
 		message TEXT,
+		expression TEXT,
+		deadband REAL DEFAULT 0,
+		trigger_delay_seconds INTEGER DEFAULT 0,
+		clear_delay_seconds INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
@@ -91,8 +98,8 @@ func TestGetDeviceThresholds_RefreshesStaleCache(t *testing.T) {
 		t.Fatalf("insert device failed: %v", err)
 	}
 
-	_, err = db.Exec(`INSERT INTO thresholds (device_id, field_name, operator, value, severity, enabled, message)
-		VALUES (1, 'humidity', '>', 50, 'warning', 1, '湿度高')`)
+	_, err = db.Exec(`INSERT INTO thresholds (device_id, field_name, operator, value, severity, shielded, message)
+		VALUES (1, 'humidity', '>', 50, 'warning', 0, '湿度高')`)
 	if err != nil {
 		t.Fatalf("insert threshold failed: %v", err)
 	}
@@ -136,10 +143,10 @@ func TestThresholdCache_RefreshRebuildsAndDropsStaleEntries(t *testing.T) {
 		t.Fatalf("insert devices failed: %v", err)
 	}
 
-	_, err = db.Exec(`INSERT INTO thresholds (device_id, field_name, operator, value, severity, enabled, message)
+	_, err = db.Exec(`INSERT INTO thresholds (device_id, field_name, operator, value, severity, shielded, message)
 		VALUES
-		(1, 'humidity', '>', 50, 'warning', 1, '湿度高'),
-		(999, 'temperature', '>', 60, 'warning', 1, '孤立阈值')`)
+		(1, 'humidity', '>', 50, 'warning', 0, '湿度高'),
+		(999, 'temperature', '>', 60, 'warning', 0, '孤立阈值')`)
 	if err != nil {
 		t.Fatalf("insert thresholds failed: %v", err)
 	}
@@ -402,10 +402,59 @@ func (c *Collector) checkThresholds(device *models.Device, data *models.CollectD
 	}
 
 	now := time.Now()
-	repeatInterval := resolveAlarmRepeatInterval()
-	maybePruneAlarmStates(now, repeatInterval)
 	lookup := newNumericFieldLookup(data.Fields, data.Points)
 	defer lookup.release()
+	sampleAt := data.Timestamp
+	if sampleAt.IsZero() {
+		sampleAt = now
+	}
+	exprSamples.record(device.ID, lookup, sampleAt)
+	c.applyThresholdRules(device, rules, lookup, sampleAt, now)
+	return nil
+}
+
+// staleThresholdCheckInterval 含 stale() 的表达式阈值的定时检查周期
+const staleThresholdCheckInterval = 10 * time.Second
+
+// checkStaleThresholds 定时对启用设备的 stale() 表达式阈值求值：
+// 设备停止上报时不会触发 checkThresholds，需要由定时检查产生或恢复报警
+func (c *Collector) checkStaleThresholds() {
+	c.mu.RLock()
+	devices := make([]*models.Device, 0, len(c.tasks))
+	for _, task := range c.tasks {
+		if task != nil && task.device != nil {
+			devices = append(devices, task.device)
+		}
+	}
+	c.mu.RUnlock()
+
+	now := time.Now()
+	for _, device := range devices {
+		rules, err := getDeviceThresholdRules(device.ID)
+		if err != nil {
+			slog.Warn("Failed to load thresholds for stale check", "device_id", device.ID, "error", err)
+			continue
+		}
+		var staleRules []thresholdEvalRule
+		for _, rule := range rules {
+			if rule.expr != nil && rule.expr.usesStale {
+				staleRules = append(staleRules, rule)
+			}
+		}
+		if len(staleRules) > 0 {
+			c.applyThresholdRules(device, staleRules, nil, now, now)
+		}
+	}
+}
+
+// applyThresholdRules 按规则推进报警状态；lookup 为 nil 时表达式字段取最近采样（定时检查）
+func (c *Collector) applyThresholdRules(device *models.Device, rules []thresholdEvalRule, lookup *numericFieldLookup, sampleAt, now time.Time) {
+	repeatInterval := resolveAlarmRepeatInterval()
+	maybePruneAlarmStates(now, repeatInterval)
+	if len(rules) > 0 {
+		ensureAlarmLifecyclesLoaded(device.ID)
+	}
+	var env *exprEnv
 	for _, rule := range rules {
 		threshold := rule.threshold
 		if threshold == nil {
@@ -415,57 +464,119 @@ func (c *Collector) checkThresholds(device *models.Device, data *models.CollectD
 			continue
 		}
 
-		value, ok := lookup.getFloatByPreparedKeys(rule.fieldName, rule.normalizedFieldName)
-		if !ok {
-			continue
+		var value float64
+		var matched, hold bool
+		if rule.operator == ThresholdOperatorExpr {
+			if rule.expr == nil {
+				continue
+			}
+			if env == nil {
+				env = &exprEnv{deviceID: device.ID, lookup: lookup, samples: exprSamples, now: sampleAt}
+			}
+			exprMatched, actual, ok := rule.expr.evaluate(env)
+			if !ok {
+				continue
+			}
+			value, matched, hold = actual, exprMatched, exprMatched
+		} else {
+			if lookup == nil {
+				continue
+			}
+			fieldValue, ok := lookup.getFloatByPreparedKeys(rule.fieldName, rule.normalizedFieldName)
+			if !ok {
+				continue
+			}
+			value = fieldValue
+			matched = thresholdMatch(fieldValue, rule.operator, rule.thresholdValue)
+			hold = thresholdHold(fieldValue, rule.operator, rule.thresholdValue, rule.deadband)
 		}
 
-		matched := thresholdMatch(value, rule.operator, rule.thresholdValue)
-		if !matched {
-			continue
-		}
-		emit := false
-		if rule.hasAlarmIDKey {
-			alarmIDKey := rule.alarmIDKey
-			alarmIDKey.DeviceID = device.ID
-			emit = shouldEmitAlarmForIDKey(alarmIDKey, now, repeatInterval)
-		} else {
+		if !rule.hasAlarmIDKey {
+			if !matched {
+				continue
+			}
 			alarmKey := rule.alarmKey
 			alarmKey.DeviceID = device.ID
-			emit = shouldEmitAlarmForKey(alarmKey, now, repeatInterval)
-		}
-		if !emit {
+			if shouldEmitAlarmForKey(alarmKey, now, repeatInterval) {
+				c.handleAlarm(device, threshold, value)
+			}
 			continue
 		}
 
-		c.handleAlarm(device, threshold, value)
+		alarmIDKey := rule.alarmIDKey
+		alarmIDKey.DeviceID = device.ID
+		switch advanceAlarmLifecycle(alarmIDKey, matched, hold, sampleAt, rule.triggerDelay, rule.clearDelay) {
+		case alarmTransitionRaise:
+			markAlarmTriggeredForIDKey(alarmIDKey, now)
+			c.raiseAlarm(device, threshold, alarmIDKey, value, sampleAt)
+		case alarmTransitionClear:
+			c.clearAlarm(device, threshold, value, sampleAt)
+		default:
+			// 报警保持期间按重复间隔再次上报北向；报警日志仍只有产生时的一条，恢复时整体标记
+			if matched && isAlarmLifecycleActive(alarmIDKey) && shouldEmitAlarmForIDKey(alarmIDKey, now, repeatInterval) {
+				c.notifyAlarmActive(device, threshold, value)
+			}
+		}
 	}
+}
 
-	return nil
+func thresholdAlarmMessage(threshold *models.Threshold) string {
+	if threshold.Message == "" && threshold.Expression != "" {
+		return threshold.Expression
+	}
+	return threshold.Message
 }
 
-// handleAlarm 处理报警
-func (c *Collector) handleAlarm(device *models.Device, threshold *models.Threshold, actualValue float64) {
-	logEntry := &models.AlarmLog{
+// raiseAlarm 产生报警：写报警日志与当前报警表，并上报北向
+func (c *Collector) raiseAlarm(device *models.Device, threshold *models.Threshold, key alarmStateIDKey, actualValue float64, triggeredAt time.Time) {
+	alarmLogID := c.handleAlarm(device, threshold, actualValue)
+	setAlarmLifecycleLogID(key, alarmLogID)
+
+	err := database.UpsertActiveAlarm(&models.ActiveAlarm{
 		DeviceID:       device.ID,
-		ThresholdID:    &threshold.ID,
+		ThresholdID:    threshold.ID,
+		AlarmLogID:     alarmLogID,
 		FieldName:      threshold.FieldName,
 		ActualValue:    actualValue,
 		ThresholdValue: threshold.Value,
 		Operator:       threshold.Operator,
 		Severity:       threshold.Severity,
-		Message:        threshold.Message,
+		Message:        thresholdAlarmMessage(threshold),
+		TriggeredAt:    triggeredAt,
+	})
+	if err != nil {
+		slog.Error("Failed to save active alarm", "device_id", device.ID, "threshold_id", threshold.ID, "error", err)
 	}
+}
 
-	if _, err := database.CreateAlarmLog(logEntry); err != nil {
-		slog.Error("Failed to create alarm log", "error", err)
+// clearAlarm 报警恢复：标记报警日志恢复时间、移出当前报警表，并上报北向恢复事件
+func (c *Collector) clearAlarm(device *models.Device, threshold *models.Threshold, actualValue float64, clearedAt time.Time) {
+	active, err := database.ListActiveAlarmsByDevice(device.ID)
+	if err != nil {
+		slog.Error("Failed to load active alarms", "device_id", device.ID, "error", err)
+	}
+	for _, alarm := range active {
+		if alarm.ThresholdID == threshold.ID && alarm.AlarmLogID > 0 {
+			if err := database.MarkAlarmLogCleared(alarm.AlarmLogID, clearedAt); err != nil {
+				slog.Error("Failed to mark alarm log cleared", "alarm_log_id", alarm.AlarmLogID, "error", err)
+			}
+		}
+	}
+	if err := database.DeleteActiveAlarm(device.ID, threshold.ID); err != nil {
+		slog.Error("Failed to delete active alarm", "device_id", device.ID, "threshold_id", threshold.ID, "error", err)
 	}
 
 	if c.northboundMgr == nil {
 		return
 	}
+	payload := newAlarmPayload(device, threshold, actualValue)
+	payload.State = models.AlarmStateCleared
+	payload.Timestamp = clearedAt
+	c.northboundMgr.SendAlarm(payload)
+}
 
-	payload := &models.AlarmPayload{
+func newAlarmPayload(device *models.Device, threshold *models.Threshold, actualValue float64) *models.AlarmPayload {
+	return &models.AlarmPayload{
 		DeviceID:    device.ID,
 		DeviceName:  device.Name,
 		ProductKey:  device.ProductKey,
@@ -475,8 +586,38 @@ func (c *Collector) handleAlarm(device *models.Device, threshold *models.Thresho
 		Threshold:   threshold.Value,
 		Operator:    threshold.Operator,
 		Severity:    threshold.Severity,
-		Message:     threshold.Message,
+		Message:     thresholdAlarmMessage(threshold),
+	}
+}
+
+// handleAlarm 处理报警（写报警日志并上报北向），返回报警日志ID
+func (c *Collector) handleAlarm(device *models.Device, threshold *models.Threshold, actualValue float64) int64 {
+	logEntry := &models.AlarmLog{
+		DeviceID:       device.ID,
+		ThresholdID:    &threshold.ID,
+		FieldName:      threshold.FieldName,
+		ActualValue:    actualValue,
+		ThresholdValue: threshold.Value,
+		Operator:       threshold.Operator,
+		Severity:       threshold.Severity,
+		Message:        thresholdAlarmMessage(threshold),
 	}
 
+	alarmLogID, err := database.CreateAlarmLog(logEntry)
+	if err != nil {
+		slog.Error("Failed to create alarm log", "error", err)
+	}
+
+	c.notifyAlarmActive(device, threshold, actualValue)
+	return alarmLogID
+}
+
+// notifyAlarmActive 向北向上报报警（不写报警日志）
+func (c *Collector) notifyAlarmActive(device *models.Device, threshold *models.Threshold, actualValue float64) {
+	if c.northboundMgr == nil {
+		return
+	}
+	payload := newAlarmPayload(device, threshold, actualValue)
+	payload.State = models.AlarmStateActive
 	c.northboundMgr.SendAlarm(payload)
 }
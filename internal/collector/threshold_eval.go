@@ -18,3 +18,22 @@ func thresholdMatch(value float64, operator string, threshold float64) bool {
 		return false
 	}
 }
+
+// thresholdHold 报警后是否保持：数值需越过 阈值∓回差 才视为恢复（== / != 不支持回差）
+func thresholdHold(value float64, operator string, threshold, deadband float64) bool {
+	if deadband < 0 {
+		deadband = -deadband
+	}
+	switch operator {
+	case ">":
+		return value > threshold-deadband
+	case ">=":
+		return value >= threshold-deadband
+	case "<":
+		return value < threshold+deadband
+	case "<=":
+		return value <= threshold+deadband
+	default:
+		return thresholdMatch(value, operator, threshold)
+	}
+}
@@ -0,0 +1,836 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// ThresholdOperatorExpr 表达式阈值的 operator 取值，规则内容保存在 threshold.expression
+const ThresholdOperatorExpr = "expr"
+
+// 表达式语法：
+//
+//	Ua < 180 && Ia > 5                 多字段布尔组合（&& || ! / and or not）
+//	Ua in [198, 242]                   闭区间判断
+//	rate(P) > 10                       每秒变化率（与上一次采样比较）
+//	stale(Ua) > 60                     字段超过 N 秒未更新
+//	#12.Ua - Ua > 5                    #<设备ID>.<字段> 引用其他设备最新值
+//	"A 相电压" > 250                   字段名含空格等字符时使用双引号
+//
+// 支持 + - * / 算术以及 abs/min/max 函数；字段缺失时规则不命中。
+
+var errExprMissingValue = errors.New("expression value missing")
+
+type exprType int
+
+const (
+	exprTypeNumber exprType = iota
+	exprTypeBool
+)
+
+type exprValue struct {
+	num     float64
+	boolean bool
+}
+
+// exprEnv 表达式求值上下文
+type exprEnv struct {
+	deviceID int64
+	lookup   *numericFieldLookup
+	samples  *exprSampleStore
+	now      time.Time
+}
+
+type exprNode interface {
+	eval(env *exprEnv) (exprValue, error)
+}
+
+// exprFieldRef 字段引用，deviceID 为 0 表示规则所属设备
+type exprFieldRef struct {
+	deviceID   int64
+	field      string
+	normalized string
+}
+
+func (r exprFieldRef) resolveDeviceID(env *exprEnv) int64 {
+	if r.deviceID == 0 {
+		return env.deviceID
+	}
+	return r.deviceID
+}
+
+// eval 本设备字段取本次采集值；定时检查（无 lookup）与其他设备字段取最近采样
+func (r exprFieldRef) eval(env *exprEnv) (exprValue, error) {
+	deviceID := r.resolveDeviceID(env)
+	if deviceID == env.deviceID && env.lookup != nil {
+		if value, ok := env.lookup.getFloatByPreparedKeys(r.field, r.normalized); ok {
+			return exprValue{num: value}, nil
+		}
+		return exprValue{}, errExprMissingValue
+	}
+	if sample, ok := env.samples.latest(deviceID, r.normalized); ok {
+		return exprValue{num: sample.value}, nil
+	}
+	return exprValue{}, errExprMissingValue
+}
+
+type exprNumber float64
+
+func (n exprNumber) eval(*exprEnv) (exprValue, error) { return exprValue{num: float64(n)}, nil }
+
+type exprBool bool
+
+func (b exprBool) eval(*exprEnv) (exprValue, error) { return exprValue{boolean: bool(b)}, nil }
+
+type exprNot struct{ operand exprNode }
+
+func (n exprNot) eval(env *exprEnv) (exprValue, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	return exprValue{boolean: !v.boolean}, nil
+}
+
+type exprNeg struct{ operand exprNode }
+
+func (n exprNeg) eval(env *exprEnv) (exprValue, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	return exprValue{num: -v.num}, nil
+}
+
+// exprLogic && / ||，一侧缺值时若另一侧已能决定结果则不影响
+type exprLogic struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n exprLogic) eval(env *exprEnv) (exprValue, error) {
+	left, leftErr := n.left.eval(env)
+	if leftErr == nil && left.boolean != n.and {
+		return exprValue{boolean: left.boolean}, nil
+	}
+	right, rightErr := n.right.eval(env)
+	if rightErr == nil && right.boolean != n.and {
+		return exprValue{boolean: right.boolean}, nil
+	}
+	if leftErr != nil {
+		return exprValue{}, leftErr
+	}
+	if rightErr != nil {
+		return exprValue{}, rightErr
+	}
+	return exprValue{boolean: n.and}, nil
+}
+
+type exprCompare struct {
+	op          string
+	left, right exprNode
+}
+
+func (n exprCompare) eval(env *exprEnv) (exprValue, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	return exprValue{boolean: thresholdMatch(left.num, n.op, right.num)}, nil
+}
+
+type exprArith struct {
+	op          byte
+	left, right exprNode
+}
+
+func (n exprArith) eval(env *exprEnv) (exprValue, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	switch n.op {
+	case '+':
+		return exprValue{num: left.num + right.num}, nil
+	case '-':
+		return exprValue{num: left.num - right.num}, nil
+	case '*':
+		return exprValue{num: left.num * right.num}, nil
+	default:
+		if right.num == 0 {
+			return exprValue{}, errExprMissingValue
+		}
+		return exprValue{num: left.num / right.num}, nil
+	}
+}
+
+type exprInRange struct {
+	value, low, high exprNode
+}
+
+func (n exprInRange) eval(env *exprEnv) (exprValue, error) {
+	value, err := n.value.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	low, err := n.low.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	high, err := n.high.eval(env)
+	if err != nil {
+		return exprValue{}, err
+	}
+	return exprValue{boolean: value.num >= low.num && value.num <= high.num}, nil
+}
+
+type exprCall struct {
+	name string
+	args []exprNode
+}
+
+func (n exprCall) eval(env *exprEnv) (exprValue, error) {
+	values := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return exprValue{}, err
+		}
+		values[i] = v.num
+	}
+	switch n.name {
+	case "abs":
+		return exprValue{num: math.Abs(values[0])}, nil
+	case "min":
+		return exprValue{num: math.Min(values[0], values[1])}, nil
+	default:
+		return exprValue{num: math.Max(values[0], values[1])}, nil
+	}
+}
+
+// exprRate 每秒变化率，需要至少两次采样
+type exprRate struct{ ref exprFieldRef }
+
+func (n exprRate) eval(env *exprEnv) (exprValue, error) {
+	sample, ok := env.samples.latest(n.ref.resolveDeviceID(env), n.ref.normalized)
+	if !ok || sample.prevAt.IsZero() {
+		return exprValue{}, errExprMissingValue
+	}
+	seconds := sample.at.Sub(sample.prevAt).Seconds()
+	if seconds <= 0 {
+		return exprValue{}, errExprMissingValue
+	}
+	return exprValue{num: (sample.value - sample.prevValue) / seconds}, nil
+}
+
+// exprStale 字段距最近一次更新的秒数；从未出现时按开始跟踪的时间计算
+type exprStale struct{ ref exprFieldRef }
+
+func (n exprStale) eval(env *exprEnv) (exprValue, error) {
+	lastAt := env.samples.lastSeenAt(n.ref.resolveDeviceID(env), n.ref.normalized)
+	return exprValue{num: env.now.Sub(lastAt).Seconds()}, nil
+}
+
+// thresholdExpr 编译后的表达式
+type thresholdExpr struct {
+	source    string
+	root      exprNode
+	refs      []exprFieldRef
+	usesStale bool // 含 stale()，需由定时检查在设备停止上报时求值
+}
+
+// evaluate 返回是否命中、用于上报的实际值（首个引用字段的值）以及是否可求值（字段缺失时为 false）
+func (e *thresholdExpr) evaluate(env *exprEnv) (matched bool, actual float64, ok bool) {
+	result, err := e.root.eval(env)
+	if err != nil {
+		return false, 0, false
+	}
+	if len(e.refs) > 0 {
+		if v, err := e.refs[0].eval(env); err == nil {
+			actual = v.num
+		}
+	}
+	return result.boolean, actual, true
+}
+
+// PrimaryField 返回表达式首个引用字段（用于报警字段名）
+func (e *thresholdExpr) PrimaryField() string {
+	if e == nil || len(e.refs) == 0 {
+		return ""
+	}
+	ref := e.refs[0]
+	if ref.deviceID != 0 {
+		return fmt.Sprintf("#%d.%s", ref.deviceID, ref.field)
+	}
+	return ref.field
+}
+
+// thresholdExprCacheEntry 按阈值 ID 缓存的编译结果
+type thresholdExprCacheEntry struct {
+	deviceID int64
+	source   string
+	expr     *thresholdExpr
+}
+
+// compiledThresholdExprs 表达式编译缓存，按阈值 ID 保存，条目数不超过表达式阈值数；
+// 阈值更新/删除时随设备缓存失效一并清理，全量刷新时剔除已不存在的阈值
+var compiledThresholdExprs = struct {
+	mu   sync.Mutex
+	byID map[int64]thresholdExprCacheEntry
+}{byID: make(map[int64]thresholdExprCacheEntry)}
+
+// compileThresholdExprFor 编译阈值表达式，表达式未变化时复用缓存
+func compileThresholdExprFor(threshold *models.Threshold) (*thresholdExpr, error) {
+	source := strings.TrimSpace(threshold.Expression)
+	if threshold.ID <= 0 {
+		return compileThresholdExpr(source)
+	}
+	compiledThresholdExprs.mu.Lock()
+	cached, ok := compiledThresholdExprs.byID[threshold.ID]
+	compiledThresholdExprs.mu.Unlock()
+	if ok && cached.source == source && cached.deviceID == threshold.DeviceID {
+		return cached.expr, nil
+	}
+	expr, err := compileThresholdExpr(source)
+	if err != nil {
+		forgetThresholdExpr(threshold.ID)
+		return nil, err
+	}
+	compiledThresholdExprs.mu.Lock()
+	compiledThresholdExprs.byID[threshold.ID] = thresholdExprCacheEntry{deviceID: threshold.DeviceID, source: source, expr: expr}
+	compiledThresholdExprs.mu.Unlock()
+	return expr, nil
+}
+
+func forgetThresholdExpr(thresholdID int64) {
+	compiledThresholdExprs.mu.Lock()
+	delete(compiledThresholdExprs.byID, thresholdID)
+	compiledThresholdExprs.mu.Unlock()
+}
+
+// forgetDeviceThresholdExprs 清理设备下阈值的编译缓存
+func forgetDeviceThresholdExprs(deviceID int64) {
+	compiledThresholdExprs.mu.Lock()
+	defer compiledThresholdExprs.mu.Unlock()
+	for id, entry := range compiledThresholdExprs.byID {
+		if entry.deviceID == deviceID {
+			delete(compiledThresholdExprs.byID, id)
+		}
+	}
+}
+
+// retainThresholdExprs 仅保留仍存在的阈值的编译缓存
+func retainThresholdExprs(live map[int64]struct{}) {
+	compiledThresholdExprs.mu.Lock()
+	defer compiledThresholdExprs.mu.Unlock()
+	for id := range compiledThresholdExprs.byID {
+		if _, ok := live[id]; !ok {
+			delete(compiledThresholdExprs.byID, id)
+		}
+	}
+}
+
+// ValidateThresholdExpression 校验阈值表达式，返回首个引用字段
+func ValidateThresholdExpression(source string) (string, error) {
+	expr, err := compileThresholdExpr(source)
+	if err != nil {
+		return "", err
+	}
+	return expr.PrimaryField(), nil
+}
+
+func compileThresholdExpr(source string) (*thresholdExpr, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	tokens, err := tokenizeThresholdExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, typ, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != exprTokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+	}
+	if typ != exprTypeBool {
+		return nil, fmt.Errorf("expression must evaluate to a boolean")
+	}
+	return &thresholdExpr{source: source, root: root, refs: p.refs, usesStale: p.usesStale}, nil
+}
+
+type exprTokenKind int
+
+const (
+	exprTokEOF exprTokenKind = iota
+	exprTokNumber
+	exprTokIdent
+	exprTokDeviceRef
+	exprTokOp
+)
+
+type exprToken struct {
+	kind     exprTokenKind
+	text     string
+	num      float64
+	deviceID int64
+	pos      int
+}
+
+func isExprIdentRune(r rune, first bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return !first && (unicode.IsDigit(r) || r == '.')
+}
+
+func tokenizeThresholdExpr(src string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0, 16)
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9' || (r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			end := i
+			for end < len(src) && (src[end] >= '0' && src[end] <= '9' || src[end] == '.' ||
+				src[end] == 'e' || src[end] == 'E' ||
+				((src[end] == '+' || src[end] == '-') && end > i && (src[end-1] == 'e' || src[end-1] == 'E'))) {
+				end++
+			}
+			num, err := strconv.ParseFloat(src[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", src[i:end], i)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokNumber, text: src[i:end], num: num, pos: i})
+			i = end
+		case r == '"':
+			field, end, err := readExprQuoted(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, exprToken{kind: exprTokIdent, text: field, pos: i})
+			i = end
+		case r == '#':
+			tok, end, err := readExprDeviceRef(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = end
+		case isExprIdentRune(r, true):
+			end := i
+			for end < len(src) {
+				next, nextSize := utf8.DecodeRuneInString(src[end:])
+				if !isExprIdentRune(next, end == i) {
+					break
+				}
+				end += nextSize
+			}
+			tokens = append(tokens, exprToken{kind: exprTokIdent, text: src[i:end], pos: i})
+			i = end
+		default:
+			op := src[i : i+size]
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "&&", "||", "<=", ">=", "==", "!=":
+					op = two
+				}
+			}
+			if !strings.Contains("&& || <= >= == != < > ! + - * / ( ) [ ] ,", op) || op == "&" || op == "|" || op == "=" {
+				return nil, fmt.Errorf("unexpected character %q at %d", op, i)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, exprToken{kind: exprTokEOF, pos: len(src)}), nil
+}
+
+func readExprQuoted(src string, start int) (string, int, error) {
+	end := strings.IndexByte(src[start+1:], '"')
+	if end < 0 {
+		return "", 0, fmt.Errorf("unterminated field name at %d", start)
+	}
+	field := strings.TrimSpace(src[start+1 : start+1+end])
+	if field == "" {
+		return "", 0, fmt.Errorf("empty field name at %d", start)
+	}
+	return field, start + end + 2, nil
+}
+
+// readExprDeviceRef 解析 #<设备ID>.<字段> 形式的跨设备引用
+func readExprDeviceRef(src string, start int) (exprToken, int, error) {
+	i := start + 1
+	for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+		i++
+	}
+	deviceID, err := strconv.ParseInt(src[start+1:i], 10, 64)
+	if err != nil || deviceID <= 0 || i >= len(src) || src[i] != '.' {
+		return exprToken{}, 0, fmt.Errorf("invalid device reference at %d, want #<device_id>.<field>", start)
+	}
+	i++
+	if i < len(src) && src[i] == '"' {
+		field, end, err := readExprQuoted(src, i)
+		if err != nil {
+			return exprToken{}, 0, err
+		}
+		return exprToken{kind: exprTokDeviceRef, text: field, deviceID: deviceID, pos: start}, end, nil
+	}
+	end := i
+	for end < len(src) {
+		r, size := utf8.DecodeRuneInString(src[end:])
+		if !isExprIdentRune(r, end == i) {
+			break
+		}
+		end += size
+	}
+	if end == i {
+		return exprToken{}, 0, fmt.Errorf("missing field name in device reference at %d", start)
+	}
+	return exprToken{kind: exprTokDeviceRef, text: src[i:end], deviceID: deviceID, pos: start}, end, nil
+}
+
+type exprParser struct {
+	tokens    []exprToken
+	pos       int
+	refs      []exprFieldRef
+	usesStale bool
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != exprTokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) acceptOp(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != exprTokOp && tok.kind != exprTokIdent {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op || (tok.kind == exprTokIdent && strings.EqualFold(tok.text, op)) {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if _, ok := p.acceptOp(op); !ok {
+		tok := p.peek()
+		return fmt.Errorf("expected %q at %d", op, tok.pos)
+	}
+	return nil
+}
+
+func expectExprType(got, want exprType, context string) error {
+	if got == want {
+		return nil
+	}
+	if want == exprTypeBool {
+		return fmt.Errorf("%s requires a boolean operand", context)
+	}
+	return fmt.Errorf("%s requires a numeric operand", context)
+}
+
+func (p *exprParser) parseOr() (exprNode, exprType, error) {
+	left, typ, err := p.parseAnd()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		if _, ok := p.acceptOp("||", "or"); !ok {
+			return left, typ, nil
+		}
+		right, rightType, err := p.parseAnd()
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(typ, exprTypeBool, "||"); err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(rightType, exprTypeBool, "||"); err != nil {
+			return nil, 0, err
+		}
+		left = exprLogic{and: false, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, exprType, error) {
+	left, typ, err := p.parseNot()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		if _, ok := p.acceptOp("&&", "and"); !ok {
+			return left, typ, nil
+		}
+		right, rightType, err := p.parseNot()
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(typ, exprTypeBool, "&&"); err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(rightType, exprTypeBool, "&&"); err != nil {
+			return nil, 0, err
+		}
+		left = exprLogic{and: true, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseNot() (exprNode, exprType, error) {
+	if _, ok := p.acceptOp("!", "not"); ok {
+		operand, typ, err := p.parseNot()
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(typ, exprTypeBool, "!"); err != nil {
+			return nil, 0, err
+		}
+		return exprNot{operand: operand}, exprTypeBool, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, exprType, error) {
+	left, typ, err := p.parseSum()
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, ok := p.acceptOp("in"); ok {
+		if err := expectExprType(typ, exprTypeNumber, "in"); err != nil {
+			return nil, 0, err
+		}
+		if err := p.expectOp("["); err != nil {
+			return nil, 0, err
+		}
+		low, err := p.parseNumeric("in")
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := p.expectOp(","); err != nil {
+			return nil, 0, err
+		}
+		high, err := p.parseNumeric("in")
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := p.expectOp("]"); err != nil {
+			return nil, 0, err
+		}
+		return exprInRange{value: left, low: low, high: high}, exprTypeBool, nil
+	}
+	op, ok := p.acceptOp("<=", ">=", "==", "!=", "<", ">")
+	if !ok {
+		return left, typ, nil
+	}
+	right, rightType, err := p.parseSum()
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := expectExprType(typ, exprTypeNumber, op); err != nil {
+		return nil, 0, err
+	}
+	if err := expectExprType(rightType, exprTypeNumber, op); err != nil {
+		return nil, 0, err
+	}
+	return exprCompare{op: op, left: left, right: right}, exprTypeBool, nil
+}
+
+func (p *exprParser) parseNumeric(context string) (exprNode, error) {
+	node, typ, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if err := expectExprType(typ, exprTypeNumber, context); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *exprParser) parseSum() (exprNode, exprType, error) {
+	left, typ, err := p.parseTerm()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, typ, nil
+		}
+		right, rightType, err := p.parseTerm()
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(typ, exprTypeNumber, op); err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(rightType, exprTypeNumber, op); err != nil {
+			return nil, 0, err
+		}
+		left = exprArith{op: op[0], left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseTerm() (exprNode, exprType, error) {
+	left, typ, err := p.parseUnary()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		op, ok := p.acceptOp("*", "/")
+		if !ok {
+			return left, typ, nil
+		}
+		right, rightType, err := p.parseUnary()
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(typ, exprTypeNumber, op); err != nil {
+			return nil, 0, err
+		}
+		if err := expectExprType(rightType, exprTypeNumber, op); err != nil {
+			return nil, 0, err
+		}
+		left = exprArith{op: op[0], left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, exprType, error) {
+	if _, ok := p.acceptOp("-"); ok {
+		operand, err := p.parseNumericUnary()
+		if err != nil {
+			return nil, 0, err
+		}
+		return exprNeg{operand: operand}, exprTypeNumber, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parseNumericUnary() (exprNode, error) {
+	node, typ, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if err := expectExprType(typ, exprTypeNumber, "-"); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *exprParser) addRef(ref exprFieldRef) exprFieldRef {
+	ref.normalized = normalizeFieldName(ref.field)
+	for _, existing := range p.refs {
+		if existing.deviceID == ref.deviceID && existing.normalized == ref.normalized {
+			return ref
+		}
+	}
+	p.refs = append(p.refs, ref)
+	return ref
+}
+
+func (p *exprParser) parsePrimary() (exprNode, exprType, error) {
+	tok := p.next()
+	switch tok.kind {
+	case exprTokNumber:
+		return exprNumber(tok.num), exprTypeNumber, nil
+	case exprTokDeviceRef:
+		return p.addRef(exprFieldRef{deviceID: tok.deviceID, field: tok.text}), exprTypeNumber, nil
+	case exprTokIdent:
+		lower := strings.ToLower(tok.text)
+		switch lower {
+		case "true", "false":
+			return exprBool(lower == "true"), exprTypeBool, nil
+		}
+		if next := p.peek(); next.kind == exprTokOp && next.text == "(" && p.tokens[p.pos-1].pos+len(tok.text) == next.pos {
+			return p.parseCall(lower, tok)
+		}
+		return p.addRef(exprFieldRef{field: tok.text}), exprTypeNumber, nil
+	case exprTokOp:
+		if tok.text == "(" {
+			node, typ, err := p.parseOr()
+			if err != nil {
+				return nil, 0, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, 0, err
+			}
+			return node, typ, nil
+		}
+	case exprTokEOF:
+		return nil, 0, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, 0, fmt.Errorf("unexpected %q at %d", tok.text, tok.pos)
+}
+
+func (p *exprParser) parseCall(name string, tok exprToken) (exprNode, exprType, error) {
+	p.next() // (
+	switch name {
+	case "rate", "stale":
+		refTok := p.next()
+		if refTok.kind != exprTokIdent && refTok.kind != exprTokDeviceRef {
+			return nil, 0, fmt.Errorf("%s() requires a field name at %d", name, refTok.pos)
+		}
+		ref := p.addRef(exprFieldRef{deviceID: refTok.deviceID, field: refTok.text})
+		if err := p.expectOp(")"); err != nil {
+			return nil, 0, err
+		}
+		if name == "rate" {
+			return exprRate{ref: ref}, exprTypeNumber, nil
+		}
+		p.usesStale = true
+		return exprStale{ref: ref}, exprTypeNumber, nil
+	case "abs", "min", "max":
+		want := 2
+		if name == "abs" {
+			want = 1
+		}
+		args := make([]exprNode, 0, want)
+		for len(args) < want {
+			if len(args) > 0 {
+				if err := p.expectOp(","); err != nil {
+					return nil, 0, err
+				}
+			}
+			arg, err := p.parseNumeric(name + "()")
+			if err != nil {
+				return nil, 0, err
+			}
+			args = append(args, arg)
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, 0, err
+		}
+		return exprCall{name: name, args: args}, exprTypeNumber, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown function %q at %d", tok.text, tok.pos)
+	}
+}
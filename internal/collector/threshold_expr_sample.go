@@ -0,0 +1,162 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// exprSampleKey 表达式采样键（设备 + 归一化字段名）
+type exprSampleKey struct {
+	deviceID int64
+	field    string
+}
+
+// exprSample 字段最近两次采样，用于 rate/stale 与跨设备引用
+type exprSample struct {
+	value     float64
+	at        time.Time
+	prevValue float64
+	prevAt    time.Time
+}
+
+// exprSampleStore 表达式规则引用字段的采样缓存，仅记录被表达式关注的字段
+type exprSampleStore struct {
+	mu        sync.RWMutex
+	watches   map[int64][]exprFieldRef
+	watchedAt map[exprSampleKey]time.Time
+	samples   map[exprSampleKey]*exprSample
+}
+
+var exprSamples = newExprSampleStore()
+
+func newExprSampleStore() *exprSampleStore {
+	return &exprSampleStore{
+		watches:   make(map[int64][]exprFieldRef),
+		watchedAt: make(map[exprSampleKey]time.Time),
+		samples:   make(map[exprSampleKey]*exprSample),
+	}
+}
+
+// collectExprWatches 汇总表达式规则引用的字段（按所在设备分组）
+func collectExprWatches(dst map[int64][]exprFieldRef, ownerDeviceID int64, expr *thresholdExpr) {
+	if expr == nil {
+		return
+	}
+	for _, ref := range expr.refs {
+		deviceID := ref.deviceID
+		if deviceID == 0 {
+			deviceID = ownerDeviceID
+		}
+		ref.deviceID = deviceID
+		if !containsExprRef(dst[deviceID], ref) {
+			dst[deviceID] = append(dst[deviceID], ref)
+		}
+	}
+}
+
+func containsExprRef(refs []exprFieldRef, ref exprFieldRef) bool {
+	for _, existing := range refs {
+		if existing.normalized == ref.normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceWatches 全量替换关注字段，并清理不再被引用的采样
+func (s *exprSampleStore) replaceWatches(watches map[int64][]exprFieldRef, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches = watches
+	nextWatchedAt := make(map[exprSampleKey]time.Time)
+	for deviceID, refs := range watches {
+		for _, ref := range refs {
+			key := exprSampleKey{deviceID: deviceID, field: ref.normalized}
+			if at, ok := s.watchedAt[key]; ok {
+				nextWatchedAt[key] = at
+			} else {
+				nextWatchedAt[key] = now
+			}
+		}
+	}
+	s.watchedAt = nextWatchedAt
+	for key := range s.samples {
+		if _, ok := nextWatchedAt[key]; !ok {
+			delete(s.samples, key)
+		}
+	}
+}
+
+// addWatches 增量追加关注字段（单设备懒加载规则时使用）
+func (s *exprSampleStore) addWatches(watches map[int64][]exprFieldRef, now time.Time) {
+	if len(watches) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for deviceID, refs := range watches {
+		for _, ref := range refs {
+			key := exprSampleKey{deviceID: deviceID, field: ref.normalized}
+			if _, ok := s.watchedAt[key]; ok {
+				continue
+			}
+			s.watchedAt[key] = now
+			s.watches[deviceID] = append(s.watches[deviceID], ref)
+		}
+	}
+}
+
+// record 记录设备本次采集中被关注字段的值
+func (s *exprSampleStore) record(deviceID int64, lookup *numericFieldLookup, at time.Time) {
+	s.mu.RLock()
+	refs := s.watches[deviceID]
+	s.mu.RUnlock()
+	if len(refs) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ref := range refs {
+		value, ok := lookup.getFloatByPreparedKeys(ref.field, ref.normalized)
+		if !ok {
+			continue
+		}
+		key := exprSampleKey{deviceID: deviceID, field: ref.normalized}
+		sample, exists := s.samples[key]
+		if !exists {
+			s.samples[key] = &exprSample{value: value, at: at}
+			continue
+		}
+		if !at.After(sample.at) {
+			sample.value = value
+			continue
+		}
+		sample.prevValue, sample.prevAt = sample.value, sample.at
+		sample.value, sample.at = value, at
+	}
+}
+
+func (s *exprSampleStore) latest(deviceID int64, field string) (exprSample, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sample, ok := s.samples[exprSampleKey{deviceID: deviceID, field: field}]
+	if !ok {
+		return exprSample{}, false
+	}
+	return *sample, true
+}
+
+// lastSeenAt 字段最近一次出现的时间；从未出现时返回开始关注的时间
+func (s *exprSampleStore) lastSeenAt(deviceID int64, field string) time.Time {
+	key := exprSampleKey{deviceID: deviceID, field: field}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if sample, ok := s.samples[key]; ok {
+		return sample.at
+	}
+	if at, ok := s.watchedAt[key]; ok {
+		return at
+	}
+	return time.Now()
+}
@@ -0,0 +1,268 @@
+package collector
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func evalThresholdExprForTest(t *testing.T, source string, fields map[string]string, samples *exprSampleStore, now time.Time) (bool, float64) {
+	t.Helper()
+	expr, err := compileThresholdExpr(source)
+	if err != nil {
+		t.Fatalf("compileThresholdExpr(%q) error = %v", source, err)
+	}
+	if samples == nil {
+		samples = newExprSampleStore()
+	}
+	lookup := newNumericFieldLookup(fields, nil)
+	defer lookup.release()
+	matched, actual, _ := expr.evaluate(&exprEnv{deviceID: 1, lookup: lookup, samples: samples, now: now})
+	return matched, actual
+}
+
+func TestCompileThresholdExpr_Errors(t *testing.T) {
+	cases := []string{
+		"",
+		"Ua",
+		"Ua + 1",
+		"Ua < 180 &&",
+		"Ua < 180 & Ia > 5",
+		"Ua in [1 2]",
+		"(Ua < 180",
+		"foo(Ua) > 1",
+		"rate(1) > 1",
+		"Ua < (Ia > 5)",
+		"#x.Ua > 1",
+		`"Ua > 1`,
+	}
+	for _, source := range cases {
+		if _, err := compileThresholdExpr(source); err == nil {
+			t.Fatalf("compileThresholdExpr(%q) expected error", source)
+		}
+	}
+}
+
+func TestThresholdExpr_BooleanCombination(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		source string
+		fields map[string]string
+		want   bool
+	}{
+		{"Ua < 180 && Ia > 5", map[string]string{"Ua": "170", "Ia": "6"}, true},
+		{"Ua < 180 && Ia > 5", map[string]string{"Ua": "170", "Ia": "4"}, false},
+		{"Ua < 180 and not (Ia <= 5)", map[string]string{"ua": "170", "IA": "6"}, true},
+		{"Ua > 250 || Ia > 5", map[string]string{"Ia": "6"}, true},
+		{"Ua > 250 || Ia > 5", map[string]string{"Ia": "1"}, false},
+		{"Ua in [198, 242]", map[string]string{"Ua": "220"}, true},
+		{"!(Ua in [198, 242])", map[string]string{"Ua": "250"}, true},
+		{"abs(Ua - Ub) > 10 && max(Ua, Ub) * 2 >= 400", map[string]string{"Ua": "220", "Ub": "205"}, true},
+		{`"A 相电压" > -1e2`, map[string]string{"A 相电压": "1"}, true},
+		{"P / Q > 1", map[string]string{"P": "1", "Q": "0"}, false},
+	}
+	for _, tc := range cases {
+		got, _ := evalThresholdExprForTest(t, tc.source, tc.fields, nil, now)
+		if got != tc.want {
+			t.Fatalf("%q with %v = %v, want %v", tc.source, tc.fields, got, tc.want)
+		}
+	}
+}
+
+func TestThresholdExpr_ActualValueUsesPrimaryField(t *testing.T) {
+	matched, actual := evalThresholdExprForTest(t, "Ua < 180 && Ia > 5", map[string]string{"Ua": "170", "Ia": "6"}, nil, time.Now())
+	if !matched || actual != 170 {
+		t.Fatalf("matched=%v actual=%v, want true 170", matched, actual)
+	}
+	field, err := ValidateThresholdExpression(" #3.Ua > 1 ")
+	if err != nil || field != "#3.Ua" {
+		t.Fatalf("ValidateThresholdExpression() = %q, %v", field, err)
+	}
+}
+
+func TestThresholdExpr_RateStaleAndCrossDevice(t *testing.T) {
+	samples := newExprSampleStore()
+	watches := make(map[int64][]exprFieldRef)
+	for _, source := range []string{"rate(P) > 10", "stale(Ua) > 60", "#2.T - T > 5"} {
+		expr, err := compileThresholdExpr(source)
+		if err != nil {
+			t.Fatalf("compile %q: %v", source, err)
+		}
+		collectExprWatches(watches, 1, expr)
+	}
+	start := time.Unix(1700000000, 0)
+	samples.replaceWatches(watches, start)
+
+	record := func(deviceID int64, fields map[string]string, at time.Time) {
+		lookup := newNumericFieldLookup(fields, nil)
+		samples.record(deviceID, lookup, at)
+		lookup.release()
+	}
+
+	record(1, map[string]string{"P": "100", "Ua": "220", "T": "20"}, start)
+	if matched, _ := evalThresholdExprForTest(t, "rate(P) > 10", nil, samples, start); matched {
+		t.Fatal("rate needs two samples")
+	}
+	record(1, map[string]string{"P": "150", "T": "20"}, start.Add(2*time.Second))
+	if matched, _ := evalThresholdExprForTest(t, "rate(P) > 10", nil, samples, start.Add(2*time.Second)); !matched {
+		t.Fatal("rate(P) = 25/s should exceed 10")
+	}
+
+	if matched, _ := evalThresholdExprForTest(t, "stale(Ua) > 60", nil, samples, start.Add(30*time.Second)); matched {
+		t.Fatal("Ua should not be stale after 30s")
+	}
+	if matched, _ := evalThresholdExprForTest(t, "stale(Ua) > 60", nil, samples, start.Add(90*time.Second)); !matched {
+		t.Fatal("Ua should be stale after 90s")
+	}
+
+	fields := map[string]string{"T": "20"}
+	if matched, _ := evalThresholdExprForTest(t, "#2.T - T > 5", fields, samples, start); matched {
+		t.Fatal("missing device 2 sample should not match")
+	}
+	record(2, map[string]string{"T": "30"}, start)
+	if matched, _ := evalThresholdExprForTest(t, "#2.T - T > 5", fields, samples, start); !matched {
+		t.Fatal("device 2 T=30 minus T=20 should exceed 5")
+	}
+}
+
+func TestCheckThresholds_ExpressionRule(t *testing.T) {
+	oldDB := database.ParamDB
+	db := setupCollectorAlarmBehaviorTestDB(t)
+	database.ParamDB = db
+	t.Cleanup(func() {
+		database.ParamDB = oldDB
+		_ = db.Close()
+	})
+
+	resetThresholdCache()
+	clearAlarmStateForDevice(1)
+	InvalidateAlarmRepeatIntervalCache()
+
+	_, err := database.CreateThreshold(&models.Threshold{
+		DeviceID:   1,
+		FieldName:  "Ua",
+		Operator:   ThresholdOperatorExpr,
+		Severity:   "critical",
+		Expression: "Ua < 180 && Ia > 5",
+	})
+	if err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+
+	collector := NewCollector(nil, nil)
+	device := &models.Device{ID: 1, Name: "d1"}
+	if err := collector.checkThresholds(device, &models.CollectData{DeviceID: 1, Fields: map[string]string{"Ua": "170", "Ia": "3"}}); err != nil {
+		t.Fatalf("checkThresholds: %v", err)
+	}
+	if got := countAlarmLogsFromDB(t, db); got != 0 {
+		t.Fatalf("expected 0 alarms, got %d", got)
+	}
+
+	if err := collector.checkThresholds(device, &models.CollectData{DeviceID: 1, Fields: map[string]string{"Ua": "170", "Ia": "6"}}); err != nil {
+		t.Fatalf("checkThresholds: %v", err)
+	}
+	var actual float64
+	var message string
+	if err := db.QueryRow(`SELECT actual_value, message FROM alarm_logs`).Scan(&actual, &message); err != nil {
+		t.Fatalf("query alarm log: %v", err)
+	}
+	if actual != 170 || message != "Ua < 180 && Ia > 5" {
+		t.Fatalf("alarm log actual=%v message=%q", actual, message)
+	}
+}
+
+func TestCheckStaleThresholds_RaisesAlarmWithoutCollection(t *testing.T) {
+	oldDB := database.ParamDB
+	db := setupCollectorAlarmBehaviorTestDB(t)
+	database.ParamDB = db
+	t.Cleanup(func() {
+		database.ParamDB = oldDB
+		_ = db.Close()
+	})
+
+	resetThresholdCache()
+	clearAlarmStateForDevice(1)
+	InvalidateAlarmRepeatIntervalCache()
+	exprSamples.replaceWatches(map[int64][]exprFieldRef{}, time.Now())
+
+	if _, err := database.CreateThreshold(&models.Threshold{
+		DeviceID:   1,
+		FieldName:  "Ua",
+		Operator:   ThresholdOperatorExpr,
+		Severity:   "warning",
+		Expression: "stale(Ua) > 60",
+	}); err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+
+	collector := NewCollector(nil, nil)
+	device := &models.Device{ID: 1, Name: "d1"}
+	collector.tasks = map[int64]*collectTask{1: {device: device}}
+
+	// 最后一次上报在 90 秒前，采集时求值不命中，此后设备不再上报
+	lastReport := time.Now().Add(-90 * time.Second)
+	if err := collector.checkThresholds(device, &models.CollectData{DeviceID: 1, Timestamp: lastReport, Fields: map[string]string{"Ua": "220"}}); err != nil {
+		t.Fatalf("checkThresholds: %v", err)
+	}
+	if got := countAlarmLogsFromDB(t, db); got != 0 {
+		t.Fatalf("expected 0 alarms after fresh sample, got %d", got)
+	}
+
+	collector.checkStaleThresholds()
+	if got := countAlarmLogsFromDB(t, db); got != 1 {
+		t.Fatalf("expected stale alarm from periodic check, got %d", got)
+	}
+
+	// 设备恢复上报后报警恢复
+	if err := collector.checkThresholds(device, &models.CollectData{DeviceID: 1, Timestamp: time.Now(), Fields: map[string]string{"Ua": "221"}}); err != nil {
+		t.Fatalf("checkThresholds: %v", err)
+	}
+	var cleared sql.NullTime
+	if err := db.QueryRow(`SELECT cleared_at FROM alarm_logs`).Scan(&cleared); err != nil {
+		t.Fatalf("query alarm log: %v", err)
+	}
+	if !cleared.Valid {
+		t.Fatal("stale alarm should clear once the field is reported again")
+	}
+}
+
+func TestCompiledThresholdExprs_InvalidatedWithThresholds(t *testing.T) {
+	retainThresholdExprs(nil)
+	t.Cleanup(func() { retainThresholdExprs(nil) })
+
+	threshold := &models.Threshold{ID: 41, DeviceID: 7, Operator: ThresholdOperatorExpr, Expression: "Ua > 1"}
+	first := buildThresholdEvalRule(threshold).expr
+	if again := buildThresholdEvalRule(threshold).expr; again != first {
+		t.Fatal("unchanged expression should reuse compiled cache")
+	}
+	threshold.Expression = "Ua > 2"
+	if updated := buildThresholdEvalRule(threshold).expr; updated == first || updated.source != "Ua > 2" {
+		t.Fatalf("updated expression compiled = %+v", updated)
+	}
+	compiledThresholdExprs.mu.Lock()
+	size := len(compiledThresholdExprs.byID)
+	compiledThresholdExprs.mu.Unlock()
+	if size != 1 {
+		t.Fatalf("cache size = %d, want 1 entry per threshold", size)
+	}
+
+	InvalidateDeviceCache(7)
+	compiledThresholdExprs.mu.Lock()
+	_, exists := compiledThresholdExprs.byID[41]
+	compiledThresholdExprs.mu.Unlock()
+	if exists {
+		t.Fatal("InvalidateDeviceCache should drop compiled expressions of the device")
+	}
+
+	buildThresholdEvalRule(threshold)
+	retainThresholdExprs(map[int64]struct{}{})
+	compiledThresholdExprs.mu.Lock()
+	size = len(compiledThresholdExprs.byID)
+	compiledThresholdExprs.mu.Unlock()
+	if size != 0 {
+		t.Fatalf("deleted threshold still cached, size = %d", size)
+	}
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// ==================== 当前报警操作 (param.db - 直接写) ====================
+
+const selectActiveAlarmFields = `SELECT device_id, threshold_id, COALESCE(alarm_log_id, 0), COALESCE(field_name, ''),
+	COALESCE(actual_value, 0), COALESCE(threshold_value, 0), COALESCE(operator, ''), COALESCE(severity, ''), COALESCE(message, ''),
+	triggered_at FROM active_alarms`
+
+// UpsertActiveAlarm 写入（或覆盖）当前报警
+func UpsertActiveAlarm(alarm *models.ActiveAlarm) error {
+	if alarm == nil {
+		return nil
+	}
+	triggeredAt := alarm.TriggeredAt
+	if triggeredAt.IsZero() {
+		triggeredAt = time.Now()
+	}
+	_, err := ParamDB.Exec(
+		`INSERT INTO active_alarms (device_id, threshold_id, alarm_log_id, field_name, actual_value, threshold_value, operator, severity, message, triggered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(device_id, threshold_id) DO UPDATE SET
+			alarm_log_id = excluded.alarm_log_id, field_name = excluded.field_name, actual_value = excluded.actual_value,
+			threshold_value = excluded.threshold_value, operator = excluded.operator, severity = excluded.severity,
+			message = excluded.message, triggered_at = excluded.triggered_at`,
+		alarm.DeviceID, alarm.ThresholdID, alarm.AlarmLogID, alarm.FieldName, alarm.ActualValue, alarm.ThresholdValue,
+		alarm.Operator, alarm.Severity, alarm.Message, triggeredAt,
+	)
+	return err
+}
+
+// DeleteActiveAlarm 删除当前报警（报警恢复）
+func DeleteActiveAlarm(deviceID, thresholdID int64) error {
+	_, err := ParamDB.Exec("DELETE FROM active_alarms WHERE device_id = ? AND threshold_id = ?", deviceID, thresholdID)
+	return err
+}
+
+// ListActiveAlarms 获取全部当前报警（按触发时间倒序）
+func ListActiveAlarms() ([]*models.ActiveAlarm, error) {
+	return listActiveAlarms(selectActiveAlarmFields+" ORDER BY triggered_at DESC", nil)
+}
+
+// ListActiveAlarmsByDevice 获取设备的当前报警
+func ListActiveAlarmsByDevice(deviceID int64) ([]*models.ActiveAlarm, error) {
+	return listActiveAlarms(selectActiveAlarmFields+" WHERE device_id = ?", []any{deviceID})
+}
+
+// ListActiveAlarmsByThreshold 获取阈值的当前报警
+func ListActiveAlarmsByThreshold(thresholdID int64) ([]*models.ActiveAlarm, error) {
+	return listActiveAlarms(selectActiveAlarmFields+" WHERE threshold_id = ?", []any{thresholdID})
+}
+
+func listActiveAlarms(query string, args []any) ([]*models.ActiveAlarm, error) {
+	return queryList[*models.ActiveAlarm](ParamDB, query, args, func(rows *sql.Rows) (*models.ActiveAlarm, error) {
+		alarm := &models.ActiveAlarm{}
+		if err := rows.Scan(
+			&alarm.DeviceID,
+			&alarm.ThresholdID,
+			&alarm.AlarmLogID,
+			&alarm.FieldName,
+			&alarm.ActualValue,
+			&alarm.ThresholdValue,
+			&alarm.Operator,
+			&alarm.Severity,
+			&alarm.Message,
+			&alarm.TriggeredAt,
+		); err != nil {
+			return nil, err
+		}
+		return alarm, nil
+	})
+}
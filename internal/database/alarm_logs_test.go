@@ -49,6 +49,10 @@ func setupAlarmLogsTestDB(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create alarm_logs table: %v", err)
 	}
+	// 旧版表结构，cleared_at 由 param 迁移补齐
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
 }
 
 func insertAlarmLogRow(t *testing.T, acknowledgedBy any, acknowledgedAt any) int64 {
@@ -194,7 +198,7 @@ func TestScanAlarmLog(t *testing.T) {
 	scanner := stubAlarmLogScanner{
 		values: []any{
 			int64(1), int64(2), thresholdID, "temperature", 101.1, 99.9, ">", "high", "too hot",
-			now, 1, "admin", acknowledgedAt,
+			now, 1, "admin", acknowledgedAt, nil,
 		},
 	}
 
@@ -219,7 +223,7 @@ func TestScanAlarmLog_AllowsNilOptionalFields(t *testing.T) {
 	scanner := stubAlarmLogScanner{
 		values: []any{
 			int64(1), int64(2), nil, "temperature", 101.1, 99.9, ">", "high", "too hot",
-			now, 0, "", nil,
+			now, 0, "", nil, nil,
 		},
 	}
 
@@ -11,7 +11,7 @@ import (
 // ==================== 报警日志操作 (param.db - 直接写) ====================
 
 const selectAlarmLogFields = `SELECT id, device_id, threshold_id, field_name, actual_value, threshold_value, operator, severity, message,
-	triggered_at, acknowledged, COALESCE(acknowledged_by, ''), acknowledged_at FROM alarm_logs`
+	triggered_at, acknowledged, COALESCE(acknowledged_by, ''), acknowledged_at, cleared_at FROM alarm_logs`
 
 // CreateAlarmLog 创建报警日志
 func CreateAlarmLog(log *models.AlarmLog) (int64, error) {
@@ -51,6 +51,12 @@ func AcknowledgeAlarmLog(id int64, acknowledgedBy string) error {
 	return err
 }
 
+// MarkAlarmLogCleared 记录报警恢复时间
+func MarkAlarmLogCleared(id int64, clearedAt time.Time) error {
+	_, err := ParamDB.Exec("UPDATE alarm_logs SET cleared_at = ? WHERE id = ? AND cleared_at IS NULL", clearedAt, id)
+	return err
+}
+
 // DeleteAlarmLog 删除单条报警日志
 func DeleteAlarmLog(id int64) error {
 	_, err := ParamDB.Exec("DELETE FROM alarm_logs WHERE id = ?", id)
@@ -123,5 +129,6 @@ func scanAlarmLog(scanner alarmLogScanner, log *models.AlarmLog) error {
 		&log.Acknowledged,
 		&log.AcknowledgedBy,
 		&log.AcknowledgedAt,
+		&log.ClearedAt,
 	)
 }
@@ -0,0 +1,775 @@
+package database
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/migrations"
+	"modernc.org/sqlite"
+)
+
+// 备份归档：<backupDir>/fsu-backup-<UTC 时间>.tar.gz，内含 manifest.json、param.db、data.db；
+// 同目录的 <归档名>.sha256 为 sha256sum 格式的整包校验和，可直接 sha256sum -c 校验
+
+const (
+	DefaultBackupDir  = "backups" // 默认备份目录
+	DefaultBackupKeep = 7         // 默认保留备份份数
+
+	BackupFilePrefix = "fsu-backup-"
+	BackupFileExt    = ".tar.gz"
+
+	backupChecksumExt  = ".sha256"
+	backupManifestName = "manifest.json"
+	backupParamEntry   = "param.db"
+	backupDataEntry    = "data.db"
+	backupTimeLayout   = "20060102T150405.000Z"
+	// backupStartupDelay 启动后首次定时备份的最短等待，避开开机时的采集与同步高峰
+	backupStartupDelay = time.Minute
+	// restoreBusyTimeout 在线恢复 param.db 时等待其他连接释放锁的最长时间
+	restoreBusyTimeout = 5 * time.Second
+)
+
+// sqliteSidecarSuffixes SQLite 与库文件同名的附属文件（回滚日志、WAL、共享内存索引）
+var sqliteSidecarSuffixes = []string{"-journal", "-wal", "-shm"}
+
+var (
+	ErrBackupNotFound  = errors.New("backup not found")
+	ErrBackupCorrupted = errors.New("backup corrupted")
+)
+
+// BackupFile 归档内单个数据库文件的校验信息
+type BackupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BackupManifest 归档清单（manifest.json）
+type BackupManifest struct {
+	CreatedAt time.Time    `json:"created_at"`
+	Files     []BackupFile `json:"files"`
+}
+
+// BackupInfo 备份归档概要
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var backupMu sync.Mutex
+var backupDir = DefaultBackupDir
+var backupKeep = DefaultBackupKeep
+
+var backupControlMu sync.Mutex
+var backupTimer *time.Timer
+var backupStop chan struct{}
+
+// ApplyBackupConfig 应用备份目录与保留份数，非法值回退到默认值
+func ApplyBackupConfig(dir string, keep int) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	if strings.TrimSpace(dir) == "" {
+		dir = DefaultBackupDir
+	}
+	if keep <= 0 {
+		keep = DefaultBackupKeep
+	}
+	backupDir = dir
+	backupKeep = keep
+	slog.Info("Applied backup config", "dir", backupDir, "keep", backupKeep)
+}
+
+// StartBackupSchedule 启动定时备份；interval<=0 时不启动。
+// 首次备份按最近一份备份的时间补齐周期，重启不会打乱备份节奏
+func StartBackupSchedule(interval time.Duration) {
+	if interval <= 0 {
+		slog.Info("Backup schedule disabled")
+		return
+	}
+	backupControlMu.Lock()
+	defer backupControlMu.Unlock()
+	if backupTimer != nil {
+		return
+	}
+
+	delay := backupStartupDelay
+	if backups, err := ListBackups(); err == nil && len(backups) > 0 {
+		if remaining := interval - time.Since(backups[0].CreatedAt); remaining > delay {
+			delay = remaining
+		}
+	}
+	backupStop = make(chan struct{})
+	backupTimer = time.NewTimer(delay)
+	timer, stop := backupTimer, backupStop
+
+	go func() {
+		slog.Info("Backup schedule started", "interval", interval, "first_run_in", delay)
+		for {
+			select {
+			case <-timer.C:
+				if info, err := CreateBackup(); err != nil {
+					slog.Error("Scheduled backup failed", "error", err)
+				} else {
+					slog.Info("Scheduled backup created", "name", info.Name, "size", info.Size)
+				}
+				timer.Reset(interval)
+			case <-stop:
+				slog.Info("Backup schedule stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopBackupSchedule 停止定时备份
+func StopBackupSchedule() {
+	backupControlMu.Lock()
+	defer backupControlMu.Unlock()
+	if backupTimer != nil {
+		backupTimer.Stop()
+		backupTimer = nil
+	}
+	if backupStop != nil {
+		close(backupStop)
+		backupStop = nil
+	}
+}
+
+// CreateBackup 在线备份 param.db 与磁盘 data.db（VACUUM INTO），打包后按保留份数轮转
+func CreateBackup() (*BackupInfo, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	if ParamDB == nil {
+		return nil, fmt.Errorf("param database is not initialized")
+	}
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup dir: %w", err)
+	}
+	staging, err := os.MkdirTemp(backupDir, ".staging-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	createdAt := time.Now().UTC()
+	entries := []string{backupParamEntry}
+	if err := vacuumInto(ParamDB, filepath.Join(staging, backupParamEntry)); err != nil {
+		return nil, fmt.Errorf("failed to snapshot param database: %w", err)
+	}
+	hasData, err := snapshotDataDisk(filepath.Join(staging, backupDataEntry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot data database: %w", err)
+	}
+	if hasData {
+		entries = append(entries, backupDataEntry)
+	}
+
+	manifest := &BackupManifest{CreatedAt: createdAt}
+	for _, entry := range entries {
+		file, err := describeBackupFile(filepath.Join(staging, entry), entry)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	name := nextBackupName(createdAt)
+	archivePath := filepath.Join(backupDir, name)
+	sum, size, err := writeBackupArchive(archivePath, staging, manifest)
+	if err != nil {
+		return nil, err
+	}
+	checksum := fmt.Sprintf("%s  %s\n", sum, name)
+	if err := os.WriteFile(archivePath+backupChecksumExt, []byte(checksum), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write backup checksum: %w", err)
+	}
+
+	if removed, err := rotateBackups(backupKeep); err != nil {
+		slog.Warn("Failed to rotate backups", "error", err)
+	} else if removed > 0 {
+		slog.Info("Rotated old backups", "removed", removed, "keep", backupKeep)
+	}
+	return &BackupInfo{Name: name, Size: size, SHA256: sum, CreatedAt: createdAt}, nil
+}
+
+// ListBackups 列出备份目录中的归档（新的在前）
+func ListBackups() ([]*BackupInfo, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	return listBackups()
+}
+
+// GetBackup 获取单个备份归档概要
+func GetBackup(name string) (*BackupInfo, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	return statBackup(name)
+}
+
+// OpenBackup 打开备份归档供下载，调用方负责关闭
+func OpenBackup(name string) (*os.File, *BackupInfo, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	info, err := statBackup(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(filepath.Join(backupDir, name))
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// RestoreBackup 校验并恢复备份：在线写回 param.db 并升级 schema，替换磁盘 data.db。
+// 归档校验、解包与 schema 版本检查全部通过后才调用 beforeSwap（用于停止采集与北向），其返回错误时不做任何替换；
+// 被替换的文件保留为 <文件名>.pre-restore 以便人工回退，内存中尚未落盘的历史数据会在下次同步时写入恢复后的 data.db
+func RestoreBackup(name string, beforeSwap func() error) (*BackupManifest, error) {
+	backupMu.Lock()
+	defer backupMu.Unlock()
+
+	info, err := statBackup(name)
+	if err != nil {
+		return nil, err
+	}
+	archivePath := filepath.Join(backupDir, name)
+	if info.SHA256 != "" {
+		sum, err := sha256File(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		if sum != info.SHA256 {
+			return nil, fmt.Errorf("%w: archive sha256 %s, want %s", ErrBackupCorrupted, sum, info.SHA256)
+		}
+	}
+
+	staging, err := os.MkdirTemp(backupDir, ".restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	manifest, err := extractBackupArchive(archivePath, staging)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range manifest.Files {
+		if err := checkStagedSchema(filepath.Join(staging, file.Name), file.Name); err != nil {
+			return nil, err
+		}
+	}
+	if beforeSwap != nil {
+		if err := beforeSwap(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range manifest.Files {
+		staged := filepath.Join(staging, file.Name)
+		switch file.Name {
+		case backupParamEntry:
+			err = restoreParamDBFile(staged)
+		case backupDataEntry:
+			err = restoreDataDBFile(staged)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	slog.Info("Backup restored", "name", name, "created_at", manifest.CreatedAt)
+	return manifest, nil
+}
+
+func vacuumInto(db *sql.DB, path string) error {
+	_, err := db.Exec("VACUUM INTO ?", path)
+	return err
+}
+
+// snapshotDataDisk 先把内存数据落盘，再在同步锁内对磁盘 data.db 做快照；磁盘库不存在时返回 false
+func snapshotDataDisk(dst string) (bool, error) {
+	if dataDBFile == "" {
+		return false, nil
+	}
+	if DataDB != nil {
+		if err := syncDataToDisk(); err != nil {
+			slog.Warn("Failed to sync data before backup", "error", err)
+		}
+	}
+
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	if _, err := os.Stat(dataDBFile); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	db, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	return true, vacuumInto(db, dst)
+}
+
+func nextBackupName(at time.Time) string {
+	for {
+		name := BackupFilePrefix + at.Format(backupTimeLayout) + BackupFileExt
+		if _, err := os.Stat(filepath.Join(backupDir, name)); os.IsNotExist(err) {
+			return name
+		}
+		at = at.Add(time.Millisecond)
+	}
+}
+
+func parseBackupName(name string) (time.Time, bool) {
+	if name != filepath.Base(name) || !strings.HasPrefix(name, BackupFilePrefix) || !strings.HasSuffix(name, BackupFileExt) {
+		return time.Time{}, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, BackupFilePrefix), BackupFileExt)
+	at, err := time.Parse(backupTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at.UTC(), true
+}
+
+func statBackup(name string) (*BackupInfo, error) {
+	createdAt, ok := parseBackupName(name)
+	if !ok {
+		return nil, ErrBackupNotFound
+	}
+	path := filepath.Join(backupDir, name)
+	stat, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBackupNotFound
+		}
+		return nil, err
+	}
+	return &BackupInfo{Name: name, Size: stat.Size(), SHA256: readBackupChecksum(path), CreatedAt: createdAt}, nil
+}
+
+func listBackups() ([]*BackupInfo, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*BackupInfo{}, nil
+		}
+		return nil, err
+	}
+	backups := make([]*BackupInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := parseBackupName(entry.Name()); !ok {
+			continue
+		}
+		info, err := statBackup(entry.Name())
+		if err != nil {
+			continue
+		}
+		backups = append(backups, info)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+func rotateBackups(keep int) (int, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for i := keep; i < len(backups); i++ {
+		path := filepath.Join(backupDir, backups[i].Name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		_ = os.Remove(path + backupChecksumExt)
+		removed++
+	}
+	return removed, nil
+}
+
+// readBackupChecksum 读取 sha256sum 格式的校验文件，缺失或格式不符时返回空串
+func readBackupChecksum(archivePath string) string {
+	data, err := os.ReadFile(archivePath + backupChecksumExt)
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+func describeBackupFile(path, name string) (BackupFile, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return BackupFile{}, err
+	}
+	sum, err := sha256File(path)
+	if err != nil {
+		return BackupFile{}, err
+	}
+	return BackupFile{Name: name, Size: stat.Size(), SHA256: sum}, nil
+}
+
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeBackupArchive 先写临时文件再改名，返回归档的 sha256 与大小
+func writeBackupArchive(archivePath, staging string, manifest *BackupManifest) (string, int64, error) {
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", 0, err
+	}
+
+	tmpPath := archivePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create backup archive: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	buffered := bufio.NewWriter(io.MultiWriter(out, hash, counter))
+	gz := gzip.NewWriter(buffered)
+	tw := tar.NewWriter(gz)
+
+	writeErr := func() error {
+		header := &tar.Header{Name: backupManifestName, Mode: 0o644, Size: int64(len(manifestData)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(manifestData); err != nil {
+			return err
+		}
+		for _, file := range manifest.Files {
+			if err := appendTarFile(tw, filepath.Join(staging, file.Name), file, manifest.CreatedAt); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		return buffered.Flush()
+	}()
+	if writeErr == nil {
+		writeErr = out.Sync()
+	}
+	if closeErr := out.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		return "", 0, fmt.Errorf("failed to write backup archive: %w", writeErr)
+	}
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		return "", 0, fmt.Errorf("failed to finalize backup archive: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), counter.n, nil
+}
+
+func appendTarFile(tw *tar.Writer, path string, file BackupFile, modTime time.Time) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	header := &tar.Header{Name: file.Name, Mode: 0o644, Size: file.Size, ModTime: modTime}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// extractBackupArchive 解包到 staging 并按清单逐个校验大小与 sha256
+func extractBackupArchive(archivePath, staging string) (*BackupManifest, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBackupCorrupted, err)
+	}
+	defer gz.Close()
+
+	var manifest *BackupManifest
+	extracted := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBackupCorrupted, err)
+		}
+		switch header.Name {
+		case backupManifestName:
+			manifest = &BackupManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("%w: invalid manifest: %v", ErrBackupCorrupted, err)
+			}
+		case backupParamEntry, backupDataEntry:
+			sum, err := extractTarFile(tr, filepath.Join(staging, header.Name))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrBackupCorrupted, err)
+			}
+			extracted[header.Name] = sum
+		default:
+			return nil, fmt.Errorf("%w: unexpected entry %q", ErrBackupCorrupted, header.Name)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: manifest missing", ErrBackupCorrupted)
+	}
+	hasParam := false
+	for _, want := range manifest.Files {
+		sum, ok := extracted[want.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s missing", ErrBackupCorrupted, want.Name)
+		}
+		if sum != want.SHA256 {
+			return nil, fmt.Errorf("%w: %s sha256 %s, want %s", ErrBackupCorrupted, want.Name, sum, want.SHA256)
+		}
+		hasParam = hasParam || want.Name == backupParamEntry
+	}
+	if !hasParam {
+		return nil, fmt.Errorf("%w: %s missing", ErrBackupCorrupted, backupParamEntry)
+	}
+	return manifest, nil
+}
+
+func extractTarFile(r io.Reader, path string) (string, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checkStagedSchema 拒绝 schema 版本高于当前程序的备份（新版本程序生成的备份），避免替换后无法启动
+func checkStagedSchema(path, entry string) error {
+	dir := migrationSetParam
+	if entry == backupDataEntry {
+		dir = migrationSetDataDisk
+	}
+	list, err := loadSchemaMigrations(migrations.FS, dir)
+	if err != nil {
+		return err
+	}
+	db, err := openSQLite(path, 1, 1)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrBackupCorrupted, entry, err)
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&tables); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrBackupCorrupted, entry, err)
+	}
+	if tables == 0 {
+		return nil
+	}
+	version, err := schemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrBackupCorrupted, entry, err)
+	}
+	if latest := list[len(list)-1].version; version > latest {
+		return fmt.Errorf("%w: %s in backup has schema version %d, binary supports up to %d", ErrSchemaTooNew, entry, version, latest)
+	}
+	return nil
+}
+
+// restoreParamDBFile 经 SQLite 在线备份接口把暂存库写回正在使用的 param.db：
+// ParamDB 句柄始终不变，并发请求最多等待页拷贝完成，不会用到已关闭的连接
+func restoreParamDBFile(staged string) error {
+	if ParamDB == nil {
+		return fmt.Errorf("param database is not initialized")
+	}
+	previous := paramDBFile + ".pre-restore"
+	if err := os.Remove(previous); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old %s: %w", filepath.Base(previous), err)
+	}
+	if err := vacuumInto(ParamDB, previous); err != nil {
+		return fmt.Errorf("failed to keep previous param.db: %w", err)
+	}
+	if err := restoreSQLiteInPlace(ParamDB, staged); err != nil {
+		return fmt.Errorf("failed to restore param.db: %w", err)
+	}
+
+	if err := InitParamSchema(); err != nil {
+		return fmt.Errorf("failed to migrate restored param database: %w", err)
+	}
+	return InitGatewayConfigTable()
+}
+
+// sqliteRestorer modernc 驱动连接提供的在线恢复（sqlite3_backup，源为文件、目标为该连接的 main 库）
+type sqliteRestorer interface {
+	NewRestore(srcURI string) (*sqlite.Backup, error)
+}
+
+// restoreSQLiteInPlace 把 src 整库拷贝进 db；其他连接持锁时按 SQLite 约定稍后重试
+func restoreSQLiteInPlace(db *sql.DB, src string) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		restorer, ok := driverConn.(sqliteRestorer)
+		if !ok {
+			return fmt.Errorf("sqlite driver %T does not support online restore", driverConn)
+		}
+		backup, err := restorer.NewRestore(src)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(restoreBusyTimeout)
+		for {
+			more, err := backup.Step(-1)
+			if err != nil && isSQLiteBusyError(err) && time.Now().Before(deadline) {
+				time.Sleep(20 * time.Millisecond)
+				continue
+			}
+			if err != nil {
+				_ = backup.Finish()
+				return err
+			}
+			if !more {
+				return backup.Finish()
+			}
+		}
+	})
+}
+
+func isSQLiteBusyError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "busy") || strings.Contains(msg, "locked")
+}
+
+func restoreDataDBFile(staged string) error {
+	if dataDBFile == "" {
+		return fmt.Errorf("data db path is empty")
+	}
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	closeCachedDataDiskDBForPath("")
+	CloseNorthboundSpool()
+	return replaceDatabaseFile(staged, dataDBFile)
+}
+
+// replaceDatabaseFile 复制到目标目录后原子改名（备份目录可能在另一块盘上，不能直接 rename）；
+// 旧库连同未检查点的 WAL / 热日志一起改名为 .pre-restore，保证保留的旧库完整，也不会被新库误用
+func replaceDatabaseFile(src, dst string) error {
+	tmp := dst + ".restore"
+	if err := copyFileSync(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to stage %s: %w", filepath.Base(dst), err)
+	}
+	previous := dst + ".pre-restore"
+	if _, err := os.Stat(dst); err == nil {
+		for _, suffix := range sqliteSidecarSuffixes {
+			_ = os.Remove(previous + suffix)
+		}
+		if err := os.Rename(dst, previous); err != nil {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("failed to keep previous %s: %w", filepath.Base(dst), err)
+		}
+		for _, suffix := range sqliteSidecarSuffixes {
+			if err := os.Rename(dst+suffix, previous+suffix); err != nil && !os.IsNotExist(err) {
+				_ = os.Remove(tmp)
+				return fmt.Errorf("failed to keep previous %s%s: %w", filepath.Base(dst), suffix, err)
+			}
+		}
+	}
+	for _, suffix := range sqliteSidecarSuffixes {
+		_ = os.Remove(dst + suffix)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(dst), err)
+	}
+	return nil
+}
+
+func copyFileSync(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func setupBackupTestDBs(t *testing.T) string {
+	t.Helper()
+	prepareRollupDiskDB(t)
+	prepareParamSchemaTestDB(t)
+	if _, err := ParamDB.Exec(`CREATE TABLE backup_probe (v TEXT); INSERT INTO backup_probe (v) VALUES ('original')`); err != nil {
+		t.Fatalf("seed param db: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "usb")
+	ApplyBackupConfig(dir, 2)
+	t.Cleanup(func() { ApplyBackupConfig(DefaultBackupDir, DefaultBackupKeep) })
+	return dir
+}
+
+func countDiskDataPoints(t *testing.T) int {
+	t.Helper()
+	db, err := openSQLite(dataDBFile, 1, 1)
+	if err != nil {
+		t.Fatalf("open disk db: %v", err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM data_points").Scan(&count); err != nil {
+		t.Fatalf("count disk rows: %v", err)
+	}
+	return count
+}
+
+func TestBackup_CreateRestoreAndRotate(t *testing.T) {
+	dir := setupBackupTestDBs(t)
+	disk, err := openSQLite(dataDBFile, 1, 1)
+	if err != nil {
+		t.Fatalf("open disk db: %v", err)
+	}
+	defer disk.Close()
+	if _, err := disk.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, collected_at) VALUES (1, 'd', 'f', '1', '2026-01-01 00:00:00')`); err != nil {
+		t.Fatalf("seed disk db: %v", err)
+	}
+
+	info, err := CreateBackup()
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+	if sum, _ := sha256File(filepath.Join(dir, info.Name)); sum != info.SHA256 || readBackupChecksum(filepath.Join(dir, info.Name)) != sum {
+		t.Fatalf("checksum sidecar mismatch: info=%s file=%s", info.SHA256, sum)
+	}
+
+	if _, err := ParamDB.Exec(`UPDATE backup_probe SET v = 'changed'`); err != nil {
+		t.Fatalf("update param db: %v", err)
+	}
+	if _, err := disk.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, collected_at) VALUES (1, 'd', 'f', '2', '2026-01-02 00:00:00')`); err != nil {
+		t.Fatalf("update disk db: %v", err)
+	}
+
+	manifest, err := RestoreBackup(info.Name, nil)
+	if err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("manifest files = %+v", manifest.Files)
+	}
+	var v string
+	if err := ParamDB.QueryRow(`SELECT v FROM backup_probe`).Scan(&v); err != nil || v != "original" {
+		t.Fatalf("restored param value = %q, %v", v, err)
+	}
+	if got := countDiskDataPoints(t); got != 1 {
+		t.Fatalf("restored disk rows = %d, want 1", got)
+	}
+	if _, err := os.Stat(paramDBFile + ".pre-restore"); err != nil {
+		t.Fatalf("previous param.db not kept: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := CreateBackup(); err != nil {
+			t.Fatalf("CreateBackup() error = %v", err)
+		}
+	}
+	backups, err := ListBackups()
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("ListBackups() = %d, %v; want 2 after rotation", len(backups), err)
+	}
+	if backups[0].Name == info.Name || backups[1].Name == info.Name {
+		t.Fatalf("oldest backup not rotated: %+v", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, info.Name+backupChecksumExt)); !os.IsNotExist(err) {
+		t.Fatalf("rotated checksum file left behind: %v", err)
+	}
+}
+
+func TestBackup_RestoreRejectsCorruptedAndUnknown(t *testing.T) {
+	dir := setupBackupTestDBs(t)
+	info, err := CreateBackup()
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+
+	sidecar := filepath.Join(dir, info.Name+backupChecksumExt)
+	bad := "0000000000000000000000000000000000000000000000000000000000000000  " + info.Name + "\n"
+	if err := os.WriteFile(sidecar, []byte(bad), 0o644); err != nil {
+		t.Fatalf("write sidecar: %v", err)
+	}
+	swapped := false
+	if _, err := RestoreBackup(info.Name, func() error { swapped = true; return nil }); !errors.Is(err, ErrBackupCorrupted) || swapped {
+		t.Fatalf("RestoreBackup() error = %v, swapped = %v; want ErrBackupCorrupted before swap", err, swapped)
+	}
+
+	for _, name := range []string{"../param.db", "fsu-backup-x.tar.gz", "fsu-backup-20260101T000000.000Z.tar.gz"} {
+		if _, err := RestoreBackup(name, nil); !errors.Is(err, ErrBackupNotFound) {
+			t.Fatalf("RestoreBackup(%q) error = %v, want ErrBackupNotFound", name, err)
+		}
+	}
+}
+
+func TestBackup_RestoreWhileParamDBInUse(t *testing.T) {
+	setupBackupTestDBs(t)
+	info, err := CreateBackup()
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+	if _, err := ParamDB.Exec(`UPDATE backup_probe SET v = 'changed'`); err != nil {
+		t.Fatalf("update param db: %v", err)
+	}
+
+	// 恢复期间 HTTP 等调用方仍在使用 ParamDB
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				var v string
+				_ = ParamDB.QueryRow(`SELECT v FROM backup_probe`).Scan(&v)
+			}
+		}()
+	}
+	_, err = RestoreBackup(info.Name, nil)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("RestoreBackup() error = %v", err)
+	}
+
+	var v string
+	if err := ParamDB.QueryRow(`SELECT v FROM backup_probe`).Scan(&v); err != nil || v != "original" {
+		t.Fatalf("restored param value = %q, %v", v, err)
+	}
+	previous, err := openSQLite(paramDBFile+".pre-restore", 1, 1)
+	if err != nil {
+		t.Fatalf("open pre-restore copy: %v", err)
+	}
+	defer previous.Close()
+	if err := previous.QueryRow(`SELECT v FROM backup_probe`).Scan(&v); err != nil || v != "changed" {
+		t.Fatalf("pre-restore param value = %q, %v", v, err)
+	}
+}
+
+func TestBackup_RestoreRejectsNewerSchema(t *testing.T) {
+	setupBackupTestDBs(t)
+	if _, err := ParamDB.Exec(`INSERT INTO schema_migrations (version, name) VALUES (9999, '9999_future.sql')`); err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+	info, err := CreateBackup()
+	if err != nil {
+		t.Fatalf("CreateBackup() error = %v", err)
+	}
+	if _, err := ParamDB.Exec(`DELETE FROM schema_migrations WHERE version = 9999`); err != nil {
+		t.Fatalf("delete future version: %v", err)
+	}
+
+	swapped := false
+	if _, err := RestoreBackup(info.Name, func() error { swapped = true; return nil }); !errors.Is(err, ErrSchemaTooNew) || swapped {
+		t.Fatalf("RestoreBackup() error = %v, swapped = %v; want ErrSchemaTooNew before swap", err, swapped)
+	}
+	if _, err := os.Stat(paramDBFile + ".pre-restore"); !os.IsNotExist(err) {
+		t.Fatalf("param.db replaced despite newer schema: %v", err)
+	}
+}
+
+func TestReplaceDatabaseFile_KeepsSidecarsWithPreviousFile(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "staged.db"), filepath.Join(dir, "data.db")
+	for path, content := range map[string]string{src: "new", dst: "old", dst + "-wal": "old-wal", dst + ".pre-restore-wal": "stale"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	if err := replaceDatabaseFile(src, dst); err != nil {
+		t.Fatalf("replaceDatabaseFile() error = %v", err)
+	}
+	for path, want := range map[string]string{dst: "new", dst + ".pre-restore": "old", dst + ".pre-restore-wal": "old-wal"} {
+		if got, err := os.ReadFile(path); err != nil || string(got) != want {
+			t.Fatalf("%s = %q, %v; want %q", filepath.Base(path), got, err, want)
+		}
+	}
+	if _, err := os.Stat(dst + "-wal"); !os.IsNotExist(err) {
+		t.Fatalf("old WAL left next to restored file: %v", err)
+	}
+}
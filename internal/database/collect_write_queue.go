@@ -17,6 +17,10 @@ const (
 type collectWriteRequest struct {
 	data         *models.CollectData
 	storeHistory bool
+	// history 非空时历史只写入这部分字段（如死区筛选后的子集），data 仍全部写入实时缓存
+	history *models.CollectData
+	// journal 入队时已追加日志并持有写入闸门，写入提交后由写入协程释放
+	journal *dataJournal
 }
 
 var (
@@ -54,28 +58,62 @@ func StopCollectDataWriter() {
 }
 
 func EnqueueCollectDataWrite(data *models.CollectData, storeHistory bool) error {
+	var history *models.CollectData
+	if storeHistory {
+		history = data
+	}
+	return EnqueueCollectDataWriteWithHistory(data, history)
+}
+
+// EnqueueCollectDataWriteWithHistory 以一个写入请求把 data 全部字段写入实时缓存、history 写入历史；
+// history 为 data 本身时等同 EnqueueCollectDataWrite(data, true)，为 nil 时只写实时缓存
+func EnqueueCollectDataWriteWithHistory(data, history *models.CollectData) error {
 	if data == nil {
 		return nil
 	}
+	if history != nil && !suppressHistoryOnDiskEmergency(history, true) {
+		history = nil
+	}
 
 	collectWriteMu.RLock()
 	ch := collectWriteCh
 	running := collectWriteAlive
 	if !running || ch == nil {
 		collectWriteMu.RUnlock()
-		return InsertCollectDataWithOptions(data, storeHistory)
+		return insertCollectDataWithHistory(data, history)
 	}
 
+	// 历史行在返回前追加日志，排队中尚未提交的行断电后也能回放；
+	// 闸门读锁随请求移交写入协程，同步封存的日志段因此不会包含尚未提交到内存库的行
+	item := collectWriteRequest{data: data, storeHistory: history != nil}
+	if history != nil && history != data {
+		item.history = history
+	}
+	if item.storeHistory {
+		item.journal = beginDataJournalWrite()
+		item.journal.appendCollectData(history)
+	}
 	select {
-	case ch <- collectWriteRequest{data: data, storeHistory: storeHistory}:
+	case ch <- item:
 		collectWriteMu.RUnlock()
 		return nil
 	default:
 		collectWriteMu.RUnlock()
-		return InsertCollectDataWithOptions(data, storeHistory)
+		return insertQueuedCollectData(&item)
 	}
 }
 
+// historyData 请求需写入历史的数据，不写历史时为 nil
+func (item *collectWriteRequest) historyData() *models.CollectData {
+	if !item.storeHistory {
+		return nil
+	}
+	if item.history != nil {
+		return item.history
+	}
+	return item.data
+}
+
 func runCollectDataWriter(ch <-chan collectWriteRequest) {
 	defer collectWriteWG.Done()
 
@@ -112,52 +150,71 @@ func flushCollectDataBatch(batch []collectWriteRequest) {
 	}
 	if err := writeCollectDataBatch(batch); err != nil {
 		slog.Error("collect data async batch write failed", "error", err)
-		for _, item := range batch {
-			if err := InsertCollectDataWithOptions(item.data, item.storeHistory); err != nil {
+		for i := range batch {
+			if err := insertQueuedCollectData(&batch[i]); err != nil {
 				slog.Error("collect data fallback write failed", "error", err)
 			}
 		}
 	}
 }
 
+// insertQueuedCollectData 单独写入一个已追加日志的请求，提交后释放其写入闸门
+func insertQueuedCollectData(item *collectWriteRequest) error {
+	historyRows, err := insertCollectDataRows(item.data, item.historyData())
+	releaseCollectWriteJournal(item)
+	if err != nil {
+		return err
+	}
+	finishCollectDataWrite(historyRows)
+	return nil
+}
+
+func releaseCollectWriteJournal(item *collectWriteRequest) {
+	item.journal.endWrite()
+	item.journal = nil
+}
+
+// writeCollectDataBatch 批量写入并释放各请求的写入闸门；返回错误时由调用方逐条回退写入，尚未释放的闸门随之释放
 func writeCollectDataBatch(items []collectWriteRequest) error {
 	if len(items) == 0 {
 		return nil
 	}
 	if len(items) == 1 {
-		return InsertCollectDataWithOptions(items[0].data, items[0].storeHistory)
+		return insertQueuedCollectData(&items[0])
 	}
-	if err := writeCollectDataCacheOnlyBatchDirect(items); err == nil {
-		maybeEnforceDataCacheLimit()
-		return nil
+	historyRows := 0
+	if err := writeCollectDataCacheOnlyBatchDirect(items); err != nil {
+		if historyRows, err = writeCollectDataBatchTx(items); err != nil {
+			return err
+		}
 	}
+	for i := range items {
+		releaseCollectWriteJournal(&items[i])
+	}
+	finishCollectDataWrite(historyRows)
+	return nil
+}
 
+func writeCollectDataBatchTx(items []collectWriteRequest) (int, error) {
 	tx, err := DataDB.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin collect data batch transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin collect data batch transaction: %w", err)
 	}
 	defer tx.Rollback()
 
 	var historyRows int
 	if err := writeCollectDataBatchItemsWithTx(tx, items, &historyRows); err != nil {
-		return err
+		return 0, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit collect data batch transaction: %w", err)
+		return 0, fmt.Errorf("failed to commit collect data batch transaction: %w", err)
 	}
-
-	maybeEnforceDataCacheLimit()
-	if historyRows > 0 {
-		noteHistoryRowsWritten(historyRows)
-		maybeEnforceDataPointsLimit()
-		TriggerSyncIfNeeded()
-	}
-	return nil
+	return historyRows, nil
 }
 
 func writeCollectDataCacheOnlyBatchDirect(items []collectWriteRequest) error {
-	args := getCollectDataArgs(collectDataCacheBatchSize * 3)
+	args := getCollectDataArgs(collectDataCacheBatchSize * 5)
 	defer putCollectDataArgs(args)
 	batchRows := 0
 
@@ -165,7 +222,7 @@ func writeCollectDataCacheOnlyBatchDirect(items []collectWriteRequest) error {
 		if batchRows == 0 {
 			return nil
 		}
-		stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheStringBatchSQLCache.get(batchRows))
+		stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheBatchSQLCache.get(batchRows))
 		if err != nil {
 			return fmt.Errorf("failed to prepare data cache batch statement: %w", err)
 		}
@@ -208,7 +265,7 @@ func writeCollectDataBatchItemsWithTx(tx *sql.Tx, items []collectWriteRequest, h
 	if tx == nil {
 		return fmt.Errorf("nil tx")
 	}
-	cacheStmtCache := newCollectDataStmtCache(tx, collectDataCacheStringBatchSQLCache.get)
+	cacheStmtCache := newCollectDataStmtCache(tx, collectDataCacheBatchSQLCache.get)
 	defer cacheStmtCache.close()
 
 	var historyStmtCache *collectDataStmtCache
@@ -219,9 +276,9 @@ func writeCollectDataBatchItemsWithTx(tx *sql.Tx, items []collectWriteRequest, h
 	}()
 	for _, item := range items {
 		if item.storeHistory && historyStmtCache == nil {
-			historyStmtCache = newCollectDataStmtCache(tx, collectDataHistoryStringBatchSQLCache.get)
+			historyStmtCache = newCollectDataStmtCache(tx, collectDataHistoryBatchSQLCache.get)
 		}
-		written, err := insertCollectDataWithOptionsTx(tx, item.data, item.storeHistory, cacheStmtCache, historyStmtCache)
+		written, err := insertCollectDataWithHistoryTx(tx, item.data, item.historyData(), cacheStmtCache, historyStmtCache)
 		if err != nil {
 			return err
 		}
@@ -22,7 +22,7 @@ var (
 	dataCacheCleanupMinInterval time.Duration = 2 * time.Second
 )
 
-const selectDataCacheFields = `SELECT id, device_id, field_name, value, value_type, collected_at FROM data_cache`
+const selectDataCacheFields = `SELECT id, device_id, field_name, value, value_num, value_type, collected_at FROM data_cache`
 
 type dbStmtCache struct {
 	mu    sync.RWMutex
@@ -71,12 +71,12 @@ func (c *dbStmtCache) get(db *sql.DB, sqlText string) (*sql.Stmt, error) {
 
 // SaveDataCache 保存实时数据缓存（内存）
 func SaveDataCache(deviceID int64, deviceName, fieldName, value, valueType string) error {
-	valueType = normalizedCollectDataValueType(valueType)
+	stored := storedEntryValue(value, valueType)
 	stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheSingleSQL)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Exec(deviceID, fieldName, value, valueType)
+	_, err = stmt.Exec(deviceID, fieldName, stored.text, stored.num, stored.valueType)
 	if err != nil {
 		return err
 	}
@@ -108,7 +108,7 @@ func BatchSaveDataCacheEntries(entries []DataPointEntry) error {
 }
 
 func batchSaveDataCacheEntriesDirect(entries []DataPointEntry, defaultValueType bool) error {
-	argsPerEntry := 4
+	argsPerEntry := 5
 	if defaultValueType {
 		argsPerEntry = 3
 	}
@@ -136,7 +136,7 @@ func batchSaveDataCacheEntriesDirect(entries []DataPointEntry, defaultValueType
 }
 
 func batchSaveDataCacheEntriesChunkedDirect(entries []DataPointEntry, defaultValueType bool) error {
-	argsPerEntry := 4
+	argsPerEntry := 5
 	if defaultValueType {
 		argsPerEntry = 3
 	}
@@ -258,12 +258,18 @@ func listDataCache(query string, args []any) ([]*models.DataCache, error) {
 }
 
 func scanDataCache(scanner dataCacheScanner, item *models.DataCache) error {
-	return scanner.Scan(
+	var num sql.NullFloat64
+	if err := scanner.Scan(
 		&item.ID,
 		&item.DeviceID,
 		&item.FieldName,
 		&item.Value,
+		&num,
 		&item.ValueType,
 		&item.CollectedAt,
-	)
+	); err != nil {
+		return err
+	}
+	item.Value = storedValueText(item.Value, num, item.ValueType)
+	return nil
 }
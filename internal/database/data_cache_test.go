@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -24,6 +25,8 @@ func (s stubDataCacheScanner) Scan(dest ...any) error {
 			*out = s.values[i].(int64)
 		case *string:
 			*out = s.values[i].(string)
+		case *sql.NullFloat64:
+			*out = s.values[i].(sql.NullFloat64)
 		case *time.Time:
 			*out = s.values[i].(time.Time)
 		}
@@ -36,7 +39,7 @@ func TestScanDataCache(t *testing.T) {
 	now := time.Now()
 	item := &models.DataCache{}
 	scanner := stubDataCacheScanner{
-		values: []any{int64(1), int64(2), "temperature", "26.5", "float", now},
+		values: []any{int64(1), int64(2), "temperature", "26.5", sql.NullFloat64{}, "float", now},
 	}
 
 	err := scanDataCache(scanner, item)
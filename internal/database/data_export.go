@@ -0,0 +1,224 @@
+package database
+
+import (
+	"database/sql"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyExportPageSize 导出时单次从内存库/磁盘库读取的行数
+const historyExportPageSize = 1000
+
+// HistoryExportQuery 历史数据导出条件，FieldNames 为空表示全部字段
+type HistoryExportQuery struct {
+	DeviceIDs  []int64
+	FieldNames []string
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// exportCursor 分页游标：上次输出的最后一行（按 collected_at, field_name 排序）
+type exportCursor struct {
+	started     bool
+	collectedAt string
+	fieldName   string
+}
+
+// StreamDataPoints 按设备 ID、时间、字段名升序分页遍历历史数据（磁盘 + 内存），每页回调一次。
+// 每页在同步锁内同时读取两库，落盘过程中的数据不会丢失或重复；回调在锁外执行。
+func StreamDataPoints(query HistoryExportQuery, fn func(page []*DataPoint) error) error {
+	for _, deviceID := range query.DeviceIDs {
+		var cursor exportCursor
+		for {
+			page, done, err := nextExportPage(deviceID, query, &cursor)
+			if err != nil {
+				return err
+			}
+			if len(page) > 0 {
+				if err := fn(page); err != nil {
+					return err
+				}
+			}
+			if done {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func nextExportPage(deviceID int64, query HistoryExportQuery, cursor *exportCursor) ([]*DataPoint, bool, error) {
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	memPoints, err := queryExportPage(DataDB, deviceID, query, cursor)
+	if err != nil {
+		return nil, false, err
+	}
+	var diskPoints []*DataPoint
+	if dataDBFile != "" {
+		diskPoints, err = getDiskExportPage(deviceID, query, cursor)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				slog.Warn("Failed to read export page from disk", "error", err)
+			}
+			diskPoints = nil
+		}
+	}
+
+	// 只输出两库都已读全的范围：某一库本页读满时，以其最后一行为上界
+	var bound *DataPoint
+	for _, points := range [][]*DataPoint{memPoints, diskPoints} {
+		if len(points) < historyExportPageSize {
+			continue
+		}
+		last := points[len(points)-1]
+		if bound == nil || compareExportKey(last, bound) < 0 {
+			bound = last
+		}
+	}
+
+	page := mergeExportPoints(memPoints, diskPoints, bound)
+	if len(page) > 0 {
+		last := page[len(page)-1]
+		cursor.started = true
+		cursor.collectedAt = formatSQLiteTime(last.CollectedAt.UTC())
+		cursor.fieldName = last.FieldName
+	}
+	return page, bound == nil, nil
+}
+
+func getDiskExportPage(deviceID int64, query HistoryExportQuery, cursor *exportCursor) ([]*DataPoint, error) {
+	db, err := openDataDiskDB()
+	if err != nil {
+		return nil, err
+	}
+	return queryExportPage(db, deviceID, query, cursor)
+}
+
+func queryExportPage(db *sql.DB, deviceID int64, query HistoryExportQuery, cursor *exportCursor) ([]*DataPoint, error) {
+	sqlText := selectDataPointFields + " WHERE device_id = ?"
+	args := []any{deviceID}
+	if !query.StartTime.IsZero() {
+		sqlText += " AND collected_at >= ?"
+		args = append(args, formatSQLiteTime(query.StartTime.UTC()))
+	}
+	if !query.EndTime.IsZero() {
+		sqlText += " AND collected_at <= ?"
+		args = append(args, formatSQLiteTime(query.EndTime.UTC()))
+	}
+	if len(query.FieldNames) > 0 {
+		sqlText += " AND field_name IN (?" + strings.Repeat(", ?", len(query.FieldNames)-1) + ")"
+		for _, name := range query.FieldNames {
+			args = append(args, name)
+		}
+	}
+	if cursor.started {
+		sqlText += " AND (collected_at > ? OR (collected_at = ? AND field_name > ?))"
+		args = append(args, cursor.collectedAt, cursor.collectedAt, cursor.fieldName)
+	}
+	sqlText += " ORDER BY collected_at ASC, field_name ASC LIMIT ?"
+	args = append(args, historyExportPageSize)
+	return listDataPointsLimit(db, sqlText, historyExportPageSize, args...)
+}
+
+func compareExportKey(a, b *DataPoint) int {
+	if c := a.CollectedAt.Compare(b.CollectedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.FieldName, b.FieldName)
+}
+
+// mergeExportPoints 归并两个有序结果，相同（时间, 字段）只保留一行；bound 非空时只保留不大于 bound 的行
+func mergeExportPoints(primary, secondary []*DataPoint, bound *DataPoint) []*DataPoint {
+	merged := make([]*DataPoint, 0, len(primary)+len(secondary))
+	i, j := 0, 0
+	for i < len(primary) || j < len(secondary) {
+		var next *DataPoint
+		switch {
+		case j >= len(secondary):
+			next = primary[i]
+			i++
+		case i >= len(primary):
+			next = secondary[j]
+			j++
+		default:
+			c := compareExportKey(primary[i], secondary[j])
+			if c > 0 {
+				next = secondary[j]
+				j++
+				break
+			}
+			next = primary[i]
+			i++
+			if c == 0 {
+				j++
+			}
+		}
+		if bound != nil && compareExportKey(next, bound) > 0 {
+			break
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+// ListHistoryFieldNames 返回设备在时间范围内出现过的字段名（磁盘 + 内存，升序去重）
+func ListHistoryFieldNames(deviceIDs []int64, startTime, endTime time.Time) ([]string, error) {
+	if len(deviceIDs) == 0 {
+		return nil, nil
+	}
+	sqlText := "SELECT DISTINCT field_name FROM data_points WHERE device_id IN (?" + strings.Repeat(", ?", len(deviceIDs)-1) + ")"
+	args := make([]any, 0, len(deviceIDs)+2)
+	for _, id := range deviceIDs {
+		args = append(args, id)
+	}
+	if !startTime.IsZero() {
+		sqlText += " AND collected_at >= ?"
+		args = append(args, formatSQLiteTime(startTime.UTC()))
+	}
+	if !endTime.IsZero() {
+		sqlText += " AND collected_at <= ?"
+		args = append(args, formatSQLiteTime(endTime.UTC()))
+	}
+
+	seen := make(map[string]struct{})
+	if err := collectDistinctFieldNames(DataDB, sqlText, args, seen); err != nil {
+		return nil, err
+	}
+	if dataDBFile != "" {
+		db, err := openDataDiskDB()
+		if err == nil {
+			err = collectDistinctFieldNames(db, sqlText, args, seen)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to read export field names from disk", "error", err)
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func collectDistinctFieldNames(db *sql.DB, sqlText string, args []any, seen map[string]struct{}) error {
+	rows, err := db.Query(sqlText, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		seen[name] = struct{}{}
+	}
+	return rows.Err()
+}
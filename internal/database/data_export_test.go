@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func insertExportTestRows(t *testing.T, db *sql.DB, base time.Time, from, to int, fields ...string) {
+	t.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	for i := from; i < to; i++ {
+		for _, field := range fields {
+			if _, err := tx.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at) VALUES (1, 'dev-1', ?, '', ?, 'int', ?)`,
+				field, i, formatSQLiteTime(base.Add(time.Duration(i)*time.Second))); err != nil {
+				t.Fatalf("insert row: %v", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+}
+
+func TestStreamDataPoints_MergesDiskAndMemoryPages(t *testing.T) {
+	diskDB := prepareDiskSchemaMemoryDB(t)
+	base := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+
+	// 磁盘 0..1199，内存 1100..1799（重叠部分模拟落盘过程中两库同时存在）
+	insertExportTestRows(t, diskDB, base, 0, 1200, "a", "b")
+	insertExportTestRows(t, DataDB, base, 1100, 1800, "a", "b")
+
+	var got []*DataPoint
+	pages := 0
+	err := StreamDataPoints(HistoryExportQuery{
+		DeviceIDs:  []int64{1, 2},
+		FieldNames: []string{"a", "b"},
+		StartTime:  base.Add(10 * time.Second),
+		EndTime:    base.Add(time.Hour),
+	}, func(page []*DataPoint) error {
+		pages++
+		got = append(got, page...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamDataPoints() error = %v", err)
+	}
+	if len(got) != (1800-10)*2 {
+		t.Fatalf("rows=%d, want %d", len(got), (1800-10)*2)
+	}
+	if pages < 3 {
+		t.Fatalf("pages=%d, want paged reads", pages)
+	}
+	for i := 1; i < len(got); i++ {
+		if compareExportKey(got[i-1], got[i]) >= 0 {
+			t.Fatalf("rows not strictly ordered at %d: %+v then %+v", i, got[i-1], got[i])
+		}
+	}
+	if got[0].FieldName != "a" || got[0].Value != "10" || !got[0].CollectedAt.Equal(base.Add(10*time.Second)) {
+		t.Fatalf("first row=%+v", got[0])
+	}
+
+	fields, err := ListHistoryFieldNames([]int64{1}, base, base.Add(time.Hour))
+	if err != nil || len(fields) != 2 || fields[0] != "a" || fields[1] != "b" {
+		t.Fatalf("ListHistoryFieldNames() = %v, %v", fields, err)
+	}
+}
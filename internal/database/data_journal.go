@@ -0,0 +1,537 @@
+package database
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// 历史写入日志：内存库两次落盘之间的历史行同时追加到磁盘日志，断电重启后回放进 data.db。
+//
+// 日志按段存放在 <data.db>.journal/ 下。每次同步在写入闸门内读取内存水位并封存当前段，
+// 封存段中的行都已提交到内存且 id 不超过水位；同步事务把封存段号写入 data_journal_state，
+// 回放时跳过不大于该段号的段，因此已落盘的行不会重复写入。
+
+const (
+	DefaultDataJournalFsyncInterval = time.Second
+	DefaultDataJournalFsyncBatch    = 256
+
+	dataJournalDirSuffix  = ".journal"
+	dataJournalSegmentExt = ".log"
+	dataJournalStateName  = "data_points"
+)
+
+var (
+	dataJournalEnabled       bool
+	dataJournalFsyncInterval = DefaultDataJournalFsyncInterval
+	dataJournalFsyncBatch    = DefaultDataJournalFsyncBatch
+
+	// dataJournalGate 历史写入（内存提交 + 追加日志）持读锁，同步封存日志段时持写锁
+	dataJournalGate   sync.RWMutex
+	dataJournalActive atomic.Pointer[dataJournal]
+	dataJournalCtrlMu sync.Mutex
+)
+
+// dataJournalRecord 一次采集写入的历史行，At 为采集时间（UTC 秒）
+type dataJournalRecord struct {
+	At         int64              `json:"at"`
+	DeviceID   int64              `json:"d"`
+	DeviceName string             `json:"n"`
+	Values     []dataJournalValue `json:"v"`
+}
+
+type dataJournalValue struct {
+	Field     string   `json:"f"`
+	Text      string   `json:"s,omitempty"`
+	Num       *float64 `json:"x,omitempty"`
+	ValueType string   `json:"t"`
+}
+
+type dataJournal struct {
+	mu       sync.Mutex
+	dir      string
+	seq      int64
+	file     *os.File
+	buf      *bufio.Writer
+	unsynced int
+	failed   bool
+
+	fsyncInterval time.Duration
+	fsyncBatch    int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ApplyDataJournalConfig 设置历史写入日志：interval 为 0 时每次追加都 fsync，
+// 否则累计 batch 条或每隔 interval fsync 一次，以限制闪存写入次数
+func ApplyDataJournalConfig(enabled bool, fsyncInterval time.Duration, fsyncBatch int) {
+	if fsyncInterval < 0 {
+		fsyncInterval = DefaultDataJournalFsyncInterval
+	}
+	if fsyncBatch <= 0 {
+		fsyncBatch = DefaultDataJournalFsyncBatch
+	}
+	dataJournalCtrlMu.Lock()
+	dataJournalEnabled = enabled
+	dataJournalFsyncInterval = fsyncInterval
+	dataJournalFsyncBatch = fsyncBatch
+	dataJournalCtrlMu.Unlock()
+	slog.Info("Applied data journal config", "enabled", enabled, "fsync_interval", fsyncInterval, "fsync_batch", fsyncBatch)
+}
+
+// OpenDataJournal 回放上次运行遗留的日志段，并在开启日志时创建新段。
+// 须在 data.db schema 初始化之后、采集写入开始之前调用
+func OpenDataJournal() error {
+	dataJournalCtrlMu.Lock()
+	defer dataJournalCtrlMu.Unlock()
+	if dataJournalActive.Load() != nil || dataDBFile == "" {
+		return nil
+	}
+
+	dir := dataDBFile + dataJournalDirSuffix
+	lastSeq, err := replayDataJournal(dir)
+	if err != nil {
+		return err
+	}
+	if !dataJournalEnabled {
+		return nil
+	}
+
+	j := &dataJournal{dir: dir, seq: lastSeq, fsyncInterval: dataJournalFsyncInterval, fsyncBatch: dataJournalFsyncBatch}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create data journal dir: %w", err)
+	}
+	if err := j.openSegment(lastSeq + 1); err != nil {
+		return err
+	}
+	if j.fsyncInterval > 0 {
+		j.stop = make(chan struct{})
+		j.done = make(chan struct{})
+		go j.runFsyncLoop(j.fsyncInterval)
+	}
+	dataJournalActive.Store(j)
+	slog.Info("Data journal opened", "dir", dir, "segment", j.seq)
+	return nil
+}
+
+// CloseDataJournal 刷盘并关闭日志，须在采集写入停止、最后一次同步之后调用。
+// 同步成功时已封存的段已被删除，当前段为空则一并删除；其余段留待下次启动回放
+func CloseDataJournal() {
+	dataJournalCtrlMu.Lock()
+	defer dataJournalCtrlMu.Unlock()
+	j := dataJournalActive.Swap(nil)
+	if j == nil {
+		return
+	}
+	if j.stop != nil {
+		close(j.stop)
+		<-j.done
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.closeSegment(); err != nil {
+		slog.Warn("Failed to close data journal segment", "error", err)
+	}
+	path := dataJournalSegmentPath(j.dir, j.seq)
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		_ = os.Remove(path)
+	}
+	slog.Info("Data journal closed", "segment", j.seq)
+}
+
+// beginDataJournalWrite 日志开启时持有写入闸门，直至 endWrite；
+// 调用方须在 endWrite 之后再触发同步或修剪，避免与等待闸门的同步互相阻塞
+func beginDataJournalWrite() *dataJournal {
+	j := dataJournalActive.Load()
+	if j != nil {
+		dataJournalGate.RLock()
+	}
+	return j
+}
+
+func (j *dataJournal) endWrite() {
+	if j != nil {
+		dataJournalGate.RUnlock()
+	}
+}
+
+// appendCollectData 追加一次采集的历史行，时间取采集时间（未设置时为当前时间）；
+// 调用方须持有写入闸门直至这些行提交到内存库。失败只记录日志，不影响采集写入
+func (j *dataJournal) appendCollectData(data *models.CollectData) {
+	if j == nil || data == nil {
+		return
+	}
+	at := data.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	record := dataJournalRecord{
+		At:         at.UTC().Unix(),
+		DeviceID:   data.DeviceID,
+		DeviceName: normalizeDeviceName(data.DeviceID, data.DeviceName),
+	}
+	forEachCollectHistoryValue(data, func(field string, value storedValue) {
+		entry := dataJournalValue{Field: field, Text: value.text, ValueType: value.valueType}
+		if num, ok := value.num.(float64); ok {
+			entry.Num = &num
+		}
+		record.Values = append(record.Values, entry)
+	})
+	if len(record.Values) == 0 {
+		return
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		slog.Warn("Failed to encode data journal record", "error", err)
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.buf == nil {
+		return
+	}
+	// 每行带 CRC，断电截断的尾行在回放时被丢弃
+	line := make([]byte, 0, len(payload)+10)
+	line = fmt.Appendf(line, "%08x ", crc32.ChecksumIEEE(payload))
+	line = append(line, payload...)
+	line = append(line, '\n')
+	if _, err := j.buf.Write(line); err != nil {
+		j.noteFailure("write", err)
+		return
+	}
+	j.unsynced++
+	if j.fsyncInterval == 0 || j.unsynced >= j.fsyncBatch {
+		j.syncLocked()
+	}
+}
+
+// sealDataJournalForSync 在写入闸门内读取本次同步的内存水位并封存当前日志段，
+// 返回封存段号（未开启日志时为 0）
+func sealDataJournalForSync() (maxID, sealedSeq int64, err error) {
+	j := dataJournalActive.Load()
+	if j != nil {
+		dataJournalGate.Lock()
+		defer dataJournalGate.Unlock()
+	}
+	if err := DataDB.QueryRow("SELECT IFNULL(MAX(id), 0) FROM data_points").Scan(&maxID); err != nil {
+		return 0, 0, fmt.Errorf("failed to get max data point id: %w", err)
+	}
+	if j == nil {
+		return maxID, 0, nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	sealed := j.seq
+	if err := j.closeSegment(); err != nil {
+		slog.Warn("Failed to seal data journal segment", "segment", sealed, "error", err)
+	}
+	if err := j.openSegment(sealed + 1); err != nil {
+		j.noteFailure("rotate", err)
+	}
+	return maxID, sealed, nil
+}
+
+// releaseDataJournal 同步成功后删除已封存的日志段
+func releaseDataJournal(sealedSeq int64) {
+	j := dataJournalActive.Load()
+	if j == nil || sealedSeq <= 0 {
+		return
+	}
+	removeDataJournalSegments(j.dir, sealedSeq)
+}
+
+// recordDataJournalSynced 在同步事务中记录已落盘的日志段号
+func recordDataJournalSynced(tx *sql.Tx, sealedSeq int64) error {
+	if sealedSeq <= 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO data_journal_state (name, seq) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET seq = MAX(seq, excluded.seq)`, dataJournalStateName, sealedSeq); err != nil {
+		return fmt.Errorf("failed to record data journal state: %w", err)
+	}
+	return nil
+}
+
+func (j *dataJournal) openSegment(seq int64) error {
+	path := dataJournalSegmentPath(j.dir, seq)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open data journal segment: %w", err)
+	}
+	syncDataJournalDir(j.dir)
+	j.seq = seq
+	j.file = file
+	j.buf = bufio.NewWriterSize(file, 64*1024)
+	j.unsynced = 0
+	j.failed = false
+	return nil
+}
+
+func (j *dataJournal) closeSegment() error {
+	if j.file == nil {
+		return nil
+	}
+	err := j.buf.Flush()
+	if syncErr := j.file.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	j.file = nil
+	j.buf = nil
+	j.unsynced = 0
+	return err
+}
+
+func (j *dataJournal) syncLocked() {
+	if j.buf == nil || j.unsynced == 0 {
+		return
+	}
+	if err := j.buf.Flush(); err != nil {
+		j.noteFailure("flush", err)
+		return
+	}
+	if err := j.file.Sync(); err != nil {
+		j.noteFailure("fsync", err)
+		return
+	}
+	j.unsynced = 0
+	j.failed = false
+}
+
+// noteFailure 同一段内连续失败只记录一次告警
+func (j *dataJournal) noteFailure(op string, err error) {
+	if !j.failed {
+		slog.Error("Data journal "+op+" failed", "segment", j.seq, "error", err)
+	}
+	j.failed = true
+}
+
+func (j *dataJournal) runFsyncLoop(interval time.Duration) {
+	defer close(j.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			j.mu.Lock()
+			j.syncLocked()
+			j.mu.Unlock()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// replayDataJournal 把段号大于 data_journal_state 的日志段写入 data.db，然后删除全部日志段；
+// 返回已用过的最大段号，新段从其后编号
+func replayDataJournal(dir string) (int64, error) {
+	segments, err := listDataJournalSegments(dir)
+	if err != nil {
+		return 0, err
+	}
+	if len(segments) == 0 && !dataJournalEnabled {
+		return 0, nil
+	}
+	if _, err := os.Stat(dataDBFile); os.IsNotExist(err) && len(segments) == 0 {
+		return 0, nil
+	}
+
+	diskDB, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open data database: %w", err)
+	}
+	defer diskDB.Close()
+	if err := prepareDataDiskDB(diskDB); err != nil {
+		return 0, err
+	}
+
+	var syncedSeq int64
+	if err := diskDB.QueryRow(`SELECT IFNULL((SELECT seq FROM data_journal_state WHERE name = ?), 0)`, dataJournalStateName).Scan(&syncedSeq); err != nil {
+		return 0, fmt.Errorf("failed to read data journal state: %w", err)
+	}
+	lastSeq := syncedSeq
+	if len(segments) == 0 {
+		return lastSeq, nil
+	}
+	lastSeq = max(lastSeq, segments[len(segments)-1])
+
+	tx, err := diskDB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin data journal replay: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO data_points
+		(device_id, device_name, field_name, value, value_num, value_type, collected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare data journal replay: %w", err)
+	}
+	defer stmt.Close()
+
+	replayed := 0
+	for _, seq := range segments {
+		if seq <= syncedSeq {
+			continue
+		}
+		count, err := replayDataJournalSegment(stmt, dataJournalSegmentPath(dir, seq))
+		if err != nil {
+			return 0, err
+		}
+		replayed += count
+	}
+	if err := recordDataJournalSynced(tx, lastSeq); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit data journal replay: %w", err)
+	}
+
+	removeDataJournalSegments(dir, lastSeq)
+	slog.Info("Data journal replayed", "segments", len(segments), "points", replayed)
+	return lastSeq, nil
+}
+
+func replayDataJournalSegment(stmt *sql.Stmt, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open data journal segment: %w", err)
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		record, ok := decodeDataJournalLine(scanner.Bytes())
+		if !ok {
+			// 断电时最后一行可能只写了一半，之后的内容不可信
+			slog.Warn("Data journal segment truncated", "path", path, "line", line)
+			break
+		}
+		collectedAt := formatSQLiteTime(time.Unix(record.At, 0).UTC())
+		for _, value := range record.Values {
+			var num any
+			if value.Num != nil {
+				num = *value.Num
+			}
+			if _, err := stmt.Exec(record.DeviceID, record.DeviceName, value.Field, value.Text, num, value.ValueType, collectedAt); err != nil {
+				return 0, fmt.Errorf("failed to replay data journal: %w", err)
+			}
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Warn("Failed to read data journal segment", "path", path, "error", err)
+	}
+	return count, nil
+}
+
+func decodeDataJournalLine(line []byte) (dataJournalRecord, bool) {
+	var record dataJournalRecord
+	if len(line) < 10 || line[8] != ' ' {
+		return record, false
+	}
+	sum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil || uint32(sum) != crc32.ChecksumIEEE(line[9:]) {
+		return record, false
+	}
+	if err := json.Unmarshal(line[9:], &record); err != nil {
+		return record, false
+	}
+	return record, true
+}
+
+// forEachCollectHistoryValue 与 insertCollectDataWithOptionsTx 写入历史的字段集合一致：Points 覆盖同名 Fields
+func forEachCollectHistoryValue(data *models.CollectData, fn func(field string, value storedValue)) {
+	if len(data.Points) == 0 {
+		for field, value := range data.Fields {
+			fn(field, storedCollectValue(value))
+		}
+		return
+	}
+	normalizedPointFields, _ := normalizeCollectPointFieldNames(data.Points)
+	pointFieldNames := collectOverriddenPointFieldNames(data.Fields, data.Points, normalizedPointFields)
+	defer putCollectDataFieldNameSet(pointFieldNames)
+	for field, value := range data.Fields {
+		if _, overridden := pointFieldNames[field]; !overridden {
+			fn(field, storedCollectValue(value))
+		}
+	}
+	for i, point := range data.Points {
+		if field := normalizedCollectPointFieldName(data.Points, normalizedPointFields, i); field != "" {
+			fn(field, storedCollectValue(point.Value))
+		}
+	}
+}
+
+func dataJournalSegmentPath(dir string, seq int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%016d%s", seq, dataJournalSegmentExt))
+}
+
+func listDataJournalSegments(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data journal: %w", err)
+	}
+	var segments []int64
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), dataJournalSegmentExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if seq, err := strconv.ParseInt(name, 10, 64); err == nil && seq > 0 {
+			segments = append(segments, seq)
+		}
+	}
+	slices.Sort(segments)
+	return segments, nil
+}
+
+// removeDataJournalSegments 删除段号不大于 upTo 的日志段
+func removeDataJournalSegments(dir string, upTo int64) {
+	segments, err := listDataJournalSegments(dir)
+	if err != nil {
+		slog.Warn("Failed to list data journal segments", "error", err)
+		return
+	}
+	for _, seq := range segments {
+		if seq > upTo {
+			break
+		}
+		if err := os.Remove(dataJournalSegmentPath(dir, seq)); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove data journal segment", "segment", seq, "error", err)
+		}
+	}
+}
+
+// syncDataJournalDir 新建段文件后同步目录项，避免断电后文件本身丢失
+func syncDataJournalDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func prepareDataJournalTestDB(t *testing.T) {
+	t.Helper()
+	prepareDiskSchemaMemoryDB(t)
+	ApplyDataJournalConfig(true, 0, 1)
+	t.Cleanup(func() {
+		CloseDataJournal()
+		ApplyDataJournalConfig(false, DefaultDataJournalFsyncInterval, DefaultDataJournalFsyncBatch)
+	})
+	if err := OpenDataJournal(); err != nil {
+		t.Fatalf("OpenDataJournal() error = %v", err)
+	}
+}
+
+// crashDataJournal 模拟断电：日志已 fsync，但既不落盘也不清理
+func crashDataJournal(t *testing.T) {
+	t.Helper()
+	j := dataJournalActive.Swap(nil)
+	if j == nil {
+		t.Fatal("data journal not active")
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.closeSegment(); err != nil {
+		t.Fatalf("close segment: %v", err)
+	}
+}
+
+func writeDataJournalTestData(t *testing.T, point string, fields ...string) {
+	t.Helper()
+	data := &models.CollectData{DeviceID: 1, DeviceName: "dev-1", Fields: map[string]string{}}
+	for _, field := range fields {
+		data.Fields[field] = "21.5"
+	}
+	data.Points = []models.CollectPoint{{FieldName: point, Value: "on"}}
+	if err := InsertCollectDataWithOptions(data, true); err != nil {
+		t.Fatalf("InsertCollectDataWithOptions() error = %v", err)
+	}
+}
+
+func TestDataJournal_ReplaysOnlyUnsyncedRowsAfterCrash(t *testing.T) {
+	prepareDataJournalTestDB(t)
+
+	writeDataJournalTestData(t, "state", "a", "b")
+	if err := syncDataToDisk(); err != nil {
+		t.Fatalf("syncDataToDisk() error = %v", err)
+	}
+	if got := countDiskDataPoints(t); got != 3 {
+		t.Fatalf("disk rows after sync = %d, want 3", got)
+	}
+	writeDataJournalTestData(t, "mode", "c")
+	crashDataJournal(t)
+
+	// 断电后内存库丢失
+	if _, err := DataDB.Exec(`DELETE FROM data_points`); err != nil {
+		t.Fatalf("clear memory: %v", err)
+	}
+	segments, _ := listDataJournalSegments(dataDBFile + dataJournalDirSuffix)
+	if len(segments) != 1 {
+		t.Fatalf("segments before replay = %v, want only the unsynced one", segments)
+	}
+
+	if err := OpenDataJournal(); err != nil {
+		t.Fatalf("OpenDataJournal() error = %v", err)
+	}
+	if got := countDiskDataPoints(t); got != 5 {
+		t.Fatalf("disk rows after replay = %d, want 5", got)
+	}
+	disk, err := openSQLite(dataDBFile, 1, 1)
+	if err != nil {
+		t.Fatalf("open disk db: %v", err)
+	}
+	defer disk.Close()
+	var num float64
+	var valueType string
+	if err := disk.QueryRow(`SELECT value_num, value_type FROM data_points WHERE field_name = 'c'`).Scan(&num, &valueType); err != nil || num != 21.5 || valueType != models.ValueTypeFloat {
+		t.Fatalf("replayed row = %v %q, %v", num, valueType, err)
+	}
+
+	CloseDataJournal()
+	if segments, _ := listDataJournalSegments(dataDBFile + dataJournalDirSuffix); len(segments) != 0 {
+		t.Fatalf("segments after clean close = %v", segments)
+	}
+}
+
+func TestDataJournal_ReplayStopsAtTornLine(t *testing.T) {
+	prepareDataJournalTestDB(t)
+
+	writeDataJournalTestData(t, "state", "a")
+	j := dataJournalActive.Load()
+	path := dataJournalSegmentPath(j.dir, j.seq)
+	crashDataJournal(t)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	_, _ = file.WriteString(`0badc0de {"at":1,"d":1,"n":"dev-1","v":[{"f":"x","t":"st`)
+	_ = file.Close()
+
+	if err := OpenDataJournal(); err != nil {
+		t.Fatalf("OpenDataJournal() error = %v", err)
+	}
+	if got := countDiskDataPoints(t); got != 2 {
+		t.Fatalf("disk rows after replay = %d, want 2", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("replayed segment not removed: %v", err)
+	}
+}
+
+func TestDataJournal_QueuedWriteJournaledBeforeCommit(t *testing.T) {
+	prepareDataJournalTestDB(t)
+
+	// 写入协程未运行：请求停留在队列中
+	collectWriteMu.Lock()
+	collectWriteCh = make(chan collectWriteRequest, 1)
+	collectWriteAlive = true
+	collectWriteMu.Unlock()
+	t.Cleanup(func() {
+		collectWriteMu.Lock()
+		collectWriteCh, collectWriteAlive = nil, false
+		collectWriteMu.Unlock()
+	})
+
+	collectedAt := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	data := &models.CollectData{DeviceID: 1, DeviceName: "dev-1", Timestamp: collectedAt, Fields: map[string]string{"a": "1"}}
+	if err := EnqueueCollectDataWrite(data, true); err != nil {
+		t.Fatalf("EnqueueCollectDataWrite() error = %v", err)
+	}
+	item := <-collectWriteCh
+
+	// 同步封存须等待已记入日志的行提交到内存库
+	sealed := make(chan struct{})
+	go func() {
+		defer close(sealed)
+		if _, _, err := sealDataJournalForSync(); err != nil {
+			t.Errorf("sealDataJournalForSync() error = %v", err)
+		}
+	}()
+	select {
+	case <-sealed:
+		t.Fatal("journal sealed while a journaled row was still queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := insertQueuedCollectData(&item); err != nil {
+		t.Fatalf("insertQueuedCollectData() error = %v", err)
+	}
+	<-sealed
+
+	// 断电：内存库丢失，回放按采集时间写入磁盘
+	crashDataJournal(t)
+	if _, err := DataDB.Exec(`DELETE FROM data_points`); err != nil {
+		t.Fatalf("clear memory: %v", err)
+	}
+	if err := OpenDataJournal(); err != nil {
+		t.Fatalf("OpenDataJournal() error = %v", err)
+	}
+	disk, err := openSQLite(dataDBFile, 1, 1)
+	if err != nil {
+		t.Fatalf("open disk db: %v", err)
+	}
+	defer disk.Close()
+	var got time.Time
+	if err := disk.QueryRow(`SELECT collected_at FROM data_points WHERE field_name = 'a'`).Scan(&got); err != nil {
+		t.Fatalf("replayed row: %v", err)
+	}
+	if !got.Equal(collectedAt) {
+		t.Fatalf("replayed collected_at = %v, want %v", got, collectedAt)
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -23,6 +24,15 @@ type DataPoint struct {
 	CollectedAt time.Time `json:"collected_at"`
 }
 
+// MarshalJSON 按 value_type 输出数值/布尔，而不是字符串
+func (p DataPoint) MarshalJSON() ([]byte, error) {
+	type dataPointJSON DataPoint
+	return json.Marshal(struct {
+		dataPointJSON
+		Value any `json:"value"`
+	}{dataPointJSON(p), models.TypedCollectValue(p.Value, p.ValueType)})
+}
+
 var (
 	dataPointsCleanupCounter uint64
 	dataPointsLastCleanupNS  int64
@@ -34,48 +44,42 @@ var (
 	dataPointsCleanupEveryWrites uint64        = 128
 	dataPointsCleanupMinInterval time.Duration = 2 * time.Second
 
-	collectDataCacheBatchSQLCache         = newCollectDataBatchSQLCache(buildCollectDataCacheBatchSQL)
-	collectDataCacheStringBatchSQLCache   = newCollectDataBatchSQLCache(buildCollectDataCacheStringBatchSQL)
-	collectDataHistoryBatchSQLCache       = newCollectDataBatchSQLCache(buildCollectDataHistoryBatchSQL)
-	collectDataHistoryStringBatchSQLCache = newCollectDataBatchSQLCache(buildCollectDataHistoryStringBatchSQL)
-	dataPointBatchSQLCache                = newCollectDataBatchSQLCache(buildDataPointBatchSQL)
-	dataPointStringBatchSQLCache          = newCollectDataBatchSQLCache(buildDataPointStringBatchSQL)
-	latestDataPointBatchSQLCache          = newCollectDataBatchSQLCache(buildLatestDataPointBatchSQL)
-	latestDataPointStringBatchSQLCache    = newCollectDataBatchSQLCache(buildLatestDataPointStringBatchSQL)
-	dataPointQueryStmtCache               dbStmtCache
-	latestDeviceFieldQueryStmtCache       dbStmtCache
-	collectDataFieldNameSetPool           = sync.Pool{
+	collectDataCacheBatchSQLCache       = newCollectDataBatchSQLCache(buildCollectDataCacheBatchSQL)
+	collectDataCacheStringBatchSQLCache = newCollectDataBatchSQLCache(buildCollectDataCacheStringBatchSQL)
+	collectDataHistoryBatchSQLCache     = newCollectDataBatchSQLCache(buildCollectDataHistoryBatchSQL)
+	dataPointBatchSQLCache              = newCollectDataBatchSQLCache(buildDataPointBatchSQL)
+	dataPointStringBatchSQLCache        = newCollectDataBatchSQLCache(buildDataPointStringBatchSQL)
+	latestDataPointBatchSQLCache        = newCollectDataBatchSQLCache(buildLatestDataPointBatchSQL)
+	latestDataPointStringBatchSQLCache  = newCollectDataBatchSQLCache(buildLatestDataPointStringBatchSQL)
+	dataPointQueryStmtCache             dbStmtCache
+	latestDeviceFieldQueryStmtCache     dbStmtCache
+	collectDataFieldNameSetPool         = sync.Pool{
 		New: func() any {
 			return make(map[string]struct{}, collectDataCacheBatchSize)
 		},
 	}
 	collectDataArgsPool = sync.Pool{
 		New: func() any {
-			return make([]any, 0, collectDataCacheBatchSize*5)
+			return make([]any, 0, collectDataCacheBatchSize*6)
 		},
 	}
 )
 
-const collectDataValueTypeString = "string"
-const selectDataPointFields = `SELECT id, device_id, device_name, field_name, value, value_type, collected_at FROM data_points`
+const collectDataValueTypeString = models.ValueTypeString
+const selectDataPointFields = `SELECT id, device_id, device_name, field_name, value, value_num, value_type, collected_at FROM data_points`
 const selectDataPointFieldsLatestLimit = selectDataPointFields + " ORDER BY collected_at DESC LIMIT ?"
 const selectDataPointFieldsByDeviceLimit = selectDataPointFields + " WHERE device_id = ? ORDER BY collected_at DESC LIMIT ?"
-const dataPointSingleSQL = `INSERT INTO data_points (device_id, device_name, field_name, value, value_type, collected_at)
-	VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+const dataPointSingleSQL = `INSERT INTO data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at)
+	VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
 const latestDataPointSingleSQL = `INSERT OR REPLACE INTO data_points (device_id, device_name, field_name, value, value_type, collected_at)
 	VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
-const collectDataCacheSingleSQL = `INSERT INTO data_cache (device_id, field_name, value, value_type, collected_at)
-	VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+const collectDataCacheSingleSQL = `INSERT INTO data_cache (device_id, field_name, value, value_num, value_type, collected_at)
+	VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 	ON CONFLICT(device_id, field_name) DO UPDATE SET
 		value = excluded.value,
+		value_num = excluded.value_num,
 		value_type = excluded.value_type,
 		collected_at = CURRENT_TIMESTAMP`
-const collectDataCacheSingleStringSQL = `INSERT INTO data_cache (device_id, field_name, value, value_type, collected_at)
-	VALUES (?, ?, ?, 'string', CURRENT_TIMESTAMP)
-	ON CONFLICT(device_id, field_name) DO UPDATE SET
-		value = excluded.value,
-		value_type = 'string',
-		collected_at = CURRENT_TIMESTAMP`
 
 func normalizeDeviceName(deviceID int64, deviceName string) string {
 	if deviceID == models.SystemStatsDeviceID {
@@ -87,12 +91,12 @@ func normalizeDeviceName(deviceID int64, deviceName string) string {
 // SaveDataPoint 保存历史数据点（内存暂存）
 func SaveDataPoint(deviceID int64, deviceName, fieldName, value, valueType string) error {
 	deviceName = normalizeDeviceName(deviceID, deviceName)
-	valueType = normalizedCollectDataValueType(valueType)
+	stored := storedEntryValue(value, valueType)
 	stmt, err := dataCacheExecStmtCache.get(DataDB, dataPointSingleSQL)
 	if err != nil {
 		return err
 	}
-	_, err = stmt.Exec(deviceID, deviceName, fieldName, value, valueType)
+	_, err = stmt.Exec(deviceID, deviceName, fieldName, stored.text, stored.num, stored.valueType)
 	if err != nil {
 		return err
 	}
@@ -109,15 +113,29 @@ type dataPointScanner interface {
 }
 
 func scanDataPoint(scanner dataPointScanner, point *DataPoint) error {
-	return scanner.Scan(
+	var num sql.NullFloat64
+	if err := scanner.Scan(
 		&point.ID,
 		&point.DeviceID,
 		&point.DeviceName,
 		&point.FieldName,
 		&point.Value,
+		&num,
 		&point.ValueType,
 		&point.CollectedAt,
-	)
+	); err != nil {
+		return err
+	}
+	point.Value = storedValueText(point.Value, num, point.ValueType)
+	return nil
+}
+
+// storedValueText 数值行的 value 列为空，按 value_num 还原文本
+func storedValueText(value string, num sql.NullFloat64, valueType string) string {
+	if !num.Valid {
+		return value
+	}
+	return models.FormatNumericValue(num.Float64, valueType)
 }
 
 func listDataPointsLimit(db *sql.DB, query string, limit int, args ...any) ([]*DataPoint, error) {
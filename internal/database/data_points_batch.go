@@ -67,7 +67,7 @@ func appendCollectDataCacheValuesSQL(dst []byte, batchSize int) []byte {
 		if i > 0 {
 			dst = append(dst, ',')
 		}
-		dst = append(dst, "(?, ?, ?, ?, CURRENT_TIMESTAMP)"...)
+		dst = append(dst, "(?, ?, ?, ?, ?, CURRENT_TIMESTAMP)"...)
 	}
 	return dst
 }
@@ -87,7 +87,7 @@ func appendCollectDataHistoryValuesSQL(dst []byte, batchSize int) []byte {
 		if i > 0 {
 			dst = append(dst, ',')
 		}
-		dst = append(dst, "(?, ?, ?, ?, ?, CURRENT_TIMESTAMP)"...)
+		dst = append(dst, "(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)"...)
 	}
 	return dst
 }
@@ -107,7 +107,7 @@ func appendDataPointValuesSQL(dst []byte, batchSize int) []byte {
 		if i > 0 {
 			dst = append(dst, ',')
 		}
-		dst = append(dst, "(?, ?, ?, ?, ?, ?)"...)
+		dst = append(dst, "(?, ?, ?, ?, ?, ?, ?)"...)
 	}
 	return dst
 }
@@ -126,10 +126,10 @@ func buildCollectDataCacheBatchSQL(batchSize int) string {
 	if batchSize <= 0 {
 		return ""
 	}
-	sqlText := make([]byte, 0, 128+batchSize*28)
-	sqlText = append(sqlText, "INSERT INTO data_cache (device_id, field_name, value, value_type, collected_at) VALUES "...)
+	sqlText := make([]byte, 0, 160+batchSize*31)
+	sqlText = append(sqlText, "INSERT INTO data_cache (device_id, field_name, value, value_num, value_type, collected_at) VALUES "...)
 	sqlText = appendCollectDataCacheValuesSQL(sqlText, batchSize)
-	sqlText = append(sqlText, ` ON CONFLICT(device_id, field_name) DO UPDATE SET value = excluded.value, value_type = excluded.value_type, collected_at = CURRENT_TIMESTAMP`...)
+	sqlText = append(sqlText, ` ON CONFLICT(device_id, field_name) DO UPDATE SET value = excluded.value, value_num = excluded.value_num, value_type = excluded.value_type, collected_at = CURRENT_TIMESTAMP`...)
 	return string(sqlText)
 }
 
@@ -137,10 +137,10 @@ func buildCollectDataCacheStringBatchSQL(batchSize int) string {
 	if batchSize <= 0 {
 		return ""
 	}
-	sqlText := make([]byte, 0, 128+batchSize*38)
+	sqlText := make([]byte, 0, 144+batchSize*38)
 	sqlText = append(sqlText, "INSERT INTO data_cache (device_id, field_name, value, value_type, collected_at) VALUES "...)
 	sqlText = appendCollectDataCacheStringValuesSQL(sqlText, batchSize)
-	sqlText = append(sqlText, ` ON CONFLICT(device_id, field_name) DO UPDATE SET value = excluded.value, value_type = 'string', collected_at = CURRENT_TIMESTAMP`...)
+	sqlText = append(sqlText, ` ON CONFLICT(device_id, field_name) DO UPDATE SET value = excluded.value, value_num = NULL, value_type = 'string', collected_at = CURRENT_TIMESTAMP`...)
 	return string(sqlText)
 }
 
@@ -148,28 +148,18 @@ func buildCollectDataHistoryBatchSQL(batchSize int) string {
 	if batchSize <= 0 {
 		return ""
 	}
-	sqlText := make([]byte, 0, 96+batchSize*32)
-	sqlText = append(sqlText, "INSERT OR REPLACE INTO data_points (device_id, device_name, field_name, value, value_type, collected_at) VALUES "...)
+	sqlText := make([]byte, 0, 112+batchSize*35)
+	sqlText = append(sqlText, "INSERT OR REPLACE INTO data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at) VALUES "...)
 	sqlText = appendCollectDataHistoryValuesSQL(sqlText, batchSize)
 	return string(sqlText)
 }
 
-func buildCollectDataHistoryStringBatchSQL(batchSize int) string {
-	if batchSize <= 0 {
-		return ""
-	}
-	sqlText := make([]byte, 0, 96+batchSize*42)
-	sqlText = append(sqlText, "INSERT OR REPLACE INTO data_points (device_id, device_name, field_name, value, value_type, collected_at) VALUES "...)
-	sqlText = appendCollectDataHistoryStringValuesSQL(sqlText, batchSize)
-	return string(sqlText)
-}
-
 func buildDataPointBatchSQL(batchSize int) string {
 	if batchSize <= 0 {
 		return ""
 	}
-	sqlText := make([]byte, 0, 96+batchSize*30)
-	sqlText = append(sqlText, "INSERT INTO data_points (device_id, device_name, field_name, value, value_type, collected_at) VALUES "...)
+	sqlText := make([]byte, 0, 112+batchSize*33)
+	sqlText = append(sqlText, "INSERT INTO data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at) VALUES "...)
 	sqlText = appendDataPointValuesSQL(sqlText, batchSize)
 	return string(sqlText)
 }
@@ -188,8 +178,8 @@ func buildLatestDataPointBatchSQL(batchSize int) string {
 	if batchSize <= 0 {
 		return ""
 	}
-	sqlText := make([]byte, 0, 96+batchSize*32)
-	sqlText = append(sqlText, "INSERT OR REPLACE INTO data_points (device_id, device_name, field_name, value, value_type, collected_at) VALUES "...)
+	sqlText := make([]byte, 0, 112+batchSize*35)
+	sqlText = append(sqlText, "INSERT OR REPLACE INTO data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at) VALUES "...)
 	sqlText = appendCollectDataHistoryValuesSQL(sqlText, batchSize)
 	return string(sqlText)
 }
@@ -204,30 +194,51 @@ func buildLatestDataPointStringBatchSQL(batchSize int) string {
 	return string(sqlText)
 }
 
-func appendCollectDataCacheArg(dst []any, deviceID int64, field, value string) []any {
-	return append(dst, deviceID, field, value, collectDataValueTypeString)
+// storedValue 值的存储形式：数值写入 value_num 且 value 置空，其余按文本写入 value（value_num 为 NULL）
+type storedValue struct {
+	text      string
+	num       any
+	valueType string
+}
+
+// storedCollectValue 保留驱动返回的原生类型；字符串仅在可无损转换时按数值存储
+func storedCollectValue(value any) storedValue {
+	if num, valueType, ok := models.NumericCollectValue(value); ok {
+		return storedValue{num: num, valueType: valueType}
+	}
+	return storedValue{text: models.CollectPointValueString(value), valueType: models.ValueTypeString}
 }
 
-func appendCollectDataCacheStringArg(dst []any, deviceID int64, field, value string) []any {
-	return append(dst, deviceID, field, value)
+// storedEntryValue 按调用方声明的 value_type 存储；文本无法按该类型无损转换时仍存文本
+func storedEntryValue(value, valueType string) storedValue {
+	valueType = normalizedCollectDataValueType(valueType)
+	switch valueType {
+	case models.ValueTypeInt, models.ValueTypeFloat, models.ValueTypeBool:
+		num, parsedType, ok := models.NumericCollectValue(value)
+		if ok && (parsedType == valueType || (valueType == models.ValueTypeFloat && parsedType == models.ValueTypeInt)) {
+			return storedValue{num: num, valueType: valueType}
+		}
+	}
+	return storedValue{text: value, valueType: valueType}
 }
 
-func appendCollectDataHistoryArg(dst []any, deviceID int64, deviceName, field, value string) []any {
-	return append(dst, deviceID, deviceName, field, value, collectDataValueTypeString)
+func appendCollectDataCacheArg(dst []any, deviceID int64, field string, value storedValue) []any {
+	return append(dst, deviceID, field, value.text, value.num, value.valueType)
 }
 
-func appendCollectDataHistoryStringArg(dst []any, deviceID int64, deviceName, field, value string) []any {
-	return append(dst, deviceID, deviceName, field, value)
+func appendCollectDataHistoryArg(dst []any, deviceID int64, deviceName, field string, value storedValue) []any {
+	return append(dst, deviceID, deviceName, field, value.text, value.num, value.valueType)
 }
 
 func appendDataPointArg(dst []any, entry DataPointEntry, collectedAt time.Time) []any {
-	valueType := normalizedCollectDataValueType(entry.ValueType)
+	value := storedEntryValue(entry.Value, entry.ValueType)
 	return append(dst,
 		entry.DeviceID,
 		normalizeDeviceName(entry.DeviceID, entry.DeviceName),
 		entry.FieldName,
-		entry.Value,
-		valueType,
+		value.text,
+		value.num,
+		value.valueType,
 		collectedAt,
 	)
 }
@@ -243,12 +254,14 @@ func appendDataPointStringArg(dst []any, entry DataPointEntry, collectedAt time.
 }
 
 func appendLatestDataPointArg(dst []any, entry DataPointEntry) []any {
+	value := storedEntryValue(entry.Value, entry.ValueType)
 	return append(dst,
 		entry.DeviceID,
 		normalizeDeviceName(entry.DeviceID, entry.DeviceName),
 		entry.FieldName,
-		entry.Value,
-		normalizedCollectDataValueType(entry.ValueType),
+		value.text,
+		value.num,
+		value.valueType,
 	)
 }
 
@@ -262,7 +275,7 @@ func appendLatestDataPointStringArg(dst []any, entry DataPointEntry) []any {
 }
 
 func appendDataCacheEntryArg(dst []any, entry DataPointEntry) []any {
-	return append(dst, entry.DeviceID, entry.FieldName, entry.Value, normalizedCollectDataValueType(entry.ValueType))
+	return appendCollectDataCacheArg(dst, entry.DeviceID, entry.FieldName, storedEntryValue(entry.Value, entry.ValueType))
 }
 
 func appendDataCacheEntryStringArg(dst []any, entry DataPointEntry) []any {
@@ -415,7 +428,7 @@ func appendCollectDataCacheArgsForData(dst []any, data *models.CollectData) []an
 	}
 	if len(data.Points) == 0 {
 		for field, value := range data.Fields {
-			dst = appendCollectDataCacheStringArg(dst, data.DeviceID, field, value)
+			dst = appendCollectDataCacheArg(dst, data.DeviceID, field, storedCollectValue(value))
 		}
 		return dst
 	}
@@ -433,7 +446,7 @@ func appendCollectDataCacheArgsForDataWithShape(dst []any, data *models.CollectD
 			if _, overridden := shape.pointFieldNames[field]; overridden {
 				continue
 			}
-			dst = appendCollectDataCacheStringArg(dst, data.DeviceID, field, value)
+			dst = appendCollectDataCacheArg(dst, data.DeviceID, field, storedCollectValue(value))
 		}
 	}
 	for i, point := range data.Points {
@@ -441,7 +454,7 @@ func appendCollectDataCacheArgsForDataWithShape(dst []any, data *models.CollectD
 		if field == "" {
 			continue
 		}
-		dst = appendCollectDataCacheStringArg(dst, data.DeviceID, field, models.CollectPointValueString(point.Value))
+		dst = appendCollectDataCacheArg(dst, data.DeviceID, field, storedCollectValue(point.Value))
 	}
 	return dst
 }
@@ -469,8 +482,10 @@ func flushCollectDataArgs(cacheStmtCache, historyStmtCache *collectDataStmtCache
 	if batchCount <= 0 {
 		return nil
 	}
-	if err := executeCollectDataCacheBatchWithArgs(cacheStmtCache, batchCount, cacheArgs); err != nil {
-		return err
+	if cacheStmtCache != nil {
+		if err := executeCollectDataCacheBatchWithArgs(cacheStmtCache, batchCount, cacheArgs); err != nil {
+			return err
+		}
 	}
 	if storeHistory {
 		if err := executeCollectDataHistoryBatchWithArgs(historyStmtCache, batchCount, historyArgs); err != nil {
@@ -486,11 +501,12 @@ func insertCollectDataCacheDirect(data *models.CollectData) error {
 	}
 	if len(data.Fields) == 1 && len(data.Points) == 0 {
 		for field, value := range data.Fields {
-			stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheSingleStringSQL)
+			stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheSingleSQL)
 			if err != nil {
 				return fmt.Errorf("failed to prepare data cache statement: %w", err)
 			}
-			_, err = stmt.Exec(data.DeviceID, field, value)
+			stored := storedCollectValue(value)
+			_, err = stmt.Exec(data.DeviceID, field, stored.text, stored.num, stored.valueType)
 			if err != nil {
 				return fmt.Errorf("failed to upsert data cache batch: %w", err)
 			}
@@ -501,7 +517,7 @@ func insertCollectDataCacheDirect(data *models.CollectData) error {
 	if len(data.Fields) == 0 {
 		validPoints := 0
 		var singleField string
-		var singleValue string
+		var singleValue any
 		for _, point := range data.Points {
 			field := trimDataPointFieldName(point.FieldName)
 			if field == "" {
@@ -510,7 +526,7 @@ func insertCollectDataCacheDirect(data *models.CollectData) error {
 			validPoints++
 			if validPoints == 1 {
 				singleField = field
-				singleValue = models.CollectPointValueString(point.Value)
+				singleValue = point.Value
 			}
 			if validPoints > 1 {
 				break
@@ -520,11 +536,12 @@ func insertCollectDataCacheDirect(data *models.CollectData) error {
 			return nil
 		}
 		if validPoints == 1 {
-			stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheSingleStringSQL)
+			stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheSingleSQL)
 			if err != nil {
 				return fmt.Errorf("failed to prepare data cache statement: %w", err)
 			}
-			_, err = stmt.Exec(data.DeviceID, singleField, singleValue)
+			stored := storedCollectValue(singleValue)
+			_, err = stmt.Exec(data.DeviceID, singleField, stored.text, stored.num, stored.valueType)
 			if err != nil {
 				return fmt.Errorf("failed to upsert data cache batch: %w", err)
 			}
@@ -536,14 +553,14 @@ func insertCollectDataCacheDirect(data *models.CollectData) error {
 	if argsCap <= 0 {
 		return nil
 	}
-	args := getCollectDataArgs(argsCap * 3)
+	args := getCollectDataArgs(argsCap * 5)
 	defer putCollectDataArgs(args)
 	args = appendCollectDataCacheArgsForData(args, data)
 	if len(args) == 0 {
 		return nil
 	}
 
-	stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheStringBatchSQLCache.get(len(args)/3))
+	stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheBatchSQLCache.get(len(args)/5))
 	if err != nil {
 		return fmt.Errorf("failed to prepare data cache batch statement: %w", err)
 	}
@@ -558,7 +575,7 @@ func insertCollectDataCacheChunkedDirect(data *models.CollectData) error {
 		return nil
 	}
 
-	args := getCollectDataArgs(collectDataCacheBatchSize * 3)
+	args := getCollectDataArgs(collectDataCacheBatchSize * 5)
 	defer putCollectDataArgs(args)
 
 	batchRows := 0
@@ -566,7 +583,7 @@ func insertCollectDataCacheChunkedDirect(data *models.CollectData) error {
 		if batchRows == 0 {
 			return nil
 		}
-		stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheStringBatchSQLCache.get(batchRows))
+		stmt, err := dataCacheExecStmtCache.get(DataDB, collectDataCacheBatchSQLCache.get(batchRows))
 		if err != nil {
 			return fmt.Errorf("failed to prepare data cache batch statement: %w", err)
 		}
@@ -578,8 +595,8 @@ func insertCollectDataCacheChunkedDirect(data *models.CollectData) error {
 		return nil
 	}
 
-	appendField := func(field, value string) error {
-		args = appendCollectDataCacheStringArg(args, data.DeviceID, field, value)
+	appendField := func(field string, value any) error {
+		args = appendCollectDataCacheArg(args, data.DeviceID, field, storedCollectValue(value))
 		batchRows++
 		if batchRows < collectDataCacheBatchSize {
 			return nil
@@ -602,7 +619,7 @@ func insertCollectDataCacheChunkedDirect(data *models.CollectData) error {
 			if field == "" {
 				continue
 			}
-			if err := appendField(field, models.CollectPointValueString(point.Value)); err != nil {
+			if err := appendField(field, point.Value); err != nil {
 				return err
 			}
 		}
@@ -626,7 +643,7 @@ func insertCollectDataCacheChunkedDirect(data *models.CollectData) error {
 		if field == "" {
 			continue
 		}
-		if err := appendField(field, models.CollectPointValueString(point.Value)); err != nil {
+		if err := appendField(field, point.Value); err != nil {
 			return err
 		}
 	}
@@ -733,7 +750,7 @@ func BatchSaveDataPoints(entries []DataPointEntry) error {
 	}
 	stmtCache := newCollectDataStmtCache(tx, sqlForBatch)
 	defer stmtCache.close()
-	argsPerEntry := 6
+	argsPerEntry := 7
 	if defaultValueType {
 		argsPerEntry = 5
 	}
@@ -784,7 +801,7 @@ func BatchSaveDataPoints(entries []DataPointEntry) error {
 }
 
 func batchSaveDataPointsDirect(entries []DataPointEntry, defaultValueType bool) error {
-	argsPerEntry := 6
+	argsPerEntry := 7
 	if defaultValueType {
 		argsPerEntry = 5
 	}
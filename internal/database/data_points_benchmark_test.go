@@ -31,6 +31,7 @@ func prepareDataPointsBenchmarkDB(b *testing.B) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -44,6 +45,7 @@ func prepareDataPointsBenchmarkDB(b *testing.B) {
 		device_id INTEGER NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -504,7 +506,7 @@ func benchmarkGetDiskLatestDataPoints1000Limit(b *testing.B, closeBeforeEach boo
 	b.Cleanup(func() {
 		_ = diskDB.Close()
 	})
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		b.Fatalf("ensure disk schema: %v", err)
 	}
 
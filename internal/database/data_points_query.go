@@ -190,11 +190,15 @@ func GetDataPointsByDeviceAndTimeLimit(deviceID int64, startTime, endTime time.T
 	return mergeDataPoints(memPoints, diskPoints, limit), nil
 }
 
-// GetDataPointsByDeviceFieldAndTime 根据设备ID/字段/时间范围获取历史数据（内存 + 磁盘）
-func GetDataPointsByDeviceFieldAndTime(deviceID int64, fieldName string, startTime, endTime time.Time, limit int) ([]*DataPoint, error) {
+// GetDataPointsByDeviceFieldAndTime 根据设备ID/字段/时间范围获取历史数据（内存 + 磁盘）。
+// agg 启用时按桶聚合，从能覆盖查询范围的最粗汇总层级读取。
+func GetDataPointsByDeviceFieldAndTime(deviceID int64, fieldName string, startTime, endTime time.Time, limit int, agg HistoryAggregation) ([]*DataPoint, error) {
 	if limit <= 0 {
 		limit = 2000
 	}
+	if agg.Enabled() {
+		return getAggregatedDataPoints(deviceID, fieldName, startTime, endTime, limit, agg)
+	}
 	memPoints, err := queryDataPointsByDeviceFieldAndTime(DataDB, deviceID, fieldName, startTime, endTime, limit)
 	if err != nil {
 		return nil, err
@@ -314,7 +318,7 @@ func deleteHistoryDataByPointOnDisk(deviceID int64, fieldName string) (int64, er
 	}
 	defer diskDB.Close()
 
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		return 0, err
 	}
 
@@ -325,6 +329,9 @@ func deleteHistoryDataByPointOnDisk(deviceID int64, fieldName string) (int64, er
 	if err != nil {
 		return 0, err
 	}
+	if err := deleteRollupsByPoint(diskDB, deviceID, fieldName); err != nil {
+		return 0, err
+	}
 
 	deleted, _ := result.RowsAffected()
 	return deleted, nil
@@ -371,11 +378,11 @@ func queryRowsWithCachedStmt(db *sql.DB, cache *dbStmtCache, query string, args
 	return stmt.Query(args...)
 }
 
-const latestDeviceFieldRowsFromCacheQuery = `SELECT device_id, field_name, value, collected_at
+const latestDeviceFieldRowsFromCacheQuery = `SELECT device_id, field_name, value, value_num, value_type, collected_at
 	FROM data_cache
 	ORDER BY device_id ASC, collected_at DESC`
 
-const latestDeviceFieldRowsFromHistoryQuery = `SELECT p.device_id, p.device_name, p.field_name, p.value, p.collected_at
+const latestDeviceFieldRowsFromHistoryQuery = `SELECT p.device_id, p.device_name, p.field_name, p.value, p.value_num, p.value_type, p.collected_at
 	FROM data_points p
 	INNER JOIN (
 		SELECT device_id, field_name, MAX(collected_at) AS max_collected_at
@@ -422,9 +429,12 @@ func appendLatestDeviceFieldRowsFromHistory(db *sql.DB, result []*LatestDeviceDa
 		var currentItem *LatestDeviceData
 		for rows.Next() {
 			var item latestDeviceFieldRow
-			if err := rows.Scan(&item.DeviceID, &item.DeviceName, &item.FieldName, &item.Value, &item.CollectedAt); err != nil {
+			var num sql.NullFloat64
+			var valueType string
+			if err := rows.Scan(&item.DeviceID, &item.DeviceName, &item.FieldName, &item.Value, &num, &valueType, &item.CollectedAt); err != nil {
 				return nil, err
 			}
+			item.Value = storedValueText(item.Value, num, valueType)
 			item.FieldName = trimDataPointFieldName(item.FieldName)
 			if item.DeviceID == 0 || item.FieldName == "" {
 				continue
@@ -460,9 +470,12 @@ func appendLatestDeviceFieldRowsFromHistory(db *sql.DB, result []*LatestDeviceDa
 	var currentItem *LatestDeviceData
 	for rows.Next() {
 		var item latestDeviceFieldRow
-		if err := rows.Scan(&item.DeviceID, &item.DeviceName, &item.FieldName, &item.Value, &item.CollectedAt); err != nil {
+		var num sql.NullFloat64
+		var valueType string
+		if err := rows.Scan(&item.DeviceID, &item.DeviceName, &item.FieldName, &item.Value, &num, &valueType, &item.CollectedAt); err != nil {
 			return nil, err
 		}
+		item.Value = storedValueText(item.Value, num, valueType)
 		item.FieldName = trimDataPointFieldName(item.FieldName)
 		if item.DeviceID == 0 || item.FieldName == "" {
 			continue
@@ -501,11 +514,13 @@ func appendLatestDeviceFieldRowsFromCache(db *sql.DB, result []*LatestDeviceData
 		var currentItem *LatestDeviceData
 		for rows.Next() {
 			var deviceID int64
-			var fieldName, value string
+			var fieldName, value, valueType string
+			var num sql.NullFloat64
 			var collectedAt time.Time
-			if err := rows.Scan(&deviceID, &fieldName, &value, &collectedAt); err != nil {
+			if err := rows.Scan(&deviceID, &fieldName, &value, &num, &valueType, &collectedAt); err != nil {
 				return nil, err
 			}
+			value = storedValueText(value, num, valueType)
 			fieldName = trimDataPointFieldName(fieldName)
 			if deviceID == 0 || fieldName == "" {
 				continue
@@ -543,9 +558,12 @@ func appendLatestDeviceFieldRowsFromCache(db *sql.DB, result []*LatestDeviceData
 	var currentItem *LatestDeviceData
 	for rows.Next() {
 		var item latestDeviceFieldRow
-		if err := rows.Scan(&item.DeviceID, &item.FieldName, &item.Value, &item.CollectedAt); err != nil {
+		var num sql.NullFloat64
+		var valueType string
+		if err := rows.Scan(&item.DeviceID, &item.FieldName, &item.Value, &num, &valueType, &item.CollectedAt); err != nil {
 			return nil, err
 		}
+		item.Value = storedValueText(item.Value, num, valueType)
 		item.FieldName = trimDataPointFieldName(item.FieldName)
 		if item.DeviceID == 0 || item.FieldName == "" {
 			continue
@@ -686,6 +704,19 @@ func InsertCollectData(data *models.CollectData) error {
 	return InsertCollectDataWithOptions(data, true)
 }
 
+// insertCollectDataWithHistoryTx 把 data 全部字段写入实时缓存、history 写入历史，返回历史行数；
+// history 为 data 本身时缓存与历史共用一次遍历
+func insertCollectDataWithHistoryTx(tx *sql.Tx, data, history *models.CollectData, cacheStmtCache, historyStmtCache *collectDataStmtCache) (int, error) {
+	if history == nil || history == data {
+		return insertCollectDataWithOptionsTx(tx, data, history != nil, cacheStmtCache, historyStmtCache)
+	}
+	if _, err := insertCollectDataWithOptionsTx(tx, data, false, cacheStmtCache, nil); err != nil {
+		return 0, err
+	}
+	return insertCollectDataWithOptionsTx(tx, history, true, nil, historyStmtCache)
+}
+
+// insertCollectDataWithOptionsTx 写入实时缓存并按需写入历史，返回历史行数；cacheStmtCache 为 nil 时只写历史
 func insertCollectDataWithOptionsTx(tx *sql.Tx, data *models.CollectData, storeHistory bool, cacheStmtCache, historyStmtCache *collectDataStmtCache) (int, error) {
 	if data == nil {
 		return 0, fmt.Errorf("collect data is nil")
@@ -704,20 +735,21 @@ func insertCollectDataWithOptionsTx(tx *sql.Tx, data *models.CollectData, storeH
 		batchSize = collectDataHistoryBatchSize
 	}
 
-	cacheArgs := getCollectDataArgs(batchSize * 3)
+	cacheArgs := getCollectDataArgs(batchSize * 5)
 	defer putCollectDataArgs(cacheArgs)
 	var historyArgs []any
 	if storeHistory {
-		historyArgs = getCollectDataArgs(batchSize * 4)
+		historyArgs = getCollectDataArgs(batchSize * 6)
 		defer putCollectDataArgs(historyArgs)
 	}
 	batchCount := 0
 	historyCount := 0
 	if len(data.Points) == 0 {
 		for field, value := range data.Fields {
-			cacheArgs = appendCollectDataCacheStringArg(cacheArgs, data.DeviceID, field, value)
+			stored := storedCollectValue(value)
+			cacheArgs = appendCollectDataCacheArg(cacheArgs, data.DeviceID, field, stored)
 			if storeHistory {
-				historyArgs = appendCollectDataHistoryStringArg(historyArgs, data.DeviceID, deviceName, field, value)
+				historyArgs = appendCollectDataHistoryArg(historyArgs, data.DeviceID, deviceName, field, stored)
 				historyCount++
 			}
 			batchCount++
@@ -750,9 +782,10 @@ func insertCollectDataWithOptionsTx(tx *sql.Tx, data *models.CollectData, storeH
 			if _, overridden := pointFieldNames[field]; overridden {
 				continue
 			}
-			cacheArgs = appendCollectDataCacheStringArg(cacheArgs, data.DeviceID, field, value)
+			stored := storedCollectValue(value)
+			cacheArgs = appendCollectDataCacheArg(cacheArgs, data.DeviceID, field, stored)
 			if storeHistory {
-				historyArgs = appendCollectDataHistoryStringArg(historyArgs, data.DeviceID, deviceName, field, value)
+				historyArgs = appendCollectDataHistoryArg(historyArgs, data.DeviceID, deviceName, field, stored)
 				historyCount++
 			}
 			batchCount++
@@ -774,10 +807,10 @@ func insertCollectDataWithOptionsTx(tx *sql.Tx, data *models.CollectData, storeH
 		if field == "" {
 			continue
 		}
-		value := models.CollectPointValueString(point.Value)
-		cacheArgs = appendCollectDataCacheStringArg(cacheArgs, data.DeviceID, field, value)
+		stored := storedCollectValue(point.Value)
+		cacheArgs = appendCollectDataCacheArg(cacheArgs, data.DeviceID, field, stored)
 		if storeHistory {
-			historyArgs = appendCollectDataHistoryStringArg(historyArgs, data.DeviceID, deviceName, field, value)
+			historyArgs = appendCollectDataHistoryArg(historyArgs, data.DeviceID, deviceName, field, stored)
 			historyCount++
 		}
 		batchCount++
@@ -867,7 +900,7 @@ func BatchSaveLatestDataPoints(entries []DataPointEntry) error {
 }
 
 func batchSaveLatestDataPointsDirect(entries []DataPointEntry) error {
-	args := getCollectDataArgs(len(entries) * 5)
+	args := getCollectDataArgs(len(entries) * 6)
 	defer putCollectDataArgs(args)
 
 	for _, entry := range entries {
@@ -912,7 +945,7 @@ func batchSaveLatestDataPointsChunkedDirect(entries []DataPointEntry) error {
 	stmtCache := newCollectDataStmtCache(tx, latestDataPointBatchSQLCache.get)
 	defer stmtCache.close()
 
-	args := getCollectDataArgs(collectDataHistoryBatchSize * 5)
+	args := getCollectDataArgs(collectDataHistoryBatchSize * 6)
 	defer putCollectDataArgs(args)
 
 	batchCount := 0
@@ -1000,56 +1033,78 @@ func batchSaveLatestDataPointsStringChunkedDirect(entries []DataPointEntry) erro
 	return nil
 }
 
+// insertCollectDataHistoryTx 在一个事务中写入实时缓存与历史数据，返回历史行数
+func insertCollectDataHistoryTx(data, history *models.CollectData) (int, error) {
+	tx, err := DataDB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin collect data transaction: %w", err)
+	}
+	defer tx.Rollback()
+	cacheStmtCache := newCollectDataStmtCache(tx, collectDataCacheBatchSQLCache.get)
+	defer cacheStmtCache.close()
+	historyStmtCache := newCollectDataStmtCache(tx, collectDataHistoryBatchSQLCache.get)
+	defer historyStmtCache.close()
+	historyCount, err := insertCollectDataWithHistoryTx(tx, data, history, cacheStmtCache, historyStmtCache)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit collect data transaction: %w", err)
+	}
+	return historyCount, nil
+}
+
 // InsertCollectDataWithOptions 写入实时缓存，并按需写入历史数据。
 func InsertCollectDataWithOptions(data *models.CollectData, storeHistory bool) error {
 	if data == nil {
 		return fmt.Errorf("collect data is nil")
 	}
-	if len(data.Fields) == 0 && len(data.Points) == 0 {
-		return nil
-	}
-	if !storeHistory {
-		rowCount := countCollectDataCacheRows(data)
-		if rowCount <= collectDataCacheBatchSize {
-			if err := insertCollectDataCacheDirect(data); err != nil {
-				return err
-			}
-		} else {
-			if err := insertCollectDataCacheChunkedDirect(data); err != nil {
-				return err
-			}
-		}
-		maybeEnforceDataCacheLimit()
-		return nil
+	var history *models.CollectData
+	if storeHistory {
+		history = data
 	}
+	return insertCollectDataWithHistory(data, history)
+}
 
-	tx, err := DataDB.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin collect data transaction: %w", err)
+// insertCollectDataWithHistory 写入 data 全部字段的实时缓存与 history 的历史数据（history 可为 nil）
+func insertCollectDataWithHistory(data, history *models.CollectData) error {
+	var journal *dataJournal
+	if history != nil {
+		journal = beginDataJournalWrite()
 	}
-	defer tx.Rollback()
-	cacheStmtCache := newCollectDataStmtCache(tx, collectDataCacheStringBatchSQLCache.get)
-	defer cacheStmtCache.close()
-	var historyStmtCache *collectDataStmtCache
-	if storeHistory {
-		historyStmtCache = newCollectDataStmtCache(tx, collectDataHistoryStringBatchSQLCache.get)
-		defer historyStmtCache.close()
+	historyCount, err := insertCollectDataRows(data, history)
+	if err == nil && historyCount > 0 {
+		journal.appendCollectData(history)
 	}
-	historyCount, err := insertCollectDataWithOptionsTx(tx, data, storeHistory, cacheStmtCache, historyStmtCache)
+	journal.endWrite()
 	if err != nil {
 		return err
 	}
+	finishCollectDataWrite(historyCount)
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit collect data transaction: %w", err)
+// insertCollectDataRows 写入实时缓存与（按需）历史数据，不追加日志，返回历史行数
+func insertCollectDataRows(data, history *models.CollectData) (int, error) {
+	if len(data.Fields) == 0 && len(data.Points) == 0 {
+		return 0, nil
 	}
+	if history != nil {
+		return insertCollectDataHistoryTx(data, history)
+	}
+	if countCollectDataCacheRows(data) <= collectDataCacheBatchSize {
+		return 0, insertCollectDataCacheDirect(data)
+	}
+	return 0, insertCollectDataCacheChunkedDirect(data)
+}
 
+// finishCollectDataWrite 写入提交且释放写入闸门后执行缓存/历史行数限制并按需触发同步
+func finishCollectDataWrite(historyRows int) {
 	maybeEnforceDataCacheLimit()
-	if storeHistory && historyCount > 0 {
-		noteHistoryRowsWritten(historyCount)
+	if historyRows > 0 {
+		noteHistoryRowsWritten(historyRows)
 		maybeEnforceDataPointsLimit()
 		TriggerSyncIfNeeded()
 	}
-
-	return nil
 }
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,6 +34,7 @@ func prepareDataPointsTestDB(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -44,6 +48,7 @@ func prepareDataPointsTestDB(t *testing.T) {
 		device_id INTEGER NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -92,6 +97,7 @@ func TestGetAllDevicesLatestData_MergeMemoryAndDisk(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -239,7 +245,7 @@ func TestGetLatestDataPoints_MemoryLimitSkipsDisk(t *testing.T) {
 		t.Fatalf("open disk db: %v", err)
 	}
 	defer func() { _ = diskDB.Close() }()
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		t.Fatalf("ensure disk schema: %v", err)
 	}
 
@@ -285,7 +291,7 @@ func TestGetDataPointsByDeviceAndTimeLimit_MemoryLimitSkipsDisk(t *testing.T) {
 		t.Fatalf("open disk db: %v", err)
 	}
 	defer func() { _ = diskDB.Close() }()
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		t.Fatalf("ensure disk schema: %v", err)
 	}
 
@@ -338,7 +344,7 @@ func TestGetDataPointsByDeviceFieldAndTime_MemoryLimitSkipsDisk(t *testing.T) {
 		t.Fatalf("open disk db: %v", err)
 	}
 	defer func() { _ = diskDB.Close() }()
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		t.Fatalf("ensure disk schema: %v", err)
 	}
 
@@ -361,7 +367,7 @@ func TestGetDataPointsByDeviceFieldAndTime_MemoryLimitSkipsDisk(t *testing.T) {
 
 	dataDBFile = diskPath
 
-	items, err := GetDataPointsByDeviceFieldAndTime(1, "temperature", start, end, 1)
+	items, err := GetDataPointsByDeviceFieldAndTime(1, "temperature", start, end, 1, HistoryAggregation{})
 	if err != nil {
 		t.Fatalf("GetDataPointsByDeviceFieldAndTime: %v", err)
 	}
@@ -739,22 +745,14 @@ func TestInsertCollectDataWithOptions_PointsCacheOnlySmallBatch(t *testing.T) {
 		t.Fatalf("InsertCollectDataWithOptions(points, storeHistory=false): %v", err)
 	}
 
-	rows, err := DataDB.Query("SELECT field_name, value FROM data_cache WHERE device_id = ? ORDER BY field_name", data.DeviceID)
+	cache, err := GetDataCacheByDeviceID(data.DeviceID)
 	if err != nil {
-		t.Fatalf("query data_cache: %v", err)
+		t.Fatalf("GetDataCacheByDeviceID: %v", err)
 	}
-	defer rows.Close()
 
 	got := make(map[string]string, 2)
-	for rows.Next() {
-		var field, value string
-		if err := rows.Scan(&field, &value); err != nil {
-			t.Fatalf("scan data_cache: %v", err)
-		}
-		got[field] = value
-	}
-	if err := rows.Err(); err != nil {
-		t.Fatalf("iterate data_cache: %v", err)
+	for _, item := range cache {
+		got[item.FieldName] = item.Value
 	}
 
 	if len(got) != 2 {
@@ -789,22 +787,14 @@ func TestInsertCollectDataWithOptions_MergesFieldsAndPoints(t *testing.T) {
 		t.Fatalf("InsertCollectDataWithOptions(mixed, storeHistory=false): %v", err)
 	}
 
-	rows, err := DataDB.Query("SELECT field_name, value FROM data_cache WHERE device_id = ? ORDER BY field_name", data.DeviceID)
+	cache, err := GetDataCacheByDeviceID(data.DeviceID)
 	if err != nil {
-		t.Fatalf("query data_cache: %v", err)
+		t.Fatalf("GetDataCacheByDeviceID: %v", err)
 	}
-	defer rows.Close()
 
 	got := make(map[string]string, 3)
-	for rows.Next() {
-		var field, value string
-		if err := rows.Scan(&field, &value); err != nil {
-			t.Fatalf("scan data_cache: %v", err)
-		}
-		got[field] = value
-	}
-	if err := rows.Err(); err != nil {
-		t.Fatalf("iterate data_cache: %v", err)
+	for _, item := range cache {
+		got[item.FieldName] = item.Value
 	}
 
 	if len(got) != 3 {
@@ -821,6 +811,65 @@ func TestInsertCollectDataWithOptions_MergesFieldsAndPoints(t *testing.T) {
 	}
 }
 
+func TestInsertCollectDataWithOptions_StoresNativeTypes(t *testing.T) {
+	prepareDataPointsTestDB(t)
+
+	data := &models.CollectData{
+		DeviceID:   79,
+		DeviceName: "dev-79",
+		Timestamp:  time.Now(),
+		Fields:     map[string]string{"serial": "0012"},
+		Points: []models.CollectPoint{
+			{FieldName: "temperature", Value: 21.5},
+			{FieldName: "count", Value: int32(7)},
+			{FieldName: "running", Value: true},
+		},
+	}
+	if err := InsertCollectDataWithOptions(data, true); err != nil {
+		t.Fatalf("InsertCollectDataWithOptions: %v", err)
+	}
+
+	rows, err := DataDB.Query(`SELECT field_name, value, value_num, value_type FROM data_points WHERE device_id = ?`, data.DeviceID)
+	if err != nil {
+		t.Fatalf("query data_points: %v", err)
+	}
+	defer rows.Close()
+	stored := make(map[string]string, 4)
+	for rows.Next() {
+		var field, value, valueType string
+		var num sql.NullFloat64
+		if err := rows.Scan(&field, &value, &num, &valueType); err != nil {
+			t.Fatalf("scan data_points: %v", err)
+		}
+		stored[field] = fmt.Sprintf("%s|%v|%v|%s", value, num.Valid, num.Float64, valueType)
+	}
+	want := map[string]string{
+		"temperature": "|true|21.5|float",
+		"count":       "|true|7|int",
+		"running":     "|true|1|bool",
+		"serial":      "0012|false|0|string",
+	}
+	for field, w := range want {
+		if stored[field] != w {
+			t.Fatalf("%s stored as %q, want %q", field, stored[field], w)
+		}
+	}
+
+	points, err := GetDataPointsByDevice(data.DeviceID, 10)
+	if err != nil {
+		t.Fatalf("GetDataPointsByDevice: %v", err)
+	}
+	payload, err := json.Marshal(points)
+	if err != nil {
+		t.Fatalf("marshal points: %v", err)
+	}
+	for _, fragment := range []string{`"value":21.5`, `"value":7`, `"value":true`, `"value":"0012"`} {
+		if !strings.Contains(string(payload), fragment) {
+			t.Fatalf("history json %s missing %s", payload, fragment)
+		}
+	}
+}
+
 func TestCollectDataWriter_StopFlushesPendingWrites(t *testing.T) {
 	prepareDataPointsTestDB(t)
 
@@ -869,6 +918,87 @@ func TestCollectDataWriter_StopFlushesPendingWrites(t *testing.T) {
 	}
 }
 
+func TestCollectDataWriter_HistorySubsetWritesCacheOnce(t *testing.T) {
+	prepareDataPointsTestDB(t)
+
+	oldTrigger := syncBatchTrigger
+	oldSyncFn := syncDataToDiskFn
+	syncBatchTrigger = int(^uint(0) >> 1)
+	syncDataToDiskFn = func() error { return nil }
+	t.Cleanup(func() {
+		syncBatchTrigger = oldTrigger
+		syncDataToDiskFn = oldSyncFn
+		StopCollectDataWriter()
+	})
+
+	// 写入协程未启动时不入队，先占满队列确认子集写入只产生一个请求
+	collectWriteMu.Lock()
+	collectWriteCh = make(chan collectWriteRequest, 2)
+	collectWriteAlive = true
+	collectWriteMu.Unlock()
+
+	now := time.Now()
+	data := &models.CollectData{
+		DeviceID:   302,
+		DeviceName: "dev-302",
+		Timestamp:  now,
+		Points: []models.CollectPoint{
+			{FieldName: "temperature", Value: 23.5},
+			{FieldName: "humidity", Value: 61},
+		},
+	}
+	history := &models.CollectData{
+		DeviceID:   302,
+		DeviceName: "dev-302",
+		Timestamp:  now,
+		Points:     []models.CollectPoint{{FieldName: "temperature", Value: 23.5}},
+	}
+	if err := EnqueueCollectDataWriteWithHistory(data, history); err != nil {
+		t.Fatalf("EnqueueCollectDataWriteWithHistory: %v", err)
+	}
+
+	collectWriteMu.Lock()
+	ch := collectWriteCh
+	collectWriteCh = nil
+	collectWriteAlive = false
+	collectWriteMu.Unlock()
+	close(ch)
+	var items []collectWriteRequest
+	for item := range ch {
+		items = append(items, item)
+	}
+	if len(items) != 1 {
+		t.Fatalf("queued requests = %d, want 1", len(items))
+	}
+	if err := writeCollectDataBatch(items); err != nil {
+		t.Fatalf("writeCollectDataBatch: %v", err)
+	}
+
+	var cacheCount int
+	if err := DataDB.QueryRow("SELECT COUNT(*) FROM data_cache WHERE device_id = ?", data.DeviceID).Scan(&cacheCount); err != nil {
+		t.Fatalf("count data_cache: %v", err)
+	}
+	if cacheCount != 2 {
+		t.Fatalf("expected cache count 2, got %d", cacheCount)
+	}
+	rows, err := DataDB.Query("SELECT field_name FROM data_points WHERE device_id = ?", data.DeviceID)
+	if err != nil {
+		t.Fatalf("query data_points: %v", err)
+	}
+	defer rows.Close()
+	var fields []string
+	for rows.Next() {
+		var field string
+		if err := rows.Scan(&field); err != nil {
+			t.Fatalf("scan data_points: %v", err)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) != 1 || fields[0] != "temperature" {
+		t.Fatalf("history fields = %v, want [temperature]", fields)
+	}
+}
+
 func TestDeleteHistoryDataByPoint_MemoryAndDisk(t *testing.T) {
 	prepareDataPointsTestDB(t)
 
@@ -892,6 +1022,7 @@ func TestDeleteHistoryDataByPoint_MemoryAndDisk(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name, collected_at)
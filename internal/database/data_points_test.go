@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -24,6 +25,8 @@ func (s stubDataPointScanner) Scan(dest ...any) error {
 			*out = s.values[i].(int64)
 		case *string:
 			*out = s.values[i].(string)
+		case *sql.NullFloat64:
+			*out = s.values[i].(sql.NullFloat64)
 		case *time.Time:
 			*out = s.values[i].(time.Time)
 		}
@@ -36,7 +39,7 @@ func TestScanDataPoint(t *testing.T) {
 	now := time.Now()
 	point := &DataPoint{}
 	scanner := stubDataPointScanner{
-		values: []any{int64(1), int64(2), "dev-2", "temperature", "26.5", "float", now},
+		values: []any{int64(1), int64(2), "dev-2", "temperature", "", sql.NullFloat64{Float64: 26.5, Valid: true}, "float", now},
 	}
 
 	err := scanDataPoint(scanner, point)
@@ -0,0 +1,231 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ==================== 历史数据降采样 (data.db 磁盘：1 分钟 / 1 小时汇总) ====================
+
+const (
+	DefaultRollupMinuteRetentionDays = 90  // 1 分钟汇总默认保留天数
+	DefaultRollupHourRetentionDays   = 730 // 1 小时汇总默认保留天数
+
+	// rollupStateSource 汇总水位记录名：data_points 中已汇总的最大 id
+	rollupStateSource = "data_points"
+
+	// rollupNumericFilter 只汇总数值行（value_num）以及可解析为数字的文本值
+	rollupNumericFilter = `(value_num IS NOT NULL OR (TRIM(value) <> '' AND TRIM(TRIM(value), '0123456789.eE+-') = '' AND value GLOB '*[0-9]*'))`
+
+	// rollupNumericValue 数值行直接取 value_num，文本行按数字解析
+	rollupNumericValue = `COALESCE(value_num, CAST(TRIM(value) AS REAL))`
+)
+
+// rollupTier 降采样层级；桶起点取时间文本前 prefixLen 个字符再补齐 suffix，
+// 同时兼容 CURRENT_TIMESTAMP 与带小数秒的写入格式
+type rollupTier struct {
+	name      string // 同时作为 interval 参数取值
+	table     string
+	bucket    time.Duration
+	prefixLen int
+	suffix    string
+}
+
+var rollupTiers = []rollupTier{
+	{name: "1m", table: "data_rollup_1m", bucket: time.Minute, prefixLen: 16, suffix: ":00"},
+	{name: "1h", table: "data_rollup_1h", bucket: time.Hour, prefixLen: 13, suffix: ":00:00"},
+}
+
+var rollupMu sync.Mutex
+var rollupMinuteRetentionDays = DefaultRollupMinuteRetentionDays
+var rollupHourRetentionDays = DefaultRollupHourRetentionDays
+
+// ApplyRollupRetention 应用降采样层级的保留天数，小于等于0时回退到默认值
+func ApplyRollupRetention(minuteDays, hourDays int) {
+	if minuteDays > 0 {
+		rollupMinuteRetentionDays = minuteDays
+	} else {
+		rollupMinuteRetentionDays = DefaultRollupMinuteRetentionDays
+	}
+	if hourDays > 0 {
+		rollupHourRetentionDays = hourDays
+	} else {
+		rollupHourRetentionDays = DefaultRollupHourRetentionDays
+	}
+	slog.Info("Applied rollup retention", "minute_days", rollupMinuteRetentionDays, "hour_days", rollupHourRetentionDays)
+}
+
+func (t rollupTier) retentionDays() int {
+	if t.bucket >= time.Hour {
+		return rollupHourRetentionDays
+	}
+	return rollupMinuteRetentionDays
+}
+
+func (t rollupTier) bucketExpr(column string) string {
+	return fmt.Sprintf("substr(%s, 1, %d) || '%s'", column, t.prefixLen, t.suffix)
+}
+
+func (t rollupTier) bucketStart(ts string) string {
+	if len(ts) < t.prefixLen {
+		return ts
+	}
+	return ts[:t.prefixLen] + t.suffix
+}
+
+// covers 层级保留期是否覆盖查询起点
+func (t rollupTier) covers(start, now time.Time) bool {
+	days := t.retentionDays()
+	return start.IsZero() || days <= 0 || !start.Before(now.AddDate(0, 0, -days))
+}
+
+// RefreshDataRollups 将已落盘但尚未汇总的原始数据汇总进各降采样层级
+func RefreshDataRollups() error {
+	if dataDBFile == "" {
+		return nil
+	}
+	if _, err := os.Stat(dataDBFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	rollupMu.Lock()
+	defer rollupMu.Unlock()
+
+	diskDB, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
+	if err != nil {
+		return fmt.Errorf("failed to open data database: %w", err)
+	}
+	defer diskDB.Close()
+
+	if err := prepareDataDiskDB(diskDB); err != nil {
+		return err
+	}
+	return refreshDataRollups(diskDB)
+}
+
+// refreshDataRollups 以 data_points.id 为水位：找出新增行中最早的时间，
+// 从该时间所在的桶开始重算各层级（上一层级作为下一层级的数据源），重算结果整体覆盖写入
+func refreshDataRollups(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin rollup transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var watermark, maxID int64
+	if err := tx.QueryRow(`SELECT
+		IFNULL((SELECT source_id FROM data_rollup_state WHERE name = ?), 0),
+		IFNULL((SELECT MAX(id) FROM data_points), 0)`, rollupStateSource).Scan(&watermark, &maxID); err != nil {
+		return fmt.Errorf("failed to read rollup watermark: %w", err)
+	}
+	if maxID == watermark {
+		return nil
+	}
+	if maxID < watermark {
+		// data_points 被重建过，按当前最大 id 重新起算
+		watermark = 0
+	}
+
+	var since sql.NullString
+	if err := tx.QueryRow(`SELECT MIN(collected_at) FROM data_points WHERE id > ? AND id <= ?`, watermark, maxID).Scan(&since); err != nil {
+		return fmt.Errorf("failed to read rollup start: %w", err)
+	}
+
+	if since.Valid {
+		for i, tier := range rollupTiers {
+			var source string
+			if i == 0 {
+				source = buildRawRollupSQL(tier)
+			} else {
+				source = buildTierRollupSQL(tier, rollupTiers[i-1])
+			}
+			if _, err := tx.Exec(source, tier.bucketStart(since.String)); err != nil {
+				return fmt.Errorf("failed to refresh %s: %w", tier.table, err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO data_rollup_state (name, source_id) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET source_id = excluded.source_id`, rollupStateSource, maxID); err != nil {
+		return fmt.Errorf("failed to update rollup watermark: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollup transaction: %w", err)
+	}
+	return nil
+}
+
+// buildRawRollupSQL 由原始数据生成最细层级；last 取桶内时间最晚的值
+func buildRawRollupSQL(tier rollupTier) string {
+	bucket := tier.bucketExpr("collected_at")
+	return fmt.Sprintf(`INSERT OR REPLACE INTO %s
+		(device_id, device_name, field_name, bucket, min_value, max_value, avg_value, last_value, count)
+		SELECT device_id, MAX(device_name), field_name, b, MIN(v), MAX(v), AVG(v), MAX(last_v), COUNT(*)
+		FROM (
+			SELECT device_id, device_name, field_name, %[2]s AS b, %[4]s AS v,
+				FIRST_VALUE(%[4]s) OVER (
+					PARTITION BY device_id, field_name, %[2]s ORDER BY collected_at DESC, id DESC
+				) AS last_v
+			FROM data_points
+			WHERE collected_at >= ? AND %[3]s
+		)
+		GROUP BY device_id, field_name, b`, tier.table, bucket, rollupNumericFilter, rollupNumericValue)
+}
+
+// buildTierRollupSQL 由上一层级合并生成更粗的层级
+func buildTierRollupSQL(tier, source rollupTier) string {
+	bucket := tier.bucketExpr("bucket")
+	return fmt.Sprintf(`INSERT OR REPLACE INTO %s
+		(device_id, device_name, field_name, bucket, min_value, max_value, avg_value, last_value, count)
+		SELECT device_id, MAX(device_name), field_name, b, MIN(min_value), MAX(max_value),
+			SUM(avg_value * count) / SUM(count), MAX(last_v), SUM(count)
+		FROM (
+			SELECT device_id, device_name, field_name, %[3]s AS b, min_value, max_value, avg_value, count,
+				FIRST_VALUE(last_value) OVER (
+					PARTITION BY device_id, field_name, %[3]s ORDER BY bucket DESC
+				) AS last_v
+			FROM %[2]s
+			WHERE bucket >= ?
+		)
+		GROUP BY device_id, field_name, b`, tier.table, source.table, bucket)
+}
+
+// cleanupRollupsOnDisk 按各层级保留天数清理过期汇总
+func cleanupRollupsOnDisk(db *sql.DB) (int64, error) {
+	var deleted int64
+	for _, tier := range rollupTiers {
+		days := tier.retentionDays()
+		if days <= 0 {
+			continue
+		}
+		result, err := db.Exec(
+			fmt.Sprintf(`DELETE FROM %s WHERE bucket < datetime('now', ?)`, tier.table),
+			fmt.Sprintf("-%d days", days),
+		)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to cleanup %s: %w", tier.table, err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+func deleteRollupsByPoint(db *sql.DB, deviceID int64, fieldName string) error {
+	for _, tier := range rollupTiers {
+		if _, err := db.Exec(
+			fmt.Sprintf(`DELETE FROM %s WHERE device_id = ? AND field_name = ?`, tier.table),
+			deviceID, fieldName,
+		); err != nil {
+			return fmt.Errorf("failed to delete %s rows: %w", tier.table, err)
+		}
+	}
+	return nil
+}
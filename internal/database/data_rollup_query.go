@@ -0,0 +1,318 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// 历史查询聚合函数
+const (
+	HistoryAggAvg   = "avg"
+	HistoryAggMin   = "min"
+	HistoryAggMax   = "max"
+	HistoryAggLast  = "last"
+	HistoryAggCount = "count"
+	HistoryAggSum   = "sum"
+)
+
+// defaultAggregatedHistoryRange 聚合查询未指定起点时的默认时间范围
+const defaultAggregatedHistoryRange = 24 * time.Hour
+
+var historyAggFuncs = []string{HistoryAggAvg, HistoryAggMin, HistoryAggMax, HistoryAggLast, HistoryAggCount, HistoryAggSum}
+
+// historyAutoIntervals interval=auto 时可选的桶宽，取能把结果控制在 limit 以内的最小值
+var historyAutoIntervals = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// HistoryAggregation 历史查询降采样参数。
+// Func 为空表示返回原始数据；Interval 为 0 时按时间范围和 limit 自动选取桶宽。
+type HistoryAggregation struct {
+	Interval time.Duration
+	Func     string
+}
+
+// Enabled 是否按桶聚合
+func (a HistoryAggregation) Enabled() bool {
+	return a.Func != ""
+}
+
+// IsValidHistoryAggFunc 聚合函数是否受支持
+func IsValidHistoryAggFunc(fn string) bool {
+	return slices.Contains(historyAggFuncs, fn)
+}
+
+// MinHistoryAggInterval 聚合桶宽下限，等于最细的汇总层级
+func MinHistoryAggInterval() time.Duration {
+	return rollupTiers[0].bucket
+}
+
+func resolveHistoryInterval(start, end time.Time, limit int) time.Duration {
+	span := end.Sub(start)
+	for _, candidate := range historyAutoIntervals {
+		if int(span/candidate) <= limit {
+			return candidate
+		}
+	}
+	return historyAutoIntervals[len(historyAutoIntervals)-1]
+}
+
+// selectRollupTier 选取桶宽能整除 interval 的最粗层级；该层级保留期不覆盖查询起点时继续换更粗的层级
+func selectRollupTier(interval time.Duration, start, now time.Time) rollupTier {
+	index := 0
+	for i, tier := range rollupTiers {
+		if tier.bucket <= interval && interval%tier.bucket == 0 {
+			index = i
+		}
+	}
+	for index < len(rollupTiers)-1 && !rollupTiers[index].covers(start, now) {
+		index++
+	}
+	return rollupTiers[index]
+}
+
+// rollupAccumulator 一个输出桶内的部分聚合结果，可合并汇总层级的行与未汇总的原始值
+type rollupAccumulator struct {
+	min, max, sum float64
+	count         int64
+	first, last   float64
+	firstAt       time.Time
+	lastAt        time.Time
+}
+
+func (a *rollupAccumulator) add(min, max, sum, last float64, count int64, at time.Time) {
+	if count <= 0 {
+		return
+	}
+	if a.count == 0 || min < a.min {
+		a.min = min
+	}
+	if a.count == 0 || max > a.max {
+		a.max = max
+	}
+	a.sum += sum
+	a.count += count
+	if !at.Before(a.lastAt) {
+		a.last = last
+		a.lastAt = at
+	}
+}
+
+// addSample 累加一个原始值，同时记录桶内首值（汇总层级不保存首值）
+func (a *rollupAccumulator) addSample(v float64, at time.Time) {
+	if a.count == 0 || at.Before(a.firstAt) {
+		a.first = v
+		a.firstAt = at
+	}
+	a.add(v, v, v, v, 1, at)
+}
+
+func (a *rollupAccumulator) value(fn string) float64 {
+	switch fn {
+	case HistoryAggFirst:
+		return a.first
+	case HistoryAggDiff:
+		return a.last - a.first
+	case HistoryAggMin:
+		return a.min
+	case HistoryAggMax:
+		return a.max
+	case HistoryAggLast:
+		return a.last
+	case HistoryAggCount:
+		return float64(a.count)
+	case HistoryAggSum:
+		return a.sum
+	default:
+		return a.sum / float64(a.count)
+	}
+}
+
+// historyBuckets 按 interval 对齐的输出桶
+type historyBuckets struct {
+	interval   time.Duration
+	deviceName string
+	items      map[int64]*rollupAccumulator
+}
+
+func (b *historyBuckets) get(at time.Time) *rollupAccumulator {
+	key := at.Truncate(b.interval).Unix()
+	acc := b.items[key]
+	if acc == nil {
+		acc = &rollupAccumulator{}
+		b.items[key] = acc
+	}
+	return acc
+}
+
+func (b *historyBuckets) addRaw(deviceName, value string, num sql.NullFloat64, at time.Time) {
+	v := num.Float64
+	if !num.Valid {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return
+		}
+		v = parsed
+	}
+	if b.deviceName == "" {
+		b.deviceName = deviceName
+	}
+	b.get(at).addSample(v, at)
+}
+
+// getAggregatedDataPoints 聚合查询：磁盘汇总层级 + 磁盘上尚未汇总的原始数据 + 内存中尚未落盘的原始数据。
+// 持有同步锁，避免同一行在落盘过程中被内存和磁盘各计一次。
+func getAggregatedDataPoints(deviceID int64, fieldName string, startTime, endTime time.Time, limit int, agg HistoryAggregation) ([]*DataPoint, error) {
+	now := time.Now()
+	if endTime.IsZero() {
+		endTime = now
+	}
+	if startTime.IsZero() {
+		startTime = endTime.Add(-defaultAggregatedHistoryRange)
+	}
+	interval := agg.Interval
+	if interval <= 0 {
+		interval = resolveHistoryInterval(startTime, endTime, limit)
+	}
+	tier := selectRollupTier(interval, startTime, now)
+	start := formatSQLiteTime(startTime.UTC())
+	end := formatSQLiteTime(endTime.UTC())
+
+	buckets := &historyBuckets{interval: interval, items: make(map[int64]*rollupAccumulator)}
+
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	if dataDBFile != "" {
+		if err := accumulateDiskHistory(buckets, tier, deviceID, fieldName, start, end); err != nil {
+			if !os.IsNotExist(err) {
+				slog.Warn("Failed to read aggregated history from disk", "error", err)
+			}
+		}
+	}
+
+	rows, err := DataDB.Query(`SELECT device_name, value, value_num, collected_at FROM data_points
+		WHERE device_id = ? AND field_name = ? AND collected_at >= ? AND collected_at <= ?`,
+		deviceID, fieldName, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if err := accumulateRawRows(buckets, rows); err != nil {
+		return nil, err
+	}
+
+	return buildAggregatedDataPoints(buckets, deviceID, fieldName, agg.Func, limit), nil
+}
+
+// accumulateDiskHistory 在同一个读事务中读取汇总水位、汇总层级和水位之后的原始数据
+func accumulateDiskHistory(buckets *historyBuckets, tier rollupTier, deviceID int64, fieldName, start, end string) error {
+	db, err := openDataDiskDB()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var watermark int64
+	err = tx.QueryRow(`SELECT IFNULL((SELECT source_id FROM data_rollup_state WHERE name = ?), 0)`, rollupStateSource).Scan(&watermark)
+	switch {
+	case err == nil:
+		if err := accumulateRollupRows(buckets, tx, tier, deviceID, fieldName, start, end); err != nil {
+			return err
+		}
+	case isNoSuchTableError(err):
+		// 尚未生成过汇总，全部按原始数据聚合
+		watermark = 0
+	default:
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT device_name, value, value_num, collected_at FROM data_points
+		WHERE id > ? AND device_id = ? AND field_name = ? AND collected_at >= ? AND collected_at <= ?`,
+		watermark, deviceID, fieldName, start, end)
+	if err != nil {
+		return err
+	}
+	return accumulateRawRows(buckets, rows)
+}
+
+func accumulateRollupRows(buckets *historyBuckets, tx *sql.Tx, tier rollupTier, deviceID int64, fieldName, start, end string) error {
+	rows, err := tx.Query(fmt.Sprintf(`SELECT device_name, bucket, min_value, max_value, avg_value, last_value, count
+		FROM %s WHERE device_id = ? AND field_name = ? AND bucket >= ? AND bucket <= ?`, tier.table),
+		deviceID, fieldName, tier.bucketStart(start), end)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deviceName string
+		var bucket time.Time
+		var min, max, avg, last float64
+		var count int64
+		if err := rows.Scan(&deviceName, &bucket, &min, &max, &avg, &last, &count); err != nil {
+			return err
+		}
+		if buckets.deviceName == "" {
+			buckets.deviceName = deviceName
+		}
+		buckets.get(bucket).add(min, max, avg*float64(count), last, count, bucket)
+	}
+	return rows.Err()
+}
+
+func accumulateRawRows(buckets *historyBuckets, rows *sql.Rows) error {
+	defer rows.Close()
+	for rows.Next() {
+		var deviceName, value string
+		var num sql.NullFloat64
+		var collectedAt time.Time
+		if err := rows.Scan(&deviceName, &value, &num, &collectedAt); err != nil {
+			return err
+		}
+		buckets.addRaw(deviceName, value, num, collectedAt)
+	}
+	return rows.Err()
+}
+
+// buildAggregatedDataPoints 与原始查询一致按时间倒序返回，超出 limit 时保留最新的桶
+func buildAggregatedDataPoints(buckets *historyBuckets, deviceID int64, fieldName, fn string, limit int) []*DataPoint {
+	keys := make([]int64, 0, len(buckets.items))
+	for key := range buckets.items {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	slices.Reverse(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	valueType := models.ValueTypeFloat
+	if fn == HistoryAggCount {
+		valueType = models.ValueTypeInt
+	}
+	points := make([]*DataPoint, 0, len(keys))
+	for _, key := range keys {
+		points = append(points, &DataPoint{
+			DeviceID:    deviceID,
+			DeviceName:  normalizeDeviceName(deviceID, buckets.deviceName),
+			FieldName:   fieldName,
+			Value:       strconv.FormatFloat(buckets.items[key].value(fn), 'f', -1, 64),
+			ValueType:   valueType,
+			CollectedAt: time.Unix(key, 0).UTC(),
+		})
+	}
+	return points
+}
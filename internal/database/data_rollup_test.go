@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func prepareRollupDiskDB(t *testing.T) *sql.DB {
+	t.Helper()
+	prepareDataPointsTestDB(t)
+
+	oldDataDBFile := dataDBFile
+	t.Cleanup(func() {
+		closeCachedDataDiskDBForPath(dataDBFile)
+		dataDBFile = oldDataDBFile
+	})
+
+	dataDBFile = filepath.Join(t.TempDir(), "data.db")
+	diskDB, err := openSQLite(dataDBFile, 1, 1)
+	if err != nil {
+		t.Fatalf("open disk db: %v", err)
+	}
+	t.Cleanup(func() { _ = diskDB.Close() })
+	if err := prepareDataDiskDB(diskDB); err != nil {
+		t.Fatalf("ensure disk schema: %v", err)
+	}
+	return diskDB
+}
+
+// prepareDiskSchemaMemoryDB 在 prepareRollupDiskDB 基础上把内存库 data_points 换成磁盘库结构（含 value_num 等列）
+func prepareDiskSchemaMemoryDB(t *testing.T) *sql.DB {
+	t.Helper()
+	diskDB := prepareRollupDiskDB(t)
+	if _, err := DataDB.Exec(`DROP TABLE data_points`); err != nil {
+		t.Fatalf("drop memory table: %v", err)
+	}
+	if err := migrateSchema(DataDB, migrationSetDataDisk); err != nil {
+		t.Fatalf("memory schema: %v", err)
+	}
+	return diskDB
+}
+
+func insertRollupTestRows(t *testing.T, db *sql.DB, rows map[time.Time]string) {
+	t.Helper()
+	for at, value := range rows {
+		if _, err := db.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, collected_at) VALUES (1, 'dev-1', 'temp', ?, ?)`,
+			value, formatSQLiteTime(at.UTC())); err != nil {
+			t.Fatalf("insert row: %v", err)
+		}
+	}
+}
+
+func TestRefreshDataRollups_IncrementalMinuteAndHour(t *testing.T) {
+	diskDB := prepareRollupDiskDB(t)
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+
+	insertRollupTestRows(t, diskDB, map[time.Time]string{
+		hour.Add(10 * time.Second): "10",
+		hour.Add(40 * time.Second): "20",
+		hour.Add(50 * time.Second): "bad",
+		hour.Add(5 * time.Minute):  "30",
+	})
+	if err := refreshDataRollups(diskDB); err != nil {
+		t.Fatalf("refreshDataRollups() error = %v", err)
+	}
+
+	var min, max, avg, last float64
+	var count int64
+	if err := diskDB.QueryRow(`SELECT min_value, max_value, avg_value, last_value, count FROM data_rollup_1m WHERE bucket = ?`,
+		formatSQLiteTime(hour)).Scan(&min, &max, &avg, &last, &count); err != nil {
+		t.Fatalf("query minute rollup: %v", err)
+	}
+	if min != 10 || max != 20 || avg != 15 || last != 20 || count != 2 {
+		t.Fatalf("minute rollup min=%v max=%v avg=%v last=%v count=%d", min, max, avg, last, count)
+	}
+
+	// 新增一行只重算其所在及之后的桶，小时层级随之更新
+	insertRollupTestRows(t, diskDB, map[time.Time]string{hour.Add(5*time.Minute + 30*time.Second): "60"})
+	if err := refreshDataRollups(diskDB); err != nil {
+		t.Fatalf("refreshDataRollups() second run error = %v", err)
+	}
+	if err := diskDB.QueryRow(`SELECT min_value, max_value, avg_value, last_value, count FROM data_rollup_1h WHERE bucket = ?`,
+		formatSQLiteTime(hour)).Scan(&min, &max, &avg, &last, &count); err != nil {
+		t.Fatalf("query hour rollup: %v", err)
+	}
+	if min != 10 || max != 60 || avg != 30 || last != 60 || count != 4 {
+		t.Fatalf("hour rollup min=%v max=%v avg=%v last=%v count=%d", min, max, avg, last, count)
+	}
+}
+
+func TestGetDataPointsByDeviceFieldAndTime_AggregatesRollupsAndPendingRows(t *testing.T) {
+	diskDB := prepareRollupDiskDB(t)
+	hour := time.Now().UTC().Truncate(2 * time.Hour).Add(-4 * time.Hour)
+
+	insertRollupTestRows(t, diskDB, map[time.Time]string{
+		hour.Add(time.Minute):             "1",
+		hour.Add(time.Hour):               "4",
+		hour.Add(time.Hour + time.Minute): "6",
+	})
+	if err := refreshDataRollups(diskDB); err != nil {
+		t.Fatalf("refreshDataRollups() error = %v", err)
+	}
+	// 已落盘但未汇总
+	insertRollupTestRows(t, diskDB, map[time.Time]string{hour.Add(2 * time.Minute): "3"})
+	// 尚在内存
+	if _, err := DataDB.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, collected_at) VALUES (1, 'dev-1', 'temp', '8', ?)`,
+		formatSQLiteTime(hour.Add(time.Hour+2*time.Minute))); err != nil {
+		t.Fatalf("insert memory row: %v", err)
+	}
+
+	points, err := GetDataPointsByDeviceFieldAndTime(1, "temp", hour, hour.Add(2*time.Hour), 0, HistoryAggregation{Interval: time.Hour, Func: HistoryAggAvg})
+	if err != nil {
+		t.Fatalf("GetDataPointsByDeviceFieldAndTime() error = %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("points=%d, want 2 hourly buckets", len(points))
+	}
+	if !points[0].CollectedAt.Equal(hour.Add(time.Hour)) || points[0].Value != "6" {
+		t.Fatalf("latest bucket=%+v", points[0])
+	}
+	if !points[1].CollectedAt.Equal(hour) || points[1].Value != "2" || points[1].DeviceName != "dev-1" {
+		t.Fatalf("earliest bucket=%+v", points[1])
+	}
+
+	points, err = GetDataPointsByDeviceFieldAndTime(1, "temp", hour, hour.Add(2*time.Hour), 0, HistoryAggregation{Interval: 2 * time.Hour, Func: HistoryAggLast})
+	if err != nil || len(points) != 1 || points[0].Value != "8" {
+		t.Fatalf("last over 2h points=%v err=%v", points, err)
+	}
+}
+
+func TestSelectRollupTier(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		interval time.Duration
+		start    time.Time
+		want     string
+	}{
+		{time.Minute, now.Add(-time.Hour), "1m"},
+		{15 * time.Minute, now.Add(-time.Hour), "1m"},
+		{2 * time.Hour, now.Add(-time.Hour), "1h"},
+		{5 * time.Minute, now.AddDate(0, 0, -(DefaultRollupMinuteRetentionDays + 1)), "1h"},
+	}
+	for _, tc := range cases {
+		if got := selectRollupTier(tc.interval, tc.start, now); got.name != tc.want {
+			t.Fatalf("selectRollupTier(%s) = %s, want %s", tc.interval, got.name, tc.want)
+		}
+	}
+
+	if got := resolveHistoryInterval(now.Add(-30*24*time.Hour), now, 2000); got != 30*time.Minute {
+		t.Fatalf("resolveHistoryInterval(30d) = %s, want 30m", got)
+	}
+}
@@ -0,0 +1,293 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// ==================== data.db 磁盘保护（字节预算 + 磁盘紧急模式） ====================
+
+const (
+	DefaultDiskEmergencyPercent = 95 // 磁盘使用率达到该值时进入紧急模式
+	DefaultDiskResumePercent    = 90 // 磁盘使用率回落到该值以下时退出紧急模式
+
+	// dataDiskPruneTargetRatio 超出预算后修剪到预算的 90%，避免每次同步都触发修剪
+	dataDiskPruneTargetRatio = 0.9
+	dataDiskPruneMinRows     = 1000
+	dataDiskPruneMaxRounds   = 50
+
+	// sqliteAutoVacuumIncremental PRAGMA auto_vacuum 返回值：0=NONE 1=FULL 2=INCREMENTAL
+	sqliteAutoVacuumIncremental = 2
+)
+
+var dataDiskMaxBytes atomic.Int64
+
+var diskPressureMu sync.Mutex
+var diskEmergencyPercent float64 = DefaultDiskEmergencyPercent
+var diskResumePercent float64 = DefaultDiskResumePercent
+var diskUsagePercent float64
+var diskEmergencySince time.Time
+
+var dataDiskEmergency atomic.Bool
+var historySuppressedPoints atomic.Int64
+var dataDiskPrunedRows atomic.Int64
+
+// DataStorageStatus data.db 磁盘保护状态
+type DataStorageStatus struct {
+	MaxDiskBytes      int64   `json:"max_disk_bytes"`
+	DiskFileBytes     int64   `json:"disk_file_bytes"`
+	DiskUsagePercent  float64 `json:"disk_usage_percent"`
+	EmergencyPercent  float64 `json:"emergency_percent"`
+	ResumePercent     float64 `json:"resume_percent"`
+	Emergency         bool    `json:"emergency"`
+	EmergencySince    string  `json:"emergency_since,omitempty"`
+	SuppressedHistory int64   `json:"suppressed_history_points"`
+	BudgetPrunedRows  int64   `json:"budget_pruned_rows"`
+}
+
+// ApplyDataDiskBudget 设置 data.db 磁盘文件字节预算，小于等于0表示不限制
+func ApplyDataDiskBudget(maxBytes int64) {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	dataDiskMaxBytes.Store(maxBytes)
+	slog.Info("Applied data disk budget", "max_bytes", maxBytes)
+}
+
+// ApplyDiskPressureThresholds 设置进入/退出紧急模式的磁盘使用率（百分比），非法值回退到默认值
+func ApplyDiskPressureThresholds(emergencyPercent, resumePercent int) {
+	if emergencyPercent <= 0 || emergencyPercent > 100 {
+		emergencyPercent = DefaultDiskEmergencyPercent
+	}
+	if resumePercent <= 0 || resumePercent >= emergencyPercent {
+		resumePercent = min(DefaultDiskResumePercent, emergencyPercent-1)
+	}
+
+	diskPressureMu.Lock()
+	diskEmergencyPercent = float64(emergencyPercent)
+	diskResumePercent = float64(resumePercent)
+	diskPressureMu.Unlock()
+	slog.Info("Applied disk pressure thresholds", "emergency_percent", emergencyPercent, "resume_percent", resumePercent)
+}
+
+// UpdateDiskUsage 上报磁盘使用率（由系统属性采集调用），按阈值进入或退出紧急模式
+func UpdateDiskUsage(usagePercent float64) {
+	diskPressureMu.Lock()
+	diskUsagePercent = usagePercent
+	enter := !dataDiskEmergency.Load() && usagePercent >= diskEmergencyPercent
+	exit := dataDiskEmergency.Load() && usagePercent < diskResumePercent
+	switch {
+	case enter:
+		dataDiskEmergency.Store(true)
+		diskEmergencySince = time.Now()
+	case exit:
+		dataDiskEmergency.Store(false)
+		diskEmergencySince = time.Time{}
+	}
+	diskPressureMu.Unlock()
+
+	switch {
+	case enter:
+		slog.Error("Disk emergency: history writes suspended", "disk_usage", usagePercent)
+		// 配置了字节预算时立即修剪一次，尽快释放空间
+		go func() {
+			if _, err := EnforceDataDiskBudget(); err != nil {
+				slog.Warn("Emergency data disk prune failed", "error", err)
+			}
+		}()
+	case exit:
+		slog.Warn("Disk emergency cleared: history writes resumed", "disk_usage", usagePercent,
+			"suppressed_points", historySuppressedPoints.Load())
+	}
+}
+
+// IsDataDiskEmergency 是否处于磁盘紧急模式（暂停历史写入与落盘）
+func IsDataDiskEmergency() bool {
+	return dataDiskEmergency.Load()
+}
+
+// GetDataStorageStatus 返回 data.db 磁盘保护状态
+func GetDataStorageStatus() *DataStorageStatus {
+	diskPressureMu.Lock()
+	status := &DataStorageStatus{
+		MaxDiskBytes:      dataDiskMaxBytes.Load(),
+		DiskUsagePercent:  diskUsagePercent,
+		EmergencyPercent:  diskEmergencyPercent,
+		ResumePercent:     diskResumePercent,
+		Emergency:         dataDiskEmergency.Load(),
+		SuppressedHistory: historySuppressedPoints.Load(),
+		BudgetPrunedRows:  dataDiskPrunedRows.Load(),
+	}
+	if !diskEmergencySince.IsZero() {
+		status.EmergencySince = diskEmergencySince.UTC().Format(time.RFC3339)
+	}
+	diskPressureMu.Unlock()
+
+	if dataDBFile != "" {
+		if info, err := os.Stat(dataDBFile); err == nil {
+			status.DiskFileBytes = info.Size()
+		}
+	}
+	return status
+}
+
+// suppressHistoryOnDiskEmergency 紧急模式下把历史写入降级为只更新实时缓存
+func suppressHistoryOnDiskEmergency(data *models.CollectData, storeHistory bool) bool {
+	if !storeHistory || !dataDiskEmergency.Load() {
+		return storeHistory
+	}
+	points := len(data.Points)
+	if points == 0 {
+		points = len(data.Fields)
+	}
+	historySuppressedPoints.Add(int64(points))
+	return false
+}
+
+// EnforceDataDiskBudget 打开磁盘 data.db 执行一次字节预算修剪，返回删除的历史行数
+func EnforceDataDiskBudget() (int64, error) {
+	if dataDiskMaxBytes.Load() <= 0 || dataDBFile == "" {
+		return 0, nil
+	}
+	if _, err := os.Stat(dataDBFile); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	diskDB, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open data database: %w", err)
+	}
+	defer diskDB.Close()
+	return enforceDataDiskBudget(diskDB)
+}
+
+// dataDiskUsage 磁盘库页面占用
+type dataDiskUsage struct {
+	pageSize  int64
+	pageCount int64
+	freePages int64
+}
+
+// usedBytes 有效数据占用字节（不含空闲页）
+func (u dataDiskUsage) usedBytes() int64 {
+	return (u.pageCount - u.freePages) * u.pageSize
+}
+
+func readDataDiskUsage(db *sql.DB) (dataDiskUsage, error) {
+	var usage dataDiskUsage
+	if err := db.QueryRow(`PRAGMA page_size`).Scan(&usage.pageSize); err != nil {
+		return usage, err
+	}
+	if err := db.QueryRow(`PRAGMA page_count`).Scan(&usage.pageCount); err != nil {
+		return usage, err
+	}
+	if err := db.QueryRow(`PRAGMA freelist_count`).Scan(&usage.freePages); err != nil {
+		return usage, err
+	}
+	return usage, nil
+}
+
+// enforceDataDiskBudget 超出预算时按 id 从旧到新删除 data_points（id 即落盘顺序），
+// 直到有效占用降到预算的 90%，随后增量回收空闲页缩小文件
+func enforceDataDiskBudget(db *sql.DB) (int64, error) {
+	limit := dataDiskMaxBytes.Load()
+	if limit <= 0 {
+		return 0, nil
+	}
+	usage, err := readDataDiskUsage(db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read data disk usage: %w", err)
+	}
+	if usage.usedBytes() <= limit {
+		if usage.pageCount*usage.pageSize > limit && usage.freePages > 0 {
+			reclaimDataDiskPages(db)
+		}
+		return 0, nil
+	}
+
+	before := usage.usedBytes()
+	target := int64(float64(limit) * dataDiskPruneTargetRatio)
+	var deleted int64
+	for round := 0; round < dataDiskPruneMaxRounds && usage.usedBytes() > target; round++ {
+		var minID, maxID int64
+		if err := db.QueryRow(`SELECT IFNULL(MIN(id), 0), IFNULL(MAX(id), 0) FROM data_points`).Scan(&minID, &maxID); err != nil {
+			return deleted, err
+		}
+		if maxID == 0 {
+			slog.Warn("data.db exceeds byte budget but no history rows left to prune",
+				"used_bytes", usage.usedBytes(), "max_bytes", limit)
+			break
+		}
+
+		// 按超出比例估算本轮删除行数
+		used := usage.usedBytes()
+		rows := (maxID - minID + 1) * (used - target) / used
+		rows = max(rows, dataDiskPruneMinRows)
+		result, err := db.Exec(`DELETE FROM data_points WHERE id < ?`, minID+rows)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to prune data points: %w", err)
+		}
+		affected, _ := result.RowsAffected()
+		deleted += affected
+
+		if usage, err = readDataDiskUsage(db); err != nil {
+			return deleted, fmt.Errorf("failed to read data disk usage: %w", err)
+		}
+	}
+
+	reclaimDataDiskPages(db)
+	dataDiskPrunedRows.Add(deleted)
+	slog.Warn("Pruned data.db to byte budget", "deleted", deleted,
+		"before_bytes", before, "after_bytes", usage.usedBytes(), "max_bytes", limit)
+	return deleted, nil
+}
+
+// reclaimDataDiskPages 增量回收空闲页（需 auto_vacuum=INCREMENTAL，否则为空操作）。
+// incremental_vacuum 每回收一页单步执行一次，需读完结果集，Exec 只会回收一页
+func reclaimDataDiskPages(db *sql.DB) {
+	rows, err := db.Query(`PRAGMA incremental_vacuum`)
+	if err == nil {
+		for rows.Next() {
+		}
+		err = rows.Err()
+		rows.Close()
+	}
+	if err != nil {
+		slog.Warn("Failed to run incremental vacuum on data.db", "error", err)
+	}
+}
+
+// ensureDataDiskIncrementalVacuum 已有 data.db 不是增量回收模式时执行一次 VACUUM 转换；
+// 只在配置了字节预算时转换，避免无预算部署在启动时承担整库重写
+func ensureDataDiskIncrementalVacuum(db *sql.DB) {
+	if dataDiskMaxBytes.Load() <= 0 {
+		return
+	}
+	var mode int
+	if err := db.QueryRow(`PRAGMA auto_vacuum`).Scan(&mode); err != nil || mode == sqliteAutoVacuumIncremental {
+		return
+	}
+	start := time.Now()
+	if _, err := db.Exec(`PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+		slog.Warn("Failed to set data.db auto_vacuum", "error", err)
+		return
+	}
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		slog.Warn("Failed to convert data.db to incremental vacuum", "error", err)
+		return
+	}
+	slog.Info("Converted data.db to incremental vacuum", "elapsed", time.Since(start))
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func TestEnforceDataDiskBudget_PrunesOldestRowsAndShrinksFile(t *testing.T) {
+	diskDB := prepareRollupDiskDB(t)
+	t.Cleanup(func() { dataDiskMaxBytes.Store(0) })
+
+	base := time.Now().UTC().Add(-24 * time.Hour)
+	filler := strings.Repeat("x", 200)
+	tx, err := diskDB.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	for i := 0; i < 20000; i++ {
+		if _, err := tx.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, collected_at) VALUES (1, 'dev-1', 'f', ?, ?)`,
+			filler, formatSQLiteTime(base.Add(time.Duration(i)*time.Second))); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	before, err := readDataDiskUsage(diskDB)
+	if err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	limit := before.usedBytes() / 2
+	dataDiskMaxBytes.Store(limit)
+
+	deleted, err := enforceDataDiskBudget(diskDB)
+	if err != nil {
+		t.Fatalf("enforceDataDiskBudget() error = %v", err)
+	}
+	if deleted == 0 {
+		t.Fatalf("expected rows to be pruned")
+	}
+
+	after, err := readDataDiskUsage(diskDB)
+	if err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	if after.usedBytes() > limit {
+		t.Fatalf("used bytes=%d, want <= %d", after.usedBytes(), limit)
+	}
+	if after.freePages != 0 || after.pageCount >= before.pageCount {
+		t.Fatalf("file not shrunk: before=%+v after=%+v", before, after)
+	}
+
+	var minID, maxID, count int64
+	if err := diskDB.QueryRow(`SELECT MIN(id), MAX(id), COUNT(*) FROM data_points`).Scan(&minID, &maxID, &count); err != nil {
+		t.Fatalf("query remaining: %v", err)
+	}
+	if maxID != 20000 || minID <= 1 || count != 20000-deleted || maxID-minID+1 != count {
+		t.Fatalf("remaining ids [%d, %d] count=%d deleted=%d, want newest rows kept", minID, maxID, count, deleted)
+	}
+
+	// 已在预算内时不再修剪
+	if again, err := enforceDataDiskBudget(diskDB); err != nil || again != 0 {
+		t.Fatalf("second enforce = %d, %v", again, err)
+	}
+}
+
+func TestUpdateDiskUsage_EmergencyHysteresisSuppressesHistory(t *testing.T) {
+	t.Cleanup(func() {
+		ApplyDiskPressureThresholds(DefaultDiskEmergencyPercent, DefaultDiskResumePercent)
+		UpdateDiskUsage(0)
+		historySuppressedPoints.Store(0)
+	})
+	ApplyDiskPressureThresholds(90, 80)
+	historySuppressedPoints.Store(0)
+
+	data := &models.CollectData{DeviceID: 1, Fields: map[string]string{"a": "1", "b": "2"}}
+
+	UpdateDiskUsage(85)
+	if IsDataDiskEmergency() || !suppressHistoryOnDiskEmergency(data, true) {
+		t.Fatalf("below threshold should keep history")
+	}
+
+	UpdateDiskUsage(92)
+	if !IsDataDiskEmergency() {
+		t.Fatalf("expected emergency at 92%%")
+	}
+	if suppressHistoryOnDiskEmergency(data, true) {
+		t.Fatalf("history should be suppressed in emergency")
+	}
+	if suppressHistoryOnDiskEmergency(data, false) {
+		t.Fatalf("cache-only writes stay cache-only")
+	}
+
+	UpdateDiskUsage(85)
+	if !IsDataDiskEmergency() {
+		t.Fatalf("emergency should hold until usage drops below resume threshold")
+	}
+
+	status := GetDataStorageStatus()
+	if !status.Emergency || status.SuppressedHistory != 2 || status.EmergencySince == "" {
+		t.Fatalf("status=%+v", status)
+	}
+
+	UpdateDiskUsage(79)
+	if IsDataDiskEmergency() {
+		t.Fatalf("expected emergency cleared at 79%%")
+	}
+}
+
+func TestCleanupOldDataByGatewayRetention_DeviceOverrides(t *testing.T) {
+	setupGatewayTestDB(t)
+	if err := InitGatewayConfigTable(); err != nil {
+		t.Fatalf("InitGatewayConfigTable: %v", err)
+	}
+	prepareDataPointsTestDB(t)
+	if _, err := DataDB.Exec(`DROP TABLE data_points`); err != nil {
+		t.Fatalf("drop memory table: %v", err)
+	}
+	if err := migrateSchema(DataDB, migrationSetDataDisk); err != nil {
+		t.Fatalf("memory schema: %v", err)
+	}
+	oldDataDBFile := dataDBFile
+	dataDBFile = ""
+	t.Cleanup(func() { dataDBFile = oldDataDBFile })
+
+	policies := []*DeviceRetentionPolicy{
+		{DeviceID: 2, StorageDays: 5, Enabled: 1},
+		{DeviceID: 3, StorageDays: 90, Enabled: 1},
+		{DeviceID: 4, StorageDays: 1, Enabled: 0},
+	}
+	for _, policy := range policies {
+		if err := UpsertDeviceRetentionPolicy(policy); err != nil {
+			t.Fatalf("UpsertDeviceRetentionPolicy: %v", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	for deviceID := int64(1); deviceID <= 4; deviceID++ {
+		for _, ageDays := range []int{3, 10, 60} {
+			if _, err := DataDB.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, collected_at) VALUES (?, ?, 'f', '1', ?)`,
+				deviceID, fmt.Sprintf("dev-%d", deviceID), formatSQLiteTime(now.AddDate(0, 0, -ageDays))); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+		}
+	}
+
+	deleted, err := CleanupOldDataByGatewayRetention()
+	if err != nil {
+		t.Fatalf("CleanupOldDataByGatewayRetention() error = %v", err)
+	}
+	// 设备1/4（禁用策略）按全局30天删1行，设备2按5天删2行，设备3按90天不删
+	if deleted != 4 {
+		t.Fatalf("deleted=%d, want 4", deleted)
+	}
+
+	want := map[int64]int{1: 2, 2: 1, 3: 3, 4: 2}
+	for deviceID, rows := range want {
+		var count int
+		if err := DataDB.QueryRow(`SELECT COUNT(*) FROM data_points WHERE device_id = ?`, deviceID).Scan(&count); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		if count != rows {
+			t.Fatalf("device %d rows=%d, want %d", deviceID, count, rows)
+		}
+	}
+
+	if err := DeleteDeviceRetentionPolicy(2); err != nil {
+		t.Fatalf("DeleteDeviceRetentionPolicy: %v", err)
+	}
+	if err := DeleteDeviceRetentionPolicy(2); err == nil {
+		t.Fatalf("expected error deleting missing policy")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -128,14 +129,20 @@ func syncDataToDisk() error {
 	dataSyncMu.Lock()
 	defer dataSyncMu.Unlock()
 
+	if IsDataDiskEmergency() {
+		slog.Warn("Disk emergency: skip syncing data to disk")
+		return nil
+	}
+
 	slog.Info("Syncing data to disk")
 
-	var maxID int64
-	if err := DataDB.QueryRow("SELECT IFNULL(MAX(id), 0) FROM data_points").Scan(&maxID); err != nil {
-		return fmt.Errorf("failed to get max data point id: %w", err)
+	maxID, journalSeq, err := sealDataJournalForSync()
+	if err != nil {
+		return err
 	}
 	if maxID == 0 {
 		resetPendingHistoryRowsForSync(0)
+		releaseDataJournal(journalSeq)
 		return nil
 	}
 
@@ -149,7 +156,7 @@ func syncDataToDisk() error {
 	}
 
 	// 1. 打开/创建磁盘数据库
-	diskDB, err := openSQLite(dataDiskRWDSN(dataDBFile), 1, 1)
+	diskDB, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
 	if err != nil {
 		return fmt.Errorf("failed to open data database: %w", err)
 	}
@@ -158,12 +165,12 @@ func syncDataToDisk() error {
 	if _, err := diskDB.Exec("PRAGMA foreign_keys = OFF"); err != nil {
 		return fmt.Errorf("failed to set disk pragma: %w", err)
 	}
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		return err
 	}
 
 	// 2. attach + 单条 SQL 批量搬运，避免逐行扫描带来的分配和系统调用开销
-	count, err := syncDataPointsWithAttach(diskDB, maxID)
+	count, err := syncDataPointsWithAttach(diskDB, maxID, journalSeq)
 	if err != nil {
 		return err
 	}
@@ -173,8 +180,14 @@ func syncDataToDisk() error {
 		return fmt.Errorf("failed to cleanup synced data points: %w", err)
 	}
 	resetPendingHistoryRowsForSync(0)
+	releaseDataJournal(journalSeq)
 
 	slog.Info("Data synced to disk", "points", count)
+
+	// 4. 字节预算：超出时按时间从旧到新修剪磁盘历史
+	if _, err := enforceDataDiskBudget(diskDB); err != nil {
+		slog.Warn("Failed to enforce data disk budget", "error", err)
+	}
 	return nil
 }
 
@@ -212,7 +225,7 @@ func getMainDatabaseFilePath(db *sql.DB) (string, error) {
 	return "", nil
 }
 
-func syncDataPointsWithAttach(diskDB *sql.DB, maxID int64) (int, error) {
+func syncDataPointsWithAttach(diskDB *sql.DB, maxID, journalSeq int64) (int, error) {
 	if DataDB == nil {
 		return 0, fmt.Errorf("data db is not initialized")
 	}
@@ -223,7 +236,7 @@ func syncDataPointsWithAttach(diskDB *sql.DB, maxID int64) (int, error) {
 	}
 
 	if strings.TrimSpace(memPath) == "" || memPath == ":memory:" {
-		return syncDataPointsRowByRow(diskDB, maxID)
+		return syncDataPointsRowByRow(diskDB, maxID, journalSeq)
 	}
 
 	tx, err := diskDB.Begin()
@@ -235,7 +248,7 @@ func syncDataPointsWithAttach(diskDB *sql.DB, maxID int64) (int, error) {
 	attachSQL := fmt.Sprintf("ATTACH DATABASE '%s' AS memdb", escapeSingleQuotes(memPath))
 	if _, err := tx.Exec(attachSQL); err != nil {
 		_ = tx.Rollback()
-		return syncDataPointsRowByRow(diskDB, maxID)
+		return syncDataPointsRowByRow(diskDB, maxID, journalSeq)
 	}
 	defer tx.Exec("DETACH DATABASE memdb")
 
@@ -250,8 +263,8 @@ func syncDataPointsWithAttach(diskDB *sql.DB, maxID int64) (int, error) {
 	}
 
 	result, err := tx.Exec(
-		`INSERT OR IGNORE INTO main.data_points (device_id, device_name, field_name, value, value_type, collected_at)
-		 SELECT device_id, device_name, field_name, value, value_type, collected_at
+		`INSERT OR IGNORE INTO main.data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at)
+		 SELECT device_id, device_name, field_name, value, value_num, value_type, collected_at
 		 FROM memdb.data_points
 		 WHERE id <= ?`,
 		maxID,
@@ -264,6 +277,9 @@ func syncDataPointsWithAttach(diskDB *sql.DB, maxID int64) (int, error) {
 	if rowsAffected < 0 {
 		rowsAffected = 0
 	}
+	if err := recordDataJournalSynced(tx, journalSeq); err != nil {
+		return 0, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("failed to commit bulk sync transaction: %w", err)
@@ -272,8 +288,8 @@ func syncDataPointsWithAttach(diskDB *sql.DB, maxID int64) (int, error) {
 	return int(rowsAffected), nil
 }
 
-func syncDataPointsRowByRow(diskDB *sql.DB, maxID int64) (int, error) {
-	points, err := DataDB.Query(`SELECT device_id, device_name, field_name, value, value_type, collected_at
+func syncDataPointsRowByRow(diskDB *sql.DB, maxID, journalSeq int64) (int, error) {
+	points, err := DataDB.Query(`SELECT device_id, device_name, field_name, value, value_num, value_type, collected_at
 		FROM data_points WHERE id <= ? ORDER BY id`, maxID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to query data points: %w", err)
@@ -287,8 +303,8 @@ func syncDataPointsRowByRow(diskDB *sql.DB, maxID int64) (int, error) {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO data_points
-		(device_id, device_name, field_name, value, value_type, collected_at)
-		VALUES (?, ?, ?, ?, ?, ?)`)
+		(device_id, device_name, field_name, value, value_num, value_type, collected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return 0, fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -298,12 +314,13 @@ func syncDataPointsRowByRow(diskDB *sql.DB, maxID int64) (int, error) {
 	for points.Next() {
 		var deviceID int64
 		var deviceName, fieldName, value, valueType string
+		var num sql.NullFloat64
 		var collectedAt time.Time
-		if err := points.Scan(&deviceID, &deviceName, &fieldName, &value, &valueType, &collectedAt); err != nil {
+		if err := points.Scan(&deviceID, &deviceName, &fieldName, &value, &num, &valueType, &collectedAt); err != nil {
 			return 0, err
 		}
 		deviceName = normalizeDeviceName(deviceID, deviceName)
-		if _, err := stmt.Exec(deviceID, deviceName, fieldName, value, valueType, collectedAt); err != nil {
+		if _, err := stmt.Exec(deviceID, deviceName, fieldName, value, num, valueType, collectedAt); err != nil {
 			return 0, err
 		}
 		count++
@@ -311,6 +328,9 @@ func syncDataPointsRowByRow(diskDB *sql.DB, maxID int64) (int, error) {
 	if err := points.Err(); err != nil {
 		return 0, err
 	}
+	if err := recordDataJournalSynced(tx, journalSeq); err != nil {
+		return 0, err
+	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("failed to commit transaction: %w", err)
@@ -319,32 +339,35 @@ func syncDataPointsRowByRow(diskDB *sql.DB, maxID int64) (int, error) {
 	return count, nil
 }
 
-func ensureDiskDataSchema(db *sql.DB) error {
-	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS data_points (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		device_id INTEGER NOT NULL,
-		device_name TEXT NOT NULL,
-		field_name TEXT NOT NULL,
-		value TEXT NOT NULL,
-		value_type TEXT DEFAULT 'string',
-		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(device_id, field_name, collected_at)
-	)`); err != nil {
-		return fmt.Errorf("failed to ensure data_points table: %w", err)
+// ensureDataDiskFileSchema 启动时补齐已有 data.db 的 schema，避免首次同步前的只读查询访问到旧表结构
+func ensureDataDiskFileSchema() error {
+	if dataDBFile == "" {
+		return nil
 	}
-
-	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_data_points_device_time ON data_points(device_id, collected_at DESC)"); err != nil {
-		return fmt.Errorf("failed to ensure data_points index: %w", err)
+	if _, err := os.Stat(dataDBFile); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
 	}
 
-	if _, err := db.Exec(
-		`UPDATE data_points SET device_name = ? WHERE device_id = ? AND device_name <> ?`,
-		models.SystemStatsDeviceName,
-		models.SystemStatsDeviceID,
-		models.SystemStatsDeviceName,
-	); err != nil {
-		return fmt.Errorf("failed to normalize system device_name: %w", err)
+	diskDB, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
+	if err != nil {
+		return fmt.Errorf("failed to open data database: %w", err)
 	}
-
+	defer diskDB.Close()
+	if err := prepareDataDiskDB(diskDB); err != nil {
+		return err
+	}
+	ensureDataDiskIncrementalVacuum(diskDB)
 	return nil
 }
+
+// prepareDataDiskDB 磁盘文件依次：启用增量回收（须在建表前）、执行版本化迁移；
+// 新建库直接启用增量回收，已有库由 ensureDataDiskIncrementalVacuum 按需转换
+func prepareDataDiskDB(db *sql.DB) error {
+	if _, err := db.Exec(`PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+		return fmt.Errorf("failed to set auto_vacuum: %w", err)
+	}
+	return migrateSchema(db, migrationSetDataDisk)
+}
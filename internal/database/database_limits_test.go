@@ -86,6 +86,7 @@ func TestTriggerSyncIfNeeded(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
@@ -209,6 +210,7 @@ func TestEnforceDataCacheLimit(t *testing.T) {
 		device_id INTEGER NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -302,6 +304,7 @@ func TestEnforceDataPointsLimit(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
@@ -404,6 +407,7 @@ func TestSaveDataCache_ThrottledCleanup(t *testing.T) {
 		device_id INTEGER NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name)
@@ -491,6 +495,7 @@ func TestSaveDataPoint_ThrottledCleanup(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
@@ -570,6 +575,7 @@ func TestSaveDataPoint_TriggersSyncAtThreshold(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
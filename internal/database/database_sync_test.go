@@ -3,6 +3,7 @@ package database
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
@@ -32,6 +33,7 @@ func TestSyncDataToDisk(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name, collected_at)
@@ -103,6 +105,7 @@ func TestSyncDataToDisk_NormalizesSystemDeviceName(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(device_id, field_name, collected_at)
@@ -137,7 +140,6 @@ func TestSyncDataToDisk_NormalizesSystemDeviceName(t *testing.T) {
 }
 
 func TestCleanupOldDataByGatewayRetention(t *testing.T) {
-	gatewayColumnsEnsured = false
 	if ParamDB != nil {
 		_ = ParamDB.Close()
 	}
@@ -190,6 +192,7 @@ func TestCleanupOldDataByGatewayRetention(t *testing.T) {
 		device_name TEXT NOT NULL,
 		field_name TEXT NOT NULL,
 		value TEXT NOT NULL,
+		value_num REAL,
 		value_type TEXT DEFAULT 'string',
 		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`)
@@ -214,7 +217,7 @@ func TestCleanupOldDataByGatewayRetention(t *testing.T) {
 	}
 	defer diskDB.Close()
 
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		t.Fatalf("ensure disk schema: %v", err)
 	}
 
@@ -253,3 +256,62 @@ func TestCleanupOldDataByGatewayRetention(t *testing.T) {
 		t.Fatalf("expected 1 disk point remain, got %d", diskCount)
 	}
 }
+
+func TestDataDiskMigrations_MigrateTextValuesToValueNum(t *testing.T) {
+	diskDB, err := openSQLite(filepath.Join(t.TempDir(), "data.db"), 1, 1)
+	if err != nil {
+		t.Fatalf("open disk db: %v", err)
+	}
+	defer diskDB.Close()
+
+	// 旧版磁盘表结构：没有 value_num 列，所有值都按文本存储
+	if _, err := diskDB.Exec(`CREATE TABLE data_points (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device_id INTEGER NOT NULL,
+		device_name TEXT NOT NULL,
+		field_name TEXT NOT NULL,
+		value TEXT NOT NULL,
+		value_type TEXT DEFAULT 'string',
+		collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(device_id, field_name, collected_at)
+	)`); err != nil {
+		t.Fatalf("create legacy data_points: %v", err)
+	}
+	for field, value := range map[string]string{"int": "42", "float": "21.5", "bool": "true", "zero_padded": "0012", "text": "on"} {
+		if _, err := diskDB.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value) VALUES (1, 'dev1', ?, ?)`, field, value); err != nil {
+			t.Fatalf("insert legacy row: %v", err)
+		}
+	}
+
+	if err := prepareDataDiskDB(diskDB); err != nil {
+		t.Fatalf("prepareDataDiskDB: %v", err)
+	}
+
+	points, err := queryDataPointsByDevice(diskDB, 1, 10, time.Time{})
+	if err != nil {
+		t.Fatalf("query migrated points: %v", err)
+	}
+	want := map[string][2]string{
+		"int":         {"42", models.ValueTypeInt},
+		"float":       {"21.5", models.ValueTypeFloat},
+		"bool":        {"true", models.ValueTypeBool},
+		"zero_padded": {"0012", models.ValueTypeString},
+		"text":        {"on", models.ValueTypeString},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("points=%d, want %d", len(points), len(want))
+	}
+	for _, point := range points {
+		if got := [2]string{point.Value, point.ValueType}; got != want[point.FieldName] {
+			t.Fatalf("%s migrated to %v, want %v", point.FieldName, got, want[point.FieldName])
+		}
+	}
+
+	var numericRows int
+	if err := diskDB.QueryRow(`SELECT COUNT(*) FROM data_points WHERE value_num IS NOT NULL AND value = ''`).Scan(&numericRows); err != nil {
+		t.Fatalf("count numeric rows: %v", err)
+	}
+	if numericRows != 3 {
+		t.Fatalf("numeric rows=%d, want 3", numericRows)
+	}
+}
@@ -2,148 +2,31 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
 
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
 const selectDeviceFields = `SELECT id, name, description, product_key, device_key, driver_type, serial_port, baud_rate, data_bits, stop_bits, parity,
-	ip_address, port_num, device_address, collect_interval, storage_interval, timeout, driver_id, enabled, resource_id, created_at, updated_at FROM devices`
+	ip_address, port_num, device_address, collect_interval, storage_interval, timeout, COALESCE(point_table, ''), COALESCE(deadbands, ''), COALESCE(device_config, ''), driver_id, enabled, resource_id, created_at, updated_at FROM devices`
 
-// InitDeviceTable 初始化设备表
-func InitDeviceTable() error {
-	// 只添加缺失的列
-	columns := []struct {
-		name  string
-		ctype string
-	}{
-		{"product_key", "TEXT"},
-		{"device_key", "TEXT"},
-		{"driver_type", "TEXT DEFAULT 'modbus_rtu'"},
-		{"serial_port", "TEXT"},
-		{"baud_rate", "INTEGER DEFAULT 9600"},
-		{"data_bits", "INTEGER DEFAULT 8"},
-		{"stop_bits", "INTEGER DEFAULT 1"},
-		{"parity", "TEXT CHECK(parity IN ('N', 'O', 'E'))"},
-		{"ip_address", "TEXT"},
-		{"port_num", "INTEGER DEFAULT 502"},
-		{"device_address", "TEXT"},
-		{"collect_interval", "INTEGER DEFAULT 5000"},
-		{"storage_interval", "INTEGER DEFAULT 300"},
-		{"timeout", "INTEGER DEFAULT 1000"},
-		{"driver_id", "INTEGER"},
-		{"resource_id", "INTEGER"},
-	}
-
-	for _, col := range columns {
-		ParamDB.Exec(fmt.Sprintf("ALTER TABLE devices ADD COLUMN %s %s", col.name, col.ctype))
-	}
-
-	return cleanupLegacyDeviceColumns()
+// CreateDevice 创建设备
+func CreateDevice(device *models.Device) (int64, error) {
+	return createDevice(ParamDB, device)
 }
 
-func cleanupLegacyDeviceColumns() error {
-	hasDeviceConfig, err := columnExists(ParamDB, "devices", "device_config")
-	if err != nil {
-		return err
-	}
-	hasUploadInterval, err := columnExists(ParamDB, "devices", "upload_interval")
-	if err != nil {
-		return err
-	}
-	hasProtocol, err := columnExists(ParamDB, "devices", "protocol")
-	if err != nil {
-		return err
-	}
-	if !hasDeviceConfig && !hasUploadInterval && !hasProtocol {
-		return nil
-	}
-
-	tx, err := ParamDB.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	_, err = tx.Exec(`CREATE TABLE devices_new (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT UNIQUE NOT NULL,
-		description TEXT,
-		product_key TEXT,
-		device_key TEXT,
-		driver_type TEXT DEFAULT 'modbus_rtu',
-		serial_port TEXT,
-		resource_id INTEGER,
-		driver_id INTEGER,
-		collect_interval INTEGER DEFAULT 5000,
-		storage_interval INTEGER DEFAULT 300,
-		timeout INTEGER DEFAULT 1000,
-		baud_rate INTEGER DEFAULT 9600,
-		data_bits INTEGER DEFAULT 8,
-		stop_bits INTEGER DEFAULT 1,
-		parity TEXT DEFAULT 'N' CHECK(parity IN ('N', 'O', 'E')),
-		ip_address TEXT,
-		port_num INTEGER DEFAULT 502,
-		device_address TEXT,
-		enabled INTEGER DEFAULT 1,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE SET NULL,
-		FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE SET NULL
-	)`)
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.Exec(`INSERT INTO devices_new (
-		id, name, description, product_key, device_key, driver_type,
-		serial_port, resource_id, driver_id,
-		collect_interval, storage_interval, timeout,
-		baud_rate, data_bits, stop_bits, parity,
-		ip_address, port_num, device_address,
-		enabled, created_at, updated_at
-	)
-	SELECT
-		id, name, description, product_key, device_key, COALESCE(driver_type, 'modbus_rtu'),
-		serial_port, resource_id, driver_id,
-		COALESCE(collect_interval, 5000), COALESCE(storage_interval, 300), COALESCE(timeout, 1000),
-		COALESCE(baud_rate, 9600), COALESCE(data_bits, 8), COALESCE(stop_bits, 1), COALESCE(parity, 'N'),
-		ip_address, COALESCE(port_num, 502), device_address,
-		COALESCE(enabled, 1), created_at, updated_at
-	FROM devices`)
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.Exec(`DROP TABLE devices`)
-	if err != nil {
-		return err
-	}
-
-	_, err = tx.Exec(`ALTER TABLE devices_new RENAME TO devices`)
-	if err != nil {
-		return err
-	}
-
-	if _, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_devices_resource ON devices(resource_id)`); err != nil {
-		return err
-	}
-	if _, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_devices_driver ON devices(driver_id)`); err != nil {
-		return err
-	}
-
-	return tx.Commit()
+// CreateDevice 在事务内创建设备
+func (tx *ParamTx) CreateDevice(device *models.Device) (int64, error) {
+	return createDevice(tx.tx, device)
 }
 
-// CreateDevice 创建设备
-func CreateDevice(device *models.Device) (int64, error) {
-	result, err := ParamDB.Exec(
+func createDevice(db paramExecer, device *models.Device) (int64, error) {
+	result, err := db.Exec(
 		`INSERT INTO devices (name, description, product_key, device_key, driver_type, serial_port, baud_rate, data_bits, stop_bits, parity, 
-			ip_address, port_num, device_address, collect_interval, storage_interval, timeout, driver_id, enabled, resource_id) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			ip_address, port_num, device_address, collect_interval, storage_interval, timeout, point_table, deadbands, device_config, driver_id, enabled, resource_id) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		device.Name, device.Description, device.ProductKey, device.DeviceKey, device.DriverType, device.SerialPort, device.BaudRate, device.DataBits,
 		device.StopBits, device.Parity, device.IPAddress, device.PortNum, device.DeviceAddress,
-		device.CollectInterval, device.StorageInterval, device.Timeout, device.DriverID, device.Enabled, device.ResourceID,
+		device.CollectInterval, device.StorageInterval, device.Timeout, device.PointTable, device.Deadbands, device.DeviceConfig, device.DriverID, device.Enabled, device.ResourceID,
 	)
 	if err != nil {
 		return 0, err
@@ -166,7 +49,6 @@ func ListDevices() ([]*models.Device, error) {
 	return listDevices(selectDeviceFields+" ORDER BY id", nil)
 }
 
-
 type deviceScanner interface {
 	Scan(dest ...any) error
 }
@@ -213,6 +95,9 @@ func scanDevice(scanner deviceScanner, device *models.Device) error {
 		&device.CollectInterval,
 		&device.StorageInterval,
 		&device.Timeout,
+		&device.PointTable,
+		&device.Deadbands,
+		&device.DeviceConfig,
 		&device.DriverID,
 		&device.Enabled,
 		&device.ResourceID,
@@ -223,18 +108,23 @@ func scanDevice(scanner deviceScanner, device *models.Device) error {
 
 // UpdateDevice 更新设备
 func UpdateDevice(device *models.Device) error {
-	return updateDeviceByID(device.ID, device)
+	return updateDeviceByID(ParamDB, device.ID, device)
 }
 
-func updateDeviceByID(id int64, device *models.Device) error {
-	_, err := ParamDB.Exec(
+// UpdateDevice 在事务内更新设备
+func (tx *ParamTx) UpdateDevice(device *models.Device) error {
+	return updateDeviceByID(tx.tx, device.ID, device)
+}
+
+func updateDeviceByID(db paramExecer, id int64, device *models.Device) error {
+	_, err := db.Exec(
 		`UPDATE devices SET name = ?, description = ?, product_key = ?, device_key = ?, driver_type = ?, serial_port = ?, baud_rate = ?, 
 			data_bits = ?, stop_bits = ?, parity = ?, ip_address = ?, port_num = ?, 
-			device_address = ?, collect_interval = ?, storage_interval = ?, timeout = ?, driver_id = ?, enabled = ?, resource_id = ?, 
+			device_address = ?, collect_interval = ?, storage_interval = ?, timeout = ?, point_table = ?, deadbands = ?, device_config = ?, driver_id = ?, enabled = ?, resource_id = ?, 
 			updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
 		device.Name, device.Description, device.ProductKey, device.DeviceKey, device.DriverType, device.SerialPort, device.BaudRate, device.DataBits,
 		device.StopBits, device.Parity, device.IPAddress, device.PortNum,
-		device.DeviceAddress, device.CollectInterval, device.StorageInterval, device.Timeout, device.DriverID, device.Enabled, device.ResourceID,
+		device.DeviceAddress, device.CollectInterval, device.StorageInterval, device.Timeout, device.PointTable, device.Deadbands, device.DeviceConfig, device.DriverID, device.Enabled, device.ResourceID,
 		id,
 	)
 	return err
@@ -242,8 +132,19 @@ func updateDeviceByID(id int64, device *models.Device) error {
 
 // DeleteDevice 删除设备
 func DeleteDevice(id int64) error {
-	_, err := ParamDB.Exec("DELETE FROM devices WHERE id = ?", id)
-	return err
+	return deleteDevice(ParamDB, id)
+}
+
+// DeleteDevice 在事务内删除设备
+func (tx *ParamTx) DeleteDevice(id int64) error {
+	return deleteDevice(tx.tx, id)
+}
+
+func deleteDevice(db paramExecer, id int64) error {
+	if _, err := db.Exec("DELETE FROM devices WHERE id = ?", id); err != nil {
+		return err
+	}
+	return deleteDeviceDriverState(db, id)
 }
 
 // ToggleDevice 切换设备状态
@@ -266,7 +167,7 @@ func UpdateDeviceProductKey(id int64, productKey string) error {
 
 // UpdateDeviceWithID 根据ID更新设备信息（用于API）
 func UpdateDeviceWithID(id int64, device *models.Device) error {
-	return updateDeviceByID(id, device)
+	return updateDeviceByID(ParamDB, id, device)
 }
 
 // UpdateDeviceDriverID 更新设备驱动ID
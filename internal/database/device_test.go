@@ -91,7 +91,7 @@ func setupDeviceTestDB(t *testing.T) {
 	}
 }
 
-func TestInitDeviceTable_CleansLegacyColumns(t *testing.T) {
+func TestParamMigrations_CleanLegacyDeviceColumns(t *testing.T) {
 	setupDeviceTestDB(t)
 
 	_, err := ParamDB.Exec(`INSERT INTO devices (
@@ -113,14 +113,10 @@ func TestInitDeviceTable_CleansLegacyColumns(t *testing.T) {
 		t.Fatalf("insert legacy device: %v", err)
 	}
 
-	if err := InitDeviceTable(); err != nil {
-		t.Fatalf("InitDeviceTable: %v", err)
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
 	}
 
-	hasDeviceConfig, err := columnExists(ParamDB, "devices", "device_config")
-	if err != nil {
-		t.Fatalf("columnExists device_config: %v", err)
-	}
 	hasUploadInterval, err := columnExists(ParamDB, "devices", "upload_interval")
 	if err != nil {
 		t.Fatalf("columnExists upload_interval: %v", err)
@@ -129,8 +125,8 @@ func TestInitDeviceTable_CleansLegacyColumns(t *testing.T) {
 	if err != nil {
 		t.Fatalf("columnExists protocol: %v", err)
 	}
-	if hasDeviceConfig || hasUploadInterval || hasProtocol {
-		t.Fatalf("expected legacy columns removed, got device_config=%v upload_interval=%v protocol=%v", hasDeviceConfig, hasUploadInterval, hasProtocol)
+	if hasUploadInterval || hasProtocol {
+		t.Fatalf("expected legacy columns removed, got upload_interval=%v protocol=%v", hasUploadInterval, hasProtocol)
 	}
 
 	device, err := LoadDevice(1)
@@ -146,6 +142,35 @@ func TestInitDeviceTable_CleansLegacyColumns(t *testing.T) {
 	if device.Parity != "E" || device.DeviceAddress != "2" {
 		t.Fatalf("unexpected device protocol fields: %+v", device)
 	}
+	if device.DeviceConfig != `{"x":1}` {
+		t.Fatalf("device_config = %q, want preserved", device.DeviceConfig)
+	}
+}
+
+func TestParamMigrations_AddDeviceConfigToLegacyDevices(t *testing.T) {
+	setupDeviceTestDB(t)
+	// 早期版本的设备表没有 device_config
+	if _, err := ParamDB.Exec(`ALTER TABLE devices DROP COLUMN device_config`); err != nil {
+		t.Fatalf("drop device_config: %v", err)
+	}
+	if _, err := ParamDB.Exec(`INSERT INTO devices (name, upload_interval, protocol) VALUES ('D1', 8000, 'udp')`); err != nil {
+		t.Fatalf("insert legacy device: %v", err)
+	}
+
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
+
+	if hasProtocol, err := columnExists(ParamDB, "devices", "protocol"); err != nil || hasProtocol {
+		t.Fatalf("protocol column exists=%v err=%v, want removed", hasProtocol, err)
+	}
+	if _, err := ParamDB.Exec(`UPDATE devices SET device_config = '{"x":1}' WHERE name = 'D1'`); err != nil {
+		t.Fatalf("device_config missing after migration: %v", err)
+	}
+	var deviceConfig string
+	if err := ParamDB.QueryRow(`SELECT device_config FROM devices WHERE name = 'D1'`).Scan(&deviceConfig); err != nil || deviceConfig != `{"x":1}` {
+		t.Fatalf("device_config = %q, %v", deviceConfig, err)
+	}
 }
 
 type stubDeviceScanner struct {
@@ -189,8 +214,8 @@ func TestScanDevice(t *testing.T) {
 	scanner := stubDeviceScanner{
 		values: []any{
 			int64(1), "d1", "desc", "pk", "dk", "modbus_tcp", "/dev/ttyUSB0",
-			9600, 8, 1, "N", "127.0.0.1", 502, "2", 5000, 300, 1000,
-			driverID, 1, resourceID, now, now,
+			9600, 8, 1, "N", "127.0.0.1", 502, "2", 5000, 300, 1000, `[{"name":"Ua","address":0}]`, `[{"field_name":"Ua","deadband":1}]`,
+			`{"slave_id":3}`, driverID, 1, resourceID, now, now,
 		},
 	}
 
@@ -207,6 +232,15 @@ func TestScanDevice(t *testing.T) {
 	if device.ResourceID == nil || *device.ResourceID != resourceID {
 		t.Fatalf("unexpected resource id: %+v", device.ResourceID)
 	}
+	if device.PointTable != `[{"name":"Ua","address":0}]` {
+		t.Fatalf("unexpected point table: %q", device.PointTable)
+	}
+	if device.Deadbands != `[{"field_name":"Ua","deadband":1}]` {
+		t.Fatalf("unexpected deadbands: %q", device.Deadbands)
+	}
+	if device.DeviceConfig != `{"slave_id":3}` {
+		t.Fatalf("unexpected device config: %q", device.DeviceConfig)
+	}
 }
 
 func TestScanDevice_AllowsNilBindings(t *testing.T) {
@@ -215,7 +249,7 @@ func TestScanDevice_AllowsNilBindings(t *testing.T) {
 	scanner := stubDeviceScanner{
 		values: []any{
 			int64(2), "d2", "", "", "", "modbus_rtu", "/dev/ttyUSB1",
-			19200, 7, 2, "E", "", 0, "", 2000, 600, 1500,
+			19200, 7, 2, "E", "", 0, "", 2000, 600, 1500, "", "", "",
 			nil, 0, nil, now, now,
 		},
 	}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// 驱动键值状态限制
+const (
+	MaxDriverStateKeyLen     = 128
+	MaxDriverStateValueLen   = 64 * 1024
+	MaxDriverStateKeysPerDev = 256
+)
+
+// ErrDriverStateLimit 键值状态超出限制
+var ErrDriverStateLimit = errors.New("driver state limit exceeded")
+
+// GetDriverState 读取设备的驱动状态值，不存在时返回 sql.ErrNoRows
+func GetDriverState(deviceID int64, key string) ([]byte, error) {
+	var value []byte
+	if err := ParamDB.QueryRow(`SELECT value FROM driver_state WHERE device_id = ? AND key = ?`, deviceID, key).Scan(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// SetDriverState 写入设备的驱动状态值；值未变化时不产生写入
+func SetDriverState(deviceID int64, key string, value []byte) error {
+	if key == "" || len(key) > MaxDriverStateKeyLen || len(value) > MaxDriverStateValueLen {
+		return fmt.Errorf("%w: key=%d bytes value=%d bytes", ErrDriverStateLimit, len(key), len(value))
+	}
+	if value == nil {
+		value = []byte{}
+	}
+
+	tx, err := ParamDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM driver_state WHERE device_id = ? AND key = ?`, deviceID, key).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM driver_state WHERE device_id = ?`, deviceID).Scan(&count); err != nil {
+			return err
+		}
+		if count >= MaxDriverStateKeysPerDev {
+			return fmt.Errorf("%w: device %d has %d keys", ErrDriverStateLimit, deviceID, count)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO driver_state (device_id, key, value) VALUES (?, ?, ?)
+		ON CONFLICT(device_id, key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+		WHERE driver_state.value IS NOT excluded.value`, deviceID, key, value); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteDriverState 删除设备的单个驱动状态键
+func DeleteDriverState(deviceID int64, key string) error {
+	_, err := ParamDB.Exec(`DELETE FROM driver_state WHERE device_id = ? AND key = ?`, deviceID, key)
+	return err
+}
+
+// deleteDeviceDriverState 删除设备的全部驱动状态（旧库缺表时忽略）
+func deleteDeviceDriverState(db paramExecer, deviceID int64) error {
+	_, err := db.Exec(`DELETE FROM driver_state WHERE device_id = ?`, deviceID)
+	if isNoSuchTableError(err) {
+		return nil
+	}
+	return err
+}
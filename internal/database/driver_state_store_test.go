@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDriverState_SetGetDeleteAndLimits(t *testing.T) {
+	prepareParamSchemaTestDB(t)
+
+	if _, err := GetDriverState(1, "seq"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetDriverState(missing) error = %v, want sql.ErrNoRows", err)
+	}
+	if err := SetDriverState(1, "seq", []byte{0x01, 0x02}); err != nil {
+		t.Fatalf("SetDriverState: %v", err)
+	}
+	if err := SetDriverState(1, "seq", []byte{0x03}); err != nil {
+		t.Fatalf("SetDriverState(update): %v", err)
+	}
+	if value, err := GetDriverState(1, "seq"); err != nil || string(value) != "\x03" {
+		t.Fatalf("GetDriverState = %v, %v", value, err)
+	}
+	if _, err := GetDriverState(2, "seq"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("state leaked across devices: %v", err)
+	}
+
+	if err := SetDriverState(1, "", []byte("x")); !errors.Is(err, ErrDriverStateLimit) {
+		t.Fatalf("empty key error = %v", err)
+	}
+	if err := SetDriverState(1, "big", make([]byte, MaxDriverStateValueLen+1)); !errors.Is(err, ErrDriverStateLimit) {
+		t.Fatalf("large value error = %v", err)
+	}
+	for i := 1; i < MaxDriverStateKeysPerDev; i++ {
+		if err := SetDriverState(1, fmt.Sprintf("k%d", i), nil); err != nil {
+			t.Fatalf("SetDriverState(k%d): %v", i, err)
+		}
+	}
+	if err := SetDriverState(1, "overflow", nil); !errors.Is(err, ErrDriverStateLimit) {
+		t.Fatalf("key count error = %v", err)
+	}
+	if err := SetDriverState(1, "seq", []byte{0x04}); err != nil {
+		t.Fatalf("updating existing key at limit: %v", err)
+	}
+
+	if err := DeleteDriverState(1, "seq"); err != nil {
+		t.Fatalf("DeleteDriverState: %v", err)
+	}
+	if _, err := GetDriverState(1, "seq"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("deleted key still present: %v", err)
+	}
+	if err := DeleteDevice(1); err != nil {
+		t.Fatalf("DeleteDevice: %v", err)
+	}
+	var count int
+	if err := ParamDB.QueryRow(`SELECT COUNT(*) FROM driver_state WHERE device_id = 1`).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("state after device delete = %d, %v", count, err)
+	}
+}
@@ -12,7 +12,16 @@ const selectDriverFields = `SELECT id, name, file_path, description, version, co
 
 // CreateDriver 创建驱动
 func CreateDriver(driver *models.Driver) (int64, error) {
-	result, err := ParamDB.Exec(
+	return createDriver(ParamDB, driver)
+}
+
+// CreateDriver 在事务内创建驱动
+func (tx *ParamTx) CreateDriver(driver *models.Driver) (int64, error) {
+	return createDriver(tx.tx, driver)
+}
+
+func createDriver(db paramExecer, driver *models.Driver) (int64, error) {
+	result, err := db.Exec(
 		"INSERT INTO drivers (name, file_path, description, version, config_schema, enabled) VALUES (?, ?, ?, ?, ?, ?)",
 		driver.Name, driver.FilePath, driver.Description, driver.Version, driver.ConfigSchema, driver.Enabled,
 	)
@@ -80,7 +89,16 @@ func scanDriver(scanner driverScanner, driver *models.Driver) error {
 
 // UpdateDriver 更新驱动
 func UpdateDriver(driver *models.Driver) error {
-	_, err := ParamDB.Exec(
+	return updateDriver(ParamDB, driver)
+}
+
+// UpdateDriver 在事务内更新驱动
+func (tx *ParamTx) UpdateDriver(driver *models.Driver) error {
+	return updateDriver(tx.tx, driver)
+}
+
+func updateDriver(db paramExecer, driver *models.Driver) error {
+	_, err := db.Exec(
 		"UPDATE drivers SET name = ?, file_path = ?, description = ?, version = ?, config_schema = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
 		driver.Name, driver.FilePath, driver.Description, driver.Version, driver.ConfigSchema, driver.Enabled, driver.ID,
 	)
@@ -93,6 +111,12 @@ func DeleteDriver(id int64) error {
 	return err
 }
 
+// DeleteDriver 在事务内删除驱动
+func (tx *ParamTx) DeleteDriver(id int64) error {
+	_, err := tx.tx.Exec("DELETE FROM drivers WHERE id = ?", id)
+	return err
+}
+
 // UpsertDriverFile 保存或忽略重复的驱动记录
 func UpsertDriverFile(name, path string) error {
 	_, err := ParamDB.Exec(
@@ -18,31 +18,11 @@ const (
 		COALESCE(data_retention_days, ?), updated_at FROM gateway_config`
 )
 
-var gatewayColumnsEnsured bool
-
-// InitGatewayConfigTable 创建网关配置表
+// InitGatewayConfigTable 写入默认网关配置（表由 param 迁移创建）
 func InitGatewayConfigTable() error {
-	if err := createGatewayConfigTable(); err != nil {
-		return err
-	}
-	if err := ensureGatewayConfigColumns(); err != nil {
-		return err
-	}
 	return ensureDefaultGatewayConfig()
 }
 
-func createGatewayConfigTable() error {
-	_, err := ParamDB.Exec(`CREATE TABLE IF NOT EXISTS gateway_config (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		product_key TEXT NOT NULL,
-		device_key TEXT NOT NULL,
-		gateway_name TEXT DEFAULT 'HuShu智能网关',
-		data_retention_days INTEGER DEFAULT 30,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	)`)
-	return err
-}
-
 func ensureDefaultGatewayConfig() error {
 	var count int
 	err := ParamDB.QueryRow("SELECT COUNT(*) FROM gateway_config").Scan(&count)
@@ -63,36 +43,18 @@ func ensureDefaultGatewayConfig() error {
 	return nil
 }
 
-func ensureGatewayConfigColumns() error {
-	if gatewayColumnsEnsured {
-		return nil
-	}
-
-	hasDataRetentionDays, err := columnExists(ParamDB, "gateway_config", "data_retention_days")
-	if err != nil {
-		return err
-	}
-	if !hasDataRetentionDays {
-		if _, err := ParamDB.Exec(`ALTER TABLE gateway_config ADD COLUMN data_retention_days INTEGER DEFAULT 30`); err != nil {
-			return err
-		}
-	}
-
-	if _, err := ParamDB.Exec(`UPDATE gateway_config SET data_retention_days = ? WHERE data_retention_days IS NULL OR data_retention_days <= 0`, DefaultRetentionDays); err != nil {
-		return err
-	}
-
-	gatewayColumnsEnsured = true
-	return nil
-}
-
 // GetGatewayConfig 获取网关配置
 func GetGatewayConfig() (*GatewayConfig, error) {
-	if err := ensureGatewayConfigColumns(); err != nil {
-		return nil, err
-	}
+	return getGatewayConfig(ParamDB)
+}
 
-	cfg, err := loadGatewayConfig(selectGatewayConfigFields+" ORDER BY id LIMIT 1", DefaultRetentionDays)
+// GetGatewayConfig 在事务内获取网关配置
+func (tx *ParamTx) GetGatewayConfig() (*GatewayConfig, error) {
+	return getGatewayConfig(tx.tx)
+}
+
+func getGatewayConfig(db paramExecer) (*GatewayConfig, error) {
+	cfg, err := loadGatewayConfig(db, selectGatewayConfigFields+" ORDER BY id LIMIT 1", DefaultRetentionDays)
 	if err != nil {
 		return nil, err
 	}
@@ -102,21 +64,26 @@ func GetGatewayConfig() (*GatewayConfig, error) {
 
 // UpdateGatewayConfig 更新网关配置
 func UpdateGatewayConfig(cfg *GatewayConfig) error {
+	return updateGatewayConfig(ParamDB, cfg)
+}
+
+// UpdateGatewayConfig 在事务内更新网关配置
+func (tx *ParamTx) UpdateGatewayConfig(cfg *GatewayConfig) error {
+	return updateGatewayConfig(tx.tx, cfg)
+}
+
+func updateGatewayConfig(db paramExecer, cfg *GatewayConfig) error {
 	if cfg == nil {
 		return nil
 	}
-	if err := ensureGatewayConfigColumns(); err != nil {
-		return err
-	}
-
 	normalizeGatewayConfig(cfg)
 
-	targetID, err := resolveTargetGatewayConfigID(cfg.ID)
+	targetID, err := resolveTargetGatewayConfigID(db, cfg.ID)
 	if err != nil {
 		return err
 	}
 
-	_, err = ParamDB.Exec(`UPDATE gateway_config SET product_key = ?, device_key = ?, gateway_name = ?, data_retention_days = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+	_, err = db.Exec(`UPDATE gateway_config SET product_key = ?, device_key = ?, gateway_name = ?, data_retention_days = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
 		cfg.ProductKey, cfg.DeviceKey, cfg.GatewayName, cfg.DataRetentionDays, targetID)
 	return err
 }
@@ -125,9 +92,9 @@ type gatewayConfigScanner interface {
 	Scan(dest ...any) error
 }
 
-func loadGatewayConfig(query string, args ...any) (*GatewayConfig, error) {
+func loadGatewayConfig(db paramExecer, query string, args ...any) (*GatewayConfig, error) {
 	cfg := &GatewayConfig{}
-	err := scanGatewayConfig(ParamDB.QueryRow(query, args...), cfg)
+	err := scanGatewayConfig(db.QueryRow(query, args...), cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -158,12 +125,12 @@ func normalizeGatewayConfig(cfg *GatewayConfig) {
 	}
 }
 
-func resolveTargetGatewayConfigID(id int64) (int64, error) {
+func resolveTargetGatewayConfigID(db paramExecer, id int64) (int64, error) {
 	if id > 0 {
 		return id, nil
 	}
 
-	current, err := GetGatewayConfig()
+	current, err := getGatewayConfig(db)
 	if err != nil {
 		return 0, err
 	}
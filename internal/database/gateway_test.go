@@ -10,9 +10,7 @@ func setupGatewayTestDB(t *testing.T) {
 	t.Helper()
 
 	originalParamDB := ParamDB
-	originalGatewayColumnsEnsured := gatewayColumnsEnsured
 	t.Cleanup(func() {
-		gatewayColumnsEnsured = originalGatewayColumnsEnsured
 		if ParamDB != nil {
 			_ = ParamDB.Close()
 		}
@@ -23,13 +21,14 @@ func setupGatewayTestDB(t *testing.T) {
 		_ = ParamDB.Close()
 	}
 
-	gatewayColumnsEnsured = false
-
 	var err error
 	ParamDB, err = openSQLite(filepath.Join(t.TempDir(), "param.db"), 1, 1)
 	if err != nil {
 		t.Fatalf("open param db: %v", err)
 	}
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
 }
 
 type stubGatewayConfigScanner struct {
@@ -125,7 +124,7 @@ func TestResolveTargetGatewayConfigID_UsesCurrentConfigWhenEmpty(t *testing.T) {
 		t.Fatalf("GetGatewayConfig returned error: %v", err)
 	}
 
-	got, err := resolveTargetGatewayConfigID(0)
+	got, err := resolveTargetGatewayConfigID(ParamDB, 0)
 	if err != nil {
 		t.Fatalf("resolveTargetGatewayConfigID returned error: %v", err)
 	}
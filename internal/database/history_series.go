@@ -0,0 +1,485 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// 多序列历史查询：多个（设备, 字段）序列按固定时间桶聚合、补齐空桶，并以游标分页
+
+// 仅多序列查询支持的聚合函数（需要桶内首值，只能从原始数据计算）
+const (
+	HistoryAggFirst = "first"
+	HistoryAggDiff  = "diff" // 桶内末值减首值
+)
+
+// 空桶补齐策略
+const (
+	HistoryFillNone     = "none"     // 不输出空桶
+	HistoryFillNull     = "null"     // 输出 null
+	HistoryFillZero     = "zero"     // 输出 0
+	HistoryFillPrevious = "previous" // 沿用前一个非空桶的值
+	HistoryFillLinear   = "linear"   // 按前后非空桶线性插值，两端无值时为 null
+)
+
+const (
+	MaxHistorySeries         = 20    // 单次查询序列数上限
+	MaxHistorySeriesBuckets  = 10000 // 单个序列的桶数上限
+	DefaultHistorySeriesPage = 1000  // 默认每页点数（所有序列合计）
+	MaxHistorySeriesPage     = 10000 // 每页点数上限
+)
+
+// ErrHistorySeriesQueryInvalid 查询条件或游标无效
+var ErrHistorySeriesQueryInvalid = errors.New("invalid history series query")
+
+var historySeriesAggFuncs = []string{
+	HistoryAggAvg, HistoryAggMin, HistoryAggMax, HistoryAggSum, HistoryAggCount,
+	HistoryAggFirst, HistoryAggLast, HistoryAggDiff,
+}
+
+var historySeriesFills = []string{HistoryFillNone, HistoryFillNull, HistoryFillZero, HistoryFillPrevious, HistoryFillLinear}
+
+// 内存库与磁盘库交替查询，各用一份语句缓存，避免切换连接时反复重新 prepare
+var (
+	historySeriesMemStmtCache  dbStmtCache
+	historySeriesDiskStmtCache dbStmtCache
+)
+
+const historySeriesRawFromSQL = selectDataPointFields + ` WHERE device_id = ? AND field_name = ? AND collected_at >= ? AND collected_at <= ?
+	ORDER BY collected_at ASC, id ASC LIMIT ?`
+const historySeriesRawAfterSQL = selectDataPointFields + ` WHERE device_id = ? AND field_name = ? AND collected_at > ? AND collected_at <= ?
+	ORDER BY collected_at ASC, id ASC LIMIT ?`
+const historySeriesAggRawSQL = `SELECT device_name, value, value_num, collected_at FROM data_points
+	WHERE device_id = ? AND field_name = ? AND collected_at >= ? AND collected_at <= ?`
+
+// HistorySeriesKey 一个查询序列
+type HistorySeriesKey struct {
+	DeviceID  int64  `json:"device_id"`
+	FieldName string `json:"field_name"`
+}
+
+// HistorySeriesQuery 多序列查询条件。
+// Func 为空返回原始数据；Interval 为 0 时按时间范围与每页点数自动选取桶宽。
+type HistorySeriesQuery struct {
+	Series    []HistorySeriesKey
+	StartTime time.Time
+	EndTime   time.Time
+	Func      string
+	Interval  time.Duration
+	Fill      string
+	Limit     int
+	Cursor    string
+}
+
+// HistorySeriesPoint 序列中的一个点，聚合空桶的 Value 为 nil
+type HistorySeriesPoint struct {
+	Time  time.Time `json:"time"`
+	Value any       `json:"value"`
+}
+
+// HistorySeries 单个序列的查询结果
+type HistorySeries struct {
+	DeviceID   int64                `json:"device_id"`
+	DeviceName string               `json:"device_name"`
+	FieldName  string               `json:"field_name"`
+	Points     []HistorySeriesPoint `json:"points"`
+}
+
+// HistorySeriesResult 一页查询结果，NextCursor 为空表示没有更多数据
+type HistorySeriesResult struct {
+	Func            string           `json:"agg,omitempty"`
+	IntervalSeconds int64            `json:"interval_seconds,omitempty"`
+	Fill            string           `json:"fill,omitempty"`
+	Series          []*HistorySeries `json:"series"`
+	NextCursor      string           `json:"next_cursor,omitempty"`
+}
+
+// IsValidHistorySeriesAggFunc 多序列查询是否支持该聚合函数
+func IsValidHistorySeriesAggFunc(fn string) bool {
+	return slices.Contains(historySeriesAggFuncs, fn)
+}
+
+// IsValidHistoryFill 补齐策略是否受支持
+func IsValidHistoryFill(fill string) bool {
+	return slices.Contains(historySeriesFills, fill)
+}
+
+// QueryHistorySeries 多序列历史查询（磁盘 + 内存）。
+// 原始数据按（时间, 序列顺序）升序分页；聚合结果按桶升序分页，每页的桶数 = limit / 序列数。
+func QueryHistorySeries(query HistorySeriesQuery) (*HistorySeriesResult, error) {
+	if err := normalizeHistorySeriesQuery(&query); err != nil {
+		return nil, err
+	}
+	result := &HistorySeriesResult{Series: make([]*HistorySeries, len(query.Series))}
+	for i, key := range query.Series {
+		result.Series[i] = &HistorySeries{
+			DeviceID:   key.DeviceID,
+			DeviceName: normalizeDeviceName(key.DeviceID, ""),
+			FieldName:  key.FieldName,
+			Points:     []HistorySeriesPoint{},
+		}
+	}
+	if query.Func == "" {
+		return result, queryRawHistorySeries(query, result)
+	}
+	return result, queryAggregatedHistorySeries(query, result)
+}
+
+func normalizeHistorySeriesQuery(query *HistorySeriesQuery) error {
+	if len(query.Series) == 0 {
+		return fmt.Errorf("%w: series is required", ErrHistorySeriesQueryInvalid)
+	}
+	if len(query.Series) > MaxHistorySeries {
+		return fmt.Errorf("%w: at most %d series", ErrHistorySeriesQueryInvalid, MaxHistorySeries)
+	}
+	for i := range query.Series {
+		key := &query.Series[i]
+		key.FieldName = strings.TrimSpace(key.FieldName)
+		if (key.DeviceID <= 0 && key.DeviceID != models.SystemStatsDeviceID) || key.FieldName == "" {
+			return fmt.Errorf("%w: series[%d] needs device_id and field_name", ErrHistorySeriesQueryInvalid, i)
+		}
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultHistorySeriesPage
+	}
+	if query.Limit > MaxHistorySeriesPage {
+		query.Limit = MaxHistorySeriesPage
+	}
+	if query.EndTime.IsZero() {
+		query.EndTime = time.Now()
+	}
+	if !query.StartTime.IsZero() && query.StartTime.After(query.EndTime) {
+		return fmt.Errorf("%w: start must be before end", ErrHistorySeriesQueryInvalid)
+	}
+	if query.Func == "" {
+		if query.Interval > 0 || (query.Fill != "" && query.Fill != HistoryFillNone) {
+			return fmt.Errorf("%w: interval/fill require agg", ErrHistorySeriesQueryInvalid)
+		}
+		return nil
+	}
+
+	if !IsValidHistorySeriesAggFunc(query.Func) {
+		return fmt.Errorf("%w: unsupported agg %q", ErrHistorySeriesQueryInvalid, query.Func)
+	}
+	if query.Fill == "" {
+		query.Fill = HistoryFillNone
+	}
+	if !IsValidHistoryFill(query.Fill) {
+		return fmt.Errorf("%w: unsupported fill %q", ErrHistorySeriesQueryInvalid, query.Fill)
+	}
+	if query.StartTime.IsZero() {
+		query.StartTime = query.EndTime.Add(-defaultAggregatedHistoryRange)
+	}
+	if query.Interval <= 0 {
+		query.Interval = resolveHistoryInterval(query.StartTime, query.EndTime, max(query.Limit/len(query.Series), 1))
+	}
+	if query.Interval < time.Second || query.Interval%time.Second != 0 {
+		return fmt.Errorf("%w: interval must be a whole number of seconds", ErrHistorySeriesQueryInvalid)
+	}
+	if historySeriesBucketCount(query.StartTime, query.EndTime, query.Interval) > MaxHistorySeriesBuckets {
+		return fmt.Errorf("%w: more than %d buckets per series, use a larger interval", ErrHistorySeriesQueryInvalid, MaxHistorySeriesBuckets)
+	}
+	return nil
+}
+
+func historySeriesBucketCount(start, end time.Time, interval time.Duration) int64 {
+	return int64(end.Truncate(interval).Sub(start.Truncate(interval))/interval) + 1
+}
+
+// ==================== 原始数据 ====================
+
+// historySeriesRow 合并排序用：序列下标 + 数据点
+type historySeriesRow struct {
+	series int
+	point  *DataPoint
+}
+
+func queryRawHistorySeries(query HistorySeriesQuery, result *HistorySeriesResult) error {
+	cursorAt, cursorSeries, hasCursor, err := decodeRawHistoryCursor(query.Cursor)
+	if err != nil {
+		return err
+	}
+	start := formatSQLiteTime(query.StartTime.UTC())
+	end := formatSQLiteTime(query.EndTime.UTC())
+
+	// 持有同步锁，保证同一行不会在落盘过程中被内存库与磁盘库各读一次
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	diskDB := openHistorySeriesDiskDB()
+	rows := make([]historySeriesRow, 0, query.Limit+1)
+	for i, key := range query.Series {
+		sqlText, lower := historySeriesRawFromSQL, start
+		if hasCursor {
+			lower = formatSQLiteTime(cursorAt)
+			if i <= cursorSeries {
+				sqlText = historySeriesRawAfterSQL
+			}
+		}
+		// 每个来源各取 limit+1 行：合并后的前 limit 行一定完整，多出的行用于判断是否还有下一页
+		for _, source := range []struct {
+			db    *sql.DB
+			cache *dbStmtCache
+		}{{diskDB, &historySeriesDiskStmtCache}, {DataDB, &historySeriesMemStmtCache}} {
+			if source.db == nil {
+				continue
+			}
+			stmt, err := source.cache.get(source.db, sqlText)
+			if err != nil {
+				return err
+			}
+			points, err := listDataPointsStmtLimit(stmt, query.Limit+1, key.DeviceID, key.FieldName, lower, end, query.Limit+1)
+			if err != nil {
+				return err
+			}
+			for _, point := range points {
+				rows = append(rows, historySeriesRow{series: i, point: point})
+			}
+		}
+	}
+
+	sort.SliceStable(rows, func(a, b int) bool {
+		if c := rows[a].point.CollectedAt.Compare(rows[b].point.CollectedAt); c != 0 {
+			return c < 0
+		}
+		return rows[a].series < rows[b].series
+	})
+	page := rows
+	if len(rows) > query.Limit {
+		// 游标精度为秒：同一序列同一秒的行不拆到两页
+		cut := query.Limit
+		last := rows[cut-1]
+		for cut < len(rows) && rows[cut].series == last.series && rows[cut].point.CollectedAt.Equal(last.point.CollectedAt) {
+			cut++
+		}
+		page = rows[:cut]
+		if cut < len(rows) {
+			result.NextCursor = encodeRawHistoryCursor(last.point.CollectedAt, last.series)
+		}
+	}
+
+	for _, row := range page {
+		series := result.Series[row.series]
+		if series.DeviceName == "" {
+			series.DeviceName = normalizeDeviceName(row.point.DeviceID, row.point.DeviceName)
+		}
+		series.Points = append(series.Points, HistorySeriesPoint{
+			Time:  row.point.CollectedAt.UTC(),
+			Value: models.TypedCollectValue(row.point.Value, row.point.ValueType),
+		})
+	}
+	return nil
+}
+
+// openHistorySeriesDiskDB 磁盘库不存在或打开失败时只查内存库
+func openHistorySeriesDiskDB() *sql.DB {
+	if dataDBFile == "" {
+		return nil
+	}
+	db, err := openDataDiskDB()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to open data disk db for history query", "error", err)
+		}
+		return nil
+	}
+	return db
+}
+
+// ==================== 聚合 ====================
+
+func queryAggregatedHistorySeries(query HistorySeriesQuery, result *HistorySeriesResult) error {
+	interval := query.Interval
+	firstBucket := query.StartTime.Truncate(interval)
+	total := int(historySeriesBucketCount(query.StartTime, query.EndTime, interval))
+	perPage := max(query.Limit/len(query.Series), 1)
+
+	offset := 0
+	if query.Cursor != "" {
+		at, err := decodeAggHistoryCursor(query.Cursor)
+		if err != nil {
+			return err
+		}
+		offset = int(at.Sub(firstBucket) / interval)
+		if offset < 0 || offset >= total || !at.Equal(firstBucket.Add(time.Duration(offset)*interval)) {
+			return fmt.Errorf("%w: cursor out of range", ErrHistorySeriesQueryInvalid)
+		}
+	}
+	pageEnd := min(offset+perPage, total)
+	if pageEnd < total {
+		result.NextCursor = encodeAggHistoryCursor(firstBucket.Add(time.Duration(pageEnd) * interval))
+	}
+	result.Func = query.Func
+	result.IntervalSeconds = int64(interval / time.Second)
+	result.Fill = query.Fill
+
+	start := formatSQLiteTime(query.StartTime.UTC())
+	end := formatSQLiteTime(query.EndTime.UTC())
+	now := time.Now()
+
+	// 补齐需要整个范围的桶值（previous/linear 依赖页外的相邻桶），再截取当前页
+	dataSyncMu.Lock()
+	defer dataSyncMu.Unlock()
+
+	diskDB := openHistorySeriesDiskDB()
+	for i, key := range query.Series {
+		buckets := &historyBuckets{interval: interval, items: make(map[int64]*rollupAccumulator)}
+		if err := accumulateHistorySeries(buckets, diskDB, key, query, start, end, now); err != nil {
+			return err
+		}
+
+		values := make([]*float64, total)
+		for bucketKey, acc := range buckets.items {
+			index := int(time.Unix(bucketKey, 0).Sub(firstBucket) / interval)
+			if index < 0 || index >= total || acc.count == 0 {
+				continue
+			}
+			v := acc.value(query.Func)
+			values[index] = &v
+		}
+		fillHistoryBuckets(values, query.Fill)
+
+		series := result.Series[i]
+		if buckets.deviceName != "" {
+			series.DeviceName = normalizeDeviceName(key.DeviceID, buckets.deviceName)
+		}
+		for index := offset; index < pageEnd; index++ {
+			if values[index] == nil && query.Fill == HistoryFillNone {
+				continue
+			}
+			point := HistorySeriesPoint{Time: firstBucket.Add(time.Duration(index) * interval).UTC()}
+			if values[index] != nil {
+				point.Value = *values[index]
+			}
+			series.Points = append(series.Points, point)
+		}
+	}
+	return nil
+}
+
+// accumulateHistorySeries 汇总层级能提供的函数且桶宽为层级整数倍时走汇总表，其余从原始数据逐行聚合
+func accumulateHistorySeries(buckets *historyBuckets, diskDB *sql.DB, key HistorySeriesKey, query HistorySeriesQuery, start, end string, now time.Time) error {
+	useRollup := IsValidHistoryAggFunc(query.Func) && query.Interval%MinHistoryAggInterval() == 0
+	switch {
+	case diskDB == nil:
+	case useRollup:
+		tier := selectRollupTier(query.Interval, query.StartTime, now)
+		if err := accumulateDiskHistory(buckets, tier, key.DeviceID, key.FieldName, start, end); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to read aggregated history from disk", "error", err)
+		}
+	default:
+		if err := accumulateHistorySeriesRaw(buckets, diskDB, &historySeriesDiskStmtCache, key, start, end); err != nil {
+			slog.Warn("Failed to read history series from disk", "error", err)
+		}
+	}
+	return accumulateHistorySeriesRaw(buckets, DataDB, &historySeriesMemStmtCache, key, start, end)
+}
+
+func accumulateHistorySeriesRaw(buckets *historyBuckets, db *sql.DB, cache *dbStmtCache, key HistorySeriesKey, start, end string) error {
+	rows, err := queryRowsWithCachedStmt(db, cache, historySeriesAggRawSQL, key.DeviceID, key.FieldName, start, end)
+	if err != nil {
+		return err
+	}
+	return accumulateRawRows(buckets, rows)
+}
+
+// fillHistoryBuckets 按策略原地补齐空桶
+func fillHistoryBuckets(values []*float64, fill string) {
+	switch fill {
+	case HistoryFillZero:
+		for i := range values {
+			if values[i] == nil {
+				zero := 0.0
+				values[i] = &zero
+			}
+		}
+	case HistoryFillPrevious:
+		var prev *float64
+		for i := range values {
+			if values[i] == nil {
+				values[i] = prev
+			} else {
+				prev = values[i]
+			}
+		}
+	case HistoryFillLinear:
+		prevIndex := -1
+		for i := range values {
+			if values[i] == nil {
+				continue
+			}
+			if prevIndex >= 0 && i-prevIndex > 1 {
+				from, to := *values[prevIndex], *values[i]
+				step := (to - from) / float64(i-prevIndex)
+				for j := prevIndex + 1; j < i; j++ {
+					v := from + step*float64(j-prevIndex)
+					values[j] = &v
+				}
+			}
+			prevIndex = i
+		}
+	}
+}
+
+// ==================== 游标 ====================
+
+// 游标为 base64url 文本：原始数据 "r:<unix 秒>:<序列下标>"，聚合 "a:<桶起点 unix 秒>"
+
+func encodeRawHistoryCursor(at time.Time, series int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("r:%d:%d", at.Unix(), series)))
+}
+
+func encodeAggHistoryCursor(bucket time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("a:%d", bucket.Unix())))
+}
+
+func decodeHistoryCursor(cursor, kind string, parts int) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrHistorySeriesQueryInvalid)
+	}
+	fields := strings.Split(string(raw), ":")
+	if len(fields) != parts || fields[0] != kind {
+		return nil, fmt.Errorf("%w: cursor does not match query", ErrHistorySeriesQueryInvalid)
+	}
+	return fields[1:], nil
+}
+
+func decodeRawHistoryCursor(cursor string) (time.Time, int, bool, error) {
+	if cursor == "" {
+		return time.Time{}, 0, false, nil
+	}
+	fields, err := decodeHistoryCursor(cursor, "r", 3)
+	if err != nil {
+		return time.Time{}, 0, false, err
+	}
+	sec, errAt := strconv.ParseInt(fields[0], 10, 64)
+	series, errSeries := strconv.Atoi(fields[1])
+	if errAt != nil || errSeries != nil || series < 0 {
+		return time.Time{}, 0, false, fmt.Errorf("%w: malformed cursor", ErrHistorySeriesQueryInvalid)
+	}
+	return time.Unix(sec, 0).UTC(), series, true, nil
+}
+
+func decodeAggHistoryCursor(cursor string) (time.Time, error) {
+	fields, err := decodeHistoryCursor(cursor, "a", 2)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: malformed cursor", ErrHistorySeriesQueryInvalid)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// prepareHistorySeriesTestDB 字段 a、b 每 10 秒一个点，值为序号：0..29 已落盘，30..39 仍在内存
+func prepareHistorySeriesTestDB(t *testing.T) time.Time {
+	t.Helper()
+	diskDB := prepareDiskSchemaMemoryDB(t)
+	base := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	for i := 0; i < 40; i++ {
+		db := diskDB
+		if i >= 30 {
+			db = DataDB
+		}
+		insertHistorySeriesTestRow(t, db, "a", i, base.Add(time.Duration(i)*10*time.Second))
+		insertHistorySeriesTestRow(t, db, "b", i, base.Add(time.Duration(i)*10*time.Second))
+	}
+	return base
+}
+
+func insertHistorySeriesTestRow(t *testing.T, db *sql.DB, field string, value int, at time.Time) {
+	t.Helper()
+	if _, err := db.Exec(`INSERT INTO data_points (device_id, device_name, field_name, value, value_num, value_type, collected_at) VALUES (1, 'dev-1', ?, '', ?, 'int', ?)`,
+		field, value, formatSQLiteTime(at)); err != nil {
+		t.Fatalf("insert row: %v", err)
+	}
+}
+
+func TestQueryHistorySeries_RawPagesAcrossSeriesAndSources(t *testing.T) {
+	base := prepareHistorySeriesTestDB(t)
+	query := HistorySeriesQuery{
+		Series:    []HistorySeriesKey{{DeviceID: 1, FieldName: "a"}, {DeviceID: 1, FieldName: "b"}},
+		StartTime: base,
+		EndTime:   base.Add(time.Hour),
+		Limit:     7,
+	}
+
+	next := map[string]int64{"a": 0, "b": 0}
+	pages := 0
+	for {
+		result, err := QueryHistorySeries(query)
+		if err != nil {
+			t.Fatalf("QueryHistorySeries() error = %v", err)
+		}
+		pages++
+		for _, series := range result.Series {
+			if series.DeviceName != "dev-1" && len(series.Points) > 0 {
+				t.Fatalf("device name = %q", series.DeviceName)
+			}
+			for _, point := range series.Points {
+				if point.Value != next[series.FieldName] {
+					t.Fatalf("series %s got %v, want %d", series.FieldName, point.Value, next[series.FieldName])
+				}
+				next[series.FieldName]++
+			}
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	if next["a"] != 40 || next["b"] != 40 || pages != 12 {
+		t.Fatalf("rows = %v, pages = %d; want 40 per series in 12 pages", next, pages)
+	}
+}
+
+func TestQueryHistorySeries_AggregatesWithFillAndCursor(t *testing.T) {
+	base := prepareHistorySeriesTestDB(t)
+	// 第 6 个分钟桶（30..35）留空
+	if _, err := DataDB.Exec(`DELETE FROM data_points WHERE value_num BETWEEN 30 AND 35`); err != nil {
+		t.Fatalf("delete memory rows: %v", err)
+	}
+	query := HistorySeriesQuery{
+		Series:    []HistorySeriesKey{{DeviceID: 1, FieldName: "a"}},
+		StartTime: base,
+		EndTime:   base.Add(10 * time.Minute),
+		Func:      HistoryAggDiff,
+		Interval:  time.Minute,
+		Fill:      HistoryFillLinear,
+		Limit:     4,
+	}
+
+	var got []any
+	for {
+		result, err := QueryHistorySeries(query)
+		if err != nil {
+			t.Fatalf("QueryHistorySeries() error = %v", err)
+		}
+		if result.IntervalSeconds != 60 || len(result.Series[0].Points) > 4 {
+			t.Fatalf("page = %+v", result)
+		}
+		for _, point := range result.Series[0].Points {
+			got = append(got, point.Value)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		query.Cursor = result.NextCursor
+	}
+	// 满桶 diff = 5，空桶线性插值为 4，末桶 36..39 diff = 3，之后无数据为 null
+	want := []any{5.0, 5.0, 5.0, 5.0, 5.0, 4.0, 3.0, nil, nil, nil, nil}
+	if len(got) != len(want) {
+		t.Fatalf("diff values = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("diff values = %v, want %v", got, want)
+		}
+	}
+
+	query.Func = HistoryAggFirst
+	query.Fill = HistoryFillNone
+	query.Cursor = ""
+	query.Limit = 100
+	result, err := QueryHistorySeries(query)
+	if err != nil {
+		t.Fatalf("QueryHistorySeries(first) error = %v", err)
+	}
+	points := result.Series[0].Points
+	if len(points) != 6 || points[5].Value != 36.0 || !points[5].Time.Equal(base.Add(6*time.Minute)) {
+		t.Fatalf("first values = %+v", points)
+	}
+}
+
+func TestQueryHistorySeries_RejectsInvalidQuery(t *testing.T) {
+	prepareHistorySeriesTestDB(t)
+	now := time.Now()
+	series := []HistorySeriesKey{{DeviceID: 1, FieldName: "a"}}
+	for name, query := range map[string]HistorySeriesQuery{
+		"no series":    {},
+		"bad agg":      {Series: series, Func: "median"},
+		"bad fill":     {Series: series, Func: HistoryAggAvg, Fill: "spline"},
+		"fill raw":     {Series: series, Fill: HistoryFillZero},
+		"too many":     {Series: series, Func: HistoryAggAvg, Interval: time.Second, StartTime: now.Add(-24 * time.Hour), EndTime: now},
+		"bad cursor":   {Series: series, Cursor: "!!"},
+		"wrong cursor": {Series: series, Func: HistoryAggAvg, Cursor: encodeRawHistoryCursor(now, 0)},
+	} {
+		if _, err := QueryHistorySeries(query); !errors.Is(err, ErrHistorySeriesQueryInvalid) {
+			t.Fatalf("%s: error = %v, want ErrHistorySeriesQueryInvalid", name, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// 北向暂存表位于磁盘 data.db：北向断线或熔断期间的消息先落盘，恢复后按 id 顺序补发
+
+// dataDiskBusyTimeoutMS 暂存与同步并发写 data.db 时的锁等待时长
+const dataDiskBusyTimeoutMS = 5000
+
+var northboundSpoolMu sync.Mutex
+var northboundSpoolDB *sql.DB
+var northboundSpoolDBPath string
+
+// NorthboundSpoolStore 北向暂存存储（供北向管理器注入使用）
+type NorthboundSpoolStore struct{}
+
+// NewNorthboundSpoolStore 创建北向暂存存储
+func NewNorthboundSpoolStore() *NorthboundSpoolStore {
+	return &NorthboundSpoolStore{}
+}
+
+// Append 追加一条暂存消息
+func (s *NorthboundSpoolStore) Append(adapter, kind string, payload []byte, createdAt time.Time) (int64, error) {
+	return AppendNorthboundSpool(adapter, kind, payload, createdAt)
+}
+
+// List 按写入顺序读取最早的暂存消息
+func (s *NorthboundSpoolStore) List(adapter string, limit int) ([]*models.NorthboundSpoolEntry, error) {
+	return ListNorthboundSpool(adapter, limit)
+}
+
+// DeleteUpTo 删除 id 不大于 maxID 的暂存消息
+func (s *NorthboundSpoolStore) DeleteUpTo(adapter string, maxID int64) (int64, error) {
+	return DeleteNorthboundSpoolUpTo(adapter, maxID)
+}
+
+// Count 返回暂存消息数量
+func (s *NorthboundSpoolStore) Count(adapter string) (int, error) {
+	return CountNorthboundSpool(adapter)
+}
+
+// Trim 按条数与时长上限裁剪暂存消息（最旧的先删）
+func (s *NorthboundSpoolStore) Trim(adapter string, maxEntries int, maxAge time.Duration) (int64, error) {
+	return TrimNorthboundSpool(adapter, maxEntries, maxAge)
+}
+
+func withSQLiteBusyTimeout(dsn string, timeoutMS int) string {
+	if strings.Contains(dsn, "busy_timeout") {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s_pragma=busy_timeout(%d)", dsn, sep, timeoutMS)
+}
+
+func openNorthboundSpoolDB() (*sql.DB, error) {
+	if dataDBFile == "" {
+		return nil, fmt.Errorf("data db path is empty")
+	}
+
+	northboundSpoolMu.Lock()
+	defer northboundSpoolMu.Unlock()
+
+	if northboundSpoolDB != nil && northboundSpoolDBPath == dataDBFile {
+		return northboundSpoolDB, nil
+	}
+	if northboundSpoolDB != nil {
+		_ = northboundSpoolDB.Close()
+		northboundSpoolDB = nil
+		northboundSpoolDBPath = ""
+	}
+
+	db, err := openSQLite(withSQLiteBusyTimeout(dataDiskRWDSN(dataDBFile), dataDiskBusyTimeoutMS), 1, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open northbound spool database: %w", err)
+	}
+	if err := prepareDataDiskDB(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	northboundSpoolDB = db
+	northboundSpoolDBPath = dataDBFile
+	return northboundSpoolDB, nil
+}
+
+// CloseNorthboundSpool 关闭北向暂存连接
+func CloseNorthboundSpool() {
+	northboundSpoolMu.Lock()
+	defer northboundSpoolMu.Unlock()
+	if northboundSpoolDB != nil {
+		_ = northboundSpoolDB.Close()
+		northboundSpoolDB = nil
+		northboundSpoolDBPath = ""
+	}
+}
+
+// AppendNorthboundSpool 追加一条北向暂存消息
+func AppendNorthboundSpool(adapter, kind string, payload []byte, createdAt time.Time) (int64, error) {
+	db, err := openNorthboundSpoolDB()
+	if err != nil {
+		return 0, err
+	}
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	result, err := db.Exec(
+		`INSERT INTO northbound_spool (adapter, kind, payload, created_at) VALUES (?, ?, ?, ?)`,
+		adapter, kind, payload, createdAt.UnixMilli(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ListNorthboundSpool 按写入顺序读取指定北向最早的暂存消息
+func ListNorthboundSpool(adapter string, limit int) ([]*models.NorthboundSpoolEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	db, err := openNorthboundSpoolDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(
+		`SELECT id, adapter, kind, payload, created_at FROM northbound_spool
+		WHERE adapter = ? ORDER BY id ASC LIMIT ?`,
+		adapter, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.NorthboundSpoolEntry, 0, limit)
+	for rows.Next() {
+		entry := &models.NorthboundSpoolEntry{}
+		var createdAtMS int64
+		if err := rows.Scan(&entry.ID, &entry.Adapter, &entry.Kind, &entry.Payload, &createdAtMS); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = time.UnixMilli(createdAtMS)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteNorthboundSpoolUpTo 删除指定北向 id 不大于 maxID 的暂存消息
+func DeleteNorthboundSpoolUpTo(adapter string, maxID int64) (int64, error) {
+	db, err := openNorthboundSpoolDB()
+	if err != nil {
+		return 0, err
+	}
+	result, err := db.Exec(`DELETE FROM northbound_spool WHERE adapter = ? AND id <= ?`, adapter, maxID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountNorthboundSpool 返回指定北向的暂存消息数量
+func CountNorthboundSpool(adapter string) (int, error) {
+	db, err := openNorthboundSpoolDB()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM northbound_spool WHERE adapter = ?`, adapter).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// TrimNorthboundSpool 按时长与条数上限裁剪暂存消息，最旧的先删；上限 <=0 表示不限制
+func TrimNorthboundSpool(adapter string, maxEntries int, maxAge time.Duration) (int64, error) {
+	db, err := openNorthboundSpoolDB()
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge).UnixMilli()
+		result, err := db.Exec(`DELETE FROM northbound_spool WHERE adapter = ? AND created_at < ?`, adapter, cutoff)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	if maxEntries > 0 {
+		result, err := db.Exec(
+			`DELETE FROM northbound_spool WHERE adapter = ? AND id NOT IN (
+				SELECT id FROM northbound_spool WHERE adapter = ? ORDER BY id DESC LIMIT ?
+			)`,
+			adapter, adapter, maxEntries,
+		)
+		if err != nil {
+			return deleted, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupNorthboundSpoolTestDB(t *testing.T) {
+	t.Helper()
+	oldPath := dataDBFile
+	CloseNorthboundSpool()
+	dataDBFile = filepath.Join(t.TempDir(), "data.db")
+	t.Cleanup(func() {
+		CloseNorthboundSpool()
+		dataDBFile = oldPath
+	})
+}
+
+func TestNorthboundSpool_AppendListDelete(t *testing.T) {
+	setupNorthboundSpoolTestDB(t)
+
+	base := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	for i, kind := range []string{"data", "alarm", "data"} {
+		if _, err := AppendNorthboundSpool("mqtt1", kind, []byte{byte('a' + i)}, base.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("AppendNorthboundSpool() error = %v", err)
+		}
+	}
+	if _, err := AppendNorthboundSpool("other", "data", []byte("x"), base); err != nil {
+		t.Fatalf("AppendNorthboundSpool(other) error = %v", err)
+	}
+
+	entries, err := ListNorthboundSpool("mqtt1", 2)
+	if err != nil {
+		t.Fatalf("ListNorthboundSpool() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Kind != "data" || entries[1].Kind != "alarm" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if !entries[0].CreatedAt.Equal(base) || string(entries[1].Payload) != "b" {
+		t.Fatalf("entry not preserved: created_at=%v payload=%q", entries[0].CreatedAt, entries[1].Payload)
+	}
+
+	deleted, err := DeleteNorthboundSpoolUpTo("mqtt1", entries[1].ID)
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteNorthboundSpoolUpTo() = %d, %v; want 2", deleted, err)
+	}
+	if count, _ := CountNorthboundSpool("mqtt1"); count != 1 {
+		t.Fatalf("count(mqtt1) = %d, want 1", count)
+	}
+	if count, _ := CountNorthboundSpool("other"); count != 1 {
+		t.Fatalf("count(other) = %d, want 1", count)
+	}
+}
+
+func TestNorthboundSpool_TrimByAgeAndEntries(t *testing.T) {
+	setupNorthboundSpoolTestDB(t)
+
+	now := time.Now()
+	_, _ = AppendNorthboundSpool("mqtt1", "data", []byte("old"), now.Add(-2*time.Hour))
+	for i := 0; i < 4; i++ {
+		_, _ = AppendNorthboundSpool("mqtt1", "data", []byte{byte('0' + i)}, now)
+	}
+
+	deleted, err := TrimNorthboundSpool("mqtt1", 3, time.Hour)
+	if err != nil {
+		t.Fatalf("TrimNorthboundSpool() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Fatalf("deleted = %d, want 2", deleted)
+	}
+	entries, _ := ListNorthboundSpool("mqtt1", 10)
+	if len(entries) != 3 || string(entries[0].Payload) != "1" {
+		t.Fatalf("expected newest 3 entries to remain, got %+v", entries)
+	}
+}
@@ -2,8 +2,6 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
-	"strings"
 	"time"
 
 	"github.com/gonglijing/xunjiFsu/internal/models"
@@ -23,10 +21,16 @@ const selectNorthboundConfigFields = `SELECT id, name, type, enabled, upload_int
 
 // CreateNorthboundConfig 创建北向配置
 func CreateNorthboundConfig(config *models.NorthboundConfig) (int64, error) {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return 0, err
-	}
-	result, err := ParamDB.Exec(
+	return createNorthboundConfig(ParamDB, config)
+}
+
+// CreateNorthboundConfig 在事务内创建北向配置
+func (tx *ParamTx) CreateNorthboundConfig(config *models.NorthboundConfig) (int64, error) {
+	return createNorthboundConfig(tx.tx, config)
+}
+
+func createNorthboundConfig(db paramExecer, config *models.NorthboundConfig) (int64, error) {
+	result, err := db.Exec(
 		`INSERT INTO northbound_configs (
 			name, type, enabled, upload_interval,
 			server_url, port, path, username, password, client_id,
@@ -50,9 +54,6 @@ func CreateNorthboundConfig(config *models.NorthboundConfig) (int64, error) {
 
 // LoadNorthboundConfig 根据ID获取北向配置
 func LoadNorthboundConfig(id int64) (*models.NorthboundConfig, error) {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return nil, err
-	}
 	config := &models.NorthboundConfig{}
 	err := scanNorthboundConfig(
 		ParamDB.QueryRow(selectNorthboundConfigFields+" WHERE id = ?", id),
@@ -64,28 +65,35 @@ func LoadNorthboundConfig(id int64) (*models.NorthboundConfig, error) {
 	return config, nil
 }
 
+// LoadNorthboundPassword 读取北向认证密码（常规查询不返回密码）
+func LoadNorthboundPassword(id int64) (string, error) {
+	var password string
+	err := ParamDB.QueryRow(`SELECT COALESCE(password, '') FROM northbound_configs WHERE id = ?`, id).Scan(&password)
+	return password, err
+}
+
 // ListNorthboundConfigs 获取所有北向配置
 func ListNorthboundConfigs() ([]*models.NorthboundConfig, error) {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return nil, err
-	}
 	return listNorthboundConfigs(selectNorthboundConfigFields+" ORDER BY id", nil)
 }
 
 // ListEnabledNorthboundConfigs 获取所有启用的北向配置
 func ListEnabledNorthboundConfigs() ([]*models.NorthboundConfig, error) {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return nil, err
-	}
 	return listNorthboundConfigs(selectNorthboundConfigFields+" WHERE enabled = 1 ORDER BY id", nil)
 }
 
 // UpdateNorthboundConfig 更新北向配置
 func UpdateNorthboundConfig(config *models.NorthboundConfig) error {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return err
-	}
-	_, err := ParamDB.Exec(
+	return updateNorthboundConfig(ParamDB, config)
+}
+
+// UpdateNorthboundConfig 在事务内更新北向配置
+func (tx *ParamTx) UpdateNorthboundConfig(config *models.NorthboundConfig) error {
+	return updateNorthboundConfig(tx.tx, config)
+}
+
+func updateNorthboundConfig(db paramExecer, config *models.NorthboundConfig) error {
+	_, err := db.Exec(
 		`UPDATE northbound_configs SET
 			name = ?, type = ?, enabled = ?, upload_interval = ?,
 			server_url = ?, port = ?, path = ?, username = ?, password = ?, client_id = ?,
@@ -108,9 +116,6 @@ func UpdateNorthboundConfig(config *models.NorthboundConfig) error {
 
 // UpdateNorthboundEnabled 更新北向使能状态
 func UpdateNorthboundEnabled(id int64, enabled int) error {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return err
-	}
 	_, err := ParamDB.Exec(
 		"UPDATE northbound_configs SET enabled = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
 		enabled, id,
@@ -120,9 +125,6 @@ func UpdateNorthboundEnabled(id int64, enabled int) error {
 
 // UpdateNorthboundConnected 更新北向连接状态
 func UpdateNorthboundConnected(id int64, connected bool) error {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return err
-	}
 
 	_, err := ParamDB.Exec(
 		"UPDATE northbound_configs SET connected = ?, last_connected_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
@@ -133,14 +135,15 @@ func UpdateNorthboundConnected(id int64, connected bool) error {
 
 // DeleteNorthboundConfig 删除北向配置
 func DeleteNorthboundConfig(id int64) error {
-	if err := ensureNorthboundConfigColumns(); err != nil {
-		return err
-	}
 	_, err := ParamDB.Exec("DELETE FROM northbound_configs WHERE id = ?", id)
 	return err
 }
 
-var northboundColumnsEnsured bool
+// DeleteNorthboundConfig 在事务内删除北向配置
+func (tx *ParamTx) DeleteNorthboundConfig(id int64) error {
+	_, err := tx.tx.Exec("DELETE FROM northbound_configs WHERE id = ?", id)
+	return err
+}
 
 func listNorthboundConfigs(query string, args []any) ([]*models.NorthboundConfig, error) {
 	return queryList[*models.NorthboundConfig](ParamDB, query, args, func(rows *sql.Rows) (*models.NorthboundConfig, error) {
@@ -178,69 +181,6 @@ func northboundLastConnectedValue(connected bool) any {
 	return &now
 }
 
-func ensureNorthboundConfigColumns() error {
-	if northboundColumnsEnsured {
-		return nil
-	}
-
-	rows, err := ParamDB.Query("PRAGMA table_info(northbound_configs)")
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-
-	hasColumn := make(map[string]bool)
-	for rows.Next() {
-		var cid int
-		var name, ctype string
-		var notnull, pk int
-		var dflt sql.NullString
-		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
-			return err
-		}
-		hasColumn[strings.ToLower(strings.TrimSpace(name))] = true
-	}
-	if err := rows.Err(); err != nil {
-		return err
-	}
-
-	columns := []struct {
-		name    string
-		typeDef string
-	}{
-		{name: "server_url", typeDef: "TEXT"},
-		{name: "port", typeDef: "INTEGER DEFAULT 0"},
-		{name: "path", typeDef: "TEXT"},
-		{name: "username", typeDef: "TEXT"},
-		{name: "password", typeDef: "TEXT"},
-		{name: "client_id", typeDef: "TEXT"},
-		{name: "topic", typeDef: "TEXT"},
-		{name: "alarm_topic", typeDef: "TEXT"},
-		{name: "qos", typeDef: "INTEGER DEFAULT 0"},
-		{name: "retain", typeDef: "INTEGER DEFAULT 0"},
-		{name: "keep_alive", typeDef: "INTEGER DEFAULT 60"},
-		{name: "timeout", typeDef: "INTEGER DEFAULT 30"},
-		{name: "product_key", typeDef: "TEXT"},
-		{name: "device_key", typeDef: "TEXT"},
-		{name: "ext_config", typeDef: "TEXT"},
-		{name: "connected", typeDef: "INTEGER DEFAULT 0"},
-		{name: "last_connected_at", typeDef: "DATETIME"},
-	}
-
-	for _, col := range columns {
-		if hasColumn[col.name] {
-			continue
-		}
-		stmt := fmt.Sprintf("ALTER TABLE northbound_configs ADD COLUMN %s %s", col.name, col.typeDef)
-		if _, err := ParamDB.Exec(stmt); err != nil {
-			return err
-		}
-	}
-
-	northboundColumnsEnsured = true
-	return nil
-}
-
 // getCurrentTime 获取当前时间
 func getCurrentTime() time.Time {
 	return time.Now()
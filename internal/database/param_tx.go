@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// paramExecer *sql.DB 与 *sql.Tx 共有的读写方法，配置写入在事务内外共用同一份 SQL
+type paramExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// ParamTx param.db 上的事务，配置导入等多步写入经它整体提交或回滚
+type ParamTx struct {
+	tx *sql.Tx
+}
+
+// WithParamTx 在一个 param.db 事务中执行 fn：fn 返回错误时回滚，否则提交；
+// 只回滚 fn 自己的写入，采集、告警等并发写入不受影响
+func WithParamTx(fn func(tx *ParamTx) error) error {
+	if ParamDB == nil {
+		return fmt.Errorf("param database is not initialized")
+	}
+	tx, err := ParamDB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin param transaction: %w", err)
+	}
+	if err := fn(&ParamTx{tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit param transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func TestWithParamTx_RollbackKeepsConcurrentWrites(t *testing.T) {
+	prepareParamSchemaTestDB(t)
+
+	errImport := errors.New("import failed")
+	err := WithParamTx(func(tx *ParamTx) error {
+		// 事务外的告警写入（采集协程）不随导入回滚
+		if _, err := CreateAlarmLog(&models.AlarmLog{DeviceID: 1, FieldName: "Ua", Operator: ">", Severity: "warning"}); err != nil {
+			return err
+		}
+		if _, err := tx.CreateResource(&models.Resource{Name: "com1", Type: "serial", Path: "/dev/ttyUSB0", Enabled: 1}); err != nil {
+			return err
+		}
+		return errImport
+	})
+	if !errors.Is(err, errImport) {
+		t.Fatalf("WithParamTx() error = %v, want %v", err, errImport)
+	}
+	if resources, _ := ListResources(); len(resources) != 0 {
+		t.Fatalf("resources after rollback = %d, want 0", len(resources))
+	}
+	if logs, _ := ListRecentAlarmLogs(10); len(logs) != 1 {
+		t.Fatalf("alarm logs after rollback = %d, want 1", len(logs))
+	}
+
+	if err := WithParamTx(func(tx *ParamTx) error {
+		_, err := tx.CreateResource(&models.Resource{Name: "com1", Type: "serial", Path: "/dev/ttyUSB0", Enabled: 1})
+		return err
+	}); err != nil {
+		t.Fatalf("WithParamTx() commit error = %v", err)
+	}
+	if resources, _ := ListResources(); len(resources) != 1 {
+		t.Fatalf("resources after commit = %d, want 1", len(resources))
+	}
+}
@@ -36,7 +36,7 @@ func setupResourceTestDB(t *testing.T, createSQL string) {
 	}
 }
 
-func TestInitResourceTable_CleansLegacyColumns(t *testing.T) {
+func TestParamMigrations_CleanLegacyResourceColumns(t *testing.T) {
 	setupResourceTestDB(t, `CREATE TABLE resources (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT UNIQUE NOT NULL,
@@ -54,8 +54,8 @@ func TestInitResourceTable_CleansLegacyColumns(t *testing.T) {
 		t.Fatalf("insert legacy resource: %v", err)
 	}
 
-	if err := InitResourceTable(); err != nil {
-		t.Fatalf("InitResourceTable: %v", err)
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
 	}
 
 	hasPort, err := columnExists(ParamDB, "resources", "port")
@@ -90,7 +90,7 @@ func TestInitResourceTable_CleansLegacyColumns(t *testing.T) {
 	}
 }
 
-func TestInitResourceTable_NormalizesLegacyTypeWhenCleaning(t *testing.T) {
+func TestParamMigrations_NormalizeLegacyResourceType(t *testing.T) {
 	setupResourceTestDB(t, `CREATE TABLE resources (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT UNIQUE NOT NULL,
@@ -109,8 +109,8 @@ func TestInitResourceTable_NormalizesLegacyTypeWhenCleaning(t *testing.T) {
 		t.Fatalf("insert legacy net resource: %v", err)
 	}
 
-	if err := InitResourceTable(); err != nil {
-		t.Fatalf("InitResourceTable: %v", err)
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
 	}
 
 	var gotType string
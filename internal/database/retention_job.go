@@ -1,9 +1,12 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -64,23 +67,26 @@ func CleanupOldData() (int64, error) {
 	return CleanupOldDataByGatewayRetention()
 }
 
-// CleanupOldDataByGatewayRetention 根据网关全局保留天数清理过期数据
+// CleanupOldDataByGatewayRetention 根据网关全局保留天数与设备级策略清理过期数据
 func CleanupOldDataByGatewayRetention() (int64, error) {
 	days := GetGatewayDataRetentionDays()
 	if days <= 0 {
 		days = DefaultRetentionDays
 	}
+	overrides, err := enabledRetentionOverrides()
+	if err != nil {
+		// 策略表读取失败时按全局天数清理，保证清理任务不中断
+		slog.Warn("Failed to load device retention policies", "error", err)
+		overrides = nil
+	}
+	statements := retentionDeleteStatements(days, overrides)
 
-	result, err := DataDB.Exec(
-		`DELETE FROM data_points WHERE collected_at < datetime('now', ?)`,
-		fmt.Sprintf("-%d days", days),
-	)
+	memDeleted, err := execRetentionStatements(DataDB, statements)
 	if err != nil {
 		return 0, err
 	}
-	memDeleted, _ := result.RowsAffected()
 
-	diskDeleted, err := cleanupOldDataOnDisk(days)
+	diskDeleted, err := cleanupOldDataOnDisk(statements)
 	if err != nil {
 		return memDeleted, err
 	}
@@ -88,7 +94,55 @@ func CleanupOldDataByGatewayRetention() (int64, error) {
 	return memDeleted + diskDeleted, nil
 }
 
-func cleanupOldDataOnDisk(days int) (int64, error) {
+// retentionStatement 一条按保留天数删除的 SQL
+type retentionStatement struct {
+	query string
+	args  []any
+}
+
+// retentionDeleteStatements 生成清理语句：全局天数作用于无覆盖的设备，覆盖设备各自按其天数清理
+func retentionDeleteStatements(defaultDays int, overrides map[int64]int) []retentionStatement {
+	deviceIDs := make([]int64, 0, len(overrides))
+	for deviceID := range overrides {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	slices.Sort(deviceIDs)
+
+	global := retentionStatement{
+		query: `DELETE FROM data_points WHERE collected_at < datetime('now', ?)`,
+		args:  []any{fmt.Sprintf("-%d days", defaultDays)},
+	}
+	if len(deviceIDs) > 0 {
+		global.query += " AND device_id NOT IN (?" + strings.Repeat(", ?", len(deviceIDs)-1) + ")"
+		for _, deviceID := range deviceIDs {
+			global.args = append(global.args, deviceID)
+		}
+	}
+
+	statements := []retentionStatement{global}
+	for _, deviceID := range deviceIDs {
+		statements = append(statements, retentionStatement{
+			query: `DELETE FROM data_points WHERE device_id = ? AND collected_at < datetime('now', ?)`,
+			args:  []any{deviceID, fmt.Sprintf("-%d days", overrides[deviceID])},
+		})
+	}
+	return statements
+}
+
+func execRetentionStatements(db *sql.DB, statements []retentionStatement) (int64, error) {
+	var deleted int64
+	for _, stmt := range statements {
+		result, err := db.Exec(stmt.query, stmt.args...)
+		if err != nil {
+			return deleted, err
+		}
+		affected, _ := result.RowsAffected()
+		deleted += affected
+	}
+	return deleted, nil
+}
+
+func cleanupOldDataOnDisk(statements []retentionStatement) (int64, error) {
 	if dataDBFile == "" {
 		return 0, nil
 	}
@@ -105,18 +159,20 @@ func cleanupOldDataOnDisk(days int) (int64, error) {
 	}
 	defer diskDB.Close()
 
-	if err := ensureDiskDataSchema(diskDB); err != nil {
+	if err := prepareDataDiskDB(diskDB); err != nil {
 		return 0, err
 	}
 
-	result, err := diskDB.Exec(
-		`DELETE FROM data_points WHERE collected_at < datetime('now', ?)`,
-		fmt.Sprintf("-%d days", days),
-	)
+	deleted, err := execRetentionStatements(diskDB, statements)
 	if err != nil {
 		return 0, err
 	}
+	if _, err := cleanupRollupsOnDisk(diskDB); err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		reclaimDataDiskPages(diskDB)
+	}
 
-	deleted, _ := result.RowsAffected()
 	return deleted, nil
 }
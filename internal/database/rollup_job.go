@@ -0,0 +1,59 @@
+package database
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 降采样汇总相关
+var rollupControlMu sync.Mutex
+var rollupTicker *time.Ticker
+var rollupStop chan struct{}
+
+// StartDataRollup 启动定期降采样汇总（只处理已落盘的数据）
+func StartDataRollup(interval time.Duration) {
+	rollupControlMu.Lock()
+	defer rollupControlMu.Unlock()
+	if rollupTicker != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	rollupStop = make(chan struct{})
+	rollupTicker = time.NewTicker(interval)
+	ticker, stop := rollupTicker, rollupStop
+
+	go func() {
+		slog.Info("Data rollup started", "interval", interval)
+		if err := RefreshDataRollups(); err != nil {
+			slog.Error("Initial data rollup error", "error", err)
+		}
+		for {
+			select {
+			case <-ticker.C:
+				if err := RefreshDataRollups(); err != nil {
+					slog.Error("Data rollup error", "error", err)
+				}
+			case <-stop:
+				slog.Info("Data rollup stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopDataRollup 停止降采样汇总任务
+func StopDataRollup() {
+	rollupControlMu.Lock()
+	defer rollupControlMu.Unlock()
+	if rollupTicker != nil {
+		rollupTicker.Stop()
+		rollupTicker = nil
+	}
+	if rollupStop != nil {
+		close(rollupStop)
+		rollupStop = nil
+	}
+}
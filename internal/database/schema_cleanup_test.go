@@ -1,45 +1,11 @@
 package database
 
 import (
-	"os"
 	"path/filepath"
 	"testing"
 )
 
-func switchToRepoRoot(t *testing.T) {
-	t.Helper()
-
-	originalWD, err := os.Getwd()
-	if err != nil {
-		t.Fatalf("getwd: %v", err)
-	}
-
-	candidates := []string{
-		".",
-		"..",
-		"../..",
-		"../../..",
-	}
-
-	for _, candidate := range candidates {
-		migrationPath := filepath.Join(candidate, "migrations", "002_param_schema.sql")
-		if _, err := os.Stat(migrationPath); err == nil {
-			if err := os.Chdir(candidate); err != nil {
-				t.Fatalf("chdir to %s: %v", candidate, err)
-			}
-			t.Cleanup(func() {
-				_ = os.Chdir(originalWD)
-			})
-			return
-		}
-	}
-
-	t.Fatalf("could not locate repository root with migrations directory from %s", originalWD)
-}
-
 func TestInitParamSchema_DropsUnusedStoragePoliciesTable(t *testing.T) {
-	switchToRepoRoot(t)
-
 	oldParam := ParamDB
 	t.Cleanup(func() {
 		if ParamDB != nil {
@@ -87,8 +53,6 @@ func TestInitParamSchema_DropsUnusedStoragePoliciesTable(t *testing.T) {
 }
 
 func TestInitDataSchema_DropsUnusedStorageConfigTable(t *testing.T) {
-	switchToRepoRoot(t)
-
 	oldData := DataDB
 	oldParam := ParamDB
 	t.Cleanup(func() {
@@ -148,8 +112,6 @@ func TestInitDataSchema_DropsUnusedStorageConfigTable(t *testing.T) {
 }
 
 func TestInitDataSchema_CreatesAlarmLogIndexesOnParamDB(t *testing.T) {
-	switchToRepoRoot(t)
-
 	oldData := DataDB
 	oldParam := ParamDB
 	t.Cleanup(func() {
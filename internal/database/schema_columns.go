@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// schemaColumn 迁移执行前须存在的列
+type schemaColumn struct {
+	table      string
+	name       string
+	definition string
+}
+
+// legacySchemaColumns 各迁移版本执行 SQL 前补齐的列（迁移目录 → 版本 → 列）。
+// 版本化之前的旧库由运行时按需补列，缺哪些列不确定，SQL 脚本里的 ADD COLUMN 无法在任意旧结构上执行，
+// 因此逐列检查后补齐；表不存在时跳过，由迁移脚本创建完整的表。新的结构变更直接写在 SQL 脚本里
+var legacySchemaColumns = map[string]map[int][]schemaColumn{
+	migrationSetParam: {
+		5: {
+			{"devices", "device_config", "TEXT"},
+		},
+		6: {
+			{"devices", "product_key", "TEXT"},
+			{"devices", "device_key", "TEXT"},
+			{"devices", "driver_type", "TEXT DEFAULT 'modbus_rtu'"},
+			{"devices", "serial_port", "TEXT"},
+			{"devices", "baud_rate", "INTEGER DEFAULT 9600"},
+			{"devices", "data_bits", "INTEGER DEFAULT 8"},
+			{"devices", "stop_bits", "INTEGER DEFAULT 1"},
+			{"devices", "parity", "TEXT CHECK(parity IN ('N', 'O', 'E'))"},
+			{"devices", "ip_address", "TEXT"},
+			{"devices", "port_num", "INTEGER DEFAULT 502"},
+			{"devices", "device_address", "TEXT"},
+			{"devices", "collect_interval", "INTEGER DEFAULT 5000"},
+			{"devices", "storage_interval", "INTEGER DEFAULT 300"},
+			{"devices", "timeout", "INTEGER DEFAULT 1000"},
+			{"devices", "driver_id", "INTEGER"},
+			{"devices", "resource_id", "INTEGER"},
+			{"devices", "point_table", "TEXT"},
+			{"devices", "deadbands", "TEXT"},
+
+			{"thresholds", "shielded", "INTEGER DEFAULT 0"},
+			{"thresholds", "expression", "TEXT"},
+			{"thresholds", "deadband", "REAL DEFAULT 0"},
+			{"thresholds", "trigger_delay_seconds", "INTEGER DEFAULT 0"},
+			{"thresholds", "clear_delay_seconds", "INTEGER DEFAULT 0"},
+
+			{"alarm_logs", "cleared_at", "TIMESTAMP"},
+
+			{"northbound_configs", "server_url", "TEXT"},
+			{"northbound_configs", "port", "INTEGER DEFAULT 0"},
+			{"northbound_configs", "path", "TEXT"},
+			{"northbound_configs", "username", "TEXT"},
+			{"northbound_configs", "password", "TEXT"},
+			{"northbound_configs", "client_id", "TEXT"},
+			{"northbound_configs", "topic", "TEXT"},
+			{"northbound_configs", "alarm_topic", "TEXT"},
+			{"northbound_configs", "qos", "INTEGER DEFAULT 0"},
+			{"northbound_configs", "retain", "INTEGER DEFAULT 0"},
+			{"northbound_configs", "keep_alive", "INTEGER DEFAULT 60"},
+			{"northbound_configs", "timeout", "INTEGER DEFAULT 30"},
+			{"northbound_configs", "product_key", "TEXT"},
+			{"northbound_configs", "device_key", "TEXT"},
+			{"northbound_configs", "ext_config", "TEXT"},
+			{"northbound_configs", "connected", "INTEGER DEFAULT 0"},
+			{"northbound_configs", "last_connected_at", "DATETIME"},
+		},
+		9: {
+			{"gateway_config", "data_retention_days", "INTEGER DEFAULT 30"},
+			{"gateway_config", "alarm_repeat_interval_seconds", "INTEGER DEFAULT 60"},
+			// 重建资源表时用旧版 port 回填 path，两列都补齐后 SQL 才能统一写成 COALESCE(path, port, '')
+			{"resources", "path", "TEXT"},
+			{"resources", "port", "TEXT"},
+		},
+	},
+	migrationSetDataDisk: {
+		3: {
+			{"data_points", "value_num", "REAL"},
+		},
+	},
+}
+
+// addMissingColumns 补齐表中缺失的列，表不存在时跳过
+func addMissingColumns(tx *sql.Tx, columns []schemaColumn) error {
+	existing := make(map[string]map[string]bool)
+	for _, col := range columns {
+		names, ok := existing[col.table]
+		if !ok {
+			var err error
+			if names, err = tableColumns(tx, col.table); err != nil {
+				return fmt.Errorf("failed to inspect %s columns: %w", col.table, err)
+			}
+			existing[col.table] = names
+		}
+		if len(names) == 0 || names[col.name] {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, col.table, col.name, col.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", col.table, col.name, err)
+		}
+		names[col.name] = true
+	}
+	return nil
+}
+
+// tableColumns 返回表的列名集合，表不存在时为空
+func tableColumns(db schemaQueryer, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var (
+		cid     int
+		name    string
+		typ     string
+		notnull int
+		dflt    sql.NullString
+		pk      int
+	)
+	names := make(map[string]bool)
+	for rows.Next() {
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
+
+// columnExists checks if a column exists in a table.
+func columnExists(db schemaQueryer, table, column string) (bool, error) {
+	names, err := tableColumns(db, table)
+	if err != nil {
+		return false, err
+	}
+	return names[column], nil
+}
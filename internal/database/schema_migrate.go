@@ -0,0 +1,158 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/gonglijing/xunjiFsu/migrations"
+)
+
+// 迁移脚本目录（见 migrations 包）
+const (
+	migrationSetParam    = "param"
+	migrationSetData     = "data"
+	migrationSetDataDisk = "data_disk"
+)
+
+// ErrSchemaTooNew 数据库 schema 版本高于当前程序内嵌的最新迁移版本（通常是降级运行了旧程序）
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_[A-Za-z0-9_]+\.sql$`)
+
+// schemaQueryer *sql.DB 与 *sql.Tx 共有的查询方法
+type schemaQueryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// schemaMigration 一个版本的迁移脚本
+type schemaMigration struct {
+	version int
+	name    string
+	sql     string
+	// columns 执行 sql 前补齐的列（见 legacySchemaColumns）
+	columns []schemaColumn
+}
+
+// loadSchemaMigrations 读取目录下的迁移脚本，按版本升序返回；版本号须从 1 开始连续
+func loadSchemaMigrations(fsys fs.FS, dir string) ([]schemaMigration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations %s: %w", dir, err)
+	}
+
+	var list []schemaMigration
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s/%s", dir, entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s/%s", dir, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s/%s: %w", dir, entry.Name(), err)
+		}
+		list = append(list, schemaMigration{
+			version: version,
+			name:    entry.Name(),
+			sql:     string(content),
+			columns: legacySchemaColumns[dir][version],
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+	for i, m := range list {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migrations %s: expected version %d, got %s", dir, i+1, m.name)
+		}
+	}
+	return list, nil
+}
+
+// migrateSchema 把内嵌目录 dir 的迁移应用到 db
+func migrateSchema(db *sql.DB, dir string) error {
+	list, err := loadSchemaMigrations(migrations.FS, dir)
+	if err != nil {
+		return err
+	}
+	_, err = applySchemaMigrations(db, dir, list)
+	return err
+}
+
+// applySchemaMigrations 按版本顺序逐个在事务内执行未应用的迁移，并写入 schema_migrations；
+// 已记录版本高于 list 最新版本时返回 ErrSchemaTooNew，不做任何修改
+func applySchemaMigrations(db *sql.DB, label string, list []schemaMigration) (int, error) {
+	if db == nil {
+		return 0, fmt.Errorf("%s database is not initialized", label)
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return 0, fmt.Errorf("failed to ensure schema_migrations on %s: %w", label, err)
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s schema version: %w", label, err)
+	}
+	latest := 0
+	if len(list) > 0 {
+		latest = list[len(list)-1].version
+	}
+	if current > latest {
+		return 0, fmt.Errorf("%w: %s schema version %d, binary supports up to %d", ErrSchemaTooNew, label, current, latest)
+	}
+
+	applied := 0
+	for _, m := range list {
+		if m.version <= current {
+			continue
+		}
+		if err := applySchemaMigration(db, m); err != nil {
+			return applied, fmt.Errorf("failed to apply %s migration %s: %w", label, m.name, err)
+		}
+		applied++
+		slog.Info("Applied schema migration", "database", label, "version", m.version, "name", m.name)
+	}
+	return applied, nil
+}
+
+func applySchemaMigration(db *sql.DB, m schemaMigration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addMissingColumns(tx, m.columns); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(m.sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// schemaVersion 返回已应用的最大迁移版本，未迁移过时为 0
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow(`SELECT IFNULL(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
@@ -1,35 +1,160 @@
 package database
 
-import "testing"
-
-func TestParseIndexTableName(t *testing.T) {
-	tests := []struct {
-		name string
-		stmt string
-		want string
-	}{
-		{
-			name: "plain table",
-			stmt: "CREATE INDEX idx_device_id ON data_points(device_id);",
-			want: "data_points",
-		},
-		{
-			name: "quoted schema table",
-			stmt: "CREATE INDEX idx_alarm ON `main`.`alarm_logs` (`device_id`);",
-			want: "alarm_logs",
-		},
-		{
-			name: "missing on clause",
-			stmt: "CREATE INDEX idx_invalid device_id;",
-			want: "",
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gonglijing/xunjiFsu/migrations"
+)
+
+// prepareParamSchemaTestDB 以临时文件初始化 param.db 及其 schema，测试结束后恢复原连接
+func prepareParamSchemaTestDB(t *testing.T) {
+	t.Helper()
+	oldParamDB, oldParamDBFile := ParamDB, paramDBFile
+	if err := InitParamDBWithPath(filepath.Join(t.TempDir(), "param.db")); err != nil {
+		t.Fatalf("InitParamDBWithPath: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = ParamDB.Close()
+		ParamDB, paramDBFile = oldParamDB, oldParamDBFile
+	})
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
+}
+
+func TestLoadSchemaMigrations_EmbeddedSetsAreContiguous(t *testing.T) {
+	for _, dir := range []string{migrationSetParam, migrationSetData, migrationSetDataDisk} {
+		list, err := loadSchemaMigrations(migrations.FS, dir)
+		if err != nil {
+			t.Fatalf("%s: %v", dir, err)
+		}
+		if len(list) == 0 {
+			t.Fatalf("%s: no migrations embedded", dir)
+		}
+	}
+}
+
+func TestLoadSchemaMigrations_RejectsBadLayout(t *testing.T) {
+	for name, files := range map[string]fstest.MapFS{
+		"gap":       {"m/0001_a.sql": {}, "m/0003_c.sql": {}},
+		"duplicate": {"m/0001_a.sql": {}, "m/001_b.sql": {}},
+		"bad name":  {"m/0001_a.sql": {}, "m/readme.md": {}},
+	} {
+		if _, err := loadSchemaMigrations(files, "m"); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestApplySchemaMigrations_TransactionalAndVersioned(t *testing.T) {
+	db, err := openSQLite(":memory:", 1, 1)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	list := []schemaMigration{
+		{version: 1, name: "0001_a.sql", sql: `CREATE TABLE a (id INTEGER PRIMARY KEY);`},
+		{version: 2, name: "0002_b.sql", sql: `CREATE TABLE b (id INTEGER PRIMARY KEY); INSERT INTO missing VALUES (1);`},
+	}
+	applied, err := applySchemaMigrations(db, "test", list)
+	if err == nil || applied != 1 {
+		t.Fatalf("applied=%d err=%v, want failure after version 1", applied, err)
+	}
+	if version, _ := schemaVersion(db); version != 1 {
+		t.Fatalf("version=%d, want 1", version)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'b'`).Scan(&count); err != nil || count != 0 {
+		t.Fatalf("failed migration must roll back, table b count=%d err=%v", count, err)
+	}
+
+	list[1].sql = `CREATE TABLE b (id INTEGER PRIMARY KEY);`
+	if applied, err := applySchemaMigrations(db, "test", list); err != nil || applied != 1 {
+		t.Fatalf("retry applied=%d err=%v", applied, err)
+	}
+	if applied, err := applySchemaMigrations(db, "test", list); err != nil || applied != 0 {
+		t.Fatalf("rerun applied=%d err=%v, want no-op", applied, err)
+	}
+
+	// 旧程序打开新版本库：拒绝运行
+	if _, err := applySchemaMigrations(db, "test", list[:1]); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("err=%v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestEnsureDataDiskFileSchema_VersionsDiskFile(t *testing.T) {
+	diskDB := prepareRollupDiskDB(t)
+
+	if err := ensureDataDiskFileSchema(); err != nil {
+		t.Fatalf("ensureDataDiskFileSchema() error = %v", err)
+	}
+	version, err := schemaVersion(diskDB)
+	if err != nil || version == 0 {
+		t.Fatalf("disk schema version=%d err=%v", version, err)
+	}
+
+	if _, err := diskDB.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, 'future.sql')`, version+100); err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+	if err := ensureDataDiskFileSchema(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("err=%v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestApplySchemaMigrations_AddsMissingLegacyColumns(t *testing.T) {
+	db, err := openSQLite(":memory:", 1, 1)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE t (id INTEGER PRIMARY KEY, kept TEXT)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO t (kept) VALUES ('x')`); err != nil {
+		t.Fatalf("insert row: %v", err)
+	}
+
+	list := []schemaMigration{{
+		version: 1,
+		name:    "0001_columns.sql",
+		sql:     `UPDATE t SET added = added + 1; CREATE TABLE IF NOT EXISTS created (id INTEGER PRIMARY KEY);`,
+		columns: []schemaColumn{
+			{"t", "kept", "TEXT"},
+			{"t", "added", "INTEGER DEFAULT 0"},
+			{"created", "extra", "TEXT"},
 		},
+	}}
+	if _, err := applySchemaMigrations(db, "test", list); err != nil {
+		t.Fatalf("applySchemaMigrations() error = %v", err)
+	}
+	var added int
+	if err := db.QueryRow(`SELECT added FROM t`).Scan(&added); err != nil || added != 1 {
+		t.Fatalf("added=%d err=%v, want 1", added, err)
+	}
+	// 表不存在时不补列，由脚本创建
+	if exists, err := columnExists(db, "created", "extra"); err != nil || exists {
+		t.Fatalf("created.extra exists=%v err=%v, want false", exists, err)
 	}
+}
 
-	for _, testCase := range tests {
-		t.Run(testCase.name, func(t *testing.T) {
-			if got := parseIndexTableName(testCase.stmt); got != testCase.want {
-				t.Fatalf("parseIndexTableName() = %q, want %q", got, testCase.want)
-			}
-		})
+func TestParamMigrations_UpgradeLegacyGatewayConfig(t *testing.T) {
+	setupLegacyThresholdsTestDB(t)
+	if _, err := ParamDB.Exec(`UPDATE gateway_config SET data_retention_days = 0`); err != nil {
+		t.Fatalf("reset retention: %v", err)
+	}
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
+
+	var retention, repeat int
+	if err := ParamDB.QueryRow(`SELECT data_retention_days, alarm_repeat_interval_seconds FROM gateway_config`).Scan(&retention, &repeat); err != nil {
+		t.Fatalf("query gateway config: %v", err)
+	}
+	if retention != DefaultRetentionDays || repeat != DefaultAlarmRepeatIntervalSeconds {
+		t.Fatalf("retention=%d repeat=%d", retention, repeat)
 	}
 }
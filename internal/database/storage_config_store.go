@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DeviceRetentionPolicy 设备级历史保留天数（覆盖网关全局 data_retention_days）
+type DeviceRetentionPolicy struct {
+	ID          int64  `json:"id" db:"id"`
+	DeviceID    int64  `json:"device_id" db:"device_id"`
+	StorageDays int    `json:"storage_days" db:"storage_days"`
+	Enabled     int    `json:"enabled" db:"enabled"`
+	CreatedAt   string `json:"created_at" db:"created_at"`
+	UpdatedAt   string `json:"updated_at" db:"updated_at"`
+}
+
+const selectStorageConfigFields = `SELECT id, device_id, storage_days, COALESCE(enabled, 1),
+	COALESCE(created_at, ''), COALESCE(updated_at, '') FROM storage_config`
+
+// ListDeviceRetentionPolicies 列出全部设备保留策略
+func ListDeviceRetentionPolicies() ([]*DeviceRetentionPolicy, error) {
+	rows, err := ParamDB.Query(selectStorageConfigFields + " ORDER BY device_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []*DeviceRetentionPolicy
+	for rows.Next() {
+		policy := &DeviceRetentionPolicy{}
+		if err := rows.Scan(&policy.ID, &policy.DeviceID, &policy.StorageDays, &policy.Enabled, &policy.CreatedAt, &policy.UpdatedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// GetDeviceRetentionPolicy 获取设备保留策略，不存在时返回 sql.ErrNoRows
+func GetDeviceRetentionPolicy(deviceID int64) (*DeviceRetentionPolicy, error) {
+	policy := &DeviceRetentionPolicy{}
+	err := ParamDB.QueryRow(selectStorageConfigFields+" WHERE device_id = ?", deviceID).
+		Scan(&policy.ID, &policy.DeviceID, &policy.StorageDays, &policy.Enabled, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// UpsertDeviceRetentionPolicy 新增或更新设备保留策略
+func UpsertDeviceRetentionPolicy(policy *DeviceRetentionPolicy) error {
+	return upsertDeviceRetentionPolicy(ParamDB, policy)
+}
+
+// UpsertDeviceRetentionPolicy 在事务内新增或更新设备保留策略
+func (tx *ParamTx) UpsertDeviceRetentionPolicy(policy *DeviceRetentionPolicy) error {
+	return upsertDeviceRetentionPolicy(tx.tx, policy)
+}
+
+func upsertDeviceRetentionPolicy(db paramExecer, policy *DeviceRetentionPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.StorageDays <= 0 {
+		return fmt.Errorf("storage_days must be positive")
+	}
+	_, err := db.Exec(`INSERT INTO storage_config (device_id, storage_days, enabled) VALUES (?, ?, ?)
+		ON CONFLICT(device_id) DO UPDATE SET storage_days = excluded.storage_days, enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP`,
+		policy.DeviceID, policy.StorageDays, policy.Enabled)
+	return err
+}
+
+// DeleteDeviceRetentionPolicy 删除设备保留策略，设备回退到网关全局保留天数
+func DeleteDeviceRetentionPolicy(deviceID int64) error {
+	return deleteDeviceRetentionPolicy(ParamDB, deviceID)
+}
+
+// DeleteDeviceRetentionPolicy 在事务内删除设备保留策略
+func (tx *ParamTx) DeleteDeviceRetentionPolicy(deviceID int64) error {
+	return deleteDeviceRetentionPolicy(tx.tx, deviceID)
+}
+
+func deleteDeviceRetentionPolicy(db paramExecer, deviceID int64) error {
+	result, err := db.Exec(`DELETE FROM storage_config WHERE device_id = ?`, deviceID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// enabledRetentionOverrides 返回启用中的设备保留天数覆盖
+func enabledRetentionOverrides() (map[int64]int, error) {
+	if ParamDB == nil {
+		return nil, nil
+	}
+	policies, err := ListDeviceRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[int64]int, len(policies))
+	for _, policy := range policies {
+		if policy.Enabled == 0 || policy.StorageDays <= 0 {
+			continue
+		}
+		overrides[policy.DeviceID] = policy.StorageDays
+	}
+	return overrides, nil
+}
@@ -3,7 +3,6 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"sync"
 
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
@@ -11,105 +10,11 @@ import (
 // ==================== 阈值操作 (param.db - 直接写) ====================
 
 const DefaultAlarmRepeatIntervalSeconds = 60
-const selectThresholdFields = `SELECT id, device_id, field_name, operator, value, severity, COALESCE(shielded, 0), message, created_at, updated_at FROM thresholds`
-
-var thresholdColumnsEnsureState struct {
-	mu        sync.Mutex
-	ensuredDB *sql.DB
-}
-
-var gatewayAlarmRepeatEnsureState struct {
-	mu        sync.Mutex
-	ensuredDB *sql.DB
-}
-
-func ensureThresholdColumns() error {
-	db := ParamDB
-	if db == nil {
-		return fmt.Errorf("param db is nil")
-	}
-
-	thresholdColumnsEnsureState.mu.Lock()
-	defer thresholdColumnsEnsureState.mu.Unlock()
-	if thresholdColumnsEnsureState.ensuredDB == db {
-		return nil
-	}
-
-	hasShielded, err := columnExists(db, "thresholds", "shielded")
-	if err != nil {
-		return err
-	}
-	if !hasShielded {
-		if _, err := db.Exec("ALTER TABLE thresholds ADD COLUMN shielded INTEGER DEFAULT 0"); err != nil {
-			return err
-		}
-	}
-
-	if _, err := db.Exec("UPDATE thresholds SET shielded = 0 WHERE shielded IS NULL"); err != nil {
-		return err
-	}
-
-	hasEnabled, err := columnExists(db, "thresholds", "enabled")
-	if err != nil {
-		return err
-	}
-	if hasEnabled {
-		if _, err := db.Exec("DROP INDEX IF EXISTS idx_thresholds_device_enabled"); err != nil {
-			return err
-		}
-		if _, err := db.Exec("ALTER TABLE thresholds DROP COLUMN enabled"); err != nil {
-			return err
-		}
-	}
-
-	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_thresholds_device ON thresholds(device_id)"); err != nil {
-		return err
-	}
-
-	thresholdColumnsEnsureState.ensuredDB = db
-	return nil
-}
-
-func ensureGatewayAlarmRepeatIntervalColumn() error {
-	db := ParamDB
-	if db == nil {
-		return fmt.Errorf("param db is nil")
-	}
-
-	gatewayAlarmRepeatEnsureState.mu.Lock()
-	defer gatewayAlarmRepeatEnsureState.mu.Unlock()
-	if gatewayAlarmRepeatEnsureState.ensuredDB == db {
-		return nil
-	}
-
-	if err := InitGatewayConfigTable(); err != nil {
-		return err
-	}
-
-	hasColumn, err := columnExists(db, "gateway_config", "alarm_repeat_interval_seconds")
-	if err != nil {
-		return err
-	}
-	if !hasColumn {
-		if _, err := db.Exec("ALTER TABLE gateway_config ADD COLUMN alarm_repeat_interval_seconds INTEGER DEFAULT 60"); err != nil {
-			return err
-		}
-	}
-
-	if _, err := db.Exec("UPDATE gateway_config SET alarm_repeat_interval_seconds = ? WHERE alarm_repeat_interval_seconds IS NULL OR alarm_repeat_interval_seconds <= 0", DefaultAlarmRepeatIntervalSeconds); err != nil {
-		return err
-	}
-
-	gatewayAlarmRepeatEnsureState.ensuredDB = db
-	return nil
-}
+const selectThresholdFields = `SELECT id, device_id, field_name, operator, value, severity, COALESCE(shielded, 0), message, COALESCE(expression, ''),
+	COALESCE(deadband, 0), COALESCE(trigger_delay_seconds, 0), COALESCE(clear_delay_seconds, 0), created_at, updated_at FROM thresholds`
 
 // GetAlarmRepeatIntervalSeconds 获取全局报警重复上报间隔（秒）
 func GetAlarmRepeatIntervalSeconds() (int, error) {
-	if err := ensureGatewayAlarmRepeatIntervalColumn(); err != nil {
-		return DefaultAlarmRepeatIntervalSeconds, err
-	}
-
 	var seconds int
 	err := ParamDB.QueryRow(
 		"SELECT COALESCE(alarm_repeat_interval_seconds, ?) FROM gateway_config ORDER BY id LIMIT 1",
@@ -126,19 +31,24 @@ func GetAlarmRepeatIntervalSeconds() (int, error) {
 
 // UpdateAlarmRepeatIntervalSeconds 更新全局报警重复上报间隔（秒）
 func UpdateAlarmRepeatIntervalSeconds(seconds int) error {
+	return updateAlarmRepeatIntervalSeconds(ParamDB, seconds)
+}
+
+// UpdateAlarmRepeatIntervalSeconds 在事务内更新全局报警重复上报间隔（秒）
+func (tx *ParamTx) UpdateAlarmRepeatIntervalSeconds(seconds int) error {
+	return updateAlarmRepeatIntervalSeconds(tx.tx, seconds)
+}
+
+func updateAlarmRepeatIntervalSeconds(db paramExecer, seconds int) error {
 	if seconds <= 0 {
 		return fmt.Errorf("alarm repeat interval must be > 0")
 	}
-	if err := ensureGatewayAlarmRepeatIntervalColumn(); err != nil {
-		return err
-	}
-
-	cfg, err := GetGatewayConfig()
+	cfg, err := getGatewayConfig(db)
 	if err != nil {
 		return err
 	}
 
-	_, err = ParamDB.Exec(
+	_, err = db.Exec(
 		"UPDATE gateway_config SET alarm_repeat_interval_seconds = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
 		seconds, cfg.ID,
 	)
@@ -147,13 +57,20 @@ func UpdateAlarmRepeatIntervalSeconds(seconds int) error {
 
 // CreateThreshold 创建阈值
 func CreateThreshold(threshold *models.Threshold) (int64, error) {
-	if err := ensureThresholdColumns(); err != nil {
-		return 0, err
-	}
+	return createThreshold(ParamDB, threshold)
+}
 
-	result, err := ParamDB.Exec(
-		"INSERT INTO thresholds (device_id, field_name, operator, value, severity, shielded, message) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		threshold.DeviceID, threshold.FieldName, threshold.Operator, threshold.Value, threshold.Severity, threshold.Shielded, threshold.Message,
+// CreateThreshold 在事务内创建阈值
+func (tx *ParamTx) CreateThreshold(threshold *models.Threshold) (int64, error) {
+	return createThreshold(tx.tx, threshold)
+}
+
+func createThreshold(db paramExecer, threshold *models.Threshold) (int64, error) {
+	result, err := db.Exec(
+		`INSERT INTO thresholds (device_id, field_name, operator, value, severity, shielded, message, expression,
+			deadband, trigger_delay_seconds, clear_delay_seconds) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		threshold.DeviceID, threshold.FieldName, threshold.Operator, threshold.Value, threshold.Severity, threshold.Shielded, threshold.Message, threshold.Expression,
+		threshold.Deadband, threshold.TriggerDelay, threshold.ClearDelay,
 	)
 	if err != nil {
 		return 0, err
@@ -163,61 +80,67 @@ func CreateThreshold(threshold *models.Threshold) (int64, error) {
 
 // LoadThreshold 根据ID获取阈值
 func LoadThreshold(id int64) (*models.Threshold, error) {
-	if err := ensureThresholdColumns(); err != nil {
-		return nil, err
-	}
 
-	return loadThreshold(selectThresholdFields+" WHERE id = ?", id)
+	return loadThreshold(ParamDB, selectThresholdFields+" WHERE id = ?", id)
+}
+
+// LoadThreshold 在事务内根据ID获取阈值
+func (tx *ParamTx) LoadThreshold(id int64) (*models.Threshold, error) {
+	return loadThreshold(tx.tx, selectThresholdFields+" WHERE id = ?", id)
 }
 
 // ListThresholdsByDevice 根据设备ID获取阈值
 func ListThresholdsByDevice(deviceID int64) ([]*models.Threshold, error) {
-	if err := ensureThresholdColumns(); err != nil {
-		return nil, err
-	}
 
 	return listThresholds(selectThresholdFields+" WHERE device_id = ?", []any{deviceID})
 }
 
 // ListThresholds 获取所有阈值
 func ListThresholds() ([]*models.Threshold, error) {
-	if err := ensureThresholdColumns(); err != nil {
-		return nil, err
-	}
 
 	return listThresholds(selectThresholdFields+" ORDER BY id", nil)
 }
 
 // UpdateThreshold 更新阈值
 func UpdateThreshold(threshold *models.Threshold) error {
-	if err := ensureThresholdColumns(); err != nil {
-		return err
-	}
+	return updateThreshold(ParamDB, threshold)
+}
+
+// UpdateThreshold 在事务内更新阈值
+func (tx *ParamTx) UpdateThreshold(threshold *models.Threshold) error {
+	return updateThreshold(tx.tx, threshold)
+}
 
-	_, err := ParamDB.Exec(
-		"UPDATE thresholds SET device_id = ?, field_name = ?, operator = ?, value = ?, severity = ?, shielded = ?, message = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
-		threshold.DeviceID, threshold.FieldName, threshold.Operator, threshold.Value, threshold.Severity, threshold.Shielded, threshold.Message, threshold.ID,
+func updateThreshold(db paramExecer, threshold *models.Threshold) error {
+	_, err := db.Exec(
+		`UPDATE thresholds SET device_id = ?, field_name = ?, operator = ?, value = ?, severity = ?, shielded = ?, message = ?, expression = ?,
+			deadband = ?, trigger_delay_seconds = ?, clear_delay_seconds = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		threshold.DeviceID, threshold.FieldName, threshold.Operator, threshold.Value, threshold.Severity, threshold.Shielded, threshold.Message, threshold.Expression,
+		threshold.Deadband, threshold.TriggerDelay, threshold.ClearDelay, threshold.ID,
 	)
 	return err
 }
 
 // DeleteThreshold 删除阈值
 func DeleteThreshold(id int64) error {
-	if err := ensureThresholdColumns(); err != nil {
-		return err
-	}
 
 	_, err := ParamDB.Exec("DELETE FROM thresholds WHERE id = ?", id)
 	return err
 }
 
+// DeleteThreshold 在事务内删除阈值
+func (tx *ParamTx) DeleteThreshold(id int64) error {
+	_, err := tx.tx.Exec("DELETE FROM thresholds WHERE id = ?", id)
+	return err
+}
+
 type thresholdScanner interface {
 	Scan(dest ...any) error
 }
 
-func loadThreshold(query string, args ...any) (*models.Threshold, error) {
+func loadThreshold(db paramExecer, query string, args ...any) (*models.Threshold, error) {
 	threshold := &models.Threshold{}
-	err := scanThreshold(ParamDB.QueryRow(query, args...), threshold)
+	err := scanThreshold(db.QueryRow(query, args...), threshold)
 	if err != nil {
 		return nil, err
 	}
@@ -244,6 +167,10 @@ func scanThreshold(scanner thresholdScanner, threshold *models.Threshold) error
 		&threshold.Severity,
 		&threshold.Shielded,
 		&threshold.Message,
+		&threshold.Expression,
+		&threshold.Deadband,
+		&threshold.TriggerDelay,
+		&threshold.ClearDelay,
 		&threshold.CreatedAt,
 		&threshold.UpdatedAt,
 	)
@@ -9,23 +9,20 @@ import (
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
+// setupThresholdsTestDB 旧版阈值库执行 param 迁移后的状态
 func setupThresholdsTestDB(t *testing.T) {
 	t.Helper()
-	thresholdColumnsEnsureState.mu.Lock()
-	thresholdColumnsEnsureState.ensuredDB = nil
-	thresholdColumnsEnsureState.mu.Unlock()
-	gatewayAlarmRepeatEnsureState.mu.Lock()
-	gatewayAlarmRepeatEnsureState.ensuredDB = nil
-	gatewayAlarmRepeatEnsureState.mu.Unlock()
+	setupLegacyThresholdsTestDB(t)
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
+}
 
+// setupLegacyThresholdsTestDB 版本化之前的阈值表（含 enabled 列）与网关配置
+func setupLegacyThresholdsTestDB(t *testing.T) {
+	t.Helper()
 	originalParamDB := ParamDB
 	t.Cleanup(func() {
-		thresholdColumnsEnsureState.mu.Lock()
-		thresholdColumnsEnsureState.ensuredDB = nil
-		thresholdColumnsEnsureState.mu.Unlock()
-		gatewayAlarmRepeatEnsureState.mu.Lock()
-		gatewayAlarmRepeatEnsureState.ensuredDB = nil
-		gatewayAlarmRepeatEnsureState.mu.Unlock()
 		if ParamDB != nil {
 			_ = ParamDB.Close()
 		}
@@ -76,54 +73,33 @@ func setupThresholdsTestDB(t *testing.T) {
 	}
 }
 
-func TestEnsureThresholdColumns_RecheckAfterDBSwitch(t *testing.T) {
-	setupThresholdsTestDB(t)
-
-	if err := ensureThresholdColumns(); err != nil {
-		t.Fatalf("ensureThresholdColumns first call: %v", err)
+func TestParamMigrations_UpgradeLegacyThresholds(t *testing.T) {
+	setupLegacyThresholdsTestDB(t)
+	if _, err := ParamDB.Exec(`CREATE INDEX idx_thresholds_device_enabled ON thresholds(device_id, enabled)`); err != nil {
+		t.Fatalf("create legacy index: %v", err)
 	}
-
-	if err := ensureThresholdColumns(); err != nil {
-		t.Fatalf("ensureThresholdColumns second call(idempotent): %v", err)
+	if _, err := ParamDB.Exec(`INSERT INTO thresholds (device_id, field_name, operator, value, enabled, message) VALUES (1, 'Ua', '<', 180, 0, 'low')`); err != nil {
+		t.Fatalf("insert legacy threshold: %v", err)
 	}
 
-	tmpDir := t.TempDir()
-	newDB, err := openSQLite(filepath.Join(tmpDir, "param_new.db"), 1, 1)
-	if err != nil {
-		t.Fatalf("open new param db: %v", err)
-	}
-	defer func() { _ = newDB.Close() }()
-
-	_, err = newDB.Exec(`CREATE TABLE thresholds (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		device_id INTEGER NOT NULL,
-		field_name TEXT NOT NULL,
-		operator TEXT NOT NULL,
-		value REAL NOT NULL,
-		severity TEXT DEFAULT 'warning',
-		enabled INTEGER DEFAULT 1,
-		message TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	)`)
-	if err != nil {
-		t.Fatalf("create thresholds in new db: %v", err)
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
 	}
 
-	original := ParamDB
-	ParamDB = newDB
-	defer func() { ParamDB = original }()
-
-	if err := ensureThresholdColumns(); err != nil {
-		t.Fatalf("ensureThresholdColumns after db switch: %v", err)
+	for column, want := range map[string]bool{
+		"enabled": false, "shielded": true, "expression": true,
+		"deadband": true, "trigger_delay_seconds": true, "clear_delay_seconds": true,
+	} {
+		if exists, err := columnExists(ParamDB, "thresholds", column); err != nil || exists != want {
+			t.Fatalf("thresholds.%s exists=%v err=%v, want %v", column, exists, err, want)
+		}
 	}
-
-	hasEnabled, err := columnExists(ParamDB, "thresholds", "enabled")
+	threshold, err := LoadThreshold(1)
 	if err != nil {
-		t.Fatalf("columnExists enabled after switch: %v", err)
+		t.Fatalf("LoadThreshold: %v", err)
 	}
-	if hasEnabled {
-		t.Fatalf("enabled column should be dropped after db switch")
+	if threshold.Shielded != 0 || threshold.Message != "low" || threshold.Operator != "<" {
+		t.Fatalf("legacy threshold not preserved: %+v", threshold)
 	}
 }
 
@@ -168,6 +144,57 @@ func TestThresholdCRUD_WithShieldedColumn(t *testing.T) {
 	}
 }
 
+func TestParamMigrations_RebuildThresholdOperatorCheckForExpr(t *testing.T) {
+	setupLegacyThresholdsTestDB(t)
+
+	if _, err := ParamDB.Exec(`DROP TABLE thresholds`); err != nil {
+		t.Fatalf("drop thresholds: %v", err)
+	}
+	_, err := ParamDB.Exec(`CREATE TABLE thresholds (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		device_id INTEGER NOT NULL,
+		field_name TEXT NOT NULL,
+		operator TEXT NOT NULL CHECK(operator IN ('>', '<', '>=', '<=', '==', '!=')),
+		value REAL NOT NULL,
+		severity TEXT DEFAULT 'warning',
+		shielded INTEGER DEFAULT 0,
+		message TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("create legacy thresholds: %v", err)
+	}
+	if _, err := ParamDB.Exec(`INSERT INTO thresholds (device_id, field_name, operator, value, message) VALUES (1, 'Ua', '<', 180, 'low')`); err != nil {
+		t.Fatalf("insert legacy threshold: %v", err)
+	}
+	if err := InitParamSchema(); err != nil {
+		t.Fatalf("InitParamSchema: %v", err)
+	}
+
+	id, err := CreateThreshold(&models.Threshold{
+		DeviceID:   1,
+		FieldName:  "Ua",
+		Operator:   "expr",
+		Severity:   "critical",
+		Expression: "Ua < 180 && Ia > 5",
+	})
+	if err != nil {
+		t.Fatalf("CreateThreshold(expr): %v", err)
+	}
+
+	all, err := ListThresholds()
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListThresholds err=%v len=%d", err, len(all))
+	}
+	if all[0].Operator != "<" || all[0].Message != "low" {
+		t.Fatalf("legacy threshold not preserved: %+v", all[0])
+	}
+	if all[1].ID != id || all[1].Expression != "Ua < 180 && Ia > 5" {
+		t.Fatalf("unexpected expression threshold: %+v", all[1])
+	}
+}
+
 func TestAlarmRepeatIntervalSettings(t *testing.T) {
 	setupThresholdsTestDB(t)
 
@@ -228,7 +255,7 @@ func TestScanThreshold(t *testing.T) {
 	threshold := &models.Threshold{}
 	scanner := stubThresholdScanner{
 		values: []any{
-			int64(1), int64(2), "temperature", ">", 40.5, "warning", 1, "too hot", now, now,
+			int64(1), int64(2), "temperature", ">", 40.5, "warning", 1, "too hot", "", 0.5, 10, 30, now, now,
 		},
 	}
 
@@ -242,6 +269,9 @@ func TestScanThreshold(t *testing.T) {
 	if threshold.Value != 40.5 || threshold.Shielded != 1 || threshold.Message != "too hot" {
 		t.Fatalf("unexpected threshold values: %+v", threshold)
 	}
+	if threshold.Deadband != 0.5 || threshold.TriggerDelay != 10 || threshold.ClearDelay != 30 {
+		t.Fatalf("unexpected threshold lifecycle values: %+v", threshold)
+	}
 }
 
 func TestScanThreshold_Error(t *testing.T) {
@@ -0,0 +1,126 @@
+package driver
+
+// 测试驱动夹具，由 wasm_builder_test.go 在测试中生成
+
+// minimalDriverWasm 仅导出 handle() -> i32（返回 0、无输出）的最小驱动
+func minimalDriverWasm() []byte {
+	m := newWasmModule(1)
+	m.export("handle", m.function(nil, []byte{wasmI32}, nil, i32Const(0)))
+	return m.bytes()
+}
+
+// ioReadRegisterRequest 为 ioDriverWasm 发出的 Modbus RTU 读保持寄存器 0 请求
+var ioReadRegisterRequest = HexFrame{0x01, 0x03, 0x00, 0x00, 0x00, 0x01, 0x84, 0x0A}
+
+// ioDriverWasm 覆盖全部总线宿主函数的测试驱动：
+//   - handle: serial_transceive 发送 ioReadRegisterRequest，收到 7 字节应答后输出 {"v":"N"}（N 为寄存器低字节）
+//   - poll: 同样的请求改用 serial_write + serial_read
+//   - stream: tcp_write "PING\r\n"，tcp_read_until "\r\n"，udp_send "HI"（发往资源路径），udp_recv，
+//     输出 {"line":"<应答行首字符>","udp":"<数据报首字符>"}
+//
+// 返回码 1-4 表示对应步骤未收到数据
+func ioDriverWasm() []byte {
+	m := newWasmModule(1)
+	env := importExtismEnv(m)
+	const user = "extism:host/user"
+	i64x := func(n int) []byte {
+		types := make([]byte, n)
+		for i := range types {
+			types[i] = wasmI64
+		}
+		return types
+	}
+	serialTransceive := m.importFunc(user, "serial_transceive", i64x(5), i64x(1))
+	serialWrite := m.importFunc(user, "serial_write", i64x(2), i64x(1))
+	serialRead := m.importFunc(user, "serial_read", i64x(2), i64x(1))
+	tcpWrite := m.importFunc(user, "tcp_write", i64x(2), i64x(1))
+	tcpReadUntil := m.importFunc(user, "tcp_read_until", i64x(5), i64x(1))
+	udpSend := m.importFunc(user, "udp_send", i64x(4), i64x(1))
+	udpRecv := m.importFunc(user, "udp_recv", i64x(3), i64x(1))
+	copyFn := addCopyFunc(m, env)
+
+	m.dataAt(0, ioReadRegisterRequest)
+	registerLen, registerSlots := jsonOutputTemplate(m, 16, `{"success":true,"data":{"v":"#"}}`)
+	m.dataAt(64, []byte("PING\r\n"))
+	m.dataAt(72, []byte("\r\n"))
+	m.dataAt(80, []byte("HI"))
+	streamLen, streamSlots := jsonOutputTemplate(m, 96, `{"success":true,"data":{"line":"#","udp":"#"}}`)
+
+	const rbuf, n = 0, 1
+	locals := []byte{wasmI64, wasmI64}
+	result := []byte{wasmI32}
+	alloc := func(size int64) []byte { return seq(i64Const(size), call(env.alloc), localSet(rbuf)) }
+	// 寄存器低字节（应答第 5 字节）转为数字字符写入输出模板
+	registerOutput := seq(
+		localGet(n), i64Const(7), ops(opI64Ne), returnIf(1),
+		i32Const(registerSlots[0]),
+		localGet(rbuf), i64Const(4), ops(opI64Add), call(env.loadU8), i32Const('0'), ops(opI32Add),
+		i32Store8(),
+		setOutput(env, copyFn, 16, registerLen),
+		i32Const(0),
+	)
+
+	handle := m.function(nil, result, locals,
+		alloc(7),
+		i32Const(0), i32Const(8), call(copyFn), i64Const(8), localGet(rbuf), i64Const(7), i64Const(100),
+		call(serialTransceive), localSet(n),
+		registerOutput,
+	)
+	poll := m.function(nil, result, locals,
+		alloc(7),
+		i32Const(0), i32Const(8), call(copyFn), i64Const(8), call(serialWrite), ops(opDrop),
+		localGet(rbuf), i64Const(7), call(serialRead), localSet(n),
+		registerOutput,
+	)
+	stream := m.function(nil, result, locals,
+		alloc(32),
+		i32Const(64), i32Const(6), call(copyFn), i64Const(6), call(tcpWrite), ops(opI64Eqz), returnIf(1),
+		localGet(rbuf), i64Const(32), i32Const(72), i32Const(2), call(copyFn), i64Const(2), i64Const(100),
+		call(tcpReadUntil), ops(opI64Eqz), returnIf(2),
+		i32Const(streamSlots[0]), localGet(rbuf), call(env.loadU8), i32Store8(),
+		i64Const(0), i64Const(0), i32Const(80), i32Const(2), call(copyFn), i64Const(2),
+		call(udpSend), ops(opI64Eqz), returnIf(3),
+		localGet(rbuf), i64Const(32), i64Const(100), call(udpRecv), ops(opI64Eqz), returnIf(4),
+		i32Const(streamSlots[1]), localGet(rbuf), call(env.loadU8), i32Store8(),
+		setOutput(env, copyFn, 96, streamLen),
+		i32Const(0),
+	)
+	m.export("handle", handle)
+	m.export("poll", poll)
+	m.export("stream", stream)
+	return m.bytes()
+}
+
+// crashDriverErrorMessage crashDriverWasm 的 fail 通过 error_set 报告的错误
+const crashDriverErrorMessage = "device busy"
+
+// crashDriverWasm 沙箱测试驱动：handle 返回 0（无输出）、trap 执行 unreachable、
+// spin 循环调用空函数、grow 申请 100 页内存（失败时 unreachable）、fail 以 error_set 报告错误并返回 1
+func crashDriverWasm() []byte {
+	m := newWasmModule(1)
+	env := importExtismEnv(m)
+	copyFn := addCopyFunc(m, env)
+	m.dataAt(0, []byte(crashDriverErrorMessage))
+	result := []byte{wasmI32}
+	handle := m.function(nil, result, nil, i32Const(0))
+	trap := m.function(nil, result, nil, ops(opUnreachable))
+	nop := m.function(nil, nil, nil)
+	spin := m.function(nil, result, nil,
+		ops(opLoop, blockTypeEmpty), call(nop), br(0), ops(opEnd),
+		i32Const(0),
+	)
+	grow := m.function(nil, result, nil,
+		i32Const(100), memoryGrow(), i32Const(-1), ops(opI32Eq), ops(opIf, blockTypeEmpty, opUnreachable, opEnd),
+		i32Const(0),
+	)
+	m.export("handle", handle)
+	m.export("trap", trap)
+	m.export("spin", spin)
+	fail := m.function(nil, result, nil,
+		i32Const(0), i32Const(int32(len(crashDriverErrorMessage))), call(copyFn), call(env.errorSet),
+		i32Const(1),
+	)
+	m.export("grow", grow)
+	m.export("fail", fail)
+	return m.bytes()
+}
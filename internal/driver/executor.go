@@ -19,8 +19,11 @@ type DriverExecutor struct {
 	manager                   *DriverManager
 	serialPorts               map[int64]SerialPort // 资源ID到串口的映射
 	tcpConns                  map[int64]net.Conn   // 资源ID到TCP连接
-	resourcePaths             map[int64]string     // 资源ID到路径的映射 (用于TCP懒连接)
-	resourceMux               sync.Map             // key:int64 -> *sync.Mutex, 同一资源串口的互斥锁
+	tcpReaders                map[int64]*tcpStreamReader
+	udpConns                  map[int64]udpSocket
+	recorders                 map[int64]*TranscriptRecorder // 资源ID到收发记录器（驱动测试录制）
+	resourcePaths             map[int64]string              // 资源ID到路径的映射 (用于TCP懒连接)
+	resourceMux               sync.Map                      // key:int64 -> *sync.Mutex, 同一资源串口的互斥锁
 	mu                        sync.RWMutex
 	executing                 map[int64]bool
 	serialTimeout             time.Duration
@@ -39,6 +42,9 @@ func NewDriverExecutor(manager *DriverManager) *DriverExecutor {
 		manager:       manager,
 		serialPorts:   make(map[int64]SerialPort),
 		tcpConns:      make(map[int64]net.Conn),
+		tcpReaders:    make(map[int64]*tcpStreamReader),
+		udpConns:      make(map[int64]udpSocket),
+		recorders:     make(map[int64]*TranscriptRecorder),
 		resourcePaths: make(map[int64]string),
 		executing:     make(map[int64]bool),
 	}
@@ -184,6 +190,25 @@ func (e *DriverExecutor) UnregisterTCP(resourceID int64) {
 		_ = c.Close()
 		delete(e.tcpConns, resourceID)
 	}
+	delete(e.tcpReaders, resourceID)
+}
+
+// SetTrafficRecorder 为资源挂载收发记录器，记录串口、TCP 与 UDP 宿主函数的真实收发；nil 取消记录
+func (e *DriverExecutor) SetTrafficRecorder(resourceID int64, recorder *TranscriptRecorder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if recorder == nil {
+		delete(e.recorders, resourceID)
+		return
+	}
+	e.recorders[resourceID] = recorder
+}
+
+// trafficRecorder 返回资源的收发记录器，未挂载时为 nil（记录方法对 nil 无操作）
+func (e *DriverExecutor) trafficRecorder(resourceID int64) *TranscriptRecorder {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.recorders[resourceID]
 }
 
 // GetSerialPort 获取串口
@@ -217,9 +242,13 @@ func (e *DriverExecutor) GetTCPConn(resourceID int64) net.Conn {
 	if unlock != nil {
 		defer unlock()
 	}
+	return e.connectTCPResource(resourceID, path)
+}
 
+// connectTCPResource 建立（或复用）资源对应的 TCP 连接，调用方需持有资源锁
+func (e *DriverExecutor) connectTCPResource(resourceID int64, path string) net.Conn {
 	e.mu.RLock()
-	conn, exists = e.tcpConns[resourceID]
+	conn, exists := e.tcpConns[resourceID]
 	e.mu.RUnlock()
 	if exists && conn != nil {
 		return conn
@@ -339,9 +368,18 @@ func (e *DriverExecutor) CloseResource(resourceID int64) {
 		_ = conn.Close()
 		delete(e.tcpConns, resourceID)
 	}
+	delete(e.tcpReaders, resourceID)
+	if conn, ok := e.udpConns[resourceID]; ok {
+		_ = conn.Close()
+		delete(e.udpConns, resourceID)
+	}
 	delete(e.resourcePaths, resourceID)
 	e.resourceMux.Delete(resourceID)
 	e.mu.Unlock()
+
+	if e.manager != nil {
+		e.manager.releaseResourceInstances(resourceID)
+	}
 }
 
 // CloseAllResources 关闭所有资源连接
@@ -355,6 +393,11 @@ func (e *DriverExecutor) CloseAllResources() {
 		_ = conn.Close()
 		delete(e.tcpConns, id)
 	}
+	for id, conn := range e.udpConns {
+		_ = conn.Close()
+		delete(e.udpConns, id)
+	}
+	e.tcpReaders = make(map[int64]*tcpStreamReader)
 	e.resourcePaths = make(map[int64]string)
 	e.resourceMux.Range(func(key, _ any) bool {
 		e.resourceMux.Delete(key)
@@ -379,4 +422,3 @@ func (e *DriverExecutor) getResourceLock(resourceID int64) *sync.Mutex {
 	actual, _ := e.resourceMux.LoadOrStore(resourceID, lock)
 	return actual.(*sync.Mutex)
 }
-
@@ -45,6 +45,9 @@ func (e *DriverExecutor) executeWithContextAndConfig(ctx context.Context, device
 }
 
 func (e *DriverExecutor) executePreparedWithContextAndConfig(ctx context.Context, device *models.Device, function string, prepared *PreparedExecution, overrides map[string]string) (*DriverResult, error) {
+	if IsNativeModbusDevice(device) {
+		return e.executeNativeModbus(ctx, device, prepared, overrides)
+	}
 	if device.DriverID == nil {
 		return nil, fmt.Errorf("device %s has no driver", device.Name)
 	}
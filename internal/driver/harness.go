@@ -0,0 +1,120 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// 驱动测试环境中的固定标识
+const (
+	harnessDriverID   = 1
+	harnessResourceID = 1
+	harnessDeviceID   = 1
+)
+
+// ReplayReport 收发记录回放结果，Failures 为空表示通过
+type ReplayReport struct {
+	Result   *DriverResult `json:"result,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Failures []string      `json:"failures,omitempty"`
+}
+
+func (r *ReplayReport) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// ReplayTranscript 在回放总线上执行驱动，校验串口/TCP/UDP 写入序列与期望测点；
+// realtime 为 true 时按记录耗时放出应答，可用于验证驱动的超时设置
+func ReplayTranscript(ctx context.Context, wasmData []byte, tr *Transcript, realtime bool) *ReplayReport {
+	bus := NewReplayBus(tr.Exchanges, realtime)
+	result, err := runDriverHarness(ctx, wasmData, tr, bus, nil)
+
+	report := &ReplayReport{Result: result}
+	report.Failures = append(report.Failures, bus.Failures()...)
+	if remaining := bus.Remaining(); remaining > 0 {
+		report.Failures = append(report.Failures, fmt.Sprintf("%d recorded exchanges not requested", remaining))
+	}
+	if err != nil {
+		report.Error = err.Error()
+		// 期望失败的记录（如设备无应答）只校验帧序列
+		if tr.Expect == nil || tr.Expect.Success == nil || *tr.Expect.Success {
+			report.Failures = append(report.Failures, "driver error: "+err.Error())
+		}
+		return report
+	}
+	report.Failures = append(report.Failures, CheckTranscriptExpect(result, tr.Expect)...)
+	return report
+}
+
+// RecordTranscript 在真实串口/TCP 连接上执行驱动，记录收发帧并以本次结果作为期望；
+// net 资源的 bus 须为 net.Conn，bus 由调用方关闭
+func RecordTranscript(ctx context.Context, wasmData []byte, tr *Transcript, bus SerialPort) (*DriverResult, error) {
+	recorder := NewTranscriptRecorder()
+	result, err := runDriverHarness(ctx, wasmData, tr, bus, recorder)
+
+	recordedAt := time.Now()
+	tr.Version = TranscriptVersion
+	tr.ResourceType = normalizeTranscriptResourceType(tr.ResourceType)
+	tr.RecordedAt = &recordedAt
+	tr.Exchanges = recorder.Exchanges()
+	if err != nil {
+		return result, err
+	}
+	success := result.Success
+	tr.Expect = &TranscriptExpect{Success: &success, Points: maps.Clone(ResultFields(result))}
+	return result, nil
+}
+
+// runDriverHarness 以独立的 DriverManager 加载驱动，将 bus 注册为唯一资源后执行一次调用；
+// bus 同时实现 UDP 收发（回放总线）时一并作为资源的 UDP 套接字。连接断开后不重连，避免回放时访问真实网络
+func runDriverHarness(ctx context.Context, wasmData []byte, tr *Transcript, bus SerialPort, recorder *TranscriptRecorder) (*DriverResult, error) {
+	manager := NewDriverManager()
+	executor := NewDriverExecutor(manager)
+	executor.tcpDialFn = func(network, address string, _ time.Duration) (net.Conn, error) {
+		return nil, fmt.Errorf("driver harness does not dial %s %s", network, address)
+	}
+	executor.SetResourcePath(harnessResourceID, tr.Address)
+
+	resourceType := normalizeTranscriptResourceType(tr.ResourceType)
+	switch resourceType {
+	case "serial":
+		executor.RegisterSerialPort(harnessResourceID, bus)
+	case "net":
+		conn, ok := bus.(net.Conn)
+		if !ok {
+			return nil, fmt.Errorf("net resource requires a net.Conn bus")
+		}
+		executor.RegisterTCP(harnessResourceID, conn)
+	default:
+		return nil, fmt.Errorf("unsupported resource type %q", tr.ResourceType)
+	}
+	if udp, ok := bus.(udpSocket); ok {
+		executor.registerUDP(harnessResourceID, udp)
+	}
+	executor.SetTrafficRecorder(harnessResourceID, recorder)
+
+	if err := manager.LoadDriver(&models.Driver{ID: harnessDriverID, Name: "harness"}, wasmData, harnessResourceID); err != nil {
+		return nil, err
+	}
+	defer func() { _ = manager.UnloadDriver(harnessDriverID) }()
+
+	if manifest, _ := manager.GetDriverManifest(harnessDriverID); manifest != nil {
+		if err := manifest.ValidateDeviceConfig(tr.DeviceConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	return manager.ExecuteDriverWithContext(ctx, harnessDriverID, resolveExecutionFunction(tr.Function), &DriverContext{
+		DeviceID:     harnessDeviceID,
+		DeviceName:   "harness",
+		ResourceID:   harnessResourceID,
+		ResourceType: resourceType,
+		Config:       tr.Config,
+		DeviceConfig: tr.DeviceConfig,
+	})
+}
@@ -0,0 +1,230 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func registerResponse(value byte) HexFrame {
+	return HexFrame{0x01, 0x03, 0x02, 0x00, value, 0x00, 0x00}
+}
+
+func TestParseHexFrame(t *testing.T) {
+	for _, text := range []string{"01 03 0a", "01030A", "0x01:03:0a", " 01\t03 0A\n"} {
+		frame, err := ParseHexFrame(text)
+		if err != nil || string(frame) != "\x01\x03\x0a" {
+			t.Fatalf("ParseHexFrame(%q) = % X, %v", text, frame, err)
+		}
+	}
+	if _, err := ParseHexFrame("01 0"); err == nil {
+		t.Fatal("expected error for odd-length frame")
+	}
+}
+
+func TestReplayTranscript_SaveLoadAndPass(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meter.json")
+	success := true
+	if err := SaveTranscript(path, &Transcript{
+		ResourceType: "serial",
+		Exchanges:    []TranscriptExchange{{Request: ioReadRegisterRequest, Response: registerResponse(5), DelayMs: 20}},
+		Expect:       &TranscriptExpect{Success: &success, Points: map[string]string{"v": "5.0"}},
+	}); err != nil {
+		t.Fatalf("SaveTranscript: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !strings.Contains(string(raw), `"request": "01 03 00 00 00 01 84 0A"`) {
+		t.Fatalf("transcript not saved as hex text:\n%s", raw)
+	}
+
+	tr, err := LoadTranscript(path)
+	if err != nil {
+		t.Fatalf("LoadTranscript: %v", err)
+	}
+	started := time.Now()
+	report := ReplayTranscript(context.Background(), ioDriverWasm(), tr, true)
+	if !report.Passed() {
+		t.Fatalf("replay failures = %v (error %q)", report.Failures, report.Error)
+	}
+	if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+		t.Fatalf("realtime replay finished in %v, want >= recorded delay", elapsed)
+	}
+}
+
+func TestReplayTranscript_Failures(t *testing.T) {
+	wasmData := ioDriverWasm()
+	cases := map[string]struct {
+		transcript *Transcript
+		want       string
+	}{
+		"request mismatch": {
+			transcript: &Transcript{Exchanges: []TranscriptExchange{{Request: HexFrame{0x02, 0x03}, Response: registerResponse(5)}}},
+			want:       "request #1 = 01 03 00 00 00 01 84 0A, want 02 03",
+		},
+		"point mismatch": {
+			transcript: &Transcript{
+				Exchanges: []TranscriptExchange{{Request: ioReadRegisterRequest, Response: registerResponse(5)}},
+				Expect:    &TranscriptExpect{Points: map[string]string{"v": "6", "missing": "1"}},
+			},
+			want: "point v = 5, want 6",
+		},
+		"exchange not requested": {
+			transcript: &Transcript{Exchanges: []TranscriptExchange{
+				{Request: ioReadRegisterRequest, Response: registerResponse(5)},
+				{Request: ioReadRegisterRequest, Response: registerResponse(6)},
+			}},
+			want: "1 recorded exchanges not requested",
+		},
+		"unexpected timeout": {
+			transcript: &Transcript{Exchanges: []TranscriptExchange{{Request: ioReadRegisterRequest}}},
+			want:       "driver error",
+		},
+	}
+	for name, tc := range cases {
+		report := ReplayTranscript(context.Background(), wasmData, tc.transcript, false)
+		if report.Passed() || !strings.Contains(strings.Join(report.Failures, "; "), tc.want) {
+			t.Errorf("%s: failures = %v, want one containing %q", name, report.Failures, tc.want)
+		}
+	}
+
+	// 记录中设备无应答且期望失败时通过
+	failure := false
+	report := ReplayTranscript(context.Background(), wasmData, &Transcript{
+		Exchanges: []TranscriptExchange{{Request: ioReadRegisterRequest}},
+		Expect:    &TranscriptExpect{Success: &failure},
+	}, false)
+	if !report.Passed() || report.Error == "" {
+		t.Fatalf("expected-failure replay = %+v", report)
+	}
+}
+
+func TestRecordTranscript_CapturesHostTraffic(t *testing.T) {
+	wasmData := ioDriverWasm()
+	// 以回放总线模拟真实设备
+	device := NewReplayBus([]TranscriptExchange{{Request: ioReadRegisterRequest, Response: registerResponse(7), DelayMs: 15}}, true)
+
+	tr := &Transcript{ResourceType: "rtu", Config: map[string]string{"slave_id": "1"}}
+	result, err := RecordTranscript(context.Background(), wasmData, tr, device)
+	if err != nil || result == nil || !result.Success {
+		t.Fatalf("RecordTranscript = %+v, %v", result, err)
+	}
+	if tr.ResourceType != "serial" || tr.RecordedAt == nil || len(tr.Exchanges) != 1 {
+		t.Fatalf("recorded transcript = %+v", tr)
+	}
+	exchange := tr.Exchanges[0]
+	if string(exchange.Request) != string(ioReadRegisterRequest) || string(exchange.Response) != string(registerResponse(7)) || exchange.DelayMs < 10 {
+		t.Fatalf("recorded exchange = %+v", exchange)
+	}
+	if tr.Expect == nil || tr.Expect.Points["v"] != "7" || tr.Expect.Success == nil || !*tr.Expect.Success {
+		t.Fatalf("recorded expect = %+v", tr.Expect)
+	}
+
+	if report := ReplayTranscript(context.Background(), wasmData, tr, false); !report.Passed() {
+		t.Fatalf("replay of recording failed: %v", report.Failures)
+	}
+}
+
+func TestReplayBus_NetConnSemantics(t *testing.T) {
+	response := HexFrame{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x01, 0x03, 0x02, 0x00, 0x2A}
+	bus := NewReplayBus([]TranscriptExchange{
+		{Request: HexFrame{0xAA}, Response: response},
+		{Request: HexFrame{0xBB}},
+	}, false)
+
+	if _, err := bus.Write([]byte{0xAA}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = bus.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	buf := make([]byte, 32)
+	n, err := readModbusTCPResponse(bus, buf)
+	if err != nil || string(buf[:n]) != string(response) {
+		t.Fatalf("readModbusTCPResponse = % X, %v", buf[:n], err)
+	}
+
+	if _, err := bus.Write([]byte{0xBB}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	_ = bus.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, err := bus.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read without response err = %v, want deadline exceeded", err)
+	}
+	if _, err := bus.Write([]byte{0xCC}); !errors.Is(err, ErrReplayMismatch) {
+		t.Fatalf("Write past transcript err = %v, want ErrReplayMismatch", err)
+	}
+}
+
+// streamExchanges 为 ioDriverWasm 的 stream 函数在 net 资源上的完整收发
+func streamExchanges() []TranscriptExchange {
+	return []TranscriptExchange{
+		{Op: TranscriptOpWrite, Request: HexFrame("PING\r\n")},
+		{Op: TranscriptOpRead, Response: HexFrame("OK\r\n"), DelayMs: 5},
+		{Op: TranscriptOpWrite, Channel: TranscriptChannelUDP, Addr: "127.0.0.1:9000", Request: HexFrame("HI")},
+		{Op: TranscriptOpRead, Channel: TranscriptChannelUDP, Addr: "127.0.0.1:9000", Response: HexFrame("Z"), DelayMs: 5},
+	}
+}
+
+func TestReplayTranscript_ReadWriteHostFunctions(t *testing.T) {
+	wasmData := ioDriverWasm()
+	poll := &Transcript{
+		Function: "poll",
+		Exchanges: []TranscriptExchange{
+			{Op: TranscriptOpWrite, Request: ioReadRegisterRequest},
+			{Op: TranscriptOpRead, Response: registerResponse(4)},
+		},
+		Expect: &TranscriptExpect{Points: map[string]string{"v": "4"}},
+	}
+	if report := ReplayTranscript(context.Background(), wasmData, poll, false); !report.Passed() {
+		t.Fatalf("serial_write/serial_read replay failures = %v (error %q)", report.Failures, report.Error)
+	}
+
+	stream := &Transcript{
+		ResourceType: "net",
+		Address:      "127.0.0.1:9000",
+		Function:     "stream",
+		Exchanges:    streamExchanges(),
+		Expect:       &TranscriptExpect{Points: map[string]string{"line": "O", "udp": "Z"}},
+	}
+	if report := ReplayTranscript(context.Background(), wasmData, stream, false); !report.Passed() {
+		t.Fatalf("tcp/udp replay failures = %v (error %q)", report.Failures, report.Error)
+	}
+
+	// 写入通道或目标地址与记录不符
+	wrongChannel := &Transcript{Function: "poll", Exchanges: []TranscriptExchange{{Op: TranscriptOpWrite, Channel: TranscriptChannelUDP, Request: ioReadRegisterRequest}}}
+	report := ReplayTranscript(context.Background(), wasmData, wrongChannel, false)
+	if report.Passed() || !strings.Contains(strings.Join(report.Failures, "; "), "recorded write on udp") {
+		t.Fatalf("wrong channel failures = %v", report.Failures)
+	}
+	wrongAddr := &Transcript{ResourceType: "net", Address: "127.0.0.1:9001", Function: "stream", Exchanges: streamExchanges()}
+	report = ReplayTranscript(context.Background(), wasmData, wrongAddr, false)
+	if report.Passed() || !strings.Contains(strings.Join(report.Failures, "; "), "sent to 127.0.0.1:9001, want 127.0.0.1:9000") {
+		t.Fatalf("wrong address failures = %v", report.Failures)
+	}
+}
+
+func TestRecordTranscript_CapturesStreamTraffic(t *testing.T) {
+	wasmData := ioDriverWasm()
+	device := NewReplayBus(streamExchanges(), false)
+
+	tr := &Transcript{ResourceType: "net", Address: "127.0.0.1:9000", Function: "stream"}
+	result, err := RecordTranscript(context.Background(), wasmData, tr, device)
+	if err != nil || result == nil || !result.Success {
+		t.Fatalf("RecordTranscript = %+v, %v", result, err)
+	}
+	want := streamExchanges()
+	if len(tr.Exchanges) != len(want) {
+		t.Fatalf("recorded %d exchanges, want %d: %+v", len(tr.Exchanges), len(want), tr.Exchanges)
+	}
+	for i, got := range tr.Exchanges {
+		if got.Op != want[i].Op || got.Channel != want[i].Channel || got.Addr != want[i].Addr ||
+			string(got.Request) != string(want[i].Request) || string(got.Response) != string(want[i].Response) {
+			t.Fatalf("exchange #%d = %+v, want %+v", i+1, got, want[i])
+		}
+	}
+	if report := ReplayTranscript(context.Background(), wasmData, tr, false); !report.Passed() {
+		t.Fatalf("replay of recording failed: %v", report.Failures)
+	}
+}
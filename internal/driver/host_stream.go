@@ -0,0 +1,146 @@
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const hostStreamReaderSize = 1024
+
+// ErrStreamBufferFull 分隔符出现前读缓冲已满
+var ErrStreamBufferFull = errors.New("stream buffer full before delimiter")
+
+// tcpStreamReader 资源 TCP 连接上的带缓冲读取器，按分隔符读取时保留多读的字节
+type tcpStreamReader struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// hostTCPConn 返回资源的 TCP 连接（懒连接），供驱动调用期间使用，调用方需持有资源锁
+func (e *DriverExecutor) hostTCPConn(resourceID int64) net.Conn {
+	e.mu.RLock()
+	conn := e.tcpConns[resourceID]
+	path := e.resourcePaths[resourceID]
+	e.mu.RUnlock()
+	if conn != nil {
+		return conn
+	}
+	if path == "" {
+		return nil
+	}
+	return e.connectTCPResource(resourceID, path)
+}
+
+// tcpStream 返回资源 TCP 连接及其读取器，连接重建后读取器随之重建，调用方需持有资源锁
+func (e *DriverExecutor) tcpStream(resourceID int64) (net.Conn, *bufio.Reader) {
+	conn := e.hostTCPConn(resourceID)
+	if conn == nil {
+		return nil, nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if stream, ok := e.tcpReaders[resourceID]; ok && stream.conn == conn {
+		return conn, stream.reader
+	}
+	stream := &tcpStreamReader{conn: conn, reader: bufio.NewReaderSize(conn, hostStreamReaderSize)}
+	e.tcpReaders[resourceID] = stream
+	return conn, stream.reader
+}
+
+// udpSocket 资源的 UDP 套接字，驱动测试时由回放总线替代
+type udpSocket interface {
+	WriteToUDP(data []byte, addr *net.UDPAddr) (int, error)
+	ReadFromUDP(data []byte) (int, *net.UDPAddr, error)
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+// registerUDP 注册资源的 UDP 套接字
+func (e *DriverExecutor) registerUDP(resourceID int64, conn udpSocket) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.udpConns[resourceID] = conn
+}
+
+// udpConn 返回资源的 UDP 套接字（懒创建，本地随机端口），调用方需持有资源锁
+func (e *DriverExecutor) udpConn(resourceID int64) (udpSocket, error) {
+	e.mu.RLock()
+	conn := e.udpConns[resourceID]
+	e.mu.RUnlock()
+	if conn != nil {
+		return conn, nil
+	}
+	listener, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, fmt.Errorf("listen udp failed: %w", err)
+	}
+	e.mu.Lock()
+	if existing := e.udpConns[resourceID]; existing != nil {
+		e.mu.Unlock()
+		_ = listener.Close()
+		return existing, nil
+	}
+	e.udpConns[resourceID] = listener
+	e.mu.Unlock()
+	return listener, nil
+}
+
+// UnregisterUDP 关闭资源的 UDP 套接字
+func (e *DriverExecutor) UnregisterUDP(resourceID int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if conn, ok := e.udpConns[resourceID]; ok {
+		_ = conn.Close()
+		delete(e.udpConns, resourceID)
+	}
+}
+
+// resolveUDPAddr 解析目标地址，为空时使用资源路径
+func (e *DriverExecutor) resolveUDPAddr(resourceID int64, address string) (*net.UDPAddr, error) {
+	if address == "" {
+		address = e.GetResourcePath(resourceID)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("udp address is empty")
+	}
+	return net.ResolveUDPAddr("udp", address)
+}
+
+// readStreamUntil 读取直到分隔符（含分隔符）；缓冲写满仍未遇到分隔符时返回 ErrStreamBufferFull
+func readStreamUntil(reader *bufio.Reader, buf []byte, delim []byte) (int, error) {
+	if len(delim) == 0 {
+		return 0, fmt.Errorf("empty delimiter")
+	}
+	n := 0
+	for n < len(buf) {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		buf[n] = b
+		n++
+		if n >= len(delim) && bytes.Equal(buf[n-len(delim):n], delim) {
+			return n, nil
+		}
+	}
+	return n, ErrStreamBufferFull
+}
+
+// isStreamTimeout 判断是否为读写超时（超时保留连接，其余错误需重建连接）
+func isStreamTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// streamDeadline 计算读写截止时间，timeoutMs <= 0 时使用默认值
+func streamDeadline(timeoutMs int, fallback time.Duration) time.Time {
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = fallback
+	}
+	return time.Now().Add(timeout)
+}
@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadStreamUntil_KeepsBytesAfterDelimiter(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("V=220.1\r\nI=5.2\r\npartial"))
+	buf := make([]byte, 32)
+
+	for _, want := range []string{"V=220.1\r\n", "I=5.2\r\n"} {
+		n, err := readStreamUntil(reader, buf, []byte("\r\n"))
+		if err != nil || string(buf[:n]) != want {
+			t.Fatalf("readStreamUntil = %q, %v; want %q", buf[:n], err, want)
+		}
+	}
+	if n, err := readStreamUntil(reader, buf, []byte("\r\n")); err == nil || string(buf[:n]) != "partial" {
+		t.Fatalf("readStreamUntil(eof) = %q, %v", buf[:n], err)
+	}
+
+	reader = bufio.NewReader(strings.NewReader("0123456789\n"))
+	if n, err := readStreamUntil(reader, buf[:4], []byte("\n")); !errors.Is(err, ErrStreamBufferFull) || n != 4 {
+		t.Fatalf("readStreamUntil(full) = %d, %v", n, err)
+	}
+}
+
+func TestTCPStream_ReaderFollowsConnection(t *testing.T) {
+	executor := NewDriverExecutor(nil)
+	first, peer := net.Pipe()
+	defer peer.Close()
+	executor.RegisterTCP(7, first)
+
+	conn, reader := executor.tcpStream(7)
+	if conn != first || reader == nil {
+		t.Fatalf("tcpStream did not use registered connection")
+	}
+	if _, again := executor.tcpStream(7); again != reader {
+		t.Fatalf("reader not reused for the same connection")
+	}
+
+	executor.UnregisterTCP(7)
+	if conn, _ := executor.tcpStream(7); conn != nil {
+		t.Fatalf("tcpStream without path should not dial")
+	}
+
+	second, peer2 := net.Pipe()
+	defer peer2.Close()
+	executor.RegisterTCP(7, second)
+	go func() { _, _ = peer2.Write([]byte("OK\n")) }()
+	conn, rebuilt := executor.tcpStream(7)
+	if conn != second || rebuilt == reader {
+		t.Fatalf("reader not rebuilt after reconnect")
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 8)
+	if n, err := readStreamUntil(rebuilt, buf, []byte("\n")); err != nil || string(buf[:n]) != "OK\n" {
+		t.Fatalf("read = %q, %v", buf[:n], err)
+	}
+	executor.CloseAllResources()
+}
@@ -0,0 +1,251 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+	"github.com/gonglijing/xunjiFsu/internal/database"
+)
+
+// HostAPIVersion 宿主函数 API 版本
+// 1: serial_read / serial_write / serial_transceive / tcp_transceive / sleep_ms
+// 2: host_api_version / host_has / host_log / clock_ms / time_unix_ms / kv_* / tcp_write / tcp_read / tcp_read_until / udp_send / udp_recv
+const HostAPIVersion = 2
+
+// maxHostLogSize 单条驱动日志上限
+const maxHostLogSize = 4096
+
+// hostClockStart 单调时钟基准
+var hostClockStart = time.Now()
+
+type hostCallKey struct{}
+
+// hostCall 单次驱动调用的上下文，供宿主函数标注日志与定位设备状态
+type hostCall struct {
+	driverID   int64
+	driverName string
+	deviceID   int64
+	deviceName string
+	resourceID int64
+}
+
+func withHostCall(ctx context.Context, call *hostCall) context.Context {
+	return context.WithValue(ctx, hostCallKey{}, call)
+}
+
+func hostCallFrom(ctx context.Context) *hostCall {
+	if ctx != nil {
+		if call, ok := ctx.Value(hostCallKey{}).(*hostCall); ok && call != nil {
+			return call
+		}
+	}
+	return &hostCall{}
+}
+
+// hostI64 将有符号返回值写入 i64 栈槽（负数表示失败）
+func hostI64(v int64) uint64 {
+	return uint64(v)
+}
+
+// readPluginBytes 拷贝插件内存中的数据
+func readPluginBytes(p *extism.CurrentPlugin, ptr uint64, size int) ([]byte, bool) {
+	if size == 0 {
+		return []byte{}, true
+	}
+	if !validI64PtrSize(ptr, size) {
+		return nil, false
+	}
+	data, ok := p.Memory().Read(uint32(ptr), uint32(size))
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), data...), true
+}
+
+// createAPIHostFunctions 创建与资源无关的宿主函数：版本探测、日志、时钟与设备键值状态
+// names 在全部宿主函数创建完成后由调用方填充，供 host_has 查询
+func (m *DriverManager) createAPIHostFunctions(names map[string]struct{}) []extism.HostFunction {
+	// host_api_version: 返回宿主 API 版本
+	hostAPIVersion := extism.NewHostFunctionWithStack(
+		"host_api_version",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			stack[0] = HostAPIVersion
+		},
+		[]extism.ValueType{},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// host_has: 按名称检测宿主函数是否存在，存在返回 1
+	hostHas := extism.NewHostFunctionWithStack(
+		"host_has",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			name, ok := readPluginBytes(p, stack[0], int(stack[1]))
+			stack[0] = 0
+			if !ok {
+				return
+			}
+			if _, exists := names[string(name)]; exists {
+				stack[0] = 1
+			}
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// host_log: 结构化日志，level 0=debug 1=info 2=warn 3=error
+	hostLog := extism.NewHostFunctionWithStack(
+		"host_log",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			size := int(stack[2])
+			if size > maxHostLogSize {
+				size = maxHostLogSize
+			}
+			msg, ok := readPluginBytes(p, stack[1], size)
+			if !ok {
+				return
+			}
+			level := slog.LevelInfo
+			switch stack[0] {
+			case 0:
+				level = slog.LevelDebug
+			case 2:
+				level = slog.LevelWarn
+			case 3:
+				level = slog.LevelError
+			}
+			call := hostCallFrom(ctx)
+			slog.Log(context.Background(), level, string(msg),
+				"driver", call.driverName,
+				"driver_id", call.driverID,
+				"device_id", call.deviceID,
+				"device_name", call.deviceName,
+				"resource_id", call.resourceID,
+			)
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{},
+	)
+
+	// clock_ms: 单调时钟（毫秒），用于测量间隔
+	clockMs := extism.NewHostFunctionWithStack(
+		"clock_ms",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			stack[0] = hostI64(time.Since(hostClockStart).Milliseconds())
+		},
+		[]extism.ValueType{},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// time_unix_ms: 墙上时钟（Unix 毫秒）
+	timeUnixMs := extism.NewHostFunctionWithStack(
+		"time_unix_ms",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			stack[0] = hostI64(time.Now().UnixMilli())
+		},
+		[]extism.ValueType{},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// sleep_ms: 毫秒延时，调用超时或取消时提前返回
+	sleepMs := extism.NewHostFunctionWithStack(
+		"sleep_ms",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			d := time.Duration(int64(stack[0])) * time.Millisecond
+			if d <= 0 {
+				return
+			}
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+		},
+		[]extism.ValueType{extism.ValueTypeI64},
+		[]extism.ValueType{},
+	)
+
+	// kv_get: 读取设备状态，返回值长度；不存在返回 -1，失败返回 -2；长度超过 cap 时不写入
+	kvGet := extism.NewHostFunctionWithStack(
+		"kv_get",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			valuePtr, valueCap := stack[2], int(stack[3])
+			key, ok := readPluginBytes(p, stack[0], int(stack[1]))
+			call := hostCallFrom(ctx)
+			if !ok || call.deviceID <= 0 {
+				stack[0] = hostI64(-2)
+				return
+			}
+			value, err := database.GetDriverState(call.deviceID, string(key))
+			if errors.Is(err, sql.ErrNoRows) {
+				stack[0] = hostI64(-1)
+				return
+			}
+			if err != nil {
+				slog.Warn("Driver kv_get failed", "device_id", call.deviceID, "key", string(key), "error", err)
+				stack[0] = hostI64(-2)
+				return
+			}
+			if len(value) > 0 && len(value) <= valueCap {
+				if !validI64Ptr(valuePtr) || !p.Memory().Write(uint32(valuePtr), value) {
+					stack[0] = hostI64(-2)
+					return
+				}
+			}
+			stack[0] = uint64(len(value))
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64, extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// kv_set: 写入设备状态（持久化到 param.db），成功返回 0，失败返回 -1
+	kvSet := extism.NewHostFunctionWithStack(
+		"kv_set",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			key, okKey := readPluginBytes(p, stack[0], int(stack[1]))
+			value, okValue := readPluginBytes(p, stack[2], int(stack[3]))
+			call := hostCallFrom(ctx)
+			if !okKey || !okValue || call.deviceID <= 0 {
+				stack[0] = hostI64(-1)
+				return
+			}
+			if err := database.SetDriverState(call.deviceID, string(key), value); err != nil {
+				slog.Warn("Driver kv_set failed", "device_id", call.deviceID, "key", string(key), "error", err)
+				stack[0] = hostI64(-1)
+				return
+			}
+			stack[0] = 0
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64, extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// kv_delete: 删除设备状态键，成功返回 0，失败返回 -1
+	kvDelete := extism.NewHostFunctionWithStack(
+		"kv_delete",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			key, ok := readPluginBytes(p, stack[0], int(stack[1]))
+			call := hostCallFrom(ctx)
+			if !ok || call.deviceID <= 0 {
+				stack[0] = hostI64(-1)
+				return
+			}
+			if err := database.DeleteDriverState(call.deviceID, string(key)); err != nil {
+				slog.Warn("Driver kv_delete failed", "device_id", call.deviceID, "key", string(key), "error", err)
+				stack[0] = hostI64(-1)
+				return
+			}
+			stack[0] = 0
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	return []extism.HostFunction{hostAPIVersion, hostHas, hostLog, clockMs, timeUnixMs, sleepMs, kvGet, kvSet, kvDelete}
+}
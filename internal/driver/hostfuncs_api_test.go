@@ -0,0 +1,53 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func TestCreateHostFunctions_UniqueVersionedSet(t *testing.T) {
+	manager := NewDriverManager()
+	NewDriverExecutor(manager)
+
+	seen := make(map[string]bool)
+	for _, f := range manager.createHostFunctions(1) {
+		if seen[f.Name] {
+			t.Fatalf("duplicate host function %q", f.Name)
+		}
+		seen[f.Name] = true
+	}
+	for _, name := range []string{
+		"host_api_version", "host_has", "host_log", "clock_ms", "time_unix_ms", "sleep_ms",
+		"kv_get", "kv_set", "kv_delete",
+		"serial_read", "serial_write", "serial_transceive", "tcp_transceive",
+		"tcp_write", "tcp_read", "tcp_read_until", "udp_send", "udp_recv",
+	} {
+		if !seen[name] {
+			t.Fatalf("host function %q missing", name)
+		}
+	}
+
+	if err := manager.LoadDriver(&models.Driver{ID: 1, Name: "api"}, minimalDriverWasm(), 1); err != nil {
+		t.Fatalf("LoadDriver() error = %v", err)
+	}
+	defer manager.UnloadDriver(1)
+	driver, _ := manager.GetDriver(1)
+	if got := driver.plugin.Config["host_api_version"]; got != strconv.Itoa(HostAPIVersion) {
+		t.Fatalf("host_api_version config = %q", got)
+	}
+}
+
+func TestHostCallFrom(t *testing.T) {
+	if call := hostCallFrom(context.Background()); call == nil || call.deviceID != 0 {
+		t.Fatalf("hostCallFrom(empty) = %+v", call)
+	}
+	ctx := withHostCall(context.Background(), &hostCall{driverID: 2, deviceID: 9, deviceName: "meter"})
+	if call := hostCallFrom(ctx); call.deviceID != 9 || call.deviceName != "meter" {
+		t.Fatalf("hostCallFrom = %+v", call)
+	}
+}
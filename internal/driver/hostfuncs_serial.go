@@ -35,11 +35,14 @@ func (m *DriverManager) createSerialHostFunctions(resourceID int64) []extism.Hos
 				return
 			}
 
+			expectReplayRead(port)
+			startedAt := time.Now()
 			n, err := port.Read(buf)
 			if err != nil {
 				stack[0] = 0
 				return
 			}
+			executor.trafficRecorder(resourceID).RecordRead("", "", buf[:n], time.Since(startedAt))
 
 			// 将数据写入插件内存
 			p.Memory().Write(uint32(ptr), buf[:n])
@@ -74,6 +77,7 @@ func (m *DriverManager) createSerialHostFunctions(resourceID int64) []extism.Hos
 				stack[0] = 0
 				return
 			}
+			executor.trafficRecorder(resourceID).RecordWrite("", "", data[:n])
 
 			stack[0] = uint64(n) // 返回实际写入的字节数
 		},
@@ -133,6 +137,7 @@ func (m *DriverManager) createSerialHostFunctions(resourceID int64) []extism.Hos
 			if dtr, ok := port.(interface{ SetDTR(bool) error }); ok {
 				_ = dtr.SetDTR(false)
 			}
+			sentAt := time.Now()
 			time.Sleep(3 * time.Millisecond)
 
 			buf := getModbusFrameBuffer(readCap)
@@ -142,6 +147,7 @@ func (m *DriverManager) createSerialHostFunctions(resourceID int64) []extism.Hos
 				tout = executor.serialReadTimeout()
 			}
 			n, err := readWithTimeout(port, buf, readCap, tout)
+			executor.trafficRecorder(resourceID).Record(req, buf[:n], time.Since(sentAt))
 			if n == 0 {
 				if err != nil {
 					slog.Warn("Serial read failed", "resource_id", resourceID, "error", err.Error(), "req", hexPreview(req, 32))
@@ -167,16 +173,5 @@ func (m *DriverManager) createSerialHostFunctions(resourceID int64) []extism.Hos
 		[]extism.ValueType{extism.ValueTypeI64}, // bytes read
 	)
 
-	// sleep_ms: 毫秒延时
-	sleepMs := extism.NewHostFunctionWithStack(
-		"sleep_ms",
-		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
-			ms := int(stack[0])
-			time.Sleep(time.Duration(ms) * time.Millisecond)
-		},
-		[]extism.ValueType{extism.ValueTypeI64},
-		[]extism.ValueType{},
-	)
-
-	return []extism.HostFunction{serialRead, serialWrite, serialTransceive, sleepMs}
+	return []extism.HostFunction{serialRead, serialWrite, serialTransceive}
 }
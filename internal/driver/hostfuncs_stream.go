@@ -0,0 +1,220 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+)
+
+// maxHostIOSize 单次流式读写上限
+const maxHostIOSize = 64 * 1024
+
+// createStreamHostFunctions 创建通用字节流宿主函数：原始 TCP 读写（按长度/分隔符分帧）与 UDP 收发
+// 失败或超时返回 0；非超时错误会关闭连接，下次调用时重建
+func (m *DriverManager) createStreamHostFunctions(resourceID int64) []extism.HostFunction {
+	executor := m.executor
+	if executor == nil {
+		return nil
+	}
+
+	// tcp_write: 写入原始字节，返回写入字节数
+	tcpWrite := extism.NewHostFunctionWithStack(
+		"tcp_write",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			size := int(stack[1])
+			data, ok := readPluginBytes(p, stack[0], size)
+			conn, _ := executor.tcpStream(resourceID)
+			if !ok || size <= 0 || size > maxHostIOSize || conn == nil {
+				stack[0] = 0
+				return
+			}
+			_ = conn.SetWriteDeadline(streamDeadline(0, executor.tcpReadTimeout()))
+			n, err := conn.Write(data)
+			if err != nil {
+				slog.Warn("TCP stream write failed", "resource_id", resourceID, "error", err)
+				if !isStreamTimeout(err) {
+					executor.UnregisterTCP(resourceID)
+				}
+			}
+			if n > 0 {
+				executor.trafficRecorder(resourceID).RecordWrite("", "", data[:n])
+			}
+			stack[0] = uint64(n)
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// tcp_read: expect > 0 时读满 expect 字节（按长度分帧），否则返回首批到达的数据
+	tcpRead := extism.NewHostFunctionWithStack(
+		"tcp_read",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			ptr, capacity, expect, timeoutMs := stack[0], int(stack[1]), int(stack[2]), int(stack[3])
+			conn, reader := executor.tcpStream(resourceID)
+			if conn == nil || !validI64PtrSize(ptr, capacity) || capacity > maxHostIOSize || expect < 0 || expect > capacity {
+				stack[0] = 0
+				return
+			}
+			buf := getModbusFrameBuffer(capacity)
+			defer putModbusFrameBuffer(buf)
+
+			expectReplayRead(conn)
+			startedAt := time.Now()
+			_ = conn.SetReadDeadline(streamDeadline(timeoutMs, executor.tcpReadTimeout()))
+			var n int
+			var err error
+			if expect > 0 {
+				n, err = io.ReadFull(reader, buf[:expect])
+			} else {
+				n, err = reader.Read(buf)
+			}
+			if err != nil {
+				slog.Warn("TCP stream read failed", "resource_id", resourceID, "read", n, "error", err)
+				if !isStreamTimeout(err) {
+					executor.UnregisterTCP(resourceID)
+				}
+				stack[0] = 0
+				return
+			}
+			executor.trafficRecorder(resourceID).RecordRead("", "", buf[:n], time.Since(startedAt))
+			p.Memory().Write(uint32(ptr), buf[:n])
+			stack[0] = uint64(n)
+		},
+		[]extism.ValueType{
+			extism.ValueTypeI64, // ptr
+			extism.ValueTypeI64, // cap
+			extism.ValueTypeI64, // expect
+			extism.ValueTypeI64, // timeout ms
+		},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// tcp_read_until: 读取直到分隔符（含分隔符），如 ASCII 仪表的 "\r\n"
+	tcpReadUntil := extism.NewHostFunctionWithStack(
+		"tcp_read_until",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			ptr, capacity, timeoutMs := stack[0], int(stack[1]), int(stack[4])
+			delim, ok := readPluginBytes(p, stack[2], int(stack[3]))
+			conn, reader := executor.tcpStream(resourceID)
+			if !ok || len(delim) == 0 || conn == nil || !validI64PtrSize(ptr, capacity) || capacity > maxHostIOSize {
+				stack[0] = 0
+				return
+			}
+			buf := getModbusFrameBuffer(capacity)
+			defer putModbusFrameBuffer(buf)
+
+			expectReplayRead(conn)
+			startedAt := time.Now()
+			_ = conn.SetReadDeadline(streamDeadline(timeoutMs, executor.tcpReadTimeout()))
+			n, err := readStreamUntil(reader, buf, delim)
+			if err != nil {
+				slog.Warn("TCP stream read until failed", "resource_id", resourceID, "read", n, "error", err)
+				if !isStreamTimeout(err) {
+					executor.UnregisterTCP(resourceID)
+				}
+				stack[0] = 0
+				return
+			}
+			executor.trafficRecorder(resourceID).RecordRead("", "", buf[:n], time.Since(startedAt))
+			p.Memory().Write(uint32(ptr), buf[:n])
+			stack[0] = uint64(n)
+		},
+		[]extism.ValueType{
+			extism.ValueTypeI64, // ptr
+			extism.ValueTypeI64, // cap
+			extism.ValueTypeI64, // delimiter ptr
+			extism.ValueTypeI64, // delimiter len
+			extism.ValueTypeI64, // timeout ms
+		},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// udp_send: 发送数据报，地址为空时发往资源路径（host:port）
+	udpSend := extism.NewHostFunctionWithStack(
+		"udp_send",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			addr, okAddr := readPluginBytes(p, stack[0], int(stack[1]))
+			size := int(stack[3])
+			data, okData := readPluginBytes(p, stack[2], size)
+			if !okAddr || !okData || size <= 0 || size > maxHostIOSize {
+				stack[0] = 0
+				return
+			}
+			target, err := executor.resolveUDPAddr(resourceID, string(addr))
+			if err != nil {
+				slog.Warn("UDP address invalid", "resource_id", resourceID, "error", err)
+				stack[0] = 0
+				return
+			}
+			conn, err := executor.udpConn(resourceID)
+			if err != nil {
+				slog.Warn("UDP socket unavailable", "resource_id", resourceID, "error", err)
+				stack[0] = 0
+				return
+			}
+			n, err := conn.WriteToUDP(data, target)
+			if err != nil {
+				slog.Warn("UDP send failed", "resource_id", resourceID, "target", target.String(), "error", err)
+				executor.UnregisterUDP(resourceID)
+				stack[0] = 0
+				return
+			}
+			executor.trafficRecorder(resourceID).RecordWrite(TranscriptChannelUDP, target.String(), data[:n])
+			stack[0] = uint64(n)
+		},
+		[]extism.ValueType{
+			extism.ValueTypeI64, // addr ptr
+			extism.ValueTypeI64, // addr len
+			extism.ValueTypeI64, // data ptr
+			extism.ValueTypeI64, // data len
+		},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	// udp_recv: 接收一个数据报，返回字节数
+	udpRecv := extism.NewHostFunctionWithStack(
+		"udp_recv",
+		func(ctx context.Context, p *extism.CurrentPlugin, stack []uint64) {
+			ptr, capacity, timeoutMs := stack[0], int(stack[1]), int(stack[2])
+			if !validI64PtrSize(ptr, capacity) || capacity > maxHostIOSize {
+				stack[0] = 0
+				return
+			}
+			conn, err := executor.udpConn(resourceID)
+			if err != nil {
+				stack[0] = 0
+				return
+			}
+			buf := getModbusFrameBuffer(capacity)
+			defer putModbusFrameBuffer(buf)
+
+			startedAt := time.Now()
+			_ = conn.SetReadDeadline(streamDeadline(timeoutMs, executor.tcpReadTimeout()))
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				if !isStreamTimeout(err) {
+					slog.Warn("UDP receive failed", "resource_id", resourceID, "error", err)
+					executor.UnregisterUDP(resourceID)
+				}
+				stack[0] = 0
+				return
+			}
+			fromAddr := ""
+			if from != nil {
+				fromAddr = from.String()
+			}
+			executor.trafficRecorder(resourceID).RecordRead(TranscriptChannelUDP, fromAddr, buf[:n], time.Since(startedAt))
+			p.Memory().Write(uint32(ptr), buf[:n])
+			stack[0] = uint64(n)
+		},
+		[]extism.ValueType{extism.ValueTypeI64, extism.ValueTypeI64, extism.ValueTypeI64},
+		[]extism.ValueType{extism.ValueTypeI64},
+	)
+
+	return []extism.HostFunction{tcpWrite, tcpRead, tcpReadUntil, udpSend, udpRecv}
+}
@@ -4,8 +4,6 @@ package driver
 
 import (
 	"context"
-	"encoding/binary"
-	"io"
 	"log/slog"
 	"time"
 
@@ -28,7 +26,7 @@ func (m *DriverManager) createTCPHostFunctions(resourceID int64) []extism.HostFu
 			rCap := int(stack[3])
 			timeoutMs := int(stack[4])
 
-			conn := executor.GetTCPConn(resourceID)
+			conn := executor.hostTCPConn(resourceID)
 			if conn == nil || wSize <= 0 || rCap <= 0 {
 				if conn == nil {
 					slog.Warn("TCP connection unavailable", "resource_id", resourceID)
@@ -50,6 +48,7 @@ func (m *DriverManager) createTCPHostFunctions(resourceID int64) []extism.HostFu
 				return
 			}
 
+			sentAt := time.Now()
 			tout := time.Duration(timeoutMs) * time.Millisecond
 			if tout <= 0 {
 				tout = executor.tcpReadTimeout()
@@ -58,6 +57,7 @@ func (m *DriverManager) createTCPHostFunctions(resourceID int64) []extism.HostFu
 			buf := getModbusFrameBuffer(rCap)
 			defer putModbusFrameBuffer(buf)
 			n, err := readModbusTCPResponse(conn, buf)
+			executor.trafficRecorder(resourceID).Record(req, buf[:n], time.Since(sentAt))
 			if err != nil || n <= 0 {
 				if err != nil {
 					slog.Warn("TCP read failed", "resource_id", resourceID, "error", err, "req", hexPreview(req, 32))
@@ -83,29 +83,3 @@ func (m *DriverManager) createTCPHostFunctions(resourceID int64) []extism.HostFu
 
 	return []extism.HostFunction{tcpTransceive}
 }
-
-func readModbusTCPResponse(conn io.Reader, buf []byte) (int, error) {
-	if len(buf) < 9 {
-		return 0, io.ErrShortBuffer
-	}
-
-	if _, err := io.ReadFull(conn, buf[:7]); err != nil {
-		return 0, err
-	}
-
-	length := int(binary.BigEndian.Uint16(buf[4:6]))
-	if length < 2 {
-		return 0, io.ErrUnexpectedEOF
-	}
-
-	total := 6 + length
-	if total > len(buf) {
-		return 0, io.ErrShortBuffer
-	}
-
-	if _, err := io.ReadFull(conn, buf[7:total]); err != nil {
-		return 0, err
-	}
-
-	return total, nil
-}
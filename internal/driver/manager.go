@@ -37,6 +37,12 @@ var ErrDriverCanceled = errors.New("driver execution canceled")
 // ErrDriverBadOutput 驱动输出无效
 var ErrDriverBadOutput = errors.New("driver output invalid")
 
+// ErrDriverCallBudgetExhausted 驱动单次调用的函数调用预算耗尽
+var ErrDriverCallBudgetExhausted = errors.New("driver call budget exhausted")
+
+// ErrDriverQuarantined 驱动因反复崩溃被隔离
+var ErrDriverQuarantined = errors.New("driver quarantined")
+
 func hexPreview(b []byte, max int) string {
 	if len(b) == 0 {
 		return ""
@@ -60,8 +66,10 @@ func outputPreview(b []byte, max int) string {
 // DriverResult 驱动执行结果
 type DriverResult struct {
 	Success       bool              `json:"success"`
-	Data          map[string]string `json:"data"`   // 旧格式: {"temperature": "25.3"}
-	Points        []DriverPoint     `json:"points"` // 新格式: [{"field_name":"temperature","value":25.3,"rw":"R"}]
+	Data          map[string]string `json:"data"`                    // 旧格式: {"temperature": "25.3"}
+	Points        []DriverPoint     `json:"points"`                  // 新格式: [{"field_name":"temperature","value":25.3,"rw":"R"}]
+	Writes        []WriteItemResult `json:"writes,omitempty"`        // 批量写逐字段结果
+	FailedPoints  []string          `json:"failed_points,omitempty"` // 部分读取失败时未取到值的测点
 	ProductKey    string            `json:"productKey,omitempty"`
 	ProductKeyAlt string            `json:"product_key,omitempty"`
 	Error         string            `json:"error"`
@@ -129,7 +137,7 @@ type SerialPort interface {
 type WasmDriver struct {
 	ID                 int64
 	Name               string
-	plugin             *extism.Plugin
+	plugin             *extism.Plugin // 加载时资源上的主实例
 	lastActiveUnixNano int64
 	config             string
 	resourceID         int64 // 关联的串口资源ID
@@ -137,6 +145,13 @@ type WasmDriver struct {
 	exportedSet        map[string]struct{}
 	version            string
 	productKey         string
+	manifest           *DriverManifest
+	wasmData           []byte
+	limits             pluginLimits // 沙箱上限，实例创建时生效
+	crash              driverCrashState
+	instMu             sync.Mutex
+	instances          map[int64]*pluginInstance // 资源ID -> 插件实例
+	closed             bool
 }
 
 // DriverManager 驱动管理器
@@ -145,13 +160,23 @@ type DriverManager struct {
 	drivers     map[int64]*WasmDriver
 	executor    *DriverExecutor // 引用执行器以访问串口
 	callTimeout time.Duration
+
+	instanceIdleTimeout time.Duration // 资源实例空闲回收时间
+	lastEvictUnixNano   int64
+
+	defaultLimits     pluginLimits
+	quarantineCrashes int
+	quarantineWindow  time.Duration
 }
 
 // NewDriverManager 创建驱动管理器
 func NewDriverManager() *DriverManager {
 	extism.SetLogLevel(extism.LogLevelError)
 	return &DriverManager{
-		drivers: make(map[int64]*WasmDriver),
+		drivers:             make(map[int64]*WasmDriver),
+		instanceIdleTimeout: defaultPluginInstanceIdleTimeout,
+		quarantineCrashes:   defaultDriverQuarantineCrashes,
+		quarantineWindow:    defaultDriverQuarantineWindow,
 	}
 }
 
@@ -167,15 +192,17 @@ func (m *DriverManager) SetCallTimeout(timeout time.Duration) {
 	m.mu.Unlock()
 }
 
-// createHostFunctions 创建 Host Functions
+// createHostFunctions 创建 Host Functions（资源相关函数需要执行器）
 func (m *DriverManager) createHostFunctions(resourceID int64) []extism.HostFunction {
-	executor := m.executor
-	if executor == nil {
-		return nil
-	}
-	funcs := make([]extism.HostFunction, 0, 5)
+	names := make(map[string]struct{})
+	funcs := make([]extism.HostFunction, 0, 20)
+	funcs = append(funcs, m.createAPIHostFunctions(names)...)
 	funcs = append(funcs, m.createSerialHostFunctions(resourceID)...)
 	funcs = append(funcs, m.createTCPHostFunctions(resourceID)...)
+	funcs = append(funcs, m.createStreamHostFunctions(resourceID)...)
+	for _, f := range funcs {
+		names[f.Name] = struct{}{}
+	}
 	return funcs
 }
 
@@ -200,18 +227,16 @@ func (m *DriverManager) LoadDriver(driver *models.Driver, wasmData []byte, resou
 		resourceID = parseDriverResourceID(driver.ConfigSchema)
 	}
 
-	// 创建设置配置 (传递给插件)
-	config := map[string]string{
-		"resource_id": fmt.Sprintf("%d", resourceID),
+	limits := parseDriverLimits(driver.ConfigSchema, m.defaultLimits)
+	if limits.maxMemoryPages > 0 || limits.maxCalls > 0 {
+		slog.Info("Driver sandbox limits applied", "driver", driver.Name, "max_memory_pages", limits.maxMemoryPages, "max_call_budget", limits.maxCalls)
 	}
-
-	hostFuncs := m.createHostFunctions(resourceID)
-	plugin, err := newWasmPlugin(driver.Name, wasmData, hostFuncs, config)
+	plugin, err := m.newResourcePlugin(driver.Name, wasmData, resourceID, limits)
 	if err != nil {
 		return fmt.Errorf("failed to create plugin: %w", err)
 	}
 	exportedFunctions, exportedSet := cachedPluginExports(plugin)
-	version, productKey, err := extractDriverMetadataFromPlugin(plugin)
+	meta, err := extractDriverMetadataFromPlugin(plugin)
 	if err != nil {
 		_ = plugin.Close(context.Background())
 		return fmt.Errorf("failed to extract driver metadata: %w", err)
@@ -226,8 +251,12 @@ func (m *DriverManager) LoadDriver(driver *models.Driver, wasmData []byte, resou
 		resourceID:         resourceID,
 		exportedFunctions:  exportedFunctions,
 		exportedSet:        exportedSet,
-		version:            version,
-		productKey:         productKey,
+		version:            meta.version,
+		productKey:         meta.productKey,
+		manifest:           meta.manifest,
+		wasmData:           wasmData,
+		limits:             limits,
+		instances:          map[int64]*pluginInstance{resourceID: newPinnedInstance(plugin, resourceID)},
 	}
 
 	m.drivers[driver.ID] = wasmDriver
@@ -254,19 +283,16 @@ func (m *DriverManager) ReloadDriver(driver *models.Driver, wasmData []byte, res
 // UnloadDriver 卸载驱动
 func (m *DriverManager) UnloadDriver(id int64) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	driver, exists := m.drivers[id]
 	if !exists {
+		m.mu.Unlock()
 		return ErrDriverNotFound
 	}
-
-	// 关闭插件
-	if driver.plugin != nil {
-		_ = driver.plugin.Close(context.Background())
-	}
-
 	delete(m.drivers, id)
+	m.mu.Unlock()
+
+	// 关闭全部资源实例（等待执行中的调用结束）
+	driver.closeInstances()
 	return nil
 }
 
@@ -294,7 +320,13 @@ func (m *DriverManager) executeDriverWithInput(ctx context.Context, id int64, fu
 		return nil, ErrDriverNotFound
 	}
 
-	atomic.StoreInt64(&driver.lastActiveUnixNano, time.Now().UnixNano())
+	now := time.Now()
+	atomic.StoreInt64(&driver.lastActiveUnixNano, now.UnixNano())
+	m.maybeEvictIdleInstances(now)
+
+	if quarantined, lastTrap := driver.crash.isQuarantined(); quarantined {
+		return nil, fmt.Errorf("%w: %s (last trap: %s)", ErrDriverQuarantined, driver.Name, lastTrap)
+	}
 
 	callFunction := resolvePluginCallFunction(driver, function, driverCtx)
 	if !driver.hasFunction(callFunction) {
@@ -310,10 +342,32 @@ func (m *DriverManager) executeDriverWithInput(ctx context.Context, id int64, fu
 		defer cancel()
 	}
 
-	// 调用插件函数
-	rc, output, err := callPlugin(ctx, driver, callFunction, inputJSON)
+	// 按资源取实例，不同资源上的调用可并发执行
+	var resourceID int64
+	if driverCtx != nil {
+		resourceID = driverCtx.ResourceID
+	}
+	inst, err := m.acquireInstance(driver, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	call := &hostCall{driverID: driver.ID, driverName: driver.Name, resourceID: inst.resourceID}
+	if driverCtx != nil {
+		call.deviceID, call.deviceName = driverCtx.DeviceID, driverCtx.DeviceName
+	}
+	rc, output, err := callPlugin(withCallBudget(withHostCall(ctx, call), driver.limits.maxCalls), inst.plugin, callFunction, inputJSON)
+	// 插件主动报告的错误与空输出不影响实例；trap、超限计入崩溃，超时/取消关闭了模块，均需重建实例
+	if err != nil && !errors.Is(err, ErrPluginEmptyOutput) && !errors.Is(err, ErrPluginReported) {
+		if isDriverCrash(err) {
+			m.recordDriverCrash(driver, err)
+		}
+		m.recoverInstanceLocked(driver, inst)
+	}
+	inst.mu.Unlock()
 	if err != nil {
 		switch {
+		case errors.Is(err, ErrDriverCallBudgetExhausted):
+			return nil, fmt.Errorf("%w: %v", ErrDriverCallBudgetExhausted, trapReason(err))
 		case errors.Is(err, context.DeadlineExceeded):
 			return nil, fmt.Errorf("%w: %v", ErrDriverTimeout, err)
 		case errors.Is(err, context.Canceled):
@@ -414,14 +468,23 @@ func (m *DriverManager) LoadDriverFromModel(driver *models.Driver, resourceID in
 
 // DriverRuntime 驱动运行时信息
 type DriverRuntime struct {
-	ID                int64     `json:"id"`
-	Name              string    `json:"name"`
-	Loaded            bool      `json:"loaded"`
-	ResourceID        int64     `json:"resource_id"`
-	LastActive        time.Time `json:"last_active"`
-	Version           string    `json:"version,omitempty"`
-	ProductKey        string    `json:"product_key,omitempty"`
-	ExportedFunctions []string  `json:"exported_functions,omitempty"`
+	ID                int64           `json:"id"`
+	Name              string          `json:"name"`
+	Loaded            bool            `json:"loaded"`
+	ResourceID        int64           `json:"resource_id"`
+	LastActive        time.Time       `json:"last_active"`
+	Version           string          `json:"version,omitempty"`
+	ProductKey        string          `json:"product_key,omitempty"`
+	ExportedFunctions []string        `json:"exported_functions,omitempty"`
+	InstanceResources []int64         `json:"instance_resources,omitempty"` // 已创建实例的资源ID
+	Manifest          *DriverManifest `json:"manifest,omitempty"`           // 驱动清单（配置 Schema 与点位目录）
+	MaxMemoryPages    uint32          `json:"max_memory_pages,omitempty"`   // 实例线性内存页上限
+	MaxCallBudget     int64           `json:"max_call_budget,omitempty"`    // 单次调用的函数调用预算
+	CrashCount        int64           `json:"crash_count"`                  // 累计崩溃（trap/超限）次数
+	LastTrap          string          `json:"last_trap,omitempty"`
+	LastTrapAt        *time.Time      `json:"last_trap_at,omitempty"`
+	Quarantined       bool            `json:"quarantined"` // 已隔离，重载驱动后解除
+	QuarantinedAt     *time.Time      `json:"quarantined_at,omitempty"`
 }
 
 func buildDriverRuntime(driver *WasmDriver) *DriverRuntime {
@@ -438,6 +501,10 @@ func buildDriverRuntime(driver *WasmDriver) *DriverRuntime {
 		Version:    driver.version,
 		ProductKey: driver.productKey,
 	}
+	runtime.InstanceResources = driver.instanceResources()
+	runtime.Manifest = driver.manifest
+	runtime.MaxMemoryPages, runtime.MaxCallBudget = driver.limits.maxMemoryPages, driver.limits.maxCalls
+	driver.crash.fillRuntime(runtime)
 	if len(driver.exportedFunctions) > 0 {
 		runtime.ExportedFunctions = append(make([]string, 0, len(driver.exportedFunctions)), driver.exportedFunctions...)
 	}
@@ -492,6 +559,17 @@ func (m *DriverManager) GetRuntime(id int64) (*DriverRuntime, error) {
 	return buildDriverRuntime(driver), nil
 }
 
+// GetDriverManifest 获取已加载驱动的清单，驱动未导出 manifest 时返回 nil
+func (m *DriverManager) GetDriverManifest(id int64) (*DriverManifest, error) {
+	m.mu.RLock()
+	driver, exists := m.drivers[id]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, ErrDriverNotLoaded
+	}
+	return driver.manifest, nil
+}
+
 // ListRuntimes 获取所有已加载驱动运行态
 func (m *DriverManager) ListRuntimes() []*DriverRuntime {
 	m.mu.RLock()
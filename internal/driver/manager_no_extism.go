@@ -29,11 +29,19 @@ var ErrDriverCanceled = errors.New("driver execution canceled")
 // ErrDriverBadOutput 驱动输出无效
 var ErrDriverBadOutput = errors.New("driver output invalid")
 
+// ErrDriverCallBudgetExhausted 驱动单次调用的函数调用预算耗尽
+var ErrDriverCallBudgetExhausted = errors.New("driver call budget exhausted")
+
+// ErrDriverQuarantined 驱动因反复崩溃被隔离
+var ErrDriverQuarantined = errors.New("driver quarantined")
+
 // DriverResult 驱动执行结果
 type DriverResult struct {
 	Success       bool              `json:"success"`
 	Data          map[string]string `json:"data"`
 	Points        []DriverPoint     `json:"points"`
+	Writes        []WriteItemResult `json:"writes,omitempty"`
+	FailedPoints  []string          `json:"failed_points,omitempty"`
 	ProductKey    string            `json:"productKey,omitempty"`
 	ProductKeyAlt string            `json:"product_key,omitempty"`
 	Error         string            `json:"error"`
@@ -80,8 +88,12 @@ func NewDriverManager() *DriverManager {
 	return &DriverManager{drivers: make(map[int64]*WasmDriver)}
 }
 
-func (m *DriverManager) SetExecutor(executor *DriverExecutor) { m.executor = executor }
-func (m *DriverManager) SetCallTimeout(timeout time.Duration) { m.callTimeout = timeout }
+func (m *DriverManager) SetExecutor(executor *DriverExecutor)   { m.executor = executor }
+func (m *DriverManager) SetCallTimeout(timeout time.Duration)   { m.callTimeout = timeout }
+func (m *DriverManager) SetInstanceIdleTimeout(time.Duration)   {}
+func (m *DriverManager) SetSandboxLimits(int, int)              {}
+func (m *DriverManager) SetQuarantinePolicy(int, time.Duration) {}
+func (m *DriverManager) releaseResourceInstances(int64)         {}
 
 func (m *DriverManager) LoadDriver(driver *models.Driver, wasmData []byte, resourceID int64) error {
 	if driver == nil {
@@ -166,12 +178,21 @@ func (m *DriverManager) LoadDriverFromModel(driver *models.Driver, resourceID in
 
 // DriverRuntime 驱动运行时信息
 type DriverRuntime struct {
-	ID                int64     `json:"id"`
-	Name              string    `json:"name"`
-	Loaded            bool      `json:"loaded"`
-	ResourceID        int64     `json:"resource_id"`
-	LastActive        time.Time `json:"last_active"`
-	ExportedFunctions []string  `json:"exported_functions,omitempty"`
+	ID                int64           `json:"id"`
+	Name              string          `json:"name"`
+	Loaded            bool            `json:"loaded"`
+	ResourceID        int64           `json:"resource_id"`
+	LastActive        time.Time       `json:"last_active"`
+	ExportedFunctions []string        `json:"exported_functions,omitempty"`
+	InstanceResources []int64         `json:"instance_resources,omitempty"`
+	Manifest          *DriverManifest `json:"manifest,omitempty"`
+	MaxMemoryPages    uint32          `json:"max_memory_pages,omitempty"`
+	MaxCallBudget     int64           `json:"max_call_budget,omitempty"`
+	CrashCount        int64           `json:"crash_count"`
+	LastTrap          string          `json:"last_trap,omitempty"`
+	LastTrapAt        *time.Time      `json:"last_trap_at,omitempty"`
+	Quarantined       bool            `json:"quarantined"`
+	QuarantinedAt     *time.Time      `json:"quarantined_at,omitempty"`
 }
 
 func buildDriverRuntime(driver *WasmDriver) *DriverRuntime {
@@ -195,6 +216,13 @@ func (m *DriverManager) GetRuntime(id int64) (*DriverRuntime, error) {
 	return buildDriverRuntime(d), nil
 }
 
+func (m *DriverManager) GetDriverManifest(id int64) (*DriverManifest, error) {
+	if _, ok := m.drivers[id]; !ok {
+		return nil, ErrDriverNotLoaded
+	}
+	return nil, nil
+}
+
 func (m *DriverManager) ListRuntimes() []*DriverRuntime {
 	runtimes := make([]*DriverRuntime, 0, len(m.drivers))
 	for _, d := range m.drivers {
@@ -218,3 +246,10 @@ func ExtractDriverMetadata(wasmData []byte) (string, string, error) {
 	}
 	return "", "", nil
 }
+
+func ExtractDriverManifest(wasmData []byte) (*DriverManifest, error) {
+	if len(wasmData) == 0 {
+		return nil, fmt.Errorf("empty wasm data")
+	}
+	return nil, nil
+}
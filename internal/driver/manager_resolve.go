@@ -190,7 +190,7 @@ func buildDriverContext(device *models.Device, resourceID int64, resourceType st
 		ResourceID:   resourceID,
 		ResourceType: resourceType,
 		Config:       deviceConfig,
-		DeviceConfig: "",
+		DeviceConfig: device.DeviceConfig,
 	}
 }
 
@@ -201,6 +201,8 @@ type PreparedExecution struct {
 	Config        map[string]string
 	DriverContext *DriverContext
 	InputJSON     []byte
+	// ModbusPlan 内置 Modbus 点表驱动的读取计划，非点表设备为 nil
+	ModbusPlan *ModbusReadPlan
 }
 
 func NewPreparedExecution(device *models.Device) *PreparedExecution {
@@ -212,13 +214,18 @@ func NewPreparedExecution(device *models.Device) *PreparedExecution {
 	deviceConfig := buildDeviceConfigForResourceType(device, resourceType)
 	driverCtx := buildDriverContext(device, resourceID, resourceType, deviceConfig)
 	inputJSON, _ := marshalDriverInvocationInput(driverCtx)
-	return &PreparedExecution{
+	prepared := &PreparedExecution{
 		ResourceID:    resourceID,
 		ResourceType:  resourceType,
 		Config:        deviceConfig,
 		DriverContext: driverCtx,
 		InputJSON:     inputJSON,
 	}
+	if IsNativeModbusDevice(device) {
+		// 点表无效时留空，执行时再解析并返回具体错误
+		prepared.ModbusPlan, _ = newNativeModbusPlan(device)
+	}
+	return prepared
 }
 
 func (e *DriverExecutor) startExecution(device *models.Device) (func(), error) {
@@ -345,13 +352,9 @@ func (e *DriverExecutor) tcpReadTimeout() time.Duration {
 func (e *DriverExecutor) ensureDriverLoaded(device *models.Device, resourceID int64) error {
 	driverID := *device.DriverID
 
+	// 已加载的驱动按资源懒创建实例，无需因资源不同而重载
 	if e.manager.IsLoaded(driverID) {
-		if loaded, err := e.manager.GetDriver(driverID); err == nil && loaded != nil {
-			if resourceID <= 0 || loaded.resourceID == resourceID {
-				return nil
-			}
-			slog.Warn("Reloading driver with new resource", "driver_id", loaded.ID, "old_resource_id", loaded.resourceID, "new_resource_id", resourceID)
-		}
+		return nil
 	}
 
 	drv, err := database.LoadDriver(driverID)
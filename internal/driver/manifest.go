@@ -0,0 +1,428 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// 驱动点位读写属性
+const (
+	PointAccessRead      = "R"
+	PointAccessWrite     = "W"
+	PointAccessReadWrite = "RW"
+)
+
+// DriverManifest 驱动自描述清单，由驱动导出的 manifest 函数返回
+type DriverManifest struct {
+	Version       string          `json:"version,omitempty"`
+	ProductKey    string          `json:"product_key,omitempty"`
+	ResourceTypes []string        `json:"resource_types,omitempty"` // 支持的资源类型：serial / net
+	ConfigSchema  json.RawMessage `json:"config_schema,omitempty"`  // device_config 的 JSON Schema
+	Points        []ManifestPoint `json:"points,omitempty"`
+	BatchWrite    bool            `json:"batch_write,omitempty"` // 驱动一次调用处理 config.writes 中的全部字段
+
+	schema *configSchema
+}
+
+// ManifestPoint 驱动点位目录项
+type ManifestPoint struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Unit        string   `json:"unit,omitempty"`
+	RW          string   `json:"rw"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Writable 点位是否可写
+func (p ManifestPoint) Writable() bool {
+	return strings.Contains(p.RW, PointAccessWrite)
+}
+
+// WritablePoints 返回可写点位
+func (m *DriverManifest) WritablePoints() []ManifestPoint {
+	if m == nil {
+		return nil
+	}
+	points := make([]ManifestPoint, 0, len(m.Points))
+	for _, p := range m.Points {
+		if p.Writable() {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// SupportsResourceType 未声明资源类型时视为全部支持
+func (m *DriverManifest) SupportsResourceType(resourceType string) bool {
+	if m == nil || len(m.ResourceTypes) == 0 {
+		return true
+	}
+	return slices.Contains(m.ResourceTypes, strings.ToLower(strings.TrimSpace(resourceType)))
+}
+
+// ValidateDeviceConfig 按清单中的 config_schema 校验设备配置，空配置按 {} 校验
+func (m *DriverManifest) ValidateDeviceConfig(deviceConfig string) error {
+	if m == nil || m.schema == nil {
+		return nil
+	}
+	raw := strings.TrimSpace(deviceConfig)
+	if raw == "" {
+		raw = "{}"
+	}
+	value, err := decodeJSONValue([]byte(raw))
+	if err != nil {
+		return fmt.Errorf("device_config is not valid JSON: %w", err)
+	}
+	return m.schema.validate("device_config", value)
+}
+
+// ParseDriverManifest 解析 manifest 输出，兼容 {"success":true,"data":{...}} 包装
+func ParseDriverManifest(output []byte) (*DriverManifest, error) {
+	var envelope struct {
+		Success *bool           `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(output, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if envelope.Success != nil {
+		if !*envelope.Success {
+			if envelope.Error != "" {
+				return nil, fmt.Errorf("manifest error: %s", envelope.Error)
+			}
+			return nil, fmt.Errorf("manifest response not success")
+		}
+		if len(envelope.Data) > 0 {
+			output = envelope.Data
+		}
+	}
+
+	var payload struct {
+		DriverManifest
+		ProductKeyAlt string `json:"productKey"`
+	}
+	if err := json.Unmarshal(output, &payload); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	manifest := payload.DriverManifest
+	if manifest.ProductKey == "" {
+		manifest.ProductKey = payload.ProductKeyAlt
+	}
+	if err := manifest.normalize(); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func (m *DriverManifest) normalize() error {
+	m.Version = strings.TrimSpace(m.Version)
+	m.ProductKey = strings.TrimSpace(m.ProductKey)
+
+	resourceTypes := make([]string, 0, len(m.ResourceTypes))
+	for _, t := range m.ResourceTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !slices.Contains(resourceTypes, t) {
+			resourceTypes = append(resourceTypes, t)
+		}
+	}
+	m.ResourceTypes = resourceTypes
+
+	seen := make(map[string]struct{}, len(m.Points))
+	for i := range m.Points {
+		p := &m.Points[i]
+		p.Name = strings.TrimSpace(p.Name)
+		p.Type = strings.ToLower(strings.TrimSpace(p.Type))
+		p.RW = strings.ToUpper(strings.TrimSpace(p.RW))
+		if p.Name == "" {
+			return fmt.Errorf("manifest points[%d]: name is required", i)
+		}
+		if _, dup := seen[p.Name]; dup {
+			return fmt.Errorf("manifest points[%d]: duplicate name %q", i, p.Name)
+		}
+		seen[p.Name] = struct{}{}
+		switch p.RW {
+		case "":
+			p.RW = PointAccessRead
+		case PointAccessRead, PointAccessWrite, PointAccessReadWrite:
+		case "WR":
+			p.RW = PointAccessReadWrite
+		default:
+			return fmt.Errorf("manifest points[%d]: invalid rw %q", i, p.RW)
+		}
+		if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+			return fmt.Errorf("manifest points[%d]: min greater than max", i)
+		}
+	}
+
+	m.ConfigSchema = bytes.TrimSpace(m.ConfigSchema)
+	if len(m.ConfigSchema) == 0 || bytes.Equal(m.ConfigSchema, []byte("null")) {
+		m.ConfigSchema = nil
+		return nil
+	}
+	schema, err := compileConfigSchema(m.ConfigSchema)
+	if err != nil {
+		return fmt.Errorf("manifest config_schema: %w", err)
+	}
+	m.schema = schema
+	return nil
+}
+
+// configSchema JSON Schema 子集：type / properties / required / additionalProperties / items /
+// enum / minimum / maximum / minLength / maxLength / pattern / minItems / maxItems
+type configSchema struct {
+	Types                []string
+	Properties           map[string]*configSchema
+	Required             []string
+	AdditionalProperties *bool
+	AdditionalSchema     *configSchema
+	Items                *configSchema
+	Enum                 []any
+	Minimum              *float64
+	Maximum              *float64
+	MinLength            *int
+	MaxLength            *int
+	MinItems             *int
+	MaxItems             *int
+	Pattern              *regexp.Regexp
+}
+
+type rawConfigSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	Enum                 json.RawMessage            `json:"enum"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	Pattern              string                     `json:"pattern"`
+}
+
+var schemaTypeNames = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+func compileConfigSchema(data json.RawMessage) (*configSchema, error) {
+	var raw rawConfigSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	schema := &configSchema{
+		Required:  raw.Required,
+		Minimum:   raw.Minimum,
+		Maximum:   raw.Maximum,
+		MinLength: raw.MinLength,
+		MaxLength: raw.MaxLength,
+		MinItems:  raw.MinItems,
+		MaxItems:  raw.MaxItems,
+	}
+
+	if len(raw.Type) > 0 {
+		var single string
+		if err := json.Unmarshal(raw.Type, &single); err == nil {
+			schema.Types = []string{single}
+		} else if err := json.Unmarshal(raw.Type, &schema.Types); err != nil {
+			return nil, fmt.Errorf("invalid type")
+		}
+		for _, t := range schema.Types {
+			if !slices.Contains(schemaTypeNames, t) {
+				return nil, fmt.Errorf("unsupported type %q", t)
+			}
+		}
+	}
+	if len(raw.Properties) > 0 {
+		schema.Properties = make(map[string]*configSchema, len(raw.Properties))
+		for name, sub := range raw.Properties {
+			child, err := compileConfigSchema(sub)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %w", name, err)
+			}
+			schema.Properties[name] = child
+		}
+	}
+	if len(raw.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(raw.AdditionalProperties, &allowed); err == nil {
+			schema.AdditionalProperties = &allowed
+		} else {
+			child, err := compileConfigSchema(raw.AdditionalProperties)
+			if err != nil {
+				return nil, fmt.Errorf("additionalProperties: %w", err)
+			}
+			schema.AdditionalSchema = child
+		}
+	}
+	if len(raw.Items) > 0 {
+		child, err := compileConfigSchema(raw.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		schema.Items = child
+	}
+	if len(raw.Enum) > 0 {
+		value, err := decodeJSONValue(raw.Enum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum: %w", err)
+		}
+		values, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("enum must be an array")
+		}
+		schema.Enum = values
+	}
+	if raw.Pattern != "" {
+		re, err := regexp.Compile(raw.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		schema.Pattern = re
+	}
+	return schema, nil
+}
+
+func (s *configSchema) validate(path string, value any) error {
+	if len(s.Types) > 0 && !slices.ContainsFunc(s.Types, func(t string) bool { return schemaTypeMatches(t, value) }) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Types, "|"), schemaValueType(value))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(v any) bool { return schemaValueEqual(v, value) }) {
+		return fmt.Errorf("%s: value not in enum", path)
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := v.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			return fmt.Errorf("%s: must be >= %v", path, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return fmt.Errorf("%s: must be <= %v", path, *s.Maximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: length must be >= %d", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: length must be <= %d", path, *s.MaxLength)
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(v) {
+			return fmt.Errorf("%s: does not match pattern %s", path, s.Pattern.String())
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: must have >= %d items", path, *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s: must have <= %d items", path, *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s.%s: is required", path, name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			childPath := path + "." + key
+			if child, ok := s.Properties[key]; ok {
+				if err := child.validate(childPath, v[key]); err != nil {
+					return err
+				}
+				continue
+			}
+			if s.AdditionalSchema != nil {
+				if err := s.AdditionalSchema.validate(childPath, v[key]); err != nil {
+					return err
+				}
+				continue
+			}
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("%s: unknown property", childPath)
+			}
+		}
+	}
+	return nil
+}
+
+func decodeJSONValue(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected trailing data")
+	}
+	return value, nil
+}
+
+func schemaTypeMatches(t string, value any) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return schemaValueType(value) == t
+	}
+}
+
+func schemaValueType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func schemaValueEqual(a, b any) bool {
+	na, okA := a.(json.Number)
+	nb, okB := b.(json.Number)
+	if okA && okB {
+		fa, errA := na.Float64()
+		fb, errB := nb.Float64()
+		return errA == nil && errB == nil && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
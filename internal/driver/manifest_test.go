@@ -0,0 +1,129 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+const testManifestOutput = `{
+	"success": true,
+	"data": {
+		"version": " 1.2.0 ",
+		"productKey": "pk-meter",
+		"resource_types": ["Serial", "net", "serial"],
+		"config_schema": {
+			"type": "object",
+			"required": ["slave_id"],
+			"additionalProperties": false,
+			"properties": {
+				"slave_id": {"type": "integer", "minimum": 1, "maximum": 247},
+				"mode": {"type": "string", "enum": ["fast", "slow"]},
+				"tag": {"type": "string", "pattern": "^[a-z]+$", "maxLength": 8},
+				"channels": {"type": "array", "items": {"type": "number"}, "maxItems": 2}
+			}
+		},
+		"points": [
+			{"name": "Ua", "type": "Float", "unit": "V", "rw": "r"},
+			{"name": "setpoint", "type": "float", "rw": "rw", "min": 0, "max": 100},
+			{"name": "reset", "type": "bool", "rw": "W"}
+		],
+		"batch_write": true
+	}
+}`
+
+func TestParseDriverManifest(t *testing.T) {
+	manifest, err := ParseDriverManifest([]byte(testManifestOutput))
+	if err != nil {
+		t.Fatalf("ParseDriverManifest: %v", err)
+	}
+	if manifest.Version != "1.2.0" || manifest.ProductKey != "pk-meter" {
+		t.Fatalf("version/product_key = %q/%q", manifest.Version, manifest.ProductKey)
+	}
+	if strings.Join(manifest.ResourceTypes, ",") != "serial,net" {
+		t.Fatalf("resource_types = %v", manifest.ResourceTypes)
+	}
+	if !manifest.BatchWrite {
+		t.Fatal("batch_write not parsed")
+	}
+	if !manifest.SupportsResourceType("NET") || manifest.SupportsResourceType("di") {
+		t.Fatal("unexpected resource type support")
+	}
+	if manifest.Points[0].Type != "float" || manifest.Points[0].RW != PointAccessRead {
+		t.Fatalf("point not normalized: %+v", manifest.Points[0])
+	}
+
+	writable := manifest.WritablePoints()
+	if len(writable) != 2 || writable[0].Name != "setpoint" || writable[1].Name != "reset" {
+		t.Fatalf("writable points = %+v", writable)
+	}
+}
+
+func TestParseDriverManifest_BareObject(t *testing.T) {
+	manifest, err := ParseDriverManifest([]byte(`{"version":"0.1","points":[{"name":"t"}]}`))
+	if err != nil {
+		t.Fatalf("ParseDriverManifest: %v", err)
+	}
+	if manifest.Version != "0.1" || manifest.Points[0].RW != PointAccessRead || manifest.BatchWrite {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	if err := manifest.ValidateDeviceConfig(`{"anything":1}`); err != nil {
+		t.Fatalf("manifest without schema should accept any config: %v", err)
+	}
+}
+
+func TestParseDriverManifest_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"not success":     `{"success":false,"error":"boom"}`,
+		"empty name":      `{"points":[{"name":" "}]}`,
+		"duplicate name":  `{"points":[{"name":"a"},{"name":"a"}]}`,
+		"bad rw":          `{"points":[{"name":"a","rw":"X"}]}`,
+		"min above max":   `{"points":[{"name":"a","min":5,"max":1}]}`,
+		"bad schema type": `{"config_schema":{"type":"map"}}`,
+		"bad pattern":     `{"config_schema":{"type":"string","pattern":"("}}`,
+		"invalid json":    `{`,
+	}
+	for name, output := range cases {
+		if _, err := ParseDriverManifest([]byte(output)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDriverManifest_ValidateDeviceConfig(t *testing.T) {
+	manifest, err := ParseDriverManifest([]byte(testManifestOutput))
+	if err != nil {
+		t.Fatalf("ParseDriverManifest: %v", err)
+	}
+
+	valid := []string{
+		`{"slave_id": 1}`,
+		`{"slave_id": 247, "mode": "slow", "tag": "abc", "channels": [1, 2.5]}`,
+		`{"slave_id": 3.0}`,
+	}
+	for _, cfg := range valid {
+		if err := manifest.ValidateDeviceConfig(cfg); err != nil {
+			t.Errorf("ValidateDeviceConfig(%s): %v", cfg, err)
+		}
+	}
+
+	invalid := map[string]string{
+		``:                                       "slave_id: is required",
+		`{"slave_id": 0}`:                        "must be >= 1",
+		`{"slave_id": 1.5}`:                      "expected integer",
+		`{"slave_id": "1"}`:                      "expected integer",
+		`{"slave_id": 1, "mode": "medium"}`:      "not in enum",
+		`{"slave_id": 1, "tag": "ABC"}`:          "does not match pattern",
+		`{"slave_id": 1, "tag": "abcdefghi"}`:    "length must be <= 8",
+		`{"slave_id": 1, "channels": [1, "x"]}`:  "channels[1]",
+		`{"slave_id": 1, "channels": [1, 2, 3]}`: "must have <= 2 items",
+		`{"slave_id": 1, "extra": true}`:         "device_config.extra: unknown property",
+		`[1]`:                                    "expected object",
+		`{"slave_id": 1} {}`:                     "not valid JSON",
+	}
+	for cfg, want := range invalid {
+		err := manifest.ValidateDeviceConfig(cfg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateDeviceConfig(%s) = %v, want error containing %q", cfg, err, want)
+		}
+	}
+}
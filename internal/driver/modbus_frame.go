@@ -0,0 +1,127 @@
+package driver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// modbusCRC16 计算 Modbus RTU CRC16（低字节在前）
+func modbusCRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+func buildModbusRTUFrame(unitID byte, pdu []byte) []byte {
+	frame := make([]byte, 0, len(pdu)+3)
+	frame = append(frame, unitID)
+	frame = append(frame, pdu...)
+	crc := modbusCRC16(frame)
+	return append(frame, byte(crc), byte(crc>>8))
+}
+
+func buildModbusTCPFrame(transactionID uint16, unitID byte, pdu []byte) []byte {
+	frame := make([]byte, 7+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:2], transactionID)
+	binary.BigEndian.PutUint16(frame[4:6], uint16(len(pdu)+1))
+	frame[6] = unitID
+	copy(frame[7:], pdu)
+	return frame
+}
+
+func buildModbusReadPDU(function byte, start, quantity uint16) []byte {
+	pdu := make([]byte, 5)
+	pdu[0] = function
+	binary.BigEndian.PutUint16(pdu[1:3], start)
+	binary.BigEndian.PutUint16(pdu[3:5], quantity)
+	return pdu
+}
+
+// readModbusRTUResponse 按功能码推算 RTU 应答长度并读取完整帧，返回去掉地址与 CRC 的 PDU
+func readModbusRTUResponse(port SerialPort, unitID byte, timeout time.Duration) ([]byte, error) {
+	buf := getModbusFrameBuffer(pooledModbusFrameSize)
+	defer putModbusFrameBuffer(buf)
+
+	if _, err := readWithTimeout(port, buf, 3, timeout); err != nil {
+		return nil, err
+	}
+	var total int
+	switch fn := buf[1]; {
+	case fn&0x80 != 0:
+		total = 5
+	case fn == modbusFuncReadCoils, fn == modbusFuncReadDiscreteInputs,
+		fn == modbusFuncReadHoldingRegisters, fn == modbusFuncReadInputRegisters:
+		total = 5 + int(buf[2])
+	default:
+		total = 8
+	}
+	if total > len(buf) {
+		return nil, io.ErrShortBuffer
+	}
+	if total > 3 {
+		if n, err := readWithTimeout(port, buf[3:], total-3, timeout); err != nil {
+			return nil, fmt.Errorf("read modbus rtu frame failed after %d bytes: %w", 3+n, err)
+		}
+	}
+	frame := buf[:total]
+	if crc := modbusCRC16(frame[:total-2]); byte(crc) != frame[total-2] || byte(crc>>8) != frame[total-1] {
+		return nil, fmt.Errorf("modbus rtu crc mismatch")
+	}
+	if frame[0] != unitID {
+		return nil, fmt.Errorf("modbus rtu unit mismatch: want %d got %d", unitID, frame[0])
+	}
+	pdu := make([]byte, total-3)
+	copy(pdu, frame[1:total-2])
+	return pdu, nil
+}
+
+func readModbusTCPResponse(conn io.Reader, buf []byte) (int, error) {
+	if len(buf) < 9 {
+		return 0, io.ErrShortBuffer
+	}
+
+	if _, err := io.ReadFull(conn, buf[:7]); err != nil {
+		return 0, err
+	}
+
+	length := int(binary.BigEndian.Uint16(buf[4:6]))
+	if length < 2 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	total := 6 + length
+	if total > len(buf) {
+		return 0, io.ErrShortBuffer
+	}
+
+	if _, err := io.ReadFull(conn, buf[7:total]); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// checkModbusResponsePDU 校验应答功能码与异常码
+func checkModbusResponsePDU(function byte, pdu []byte) error {
+	if len(pdu) < 2 {
+		return fmt.Errorf("modbus response too short")
+	}
+	if pdu[0] == function|0x80 {
+		return fmt.Errorf("modbus exception: function=%d code=%d", function, pdu[1])
+	}
+	if pdu[0] != function {
+		return fmt.Errorf("modbus function mismatch: want %d got %d", function, pdu[0])
+	}
+	return nil
+}
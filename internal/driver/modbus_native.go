@@ -0,0 +1,367 @@
+package driver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+var modbusTCPTransactionID atomic.Uint32
+
+// modbusTransport 抽象 RTU/TCP 的一次请求应答，收发的都是 PDU
+type modbusTransport interface {
+	roundTrip(unitID byte, pdu []byte) ([]byte, error)
+}
+
+type modbusRTUTransport struct {
+	port    SerialPort
+	timeout time.Duration
+}
+
+func (t *modbusRTUTransport) roundTrip(unitID byte, pdu []byte) ([]byte, error) {
+	if resetIn, ok := t.port.(interface{ ResetInputBuffer() error }); ok {
+		_ = resetIn.ResetInputBuffer()
+	}
+	if _, err := t.port.Write(buildModbusRTUFrame(unitID, pdu)); err != nil {
+		return nil, err
+	}
+	return readModbusRTUResponse(t.port, unitID, t.timeout)
+}
+
+type modbusTCPTransport struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (t *modbusTCPTransport) roundTrip(unitID byte, pdu []byte) ([]byte, error) {
+	transactionID := uint16(modbusTCPTransactionID.Add(1))
+	_ = t.conn.SetDeadline(time.Now().Add(t.timeout))
+	if _, err := t.conn.Write(buildModbusTCPFrame(transactionID, unitID, pdu)); err != nil {
+		return nil, err
+	}
+	buf := getModbusFrameBuffer(pooledModbusFrameSize)
+	defer putModbusFrameBuffer(buf)
+	n, err := readModbusTCPResponse(t.conn, buf)
+	if err != nil {
+		return nil, err
+	}
+	if got := binary.BigEndian.Uint16(buf[0:2]); got != transactionID {
+		return nil, fmt.Errorf("modbus tcp transaction mismatch: want %d got %d", transactionID, got)
+	}
+	out := make([]byte, n-7)
+	copy(out, buf[7:n])
+	return out, nil
+}
+
+// IsNativeModbusDevice 判断设备是否走内置 Modbus 点表驱动
+// 仅 driver_type 为 modbus_rtu/modbus_tcp 且配置了点表时生效，未配置点表的设备仍走 WASM 驱动。
+func IsNativeModbusDevice(device *models.Device) bool {
+	if device == nil || strings.TrimSpace(device.PointTable) == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(device.DriverType)) {
+	case "modbus_rtu", "modbus_tcp":
+		return true
+	default:
+		return false
+	}
+}
+
+// NativeModbusDevicePoints 返回内置 Modbus 设备点表中的测点定义，非内置 Modbus 设备或点表无效时返回 nil
+func NativeModbusDevicePoints(device *models.Device) []models.DevicePoint {
+	if !IsNativeModbusDevice(device) {
+		return nil
+	}
+	table, err := ParseModbusPointTable(device.PointTable)
+	if err != nil {
+		return nil
+	}
+	points := make([]models.DevicePoint, 0, len(table.Points))
+	for _, point := range table.Points {
+		points = append(points, models.DevicePoint{Name: point.Name, RW: point.RW, DataType: point.DataType})
+	}
+	return points
+}
+
+func newNativeModbusPlan(device *models.Device) (*ModbusReadPlan, error) {
+	table, err := ParseModbusPointTable(device.PointTable)
+	if err != nil {
+		return nil, err
+	}
+	return NewModbusReadPlan(table), nil
+}
+
+// parseModbusUnitID 解析设备地址为从站号；未填写时默认 1，无法解析或超出 0-255 时报错
+func parseModbusUnitID(address string) (byte, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return 1, nil
+	}
+	id, err := strconv.Atoi(address)
+	if err != nil || id < 0 || id > 255 {
+		return 0, fmt.Errorf("invalid modbus unit id %q: must be 0-255", address)
+	}
+	return byte(id), nil
+}
+
+// ValidateNativeModbusDevice 校验内置 Modbus 设备的点表与从站地址，非内置 Modbus 设备直接通过
+func ValidateNativeModbusDevice(device *models.Device) error {
+	if !IsNativeModbusDevice(device) {
+		return nil
+	}
+	if _, err := ParseModbusPointTable(device.PointTable); err != nil {
+		return err
+	}
+	_, err := parseModbusUnitID(device.DeviceAddress)
+	return err
+}
+
+func (e *DriverExecutor) executeNativeModbus(ctx context.Context, device *models.Device, prepared *PreparedExecution, overrides map[string]string) (*DriverResult, error) {
+	done, err := e.startExecution(device)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	prepared = normalizePreparedExecution(device, prepared)
+	plan := prepared.ModbusPlan
+	if plan == nil {
+		if plan, err = newNativeModbusPlan(device); err != nil {
+			return nil, fmt.Errorf("device %s: %w", device.Name, err)
+		}
+	}
+
+	resourceID := prepared.ResourceID
+	resourceType := prepared.ResourceType
+	if resourceID <= 0 {
+		return nil, fmt.Errorf("device %s has no resource", device.Name)
+	}
+	e.ensureResourcePath(resourceID, resourceType, device)
+
+	unlock := e.lockResource(resourceID)
+	if unlock != nil {
+		defer unlock()
+	}
+
+	transport, err := e.nativeModbusTransport(device, resourceID, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	unitID, err := parseModbusUnitID(device.DeviceAddress)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %w", device.Name, err)
+	}
+	var result *DriverResult
+	if strings.EqualFold(strings.TrimSpace(overrides["func_name"]), "write") {
+		if raw := overrides[WriteBatchConfigKey]; raw != "" {
+			result, err = writeNativeModbusPoints(transport, unitID, plan, raw)
+		} else {
+			result, err = writeNativeModbusPoint(transport, unitID, plan, overrides["field_name"], overrides["value"])
+		}
+	} else {
+		result, err = readNativeModbusPoints(ctx, transport, unitID, plan)
+	}
+	if err != nil && resourceType == "net" && isModbusTransportError(err) {
+		e.UnregisterTCP(resourceID)
+	}
+	return result, err
+}
+
+func (e *DriverExecutor) nativeModbusTransport(device *models.Device, resourceID int64, resourceType string) (modbusTransport, error) {
+	timeout := time.Duration(device.Timeout) * time.Millisecond
+	if resourceType == "net" {
+		if timeout <= 0 {
+			timeout = e.tcpReadTimeout()
+		}
+		conn := e.connectTCPResource(resourceID, e.GetResourcePath(resourceID))
+		if conn == nil {
+			return nil, fmt.Errorf("tcp resource %d unavailable", resourceID)
+		}
+		return &modbusTCPTransport{conn: conn, timeout: timeout}, nil
+	}
+
+	if err := e.ensureSerialResource(resourceID, resourceType, device); err != nil {
+		return nil, err
+	}
+	port := e.GetSerialPort(resourceID)
+	if port == nil {
+		return nil, fmt.Errorf("serial resource %d unavailable", resourceID)
+	}
+	if timeout <= 0 {
+		timeout = e.serialReadTimeout()
+	}
+	return &modbusRTUTransport{port: port, timeout: timeout}, nil
+}
+
+// errModbusProtocol 标记设备已应答但内容不合法，区别于链路错误
+var errModbusProtocol = errors.New("modbus protocol error")
+
+func isModbusTransportError(err error) bool {
+	return err != nil && !errors.Is(err, errModbusProtocol)
+}
+
+// readNativeModbusPoints 逐块读取点表；部分块失败时仍返回成功读取的测点，
+// 失败块内的测点记入 FailedPoints，Error 汇总失败块数与首个错误
+func readNativeModbusPoints(ctx context.Context, transport modbusTransport, unitID byte, plan *ModbusReadPlan) (*DriverResult, error) {
+	points := make([]DriverPoint, 0, len(plan.Table.Points))
+	var failedPoints []string
+	var firstErr error
+	failedBlocks := 0
+	for _, block := range plan.Blocks {
+		if ctx != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		decoded := len(points)
+		data, err := readNativeModbusBlock(transport, unitID, block)
+		if err == nil {
+			points, err = decodeModbusBlock(plan.Table, block, data, points)
+		}
+		if err != nil {
+			slog.Warn("Modbus block read failed", "unit", unitID, "function", block.Function, "start", block.Start, "quantity", block.Quantity, "error", err)
+			// decodeModbusBlock 按块内顺序追加，出错点及其后的测点均视为失败
+			for _, idx := range block.Points[len(points)-decoded:] {
+				failedPoints = append(failedPoints, plan.Table.Points[idx].Name)
+			}
+			failedBlocks++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("function %d start %d quantity %d: %w", block.Function, block.Start, block.Quantity, err)
+			}
+		}
+	}
+	if len(points) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	result := &DriverResult{Success: true, Points: points, FailedPoints: failedPoints, Timestamp: time.Now()}
+	if firstErr != nil {
+		result.Error = fmt.Sprintf("%d of %d blocks failed: %v", failedBlocks, len(plan.Blocks), firstErr)
+	}
+	return result, nil
+}
+
+func readNativeModbusBlock(transport modbusTransport, unitID byte, block modbusReadBlock) ([]byte, error) {
+	pdu, err := transport.roundTrip(unitID, buildModbusReadPDU(block.Function, block.Start, block.Quantity))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkModbusResponsePDU(block.Function, pdu); err != nil {
+		return nil, fmt.Errorf("%w: %v", errModbusProtocol, err)
+	}
+	byteCount := int(pdu[1])
+	if len(pdu) < 2+byteCount {
+		return nil, fmt.Errorf("%w: byte count %d exceeds payload", errModbusProtocol, byteCount)
+	}
+	return pdu[2 : 2+byteCount], nil
+}
+
+func writeNativeModbusPoint(transport modbusTransport, unitID byte, plan *ModbusReadPlan, fieldName, value string) (*DriverResult, error) {
+	point, ok := plan.LookupPoint(fieldName)
+	if !ok {
+		return &DriverResult{Success: false, Error: fmt.Sprintf("point %q not found", fieldName), Timestamp: time.Now()}, nil
+	}
+	if !strings.Contains(point.RW, "W") {
+		return &DriverResult{Success: false, Error: fmt.Sprintf("point %q is read-only", point.Name), Timestamp: time.Now()}, nil
+	}
+
+	pdu, err := buildModbusWritePDU(*point, value)
+	if err != nil {
+		return &DriverResult{Success: false, Error: err.Error(), Timestamp: time.Now()}, nil
+	}
+	resp, err := transport.roundTrip(unitID, pdu)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkModbusResponsePDU(pdu[0], resp); err != nil {
+		return &DriverResult{Success: false, Error: err.Error(), Timestamp: time.Now()}, nil
+	}
+	return &DriverResult{
+		Success:   true,
+		Points:    []DriverPoint{{FieldName: point.Name, Value: strings.TrimSpace(value), RW: point.RW}},
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// writeNativeModbusPoints 逐点写入并逐字段回报；链路出错后剩余字段不再尝试
+func writeNativeModbusPoints(transport modbusTransport, unitID byte, plan *ModbusReadPlan, raw string) (*DriverResult, error) {
+	items, err := ParseWriteItems(raw)
+	if err != nil {
+		return &DriverResult{Success: false, Error: err.Error(), Timestamp: time.Now()}, nil
+	}
+
+	writes := make([]WriteItemResult, 0, len(items))
+	var linkErr error
+	for _, item := range items {
+		itemResult := WriteItemResult{FieldName: item.FieldName, Value: item.Value}
+		if linkErr != nil {
+			itemResult.Error = linkErr.Error()
+			writes = append(writes, itemResult)
+			continue
+		}
+		result, err := writeNativeModbusPoint(transport, unitID, plan, item.FieldName, item.Value)
+		switch {
+		case err != nil:
+			if len(writes) == 0 {
+				return nil, err
+			}
+			linkErr = err
+			itemResult.Error = err.Error()
+		case !result.Success:
+			itemResult.Error = result.Error
+		default:
+			itemResult.Success = true
+		}
+		writes = append(writes, itemResult)
+	}
+
+	out := &DriverResult{Success: true, Writes: writes, Timestamp: time.Now()}
+	for _, item := range writes {
+		if !item.Success {
+			out.Success = false
+			out.Error = item.FieldName + ": " + item.Error
+			break
+		}
+	}
+	return out, nil
+}
+
+func buildModbusWritePDU(point ModbusPoint, value string) ([]byte, error) {
+	if point.Function == modbusFuncReadCoils {
+		on, err := parseModbusBool(value)
+		if err != nil {
+			return nil, err
+		}
+		pdu := []byte{modbusFuncWriteSingleCoil, 0, 0, 0, 0}
+		binary.BigEndian.PutUint16(pdu[1:3], point.Address)
+		if on {
+			pdu[3] = 0xFF
+		}
+		return pdu, nil
+	}
+
+	payload, err := EncodeModbusValue(point, value)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) == 2 {
+		pdu := []byte{modbusFuncWriteSingleRegister, 0, 0, payload[0], payload[1]}
+		binary.BigEndian.PutUint16(pdu[1:3], point.Address)
+		return pdu, nil
+	}
+	pdu := make([]byte, 6, 6+len(payload))
+	pdu[0] = modbusFuncWriteMultipleRegs
+	binary.BigEndian.PutUint16(pdu[1:3], point.Address)
+	binary.BigEndian.PutUint16(pdu[3:5], uint16(len(payload)/2))
+	pdu[5] = byte(len(payload))
+	return append(pdu, payload...), nil
+}
@@ -0,0 +1,290 @@
+package driver
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// fakeModbusSlave 内存中的寄存器表，用于模拟从站应答
+type fakeModbusSlave struct {
+	mu        sync.Mutex
+	holding   map[uint16]uint16
+	coils     map[uint16]bool
+	requests  int
+	exception byte
+	failFunc  byte // 仅对该功能码返回非法地址异常
+}
+
+func newFakeModbusSlave() *fakeModbusSlave {
+	return &fakeModbusSlave{holding: make(map[uint16]uint16), coils: make(map[uint16]bool)}
+}
+
+func (s *fakeModbusSlave) handle(pdu []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	fn := pdu[0]
+	if s.exception != 0 {
+		return []byte{fn | 0x80, s.exception}
+	}
+	if s.failFunc != 0 && fn == s.failFunc {
+		return []byte{fn | 0x80, 0x02}
+	}
+	addr := binary.BigEndian.Uint16(pdu[1:3])
+	switch fn {
+	case modbusFuncReadHoldingRegisters, modbusFuncReadInputRegisters:
+		qty := binary.BigEndian.Uint16(pdu[3:5])
+		out := []byte{fn, byte(qty * 2)}
+		for i := uint16(0); i < qty; i++ {
+			out = binary.BigEndian.AppendUint16(out, s.holding[addr+i])
+		}
+		return out
+	case modbusFuncReadCoils:
+		qty := binary.BigEndian.Uint16(pdu[3:5])
+		data := make([]byte, (qty+7)/8)
+		for i := uint16(0); i < qty; i++ {
+			if s.coils[addr+i] {
+				data[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append([]byte{fn, byte(len(data))}, data...)
+	case modbusFuncWriteSingleRegister:
+		s.holding[addr] = binary.BigEndian.Uint16(pdu[3:5])
+		return append([]byte(nil), pdu...)
+	case modbusFuncWriteSingleCoil:
+		s.coils[addr] = pdu[3] == 0xFF
+		return append([]byte(nil), pdu...)
+	case modbusFuncWriteMultipleRegs:
+		qty := binary.BigEndian.Uint16(pdu[3:5])
+		for i := uint16(0); i < qty; i++ {
+			s.holding[addr+i] = binary.BigEndian.Uint16(pdu[6+i*2:])
+		}
+		return append([]byte(nil), pdu[:5]...)
+	}
+	return []byte{fn | 0x80, 0x01}
+}
+
+// fakeRTUPort 把写入的 RTU 帧交给从站，并把应答放入读缓冲
+type fakeRTUPort struct {
+	slave *fakeModbusSlave
+	mu    sync.Mutex
+	rx    []byte
+}
+
+func (p *fakeRTUPort) Write(frame []byte) (int, error) {
+	resp := buildModbusRTUFrame(frame[0], p.slave.handle(frame[1:len(frame)-2]))
+	p.mu.Lock()
+	p.rx = append(p.rx, resp...)
+	p.mu.Unlock()
+	return len(frame), nil
+}
+
+func (p *fakeRTUPort) Read(buf []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := copy(buf, p.rx)
+	p.rx = p.rx[n:]
+	return n, nil
+}
+
+func (p *fakeRTUPort) Close() error { return nil }
+
+func serveFakeModbusTCP(t *testing.T, conn net.Conn, slave *fakeModbusSlave) {
+	t.Helper()
+	go func() {
+		defer conn.Close()
+		header := make([]byte, 7)
+		for {
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			pdu := make([]byte, int(binary.BigEndian.Uint16(header[4:6]))-1)
+			if _, err := io.ReadFull(conn, pdu); err != nil {
+				return
+			}
+			resp := slave.handle(pdu)
+			if _, err := conn.Write(buildModbusTCPFrame(binary.BigEndian.Uint16(header[0:2]), header[6], resp)); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+const testModbusPointTable = `[
+	{"name":"Ua","address":0,"data_type":"uint16","scale":0.1,"unit":"V"},
+	{"name":"Ia","address":1,"data_type":"int16","scale":0.01,"unit":"A"},
+	{"name":"setpoint","address":2,"data_type":"float32","rw":"RW"},
+	{"name":"relay","address":0,"function":1,"rw":"RW"}
+]`
+
+func newNativeModbusTestDevice(driverType, resourceType string, resourceID int64) *models.Device {
+	return &models.Device{
+		ID:            1,
+		Name:          "meter",
+		DriverType:    driverType,
+		DeviceAddress: "3",
+		ResourceID:    &resourceID,
+		ResourceType:  resourceType,
+		PointTable:    testModbusPointTable,
+		Timeout:       200,
+	}
+}
+
+func TestIsNativeModbusDevice(t *testing.T) {
+	if IsNativeModbusDevice(&models.Device{DriverType: "modbus_rtu"}) {
+		t.Fatal("device without point table should use wasm driver")
+	}
+	if IsNativeModbusDevice(&models.Device{DriverType: "custom", PointTable: "[]"}) {
+		t.Fatal("non-modbus driver type should not be native")
+	}
+	if !IsNativeModbusDevice(&models.Device{DriverType: " Modbus_TCP ", PointTable: "[]"}) {
+		t.Fatal("modbus_tcp with point table should be native")
+	}
+}
+
+func TestExecuteNativeModbusRTU_ReadBatchesAndWrites(t *testing.T) {
+	slave := newFakeModbusSlave()
+	slave.holding[0] = 2205
+	slave.holding[1] = uint16(0xFFFF - 149) // -1.50A
+	slave.coils[0] = true
+
+	executor := NewDriverExecutor(NewDriverManager())
+	executor.RegisterSerialPort(5, &fakeRTUPort{slave: slave})
+	device := newNativeModbusTestDevice("modbus_rtu", "serial", 5)
+
+	prepared := NewPreparedExecution(device)
+	if prepared.ModbusPlan == nil {
+		t.Fatal("expected prepared modbus plan")
+	}
+	result, err := executor.ExecutePrepared(device, "handle", prepared, nil)
+	if err != nil {
+		t.Fatalf("ExecutePrepared() error = %v", err)
+	}
+	if !result.Success || len(result.Points) != 4 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if slave.requests != 2 {
+		t.Fatalf("requests = %d, want 2 (coils + merged holding block)", slave.requests)
+	}
+	fields := ResultFields(result)
+	if fields["Ua"] != "220.500000" || fields["Ia"] != "-1.500000" || fields["relay"] != "true" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+
+	result, err = executor.ExecuteCommand(device, "handle", map[string]string{"func_name": "write", "field_name": "setpoint", "value": "12.5"})
+	if err != nil || !result.Success {
+		t.Fatalf("write setpoint: result=%+v err=%v", result, err)
+	}
+	result, err = executor.ExecuteCommand(device, "handle", map[string]string{"func_name": "write", "field_name": "relay", "value": "0"})
+	if err != nil || !result.Success {
+		t.Fatalf("write relay: result=%+v err=%v", result, err)
+	}
+	result, err = executor.ExecuteCommand(device, "handle", map[string]string{"func_name": "write", "field_name": "Ua", "value": "1"})
+	if err != nil || result.Success {
+		t.Fatalf("write read-only point should fail: result=%+v err=%v", result, err)
+	}
+
+	result, err = executor.ExecutePrepared(device, "handle", prepared, nil)
+	if err != nil {
+		t.Fatalf("ExecutePrepared() error = %v", err)
+	}
+	fields = ResultFields(result)
+	if fields["setpoint"] != "12.500000" || fields["relay"] != "false" {
+		t.Fatalf("unexpected fields after write: %v", fields)
+	}
+}
+
+func TestExecuteNativeModbusTCP_Read(t *testing.T) {
+	slave := newFakeModbusSlave()
+	slave.holding[0] = 100
+
+	client, server := net.Pipe()
+	serveFakeModbusTCP(t, server, slave)
+
+	executor := NewDriverExecutor(NewDriverManager())
+	executor.SetResourcePath(8, "127.0.0.1:502")
+	executor.RegisterTCP(8, client)
+	device := newNativeModbusTestDevice("modbus_tcp", "net", 8)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := executor.ExecutePreparedWithContext(ctx, device, "handle", nil, nil)
+	if err != nil {
+		t.Fatalf("ExecutePreparedWithContext() error = %v", err)
+	}
+	if got := ResultFields(result)["Ua"]; got != "10.000000" {
+		t.Fatalf("Ua = %q, want 10.000000", got)
+	}
+}
+
+func TestExecuteNativeModbus_ExceptionKeepsConnection(t *testing.T) {
+	slave := newFakeModbusSlave()
+	slave.exception = 0x02
+
+	executor := NewDriverExecutor(NewDriverManager())
+	executor.RegisterSerialPort(5, &fakeRTUPort{slave: slave})
+	device := newNativeModbusTestDevice("modbus_rtu", "serial", 5)
+
+	if _, err := executor.Execute(device); err == nil {
+		t.Fatal("expected exception error")
+	}
+	if executor.GetSerialPort(5) == nil {
+		t.Fatal("serial port should stay registered after protocol error")
+	}
+}
+
+func TestExecuteNativeModbus_PartialReadReportsFailedPoints(t *testing.T) {
+	slave := newFakeModbusSlave()
+	slave.holding[0] = 2205
+	slave.failFunc = modbusFuncReadCoils
+
+	executor := NewDriverExecutor(NewDriverManager())
+	executor.RegisterSerialPort(5, &fakeRTUPort{slave: slave})
+	device := newNativeModbusTestDevice("modbus_rtu", "serial", 5)
+
+	result, err := executor.Execute(device)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !result.Success || len(result.Points) != 3 || ResultFields(result)["Ua"] != "220.500000" {
+		t.Fatalf("unexpected partial result: %+v", result)
+	}
+	if len(result.FailedPoints) != 1 || result.FailedPoints[0] != "relay" {
+		t.Fatalf("FailedPoints = %v, want [relay]", result.FailedPoints)
+	}
+	if !strings.Contains(result.Error, "1 of 2 blocks failed") {
+		t.Fatalf("Error = %q, want failed block summary", result.Error)
+	}
+}
+
+func TestParseModbusUnitID(t *testing.T) {
+	for address, want := range map[string]byte{"": 1, " 3 ": 3, "0": 0, "255": 255} {
+		if got, err := parseModbusUnitID(address); err != nil || got != want {
+			t.Fatalf("parseModbusUnitID(%q) = %d, %v, want %d", address, got, err, want)
+		}
+	}
+	for _, address := range []string{"abc", "-1", "256", "1.5"} {
+		if _, err := parseModbusUnitID(address); err == nil {
+			t.Fatalf("parseModbusUnitID(%q) expected error", address)
+		}
+	}
+
+	device := newNativeModbusTestDevice("modbus_rtu", "serial", 5)
+	device.DeviceAddress = "slave-3"
+	if err := ValidateNativeModbusDevice(device); err == nil {
+		t.Fatal("ValidateNativeModbusDevice should reject an invalid unit id")
+	}
+	executor := NewDriverExecutor(NewDriverManager())
+	executor.RegisterSerialPort(5, &fakeRTUPort{slave: newFakeModbusSlave()})
+	if _, err := executor.Execute(device); err == nil || !strings.Contains(err.Error(), "invalid modbus unit id") {
+		t.Fatalf("Execute() with invalid unit id err = %v", err)
+	}
+}
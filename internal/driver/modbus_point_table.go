@@ -0,0 +1,392 @@
+package driver
+
+import (
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 内置 Modbus 点表驱动：driver_type 为 modbus_rtu/modbus_tcp 且设备配置了 point_table 时，
+// 直接按点表读写寄存器，不再依赖 WASM 驱动。
+
+const (
+	modbusFuncReadCoils            = 0x01
+	modbusFuncReadDiscreteInputs   = 0x02
+	modbusFuncReadHoldingRegisters = 0x03
+	modbusFuncReadInputRegisters   = 0x04
+	modbusFuncWriteSingleCoil      = 0x05
+	modbusFuncWriteSingleRegister  = 0x06
+	modbusFuncWriteMultipleRegs    = 0x10
+
+	modbusMaxReadRegisters = 125
+	modbusMaxReadBits      = 2000
+)
+
+// ModbusPoint 点表中的单个测点
+type ModbusPoint struct {
+	Name      string  `json:"name"`
+	Address   uint16  `json:"address"`
+	Function  int     `json:"function"`   // 1/2/3/4
+	DataType  string  `json:"data_type"`  // bool,int16,uint16,int32,uint32,float32,int64,uint64,float64
+	ByteOrder string  `json:"byte_order"` // big(AB) / little(BA)
+	WordOrder string  `json:"word_order"` // big(高字在前) / little(低字在前)
+	Scale     float64 `json:"scale"`
+	Offset    float64 `json:"offset"`
+	Unit      string  `json:"unit,omitempty"`
+	RW        string  `json:"rw"` // R / W / RW
+}
+
+// ModbusPointTable 设备点表
+type ModbusPointTable struct {
+	Points []ModbusPoint `json:"points"`
+	// MaxGap 合并读取时允许跨越的空洞寄存器数量，默认 0（仅合并相邻寄存器）
+	MaxGap int `json:"max_gap,omitempty"`
+	// MaxRegisters 单次读取的最大寄存器数量，默认 125
+	MaxRegisters int `json:"max_registers,omitempty"`
+}
+
+// modbusReadBlock 合并后的一次读取请求
+type modbusReadBlock struct {
+	Function byte
+	Start    uint16
+	Quantity uint16
+	Points   []int // 指向 ModbusPointTable.Points 的下标
+}
+
+// ModbusReadPlan 预编译的点表读取计划
+type ModbusReadPlan struct {
+	Table  *ModbusPointTable
+	Blocks []modbusReadBlock
+	index  map[string]int
+}
+
+// ParseModbusPointTable 解析点表 JSON，支持数组或 {"points": [...]} 两种写法
+func ParseModbusPointTable(raw string) (*ModbusPointTable, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("point table is empty")
+	}
+	table := &ModbusPointTable{}
+	if strings.HasPrefix(raw, "[") {
+		if err := json.Unmarshal([]byte(raw), &table.Points); err != nil {
+			return nil, fmt.Errorf("invalid point table: %w", err)
+		}
+	} else if err := json.Unmarshal([]byte(raw), table); err != nil {
+		return nil, fmt.Errorf("invalid point table: %w", err)
+	}
+	if len(table.Points) == 0 {
+		return nil, fmt.Errorf("point table has no points")
+	}
+
+	seen := make(map[string]struct{}, len(table.Points))
+	for i := range table.Points {
+		point := &table.Points[i]
+		if err := NormalizeModbusPoint(point); err != nil {
+			return nil, fmt.Errorf("point #%d: %w", i, err)
+		}
+		key := strings.ToLower(point.Name)
+		if _, exists := seen[key]; exists {
+			return nil, fmt.Errorf("point #%d: duplicate name %q", i, point.Name)
+		}
+		seen[key] = struct{}{}
+	}
+	if table.MaxGap < 0 {
+		table.MaxGap = 0
+	}
+	if table.MaxRegisters <= 0 || table.MaxRegisters > modbusMaxReadRegisters {
+		table.MaxRegisters = modbusMaxReadRegisters
+	}
+	return table, nil
+}
+
+// NormalizeModbusPoint 校验测点并补齐默认功能码、数据类型、字节序与读写属性
+func NormalizeModbusPoint(point *ModbusPoint) error {
+	point.Name = strings.TrimSpace(point.Name)
+	if point.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if point.Function == 0 {
+		point.Function = modbusFuncReadHoldingRegisters
+	}
+	point.DataType = strings.ToLower(strings.TrimSpace(point.DataType))
+	switch point.Function {
+	case modbusFuncReadCoils, modbusFuncReadDiscreteInputs:
+		if point.DataType == "" {
+			point.DataType = "bool"
+		}
+		if point.DataType != "bool" {
+			return fmt.Errorf("function %d only supports bool", point.Function)
+		}
+	case modbusFuncReadHoldingRegisters, modbusFuncReadInputRegisters:
+		if point.DataType == "" {
+			point.DataType = "uint16"
+		}
+		if ModbusDataTypeRegisters(point.DataType) == 0 || point.DataType == "bool" {
+			return fmt.Errorf("unsupported data_type %q", point.DataType)
+		}
+	default:
+		return fmt.Errorf("unsupported function %d", point.Function)
+	}
+
+	var err error
+	if point.ByteOrder, err = normalizeModbusOrder(point.ByteOrder); err != nil {
+		return fmt.Errorf("byte_order: %w", err)
+	}
+	if point.WordOrder, err = normalizeModbusOrder(point.WordOrder); err != nil {
+		return fmt.Errorf("word_order: %w", err)
+	}
+	if point.Scale == 0 {
+		point.Scale = 1
+	}
+
+	point.RW = strings.ToUpper(strings.TrimSpace(point.RW))
+	switch point.RW {
+	case "":
+		point.RW = "R"
+	case "R", "W", "RW":
+	default:
+		return fmt.Errorf("unsupported rw %q", point.RW)
+	}
+	if point.RW != "R" && (point.Function == modbusFuncReadDiscreteInputs || point.Function == modbusFuncReadInputRegisters) {
+		return fmt.Errorf("function %d is read-only", point.Function)
+	}
+	return nil
+}
+
+func normalizeModbusOrder(order string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "", "big", "be", "ab", "abcd":
+		return "big", nil
+	case "little", "le", "ba", "dcba":
+		return "little", nil
+	default:
+		return "", fmt.Errorf("unsupported order %q", order)
+	}
+}
+
+// ModbusDataTypeRegisters 返回数据类型占用的寄存器数量（bool 按 1 个位计）
+func ModbusDataTypeRegisters(dataType string) int {
+	switch dataType {
+	case "bool", "int16", "uint16":
+		return 1
+	case "int32", "uint32", "float32":
+		return 2
+	case "int64", "uint64", "float64":
+		return 4
+	default:
+		return 0
+	}
+}
+
+// NewModbusReadPlan 按功能码分组并合并地址连续的测点，尽量减少读取次数
+func NewModbusReadPlan(table *ModbusPointTable) *ModbusReadPlan {
+	if table == nil {
+		return nil
+	}
+	plan := &ModbusReadPlan{Table: table, index: make(map[string]int, len(table.Points))}
+
+	order := make([]int, 0, len(table.Points))
+	for i, point := range table.Points {
+		plan.index[strings.ToLower(point.Name)] = i
+		if point.RW == "W" {
+			continue
+		}
+		order = append(order, i)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		pa, pb := table.Points[a], table.Points[b]
+		if c := cmp.Compare(pa.Function, pb.Function); c != 0 {
+			return c
+		}
+		return cmp.Compare(pa.Address, pb.Address)
+	})
+
+	for _, idx := range order {
+		point := table.Points[idx]
+		fn := byte(point.Function)
+		start := int(point.Address)
+		end := start + ModbusDataTypeRegisters(point.DataType)
+		limit := table.MaxRegisters
+		if fn == modbusFuncReadCoils || fn == modbusFuncReadDiscreteInputs {
+			limit = modbusMaxReadBits
+		}
+
+		if n := len(plan.Blocks); n > 0 {
+			last := &plan.Blocks[n-1]
+			lastEnd := int(last.Start) + int(last.Quantity)
+			if last.Function == fn && start <= lastEnd+table.MaxGap && max(end, lastEnd)-int(last.Start) <= limit {
+				if end > lastEnd {
+					last.Quantity = uint16(end - int(last.Start))
+				}
+				last.Points = append(last.Points, idx)
+				continue
+			}
+		}
+		plan.Blocks = append(plan.Blocks, modbusReadBlock{
+			Function: fn,
+			Start:    point.Address,
+			Quantity: uint16(end - start),
+			Points:   []int{idx},
+		})
+	}
+	return plan
+}
+
+// LookupPoint 按名称查找测点（不区分大小写）
+func (p *ModbusReadPlan) LookupPoint(name string) (*ModbusPoint, bool) {
+	if p == nil || p.Table == nil {
+		return nil, false
+	}
+	idx, ok := p.index[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, false
+	}
+	return &p.Table.Points[idx], true
+}
+
+// decodeModbusBlock 把一次读取的数据区解析为测点值
+func decodeModbusBlock(table *ModbusPointTable, block modbusReadBlock, data []byte, dst []DriverPoint) ([]DriverPoint, error) {
+	bitMode := block.Function == modbusFuncReadCoils || block.Function == modbusFuncReadDiscreteInputs
+	for _, idx := range block.Points {
+		point := table.Points[idx]
+		offset := int(point.Address - block.Start)
+		if bitMode {
+			byteIdx := offset / 8
+			if byteIdx >= len(data) {
+				return dst, fmt.Errorf("point %s: response too short", point.Name)
+			}
+			dst = append(dst, DriverPoint{FieldName: point.Name, Value: data[byteIdx]&(1<<(offset%8)) != 0, RW: point.RW})
+			continue
+		}
+		size := ModbusDataTypeRegisters(point.DataType) * 2
+		if (offset*2)+size > len(data) {
+			return dst, fmt.Errorf("point %s: response too short", point.Name)
+		}
+		value, err := DecodeModbusValue(point, data[offset*2:offset*2+size])
+		if err != nil {
+			return dst, fmt.Errorf("point %s: %w", point.Name, err)
+		}
+		dst = append(dst, DriverPoint{FieldName: point.Name, Value: value, RW: point.RW})
+	}
+	return dst, nil
+}
+
+// normalizeModbusRegisterBytes 把寄存器原始字节按字序/字节序调整为大端顺序
+func normalizeModbusRegisterBytes(point ModbusPoint, raw []byte) []byte {
+	buf := make([]byte, len(raw))
+	words := len(raw) / 2
+	for i := 0; i < words; i++ {
+		src := i
+		if point.WordOrder == "little" {
+			src = words - 1 - i
+		}
+		hi, lo := raw[src*2], raw[src*2+1]
+		if point.ByteOrder == "little" {
+			hi, lo = lo, hi
+		}
+		buf[i*2], buf[i*2+1] = hi, lo
+	}
+	return buf
+}
+
+// DecodeModbusValue 把寄存器原始字节按点表解析为工程值
+func DecodeModbusValue(point ModbusPoint, raw []byte) (any, error) {
+	buf := normalizeModbusRegisterBytes(point, raw)
+	var value float64
+	switch point.DataType {
+	case "int16":
+		value = float64(int16(binary.BigEndian.Uint16(buf)))
+	case "uint16":
+		value = float64(binary.BigEndian.Uint16(buf))
+	case "int32":
+		value = float64(int32(binary.BigEndian.Uint32(buf)))
+	case "uint32":
+		value = float64(binary.BigEndian.Uint32(buf))
+	case "float32":
+		value = float64(math.Float32frombits(binary.BigEndian.Uint32(buf)))
+	case "int64":
+		value = float64(int64(binary.BigEndian.Uint64(buf)))
+	case "uint64":
+		value = float64(binary.BigEndian.Uint64(buf))
+	case "float64":
+		value = math.Float64frombits(binary.BigEndian.Uint64(buf))
+	default:
+		return nil, fmt.Errorf("unsupported data_type %q", point.DataType)
+	}
+	return value*point.Scale + point.Offset, nil
+}
+
+// EncodeModbusValue 把工程值反算为寄存器字节（写入用）；超出数据类型范围或非有限值时返回错误
+func EncodeModbusValue(point ModbusPoint, text string) ([]byte, error) {
+	engineering, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", text)
+	}
+	rawValue := (engineering - point.Offset) / point.Scale
+	if math.IsNaN(rawValue) || math.IsInf(rawValue, 0) {
+		return nil, fmt.Errorf("value %q is not a finite number for data_type %s", text, point.DataType)
+	}
+
+	size := ModbusDataTypeRegisters(point.DataType) * 2
+	buf := make([]byte, size)
+	switch point.DataType {
+	case "float32":
+		if math.Abs(rawValue) > math.MaxFloat32 {
+			return nil, fmt.Errorf("value %q out of range for data_type float32", text)
+		}
+		binary.BigEndian.PutUint32(buf, math.Float32bits(float32(rawValue)))
+	case "float64":
+		binary.BigEndian.PutUint64(buf, math.Float64bits(rawValue))
+	case "int16", "uint16", "int32", "uint32", "int64", "uint64":
+		rounded := math.Round(rawValue)
+		limits := modbusIntegerLimits[point.DataType]
+		// 上界为开区间：2^63、2^64 在 float64 中可精确表示，而 MaxInt64/MaxUint64 不能
+		if rounded < limits[0] || rounded >= limits[1] {
+			return nil, fmt.Errorf("value %q out of range for data_type %s", text, point.DataType)
+		}
+		switch point.DataType {
+		case "int16":
+			binary.BigEndian.PutUint16(buf, uint16(int16(rounded)))
+		case "uint16":
+			binary.BigEndian.PutUint16(buf, uint16(rounded))
+		case "int32":
+			binary.BigEndian.PutUint32(buf, uint32(int32(rounded)))
+		case "uint32":
+			binary.BigEndian.PutUint32(buf, uint32(rounded))
+		case "int64":
+			binary.BigEndian.PutUint64(buf, uint64(int64(rounded)))
+		case "uint64":
+			binary.BigEndian.PutUint64(buf, uint64(rounded))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported data_type %q", point.DataType)
+	}
+	// 字序/字节序变换是对合的，直接复用同一函数
+	return normalizeModbusRegisterBytes(point, buf), nil
+}
+
+// modbusIntegerLimits 整型数据类型原始值的取值区间 [min, max)
+var modbusIntegerLimits = map[string][2]float64{
+	"int16":  {math.MinInt16, math.MaxInt16 + 1},
+	"uint16": {0, math.MaxUint16 + 1},
+	"int32":  {math.MinInt32, math.MaxInt32 + 1},
+	"uint32": {0, math.MaxUint32 + 1},
+	"int64":  {math.MinInt64, 1 << 63},
+	"uint64": {0, 1 << 64},
+}
+
+func parseModbusBool(text string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "1", "true", "on":
+		return true, nil
+	case "0", "false", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid bool value %q", text)
+	}
+}
@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseModbusPointTable_Defaults(t *testing.T) {
+	table, err := ParseModbusPointTable(`[{"name":"Ua","address":0}]`)
+	if err != nil {
+		t.Fatalf("ParseModbusPointTable() error = %v", err)
+	}
+	point := table.Points[0]
+	if point.Function != modbusFuncReadHoldingRegisters || point.DataType != "uint16" {
+		t.Fatalf("defaults = fn %d type %s, want 3 uint16", point.Function, point.DataType)
+	}
+	if point.Scale != 1 || point.RW != "R" || point.ByteOrder != "big" || point.WordOrder != "big" {
+		t.Fatalf("unexpected defaults: %+v", point)
+	}
+	if table.MaxRegisters != modbusMaxReadRegisters {
+		t.Fatalf("MaxRegisters = %d, want %d", table.MaxRegisters, modbusMaxReadRegisters)
+	}
+}
+
+func TestParseModbusPointTable_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"empty":          ``,
+		"no points":      `{"points":[]}`,
+		"missing name":   `[{"address":1}]`,
+		"duplicate":      `[{"name":"a","address":1},{"name":"A","address":2}]`,
+		"bad type":       `[{"name":"a","function":3,"data_type":"string"}]`,
+		"coil as int":    `[{"name":"a","function":1,"data_type":"int16"}]`,
+		"write on input": `[{"name":"a","function":4,"rw":"RW"}]`,
+		"bad order":      `[{"name":"a","byte_order":"middle"}]`,
+	}
+	for name, raw := range cases {
+		if _, err := ParseModbusPointTable(raw); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNewModbusReadPlan_MergesAdjacentRegisters(t *testing.T) {
+	table, err := ParseModbusPointTable(`{"points":[
+		{"name":"Ub","address":2,"data_type":"float32"},
+		{"name":"Ua","address":0,"data_type":"float32"},
+		{"name":"Ia","address":10},
+		{"name":"P","address":4,"function":4},
+		{"name":"sw","address":3,"function":1},
+		{"name":"sp","address":20,"rw":"W"}
+	]}`)
+	if err != nil {
+		t.Fatalf("ParseModbusPointTable() error = %v", err)
+	}
+	plan := NewModbusReadPlan(table)
+	if len(plan.Blocks) != 4 {
+		t.Fatalf("blocks = %d, want 4: %+v", len(plan.Blocks), plan.Blocks)
+	}
+	first := plan.Blocks[1]
+	if first.Function != modbusFuncReadHoldingRegisters || first.Start != 0 || first.Quantity != 4 || len(first.Points) != 2 {
+		t.Fatalf("holding block = %+v", first)
+	}
+	if plan.Blocks[0].Function != modbusFuncReadCoils {
+		t.Fatalf("expected coil block first, got %+v", plan.Blocks[0])
+	}
+	if _, ok := plan.LookupPoint("SP"); !ok {
+		t.Fatal("write-only point should be addressable by name")
+	}
+}
+
+func TestNewModbusReadPlan_MaxGapAndLimit(t *testing.T) {
+	table, err := ParseModbusPointTable(`{"max_gap":4,"max_registers":6,"points":[
+		{"name":"a","address":0},
+		{"name":"b","address":3},
+		{"name":"c","address":5,"data_type":"uint32"}
+	]}`)
+	if err != nil {
+		t.Fatalf("ParseModbusPointTable() error = %v", err)
+	}
+	plan := NewModbusReadPlan(table)
+	if len(plan.Blocks) != 2 {
+		t.Fatalf("blocks = %+v, want 2", plan.Blocks)
+	}
+	if plan.Blocks[0].Quantity != 4 || plan.Blocks[1].Start != 5 || plan.Blocks[1].Quantity != 2 {
+		t.Fatalf("unexpected blocks: %+v", plan.Blocks)
+	}
+}
+
+func TestDecodeModbusValue_ByteAndWordOrder(t *testing.T) {
+	bits := math.Float32bits(230.5)
+	abcd := []byte{byte(bits >> 24), byte(bits >> 16), byte(bits >> 8), byte(bits)}
+	cases := []struct {
+		byteOrder, wordOrder string
+		raw                  []byte
+	}{
+		{"big", "big", abcd},
+		{"big", "little", []byte{abcd[2], abcd[3], abcd[0], abcd[1]}},
+		{"little", "big", []byte{abcd[1], abcd[0], abcd[3], abcd[2]}},
+		{"little", "little", []byte{abcd[3], abcd[2], abcd[1], abcd[0]}},
+	}
+	for _, tc := range cases {
+		point := ModbusPoint{Name: "u", DataType: "float32", ByteOrder: tc.byteOrder, WordOrder: tc.wordOrder, Scale: 1}
+		value, err := DecodeModbusValue(point, tc.raw)
+		if err != nil {
+			t.Fatalf("decode %s/%s error = %v", tc.byteOrder, tc.wordOrder, err)
+		}
+		if value.(float64) != 230.5 {
+			t.Fatalf("decode %s/%s = %v, want 230.5", tc.byteOrder, tc.wordOrder, value)
+		}
+	}
+}
+
+func TestDecodeModbusValue_ScaleOffsetAndSigned(t *testing.T) {
+	point := ModbusPoint{Name: "t", DataType: "int16", ByteOrder: "big", WordOrder: "big", Scale: 0.1, Offset: -40}
+	value, err := DecodeModbusValue(point, []byte{0xFF, 0x38}) // -200
+	if err != nil {
+		t.Fatalf("DecodeModbusValue() error = %v", err)
+	}
+	if got := value.(float64); math.Abs(got-(-60)) > 1e-9 {
+		t.Fatalf("value = %v, want -60", got)
+	}
+}
+
+func TestEncodeModbusValue_RoundTrip(t *testing.T) {
+	point := ModbusPoint{Name: "sp", DataType: "int32", ByteOrder: "little", WordOrder: "little", Scale: 0.01}
+	raw, err := EncodeModbusValue(point, "-12.34")
+	if err != nil {
+		t.Fatalf("EncodeModbusValue() error = %v", err)
+	}
+	value, err := DecodeModbusValue(point, raw)
+	if err != nil {
+		t.Fatalf("DecodeModbusValue() error = %v", err)
+	}
+	if got := value.(float64); math.Abs(got-(-12.34)) > 1e-9 {
+		t.Fatalf("round trip = %v, want -12.34", got)
+	}
+	if _, err := EncodeModbusValue(point, "abc"); err == nil || !strings.Contains(err.Error(), "invalid value") {
+		t.Fatalf("expected invalid value error, got %v", err)
+	}
+}
+
+func TestEncodeModbusValue_RejectsOutOfRange(t *testing.T) {
+	cases := []struct {
+		point ModbusPoint
+		value string
+	}{
+		{ModbusPoint{DataType: "uint16", Scale: 1}, "70000"},
+		{ModbusPoint{DataType: "uint16", Scale: 1}, "65535.6"},
+		{ModbusPoint{DataType: "uint16", Scale: 0.1}, "6553.6"},
+		{ModbusPoint{DataType: "uint16", Scale: 1}, "-1"},
+		{ModbusPoint{DataType: "uint32", Scale: 1}, "-5"},
+		{ModbusPoint{DataType: "uint64", Scale: 1}, "-0.6"},
+		{ModbusPoint{DataType: "uint64", Scale: 1}, "18446744073709551616"},
+		{ModbusPoint{DataType: "int16", Scale: 1}, "32768"},
+		{ModbusPoint{DataType: "int16", Scale: 1}, "-32769"},
+		{ModbusPoint{DataType: "int32", Scale: 1}, "2147483648"},
+		{ModbusPoint{DataType: "int64", Scale: 1}, "9223372036854775808"},
+		{ModbusPoint{DataType: "float32", Scale: 1}, "1e39"},
+		{ModbusPoint{DataType: "float32", Scale: 1}, "NaN"},
+		{ModbusPoint{DataType: "float64", Scale: 1}, "+Inf"},
+		{ModbusPoint{DataType: "int16", Scale: 0}, "1"},
+	}
+	for _, tc := range cases {
+		if raw, err := EncodeModbusValue(tc.point, tc.value); err == nil {
+			t.Fatalf("%s %q: expected error, got % x", tc.point.DataType, tc.value, raw)
+		}
+	}
+
+	// 边界值仍可写入
+	bounds := []struct {
+		point ModbusPoint
+		value string
+		want  []byte
+	}{
+		{ModbusPoint{DataType: "uint16", Scale: 1}, "65535", []byte{0xFF, 0xFF}},
+		{ModbusPoint{DataType: "uint16", Scale: 1}, "-0.4", []byte{0x00, 0x00}},
+		{ModbusPoint{DataType: "int16", Scale: 1}, "-32768", []byte{0x80, 0x00}},
+		{ModbusPoint{DataType: "uint32", Scale: 1}, "4294967295", []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, tc := range bounds {
+		raw, err := EncodeModbusValue(tc.point, tc.value)
+		if err != nil || string(raw) != string(tc.want) {
+			t.Fatalf("%s %q = % x, %v, want % x", tc.point.DataType, tc.value, raw, err, tc.want)
+		}
+	}
+}
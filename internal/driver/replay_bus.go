@@ -0,0 +1,240 @@
+package driver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrReplayMismatch 驱动发出的请求与收发记录不一致
+var ErrReplayMismatch = errors.New("replay request mismatch")
+
+// ReplayBus 按收发记录应答的虚拟总线，同时实现 SerialPort、net.Conn 与资源 UDP 套接字：
+// 每次写入与下一条记录比对，写后读记录匹配后放出应答供 Read 读取；
+// 仅读记录在驱动调用读取宿主函数时（ExpectRead）放出，UDP 数据报由 ReadFromUDP 按序取出
+type ReplayBus struct {
+	mu         sync.Mutex
+	exchanges  []TranscriptExchange
+	next       int
+	pending    []byte
+	readyAt    time.Time
+	datagram   *TranscriptExchange
+	datagramAt time.Time
+	deadline   time.Time
+	realtime   bool
+	closed     bool
+	failures   []string
+}
+
+// NewReplayBus 创建回放总线；realtime 为 true 时按记录的 delay_ms 延迟放出应答
+func NewReplayBus(exchanges []TranscriptExchange, realtime bool) *ReplayBus {
+	return &ReplayBus{exchanges: exchanges, realtime: realtime}
+}
+
+func (b *ReplayBus) Write(data []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, net.ErrClosed
+	}
+	b.pending = nil
+	exchange, err := b.matchWrite(data, "", "")
+	if err != nil {
+		return 0, err
+	}
+	if exchange.Op == TranscriptOpTransceive {
+		b.pending = append(b.pending, exchange.Response...)
+		b.readyAt = b.releaseTime(exchange)
+	}
+	return len(data), nil
+}
+
+// ExpectRead 由仅读宿主函数（serial_read / tcp_read / tcp_read_until）在读取前调用：
+// 无待读数据且下一条记录为总线仅读交互时放出其数据
+func (b *ReplayBus) ExpectRead() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.pending) > 0 || b.next >= len(b.exchanges) {
+		return
+	}
+	exchange := &b.exchanges[b.next]
+	if exchange.Op != TranscriptOpRead || exchange.Channel != "" {
+		return
+	}
+	b.next++
+	b.pending = append(b.pending, exchange.Response...)
+	b.readyAt = b.releaseTime(exchange)
+}
+
+// WriteToUDP 与下一条 UDP 仅写记录比对数据与目标地址
+func (b *ReplayBus) WriteToUDP(data []byte, addr *net.UDPAddr) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, net.ErrClosed
+	}
+	target := ""
+	if addr != nil {
+		target = addr.String()
+	}
+	if _, err := b.matchWrite(data, TranscriptChannelUDP, target); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// ReadFromUDP 取出下一条 UDP 仅读记录的数据报；没有可用记录时等待至截止时间并返回超时
+func (b *ReplayBus) ReadFromUDP(data []byte) (int, *net.UDPAddr, error) {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return 0, nil, net.ErrClosed
+		}
+		if b.datagram == nil && b.next < len(b.exchanges) {
+			if exchange := &b.exchanges[b.next]; exchange.Op == TranscriptOpRead && exchange.Channel == TranscriptChannelUDP {
+				b.next++
+				b.datagram = exchange
+				b.datagramAt = b.releaseTime(exchange)
+			}
+		}
+		now := time.Now()
+		if b.datagram != nil && !now.Before(b.datagramAt) {
+			n := copy(data, b.datagram.Response)
+			from, _ := net.ResolveUDPAddr("udp", b.datagram.Addr)
+			b.datagram = nil
+			b.mu.Unlock()
+			return n, from, nil
+		}
+		deadline := b.deadline
+		wakeAt := deadline
+		if b.datagram != nil && (wakeAt.IsZero() || b.datagramAt.Before(wakeAt)) {
+			wakeAt = b.datagramAt
+		}
+		b.mu.Unlock()
+
+		if wakeAt.IsZero() || (!deadline.IsZero() && !now.Before(deadline)) {
+			return 0, nil, os.ErrDeadlineExceeded
+		}
+		time.Sleep(wakeAt.Sub(now))
+	}
+}
+
+// matchWrite 将一次写入与下一条记录比对：总线写入对应写后读或仅写记录，UDP 写入对应 UDP 仅写记录；须持有锁
+func (b *ReplayBus) matchWrite(data []byte, channel, addr string) (*TranscriptExchange, error) {
+	if b.next >= len(b.exchanges) {
+		b.failures = append(b.failures, fmt.Sprintf("unexpected request #%d: % X", b.next+1, data))
+		return nil, fmt.Errorf("%w: no more recorded exchanges", ErrReplayMismatch)
+	}
+	exchange := &b.exchanges[b.next]
+	b.next++
+	opMatched := exchange.Op != TranscriptOpRead
+	if channel == TranscriptChannelUDP {
+		opMatched = exchange.Op == TranscriptOpWrite
+	}
+	switch {
+	case !opMatched || exchange.Channel != channel:
+		b.failures = append(b.failures, fmt.Sprintf("request #%d: driver wrote % X on %s, recorded %s", b.next, data, transcriptChannelName(channel), exchange.describe()))
+	case !bytes.Equal(data, exchange.Request):
+		b.failures = append(b.failures, fmt.Sprintf("request #%d = % X, want % X", b.next, data, []byte(exchange.Request)))
+	case exchange.Addr != "" && addr != exchange.Addr:
+		b.failures = append(b.failures, fmt.Sprintf("request #%d sent to %s, want %s", b.next, addr, exchange.Addr))
+	default:
+		return exchange, nil
+	}
+	return nil, fmt.Errorf("%w: request #%d", ErrReplayMismatch, b.next)
+}
+
+func (b *ReplayBus) releaseTime(exchange *TranscriptExchange) time.Time {
+	at := time.Now()
+	if b.realtime && exchange.DelayMs > 0 {
+		at = at.Add(time.Duration(exchange.DelayMs) * time.Millisecond)
+	}
+	return at
+}
+
+// Read 返回已放出的应答字节；无数据时未设截止时间立即返回 0（串口语义），
+// 设置了截止时间则等待至截止并返回超时（net.Conn 语义）
+func (b *ReplayBus) Read(data []byte) (int, error) {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return 0, net.ErrClosed
+		}
+		now := time.Now()
+		if len(b.pending) > 0 && !now.Before(b.readyAt) {
+			n := copy(data, b.pending)
+			b.pending = b.pending[n:]
+			b.mu.Unlock()
+			return n, nil
+		}
+		deadline := b.deadline
+		wakeAt := deadline
+		if len(b.pending) > 0 && (wakeAt.IsZero() || b.readyAt.Before(wakeAt)) {
+			wakeAt = b.readyAt
+		}
+		b.mu.Unlock()
+
+		if deadline.IsZero() && (wakeAt.IsZero() || wakeAt.Sub(now) > driverReadRetryInterval) {
+			return 0, nil
+		}
+		if !deadline.IsZero() && !now.Before(deadline) {
+			return 0, os.ErrDeadlineExceeded
+		}
+		time.Sleep(wakeAt.Sub(now))
+	}
+}
+
+func (b *ReplayBus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	return nil
+}
+
+// Remaining 返回尚未被驱动请求的记录条数
+func (b *ReplayBus) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.exchanges) - b.next
+}
+
+// Failures 返回请求比对失败明细
+func (b *ReplayBus) Failures() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.failures...)
+}
+
+func (b *ReplayBus) LocalAddr() net.Addr  { return replayAddr{} }
+func (b *ReplayBus) RemoteAddr() net.Addr { return replayAddr{} }
+
+func (b *ReplayBus) SetDeadline(t time.Time) error {
+	return b.SetReadDeadline(t)
+}
+
+func (b *ReplayBus) SetReadDeadline(t time.Time) error {
+	b.mu.Lock()
+	b.deadline = t
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *ReplayBus) SetWriteDeadline(time.Time) error { return nil }
+
+// expectReplayRead 通知回放总线驱动即将仅读，真实串口/连接不实现该方法
+func expectReplayRead(bus any) {
+	if replay, ok := bus.(interface{ ExpectRead() }); ok {
+		replay.ExpectRead()
+	}
+}
+
+type replayAddr struct{}
+
+func (replayAddr) Network() string { return "replay" }
+func (replayAddr) String() string  { return "replay" }
@@ -0,0 +1,252 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// 驱动沙箱默认值
+const (
+	defaultDriverQuarantineCrashes = 5
+	defaultDriverQuarantineWindow  = 10 * time.Minute
+	maxWasmMemoryPages             = 65536
+	maxTrapReasonLen               = 256
+)
+
+// pluginLimits 插件实例的资源上限，0 表示不限制
+type pluginLimits struct {
+	maxMemoryPages uint32
+	maxCalls       int64
+}
+
+// SetSandboxLimits 设置驱动默认内存页上限与单次调用的函数调用预算（驱动 config_schema 可单独覆盖），0 表示不限制
+func (m *DriverManager) SetSandboxLimits(maxMemoryPages, maxCalls int) {
+	m.mu.Lock()
+	m.defaultLimits = normalizePluginLimits(int64(maxMemoryPages), int64(maxCalls))
+	m.mu.Unlock()
+}
+
+// SetQuarantinePolicy 设置隔离策略：window 内崩溃 crashes 次后隔离驱动（crashes 为 0 表示不隔离）
+func (m *DriverManager) SetQuarantinePolicy(crashes int, window time.Duration) {
+	if crashes < 0 {
+		crashes = 0
+	}
+	if window <= 0 {
+		window = defaultDriverQuarantineWindow
+	}
+	m.mu.Lock()
+	m.quarantineCrashes = crashes
+	m.quarantineWindow = window
+	m.mu.Unlock()
+}
+
+func normalizePluginLimits(maxMemoryPages, maxCalls int64) pluginLimits {
+	var limits pluginLimits
+	if maxMemoryPages > 0 {
+		limits.maxMemoryPages = uint32(min(maxMemoryPages, maxWasmMemoryPages))
+	}
+	if maxCalls > 0 {
+		limits.maxCalls = maxCalls
+	}
+	return limits
+}
+
+// parseDriverLimits 从驱动 config_schema 读取 max_memory_pages / max_call_budget，未配置时使用默认值
+func parseDriverLimits(configSchema string, defaults pluginLimits) pluginLimits {
+	if strings.TrimSpace(configSchema) == "" {
+		return defaults
+	}
+	var cfg struct {
+		MaxMemoryPages int64 `json:"max_memory_pages"`
+		MaxCallBudget  int64 `json:"max_call_budget"`
+	}
+	if err := json.Unmarshal([]byte(configSchema), &cfg); err != nil {
+		return defaults
+	}
+	override := normalizePluginLimits(cfg.MaxMemoryPages, cfg.MaxCallBudget)
+	if override.maxMemoryPages > 0 {
+		defaults.maxMemoryPages = override.maxMemoryPages
+	}
+	if override.maxCalls > 0 {
+		defaults.maxCalls = override.maxCalls
+	}
+	return defaults
+}
+
+type callBudgetKey struct{}
+
+// callBudget 单次驱动调用剩余可执行的 wasm 函数调用次数；实例调用期间独占，无需同步
+type callBudget struct {
+	remaining int64
+}
+
+func withCallBudget(ctx context.Context, calls int64) context.Context {
+	if calls <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, callBudgetKey{}, &callBudget{remaining: calls})
+}
+
+// callBudgetListenerFactory 按 wasm 函数调用计数（不是指令计量）：每进入一次 wasm 函数扣减 1，预算耗尽时中止调用；
+// 宿主函数不计。wazero 不支持指令级计量，无函数调用的死循环由调用超时中断
+type callBudgetListenerFactory struct{}
+
+type callBudgetListener struct{}
+
+func (callBudgetListenerFactory) NewFunctionListener(def api.FunctionDefinition) experimental.FunctionListener {
+	if def.GoFunction() != nil {
+		return nil
+	}
+	return callBudgetListener{}
+}
+
+func (callBudgetListener) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	meter, ok := ctx.Value(callBudgetKey{}).(*callBudget)
+	if !ok {
+		return
+	}
+	meter.remaining--
+	if meter.remaining < 0 {
+		panic(ErrDriverCallBudgetExhausted)
+	}
+}
+
+func (callBudgetListener) After(context.Context, api.Module, api.FunctionDefinition, []uint64) {}
+
+func (callBudgetListener) Abort(context.Context, api.Module, api.FunctionDefinition, error) {}
+
+// driverCrashState 驱动崩溃统计与隔离状态
+type driverCrashState struct {
+	mu            sync.Mutex
+	total         int64
+	recent        []time.Time // 隔离窗口内的崩溃时间
+	lastTrap      string
+	lastTrapAt    time.Time
+	quarantined   bool
+	quarantinedAt time.Time
+}
+
+func (s *driverCrashState) isQuarantined() (bool, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.quarantined, s.lastTrap
+}
+
+// record 记录一次崩溃，返回本次是否触发隔离
+func (s *driverCrashState) record(reason string, now time.Time, threshold int, window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total++
+	s.lastTrap = reason
+	s.lastTrapAt = now
+
+	cutoff := now.Add(-window)
+	kept := s.recent[:0]
+	for _, at := range s.recent {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	s.recent = append(kept, now)
+
+	if s.quarantined || threshold <= 0 || len(s.recent) < threshold {
+		return false
+	}
+	s.quarantined = true
+	s.quarantinedAt = now
+	return true
+}
+
+func (s *driverCrashState) fillRuntime(runtime *DriverRuntime) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runtime.CrashCount = s.total
+	runtime.LastTrap = s.lastTrap
+	runtime.Quarantined = s.quarantined
+	if !s.lastTrapAt.IsZero() {
+		at := s.lastTrapAt
+		runtime.LastTrapAt = &at
+	}
+	if s.quarantined {
+		at := s.quarantinedAt
+		runtime.QuarantinedAt = &at
+	}
+}
+
+// trapReason 取错误首行作为崩溃原因（去掉 wasm 调用栈）
+func trapReason(err error) string {
+	reason, _, _ := strings.Cut(err.Error(), "\n")
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxTrapReasonLen {
+		reason = reason[:maxTrapReasonLen]
+	}
+	return reason
+}
+
+// isDriverCrash 判断调用错误是否为实例崩溃：wasm 运行时错误（trap）、非零退出、调用预算耗尽或监听器 panic；
+// 插件主动报告的错误、空输出与超时/取消不计入
+func isDriverCrash(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrPluginReported),
+		errors.Is(err, ErrPluginEmptyOutput),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, ErrDriverCallBudgetExhausted):
+		return true
+	}
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode() != 0
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "wasm error:") || strings.Contains(msg, "recovered by wazero")
+}
+
+// recordDriverCrash 记录驱动崩溃，达到阈值时隔离驱动并释放其非主实例
+func (m *DriverManager) recordDriverCrash(driver *WasmDriver, err error) {
+	m.mu.RLock()
+	threshold, window := m.quarantineCrashes, m.quarantineWindow
+	m.mu.RUnlock()
+
+	reason := trapReason(err)
+	slog.Warn("Driver crashed", "driver", driver.Name, "driver_id", driver.ID, "reason", reason)
+	if !driver.crash.record(reason, time.Now(), threshold, window) {
+		return
+	}
+	slog.Error("Driver quarantined after repeated crashes", "driver", driver.Name, "driver_id", driver.ID,
+		"crashes", threshold, "window", window, "last_trap", reason)
+	driver.removeInstances(func(inst *pluginInstance) bool { return !inst.pinned })
+}
+
+// recoverInstanceLocked 调用出错后实例状态不可信（trap、超限或超时关闭），原地重建插件；
+// 重建失败或驱动已隔离时移出实例池，调用方需持有 inst.mu
+func (m *DriverManager) recoverInstanceLocked(driver *WasmDriver, inst *pluginInstance) {
+	inst.closeLocked()
+	if quarantined, _ := driver.crash.isQuarantined(); !quarantined {
+		plugin, err := m.newResourcePlugin(driver.Name, driver.wasmData, inst.resourceID, driver.limits)
+		if err == nil {
+			inst.plugin = plugin
+			slog.Info("Driver instance recreated", "driver", driver.Name, "resource_id", inst.resourceID)
+			return
+		}
+		slog.Warn("Recreate driver instance failed", "driver", driver.Name, "resource_id", inst.resourceID, "error", err)
+	}
+	driver.instMu.Lock()
+	if driver.instances[inst.resourceID] == inst {
+		delete(driver.instances, inst.resourceID)
+	}
+	driver.instMu.Unlock()
+}
@@ -0,0 +1,211 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+func loadCrashTestDriver(t *testing.T, manager *DriverManager, configSchema string) *WasmDriver {
+	t.Helper()
+	if err := manager.LoadDriver(&models.Driver{ID: 2, Name: "crashy", ConfigSchema: configSchema}, crashDriverWasm(), 1); err != nil {
+		t.Fatalf("LoadDriver() error = %v", err)
+	}
+	t.Cleanup(func() { _ = manager.UnloadDriver(2) })
+	driver, _ := manager.GetDriver(2)
+	return driver
+}
+
+func TestParseDriverLimits(t *testing.T) {
+	defaults := pluginLimits{maxMemoryPages: 512}
+	if got := parseDriverLimits(`{"resource_id":1}`, defaults); got != defaults {
+		t.Fatalf("limits without override = %+v", got)
+	}
+	got := parseDriverLimits(`{"max_memory_pages":99999,"max_call_budget":500}`, defaults)
+	if got.maxMemoryPages != maxWasmMemoryPages || got.maxCalls != 500 {
+		t.Fatalf("limits with override = %+v", got)
+	}
+	if got := parseDriverLimits(`not json`, defaults); got != defaults {
+		t.Fatalf("limits with invalid schema = %+v", got)
+	}
+}
+
+func TestDriverManager_TrapRecreatesInstance(t *testing.T) {
+	manager := NewDriverManager()
+	driver := loadCrashTestDriver(t, manager, "")
+	before := driver.plugin
+
+	if _, err := manager.ExecuteDriver(2, "trap", &DriverContext{}); !errors.Is(err, ErrDriverExecutionFailed) {
+		t.Fatalf("trap err = %v, want ErrDriverExecutionFailed", err)
+	}
+	inst, err := manager.acquireInstance(driver, 1)
+	if err != nil {
+		t.Fatalf("acquireInstance() error = %v", err)
+	}
+	recreated := inst.plugin != before && inst.pinned
+	inst.mu.Unlock()
+	if !recreated {
+		t.Fatal("trapped instance was not recreated in place")
+	}
+
+	// 重建后的实例可继续调用（空输出不是崩溃）
+	if _, err := manager.ExecuteDriver(2, "handle", &DriverContext{}); !errors.Is(err, ErrDriverBadOutput) {
+		t.Fatalf("handle after trap err = %v, want ErrDriverBadOutput", err)
+	}
+	runtime, _ := manager.GetRuntime(2)
+	if runtime.CrashCount != 1 || !strings.Contains(runtime.LastTrap, "unreachable") || runtime.LastTrapAt == nil || runtime.Quarantined {
+		t.Fatalf("runtime crash info = %+v", runtime)
+	}
+}
+
+func TestDriverManager_PluginReportedErrorIsNotCrash(t *testing.T) {
+	manager := NewDriverManager()
+	manager.SetQuarantinePolicy(2, time.Minute)
+	driver := loadCrashTestDriver(t, manager, "")
+	before := driver.plugin
+
+	for i := 0; i < 3; i++ {
+		_, err := manager.ExecuteDriver(2, "fail", &DriverContext{})
+		if !errors.Is(err, ErrDriverExecutionFailed) || !strings.Contains(err.Error(), crashDriverErrorMessage) {
+			t.Fatalf("fail #%d err = %v, want reported %q", i+1, err, crashDriverErrorMessage)
+		}
+	}
+	inst, err := manager.acquireInstance(driver, 1)
+	if err != nil {
+		t.Fatalf("acquireInstance() error = %v", err)
+	}
+	kept := inst.plugin == before
+	inst.mu.Unlock()
+	if !kept {
+		t.Fatal("plugin-reported error recreated the instance")
+	}
+	runtime, _ := manager.GetRuntime(2)
+	if runtime.CrashCount != 0 || runtime.Quarantined {
+		t.Fatalf("plugin-reported errors counted as crashes: %+v", runtime)
+	}
+}
+
+func TestIsDriverCrash(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("wasm error: unreachable\nwasm stack trace:\n\t.$1()"), true},
+		{fmt.Errorf("%w (recovered by wazero)", ErrDriverCallBudgetExhausted), true},
+		{errors.New("runtime error: index out of range (recovered by wazero)"), true},
+		{sys.NewExitError(2), true},
+		{sys.NewExitError(sys.ExitCodeDeadlineExceeded), false},
+		{fmt.Errorf("%w: %s", ErrPluginReported, "device busy"), false},
+		{ErrPluginEmptyOutput, false},
+		{context.Canceled, false},
+		{errors.New("module closed"), false},
+	}
+	for _, tc := range cases {
+		if got := isDriverCrash(tc.err); got != tc.want {
+			t.Errorf("isDriverCrash(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestDriverManager_SandboxLimitsOffByDefault(t *testing.T) {
+	manager := NewDriverManager()
+	loadCrashTestDriver(t, manager, "")
+	runtime, _ := manager.GetRuntime(2)
+	if runtime.MaxMemoryPages != 0 || runtime.MaxCallBudget != 0 {
+		t.Fatalf("default runtime limits = %d/%d, want none", runtime.MaxMemoryPages, runtime.MaxCallBudget)
+	}
+}
+
+func TestDriverManager_SandboxLimits(t *testing.T) {
+	manager := NewDriverManager()
+	manager.SetSandboxLimits(64, 0)
+	loadCrashTestDriver(t, manager, `{"max_memory_pages":20,"max_call_budget":1000}`)
+
+	runtime, _ := manager.GetRuntime(2)
+	if runtime.MaxMemoryPages != 20 || runtime.MaxCallBudget != 1000 {
+		t.Fatalf("runtime limits = %d/%d, want 20/1000", runtime.MaxMemoryPages, runtime.MaxCallBudget)
+	}
+	if _, err := manager.ExecuteDriver(2, "grow", &DriverContext{}); !errors.Is(err, ErrDriverExecutionFailed) {
+		t.Fatalf("grow beyond memory cap err = %v, want ErrDriverExecutionFailed", err)
+	}
+	if _, err := manager.ExecuteDriver(2, "spin", &DriverContext{}); !errors.Is(err, ErrDriverCallBudgetExhausted) {
+		t.Fatalf("spin err = %v, want ErrDriverCallBudgetExhausted", err)
+	}
+	runtime, _ = manager.GetRuntime(2)
+	if runtime.CrashCount != 2 || !strings.Contains(runtime.LastTrap, "call budget exhausted") {
+		t.Fatalf("runtime crash info = %+v", runtime)
+	}
+}
+
+func TestDriverManager_TimeoutInterruptsSpin(t *testing.T) {
+	manager := NewDriverManager()
+	manager.SetCallTimeout(50 * time.Millisecond)
+	loadCrashTestDriver(t, manager, "")
+
+	if _, err := manager.ExecuteDriver(2, "spin", &DriverContext{}); !errors.Is(err, ErrDriverTimeout) {
+		t.Fatalf("spin err = %v, want ErrDriverTimeout", err)
+	}
+	// 超时关闭的模块已重建，且不计入崩溃
+	if _, err := manager.ExecuteDriver(2, "handle", &DriverContext{}); !errors.Is(err, ErrDriverBadOutput) {
+		t.Fatalf("handle after timeout err = %v, want ErrDriverBadOutput", err)
+	}
+	if runtime, _ := manager.GetRuntime(2); runtime.CrashCount != 0 {
+		t.Fatalf("timeout counted as crash: %+v", runtime)
+	}
+}
+
+func TestDriverManager_QuarantineAfterRepeatedCrashes(t *testing.T) {
+	manager := NewDriverManager()
+	manager.SetQuarantinePolicy(2, time.Minute)
+	driver := loadCrashTestDriver(t, manager, "")
+
+	// 非主实例在隔离时一并释放
+	if _, err := manager.ExecuteDriver(2, "handle", &DriverContext{ResourceID: 5}); !errors.Is(err, ErrDriverBadOutput) {
+		t.Fatalf("handle on resource 5 err = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := manager.ExecuteDriver(2, "trap", &DriverContext{}); !errors.Is(err, ErrDriverExecutionFailed) {
+			t.Fatalf("trap #%d err = %v", i+1, err)
+		}
+	}
+	if _, err := manager.ExecuteDriver(2, "handle", &DriverContext{}); !errors.Is(err, ErrDriverQuarantined) {
+		t.Fatalf("handle after quarantine err = %v, want ErrDriverQuarantined", err)
+	}
+	runtime, _ := manager.GetRuntime(2)
+	if !runtime.Quarantined || runtime.QuarantinedAt == nil || runtime.CrashCount != 2 {
+		t.Fatalf("runtime = %+v, want quarantined after 2 crashes", runtime)
+	}
+	if ids := driver.instanceResources(); len(ids) != 0 {
+		t.Fatalf("instances after quarantine = %v, want none", ids)
+	}
+
+	// 重载驱动解除隔离
+	if err := manager.ReloadDriver(&models.Driver{ID: 2, Name: "crashy"}, crashDriverWasm(), 1); err != nil {
+		t.Fatalf("ReloadDriver() error = %v", err)
+	}
+	if _, err := manager.ExecuteDriver(2, "handle", &DriverContext{}); !errors.Is(err, ErrDriverBadOutput) {
+		t.Fatalf("handle after reload err = %v, want ErrDriverBadOutput", err)
+	}
+}
+
+func TestDriverCrashState_WindowExpires(t *testing.T) {
+	var state driverCrashState
+	now := time.Now()
+	if state.record("a", now, 2, time.Minute) {
+		t.Fatal("quarantined after first crash")
+	}
+	if state.record("b", now.Add(2*time.Minute), 2, time.Minute) {
+		t.Fatal("crash outside window should not count")
+	}
+	if !state.record("c", now.Add(2*time.Minute+time.Second), 2, time.Minute) {
+		t.Fatal("expected quarantine after 2 crashes within window")
+	}
+}
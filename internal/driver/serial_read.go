@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"errors"
+	"time"
+)
+
+const driverReadRetryInterval = 2 * time.Millisecond
+
+var ErrDriverReadTimeout = errors.New("timeout")
+
+func readWithTimeout(port SerialPort, buf []byte, expect int, timeout time.Duration) (int, error) {
+	if timeout <= 0 {
+		timeout = driverReadRetryInterval
+	}
+	deadline := time.Now().Add(timeout)
+	read := 0
+	for read < expect {
+		n, err := port.Read(buf[read:expect])
+		if n > 0 {
+			read += n
+		}
+		if err != nil {
+			return read, err
+		}
+		if read >= expect {
+			break
+		}
+		if n > 0 {
+			continue
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		if remaining > driverReadRetryInterval {
+			remaining = driverReadRetryInterval
+		}
+		time.Sleep(remaining)
+	}
+	if read < expect {
+		return read, ErrDriverReadTimeout
+	}
+	return read, nil
+}
@@ -0,0 +1,255 @@
+package driver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TranscriptVersion 当前收发记录格式版本
+const TranscriptVersion = 1
+
+// Transcript 驱动总线收发记录：一次驱动调用的参数、按序的请求/应答帧与期望结果，
+// 用于脱离真实设备回放测试驱动
+type Transcript struct {
+	Version      int                  `json:"version"`
+	ResourceType string               `json:"resource_type"`     // serial / net
+	Address      string               `json:"address,omitempty"` // 资源路径，udp_send 地址为空时的目标
+	Function     string               `json:"function,omitempty"`
+	Config       map[string]string    `json:"config,omitempty"`
+	DeviceConfig string               `json:"device_config,omitempty"`
+	RecordedAt   *time.Time           `json:"recorded_at,omitempty"`
+	Exchanges    []TranscriptExchange `json:"exchanges"`
+	Expect       *TranscriptExpect    `json:"expect,omitempty"`
+}
+
+// 收发记录的交互类型
+const (
+	TranscriptOpTransceive = ""      // serial_transceive / tcp_transceive 写后读
+	TranscriptOpWrite      = "write" // serial_write / tcp_write / udp_send
+	TranscriptOpRead       = "read"  // serial_read / tcp_read / tcp_read_until / udp_recv
+)
+
+// TranscriptChannelUDP UDP 数据报交互；Channel 为空表示资源总线（串口或 TCP 连接）
+const TranscriptChannelUDP = "udp"
+
+// TranscriptExchange 一次总线交互：写后读的 Request/Response 成对出现（Response 为空表示设备未应答），
+// 仅写只有 Request，仅读只有 Response
+type TranscriptExchange struct {
+	Op       string   `json:"op,omitempty"`
+	Channel  string   `json:"channel,omitempty"`
+	Addr     string   `json:"addr,omitempty"` // UDP 对端地址
+	Request  HexFrame `json:"request,omitempty"`
+	Response HexFrame `json:"response,omitempty"`
+	DelayMs  int64    `json:"delay_ms"` // 请求发出（仅读时为开始读取）到收到应答的耗时
+}
+
+func (e *TranscriptExchange) describe() string {
+	op := e.Op
+	if op == TranscriptOpTransceive {
+		op = "transceive"
+	}
+	return op + " on " + transcriptChannelName(e.Channel)
+}
+
+func transcriptChannelName(channel string) string {
+	if channel == "" {
+		return "bus"
+	}
+	return channel
+}
+
+// TranscriptExpect 回放后对 DriverResult 的断言
+type TranscriptExpect struct {
+	Success *bool             `json:"success,omitempty"`
+	Points  map[string]string `json:"points,omitempty"` // 字段名 -> 期望值，数值按数值比较
+}
+
+// HexFrame 以 "01 03 00 00" 形式序列化的字节帧，解析时忽略空白与大小写
+type HexFrame []byte
+
+func (f HexFrame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("% X", []byte(f)))
+}
+
+func (f *HexFrame) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("hex frame must be a string: %w", err)
+	}
+	frame, err := ParseHexFrame(text)
+	if err != nil {
+		return err
+	}
+	*f = frame
+	return nil
+}
+
+// ParseHexFrame 解析十六进制帧文本，允许空格、制表符、冒号分隔
+func ParseHexFrame(text string) (HexFrame, error) {
+	compact := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', ':':
+			return -1
+		}
+		return r
+	}, text)
+	compact = strings.TrimPrefix(strings.TrimPrefix(compact, "0x"), "0X")
+	frame, err := hex.DecodeString(compact)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex frame %q: %w", text, err)
+	}
+	return frame, nil
+}
+
+// LoadTranscript 读取收发记录文件
+func LoadTranscript(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tr Transcript
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return nil, fmt.Errorf("parse transcript %s: %w", path, err)
+	}
+	if tr.Version != 0 && tr.Version != TranscriptVersion {
+		return nil, fmt.Errorf("unsupported transcript version %d", tr.Version)
+	}
+	tr.ResourceType = normalizeTranscriptResourceType(tr.ResourceType)
+	return &tr, nil
+}
+
+// SaveTranscript 写出收发记录文件
+func SaveTranscript(path string, tr *Transcript) error {
+	if tr.Version == 0 {
+		tr.Version = TranscriptVersion
+	}
+	data, err := json.MarshalIndent(tr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func normalizeTranscriptResourceType(resourceType string) string {
+	resourceType = strings.ToLower(strings.TrimSpace(resourceType))
+	if resourceType == "" || resourceType == "rtu" {
+		return "serial"
+	}
+	if resourceType == "tcp" {
+		return "net"
+	}
+	return resourceType
+}
+
+// TranscriptRecorder 按调用顺序记录串口、TCP 与 UDP 宿主函数的真实收发
+type TranscriptRecorder struct {
+	mu        sync.Mutex
+	exchanges []TranscriptExchange
+}
+
+func NewTranscriptRecorder() *TranscriptRecorder {
+	return &TranscriptRecorder{}
+}
+
+// Record 追加一次写后读交互，resp 为空表示未收到应答
+func (r *TranscriptRecorder) Record(req, resp []byte, elapsed time.Duration) {
+	r.append(TranscriptExchange{
+		Request:  append(HexFrame(nil), req...),
+		Response: append(HexFrame(nil), resp...),
+		DelayMs:  elapsed.Milliseconds(),
+	})
+}
+
+// RecordWrite 追加一次仅写交互；channel 为 TranscriptChannelUDP 时 addr 为目标地址
+func (r *TranscriptRecorder) RecordWrite(channel, addr string, data []byte) {
+	r.append(TranscriptExchange{
+		Op:      TranscriptOpWrite,
+		Channel: channel,
+		Addr:    addr,
+		Request: append(HexFrame(nil), data...),
+	})
+}
+
+// RecordRead 追加一次仅读交互，未读到数据（超时）不记录；channel 为 TranscriptChannelUDP 时 addr 为来源地址
+func (r *TranscriptRecorder) RecordRead(channel, addr string, data []byte, elapsed time.Duration) {
+	if len(data) == 0 {
+		return
+	}
+	r.append(TranscriptExchange{
+		Op:       TranscriptOpRead,
+		Channel:  channel,
+		Addr:     addr,
+		Response: append(HexFrame(nil), data...),
+		DelayMs:  elapsed.Milliseconds(),
+	})
+}
+
+func (r *TranscriptRecorder) append(exchange TranscriptExchange) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.exchanges = append(r.exchanges, exchange)
+	r.mu.Unlock()
+}
+
+// Exchanges 返回已记录交互的副本
+func (r *TranscriptRecorder) Exchanges() []TranscriptExchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]TranscriptExchange(nil), r.exchanges...)
+}
+
+// CheckTranscriptExpect 按期望校验驱动结果，返回不满足的断言（按字段名排序）
+func CheckTranscriptExpect(result *DriverResult, expect *TranscriptExpect) []string {
+	if expect == nil {
+		return nil
+	}
+	var failures []string
+	if expect.Success != nil {
+		success := result != nil && result.Success
+		if success != *expect.Success {
+			failures = append(failures, fmt.Sprintf("success = %v, want %v", success, *expect.Success))
+		}
+	}
+
+	fields := ResultFields(result)
+	names := make([]string, 0, len(expect.Points))
+	for name := range expect.Points {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		want := expect.Points[name]
+		got, ok := fields[name]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("point %s missing, want %s", name, want))
+			continue
+		}
+		if !transcriptValueEqual(got, want) {
+			failures = append(failures, fmt.Sprintf("point %s = %s, want %s", name, got, want))
+		}
+	}
+	return failures
+}
+
+func transcriptValueEqual(got, want string) bool {
+	got, want = strings.TrimSpace(got), strings.TrimSpace(want)
+	if got == want {
+		return true
+	}
+	g, errG := strconv.ParseFloat(got, 64)
+	w, errW := strconv.ParseFloat(want, 64)
+	if errG != nil || errW != nil {
+		return false
+	}
+	return math.Abs(g-w) <= 1e-6*math.Max(1, math.Abs(w))
+}
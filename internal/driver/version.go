@@ -18,14 +18,38 @@ type driverVersionPayload struct {
 	Error      string                 `json:"error"`
 }
 
+// driverMetadata 加载时从驱动读取的元数据
+type driverMetadata struct {
+	version    string
+	productKey string
+	manifest   *DriverManifest
+}
+
 // ExtractDriverMetadata reads driver metadata from wasm binary if exported.
 func ExtractDriverMetadata(wasmData []byte) (string, string, error) {
+	meta, err := extractDriverMetadata(wasmData)
+	if err != nil {
+		return "", "", err
+	}
+	return meta.version, meta.productKey, nil
+}
+
+// ExtractDriverManifest reads driver manifest from wasm binary, nil if not exported.
+func ExtractDriverManifest(wasmData []byte) (*DriverManifest, error) {
+	meta, err := extractDriverMetadata(wasmData)
+	if err != nil {
+		return nil, err
+	}
+	return meta.manifest, nil
+}
+
+func extractDriverMetadata(wasmData []byte) (driverMetadata, error) {
 	if len(wasmData) == 0 {
-		return "", "", fmt.Errorf("empty wasm data")
+		return driverMetadata{}, fmt.Errorf("empty wasm data")
 	}
-	plugin, err := newWasmPlugin("driver_version", wasmData, nil, nil)
+	plugin, err := newWasmPlugin("driver_version", wasmData, nil, nil, pluginLimits{})
 	if err != nil {
-		return "", "", err
+		return driverMetadata{}, err
 	}
 	defer func() {
 		_ = plugin.Close(context.Background())
@@ -35,24 +59,51 @@ func ExtractDriverMetadata(wasmData []byte) (string, string, error) {
 }
 
 func extractDriverVersionFromPlugin(plugin *extism.Plugin) (string, error) {
-	version, _, err := extractDriverMetadataFromPlugin(plugin)
-	return version, err
+	meta, err := extractDriverMetadataFromPlugin(plugin)
+	return meta.version, err
 }
 
-func extractDriverMetadataFromPlugin(plugin *extism.Plugin) (string, string, error) {
+// extractDriverMetadataFromPlugin 优先读取 manifest 导出，未声明版本时再回退到 version 导出
+func extractDriverMetadataFromPlugin(plugin *extism.Plugin) (driverMetadata, error) {
 	if plugin == nil {
-		return "", "", fmt.Errorf("nil plugin")
+		return driverMetadata{}, fmt.Errorf("nil plugin")
+	}
+
+	var meta driverMetadata
+	if plugin.FunctionExists("manifest") {
+		output, err := callMetadataFunction(plugin, "manifest")
+		if err != nil {
+			return driverMetadata{}, err
+		}
+		manifest, err := ParseDriverManifest(output)
+		if err != nil {
+			return driverMetadata{}, err
+		}
+		meta = driverMetadata{version: manifest.Version, productKey: manifest.ProductKey, manifest: manifest}
 	}
-	if !plugin.FunctionExists("version") {
-		return "", "", nil
+	if meta.version != "" || !plugin.FunctionExists("version") {
+		return meta, nil
 	}
 
-	rc, output, err := plugin.CallWithContext(context.Background(), "version", []byte("{}"))
+	output, err := callMetadataFunction(plugin, "version")
 	if err != nil {
-		return "", "", err
+		return driverMetadata{}, err
 	}
-	if rc != 0 {
-		// keep parsing output but note non-zero rc
+	version, productKey, err := parseDriverVersionOutput(output)
+	if err != nil {
+		return driverMetadata{}, err
+	}
+	meta.version = version
+	if meta.productKey == "" {
+		meta.productKey = productKey
+	}
+	return meta, nil
+}
+
+func callMetadataFunction(plugin *extism.Plugin, function string) ([]byte, error) {
+	_, output, err := plugin.CallWithContext(context.Background(), function, []byte("{}"))
+	if err != nil {
+		return nil, err
 	}
 	if len(output) == 0 {
 		if alt, err2 := plugin.GetOutput(); err2 == nil && len(alt) > 0 {
@@ -61,12 +112,11 @@ func extractDriverMetadataFromPlugin(plugin *extism.Plugin) (string, string, err
 	}
 	if len(output) == 0 {
 		if msg := plugin.GetError(); msg != "" {
-			return "", "", fmt.Errorf("driver version error: %s", msg)
+			return nil, fmt.Errorf("driver %s error: %s", function, msg)
 		}
-		return "", "", fmt.Errorf("driver version output empty")
+		return nil, fmt.Errorf("driver %s output empty", function)
 	}
-
-	return parseDriverVersionOutput(output)
+	return output, nil
 }
 
 func parseDriverVersionOutput(output []byte) (string, string, error) {
@@ -112,5 +162,10 @@ func (m *DriverManager) GetDriverVersion(id int64) (string, error) {
 	if version != "" {
 		return version, nil
 	}
-	return extractDriverVersionFromPlugin(driver.plugin)
+	inst, err := m.acquireInstance(driver, 0)
+	if err != nil {
+		return "", err
+	}
+	defer inst.mu.Unlock()
+	return extractDriverVersionFromPlugin(inst.plugin)
 }
@@ -0,0 +1,258 @@
+package driver
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// 测试用最小 WASM 模块构造器：驱动夹具以 Go 代码生成，源码即夹具，无需提交二进制
+
+const (
+	wasmI32 byte = 0x7f
+	wasmI64 byte = 0x7e
+)
+
+// 用到的指令操作码
+const (
+	opUnreachable  byte = 0x00
+	opBlock        byte = 0x02
+	opLoop         byte = 0x03
+	opIf           byte = 0x04
+	opEnd          byte = 0x0b
+	opBr           byte = 0x0c
+	opBrIf         byte = 0x0d
+	opReturn       byte = 0x0f
+	opCall         byte = 0x10
+	opDrop         byte = 0x1a
+	opLocalGet     byte = 0x20
+	opLocalSet     byte = 0x21
+	opI32Load8U    byte = 0x2d
+	opI32Store8    byte = 0x3a
+	opMemoryGrow   byte = 0x40
+	opI32Const     byte = 0x41
+	opI64Const     byte = 0x42
+	opI32Eqz       byte = 0x45
+	opI32Eq        byte = 0x46
+	opI32GeU       byte = 0x4f
+	opI64Eqz       byte = 0x50
+	opI64Ne        byte = 0x52
+	opI32Add       byte = 0x6a
+	opI64Add       byte = 0x7c
+	opI32WrapI64   byte = 0xa7
+	opI64ExtendU   byte = 0xad
+	blockTypeEmpty byte = 0x40
+)
+
+type wasmFunc struct {
+	typeIdx uint32
+	locals  []byte
+	body    []byte
+}
+
+type wasmModule struct {
+	types      [][]byte
+	imports    [][]byte
+	funcs      []wasmFunc
+	exports    [][]byte
+	data       [][]byte
+	memPages   uint32
+	numImports uint32
+}
+
+func newWasmModule(memPages uint32) *wasmModule {
+	return &wasmModule{memPages: memPages}
+}
+
+func (m *wasmModule) typeIndex(params, results []byte) uint32 {
+	sig := append([]byte{0x60}, wasmVec(len(params), params)...)
+	sig = append(sig, wasmVec(len(results), results)...)
+	for i, existing := range m.types {
+		if string(existing) == string(sig) {
+			return uint32(i)
+		}
+	}
+	m.types = append(m.types, sig)
+	return uint32(len(m.types) - 1)
+}
+
+// importFunc 导入宿主函数并返回函数索引，须在 function 之前调用
+func (m *wasmModule) importFunc(module, name string, params, results []byte) uint32 {
+	if len(m.funcs) > 0 {
+		panic("wasm imports must be declared before functions")
+	}
+	entry := append(wasmName(module), wasmName(name)...)
+	entry = append(entry, 0x00)
+	entry = append(entry, wasmULEB(uint64(m.typeIndex(params, results)))...)
+	m.imports = append(m.imports, entry)
+	m.numImports++
+	return m.numImports - 1
+}
+
+// function 添加函数并返回函数索引；locals 为参数之后的局部变量类型
+func (m *wasmModule) function(params, results, locals []byte, body ...[]byte) uint32 {
+	m.funcs = append(m.funcs, wasmFunc{typeIdx: m.typeIndex(params, results), locals: locals, body: append(seq(body...), opEnd)})
+	return m.numImports + uint32(len(m.funcs)) - 1
+}
+
+func (m *wasmModule) export(name string, funcIdx uint32) {
+	entry := append(wasmName(name), 0x00)
+	m.exports = append(m.exports, append(entry, wasmULEB(uint64(funcIdx))...))
+}
+
+// dataAt 在插件内存 offset 处放置初始数据
+func (m *wasmModule) dataAt(offset int32, content []byte) {
+	entry := append([]byte{0x00}, i32Const(offset)...)
+	entry = append(entry, opEnd)
+	entry = append(entry, wasmULEB(uint64(len(content)))...)
+	m.data = append(m.data, append(entry, content...))
+}
+
+func (m *wasmModule) bytes() []byte {
+	out := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	out = appendWasmSection(out, 1, wasmVecOf(m.types))
+	if len(m.imports) > 0 {
+		out = appendWasmSection(out, 2, wasmVecOf(m.imports))
+	}
+	funcTypes := make([][]byte, len(m.funcs))
+	codes := make([][]byte, len(m.funcs))
+	for i, fn := range m.funcs {
+		funcTypes[i] = wasmULEB(uint64(fn.typeIdx))
+		locals := make([][]byte, len(fn.locals))
+		for j, typ := range fn.locals {
+			locals[j] = []byte{0x01, typ}
+		}
+		body := append(wasmVecOf(locals), fn.body...)
+		codes[i] = append(wasmULEB(uint64(len(body))), body...)
+	}
+	out = appendWasmSection(out, 3, wasmVecOf(funcTypes))
+	out = appendWasmSection(out, 5, append([]byte{0x01, 0x00}, wasmULEB(uint64(m.memPages))...)) // 单个内存，仅 min
+	out = appendWasmSection(out, 7, wasmVecOf(m.exports))
+	out = appendWasmSection(out, 10, wasmVecOf(codes))
+	if len(m.data) > 0 {
+		out = appendWasmSection(out, 11, wasmVecOf(m.data))
+	}
+	return out
+}
+
+func appendWasmSection(out []byte, id byte, content []byte) []byte {
+	out = append(out, id)
+	out = append(out, wasmULEB(uint64(len(content)))...)
+	return append(out, content...)
+}
+
+func wasmVec(n int, content []byte) []byte {
+	return append(wasmULEB(uint64(n)), content...)
+}
+
+func wasmVecOf(items [][]byte) []byte {
+	out := wasmULEB(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func wasmName(name string) []byte {
+	return wasmVec(len(name), []byte(name))
+}
+
+func wasmULEB(v uint64) []byte {
+	return binary.AppendUvarint(nil, v)
+}
+
+func wasmSLEB(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// 带立即数的指令
+func i32Const(v int32) []byte    { return append([]byte{opI32Const}, wasmSLEB(int64(v))...) }
+func i64Const(v int64) []byte    { return append([]byte{opI64Const}, wasmSLEB(v)...) }
+func call(funcIdx uint32) []byte { return append([]byte{opCall}, wasmULEB(uint64(funcIdx))...) }
+func localGet(idx uint32) []byte { return append([]byte{opLocalGet}, wasmULEB(uint64(idx))...) }
+func localSet(idx uint32) []byte { return append([]byte{opLocalSet}, wasmULEB(uint64(idx))...) }
+func br(depth uint32) []byte     { return append([]byte{opBr}, wasmULEB(uint64(depth))...) }
+func brIf(depth uint32) []byte   { return append([]byte{opBrIf}, wasmULEB(uint64(depth))...) }
+func ops(codes ...byte) []byte   { return codes }
+
+// seq 拼接指令序列
+func seq(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// returnIf 栈顶条件（i32）成立时以 code 返回
+func returnIf(code int32) []byte {
+	return append(append([]byte{opIf, blockTypeEmpty}, i32Const(code)...), opReturn, opEnd)
+}
+
+// 内存访问指令（对齐 0、偏移 0）
+func i32Load8U() []byte  { return []byte{opI32Load8U, 0x00, 0x00} }
+func i32Store8() []byte  { return []byte{opI32Store8, 0x00, 0x00} }
+func memoryGrow() []byte { return []byte{opMemoryGrow, 0x00} }
+
+// extismEnv extism 内核函数的导入索引
+type extismEnv struct {
+	alloc, storeU8, loadU8, outputSet, errorSet uint32
+}
+
+func importExtismEnv(m *wasmModule) extismEnv {
+	const env = "extism:host/env"
+	i64 := []byte{wasmI64}
+	return extismEnv{
+		alloc:     m.importFunc(env, "alloc", i64, i64),
+		storeU8:   m.importFunc(env, "store_u8", []byte{wasmI64, wasmI32}, nil),
+		loadU8:    m.importFunc(env, "load_u8", i64, []byte{wasmI32}),
+		outputSet: m.importFunc(env, "output_set", []byte{wasmI64, wasmI64}, nil),
+		errorSet:  m.importFunc(env, "error_set", i64, nil),
+	}
+}
+
+// addCopyFunc 添加 copy(src i32, len i32) -> i64：把插件内存 [src, src+len) 复制到 extism 内存并返回偏移
+func addCopyFunc(m *wasmModule, env extismEnv) uint32 {
+	const src, length, dst, i = 0, 1, 2, 3
+	return m.function([]byte{wasmI32, wasmI32}, []byte{wasmI64}, []byte{wasmI64, wasmI32},
+		localGet(length), ops(opI64ExtendU), call(env.alloc), localSet(dst),
+		ops(opBlock, blockTypeEmpty, opLoop, blockTypeEmpty),
+		localGet(i), localGet(length), ops(opI32GeU), brIf(1),
+		localGet(dst), localGet(i), ops(opI64ExtendU, opI64Add),
+		localGet(src), localGet(i), ops(opI32Add), i32Load8U(),
+		call(env.storeU8),
+		localGet(i), i32Const(1), ops(opI32Add), localSet(i),
+		br(0),
+		ops(opEnd, opEnd),
+		localGet(dst),
+	)
+}
+
+// setOutput 把插件内存 [offset, offset+length) 设为调用输出
+func setOutput(env extismEnv, copyFn uint32, offset, length int32) []byte {
+	return seq(
+		i32Const(offset), i32Const(length), call(copyFn), i64Const(int64(length)), call(env.outputSet),
+	)
+}
+
+// jsonOutputTemplate 在插件内存 offset 处放置输出模板，返回模板长度与各 # 占位符的绝对地址
+func jsonOutputTemplate(m *wasmModule, offset int32, template string) (int32, []int32) {
+	var slots []int32
+	for i := 0; i < len(template); i++ {
+		if template[i] == '#' {
+			slots = append(slots, offset+int32(i))
+		}
+	}
+	if len(slots) == 0 {
+		panic(fmt.Sprintf("template %q has no # slot", template))
+	}
+	m.dataAt(offset, []byte(template))
+	return int32(len(template)), slots
+}
@@ -6,10 +6,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
-)
 
-const driverReadRetryInterval = 2 * time.Millisecond
+	extism "github.com/extism/go-sdk"
+)
 
 func validI64Ptr(ptr uint64) bool {
 	return ptr != 0 && ptr <= uint64(^uint32(0))
@@ -19,61 +18,31 @@ func validI64PtrSize(ptr uint64, size int) bool {
 	return validI64Ptr(ptr) && size > 0
 }
 
-func readWithTimeout(port SerialPort, buf []byte, expect int, timeout time.Duration) (int, error) {
-	if timeout <= 0 {
-		timeout = driverReadRetryInterval
-	}
-	deadline := time.Now().Add(timeout)
-	read := 0
-	for read < expect {
-		n, err := port.Read(buf[read:expect])
-		if n > 0 {
-			read += n
-		}
-		if err != nil {
-			return read, err
-		}
-		if read >= expect {
-			break
-		}
-		if n > 0 {
-			continue
-		}
-		remaining := time.Until(deadline)
-		if remaining <= 0 {
-			break
-		}
-		if remaining > driverReadRetryInterval {
-			remaining = driverReadRetryInterval
-		}
-		time.Sleep(remaining)
-	}
-	if read < expect {
-		return read, ErrDriverReadTimeout
-	}
-	return read, nil
-}
-
 var ErrPluginEmptyOutput = errors.New("plugin returned empty output")
-var ErrDriverReadTimeout = errors.New("timeout")
 
-func callPlugin(ctx context.Context, driver *WasmDriver, function string, input []byte) (uint32, []byte, error) {
+// ErrPluginReported 插件通过 error_set 主动报告的错误，属于驱动正常的失败路径
+var ErrPluginReported = errors.New("plugin reported error")
+
+func callPlugin(ctx context.Context, plugin *extism.Plugin, function string, input []byte) (uint32, []byte, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	rc, output, err := driver.plugin.CallWithContext(ctx, function, input)
+	rc, output, err := plugin.CallWithContext(ctx, function, input)
 	if err != nil {
+		if errMsg := plugin.GetError(); errMsg != "" && errMsg == err.Error() {
+			return rc, nil, fmt.Errorf("%w: %s", ErrPluginReported, errMsg)
+		}
 		return rc, nil, err
 	}
 
 	if len(output) == 0 {
-		if alt, err2 := driver.plugin.GetOutput(); err2 == nil && len(alt) > 0 {
+		if alt, err2 := plugin.GetOutput(); err2 == nil && len(alt) > 0 {
 			output = alt
 		}
 	}
 
 	if len(output) == 0 {
-		errMsg := driver.plugin.GetError()
+		errMsg := plugin.GetError()
 		if errMsg != "" {
 			return rc, nil, fmt.Errorf("%w: %s", ErrPluginEmptyOutput, errMsg)
 		}
@@ -7,9 +7,11 @@ import (
 	"log/slog"
 
 	extism "github.com/extism/go-sdk"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
 )
 
-func newWasmPlugin(driverName string, wasmData []byte, hostFuncs []extism.HostFunction, config map[string]string) (*extism.Plugin, error) {
+func newWasmPlugin(driverName string, wasmData []byte, hostFuncs []extism.HostFunction, config map[string]string, limits pluginLimits) (*extism.Plugin, error) {
 	manifest := extism.Manifest{
 		Wasm: []extism.Wasm{
 			&extism.WasmData{
@@ -18,9 +20,19 @@ func newWasmPlugin(driverName string, wasmData []byte, hostFuncs []extism.HostFu
 			},
 		},
 	}
+	if limits.maxMemoryPages > 0 {
+		manifest.Memory = &extism.ManifestMemory{MaxPages: limits.maxMemoryPages}
+	}
 
-	plugin, err := extism.NewPlugin(context.Background(), manifest, extism.PluginConfig{
-		EnableWasi: true,
+	// 调用预算在编译期挂载函数监听器，仅在配置了预算时启用
+	ctx := context.Background()
+	if limits.maxCalls > 0 {
+		ctx = experimental.WithFunctionListenerFactory(ctx, callBudgetListenerFactory{})
+	}
+	// 上下文超时/取消时关闭模块，中断无宿主调用的死循环
+	plugin, err := extism.NewPlugin(ctx, manifest, extism.PluginConfig{
+		EnableWasi:    true,
+		RuntimeConfig: wazero.NewRuntimeConfig().WithCloseOnContextDone(true),
 	}, hostFuncs)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,222 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	extism "github.com/extism/go-sdk"
+)
+
+// defaultPluginInstanceIdleTimeout 资源实例默认空闲回收时间
+const defaultPluginInstanceIdleTimeout = 10 * time.Minute
+
+// pluginInstance 驱动绑定到单个资源的插件实例
+// extism 插件非并发安全，调用期间需持有 mu；plugin 为 nil 表示实例已关闭
+type pluginInstance struct {
+	mu               sync.Mutex
+	plugin           *extism.Plugin
+	resourceID       int64
+	pinned           bool // 加载时创建的主实例，不参与空闲回收
+	lastUsedUnixNano int64
+}
+
+func newPinnedInstance(plugin *extism.Plugin, resourceID int64) *pluginInstance {
+	return &pluginInstance{
+		plugin:           plugin,
+		resourceID:       resourceID,
+		pinned:           true,
+		lastUsedUnixNano: time.Now().UnixNano(),
+	}
+}
+
+// closeLocked 关闭插件，调用方需持有 inst.mu
+func (inst *pluginInstance) closeLocked() {
+	if inst.plugin != nil {
+		_ = inst.plugin.Close(context.Background())
+		inst.plugin = nil
+	}
+}
+
+// SetInstanceIdleTimeout 设置资源实例空闲回收时间（为0表示不回收）
+func (m *DriverManager) SetInstanceIdleTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	m.mu.Lock()
+	m.instanceIdleTimeout = timeout
+	m.mu.Unlock()
+}
+
+// newResourcePlugin 创建绑定到指定资源的插件实例
+func (m *DriverManager) newResourcePlugin(name string, wasmData []byte, resourceID int64, limits pluginLimits) (*extism.Plugin, error) {
+	config := map[string]string{
+		"resource_id":      fmt.Sprintf("%d", resourceID),
+		"host_api_version": fmt.Sprintf("%d", HostAPIVersion),
+	}
+	return newWasmPlugin(name, wasmData, m.createHostFunctions(resourceID), config, limits)
+}
+
+// acquireInstance 获取驱动在资源上的实例（不存在则懒创建），返回时已持有实例锁
+// resourceID <= 0 时使用加载时绑定的资源
+func (m *DriverManager) acquireInstance(driver *WasmDriver, resourceID int64) (*pluginInstance, error) {
+	if resourceID <= 0 {
+		resourceID = driver.resourceID
+	}
+	for {
+		driver.instMu.Lock()
+		if driver.closed {
+			driver.instMu.Unlock()
+			return nil, ErrDriverNotLoaded
+		}
+		inst := driver.instances[resourceID]
+		if inst != nil {
+			driver.instMu.Unlock()
+			inst.mu.Lock()
+			if inst.plugin == nil {
+				// 等待期间实例被回收或创建失败，重新获取
+				inst.mu.Unlock()
+				continue
+			}
+			atomic.StoreInt64(&inst.lastUsedUnixNano, time.Now().UnixNano())
+			return inst, nil
+		}
+
+		// 先占位并持有实例锁，编译插件时不阻塞其他资源
+		inst = &pluginInstance{resourceID: resourceID, lastUsedUnixNano: time.Now().UnixNano()}
+		inst.mu.Lock()
+		if driver.instances == nil {
+			driver.instances = make(map[int64]*pluginInstance)
+		}
+		driver.instances[resourceID] = inst
+		wasmData, limits := driver.wasmData, driver.limits
+		driver.instMu.Unlock()
+
+		var plugin *extism.Plugin
+		err := fmt.Errorf("driver wasm is empty")
+		if len(wasmData) > 0 {
+			plugin, err = m.newResourcePlugin(driver.Name, wasmData, resourceID, limits)
+		}
+		if err != nil {
+			driver.instMu.Lock()
+			if driver.instances[resourceID] == inst {
+				delete(driver.instances, resourceID)
+			}
+			driver.instMu.Unlock()
+			inst.mu.Unlock()
+			return nil, fmt.Errorf("failed to create plugin for resource %d: %w", resourceID, err)
+		}
+		inst.plugin = plugin
+		slog.Debug("Driver instance created", "driver", driver.Name, "resource_id", resourceID)
+		return inst, nil
+	}
+}
+
+// evictIdleInstances 回收所有驱动中空闲超时的资源实例，正在执行的实例跳过
+func (m *DriverManager) evictIdleInstances(now time.Time) {
+	m.mu.RLock()
+	timeout := m.instanceIdleTimeout
+	drivers := make([]*WasmDriver, 0, len(m.drivers))
+	for _, d := range m.drivers {
+		drivers = append(drivers, d)
+	}
+	m.mu.RUnlock()
+	if timeout <= 0 {
+		return
+	}
+
+	deadline := now.Add(-timeout).UnixNano()
+	for _, d := range drivers {
+		d.removeInstances(func(inst *pluginInstance) bool {
+			return !inst.pinned && atomic.LoadInt64(&inst.lastUsedUnixNano) < deadline
+		})
+	}
+}
+
+// maybeEvictIdleInstances 按空闲时间的一半节流触发回收
+func (m *DriverManager) maybeEvictIdleInstances(now time.Time) {
+	m.mu.RLock()
+	timeout := m.instanceIdleTimeout
+	m.mu.RUnlock()
+	if timeout <= 0 {
+		return
+	}
+	last := atomic.LoadInt64(&m.lastEvictUnixNano)
+	if now.UnixNano()-last < int64(timeout/2) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&m.lastEvictUnixNano, last, now.UnixNano()) {
+		return
+	}
+	m.evictIdleInstances(now)
+}
+
+// releaseResourceInstances 资源关闭时释放各驱动在该资源上的非主实例
+func (m *DriverManager) releaseResourceInstances(resourceID int64) {
+	if m == nil || resourceID <= 0 {
+		return
+	}
+	m.mu.RLock()
+	drivers := make([]*WasmDriver, 0, len(m.drivers))
+	for _, d := range m.drivers {
+		drivers = append(drivers, d)
+	}
+	m.mu.RUnlock()
+
+	for _, d := range drivers {
+		d.removeInstances(func(inst *pluginInstance) bool {
+			return !inst.pinned && inst.resourceID == resourceID
+		})
+	}
+}
+
+// removeInstances 关闭并移除满足条件且空闲的实例
+func (d *WasmDriver) removeInstances(match func(*pluginInstance) bool) {
+	d.instMu.Lock()
+	defer d.instMu.Unlock()
+	for resourceID, inst := range d.instances {
+		if !match(inst) || !inst.mu.TryLock() {
+			continue
+		}
+		inst.closeLocked()
+		inst.mu.Unlock()
+		delete(d.instances, resourceID)
+		slog.Debug("Driver instance evicted", "driver", d.Name, "resource_id", resourceID)
+	}
+}
+
+// closeInstances 卸载时关闭全部实例，等待执行中的调用结束
+func (d *WasmDriver) closeInstances() {
+	d.instMu.Lock()
+	d.closed = true
+	instances := d.instances
+	d.instances = nil
+	d.instMu.Unlock()
+
+	for _, inst := range instances {
+		inst.mu.Lock()
+		inst.closeLocked()
+		inst.mu.Unlock()
+	}
+}
+
+// instanceResources 返回当前实例绑定的资源ID（升序）
+func (d *WasmDriver) instanceResources() []int64 {
+	d.instMu.Lock()
+	defer d.instMu.Unlock()
+	if len(d.instances) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(d.instances))
+	for resourceID := range d.instances {
+		ids = append(ids, resourceID)
+	}
+	slices.Sort(ids)
+	return ids
+}
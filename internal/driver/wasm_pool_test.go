@@ -0,0 +1,106 @@
+//go:build !no_extism
+
+package driver
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func loadPoolTestDriver(t *testing.T, manager *DriverManager) *WasmDriver {
+	t.Helper()
+	if err := manager.LoadDriver(&models.Driver{ID: 1, Name: "pool"}, minimalDriverWasm(), 1); err != nil {
+		t.Fatalf("LoadDriver() error = %v", err)
+	}
+	t.Cleanup(func() { _ = manager.UnloadDriver(1) })
+	driver, _ := manager.GetDriver(1)
+	return driver
+}
+
+func TestDriverManager_AcquireInstancePerResource(t *testing.T) {
+	manager := NewDriverManager()
+	driver := loadPoolTestDriver(t, manager)
+
+	// 持有资源 2 的实例时，资源 3 仍可获取独立实例
+	inst2, err := manager.acquireInstance(driver, 2)
+	if err != nil {
+		t.Fatalf("acquireInstance(2) error = %v", err)
+	}
+	inst3, err := manager.acquireInstance(driver, 3)
+	if err != nil {
+		t.Fatalf("acquireInstance(3) error = %v", err)
+	}
+	if inst2 == inst3 || inst2.plugin.Config["resource_id"] != "2" || inst3.plugin.Config["resource_id"] != "3" {
+		t.Fatalf("instances not bound per resource: %v / %v", inst2.plugin.Config, inst3.plugin.Config)
+	}
+	inst2.mu.Unlock()
+	inst3.mu.Unlock()
+
+	primary, err := manager.acquireInstance(driver, 0)
+	if err != nil {
+		t.Fatalf("acquireInstance(0) error = %v", err)
+	}
+	if primary.plugin != driver.plugin || !primary.pinned {
+		t.Fatalf("resource 0 should use the pinned load-time instance")
+	}
+	primary.mu.Unlock()
+
+	again, _ := manager.acquireInstance(driver, 2)
+	again.mu.Unlock()
+	if again != inst2 {
+		t.Fatalf("instance for resource 2 was not reused")
+	}
+
+	runtime, err := manager.GetRuntime(1)
+	if err != nil {
+		t.Fatalf("GetRuntime() error = %v", err)
+	}
+	if !slices.Equal(runtime.InstanceResources, []int64{1, 2, 3}) {
+		t.Fatalf("InstanceResources = %v, want [1 2 3]", runtime.InstanceResources)
+	}
+}
+
+func TestDriverManager_EvictsIdleInstances(t *testing.T) {
+	manager := NewDriverManager()
+	manager.SetInstanceIdleTimeout(time.Minute)
+	driver := loadPoolTestDriver(t, manager)
+
+	for _, resourceID := range []int64{2, 3, 4} {
+		inst, err := manager.acquireInstance(driver, resourceID)
+		if err != nil {
+			t.Fatalf("acquireInstance(%d) error = %v", resourceID, err)
+		}
+		inst.mu.Unlock()
+	}
+	old := time.Now().Add(-2 * time.Minute).UnixNano()
+	for _, inst := range driver.instances {
+		atomic.StoreInt64(&inst.lastUsedUnixNano, old)
+	}
+	// 资源 3 正在执行，不应被回收
+	busy := driver.instances[3]
+	busy.mu.Lock()
+	manager.evictIdleInstances(time.Now())
+	busy.mu.Unlock()
+
+	if got := driver.instanceResources(); !slices.Equal(got, []int64{1, 3}) {
+		t.Fatalf("after eviction = %v, want [1 3]", got)
+	}
+
+	manager.releaseResourceInstances(3)
+	manager.releaseResourceInstances(1)
+	if got := driver.instanceResources(); !slices.Equal(got, []int64{1}) {
+		t.Fatalf("after release = %v, want [1]", got)
+	}
+
+	if err := manager.UnloadDriver(1); err != nil {
+		t.Fatalf("UnloadDriver() error = %v", err)
+	}
+	if _, err := manager.acquireInstance(driver, 2); !errors.Is(err, ErrDriverNotLoaded) {
+		t.Fatalf("acquireInstance after unload error = %v, want ErrDriverNotLoaded", err)
+	}
+}
@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// WriteBatchConfigKey 批量写时 handle 输入 config 中携带全部字段的键，
+// 值为 [{"field_name":"a","value":"1"},...] 的 JSON 字符串；field_name/value 仍填第一个字段，兼容只支持单字段的驱动
+const WriteBatchConfigKey = "writes"
+
+// WriteItem 批量写中的单个字段
+type WriteItem struct {
+	FieldName string `json:"field_name"`
+	Value     string `json:"value"`
+}
+
+// WriteItemResult 单个字段的写入结果，驱动可在 DriverResult.Writes 中逐字段回报
+type WriteItemResult struct {
+	FieldName string `json:"field_name"`
+	Value     string `json:"value"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// WriteBatchResult 一次（批量）写的汇总结果
+type WriteBatchResult struct {
+	Success   bool              `json:"success"`           // 全部字段写入成功
+	Partial   bool              `json:"partial,omitempty"` // 仅部分字段成功
+	Writes    []WriteItemResult `json:"writes"`
+	Error     string            `json:"error,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// WriteInvoker 以给定 config 调用一次驱动写函数
+type WriteInvoker func(config map[string]string) (*DriverResult, error)
+
+// ParseWriteItems 解析 config 中的 writes 字段
+func ParseWriteItems(raw string) ([]WriteItem, error) {
+	var items []WriteItem
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", WriteBatchConfigKey, err)
+	}
+	for i := range items {
+		items[i].FieldName = strings.TrimSpace(items[i].FieldName)
+		if items[i].FieldName == "" {
+			return nil, fmt.Errorf("invalid %s: item %d missing field_name", WriteBatchConfigKey, i)
+		}
+	}
+	return items, nil
+}
+
+// ExecuteWriteBatch 写入全部字段。batch 为 true（驱动声明支持批量写）时一次调用写入全部字段，
+// 驱动未在 Writes 中逐字段回报时全部字段取整体结果；否则逐个字段调用，不携带 writes。
+// 仅首次调用本身出错（驱动未加载、链路异常等）时返回 error。
+func ExecuteWriteBatch(invoke WriteInvoker, base map[string]string, items []WriteItem, batch bool) (*WriteBatchResult, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("write params missing field_name")
+	}
+	if !batch {
+		return executeWritesPerField(invoke, base, items)
+	}
+
+	config := buildWriteItemConfig(base, items[0])
+	if len(items) > 1 {
+		encoded, err := json.Marshal(items)
+		if err != nil {
+			return nil, err
+		}
+		config[WriteBatchConfigKey] = string(encoded)
+	}
+	result, err := invoke(config)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := indexWriteResults(result)
+	results := make([]WriteItemResult, 0, len(items))
+	for _, item := range items {
+		if reported == nil {
+			results = append(results, writeItemResultFrom(item, result, nil))
+			continue
+		}
+		itemResult, ok := reported[item.FieldName]
+		if !ok {
+			itemResult = WriteItemResult{Error: "driver reported no result for field"}
+		}
+		itemResult.FieldName = item.FieldName
+		itemResult.Value = item.Value
+		results = append(results, itemResult)
+	}
+	return summarizeWriteResults(results), nil
+}
+
+func executeWritesPerField(invoke WriteInvoker, base map[string]string, items []WriteItem) (*WriteBatchResult, error) {
+	results := make([]WriteItemResult, 0, len(items))
+	for i, item := range items {
+		result, err := invoke(buildWriteItemConfig(base, item))
+		if err != nil && i == 0 {
+			return nil, err
+		}
+		results = append(results, writeItemResultFrom(item, result, err))
+	}
+	return summarizeWriteResults(results), nil
+}
+
+// SupportsBatchWrite 设备的驱动是否声明支持批量写；内置 Modbus 始终支持
+func (e *DriverExecutor) SupportsBatchWrite(device *models.Device) bool {
+	if IsNativeModbusDevice(device) {
+		return true
+	}
+	if e == nil || e.manager == nil || device == nil || device.DriverID == nil {
+		return false
+	}
+	manifest, err := e.manager.GetDriverManifest(*device.DriverID)
+	return err == nil && manifest != nil && manifest.BatchWrite
+}
+
+func buildWriteItemConfig(base map[string]string, item WriteItem) map[string]string {
+	config := maps.Clone(base)
+	if config == nil {
+		config = make(map[string]string, 3)
+	}
+	delete(config, WriteBatchConfigKey)
+	config["func_name"] = "write"
+	config["field_name"] = item.FieldName
+	config["value"] = item.Value
+	return config
+}
+
+func indexWriteResults(result *DriverResult) map[string]WriteItemResult {
+	if result == nil || len(result.Writes) == 0 {
+		return nil
+	}
+	out := make(map[string]WriteItemResult, len(result.Writes))
+	for _, item := range result.Writes {
+		out[strings.TrimSpace(item.FieldName)] = item
+	}
+	return out
+}
+
+func writeItemResultFrom(item WriteItem, result *DriverResult, err error) WriteItemResult {
+	out := WriteItemResult{FieldName: item.FieldName, Value: item.Value, Success: true}
+	switch {
+	case err != nil:
+		out.Success = false
+		out.Error = err.Error()
+	case result != nil && !result.Success:
+		out.Success = false
+		out.Error = strings.TrimSpace(result.Error)
+		if out.Error == "" {
+			out.Error = "driver write returned success=false"
+		}
+	}
+	return out
+}
+
+func summarizeWriteResults(results []WriteItemResult) *WriteBatchResult {
+	summary := &WriteBatchResult{Writes: results, Timestamp: time.Now()}
+	var failed []string
+	for _, item := range results {
+		if !item.Success {
+			failed = append(failed, item.FieldName+": "+item.Error)
+		}
+	}
+	summary.Success = len(failed) == 0
+	summary.Partial = len(failed) > 0 && len(failed) < len(results)
+	if len(failed) == 1 && len(results) == 1 {
+		summary.Error = results[0].Error
+	} else if len(failed) > 0 {
+		summary.Error = fmt.Sprintf("%d/%d fields failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return summary
+}
@@ -0,0 +1,95 @@
+package driver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExecuteWriteBatch_NativeModbusReportsPerField(t *testing.T) {
+	slave := newFakeModbusSlave()
+	executor := NewDriverExecutor(NewDriverManager())
+	executor.RegisterSerialPort(5, &fakeRTUPort{slave: slave})
+	device := newNativeModbusTestDevice("modbus_rtu", "serial", 5)
+
+	invoke := func(config map[string]string) (*DriverResult, error) {
+		return executor.ExecuteCommand(device, "handle", config)
+	}
+	items := []WriteItem{{FieldName: "setpoint", Value: "12.5"}, {FieldName: "Ua", Value: "1"}, {FieldName: "relay", Value: "1"}}
+	if !executor.SupportsBatchWrite(device) {
+		t.Fatal("native modbus should support batch write")
+	}
+	result, err := ExecuteWriteBatch(invoke, map[string]string{"device_address": "3"}, items, true)
+	if err != nil {
+		t.Fatalf("ExecuteWriteBatch() error = %v", err)
+	}
+	if result.Success || !result.Partial || len(result.Writes) != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !result.Writes[0].Success || result.Writes[1].Success || !result.Writes[2].Success {
+		t.Fatalf("unexpected per-field results: %+v", result.Writes)
+	}
+	if !slave.coils[0] || slave.requests != 2 {
+		t.Fatalf("writes not applied: coils=%v requests=%d", slave.coils, slave.requests)
+	}
+}
+
+func TestExecuteWriteBatch_SingleFieldDriverWritesPerField(t *testing.T) {
+	var calls []map[string]string
+	invoke := func(config map[string]string) (*DriverResult, error) {
+		calls = append(calls, config)
+		switch config["field_name"] {
+		case "b":
+			return nil, errors.New("timeout")
+		case "c":
+			return &DriverResult{Success: false, Error: " busy "}, nil
+		}
+		return &DriverResult{Success: true}, nil
+	}
+
+	items := []WriteItem{{FieldName: "a", Value: "1"}, {FieldName: "b", Value: "2"}, {FieldName: "c", Value: "3"}}
+	result, err := ExecuteWriteBatch(invoke, map[string]string{"product_key": "pk"}, items, false)
+	if err != nil {
+		t.Fatalf("ExecuteWriteBatch() error = %v", err)
+	}
+	if len(calls) != 3 || calls[0][WriteBatchConfigKey] != "" || calls[0]["field_name"] != "a" || calls[2]["product_key"] != "pk" {
+		t.Fatalf("unexpected calls: %+v", calls)
+	}
+	if !result.Partial || result.Writes[1].Success || result.Writes[1].Error != "timeout" || result.Writes[2].Error != "busy" || !result.Writes[0].Success {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecuteWriteBatch_BatchDriverWithoutPerFieldResults(t *testing.T) {
+	var calls []map[string]string
+	invoke := func(config map[string]string) (*DriverResult, error) {
+		calls = append(calls, config)
+		return &DriverResult{Success: true}, nil
+	}
+
+	items := []WriteItem{{FieldName: "a", Value: "1"}, {FieldName: "b", Value: "2"}}
+	result, err := ExecuteWriteBatch(invoke, nil, items, true)
+	if err != nil {
+		t.Fatalf("ExecuteWriteBatch() error = %v", err)
+	}
+	// 声明批量写的驱动只调用一次，不因未回报 Writes 而重复写入
+	if len(calls) != 1 || calls[0][WriteBatchConfigKey] == "" {
+		t.Fatalf("unexpected calls: %+v", calls)
+	}
+	if !result.Success || len(result.Writes) != 2 || !result.Writes[1].Success {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestExecuteWriteBatch_FirstCallErrorAborts(t *testing.T) {
+	invoke := func(map[string]string) (*DriverResult, error) {
+		return nil, ErrDriverNotFound
+	}
+	for _, batch := range []bool{true, false} {
+		if _, err := ExecuteWriteBatch(invoke, nil, []WriteItem{{FieldName: "a"}, {FieldName: "b"}}, batch); !errors.Is(err, ErrDriverNotFound) {
+			t.Fatalf("batch=%v err = %v, want ErrDriverNotFound", batch, err)
+		}
+	}
+	if _, err := ExecuteWriteBatch(invoke, nil, nil, false); err == nil {
+		t.Fatal("expected error for empty batch")
+	}
+}
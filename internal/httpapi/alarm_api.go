@@ -12,6 +12,7 @@ func NewAlarmAPI(alarmService *service.AlarmService) *AlarmAPI {
 
 var (
 	errListAlarmLogsFailed    = APIErrorDef{Code: "E_LIST_ALARM_LOGS_FAILED", Message: "获取报警日志失败"}
+	errListActiveAlarmsFailed = APIErrorDef{Code: "E_LIST_ACTIVE_ALARMS_FAILED", Message: "获取当前报警失败"}
 	errAcknowledgeAlarmFailed = APIErrorDef{Code: "E_ACKNOWLEDGE_ALARM_FAILED", Message: "确认报警失败"}
 	errDeleteAlarmFailed      = APIErrorDef{Code: "E_DELETE_ALARM_FAILED", Message: "删除报警失败"}
 	errBatchDeleteAlarmFailed = APIErrorDef{Code: "E_BATCH_DELETE_ALARM_FAILED", Message: "批量删除报警失败"}
@@ -10,3 +10,12 @@ func (api *AlarmAPI) GetAlarmLogs(w http.ResponseWriter, r *http.Request) {
 	}
 	WriteSuccess(w, logs)
 }
+
+func (api *AlarmAPI) GetActiveAlarms(w http.ResponseWriter, r *http.Request) {
+	alarms, err := api.service.ListActiveAlarms()
+	if err != nil {
+		writeServerErrorWithLog(w, errListActiveAlarmsFailed, err)
+		return
+	}
+	WriteSuccess(w, alarms)
+}
@@ -0,0 +1,20 @@
+package httpapi
+
+import "github.com/gonglijing/xunjiFsu/internal/service"
+
+type BackupAPI struct {
+	service *service.BackupService
+}
+
+func NewBackupAPI(backupService *service.BackupService) *BackupAPI {
+	return &BackupAPI{service: backupService}
+}
+
+var (
+	errListBackupsFailed   = APIErrorDef{Code: "E_LIST_BACKUPS_FAILED", Message: "获取备份列表失败"}
+	errCreateBackupFailed  = APIErrorDef{Code: "E_CREATE_BACKUP_FAILED", Message: "创建备份失败"}
+	errOpenBackupFailed    = APIErrorDef{Code: "E_OPEN_BACKUP_FAILED", Message: "读取备份失败"}
+	errRestoreBackupFailed = APIErrorDef{Code: "E_RESTORE_BACKUP_FAILED", Message: "恢复备份失败"}
+	errBackupNotFound      = APIErrorDef{Code: "E_BACKUP_NOT_FOUND", Message: "备份不存在"}
+	errBackupCorrupted     = APIErrorDef{Code: "E_BACKUP_CORRUPTED", Message: "备份校验失败"}
+)
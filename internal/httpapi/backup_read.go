@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+)
+
+// ListBackups 列出备份归档（新的在前）
+func (api *BackupAPI) ListBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := api.service.List()
+	if err != nil {
+		writeServerErrorWithLog(w, errListBackupsFailed, err)
+		return
+	}
+	WriteSuccess(w, backups)
+}
+
+// DownloadBackup 下载备份归档，响应头 X-Checksum-Sha256 携带整包校验和
+func (api *BackupAPI) DownloadBackup(w http.ResponseWriter, r *http.Request) {
+	file, info, err := api.service.Open(r.PathValue("name"))
+	if err != nil {
+		if errors.Is(err, database.ErrBackupNotFound) {
+			WriteNotFoundDef(w, errBackupNotFound)
+			return
+		}
+		writeServerErrorWithLog(w, errOpenBackupFailed, err)
+		return
+	}
+	defer file.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "application/gzip")
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, info.Name))
+	header.Set("Cache-Control", "no-store")
+	if info.SHA256 != "" {
+		header.Set("X-Checksum-Sha256", info.SHA256)
+	}
+	http.ServeContent(w, r, info.Name, info.CreatedAt, file)
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+)
+
+// CreateBackup 立即创建一份备份
+func (api *BackupAPI) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	info, err := api.service.Create()
+	if err != nil {
+		writeServerErrorWithLog(w, errCreateBackupFailed, err)
+		return
+	}
+	WriteCreated(w, info)
+}
+
+// RestoreBackup 校验后停止采集与北向并恢复备份，完成后需重启网关
+func (api *BackupAPI) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	result, err := api.service.Restore(r.PathValue("name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrBackupNotFound):
+			WriteNotFoundDef(w, errBackupNotFound)
+		case errors.Is(err, database.ErrBackupCorrupted):
+			WriteBadRequestCode(w, errBackupCorrupted.Code, errBackupCorrupted.Message+": "+err.Error())
+		default:
+			writeServerErrorWithLog(w, errRestoreBackupFailed, err)
+		}
+		return
+	}
+	WriteSuccess(w, result)
+}
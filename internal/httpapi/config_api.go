@@ -0,0 +1,19 @@
+package httpapi
+
+import "github.com/gonglijing/xunjiFsu/internal/service"
+
+type ConfigAPI struct {
+	service *service.ConfigBundleService
+}
+
+func NewConfigAPI(bundleService *service.ConfigBundleService) *ConfigAPI {
+	return &ConfigAPI{service: bundleService}
+}
+
+var (
+	errExportConfigFailed  = APIErrorDef{Code: "E_EXPORT_CONFIG_FAILED", Message: "导出配置失败"}
+	errImportConfigFailed  = APIErrorDef{Code: "E_IMPORT_CONFIG_FAILED", Message: "导入配置失败"}
+	errConfigBundleQuery   = APIErrorDef{Code: "E_CONFIG_BUNDLE_QUERY_INVALID", Message: "导入导出参数无效"}
+	errConfigBundleBody    = APIErrorDef{Code: "E_CONFIG_BUNDLE_BODY_INVALID", Message: "配置包解析失败"}
+	errConfigBundleInvalid = APIErrorDef{Code: "E_CONFIG_BUNDLE_INVALID", Message: "配置包内容无效"}
+)
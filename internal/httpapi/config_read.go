@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/platform/yamlconv"
+	"github.com/gonglijing/xunjiFsu/internal/service"
+)
+
+const (
+	configBundleFormatJSON = "json"
+	configBundleFormatYAML = "yaml"
+)
+
+// ExportConfig 导出 param.db 配置包（JSON/YAML），可选附带驱动文件与明文密钥
+func (api *ConfigAPI) ExportConfig(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	format, err := parseConfigBundleFormat(values.Get("format"))
+	if err != nil {
+		WriteBadRequestCode(w, errConfigBundleQuery.Code, errConfigBundleQuery.Message+": "+err.Error())
+		return
+	}
+	var opts service.ConfigExportOptions
+	if opts.IncludeDrivers, err = parseOptionalBool(values.Get("include_drivers")); err != nil {
+		WriteBadRequestCode(w, errConfigBundleQuery.Code, errConfigBundleQuery.Message+": invalid include_drivers")
+		return
+	}
+	if opts.IncludeSecrets, err = parseOptionalBool(values.Get("include_secrets")); err != nil {
+		WriteBadRequestCode(w, errConfigBundleQuery.Code, errConfigBundleQuery.Message+": invalid include_secrets")
+		return
+	}
+
+	bundle, err := api.service.Export(opts)
+	if err != nil {
+		writeServerErrorWithLog(w, errExportConfigFailed, err)
+		return
+	}
+	body, err := json.MarshalIndent(bundle, "", "  ")
+	if err == nil && format == configBundleFormatYAML {
+		body, err = yamlconv.FromJSON(body)
+	}
+	if err != nil {
+		writeServerErrorWithLog(w, errExportConfigFailed, err)
+		return
+	}
+
+	header := w.Header()
+	if format == configBundleFormatYAML {
+		header.Set("Content-Type", "application/yaml; charset=utf-8")
+	} else {
+		header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="fsu_config_%s.%s"`, time.Now().Format("20060102T150405"), format))
+	header.Set("Cache-Control", "no-store")
+	_, _ = w.Write(body)
+}
+
+func parseConfigBundleFormat(raw string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(raw)); format {
+	case "", configBundleFormatJSON:
+		return configBundleFormatJSON, nil
+	case configBundleFormatYAML, "yml":
+		return configBundleFormatYAML, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q", raw)
+	}
+}
+
+func parseOptionalBool(raw string) (bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(raw)
+}
@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gonglijing/xunjiFsu/internal/platform/yamlconv"
+	"github.com/gonglijing/xunjiFsu/internal/service"
+)
+
+// maxConfigBundleBytes 配置包请求体上限（含 base64 驱动文件）
+const maxConfigBundleBytes = 64 << 20
+
+// ImportConfig 导入配置包：mode=merge|replace，dry_run=true 只返回差异，
+// path_map=旧路径=新路径（可重复）重映射资源路径与设备串口
+func (api *ConfigAPI) ImportConfig(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	opts := service.ConfigImportOptions{Mode: values.Get("mode")}
+	var err error
+	if opts.DryRun, err = parseOptionalBool(values.Get("dry_run")); err != nil {
+		WriteBadRequestCode(w, errConfigBundleQuery.Code, errConfigBundleQuery.Message+": invalid dry_run")
+		return
+	}
+	if opts.PathMap, err = parseConfigPathMap(values["path_map"]); err != nil {
+		WriteBadRequestCode(w, errConfigBundleQuery.Code, errConfigBundleQuery.Message+": "+err.Error())
+		return
+	}
+
+	bundle, err := decodeConfigBundle(r)
+	if err != nil {
+		WriteBadRequestCode(w, errConfigBundleBody.Code, errConfigBundleBody.Message+": "+err.Error())
+		return
+	}
+
+	result, err := api.service.Import(bundle, opts)
+	if err != nil {
+		if errors.Is(err, service.ErrConfigBundleInvalid) {
+			WriteBadRequestCode(w, errConfigBundleInvalid.Code, errConfigBundleInvalid.Message+": "+err.Error())
+			return
+		}
+		if result != nil {
+			slog.Error("Config import stopped after partial apply", "changes", len(result.Changes), "error", err)
+		}
+		writeServerErrorWithLog(w, errImportConfigFailed, err)
+		return
+	}
+	WriteSuccess(w, result)
+}
+
+// decodeConfigBundle 按 format 参数、Content-Type 或首字符识别 JSON/YAML
+func decodeConfigBundle(r *http.Request) (*service.ConfigBundle, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxConfigBundleBytes))
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, errors.New("empty body")
+	}
+
+	isYAML := strings.Contains(strings.ToLower(r.Header.Get("Content-Type")), "yaml") || trimmed[0] != '{'
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		format, err := parseConfigBundleFormat(raw)
+		if err != nil {
+			return nil, err
+		}
+		isYAML = format == configBundleFormatYAML
+	}
+	if isYAML {
+		if body, err = yamlconv.ToJSON(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var bundle service.ConfigBundle
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func parseConfigPathMap(raw []string) (map[string]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	pathMap := make(map[string]string, len(raw))
+	for _, item := range raw {
+		from, to, ok := strings.Cut(item, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, errors.New("path_map must be old=new")
+		}
+		pathMap[from] = to
+	}
+	return pathMap, nil
+}
@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeConfigBundle_JSONAndYAML(t *testing.T) {
+	bodies := map[string]string{
+		"json": `{"format":"xunji-fsu-config","version":1,"resources":[{"name":"com1","type":"serial","path":"/dev/ttyUSB0"}]}`,
+		"yaml": "format: xunji-fsu-config\nversion: 1\nresources:\n  - name: com1\n    type: serial\n    path: /dev/ttyUSB0\n",
+	}
+	for name, body := range bodies {
+		r := httptest.NewRequest(http.MethodPost, "/config/import", strings.NewReader(body))
+		bundle, err := decodeConfigBundle(r)
+		if err != nil {
+			t.Fatalf("%s: decodeConfigBundle() error = %v", name, err)
+		}
+		if bundle.Version != 1 || len(bundle.Resources) != 1 || bundle.Resources[0].Path != "/dev/ttyUSB0" {
+			t.Fatalf("%s: bundle = %+v", name, bundle)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/config/import?format=json", strings.NewReader("format: x\n"))
+	if _, err := decodeConfigBundle(r); err == nil {
+		t.Fatalf("expected error when format=json and body is yaml")
+	}
+}
+
+func TestParseConfigPathMap(t *testing.T) {
+	got, err := parseConfigPathMap([]string{"/dev/ttyUSB0=/dev/ttyS1", " /dev/ttyUSB1 = /dev/ttyS2 "})
+	if err != nil || got["/dev/ttyUSB0"] != "/dev/ttyS1" || got["/dev/ttyUSB1"] != "/dev/ttyS2" {
+		t.Fatalf("parseConfigPathMap() = %v, %v", got, err)
+	}
+	if _, err := parseConfigPathMap([]string{"/dev/ttyUSB0"}); err == nil {
+		t.Fatalf("expected error without '='")
+	}
+}
@@ -0,0 +1,175 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/service"
+)
+
+// historyExportWriteTimeout 导出期间每写完一页顺延的写超时
+const historyExportWriteTimeout = 60 * time.Second
+
+// ExportHistoryData 流式导出历史数据（CSV 长表/宽表或 Parquet）
+func (api *DataAPI) ExportHistoryData(w http.ResponseWriter, r *http.Request) {
+	req, err := parseHistoryExportRequest(r)
+	if err != nil {
+		WriteBadRequestCode(w, errHistoryExportQueryDef.Code, errHistoryExportQueryDef.Message+": "+err.Error())
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", historyExportContentType(req))
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, historyExportFileName(req)))
+	header.Set("Cache-Control", "no-store")
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(historyExportWriteTimeout))
+	out := &exportResponseWriter{w: w}
+	flush := func() {
+		_ = rc.SetWriteDeadline(time.Now().Add(historyExportWriteTimeout))
+		_ = rc.Flush()
+	}
+
+	err = api.service.ExportHistoryData(r.Context(), out, req, flush)
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+	case !out.written:
+		header.Del("Content-Disposition")
+		writeServerErrorWithLog(w, errExportHistoryData, err)
+	default:
+		// 响应已开始发送，只能中断连接让客户端感知下载不完整
+		slog.Error("Export history data failed", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportResponseWriter 记录是否已开始写出响应体
+type exportResponseWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (e *exportResponseWriter) Write(p []byte) (int, error) {
+	e.written = true
+	return e.w.Write(p)
+}
+
+func parseHistoryExportRequest(r *http.Request) (service.HistoryExportRequest, error) {
+	values := r.URL.Query()
+	req := service.HistoryExportRequest{
+		Format: strings.ToLower(strings.TrimSpace(values.Get("format"))),
+		Layout: strings.ToLower(strings.TrimSpace(values.Get("layout"))),
+	}
+
+	loc, err := parseExportLocation(values.Get("tz"))
+	if err != nil {
+		return req, errors.New(errHistoryInvalidTZ)
+	}
+	req.Location = loc
+
+	for _, raw := range splitQueryList(values["device_id"]) {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || (id <= 0 && id != -1) {
+			return req, errors.New(errInvalidDeviceID)
+		}
+		req.Query.DeviceIDs = append(req.Query.DeviceIDs, id)
+	}
+	req.Query.FieldNames = splitQueryList(values["field_name"])
+
+	startTime, err := parseTimeParamInLocation(strings.TrimSpace(values.Get("start")), loc)
+	if err != nil {
+		return req, fmt.Errorf("Invalid start time")
+	}
+	endTime, err := parseTimeParamInLocation(strings.TrimSpace(values.Get("end")), loc)
+	if err != nil {
+		return req, fmt.Errorf("Invalid end time")
+	}
+	if startTime.IsZero() {
+		return req, errors.New(errHistoryExportNoStart)
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if startTime.After(endTime) {
+		return req, errors.New(errHistoryStartAfterEnd)
+	}
+	req.Query.StartTime = startTime
+	req.Query.EndTime = endTime
+
+	if raw := strings.TrimSpace(values.Get("gzip")); raw != "" {
+		req.Gzip, err = strconv.ParseBool(raw)
+		if err != nil {
+			return req, fmt.Errorf("Invalid gzip")
+		}
+	}
+
+	return service.ValidateHistoryExport(req)
+}
+
+// splitQueryList 支持重复参数与逗号分隔两种写法，去除空项与重复项
+func splitQueryList(raw []string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, item := range raw {
+		for _, part := range strings.Split(item, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			if _, ok := seen[part]; ok {
+				continue
+			}
+			seen[part] = struct{}{}
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseExportLocation 支持 IANA 时区名（如 Asia/Shanghai）与固定偏移（如 +08:00），默认 UTC
+func parseExportLocation(raw string) (*time.Location, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.UTC, nil
+	}
+	if raw[0] == '+' || raw[0] == '-' {
+		ts, err := time.Parse("-07:00", raw)
+		if err != nil {
+			return nil, err
+		}
+		_, offset := ts.Zone()
+		return time.FixedZone(raw, offset), nil
+	}
+	return time.LoadLocation(raw)
+}
+
+func historyExportContentType(req service.HistoryExportRequest) string {
+	switch {
+	case req.Format == service.HistoryExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	case req.Gzip:
+		return "application/gzip"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+func historyExportFileName(req service.HistoryExportRequest) string {
+	name := fmt.Sprintf("history_%s_%s",
+		req.Query.StartTime.In(req.Location).Format("20060102T1504"),
+		req.Query.EndTime.In(req.Location).Format("20060102T1504"))
+	if req.Format == service.HistoryExportFormatParquet {
+		return name + ".parquet"
+	}
+	if req.Gzip {
+		return name + ".csv.gz"
+	}
+	return name + ".csv"
+}
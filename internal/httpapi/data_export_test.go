@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/service"
+)
+
+func TestParseHistoryExportRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/data/export?device_id=1,2&device_id=2&field_name=Ua&field_name=Ia,Ua&start=2026-10-01%2000:00:00&end=2026-10-31T00:00&tz=%2B08:00&format=parquet&gzip=1", nil)
+	req, err := parseHistoryExportRequest(r)
+	if err != nil {
+		t.Fatalf("parseHistoryExportRequest() error = %v", err)
+	}
+	if len(req.Query.DeviceIDs) != 2 || req.Query.DeviceIDs[1] != 2 {
+		t.Fatalf("device ids = %v", req.Query.DeviceIDs)
+	}
+	if len(req.Query.FieldNames) != 2 || req.Query.FieldNames[0] != "Ua" || req.Query.FieldNames[1] != "Ia" {
+		t.Fatalf("field names = %v", req.Query.FieldNames)
+	}
+	if want := time.Date(2026, 9, 30, 16, 0, 0, 0, time.UTC); !req.Query.StartTime.Equal(want) {
+		t.Fatalf("start = %s, want %s (tz applied)", req.Query.StartTime, want)
+	}
+	if req.Format != service.HistoryExportFormatParquet || !req.Gzip || req.Layout != service.HistoryExportLayoutLong {
+		t.Fatalf("req = %+v", req)
+	}
+	if name := historyExportFileName(req); name != "history_20261001T0000_20261031T0000.parquet" {
+		t.Fatalf("file name = %s", name)
+	}
+
+	for _, query := range []string{
+		"device_id=1",
+		"start=2026-10-01T00:00",
+		"device_id=x&start=2026-10-01T00:00",
+		"device_id=1&start=2026-10-01T00:00&tz=Mars/Base",
+		"device_id=1&start=2026-10-01T00:00&format=parquet&layout=wide",
+	} {
+		if _, err := parseHistoryExportRequest(httptest.NewRequest("GET", "/data/export?"+query, nil)); err == nil {
+			t.Fatalf("query %q expected error", query)
+		}
+	}
+}
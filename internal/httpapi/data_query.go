@@ -1,19 +1,23 @@
 package httpapi
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
 )
 
 type historyDataQuery struct {
-	DeviceID  *int64
-	FieldName string
-	StartTime time.Time
-	EndTime   time.Time
+	DeviceID    *int64
+	FieldName   string
+	StartTime   time.Time
+	EndTime     time.Time
+	Aggregation database.HistoryAggregation
 }
 
 type historyPointQuery struct {
@@ -21,6 +25,53 @@ type historyPointQuery struct {
 	FieldName string
 }
 
+// historySeriesRequest POST /api/data/query 请求体，interval 取 auto 或时长（如 30s、5m）
+type historySeriesRequest struct {
+	Series   []database.HistorySeriesKey `json:"series"`
+	Start    string                      `json:"start"`
+	End      string                      `json:"end"`
+	Agg      string                      `json:"agg"`
+	Interval string                      `json:"interval"`
+	Fill     string                      `json:"fill"`
+	Limit    int                         `json:"limit"`
+	Cursor   string                      `json:"cursor"`
+}
+
+func parseHistorySeriesQuery(r *http.Request) (database.HistorySeriesQuery, error) {
+	var req historySeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return database.HistorySeriesQuery{}, fmt.Errorf("Invalid request body")
+	}
+
+	startTime, err := parseTimeParam(strings.TrimSpace(req.Start))
+	if err != nil {
+		return database.HistorySeriesQuery{}, fmt.Errorf("Invalid start time")
+	}
+	endTime, err := parseTimeParam(strings.TrimSpace(req.End))
+	if err != nil {
+		return database.HistorySeriesQuery{}, fmt.Errorf("Invalid end time")
+	}
+
+	query := database.HistorySeriesQuery{
+		Series:    req.Series,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Func:      strings.ToLower(strings.TrimSpace(req.Agg)),
+		Fill:      strings.ToLower(strings.TrimSpace(req.Fill)),
+		Limit:     req.Limit,
+		Cursor:    strings.TrimSpace(req.Cursor),
+	}
+	rawInterval := strings.ToLower(strings.TrimSpace(req.Interval))
+	if rawInterval != "" && rawInterval != "auto" {
+		interval, err := time.ParseDuration(rawInterval)
+		if err != nil || interval <= 0 {
+			return database.HistorySeriesQuery{}, fmt.Errorf("Invalid interval: must be auto or a duration like 30s, 5m")
+		}
+		query.Interval = interval
+	}
+	return query, nil
+}
+
 func parseHistoryDataQuery(r *http.Request) (historyDataQuery, error) {
 	query := historyDataQuery{
 		FieldName: strings.TrimSpace(r.URL.Query().Get("field_name")),
@@ -39,6 +90,12 @@ func parseHistoryDataQuery(r *http.Request) (historyDataQuery, error) {
 	query.StartTime = startTime
 	query.EndTime = endTime
 
+	aggregation, err := parseHistoryAggregation(r)
+	if err != nil {
+		return historyDataQuery{}, err
+	}
+	query.Aggregation = aggregation
+
 	if err := validateHistoryDataQuery(query); err != nil {
 		return historyDataQuery{}, err
 	}
@@ -74,6 +131,9 @@ func validateHistoryDataQuery(query historyDataQuery) error {
 	if !query.StartTime.IsZero() && !query.EndTime.IsZero() && query.StartTime.After(query.EndTime) {
 		return errors.New(errHistoryStartAfterEnd)
 	}
+	if query.Aggregation.Enabled() && (query.DeviceID == nil || query.FieldName == "") {
+		return errors.New(errHistoryAggNeedsField)
+	}
 	if query.DeviceID == nil {
 		hasFilter := query.FieldName != "" || !query.StartTime.IsZero() || !query.EndTime.IsZero()
 		if hasFilter {
@@ -101,6 +161,35 @@ func parseHistoryQueryTimeRange(r *http.Request) (time.Time, time.Time, error) {
 	return startTime, endTime, nil
 }
 
+// parseHistoryAggregation interval 取 raw/auto 或时长（如 5m、1h），agg 取聚合函数；
+// 只给 agg 时自动选桶宽，只给 interval 时按 avg 聚合
+func parseHistoryAggregation(r *http.Request) (database.HistoryAggregation, error) {
+	rawInterval := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("interval")))
+	fn := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("agg")))
+	if rawInterval == "raw" || (rawInterval == "" && fn == "") {
+		return database.HistoryAggregation{}, nil
+	}
+
+	if fn == "" {
+		fn = database.HistoryAggAvg
+	}
+	if !database.IsValidHistoryAggFunc(fn) {
+		return database.HistoryAggregation{}, errors.New(errHistoryInvalidAgg)
+	}
+
+	aggregation := database.HistoryAggregation{Func: fn}
+	if rawInterval == "" || rawInterval == "auto" {
+		return aggregation, nil
+	}
+	interval, err := time.ParseDuration(rawInterval)
+	minInterval := database.MinHistoryAggInterval()
+	if err != nil || interval < minInterval || interval%minInterval != 0 {
+		return database.HistoryAggregation{}, fmt.Errorf("Invalid interval: must be raw, auto or a multiple of %s", minInterval)
+	}
+	aggregation.Interval = interval
+	return aggregation, nil
+}
+
 func isValidHistoryDeviceID(deviceID *int64) bool {
 	return deviceID == nil || *deviceID > 0 || *deviceID == -1
 }
@@ -125,16 +214,21 @@ func parseOptionalTimeQuery(r *http.Request, key string) (time.Time, error) {
 }
 
 func parseTimeParam(value string) (time.Time, error) {
+	return parseTimeParamInLocation(value, time.UTC)
+}
+
+// parseTimeParamInLocation 不带时区的时间按 loc 解释，RFC3339 按自带偏移
+func parseTimeParamInLocation(value string, loc *time.Location) (time.Time, error) {
 	if value == "" {
 		return time.Time{}, nil
 	}
 	if ts, err := time.Parse(time.RFC3339, value); err == nil {
 		return ts, nil
 	}
-	if ts, err := time.Parse("2006-01-02T15:04", value); err == nil {
+	if ts, err := time.ParseInLocation("2006-01-02T15:04", value, loc); err == nil {
 		return ts, nil
 	}
-	if ts, err := time.Parse("2006-01-02 15:04:05", value); err == nil {
+	if ts, err := time.ParseInLocation("2006-01-02 15:04:05", value, loc); err == nil {
 		return ts, nil
 	}
 	return time.Time{}, fmt.Errorf("invalid time format")
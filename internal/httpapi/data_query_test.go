@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -190,3 +191,61 @@ func TestIsValidHistoryDeviceID(t *testing.T) {
 		t.Fatal("zero device id should be invalid")
 	}
 }
+
+func TestParseHistoryDataQuery_Aggregation(t *testing.T) {
+	req := httptest.NewRequest("GET", "/history?device_id=7&field_name=temp&interval=15m&agg=MAX", nil)
+	query, err := parseHistoryDataQuery(req)
+	if err != nil {
+		t.Fatalf("parseHistoryDataQuery returned error: %v", err)
+	}
+	if query.Aggregation.Interval != 15*time.Minute || query.Aggregation.Func != "max" {
+		t.Fatalf("aggregation = %+v", query.Aggregation)
+	}
+
+	req = httptest.NewRequest("GET", "/history?device_id=7&field_name=temp&agg=last", nil)
+	query, err = parseHistoryDataQuery(req)
+	if err != nil || query.Aggregation.Interval != 0 || query.Aggregation.Func != "last" {
+		t.Fatalf("auto aggregation = %+v err=%v", query.Aggregation, err)
+	}
+
+	req = httptest.NewRequest("GET", "/history?device_id=7&field_name=temp&interval=raw&agg=max", nil)
+	query, err = parseHistoryDataQuery(req)
+	if err != nil || query.Aggregation.Enabled() {
+		t.Fatalf("raw aggregation = %+v err=%v", query.Aggregation, err)
+	}
+
+	invalid := []string{
+		"/history?device_id=7&field_name=temp&interval=90s",
+		"/history?device_id=7&field_name=temp&interval=1h&agg=median",
+		"/history?device_id=7&interval=1h",
+	}
+	for _, target := range invalid {
+		if _, err := parseHistoryDataQuery(httptest.NewRequest("GET", target, nil)); err == nil {
+			t.Fatalf("expected error for %s", target)
+		}
+	}
+}
+
+func TestParseHistorySeriesQuery(t *testing.T) {
+	body := `{"series":[{"device_id":7,"field_name":"temp"},{"device_id":-1,"field_name":"cpu_usage"}],
+		"start":"2026-01-02T03:04:05Z","agg":"DIFF","interval":"30s","fill":"linear","limit":500,"cursor":"abc"}`
+	query, err := parseHistorySeriesQuery(httptest.NewRequest("POST", "/data/query", strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("parseHistorySeriesQuery returned error: %v", err)
+	}
+	if len(query.Series) != 2 || query.Series[1].DeviceID != -1 || query.Series[1].FieldName != "cpu_usage" {
+		t.Fatalf("series = %+v", query.Series)
+	}
+	if query.Func != "diff" || query.Interval != 30*time.Second || query.Fill != "linear" || query.Limit != 500 || query.Cursor != "abc" {
+		t.Fatalf("query = %+v", query)
+	}
+	if !query.StartTime.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) || !query.EndTime.IsZero() {
+		t.Fatalf("time range = %v..%v", query.StartTime, query.EndTime)
+	}
+
+	for _, body := range []string{`{"series":`, `{"start":"yesterday"}`, `{"interval":"-5m"}`, `{"interval":"often"}`} {
+		if _, err := parseHistorySeriesQuery(httptest.NewRequest("POST", "/data/query", strings.NewReader(body))); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package httpapi
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/gonglijing/xunjiFsu/internal/database"
+
 	"github.com/gonglijing/xunjiFsu/internal/service"
 )
 
@@ -31,10 +34,11 @@ func (api *DataAPI) GetHistoryData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	points, err := api.service.QueryHistoryData(service.HistoryDataQuery{
-		DeviceID:  query.DeviceID,
-		FieldName: query.FieldName,
-		StartTime: query.StartTime,
-		EndTime:   query.EndTime,
+		DeviceID:    query.DeviceID,
+		FieldName:   query.FieldName,
+		StartTime:   query.StartTime,
+		EndTime:     query.EndTime,
+		Aggregation: query.Aggregation,
 	})
 	if err != nil {
 		writeServerErrorWithLog(w, errQueryHistoryData, err)
@@ -42,3 +46,23 @@ func (api *DataAPI) GetHistoryData(w http.ResponseWriter, r *http.Request) {
 	}
 	WriteSuccess(w, points)
 }
+
+// QueryHistorySeries 多序列历史查询：按桶聚合、补齐空桶，next_cursor 非空时带回继续翻页
+func (api *DataAPI) QueryHistorySeries(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHistorySeriesQuery(r)
+	if err != nil {
+		WriteBadRequestCode(w, errHistoryDataQueryDef.Code, errHistoryDataQueryDef.Message+": "+err.Error())
+		return
+	}
+
+	result, err := api.service.QueryHistorySeries(query)
+	if errors.Is(err, database.ErrHistorySeriesQueryInvalid) {
+		WriteBadRequestCode(w, errHistoryDataQueryDef.Code, errHistoryDataQueryDef.Message+": "+err.Error())
+		return
+	}
+	if err != nil {
+		writeServerErrorWithLog(w, errQueryHistoryData, err)
+		return
+	}
+	WriteSuccess(w, result)
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/service"
+)
+
+var (
+	errLoadDataStorage         = APIErrorDef{Code: "E_LOAD_DATA_STORAGE_FAILED", Message: "获取存储状态失败"}
+	errSaveRetentionPolicy     = APIErrorDef{Code: "E_SAVE_RETENTION_POLICY_FAILED", Message: "保存保留策略失败"}
+	errDeleteRetentionPolicy   = APIErrorDef{Code: "E_DELETE_RETENTION_POLICY_FAILED", Message: "删除保留策略失败"}
+	errRetentionPolicyInvalid  = APIErrorDef{Code: "E_RETENTION_POLICY_INVALID", Message: "保留策略无效"}
+	errRetentionPolicyNotFound = APIErrorDef{Code: "E_RETENTION_POLICY_NOT_FOUND", Message: "保留策略不存在"}
+	errRetentionDeviceNotFound = APIErrorDef{Code: "E_RETENTION_DEVICE_NOT_FOUND", Message: "设备不存在"}
+)
+
+// retentionPolicyPayload 设备保留策略请求体，enabled 缺省为启用
+type retentionPolicyPayload struct {
+	StorageDays int  `json:"storage_days"`
+	Enabled     *int `json:"enabled"`
+}
+
+// GetDataStorage 返回 data.db 磁盘保护状态与设备保留策略
+func (api *DataAPI) GetDataStorage(w http.ResponseWriter, r *http.Request) {
+	view, err := api.service.LoadDataStorage()
+	if err != nil {
+		writeServerErrorWithLog(w, errLoadDataStorage, err)
+		return
+	}
+	WriteSuccess(w, view)
+}
+
+// UpdateDeviceRetentionPolicy 设置设备级历史保留天数
+func (api *DataAPI) UpdateDeviceRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	deviceID, ok := parseIDOrWriteBadRequestDefault(w, r)
+	if !ok {
+		return
+	}
+	var payload retentionPolicyPayload
+	if !parseRequestOrWriteBadRequestDefault(w, r, &payload) {
+		return
+	}
+
+	policy := &database.DeviceRetentionPolicy{DeviceID: deviceID, StorageDays: payload.StorageDays, Enabled: 1}
+	if payload.Enabled != nil {
+		policy.Enabled = *payload.Enabled
+	}
+	if err := service.ValidateDeviceRetentionPolicy(policy); err != nil {
+		WriteBadRequestCode(w, errRetentionPolicyInvalid.Code, errRetentionPolicyInvalid.Message+": "+err.Error())
+		return
+	}
+
+	saved, err := api.service.SaveDeviceRetentionPolicy(policy)
+	if err != nil {
+		if errors.Is(err, service.ErrRetentionDeviceNotFound) {
+			WriteNotFoundDef(w, errRetentionDeviceNotFound)
+			return
+		}
+		writeServerErrorWithLog(w, errSaveRetentionPolicy, err)
+		return
+	}
+	WriteSuccess(w, saved)
+}
+
+// DeleteDeviceRetentionPolicy 删除设备级保留策略
+func (api *DataAPI) DeleteDeviceRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	deviceID, ok := parseIDOrWriteBadRequestDefault(w, r)
+	if !ok {
+		return
+	}
+	if err := api.service.DeleteDeviceRetentionPolicy(deviceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			WriteNotFoundDef(w, errRetentionPolicyNotFound)
+			return
+		}
+		writeServerErrorWithLog(w, errDeleteRetentionPolicy, err)
+		return
+	}
+	WriteDeleted(w)
+}
@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gonglijing/xunjiFsu/internal/service"
+)
+
+func TestUpdateDeviceRetentionPolicy_RejectsInvalidDays(t *testing.T) {
+	api := NewDataAPI(service.NewDataService())
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /data/storage/devices/{id}", api.UpdateDeviceRetentionPolicy)
+
+	for _, body := range []string{`{"storage_days":0}`, `{"storage_days":3651}`, `{"storage_days":"x"}`} {
+		r := httptest.NewRequest(http.MethodPut, "/data/storage/devices/1", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: status=%d, want 400", body, w.Code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/data/storage/devices/1", strings.NewReader(`{"storage_days":0}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	var resp struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != errRetentionPolicyInvalid.Code {
+		t.Fatalf("code=%q err=%v, want %s", resp.Code, err, errRetentionPolicyInvalid.Code)
+	}
+}
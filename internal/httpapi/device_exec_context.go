@@ -77,13 +77,13 @@ func buildExecuteDriverContext(device *models.Device, config map[string]string)
 	}
 	ctx := &driver.DriverContext{
 		Config:       config,
-		DeviceConfig: "",
 		ResourceID:   resourceID,
 		ResourceType: inferDeviceResourceType(device),
 	}
 	if device != nil {
 		ctx.DeviceID = device.ID
 		ctx.DeviceName = device.Name
+		ctx.DeviceConfig = device.DeviceConfig
 	}
 	return ctx
 }
@@ -72,23 +72,26 @@ func TestNormalizeWriteParams_FromSubDeviceProperties(t *testing.T) {
 	}
 }
 
-func TestNormalizeWriteParams_Ambiguous(t *testing.T) {
+func TestNormalizeWriteParams_MultipleFieldsBecomeBatch(t *testing.T) {
 	config := map[string]string{}
 	params := map[string]any{
 		"temperature": 25,
 		"humidity":    60,
 	}
 
-	err := normalizeWriteParams(config, params)
-	if err == nil {
-		t.Fatal("expected error, got nil")
+	if err := normalizeWriteParams(config, params); err != nil {
+		t.Fatalf("normalizeWriteParams returned error: %v", err)
 	}
-	if !strings.Contains(err.Error(), "one field per call") {
-		t.Fatalf("error = %q, want contains one field per call", err.Error())
+
+	if got := config["field_name"]; got != "humidity" {
+		t.Fatalf("field_name = %q, want first sorted field humidity", got)
+	}
+	if got := config["writes"]; got != `[{"field_name":"humidity","value":"60"},{"field_name":"temperature","value":"25"}]` {
+		t.Fatalf("writes = %s", got)
 	}
 }
 
-func TestNormalizeWriteParams_RejectsMultipleFieldsEvenWithExplicitFieldName(t *testing.T) {
+func TestNormalizeWriteParams_ExplicitFieldLeadsBatch(t *testing.T) {
 	config := map[string]string{
 		"field_name": "temperature",
 		"value":      "25",
@@ -96,14 +99,29 @@ func TestNormalizeWriteParams_RejectsMultipleFieldsEvenWithExplicitFieldName(t *
 	params := map[string]any{
 		"temperature": 25,
 		"humidity":    60,
+		"writes":      []any{map[string]any{"field": "mode", "value": "auto"}},
 	}
 
-	err := normalizeWriteParams(config, params)
-	if err == nil {
-		t.Fatal("expected error, got nil")
+	if err := normalizeWriteParams(config, params); err != nil {
+		t.Fatalf("normalizeWriteParams returned error: %v", err)
 	}
-	if !strings.Contains(err.Error(), "one field per call") {
-		t.Fatalf("error = %q, want contains one field per call", err.Error())
+
+	if got := config["writes"]; got != `[{"field_name":"temperature","value":"25"},{"field_name":"humidity","value":"60"},{"field_name":"mode","value":"auto"}]` {
+		t.Fatalf("writes = %s", got)
+	}
+}
+
+func TestNormalizeWriteParams_RejectsInvalidBatch(t *testing.T) {
+	cases := map[string]map[string]any{
+		"value requires field_name": {"value": 1, "a": 1, "b": 2},
+		"missing value for field":   {"writes": []any{map[string]any{"field_name": "a"}, map[string]any{"field_name": "b", "value": 1}}},
+		"must be an array":          {"writes": "a=1"},
+	}
+	for want, params := range cases {
+		_, err := buildExecuteDriverConfig(params, nil, "write")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("params %v: error = %v, want contains %q", params, err, want)
+		}
 	}
 }
 
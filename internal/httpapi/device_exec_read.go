@@ -2,8 +2,6 @@ package httpapi
 
 import (
 	"net/http"
-
-	"github.com/gonglijing/xunjiFsu/internal/service"
 )
 
 func (api *DeviceExecAPI) GetDeviceWritables(w http.ResponseWriter, r *http.Request) {
@@ -16,7 +14,7 @@ func (api *DeviceExecAPI) GetDeviceWritables(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	writables, err := service.ParseDriverWritables(driverModel.ConfigSchema)
+	writables, err := api.service.ResolveDriverWritables(driverModel)
 	if err != nil {
 		WriteBadRequestDef(w, errDriverSchemaInvalid)
 		return
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gonglijing/xunjiFsu/internal/driver"
+	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
 func (api *DeviceExecAPI) ExecuteDriverFunction(w http.ResponseWriter, r *http.Request) {
@@ -13,7 +14,7 @@ func (api *DeviceExecAPI) ExecuteDriverFunction(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	device, _, ok := api.loadDeviceDriverForExecutionRequest(w, r)
+	device, driverModel, ok := api.loadDeviceDriverForExecutionRequest(w, r)
 	if !ok {
 		return
 	}
@@ -27,16 +28,44 @@ func (api *DeviceExecAPI) ExecuteDriverFunction(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if raw := config[driver.WriteBatchConfigKey]; configFunc == "write" && raw != "" {
+		api.executeWriteBatch(w, device, driverModel, pluginFunc, config, raw)
+		return
+	}
+
 	ctx := buildExecuteDriverContext(device, config)
 	result, err := api.service.ExecuteDriverFunction(driverID, pluginFunc, ctx)
 	if err != nil {
-		if errors.Is(err, driver.ErrDriverNotFound) {
-			WriteBadRequestDef(w, errDriverNotLoaded)
-			return
-		}
-		writeServerErrorWithLog(w, errExecuteDriverFailed, err)
+		writeExecuteDriverError(w, err)
 		return
 	}
 
 	WriteSuccess(w, result)
 }
+
+// executeWriteBatch 多字段写入，返回逐字段结果；驱动清单未声明 batch_write 时逐个字段调用
+func (api *DeviceExecAPI) executeWriteBatch(w http.ResponseWriter, device *models.Device, driverModel *models.Driver, pluginFunc string, config map[string]string, raw string) {
+	items, err := driver.ParseWriteItems(raw)
+	if err != nil {
+		WriteBadRequestCode(w, errExecuteDriverParamFail.Code, errExecuteDriverParamFail.Message+": "+err.Error())
+		return
+	}
+
+	result, err := driver.ExecuteWriteBatch(func(config map[string]string) (*driver.DriverResult, error) {
+		return api.service.ExecuteDriverFunction(driverModel.ID, pluginFunc, buildExecuteDriverContext(device, config))
+	}, config, items, api.service.SupportsBatchWrite(driverModel))
+	if err != nil {
+		writeExecuteDriverError(w, err)
+		return
+	}
+
+	WriteSuccess(w, result)
+}
+
+func writeExecuteDriverError(w http.ResponseWriter, err error) {
+	if errors.Is(err, driver.ErrDriverNotFound) {
+		WriteBadRequestDef(w, errDriverNotLoaded)
+		return
+	}
+	writeServerErrorWithLog(w, errExecuteDriverFailed, err)
+}
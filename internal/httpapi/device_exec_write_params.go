@@ -2,87 +2,102 @@ package httpapi
 
 import (
 	"cmp"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 
+	"github.com/gonglijing/xunjiFsu/internal/driver"
 	"github.com/gonglijing/xunjiFsu/internal/service"
 )
 
+// normalizeWriteParams 归一化写参数：field_name/value 为第一个字段，多个字段时 writes 携带全部字段
 func normalizeWriteParams(config map[string]string, params map[string]any) error {
 	if config == nil {
 		return fmt.Errorf("write params are empty")
 	}
-	if err := validateSingleWriteRequest(config, params); err != nil {
+	items, err := collectWriteItems(config, params)
+	if err != nil {
 		return err
 	}
-	fieldName := strings.TrimSpace(firstNonEmpty(config["field_name"], config["fieldName"], config["field"]))
-	value := strings.TrimSpace(firstNonEmpty(config["value"], config["val"]))
-	if fieldName != "" && value == "" {
-		if raw, ok := params[fieldName]; ok {
-			value = stringifyParamValue(raw)
-		}
-	}
-	if fieldName == "" || value == "" {
-		candidateField, candidateValue, err := resolveSingleWriteCandidate(params)
-		if err != nil {
-			return err
-		}
-		if fieldName == "" {
-			fieldName = candidateField
-		}
-		if value == "" {
-			value = candidateValue
-		}
-	}
-	fieldName = strings.TrimSpace(fieldName)
-	value = strings.TrimSpace(value)
-	if fieldName == "" {
+	if len(items) == 0 {
 		return fmt.Errorf("write params missing field_name")
 	}
-	if value == "" {
-		return fmt.Errorf("write params missing value")
+	for _, item := range items {
+		if item.Value == "" {
+			if len(items) == 1 {
+				return fmt.Errorf("write params missing value")
+			}
+			return fmt.Errorf("write params missing value for field %q", item.FieldName)
+		}
 	}
-	config["field_name"] = fieldName
-	config["value"] = value
+
+	config["field_name"] = items[0].FieldName
+	config["value"] = items[0].Value
 	delete(config, "field")
 	delete(config, "fieldName")
 	delete(config, "val")
+	delete(config, driver.WriteBatchConfigKey)
+	if len(items) > 1 {
+		encoded, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		config[driver.WriteBatchConfigKey] = string(encoded)
+	}
 	return nil
 }
 
-func validateSingleWriteRequest(config map[string]string, params map[string]any) error {
-	if len(params) == 0 {
-		return nil
-	}
+// collectWriteItems 显式 field_name/value 排在最前，其余字段来自参数本身、properties、子设备与 writes 数组
+func collectWriteItems(config map[string]string, params map[string]any) ([]driver.WriteItem, error) {
 	explicitField := strings.TrimSpace(firstNonEmpty(config["field_name"], config["fieldName"], config["field"]))
-	candidateFields, err := collectWriteCandidateFields(params)
-	if err != nil {
-		return err
-	}
-	if len(candidateFields) > 1 {
-		return fmt.Errorf("write only supports one field per call")
-	}
-	if explicitField != "" && len(candidateFields) == 1 && !strings.EqualFold(explicitField, candidateFields[0]) {
-		return fmt.Errorf("write params field_name %q does not match payload field %q", explicitField, candidateFields[0])
-	}
+	explicitValue := strings.TrimSpace(firstNonEmpty(config["value"], config["val"]))
 	if raw, ok := firstPresentValue(params, "value", "val"); ok {
 		switch raw.(type) {
 		case map[string]any, []any:
-			return fmt.Errorf("write params value must be a scalar")
+			return nil, fmt.Errorf("write params value must be a scalar")
 		}
 	}
-	return nil
+
+	candidates, err := collectWriteCandidates(params)
+	if err != nil {
+		return nil, err
+	}
+	if explicitField == "" {
+		if explicitValue != "" {
+			if len(candidates) > 1 {
+				return nil, fmt.Errorf("write params value requires field_name when writing multiple fields")
+			}
+			if len(candidates) == 1 {
+				candidates[0].Value = explicitValue
+			}
+		}
+		return candidates, nil
+	}
+
+	items := make([]driver.WriteItem, 1, len(candidates)+1)
+	items[0] = driver.WriteItem{FieldName: explicitField, Value: explicitValue}
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate.FieldName, explicitField) {
+			if items[0].Value == "" {
+				items[0].Value = candidate.Value
+			}
+			continue
+		}
+		items = append(items, candidate)
+	}
+	return items, nil
 }
 
-func collectWriteCandidateFields(params map[string]any) ([]string, error) {
+func collectWriteCandidates(params map[string]any) ([]driver.WriteItem, error) {
 	if len(params) == 0 {
 		return nil, nil
 	}
-	fields := make(map[string]string)
-	addWriteCandidateFields(fields, params)
+	candidates := make(map[string]driver.WriteItem)
+	addWriteCandidates(candidates, params)
 	if properties, ok := resolveMapValue(params["properties"]); ok {
-		addWriteCandidateFields(fields, properties)
+		addWriteCandidates(candidates, properties)
 	}
 	for _, key := range []string{"sub_device", "subDevice"} {
 		sub, ok := resolveMapValue(params[key])
@@ -90,7 +105,7 @@ func collectWriteCandidateFields(params map[string]any) ([]string, error) {
 			continue
 		}
 		if properties, ok := resolveMapValue(sub["properties"]); ok {
-			addWriteCandidateFields(fields, properties)
+			addWriteCandidates(candidates, properties)
 		}
 	}
 	for _, key := range []string{"sub_devices", "subDevices"} {
@@ -113,21 +128,19 @@ func collectWriteCandidateFields(params map[string]any) ([]string, error) {
 			return nil, fmt.Errorf("write params %s[0] must be an object", key)
 		}
 		if properties, ok := resolveMapValue(item["properties"]); ok {
-			addWriteCandidateFields(fields, properties)
+			addWriteCandidates(candidates, properties)
 		}
 	}
-	names := make([]string, 0, len(fields))
-	for _, field := range fields {
-		names = append(names, field)
+	if err := addWriteListCandidates(candidates, params[driver.WriteBatchConfigKey]); err != nil {
+		return nil, err
 	}
-	slices.Sort(names)
-	return names, nil
+
+	out := slices.Collect(maps.Values(candidates))
+	slices.SortFunc(out, func(a, b driver.WriteItem) int { return cmp.Compare(a.FieldName, b.FieldName) })
+	return out, nil
 }
 
-func addWriteCandidateFields(dst map[string]string, values map[string]any) {
-	if len(values) == 0 {
-		return
-	}
+func addWriteCandidates(dst map[string]driver.WriteItem, values map[string]any) {
 	for key, raw := range values {
 		trimmedKey := strings.TrimSpace(key)
 		if trimmedKey == "" || isReservedWriteKey(trimmedKey) {
@@ -137,11 +150,49 @@ func addWriteCandidateFields(dst map[string]string, values map[string]any) {
 		case map[string]any, []any:
 			continue
 		}
-		normalized := strings.ToLower(trimmedKey)
-		if _, exists := dst[normalized]; !exists {
-			dst[normalized] = trimmedKey
+		addWriteCandidate(dst, trimmedKey, stringifyParamValue(raw))
+	}
+}
+
+// addWriteListCandidates 解析 writes: [{"field_name":"a","value":1}]
+func addWriteListCandidates(dst map[string]driver.WriteItem, raw any) error {
+	if raw == nil {
+		return nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return fmt.Errorf("write params %s must be an array", driver.WriteBatchConfigKey)
+	}
+	for i, entry := range list {
+		item, ok := resolveMapValue(entry)
+		if !ok {
+			return fmt.Errorf("write params %s[%d] must be an object", driver.WriteBatchConfigKey, i)
+		}
+		field := ""
+		if rawField, ok := firstPresentValue(item, "field_name", "fieldName", "field"); ok {
+			field = strings.TrimSpace(stringifyParamValue(rawField))
+		}
+		if field == "" {
+			return fmt.Errorf("write params %s[%d] missing field_name", driver.WriteBatchConfigKey, i)
+		}
+		rawValue, _ := firstPresentValue(item, "value", "val")
+		switch rawValue.(type) {
+		case map[string]any, []any:
+			return fmt.Errorf("write params %s[%d] value must be a scalar", driver.WriteBatchConfigKey, i)
+		case nil:
+			addWriteCandidate(dst, field, "")
+		default:
+			addWriteCandidate(dst, field, stringifyParamValue(rawValue))
 		}
 	}
+	return nil
+}
+
+func addWriteCandidate(dst map[string]driver.WriteItem, field, value string) {
+	normalized := strings.ToLower(field)
+	if _, exists := dst[normalized]; !exists {
+		dst[normalized] = driver.WriteItem{FieldName: field, Value: strings.TrimSpace(value)}
+	}
 }
 
 func firstPresentValue(values map[string]any, keys ...string) (any, bool) {
@@ -156,16 +207,6 @@ func firstPresentValue(values map[string]any, keys ...string) (any, bool) {
 	return nil, false
 }
 
-func resolveSingleWriteCandidate(params map[string]any) (field string, value string, err error) {
-	if len(params) == 0 {
-		return "", "", nil
-	}
-	if props, ok := resolveWriteProperties(params); ok {
-		return pickSingleWriteValue(props)
-	}
-	return pickSingleWriteValue(params)
-}
-
 func resolveWriteProperties(params map[string]any) (map[string]any, bool) {
 	if properties, ok := resolveMapValue(params["properties"]); ok {
 		return properties, true
@@ -203,42 +244,11 @@ func resolveMapValue(value any) (map[string]any, bool) {
 	return out, ok
 }
 
-func pickSingleWriteValue(values map[string]any) (field string, value string, err error) {
-	type candidate struct {
-		key   string
-		value string
-	}
-	candidates := make([]candidate, 0, len(values))
-	for key, raw := range values {
-		trimmedKey := strings.TrimSpace(key)
-		if trimmedKey == "" || isReservedWriteKey(trimmedKey) {
-			continue
-		}
-		switch raw.(type) {
-		case map[string]any, []any:
-			continue
-		}
-		candidates = append(candidates, candidate{key: trimmedKey, value: stringifyParamValue(raw)})
-	}
-	if len(candidates) == 0 {
-		return "", "", nil
-	}
-	slices.SortFunc(candidates, func(a, b candidate) int { return cmp.Compare(a.key, b.key) })
-	if len(candidates) > 1 {
-		fields := make([]string, 0, len(candidates))
-		for _, item := range candidates {
-			fields = append(fields, item.key)
-		}
-		return "", "", fmt.Errorf("write params are ambiguous, please provide field_name and value explicitly (fields: %s)", strings.Join(fields, ","))
-	}
-	return candidates[0].key, candidates[0].value, nil
-}
-
 func isReservedWriteKey(key string) bool {
 	switch strings.ToLower(strings.TrimSpace(key)) {
 	case "field_name", "fieldname", "field", "value", "val", "values",
 		"product_key", "productkey", "device_key", "devicekey",
-		"identity", "properties", "sub_device", "subdevice", "sub_devices", "subdevices":
+		"identity", "properties", "sub_device", "subdevice", "sub_devices", "subdevices", "writes":
 		return true
 	default:
 		return false
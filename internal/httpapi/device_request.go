@@ -2,9 +2,12 @@ package httpapi
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/gonglijing/xunjiFsu/internal/driver"
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
@@ -32,9 +35,49 @@ func parseAndNormalizeDevice(w http.ResponseWriter, r *http.Request) (*models.De
 		WriteBadRequestDef(w, errDeviceNameRequired)
 		return nil, false
 	}
+	if err := validateDevicePointTable(&device); err != nil {
+		WriteBadRequestCode(w, errDevicePointTableInvalid.Code, errDevicePointTableInvalid.Message+": "+err.Error())
+		return nil, false
+	}
+	if err := validateDeviceDeadbands(&device); err != nil {
+		WriteBadRequestCode(w, errDeviceDeadbandsInvalid.Code, errDeviceDeadbandsInvalid.Message+": "+err.Error())
+		return nil, false
+	}
+	if err := validateDeviceConfigJSON(&device); err != nil {
+		WriteBadRequestCode(w, errDeviceConfigInvalid.Code, errDeviceConfigInvalid.Message+": "+err.Error())
+		return nil, false
+	}
 	return &device, true
 }
 
+func validateDevicePointTable(device *models.Device) error {
+	device.PointTable = strings.TrimSpace(device.PointTable)
+	if !driver.IsNativeModbusDevice(device) {
+		return nil
+	}
+	_, err := driver.ParseModbusPointTable(device.PointTable)
+	return err
+}
+
+func validateDeviceDeadbands(device *models.Device) error {
+	device.Deadbands = strings.TrimSpace(device.Deadbands)
+	_, err := models.ParseDeadbandRules(device.Deadbands)
+	return err
+}
+
+// validateDeviceConfigJSON 仅校验 device_config 为 JSON 对象，按驱动 Schema 的校验在服务层完成
+func validateDeviceConfigJSON(device *models.Device) error {
+	device.DeviceConfig = strings.TrimSpace(device.DeviceConfig)
+	if device.DeviceConfig == "" {
+		return nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(device.DeviceConfig), &obj); err != nil {
+		return errors.New("device_config must be a JSON object")
+	}
+	return nil
+}
+
 func normalizeDeviceInput(device *models.Device) error {
 	if device == nil {
 		return sql.ErrNoRows
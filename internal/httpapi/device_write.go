@@ -1,18 +1,24 @@
 package httpapi
 
 import (
+	"errors"
 	"net/http"
+
+	"github.com/gonglijing/xunjiFsu/internal/service"
 )
 
 const errInvalidRequestBodyWithDetailPrefix = "Invalid request body: "
 
 var (
-	errInvalidID          = APIErrorDef{Code: "E_INVALID_ID", Message: "Invalid ID"}
-	errDeviceNotFound     = APIErrorDef{Code: "E_DEVICE_NOT_FOUND", Message: "Device not found"}
-	errDeviceNameRequired = APIErrorDef{Code: "E_DEVICE_NAME_REQUIRED", Message: "device name is required"}
-	errCreateDeviceFailed = APIErrorDef{Code: "E_CREATE_DEVICE_FAILED", Message: "创建设备失败"}
-	errUpdateDeviceFailed = APIErrorDef{Code: "E_UPDATE_DEVICE_FAILED", Message: "更新设备失败"}
-	errDeleteDeviceFailed = APIErrorDef{Code: "E_DELETE_DEVICE_FAILED", Message: "删除设备失败"}
+	errInvalidID               = APIErrorDef{Code: "E_INVALID_ID", Message: "Invalid ID"}
+	errDeviceNotFound          = APIErrorDef{Code: "E_DEVICE_NOT_FOUND", Message: "Device not found"}
+	errDeviceNameRequired      = APIErrorDef{Code: "E_DEVICE_NAME_REQUIRED", Message: "device name is required"}
+	errDevicePointTableInvalid = APIErrorDef{Code: "E_DEVICE_POINT_TABLE_INVALID", Message: "点表配置无效"}
+	errDeviceDeadbandsInvalid  = APIErrorDef{Code: "E_DEVICE_DEADBANDS_INVALID", Message: "死区配置无效"}
+	errDeviceConfigInvalid     = APIErrorDef{Code: "E_DEVICE_CONFIG_INVALID", Message: "设备配置无效"}
+	errCreateDeviceFailed      = APIErrorDef{Code: "E_CREATE_DEVICE_FAILED", Message: "创建设备失败"}
+	errUpdateDeviceFailed      = APIErrorDef{Code: "E_UPDATE_DEVICE_FAILED", Message: "更新设备失败"}
+	errDeleteDeviceFailed      = APIErrorDef{Code: "E_DELETE_DEVICE_FAILED", Message: "删除设备失败"}
 )
 
 func (api *DeviceAPI) CreateDevice(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +29,10 @@ func (api *DeviceAPI) CreateDevice(w http.ResponseWriter, r *http.Request) {
 
 	device, err := api.service.CreateDevice(device)
 	if err != nil {
+		if errors.Is(err, service.ErrDeviceConfigInvalid) {
+			WriteBadRequestCode(w, errDeviceConfigInvalid.Code, errDeviceConfigInvalid.Message+": "+err.Error())
+			return
+		}
 		writeServerErrorWithLog(w, errCreateDeviceFailed, err)
 		return
 	}
@@ -43,6 +53,10 @@ func (api *DeviceAPI) UpdateDevice(w http.ResponseWriter, r *http.Request) {
 
 	device, err := api.service.UpdateDevice(device)
 	if err != nil {
+		if errors.Is(err, service.ErrDeviceConfigInvalid) {
+			WriteBadRequestCode(w, errDeviceConfigInvalid.Code, errDeviceConfigInvalid.Message+": "+err.Error())
+			return
+		}
 		writeServerErrorWithLog(w, errUpdateDeviceFailed, err)
 		return
 	}
@@ -58,6 +58,10 @@ func (w *gzipResponseWriter) WriteString(s string) (int, error) {
 	return io.WriteString(w.Writer, s)
 }
 
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *gzipResponseWriter) Flush() {
 	_ = w.Writer.Flush()
 	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
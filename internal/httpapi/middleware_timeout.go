@@ -11,6 +11,8 @@ type TimeoutConfig struct {
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
+	// StreamingPaths 不经 http.TimeoutHandler 的流式下载路径（TimeoutHandler 会缓存整个响应）
+	StreamingPaths []string
 }
 
 // DefaultTimeoutConfig 默认超时配置
@@ -34,6 +36,20 @@ func TimeoutMiddleware(cfg *TimeoutConfig) func(http.Handler) http.Handler {
 		if timeout <= 0 {
 			return next
 		}
-		return http.TimeoutHandler(next, timeout, "Request timeout")
+		timeoutHandler := http.TimeoutHandler(next, timeout, "Request timeout")
+		if cfg == nil || len(cfg.StreamingPaths) == 0 {
+			return timeoutHandler
+		}
+		streaming := make(map[string]struct{}, len(cfg.StreamingPaths))
+		for _, path := range cfg.StreamingPaths {
+			streaming[path] = struct{}{}
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := streaming[r.URL.Path]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			timeoutHandler.ServeHTTP(w, r)
+		})
 	}
 }
@@ -37,3 +37,23 @@ func TestTimeoutMiddleware_NoTimeoutWhenDisabled(t *testing.T) {
 		t.Fatalf("status=%d, want=%d", rr.Code, http.StatusAccepted)
 	}
 }
+
+func TestTimeoutMiddleware_StreamingPathBypassesTimeout(t *testing.T) {
+	mw := TimeoutMiddleware(&TimeoutConfig{ReadTimeout: 20 * time.Millisecond, StreamingPaths: []string{"/export"}})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(60 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("streaming status=%d, want=%d", rr.Code, http.StatusOK)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/other", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("other status=%d, want=%d", rr.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -36,4 +36,5 @@ var (
 	errUpdateNorthboundConfig      = APIErrorDef{Code: "E_UPDATE_NORTHBOUND_CONFIG_FAILED", Message: "更新北向配置失败"}
 	errDeleteNorthboundConfig      = APIErrorDef{Code: "E_DELETE_NORTHBOUND_CONFIG_FAILED", Message: "删除北向配置失败"}
 	errListNorthboundStatusFailed  = APIErrorDef{Code: "E_LIST_NORTHBOUND_STATUS_FAILED", Message: "获取北向运行态失败"}
+	errNorthboundPreviewFailed     = APIErrorDef{Code: "E_NORTHBOUND_PREVIEW_FAILED", Message: "北向消息预览失败"}
 )
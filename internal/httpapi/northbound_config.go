@@ -15,6 +15,8 @@ const (
 	defaultNorthboundKeepAlive      = 60
 	defaultNorthboundTimeout        = 30
 	defaultMQTTPort                 = 1883
+	defaultOPCUAPort                = 4840
+	defaultModbusSlavePort          = 502
 )
 
 type requiredFieldRule struct {
@@ -43,6 +45,9 @@ var northboundRequiredFieldRules = map[string][]requiredFieldRule{
 		{fieldName: "server_url", present: func(cfg *models.NorthboundConfig) bool { return strings.TrimSpace(cfg.ServerURL) != "" }},
 		{fieldName: "username", present: func(cfg *models.NorthboundConfig) bool { return strings.TrimSpace(cfg.Username) != "" }},
 	},
+	nbtype.TypeHTTP: {
+		{fieldName: "server_url", present: func(cfg *models.NorthboundConfig) bool { return strings.TrimSpace(cfg.ServerURL) != "" }},
+	},
 }
 
 func normalizeNorthboundConfig(config *models.NorthboundConfig) {
@@ -84,10 +89,14 @@ func normalizeNorthboundConfig(config *models.NorthboundConfig) {
 
 func defaultNorthboundPort(nbType string) int {
 	switch nbType {
-	case "http":
+	case nbtype.TypeHTTP:
 		return 80
 	case nbtype.TypeMQTT, nbtype.TypeXunji, nbtype.TypeSagoo, nbtype.TypePandaX, nbtype.TypeIThings:
 		return defaultMQTTPort
+	case nbtype.TypeOPCUA:
+		return defaultOPCUAPort
+	case nbtype.TypeModbusSlave:
+		return defaultModbusSlavePort
 	default:
 		return 0
 	}
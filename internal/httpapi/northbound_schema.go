@@ -2,9 +2,11 @@ package httpapi
 
 import (
 	"fmt"
+	"net/http"
 	"slices"
 	"strings"
 
+	"github.com/gonglijing/xunjiFsu/internal/northbound/adapters"
 	northboundschema "github.com/gonglijing/xunjiFsu/internal/northbound/schema"
 )
 
@@ -50,3 +52,21 @@ func loadNorthboundSchema(typeName string) (*northboundSchemaView, error) {
 		Fields:         fields,
 	}, nil
 }
+
+// PreviewNorthboundConfig 按提交的北向配置（与创建接口同结构）渲染示例主题与载荷，不保存
+func (api *NorthboundAPI) PreviewNorthboundConfig(w http.ResponseWriter, r *http.Request) {
+	config, ok := parseNorthboundConfigRequest(w, r)
+	if !ok {
+		return
+	}
+	configStr := config.Config
+	if !hasSchemaConfig(config) {
+		configStr = adapters.BuildConfigFromModel(config)
+	}
+	previews, err := adapters.PreviewMessages(config.Type, config.Name, configStr)
+	if err != nil {
+		WriteBadRequestCode(w, errNorthboundPreviewFailed.Code, errNorthboundPreviewFailed.Message+": "+err.Error())
+		return
+	}
+	WriteSuccess(w, previews)
+}
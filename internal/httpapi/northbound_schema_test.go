@@ -0,0 +1,49 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreviewNorthboundConfig_RendersMQTTTemplates(t *testing.T) {
+	api := &NorthboundAPI{}
+	body := `{"name":"cloud","type":"mqtt","server_url":"127.0.0.1","topic":"up/{device_key}","config":"{\"broker\":\"tcp://127.0.0.1:1883\",\"topic\":\"up/{device_key}\",\"payloadMapping\":{\"id\":\"$.device_key\"}}"}`
+	req := httptest.NewRequest(http.MethodPost, "/northbound/preview", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	api.PreviewNorthboundConfig(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []struct {
+			Kind    string `json:"kind"`
+			Topic   string `json:"topic"`
+			Payload string `json:"payload"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Data) == 0 || resp.Data[0].Topic != "up/demo_device" || resp.Data[0].Payload != `{"id":"demo_device"}` {
+		t.Fatalf("previews=%+v", resp.Data)
+	}
+}
+
+func TestPreviewNorthboundConfig_UnsupportedType(t *testing.T) {
+	api := &NorthboundAPI{}
+	req := httptest.NewRequest(http.MethodPost, "/northbound/preview", strings.NewReader(`{"name":"s","type":"sagoo","server_url":"tcp://x","product_key":"p","device_key":"d"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	api.PreviewNorthboundConfig(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status=%d, want 400", w.Code)
+	}
+}
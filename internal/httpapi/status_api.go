@@ -17,10 +17,16 @@ var (
 	errGetDeviceCacheFailed  = APIErrorDef{Code: "E_GET_DEVICE_DATA_CACHE_FAILED", Message: "获取设备缓存失败"}
 	errQueryHistoryData      = APIErrorDef{Code: "E_QUERY_HISTORY_DATA_FAILED", Message: "查询历史数据失败"}
 	errClearHistoryData      = APIErrorDef{Code: "E_CLEAR_HISTORY_DATA_FAILED", Message: "清除历史数据失败"}
+	errExportHistoryData     = APIErrorDef{Code: "E_EXPORT_HISTORY_DATA_FAILED", Message: "导出历史数据失败"}
 	errHistoryDataQueryDef   = APIErrorDef{Code: "E_HISTORY_DATA_QUERY_INVALID", Message: "历史数据查询参数无效"}
 	errHistoryPointQueryDef  = APIErrorDef{Code: "E_HISTORY_POINT_QUERY_INVALID", Message: "历史测点参数无效"}
+	errHistoryExportQueryDef = APIErrorDef{Code: "E_HISTORY_EXPORT_QUERY_INVALID", Message: "历史导出参数无效"}
 	errInvalidDeviceID       = "Invalid device_id"
 	errHistoryStartAfterEnd  = "start time must be before end time"
 	errHistoryFilterRequires = "device_id is required when using field_name/start/end filters"
 	errHistoryFieldRequired  = "field_name is required"
+	errHistoryAggNeedsField  = "device_id and field_name are required when using interval/agg"
+	errHistoryInvalidAgg     = "agg must be one of avg/min/max/last/count/sum"
+	errHistoryExportNoStart  = "start is required"
+	errHistoryInvalidTZ      = "Invalid tz: must be an IANA name or an offset like +08:00"
 )
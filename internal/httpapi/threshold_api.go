@@ -27,4 +27,5 @@ var (
 	errGetAlarmRepeatIntervalFailed    = APIErrorDef{Code: "E_GET_ALARM_REPEAT_INTERVAL_FAILED", Message: "获取报警重复触发间隔失败"}
 	errUpdateAlarmRepeatIntervalFailed = APIErrorDef{Code: "E_UPDATE_ALARM_REPEAT_INTERVAL_FAILED", Message: "更新报警重复触发间隔失败"}
 	errInvalidAlarmRepeatInterval      = APIErrorDef{Code: "E_INVALID_ALARM_REPEAT_INTERVAL", Message: "alarm repeat interval must be > 0"}
+	errThresholdExpressionInvalid      = APIErrorDef{Code: "E_THRESHOLD_EXPRESSION_INVALID", Message: "阈值表达式无效"}
 )
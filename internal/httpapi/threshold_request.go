@@ -28,5 +28,9 @@ func parseThresholdRequest(w http.ResponseWriter, r *http.Request) (*models.Thre
 		return nil, false
 	}
 	service.NormalizeThresholdInput(&threshold)
+	if err := service.ValidateThresholdExpression(&threshold); err != nil {
+		WriteBadRequestCode(w, errThresholdExpressionInvalid.Code, err.Error())
+		return nil, false
+	}
 	return &threshold, true
 }
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DeadbandFieldWildcard 匹配未单独配置规则的字段
+const DeadbandFieldWildcard = "*"
+
+// DeadbandRule 单字段变化上报（死区）规则
+type DeadbandRule struct {
+	FieldName       string  `json:"field_name"`
+	Deadband        float64 `json:"deadband,omitempty"`         // 绝对死区，变化量超过该值才上报
+	DeadbandPercent float64 `json:"deadband_percent,omitempty"` // 百分比死区，相对上次上报值
+	MaxSilence      int     `json:"max_silence,omitempty"`      // 最长静默(s)，超时即使未变化也上报
+}
+
+// ParseDeadbandRules 解析设备死区配置 JSON 数组，空文本返回 nil
+func ParseDeadbandRules(raw string) ([]DeadbandRule, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var rules []DeadbandRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("invalid deadband json: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		rule.FieldName = strings.TrimSpace(rule.FieldName)
+		if rule.FieldName == "" {
+			return nil, fmt.Errorf("rule %d: field_name is required", i)
+		}
+		if _, ok := seen[rule.FieldName]; ok {
+			return nil, fmt.Errorf("rule %d: duplicate field_name %q", i, rule.FieldName)
+		}
+		seen[rule.FieldName] = struct{}{}
+		if !validDeadbandNumber(rule.Deadband) || !validDeadbandNumber(rule.DeadbandPercent) {
+			return nil, fmt.Errorf("rule %d: deadband must be a non-negative number", i)
+		}
+		if rule.MaxSilence < 0 {
+			return nil, fmt.Errorf("rule %d: max_silence must be non-negative", i)
+		}
+	}
+	return rules, nil
+}
+
+func validDeadbandNumber(v float64) bool {
+	return v >= 0 && !math.IsInf(v, 0)
+}
+
+// DeadbandTracker 记录字段最近一次上报的值，按死区 / 最长静默规则判断新采样是否需要上报；非并发安全
+type DeadbandTracker struct {
+	rules    map[string]DeadbandRule
+	fallback *DeadbandRule
+	last     map[string]deadbandSample
+}
+
+// deadbandSample 字段最近一次上报的值
+type deadbandSample struct {
+	text    string
+	num     float64
+	numeric bool
+	at      time.Time
+}
+
+// NewDeadbandTracker 按规则创建跟踪器，没有规则时返回 nil
+func NewDeadbandTracker(rules []DeadbandRule) *DeadbandTracker {
+	if len(rules) == 0 {
+		return nil
+	}
+	t := &DeadbandTracker{
+		rules: make(map[string]DeadbandRule, len(rules)),
+		last:  make(map[string]deadbandSample, len(rules)),
+	}
+	for _, rule := range rules {
+		if rule.FieldName == DeadbandFieldWildcard {
+			fallback := rule
+			t.fallback = &fallback
+			continue
+		}
+		t.rules[rule.FieldName] = rule
+	}
+	return t
+}
+
+// Rule 返回字段适用的规则，未配置时返回 nil
+func (t *DeadbandTracker) Rule(field string) *DeadbandRule {
+	if t == nil {
+		return nil
+	}
+	if rule, ok := t.rules[field]; ok {
+		return &rule
+	}
+	return t.fallback
+}
+
+// Report 判断 at 时刻的采样是否需要按 rule 上报，需要时记为该字段最近一次上报的值
+func (t *DeadbandTracker) Report(field string, value any, rule *DeadbandRule, at time.Time) bool {
+	current := newDeadbandSample(value, at)
+	previous, ok := t.last[field]
+	if ok && !deadbandSilenceExpired(previous, rule, at) && !deadbandChanged(previous, current, rule) {
+		return false
+	}
+	t.last[field] = current
+	return true
+}
+
+func newDeadbandSample(value any, at time.Time) deadbandSample {
+	sample := deadbandSample{text: CollectPointValueString(value), at: at}
+	// 布尔值按文本比较，任何变化都上报
+	if num, valueType, ok := NumericCollectValue(value); ok && valueType != ValueTypeBool {
+		sample.num = num
+		sample.numeric = true
+	}
+	return sample
+}
+
+func deadbandSilenceExpired(previous deadbandSample, rule *DeadbandRule, at time.Time) bool {
+	if rule.MaxSilence <= 0 {
+		return false
+	}
+	return at.Sub(previous.at) >= time.Duration(rule.MaxSilence)*time.Second
+}
+
+// deadbandChanged 数值超出任一死区即视为变化；未配置死区时任何变化都上报
+func deadbandChanged(previous, current deadbandSample, rule *DeadbandRule) bool {
+	if !previous.numeric || !current.numeric {
+		return previous.text != current.text
+	}
+
+	delta := math.Abs(current.num - previous.num)
+	if rule.Deadband <= 0 && rule.DeadbandPercent <= 0 {
+		return delta > 0
+	}
+	if rule.Deadband > 0 && delta > rule.Deadband {
+		return true
+	}
+	if rule.DeadbandPercent > 0 {
+		if previous.num == 0 {
+			return delta > 0
+		}
+		return delta*100/math.Abs(previous.num) > rule.DeadbandPercent
+	}
+	return false
+}
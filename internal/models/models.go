@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -79,6 +80,12 @@ type Device struct {
 	CollectInterval int    `json:"collect_interval" db:"collect_interval"` // 采集周期(ms)
 	StorageInterval int    `json:"storage_interval" db:"storage_interval"` // 存储周期(s)
 	Timeout         int    `json:"timeout" db:"timeout"`                   // 响应超时(ms)
+	// 内置 Modbus 点表（JSON），driver_type 为 modbus_rtu/modbus_tcp 时无需 WASM 驱动
+	PointTable string `json:"point_table,omitempty" db:"point_table"`
+	// 变化上报（死区）规则（JSON 数组，见 DeadbandRule），为空时按 storage_interval 存储并每轮上报
+	Deadbands string `json:"deadbands,omitempty" db:"deadbands"`
+	// WASM 驱动设备配置（JSON 对象），按驱动 manifest 中的 config_schema 校验
+	DeviceConfig string `json:"device_config,omitempty" db:"device_config"`
 	// 驱动（保留用于未来扩展）
 	DriverID     *int64 `json:"driver_id" db:"driver_id"`
 	DriverName   string `json:"driver_name,omitempty"`
@@ -148,16 +155,24 @@ type NorthboundConfig struct {
 
 // Threshold 阈值配置模型
 type Threshold struct {
-	ID        int64     `json:"id" db:"id"`
-	DeviceID  int64     `json:"device_id" db:"device_id"`
-	FieldName string    `json:"field_name" db:"field_name"`
-	Operator  string    `json:"operator" db:"operator"`
-	Value     float64   `json:"value" db:"value"`
-	Severity  string    `json:"severity" db:"severity"`
-	Shielded  int       `json:"shielded" db:"shielded"`
-	Message   string    `json:"message" db:"message"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        int64   `json:"id" db:"id"`
+	DeviceID  int64   `json:"device_id" db:"device_id"`
+	FieldName string  `json:"field_name" db:"field_name"`
+	Operator  string  `json:"operator" db:"operator"`
+	Value     float64 `json:"value" db:"value"`
+	Severity  string  `json:"severity" db:"severity"`
+	Shielded  int     `json:"shielded" db:"shielded"`
+	Message   string  `json:"message" db:"message"`
+	// Expression operator 为 expr 时的组合条件，如 Ua < 180 && Ia > 5
+	Expression string `json:"expression,omitempty" db:"expression"`
+	// Deadband 恢复回差：报警后数值需越过 阈值∓回差 才恢复
+	Deadband float64 `json:"deadband" db:"deadband"`
+	// TriggerDelay 持续满足条件多少秒后才产生报警
+	TriggerDelay int `json:"trigger_delay_seconds" db:"trigger_delay_seconds"`
+	// ClearDelay 持续不满足条件多少秒后才恢复
+	ClearDelay int       `json:"clear_delay_seconds" db:"clear_delay_seconds"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // AlarmLog 报警日志模型
@@ -175,6 +190,21 @@ type AlarmLog struct {
 	Acknowledged   int        `json:"acknowledged" db:"acknowledged"`
 	AcknowledgedBy string     `json:"acknowledged_by" db:"acknowledged_by"`
 	AcknowledgedAt *time.Time `json:"acknowledged_at" db:"acknowledged_at"`
+	ClearedAt      *time.Time `json:"cleared_at" db:"cleared_at"`
+}
+
+// ActiveAlarm 当前处于报警态的阈值（按设备 + 阈值唯一），恢复后删除
+type ActiveAlarm struct {
+	DeviceID       int64     `json:"device_id" db:"device_id"`
+	ThresholdID    int64     `json:"threshold_id" db:"threshold_id"`
+	AlarmLogID     int64     `json:"alarm_log_id" db:"alarm_log_id"`
+	FieldName      string    `json:"field_name" db:"field_name"`
+	ActualValue    float64   `json:"actual_value" db:"actual_value"`
+	ThresholdValue float64   `json:"threshold_value" db:"threshold_value"`
+	Operator       string    `json:"operator" db:"operator"`
+	Severity       string    `json:"severity" db:"severity"`
+	Message        string    `json:"message" db:"message"`
+	TriggeredAt    time.Time `json:"triggered_at" db:"triggered_at"`
 }
 
 // DataCache 采集数据缓存
@@ -187,6 +217,15 @@ type DataCache struct {
 	CollectedAt time.Time `json:"collected_at" db:"collected_at"`
 }
 
+// MarshalJSON 按 value_type 输出数值/布尔，而不是字符串
+func (c DataCache) MarshalJSON() ([]byte, error) {
+	type dataCacheJSON DataCache
+	return json.Marshal(struct {
+		dataCacheJSON
+		Value any `json:"value"`
+	}{dataCacheJSON(c), TypedCollectValue(c.Value, c.ValueType)})
+}
+
 // CollectData 采集数据结构
 type CollectData struct {
 	DeviceID   int64             `json:"device_id"`
@@ -200,9 +239,16 @@ type CollectData struct {
 }
 
 type CollectPoint struct {
-	FieldName string      `json:"field_name"`
-	Value     any `json:"value"`
-	RW        string      `json:"rw,omitempty"`
+	FieldName string `json:"field_name"`
+	Value     any    `json:"value"`
+	RW        string `json:"rw,omitempty"`
+}
+
+// DevicePoint 设备预定义测点（如内置 Modbus 点表），供服务端型北向在采集前建立节点
+type DevicePoint struct {
+	Name     string `json:"name"`
+	RW       string `json:"rw,omitempty"`
+	DataType string `json:"data_type,omitempty"`
 }
 
 func (c *CollectData) EnsureFields() map[string]string {
@@ -319,6 +365,24 @@ type AlarmPayload struct {
 	Operator    string  `json:"operator"`
 	Severity    string  `json:"severity"`
 	Message     string  `json:"message"`
+	// State 报警状态：active 产生 / cleared 恢复，为空视为 active
+	State string `json:"state,omitempty"`
+	// Timestamp 报警产生时间，为零时由北向按发送时间补齐
+	Timestamp time.Time `json:"timestamp,omitzero"`
+}
+
+// 报警状态
+const (
+	AlarmStateActive  = "active"
+	AlarmStateCleared = "cleared"
+)
+
+// TimestampOrNow 返回报警产生时间，未设置时返回当前时间
+func (a *AlarmPayload) TimestampOrNow() time.Time {
+	if a == nil || a.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return a.Timestamp
 }
 
 // SagooConfig 循迹北向配置
@@ -358,32 +422,74 @@ type NorthboundPayload struct {
 }
 
 // NorthboundCommand 北向下发命令（用于写入子设备）
+// 多字段批量写时 Fields 携带全部字段，FieldName/Value 为其中第一个，兼容只认单字段的调用方
 type NorthboundCommand struct {
-	RequestID  string `json:"request_id"`
-	ProductKey string `json:"product_key"`
-	DeviceKey  string `json:"device_key"`
-	FieldName  string `json:"field_name"`
-	Value      string `json:"value"`
-	Source     string `json:"source"`
+	RequestID  string                   `json:"request_id"`
+	ProductKey string                   `json:"product_key"`
+	DeviceKey  string                   `json:"device_key"`
+	FieldName  string                   `json:"field_name"`
+	Value      string                   `json:"value"`
+	Fields     []NorthboundCommandField `json:"fields,omitempty"`
+	Source     string                   `json:"source"`
+}
+
+// NorthboundCommandField 命令中的单个写入字段
+type NorthboundCommandField struct {
+	FieldName string `json:"field_name"`
+	Value     string `json:"value"`
+}
+
+// WriteFields 返回命令要写入的全部字段
+func (c *NorthboundCommand) WriteFields() []NorthboundCommandField {
+	if c == nil {
+		return nil
+	}
+	if len(c.Fields) > 0 {
+		return c.Fields
+	}
+	if c.FieldName == "" {
+		return nil
+	}
+	return []NorthboundCommandField{{FieldName: c.FieldName, Value: c.Value}}
 }
 
 // NorthboundCommandResult 北向下发命令执行结果
+// 批量写时 Fields 为逐字段结果，Success 表示全部成功，Partial 表示仅部分字段成功
 type NorthboundCommandResult struct {
-	RequestID  string `json:"request_id"`
-	ProductKey string `json:"product_key"`
-	DeviceKey  string `json:"device_key"`
-	FieldName  string `json:"field_name"`
-	Value      string `json:"value"`
-	Source     string `json:"source"`
-	Success    bool   `json:"success"`
-	Code       int    `json:"code"`
-	Message    string `json:"message"`
+	RequestID  string                         `json:"request_id"`
+	ProductKey string                         `json:"product_key"`
+	DeviceKey  string                         `json:"device_key"`
+	FieldName  string                         `json:"field_name"`
+	Value      string                         `json:"value"`
+	Source     string                         `json:"source"`
+	Success    bool                           `json:"success"`
+	Partial    bool                           `json:"partial,omitempty"`
+	Code       int                            `json:"code"`
+	Message    string                         `json:"message"`
+	Fields     []NorthboundCommandFieldResult `json:"fields,omitempty"`
+}
+
+// NorthboundCommandFieldResult 批量写中单个字段的执行结果
+type NorthboundCommandFieldResult struct {
+	FieldName string `json:"field_name"`
+	Value     string `json:"value"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message,omitempty"`
+}
+
+// NorthboundSpoolEntry 北向暂存消息（断线/熔断期间落盘，恢复后按序补发）
+type NorthboundSpoolEntry struct {
+	ID        int64     `json:"id" db:"id"`
+	Adapter   string    `json:"adapter" db:"adapter"`
+	Kind      string    `json:"kind" db:"kind"`
+	Payload   []byte    `json:"payload" db:"payload"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 // Event 事件数据
 type Event struct {
 	Value map[string]any `json:"value"`
-	Time  int64                  `json:"time"`
+	Time  int64          `json:"time"`
 }
 
 // SystemStats FSU 本身系统属性（CPU、内存、硬盘等）
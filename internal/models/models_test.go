@@ -1,6 +1,10 @@
 package models
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
 
 func TestCollectDataEnsureFields_MergesOnce(t *testing.T) {
 	data := &CollectData{
@@ -50,3 +54,72 @@ func TestCollectDataEnsureFields_UnicodeWhitespaceTrimmed(t *testing.T) {
 		t.Fatalf("humidity = %q, want 50", fields["humidity"])
 	}
 }
+
+func TestNumericCollectValue(t *testing.T) {
+	cases := []struct {
+		input     any
+		wantNum   float64
+		wantType  string
+		wantValid bool
+	}{
+		{21.5, 21.5, ValueTypeFloat, true},
+		{float32(0.1), 0.1, ValueTypeFloat, true},
+		{int64(42), 42, ValueTypeInt, true},
+		{true, 1, ValueTypeBool, true},
+		{"-3", -3, ValueTypeInt, true},
+		{"21.50", 21.5, ValueTypeFloat, true},
+		{"false", 0, ValueTypeBool, true},
+		{"0012", 0, "", false},
+		{"1e3", 0, "", false},
+		{"abc", 0, "", false},
+		{uint64(1) << 60, 0, "", false},
+		{"0.12345678901234567", 0, "", false},
+	}
+	for _, tc := range cases {
+		num, valueType, ok := NumericCollectValue(tc.input)
+		if ok != tc.wantValid || num != tc.wantNum || valueType != tc.wantType {
+			t.Fatalf("NumericCollectValue(%#v) = (%v, %q, %v), want (%v, %q, %v)",
+				tc.input, num, valueType, ok, tc.wantNum, tc.wantType, tc.wantValid)
+		}
+	}
+}
+
+func TestDataCacheMarshalJSON_TypedValue(t *testing.T) {
+	cases := map[string]DataCache{
+		`"value":21.5`:   {Value: "21.5", ValueType: ValueTypeFloat},
+		`"value":7`:      {Value: "7", ValueType: ValueTypeInt},
+		`"value":true`:   {Value: "true", ValueType: ValueTypeBool},
+		`"value":"0012"`: {Value: "0012", ValueType: ValueTypeString},
+		`"value":"bad"`:  {Value: "bad", ValueType: ValueTypeFloat},
+	}
+	for want, item := range cases {
+		data, err := json.Marshal(item)
+		if err != nil {
+			t.Fatalf("marshal %+v: %v", item, err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("json = %s, want %s", data, want)
+		}
+	}
+}
+
+func TestParseDeadbandRules(t *testing.T) {
+	rules, err := ParseDeadbandRules(` [{"field_name":" temp ","deadband":0.5,"max_silence":300},{"field_name":"*","deadband_percent":1}] `)
+	if err != nil || len(rules) != 2 || rules[0].FieldName != "temp" || rules[1].DeadbandPercent != 1 {
+		t.Fatalf("ParseDeadbandRules() = %+v, %v", rules, err)
+	}
+	if rules, err := ParseDeadbandRules(""); rules != nil || err != nil {
+		t.Fatalf("empty deadbands = %+v, %v", rules, err)
+	}
+	for _, raw := range []string{
+		`{"field_name":"temp"}`,
+		`[{"deadband":1}]`,
+		`[{"field_name":"temp"},{"field_name":"temp"}]`,
+		`[{"field_name":"temp","deadband":-1}]`,
+		`[{"field_name":"temp","max_silence":-1}]`,
+	} {
+		if _, err := ParseDeadbandRules(raw); err == nil {
+			t.Fatalf("ParseDeadbandRules(%s) expected error", raw)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 采集值存储类型（data_points / data_cache 的 value_type）
+const (
+	ValueTypeString = "string"
+	ValueTypeInt    = "int"
+	ValueTypeFloat  = "float"
+	ValueTypeBool   = "bool"
+)
+
+// maxExactFloatInt float64 能精确表示的最大整数（2^53）
+const maxExactFloatInt = 1 << 53
+
+// maxExactFloatDigits 文本转 float64 后可无损还原的最大有效位数
+const maxExactFloatDigits = 15
+
+// NumericCollectValue 判断采集值能否按数值存储，返回数值及其类型。
+// 字符串仅在可无损还原时视为数值（如 "0012"、超过 15 位有效数字的小数仍按字符串存储）。
+func NumericCollectValue(value any) (float64, string, bool) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1, ValueTypeBool, true
+		}
+		return 0, ValueTypeBool, true
+	case int:
+		return intCollectValue(int64(v))
+	case int8:
+		return float64(v), ValueTypeInt, true
+	case int16:
+		return float64(v), ValueTypeInt, true
+	case int32:
+		return float64(v), ValueTypeInt, true
+	case int64:
+		return intCollectValue(v)
+	case uint:
+		return uintCollectValue(uint64(v))
+	case uint8:
+		return float64(v), ValueTypeInt, true
+	case uint16:
+		return float64(v), ValueTypeInt, true
+	case uint32:
+		return float64(v), ValueTypeInt, true
+	case uint64:
+		return uintCollectValue(v)
+	case float32:
+		// 按 32 位最短文本取值，避免 0.1 变成 0.10000000149011612
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return floatCollectValue(f)
+	case float64:
+		return floatCollectValue(v)
+	case string:
+		return numericCollectText(v)
+	case []byte:
+		return numericCollectText(string(v))
+	default:
+		return 0, "", false
+	}
+}
+
+func intCollectValue(v int64) (float64, string, bool) {
+	if v > maxExactFloatInt || v < -maxExactFloatInt {
+		return 0, "", false
+	}
+	return float64(v), ValueTypeInt, true
+}
+
+func uintCollectValue(v uint64) (float64, string, bool) {
+	if v > maxExactFloatInt {
+		return 0, "", false
+	}
+	return float64(v), ValueTypeInt, true
+}
+
+func floatCollectValue(v float64) (float64, string, bool) {
+	// NaN / Inf 无法写入 REAL 列后再以 JSON 数字返回
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, "", false
+	}
+	return v, ValueTypeFloat, true
+}
+
+func numericCollectText(text string) (float64, string, bool) {
+	switch text {
+	case "true":
+		return 1, ValueTypeBool, true
+	case "false":
+		return 0, ValueTypeBool, true
+	}
+	isInt, ok := plainDecimalText(text)
+	if !ok {
+		return 0, "", false
+	}
+	if isInt {
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return 0, "", false
+		}
+		return intCollectValue(v)
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return floatCollectValue(v)
+}
+
+// plainDecimalText 只接受 -?(0|[1-9][0-9]*)(.[0-9]+)? 形式的十进制文本，且有效位数不超过 15
+func plainDecimalText(text string) (isInt bool, ok bool) {
+	s := strings.TrimPrefix(text, "-")
+	if s == "" {
+		return false, false
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if intPart == "" || (len(intPart) > 1 && intPart[0] == '0') || (hasFrac && fracPart == "") {
+		return false, false
+	}
+	digits := 0
+	for _, part := range []string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return false, false
+			}
+			if digits > 0 || part[i] != '0' {
+				digits++
+			}
+		}
+	}
+	if hasFrac && digits > maxExactFloatDigits {
+		return false, false
+	}
+	return !hasFrac, true
+}
+
+// FormatNumericValue 将数值列还原为文本表示
+func FormatNumericValue(num float64, valueType string) string {
+	switch valueType {
+	case ValueTypeBool:
+		return strconv.FormatBool(num != 0)
+	case ValueTypeInt:
+		return strconv.FormatInt(int64(num), 10)
+	default:
+		return strconv.FormatFloat(num, 'f', -1, 64)
+	}
+}
+
+// TypedCollectValue 按 value_type 将文本值转换为 JSON 原生类型，无法转换时保持字符串
+func TypedCollectValue(text, valueType string) any {
+	switch valueType {
+	case ValueTypeBool:
+		if v, err := strconv.ParseBool(text); err == nil {
+			return v
+		}
+	case ValueTypeInt:
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case ValueTypeFloat:
+		if v, err := strconv.ParseFloat(text, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return v
+		}
+	}
+	return text
+}
@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"context"
 	"time"
 
 	"github.com/gonglijing/xunjiFsu/internal/models"
@@ -69,10 +70,22 @@ type RuntimeStatsSnapshot struct {
 	ProductKey              string
 	DeviceKey               string
 	Error                   string
+	// 服务端型北向（OPC UA / Modbus 从站）的监听地址与当前客户端连接数
+	ListenAddress string
+	Clients       int
+	// 推送型北向（HTTP）的目标地址
+	Endpoint string
+	// 断线/熔断暂存状态（由北向管理器填充）
+	SpoolDepth        int
+	SpoolSpooled      int64
+	SpoolReplayed     int64
+	SpoolDropped      int64
+	SpoolLastReplayAt time.Time
+	SpoolError        string
 }
 
 func (s RuntimeStatsSnapshot) HasPending() bool {
-	return s.PendingData > 0 || s.PendingAlarm > 0 || s.PendingCmd > 0
+	return s.PendingData > 0 || s.PendingAlarm > 0 || s.PendingCmd > 0 || s.SpoolDepth > 0
 }
 
 func (s RuntimeStatsSnapshot) ToMap() map[string]any {
@@ -145,6 +158,27 @@ func (s RuntimeStatsSnapshot) ToMap() map[string]any {
 	if s.Error != "" {
 		out["error"] = s.Error
 	}
+	if s.ListenAddress != "" {
+		out["listen_address"] = s.ListenAddress
+	}
+	if s.Clients > 0 {
+		out["clients"] = s.Clients
+	}
+	if s.Endpoint != "" {
+		out["endpoint"] = s.Endpoint
+	}
+	if s.SpoolDepth > 0 || s.SpoolSpooled > 0 {
+		out["spool_depth"] = s.SpoolDepth
+		out["spool_spooled"] = s.SpoolSpooled
+		out["spool_replayed"] = s.SpoolReplayed
+		out["spool_dropped"] = s.SpoolDropped
+	}
+	if !s.SpoolLastReplayAt.IsZero() {
+		out["spool_last_replay_at"] = s.SpoolLastReplayAt.Format(time.RFC3339)
+	}
+	if s.SpoolError != "" {
+		out["spool_error"] = s.SpoolError
+	}
 	return out
 }
 
@@ -161,6 +195,29 @@ type NorthboundAdapterWithCommands interface {
 	ReportCommandResult(result *models.NorthboundCommandResult) error
 }
 
+// CommandExecutor 同步执行一条设备写命令（由采集器直接调用驱动执行器实现），
+// 多字段命令在结果的 Fields 中给出逐字段结果；ctx 到期时应尽快返回
+type CommandExecutor func(ctx context.Context, command *models.NorthboundCommand) *models.NorthboundCommandResult
+
+// NorthboundAdapterWithCommandExecutor 客户端写入需要同步结果的适配器接口（如 OPC UA / Modbus 从站）
+type NorthboundAdapterWithCommandExecutor interface {
+	NorthboundAdapter
+	// SetCommandExecutor 设置写命令执行器，未设置时客户端写入失败
+	SetCommandExecutor(executor CommandExecutor)
+}
+
+// DevicePointResolver 返回设备预定义的测点（如内置 Modbus 点表），没有预定义时返回 nil
+type DevicePointResolver func(device *models.Device) []models.DevicePoint
+
+// NorthboundAdapterWithDeviceCatalog 按设备表维护地址空间的适配器接口（如 OPC UA 服务端）
+type NorthboundAdapterWithDeviceCatalog interface {
+	NorthboundAdapter
+	// SetDevicePointResolver 设置设备测点解析器，已初始化时按设备表重建节点
+	SetDevicePointResolver(resolver DevicePointResolver)
+	// ApplyDeviceCatalog 按当前设备表补建设备与测点，并移除已删除的设备与点表测点
+	ApplyDeviceCatalog(devices []*models.Device)
+}
+
 // NorthboundAdapterWithDeviceSync 支持设备同步能力的适配器接口
 type NorthboundAdapterWithDeviceSync interface {
 	NorthboundAdapter
@@ -168,6 +225,13 @@ type NorthboundAdapterWithDeviceSync interface {
 	SyncDevices() error
 }
 
+// NorthboundAdapterWithLiveData 需要每次采集实时值的适配器接口（如服务端型北向）
+type NorthboundAdapterWithLiveData interface {
+	NorthboundAdapter
+	// ApplyLiveData 采集落库后刷新实时值，不得阻塞
+	ApplyLiveData(data *models.CollectData)
+}
+
 // NewAdapter 创建指定类型的适配器
 func NewAdapter(northboundType, name string) NorthboundAdapter {
 	switch nbtype.Normalize(northboundType) {
@@ -181,6 +245,12 @@ func NewAdapter(northboundType, name string) NorthboundAdapter {
 		return NewIThingsAdapter(name)
 	case nbtype.TypeSagoo:
 		return NewSagooAdapter(name)
+	case nbtype.TypeOPCUA:
+		return NewOPCUAAdapter(name)
+	case nbtype.TypeModbusSlave:
+		return NewModbusSlaveAdapter(name)
+	case nbtype.TypeHTTP:
+		return NewHTTPAdapter(name)
 	default:
 		return nil
 	}
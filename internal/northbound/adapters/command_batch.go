@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// buildBatchWriteCommand 把同一设备的多个字段合并为一条命令，字段按名称排序，由驱动一次写入
+func buildBatchWriteCommand(requestID, productKey, deviceKey, source string, values map[string]any) *models.NorthboundCommand {
+	fields := make([]models.NorthboundCommandField, 0, len(values))
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		name := strings.TrimSpace(key)
+		if name == "" || values[key] == nil {
+			continue
+		}
+		fields = append(fields, models.NorthboundCommandField{FieldName: name, Value: stringifyAny(values[key])})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	command := &models.NorthboundCommand{
+		RequestID:  requestID,
+		ProductKey: productKey,
+		DeviceKey:  deviceKey,
+		FieldName:  fields[0].FieldName,
+		Value:      fields[0].Value,
+		Source:     source,
+	}
+	if len(fields) > 1 {
+		command.Fields = fields
+	}
+	return command
+}
+
+// commandResultWrittenValues 命令结果中写入成功的字段值
+func commandResultWrittenValues(result *models.NorthboundCommandResult) map[string]string {
+	out := make(map[string]string)
+	if len(result.Fields) == 0 {
+		if result.Success && result.FieldName != "" {
+			out[result.FieldName] = result.Value
+		}
+		return out
+	}
+	for _, field := range result.Fields {
+		if field.Success {
+			out[field.FieldName] = field.Value
+		}
+	}
+	return out
+}
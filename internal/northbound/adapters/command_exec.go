@@ -0,0 +1,66 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+var (
+	errCommandWaitTimeout         = errors.New("command result timeout")
+	errCommandExecutorUnavailable = errors.New("command executor not set")
+)
+
+// syncCommandRunner 服务端型北向的同步写命令：客户端写请求直接交给采集器设置的执行器，
+// 在写超时内等待驱动执行结果，不经过命令轮询队列
+type syncCommandRunner struct {
+	source string
+
+	mu       sync.RWMutex
+	executor CommandExecutor
+	inflight atomic.Int64
+}
+
+func newSyncCommandRunner(source string) *syncCommandRunner {
+	return &syncCommandRunner{source: source}
+}
+
+func (r *syncCommandRunner) setExecutor(executor CommandExecutor) {
+	r.mu.Lock()
+	r.executor = executor
+	r.mu.Unlock()
+}
+
+// run 执行写命令；超时返回 errCommandWaitTimeout，未设置执行器时返回 errCommandExecutorUnavailable
+func (r *syncCommandRunner) run(command models.NorthboundCommand, timeout time.Duration) (*models.NorthboundCommandResult, error) {
+	r.mu.RLock()
+	executor := r.executor
+	r.mu.RUnlock()
+	if executor == nil {
+		return nil, errCommandExecutorUnavailable
+	}
+
+	r.inflight.Add(1)
+	defer r.inflight.Add(-1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	command.Source = r.source
+	result := executor(ctx, &command)
+	if (result == nil || !result.Success) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, errCommandWaitTimeout
+	}
+	if result == nil {
+		return &models.NorthboundCommandResult{Source: r.source, Message: "empty command result"}, nil
+	}
+	return result, nil
+}
+
+// pending 正在执行的写命令数
+func (r *syncCommandRunner) pending() int {
+	return int(r.inflight.Load())
+}
@@ -29,6 +29,25 @@ func (b *NorthboundConfigBuilder) SetBrokerURL(url string) *NorthboundConfigBuil
 	return b
 }
 
+// SetListenAddress 设置服务端型北向的监听地址与端口
+func (b *NorthboundConfigBuilder) SetListenAddress(host string, port int) *NorthboundConfigBuilder {
+	if host != "" {
+		b.config["host"] = host
+	}
+	if port > 0 {
+		b.config["port"] = port
+	}
+	return b
+}
+
+// SetEndpointURL 设置推送地址（HTTP）
+func (b *NorthboundConfigBuilder) SetEndpointURL(url string) *NorthboundConfigBuilder {
+	if url != "" {
+		b.config["url"] = url
+	}
+	return b
+}
+
 // SetUsername 设置用户名
 func (b *NorthboundConfigBuilder) SetUsername(username string) *NorthboundConfigBuilder {
 	if username != "" {
@@ -182,6 +201,20 @@ func BuildConfigFromModel(cfg *models.NorthboundConfig) string {
 			includeProductIdentity: true,
 			includeUploadInterval:  true,
 		})
+
+	case nbtype.TypeOPCUA, nbtype.TypeModbusSlave:
+		builder.SetListenAddress(cfg.ServerURL, cfg.Port)
+		builder.SetExtConfig(cfg.ExtConfig)
+
+	case nbtype.TypeHTTP:
+		builder.SetEndpointURL(buildHTTPEndpoint(cfg.ServerURL, cfg.Port, cfg.Path))
+		builder.SetUsername(cfg.Username)
+		builder.SetPassword(cfg.Password)
+		builder.SetUploadIntervalMs(cfg.UploadInterval)
+		if cfg.Timeout > 0 {
+			builder.config["timeoutMs"] = cfg.Timeout * 1000
+		}
+		builder.SetExtConfig(cfg.ExtConfig)
 	}
 
 	return builder.Build()
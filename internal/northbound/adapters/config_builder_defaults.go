@@ -71,6 +71,37 @@ var northboundConfigDefaults = map[string]configDefaults{
 			"uploadIntervalMs": 5000,
 		},
 	},
+	nbtype.TypeOPCUA: {
+		values: map[string]any{
+			"host":           defaultOPCUAHost,
+			"port":           defaultOPCUAPort,
+			"namespaceUri":   defaultOPCUANamespaceURI,
+			"writeEnabled":   false,
+			"writeTimeoutMs": int(defaultOPCUAWriteTimeout.Milliseconds()),
+			"allowInsecure":  false,
+		},
+	},
+	nbtype.TypeModbusSlave: {
+		values: map[string]any{
+			"host":              defaultModbusSlaveHost,
+			"port":              defaultModbusSlavePort,
+			"writeEnabled":      false,
+			"writeTimeoutMs":    int(defaultModbusSlaveWriteTimeout.Milliseconds()),
+			"refreshIntervalMs": int(defaultModbusSlaveRefreshInterval.Milliseconds()),
+			"maxConnections":    defaultModbusSlaveMaxConnections,
+		},
+	},
+	nbtype.TypeHTTP: {
+		values: map[string]any{
+			"url":              "",
+			"method":           defaultHTTPMethod,
+			"batchSize":        defaultHTTPBatchSize,
+			"uploadIntervalMs": int(defaultReportInterval.Milliseconds()),
+			"timeoutMs":        int(defaultHTTPTimeout.Milliseconds()),
+			"maxRetries":       defaultHTTPMaxRetries,
+			"retryBackoffMs":   int(defaultHTTPRetryBackoff.Milliseconds()),
+		},
+	},
 }
 
 func (b *NorthboundConfigBuilder) applyDefaults(defaults configDefaults) {
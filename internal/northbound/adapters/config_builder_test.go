@@ -69,6 +69,49 @@ func TestBuildConfigFromModel_IThingsIncludesIdentityFields(t *testing.T) {
 	assertConfigValue(t, decoded, "uploadIntervalMs", float64(6000))
 }
 
+func TestBuildConfigFromModel_OPCUAUsesListenAddress(t *testing.T) {
+	cfg := &models.NorthboundConfig{
+		Type:      nbtype.TypeOPCUA,
+		ServerURL: "127.0.0.1",
+		Port:      14840,
+		ExtConfig: `{"writeEnabled":true}`,
+	}
+
+	decoded := decodeConfigJSON(t, BuildConfigFromModel(cfg))
+
+	assertConfigValue(t, decoded, "host", "127.0.0.1")
+	assertConfigValue(t, decoded, "port", float64(14840))
+	assertConfigValue(t, decoded, "writeEnabled", true)
+	assertConfigValue(t, decoded, "namespaceUri", defaultOPCUANamespaceURI)
+	if _, ok := decoded["broker"]; ok {
+		t.Fatalf("opcua config should not carry broker, got %v", decoded["broker"])
+	}
+}
+
+func TestBuildConfigFromModel_HTTPBuildsEndpointURL(t *testing.T) {
+	cfg := &models.NorthboundConfig{
+		Type:      nbtype.TypeHTTP,
+		ServerURL: "https://push.example.com",
+		Port:      80,
+		Path:      "/api/telemetry",
+		Username:  "gw",
+		Password:  "secret",
+		Timeout:   3,
+	}
+
+	decoded := decodeConfigJSON(t, BuildConfigFromModel(cfg))
+
+	assertConfigValue(t, decoded, "url", "https://push.example.com/api/telemetry")
+	assertConfigValue(t, decoded, "username", "gw")
+	assertConfigValue(t, decoded, "timeoutMs", float64(3000))
+	assertConfigValue(t, decoded, "method", "POST")
+
+	cfg.ServerURL = "10.0.0.5"
+	cfg.Port = 8080
+	decoded = decodeConfigJSON(t, BuildConfigFromModel(cfg))
+	assertConfigValue(t, decoded, "url", "http://10.0.0.5:8080/api/telemetry")
+}
+
 func decodeConfigJSON(t *testing.T, raw string) map[string]any {
 	t.Helper()
 
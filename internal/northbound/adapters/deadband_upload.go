@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// deadbandUploadFilter 周期上报前按设备死区规则筛选最新值：配置了规则的字段只在相对本适配器
+// 上次上报的值超出死区或静默超时时上报，其余字段照常上报。每个适配器各持一份，互不影响
+type deadbandUploadFilter struct {
+	mu         sync.Mutex
+	devices    map[int64]*deadbandUploadDevice
+	suppressed uint64
+}
+
+type deadbandUploadDevice struct {
+	raw     string
+	tracker *models.DeadbandTracker
+}
+
+// filter 返回 data 中需要上报的字段，deadbands 为设备当前的死区配置；没有字段需要上报时返回 nil
+func (f *deadbandUploadFilter) filter(data *models.CollectData, deadbands string) *models.CollectData {
+	if data == nil || len(data.Fields) == 0 {
+		return data
+	}
+	tracker := f.trackerFor(data.DeviceID, deadbands)
+	if tracker == nil {
+		return data
+	}
+
+	at := data.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	fields := make(map[string]string, len(data.Fields))
+	f.mu.Lock()
+	for name, value := range data.Fields {
+		if rule := tracker.Rule(name); rule != nil && !tracker.Report(name, value, rule, at) {
+			f.suppressed++
+			continue
+		}
+		fields[name] = value
+	}
+	f.mu.Unlock()
+
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) == len(data.Fields) {
+		return data
+	}
+	filtered := *data
+	filtered.Fields = fields
+	filtered.Points = nil
+	return &filtered
+}
+
+// trackerFor 设备死区配置未变化时沿用原跟踪器（保留本适配器上次上报的值）
+func (f *deadbandUploadFilter) trackerFor(deviceID int64, deadbands string) *models.DeadbandTracker {
+	deadbands = strings.TrimSpace(deadbands)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if device, ok := f.devices[deviceID]; ok && device.raw == deadbands {
+		return device.tracker
+	}
+	device := &deadbandUploadDevice{raw: deadbands}
+	if rules, err := models.ParseDeadbandRules(deadbands); err != nil {
+		slog.Warn("Invalid device deadbands, upload filter disabled", "device_id", deviceID, "error", err)
+	} else {
+		device.tracker = models.NewDeadbandTracker(rules)
+	}
+	if f.devices == nil {
+		f.devices = make(map[int64]*deadbandUploadDevice)
+	}
+	f.devices[deviceID] = device
+	return device.tracker
+}
+
+// suppressedCount 返回被死区抑制的字段数
+func (f *deadbandUploadFilter) suppressedCount() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.suppressed
+}
@@ -0,0 +1,254 @@
+package adapters
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/circuit"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// HTTPAdapter HTTP/HTTPS 推送北向适配器
+// 采集数据与报警分别缓冲，按批次以 JSON 请求推送到配置的地址
+type HTTPAdapter struct {
+	name         string
+	config       *HTTPConfig
+	client       *http.Client
+	bodyTemplate *template.Template
+	breaker      *circuit.CircuitBreaker
+	interval     time.Duration
+	lastSend     time.Time
+	lastError    string
+
+	// 数据缓冲
+	pendingData  []*models.CollectData
+	pendingMu    sync.Mutex
+	dataInFlight inFlightCounter
+
+	// 报警缓冲
+	pendingAlarms []*models.AlarmPayload
+	alarmMu       sync.Mutex
+	alarmInFlight inFlightCounter
+
+	// 控制通道
+	flushNow chan struct{}
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	// 状态
+	mu          sync.RWMutex
+	initialized bool
+	enabled     bool
+	connected   bool
+	loopState   adapterLoopState
+}
+
+// NewHTTPAdapter 创建HTTP适配器
+func NewHTTPAdapter(name string) *HTTPAdapter {
+	return &HTTPAdapter{
+		name:          name,
+		interval:      defaultReportInterval,
+		flushNow:      make(chan struct{}, 1),
+		stopChan:      make(chan struct{}),
+		pendingData:   make([]*models.CollectData, 0),
+		pendingAlarms: make([]*models.AlarmPayload, 0),
+		loopState:     adapterLoopStopped,
+	}
+}
+
+// Name 获取名称
+func (a *HTTPAdapter) Name() string {
+	return a.name
+}
+
+// Type 获取类型
+func (a *HTTPAdapter) Type() string {
+	return "http"
+}
+
+// Initialize 初始化
+func (a *HTTPAdapter) Initialize(configStr string) error {
+	cfg, err := parseHTTPConfig(configStr)
+	if err != nil {
+		return err
+	}
+	var bodyTemplate *template.Template
+	if cfg.BodyTemplate != "" {
+		if bodyTemplate, err = parseHTTPBodyTemplate(cfg.BodyTemplate); err != nil {
+			return err
+		}
+	}
+
+	_ = a.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	a.mu.Lock()
+	a.config = cfg
+	a.client = &http.Client{Transport: transport, Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond}
+	a.bodyTemplate = bodyTemplate
+	a.breaker = circuit.NewCircuitBreaker(circuit.DefaultConfig())
+	a.interval = cfg.uploadInterval()
+	a.lastError = ""
+	a.initialized = true
+	// 无长连接，初始视为可达，推送失败后置为断开，下次推送成功后恢复
+	a.connected = true
+	a.mu.Unlock()
+
+	slog.Info("HTTP adapter initialized", "name", a.name, "url", cfg.URL, "method", cfg.Method, "gzip", cfg.Gzip)
+	return nil
+}
+
+func (a *HTTPAdapter) lifecycleState() adapterLifecycleState {
+	return adapterLifecycleState{
+		adapterType:    "http",
+		logLabel:       "HTTP",
+		adapterName:    a.name,
+		mu:             &a.mu,
+		wg:             &a.wg,
+		initialized:    &a.initialized,
+		enabled:        &a.enabled,
+		connected:      &a.connected,
+		loopState:      &a.loopState,
+		stopChan:       &a.stopChan,
+		workSignalChan: &a.flushNow,
+	}
+}
+
+// Start 启动适配器的后台线程
+func (a *HTTPAdapter) Start() {
+	a.lifecycleState().start(a.executeLoop, nil)
+}
+
+// Stop 停止适配器的后台线程
+func (a *HTTPAdapter) Stop() {
+	a.lifecycleState().stop()
+}
+
+// SetInterval 设置发送周期（重启后台线程后生效）
+func (a *HTTPAdapter) SetInterval(interval time.Duration) {
+	a.mu.Lock()
+	if interval < minUploadInterval {
+		interval = minUploadInterval
+	}
+	a.interval = interval
+	a.mu.Unlock()
+}
+
+// IsEnabled 检查是否启用
+func (a *HTTPAdapter) IsEnabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.enabled
+}
+
+// IsConnected 最近一次推送是否成功
+func (a *HTTPAdapter) IsConnected() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.initialized && a.connected
+}
+
+// Close 关闭
+func (a *HTTPAdapter) Close() error {
+	return a.lifecycleState().close(
+		func() { _ = a.flushPendingData() },
+		func() { _ = a.flushAlarms() },
+		func() disconnectableClient {
+			if a.client != nil {
+				a.client.CloseIdleConnections()
+			}
+			a.client = nil
+			a.pendingData = nil
+			a.pendingAlarms = nil
+			return nil
+		},
+	)
+}
+
+// executeLoop 单协程事件循环（数据/报警）
+func (a *HTTPAdapter) executeLoop() {
+	defer func() {
+		a.mu.Lock()
+		transition := updateLoopState(&a.loopState, adapterLoopStopped)
+		a.mu.Unlock()
+		logLoopStateTransition("http", a.name, transition)
+		a.wg.Done()
+	}()
+
+	a.mu.RLock()
+	interval := a.interval
+	flushNow := a.flushNow
+	stopChan := a.stopChan
+	a.mu.RUnlock()
+
+	executePeriodicFlushLoop(periodicFlushLoopConfig{
+		logLabel:       "HTTP",
+		reportLabel:    "data",
+		reportInterval: interval,
+		alarmInterval:  defaultAlarmInterval,
+		stopChan:       stopChan,
+		flushNow:       flushNow,
+		flushData:      a.flushPendingData,
+		flushAlarm:     a.flushAlarms,
+		alarmQueueEmpty: func() bool {
+			a.alarmMu.Lock()
+			defer a.alarmMu.Unlock()
+			return len(a.pendingAlarms) == 0
+		},
+	})
+}
+
+// RuntimeStatsSnapshot 获取运行时统计
+func (a *HTTPAdapter) RuntimeStatsSnapshot() RuntimeStatsSnapshot {
+	a.mu.RLock()
+	endpoint := ""
+	if a.config != nil {
+		endpoint = a.config.URL
+	}
+	snapshot := RuntimeStatsSnapshot{
+		Name:        a.name,
+		Type:        "http",
+		Enabled:     a.enabled,
+		Initialized: a.initialized,
+		Connected:   a.initialized && a.connected,
+		LoopState:   a.loopState.String(),
+		IntervalMS:  a.interval.Milliseconds(),
+		Endpoint:    endpoint,
+		Error:       a.lastError,
+	}
+	a.mu.RUnlock()
+
+	a.pendingMu.Lock()
+	snapshot.PendingData = len(a.pendingData)
+	a.pendingMu.Unlock()
+
+	a.alarmMu.Lock()
+	snapshot.PendingAlarm = len(a.pendingAlarms)
+	a.alarmMu.Unlock()
+
+	return snapshot
+}
+
+func (a *HTTPAdapter) GetStats() map[string]any {
+	return a.RuntimeStatsSnapshot().ToMap()
+}
+
+// GetLastSendTime 获取最后一次推送成功的时间
+func (a *HTTPAdapter) GetLastSendTime() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.lastSend
+}
+
+// PendingCommandCount HTTP 推送不接收命令
+func (a *HTTPAdapter) PendingCommandCount() int {
+	return 0
+}
@@ -0,0 +1,228 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultHTTPMethod       = http.MethodPost
+	defaultHTTPBatchSize    = 50
+	defaultHTTPTimeout      = 10 * time.Second
+	defaultHTTPMaxRetries   = 3
+	defaultHTTPRetryBackoff = 500 * time.Millisecond
+	maxHTTPRetryBackoff     = 30 * time.Second
+	httpPendingDataCap      = 1000
+	httpPendingAlarmCap     = 200
+
+	httpAuthNone   = "none"
+	httpAuthBearer = "bearer"
+	httpAuthBasic  = "basic"
+)
+
+// HTTPConfig HTTP/HTTPS 推送北向配置
+type HTTPConfig struct {
+	URL      string `json:"url"`
+	AlarmURL string `json:"alarmUrl"` // 为空时报警与数据推送到同一地址
+	Method   string `json:"method"`
+	// Headers 自定义请求头，可为 JSON 对象或 JSON 字符串
+	Headers  map[string]string `json:"headers"`
+	AuthType string            `json:"authType"` // none / bearer / basic
+	Token    string            `json:"token"`
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	// BodyTemplate text/template 请求体模板，为空时使用默认 JSON 结构
+	BodyTemplate       string `json:"bodyTemplate"`
+	Gzip               bool   `json:"gzip"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+	BatchSize          int    `json:"batchSize"`
+	UploadIntervalMs   int    `json:"uploadIntervalMs"`
+	TimeoutMs          int    `json:"timeoutMs"`
+	MaxRetries         int    `json:"maxRetries"`
+	RetryBackoffMs     int    `json:"retryBackoffMs"`
+}
+
+func parseHTTPConfig(configStr string) (*HTTPConfig, error) {
+	raw, err := parseAdapterRawConfig(configStr)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := parseHTTPHeaders(raw.values["headers"])
+	if err != nil {
+		return nil, err
+	}
+	cfg := &HTTPConfig{
+		URL:                raw.pickString("url", "serverUrl", "server_url"),
+		AlarmURL:           raw.pickString("alarmUrl", "alarm_url"),
+		Method:             raw.pickString("method"),
+		Headers:            headers,
+		AuthType:           raw.pickString("authType", "auth_type"),
+		Token:              raw.pickString("token"),
+		Username:           raw.pickString("username"),
+		Password:           raw.pickString("password"),
+		BodyTemplate:       raw.pickString("bodyTemplate", "body_template"),
+		Gzip:               raw.pickBool(false, "gzip"),
+		InsecureSkipVerify: raw.pickBool(false, "insecureSkipVerify", "insecure_skip_verify"),
+		BatchSize:          raw.pickInt(0, "batchSize", "batch_size"),
+		UploadIntervalMs:   raw.pickInt(0, "uploadIntervalMs", "upload_interval_ms"),
+		TimeoutMs:          raw.pickInt(raw.pickInt(0, "connectTimeout")*1000, "timeoutMs", "timeout_ms"),
+		MaxRetries:         raw.pickInt(defaultHTTPMaxRetries, "maxRetries", "max_retries"),
+		RetryBackoffMs:     raw.pickInt(0, "retryBackoffMs", "retry_backoff_ms"),
+	}
+
+	if err := normalizeHTTPConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseHTTPHeaders 请求头既可以是 JSON 对象，也可以是表单里填写的 JSON 字符串
+func parseHTTPHeaders(value any) (map[string]string, error) {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		trimmed := strings.TrimSpace(v)
+		if trimmed == "" {
+			return nil, nil
+		}
+		data = []byte(trimmed)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid headers: %w", err)
+		}
+		data = encoded
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return nil, fmt.Errorf("invalid headers: %w", err)
+	}
+	return headers, nil
+}
+
+func normalizeHTTPConfig(cfg *HTTPConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+	if err := validateHTTPEndpoint(cfg.URL); err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+	if cfg.AlarmURL != "" {
+		if err := validateHTTPEndpoint(cfg.AlarmURL); err != nil {
+			return fmt.Errorf("alarmUrl: %w", err)
+		}
+	}
+
+	cfg.Method = strings.ToUpper(strings.TrimSpace(cfg.Method))
+	switch cfg.Method {
+	case "":
+		cfg.Method = defaultHTTPMethod
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("unsupported method %q", cfg.Method)
+	}
+
+	cfg.AuthType = strings.ToLower(strings.TrimSpace(cfg.AuthType))
+	switch cfg.AuthType {
+	case "":
+		// 只填写了用户名时按 Basic 认证处理，兼容模型字段 username/password
+		cfg.AuthType = httpAuthNone
+		if cfg.Username != "" {
+			cfg.AuthType = httpAuthBasic
+		}
+	case httpAuthNone:
+	case httpAuthBearer:
+		if cfg.Token == "" {
+			return fmt.Errorf("token is required for bearer auth")
+		}
+	case httpAuthBasic:
+		if cfg.Username == "" {
+			return fmt.Errorf("username is required for basic auth")
+		}
+	default:
+		return fmt.Errorf("unsupported authType %q", cfg.AuthType)
+	}
+
+	if cfg.BodyTemplate != "" {
+		if _, err := parseHTTPBodyTemplate(cfg.BodyTemplate); err != nil {
+			return fmt.Errorf("invalid bodyTemplate: %w", err)
+		}
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	applyDefaultPositiveInt(&cfg.BatchSize, defaultHTTPBatchSize)
+	applyDefaultPositiveInt(&cfg.UploadIntervalMs, int(defaultReportInterval.Milliseconds()))
+	applyDefaultPositiveInt(&cfg.TimeoutMs, int(defaultHTTPTimeout.Milliseconds()))
+	applyDefaultPositiveInt(&cfg.RetryBackoffMs, int(defaultHTTPRetryBackoff.Milliseconds()))
+	return nil
+}
+
+func validateHTTPEndpoint(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return fmt.Errorf("is required")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	return nil
+}
+
+func parseHTTPBodyTemplate(text string) (*template.Template, error) {
+	return template.New("body").Funcs(payloadTemplateFuncs).Parse(text)
+}
+
+func (c *HTTPConfig) alarmEndpoint() string {
+	if c.AlarmURL != "" {
+		return c.AlarmURL
+	}
+	return c.URL
+}
+
+func (c *HTTPConfig) uploadInterval() time.Duration {
+	interval := time.Duration(c.UploadIntervalMs) * time.Millisecond
+	if interval < minUploadInterval {
+		return minUploadInterval
+	}
+	return interval
+}
+
+// retryDelay 第 attempt 次重试前的等待时间，指数退避并封顶
+func (c *HTTPConfig) retryDelay(attempt int) time.Duration {
+	delay := time.Duration(c.RetryBackoffMs) * time.Millisecond
+	for i := 1; i < attempt && delay < maxHTTPRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxHTTPRetryBackoff)
+}
+
+// buildHTTPEndpoint 由模型字段拼出推送地址；https 地址忽略表单默认的 80 端口
+func buildHTTPEndpoint(serverURL string, port int, path string) string {
+	endpoint := ensureServerURLProtocol(serverURL, "http")
+	if endpoint == "" {
+		return ""
+	}
+	if !(strings.HasPrefix(strings.ToLower(endpoint), "https://") && port == 80) {
+		endpoint = appendServerURLPort(endpoint, port)
+	}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return endpoint
+	}
+	return strings.TrimRight(endpoint, "/") + "/" + strings.TrimLeft(path, "/")
+}
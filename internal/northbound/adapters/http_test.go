@@ -0,0 +1,227 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func TestParseHTTPConfig(t *testing.T) {
+	cfg, err := parseHTTPConfig(`{"url":"https://example.com/push","headers":"{\"X-Api-Key\":\"abc\"}","username":"gw"}`)
+	if err != nil {
+		t.Fatalf("parseHTTPConfig() error = %v", err)
+	}
+	if cfg.Method != "POST" || cfg.AuthType != httpAuthBasic || cfg.BatchSize != defaultHTTPBatchSize || cfg.MaxRetries != defaultHTTPMaxRetries {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.Headers["X-Api-Key"] != "abc" {
+		t.Fatalf("headers=%v", cfg.Headers)
+	}
+	if cfg.alarmEndpoint() != cfg.URL {
+		t.Fatalf("alarm endpoint=%q, want %q", cfg.alarmEndpoint(), cfg.URL)
+	}
+
+	invalid := []string{
+		`{}`,
+		`{"url":"ftp://example.com"}`,
+		`{"url":"http://example.com","method":"GET"}`,
+		`{"url":"http://example.com","authType":"bearer"}`,
+		`{"url":"http://example.com","authType":"digest"}`,
+		`{"url":"http://example.com","headers":"not-json"}`,
+		`{"url":"http://example.com","bodyTemplate":"{{.Items"}`,
+	}
+	for _, raw := range invalid {
+		if _, err := parseHTTPConfig(raw); err == nil {
+			t.Fatalf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestHTTPConfig_RetryDelay(t *testing.T) {
+	cfg := &HTTPConfig{RetryBackoffMs: 100}
+	if got := cfg.retryDelay(1); got != 100*time.Millisecond {
+		t.Fatalf("retryDelay(1)=%v", got)
+	}
+	if got := cfg.retryDelay(3); got != 400*time.Millisecond {
+		t.Fatalf("retryDelay(3)=%v", got)
+	}
+	if got := cfg.retryDelay(20); got != maxHTTPRetryBackoff {
+		t.Fatalf("retryDelay(20)=%v, want cap", got)
+	}
+}
+
+func TestHTTPAdapter_FlushBatchesWithAuthAndGzip(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches []httpPushPayload
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" || r.Header.Get("X-Site") != "plant-1" || r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var payload httpPushPayload
+		if err := json.NewDecoder(reader).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		batches = append(batches, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	adapter := NewHTTPAdapter("http-test")
+	config := `{"url":"` + server.URL + `","authType":"bearer","token":"t0ken","headers":{"X-Site":"plant-1"},"gzip":true,"batchSize":2}`
+	if err := adapter.Initialize(config); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer adapter.Close()
+
+	for i := 0; i < 3; i++ {
+		_ = adapter.Send(&models.CollectData{DeviceID: int64(i + 1), Fields: map[string]string{"temp": "21.5"}})
+	}
+	if err := adapter.flushPendingData(); err != nil {
+		t.Fatalf("flushPendingData() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 {
+		t.Fatalf("batches=%d, want 2", len(batches))
+	}
+	if batches[0].Type != httpPayloadData || batches[0].Gateway != "http-test" || len(batches[0].Items.([]any)) != 2 || len(batches[1].Items.([]any)) != 1 {
+		t.Fatalf("unexpected batches: %+v", batches)
+	}
+	if stats := adapter.RuntimeStatsSnapshot(); stats.PendingData != 0 || !stats.Connected || stats.Endpoint != server.URL {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestHTTPAdapter_BodyTemplateForAlarms(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies <- string(data)
+	}))
+	defer server.Close()
+
+	adapter := NewHTTPAdapter("gw")
+	config := `{"url":"http://127.0.0.1:1/unused","alarmUrl":"` + server.URL + `","bodyTemplate":"{\"gw\":\"{{.Gateway}}\",\"kind\":\"{{.Type}}\",\"n\":{{len .Items}},\"first\":{{json (index .Items 0).FieldName}}}"}`
+	if err := adapter.Initialize(config); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer adapter.Close()
+
+	_ = adapter.SendAlarm(&models.AlarmPayload{DeviceID: 1, FieldName: "temp", ActualValue: 90})
+	if err := adapter.flushAlarms(); err != nil {
+		t.Fatalf("flushAlarms() error = %v", err)
+	}
+	if got := <-bodies; got != `{"gw":"gw","kind":"alarm","n":1,"first":"temp"}` {
+		t.Fatalf("body=%s", got)
+	}
+}
+
+func TestHTTPAdapter_RetryAndRequeue(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if failing.Load() || n <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	adapter := NewHTTPAdapter("retry")
+	if err := adapter.Initialize(`{"url":"` + server.URL + `","maxRetries":2,"retryBackoffMs":1}`); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	// 仅运行中的适配器才会退避重试，关闭时的最后一次刷新不等待
+	adapter.Start()
+	defer adapter.Close()
+
+	_ = adapter.Send(&models.CollectData{DeviceID: 1, Fields: map[string]string{"a": "1"}})
+	if err := adapter.flushPendingData(); err != nil {
+		t.Fatalf("flushPendingData() error = %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("calls=%d, want 3 (two retries)", got)
+	}
+
+	failing.Store(true)
+	_ = adapter.Send(&models.CollectData{DeviceID: 2, Fields: map[string]string{"a": "2"}})
+	if err := adapter.flushPendingData(); err == nil {
+		t.Fatalf("expected flush error after retries exhausted")
+	}
+	stats := adapter.RuntimeStatsSnapshot()
+	if stats.PendingData != 1 || stats.Connected || stats.Error == "" {
+		t.Fatalf("batch should be kept for next cycle, stats=%+v", stats)
+	}
+}
+
+func TestHTTPAdapter_ClientErrorDropsBatch(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	adapter := NewHTTPAdapter("reject")
+	if err := adapter.Initialize(`{"url":"` + server.URL + `","retryBackoffMs":1}`); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer adapter.Close()
+
+	_ = adapter.Send(&models.CollectData{DeviceID: 1})
+	if err := adapter.flushPendingData(); err != nil {
+		t.Fatalf("flushPendingData() error = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls=%d, 4xx should not be retried", calls.Load())
+	}
+	if stats := adapter.RuntimeStatsSnapshot(); stats.PendingData != 0 || !stats.Connected {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestHTTPAdapter_PendingQueueCountsInFlightBatch(t *testing.T) {
+	adapter := NewHTTPAdapter("inflight")
+	var duringPost PendingQueueState
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		duringPost = adapter.PendingQueue()
+	}))
+	defer server.Close()
+
+	if err := adapter.Initialize(`{"url":"` + server.URL + `"}`); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer adapter.Close()
+
+	_ = adapter.Send(&models.CollectData{DeviceID: 1})
+	_ = adapter.Send(&models.CollectData{DeviceID: 2})
+	if err := adapter.flushPendingData(); err != nil {
+		t.Fatalf("flushPendingData() error = %v", err)
+	}
+	// 推送过程中批次已出队但仍计入待发，暂存补发不能据此提前确认
+	if duringPost.Data != 2 || duringPost.Drained() || duringPost.DataCap != httpPendingDataCap {
+		t.Fatalf("queue during post = %+v", duringPost)
+	}
+	if state := adapter.PendingQueue(); !state.Drained() || state.DataSpace() != httpPendingDataCap {
+		t.Fatalf("queue after flush = %+v", state)
+	}
+}
@@ -0,0 +1,305 @@
+package adapters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/circuit"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+const (
+	httpPayloadData  = "data"
+	httpPayloadAlarm = "alarm"
+)
+
+// httpPushPayload 默认请求体，同时作为 bodyTemplate 的模板数据
+type httpPushPayload struct {
+	Type      string `json:"type"` // data / alarm
+	Gateway   string `json:"gateway"`
+	Timestamp int64  `json:"timestamp"` // 毫秒
+	Items     any    `json:"items"`
+}
+
+// httpStatusError 服务端返回非 2xx 状态码
+type httpStatusError struct {
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// retryable 4xx（408/429 除外）说明请求本身有误，重试无意义
+func (e *httpStatusError) retryable() bool {
+	if e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return e.StatusCode < 400 || e.StatusCode >= 500
+}
+
+// httpPushSettings 一次刷新使用的配置快照
+type httpPushSettings struct {
+	config       *HTTPConfig
+	client       *http.Client
+	bodyTemplate *template.Template
+	breaker      *circuit.CircuitBreaker
+	stopChan     <-chan struct{}
+}
+
+// Send 发送数据（加入缓冲队列）
+func (a *HTTPAdapter) Send(data *models.CollectData) error {
+	if data == nil {
+		return nil
+	}
+
+	a.pendingMu.Lock()
+	a.pendingData = appendQueueItemWithCap(a.pendingData, data, httpPendingDataCap)
+	a.pendingMu.Unlock()
+
+	return nil
+}
+
+// SendAlarm 发送报警（加入缓冲队列并尽快推送）
+func (a *HTTPAdapter) SendAlarm(alarm *models.AlarmPayload) error {
+	if alarm == nil {
+		return nil
+	}
+	item := *alarm
+	if item.Timestamp.IsZero() {
+		item.Timestamp = time.Now()
+	}
+
+	a.alarmMu.Lock()
+	a.pendingAlarms = appendQueueItemWithCap(a.pendingAlarms, &item, httpPendingAlarmCap)
+	a.alarmMu.Unlock()
+
+	a.mu.RLock()
+	flushNow := a.flushNow
+	a.mu.RUnlock()
+	signalStructChan(flushNow)
+
+	return nil
+}
+
+// PendingQueue 获取待发队列状态（含正在发送的批次）
+func (a *HTTPAdapter) PendingQueue() PendingQueueState {
+	a.pendingMu.Lock()
+	data := len(a.pendingData) + a.dataInFlight.load()
+	dataCap := httpPendingDataCap
+	a.pendingMu.Unlock()
+
+	a.alarmMu.Lock()
+	alarm := len(a.pendingAlarms) + a.alarmInFlight.load()
+	alarmCap := httpPendingAlarmCap
+	a.alarmMu.Unlock()
+
+	return PendingQueueState{Data: data, DataCap: dataCap, Alarm: alarm, AlarmCap: alarmCap}
+}
+
+// flushPendingData 按批次推送缓冲的采集数据
+func (a *HTTPAdapter) flushPendingData() error {
+	settings, ok := a.pushSettings()
+	if !ok {
+		return nil
+	}
+	return flushHTTPQueue(&a.pendingMu, &a.pendingData, &a.dataInFlight, settings.config.BatchSize, httpPendingDataCap, func(batch []*models.CollectData) error {
+		for _, data := range batch {
+			data.EnsureFields()
+		}
+		return a.deliverBatch(settings, httpPayloadData, settings.config.URL, batch)
+	})
+}
+
+// flushAlarms 按批次推送缓冲的报警
+func (a *HTTPAdapter) flushAlarms() error {
+	settings, ok := a.pushSettings()
+	if !ok {
+		return nil
+	}
+	return flushHTTPQueue(&a.alarmMu, &a.pendingAlarms, &a.alarmInFlight, settings.config.BatchSize, httpPendingAlarmCap, func(batch []*models.AlarmPayload) error {
+		return a.deliverBatch(settings, httpPayloadAlarm, settings.config.alarmEndpoint(), batch)
+	})
+}
+
+// flushHTTPQueue 依次取出一批推送；失败时整批放回队首，等待下个周期
+func flushHTTPQueue[T any](mu *sync.Mutex, queue *[]T, flight *inFlightCounter, batchSize, capLimit int, deliver func([]T) error) error {
+	for {
+		mu.Lock()
+		n := min(len(*queue), batchSize)
+		if n == 0 {
+			mu.Unlock()
+			return nil
+		}
+		batch := make([]T, n)
+		copy(batch, (*queue)[:n])
+		clear((*queue)[:n])
+		*queue = (*queue)[n:]
+		done := flight.begin(n)
+		mu.Unlock()
+
+		if err := deliver(batch); err != nil {
+			mu.Lock()
+			*queue = prependQueueWithCap(*queue, batch, capLimit)
+			mu.Unlock()
+			done()
+			return err
+		}
+		done()
+	}
+}
+
+func (a *HTTPAdapter) pushSettings() (httpPushSettings, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.initialized || a.config == nil || a.client == nil {
+		return httpPushSettings{}, false
+	}
+	return httpPushSettings{
+		config:       a.config,
+		client:       a.client,
+		bodyTemplate: a.bodyTemplate,
+		breaker:      a.breaker,
+		stopChan:     a.stopChan,
+	}, true
+}
+
+// deliverBatch 编码并推送一批数据，返回错误表示应保留该批次稍后重试
+func (a *HTTPAdapter) deliverBatch(settings httpPushSettings, kind, endpoint string, items any) error {
+	body, err := a.encodeBody(settings, kind, items)
+	if err != nil {
+		// 模板/编码错误重试也不会成功，直接丢弃，避免阻塞后续数据
+		slog.Warn("HTTP encode payload failed, batch dropped", "adapter", a.name, "type", kind, "error", err)
+		a.recordPushResult(err, false)
+		return nil
+	}
+
+	var rejected error
+	for attempt := 0; ; attempt++ {
+		err = settings.breaker.Execute(func() error {
+			postErr := a.post(settings, endpoint, body)
+			var statusErr *httpStatusError
+			if errors.As(postErr, &statusErr) && !statusErr.retryable() {
+				// 服务端拒收不代表地址不可达，不计入熔断
+				rejected = postErr
+				return nil
+			}
+			return postErr
+		})
+		if err == nil {
+			break
+		}
+		var openErr *circuit.CircuitOpenError
+		if errors.As(err, &openErr) || attempt >= settings.config.MaxRetries || !waitHTTPRetry(settings.stopChan, settings.config.retryDelay(attempt+1)) {
+			slog.Warn("HTTP push failed", "adapter", a.name, "type", kind, "attempts", attempt+1, "error", err)
+			a.recordPushResult(err, true)
+			return err
+		}
+	}
+
+	if rejected != nil {
+		slog.Warn("HTTP push rejected, batch dropped", "adapter", a.name, "type", kind, "error", rejected)
+	}
+	a.recordPushResult(rejected, false)
+	return nil
+}
+
+// waitHTTPRetry 退避等待；适配器停止时立即放弃重试
+func waitHTTPRetry(stopChan <-chan struct{}, delay time.Duration) bool {
+	if stopChan == nil {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-stopChan:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (a *HTTPAdapter) recordPushResult(err error, unreachable bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.connected = !unreachable
+	if err != nil {
+		a.lastError = err.Error()
+		return
+	}
+	a.lastError = ""
+	a.lastSend = time.Now()
+}
+
+func (a *HTTPAdapter) encodeBody(settings httpPushSettings, kind string, items any) ([]byte, error) {
+	payload := httpPushPayload{
+		Type:      kind,
+		Gateway:   a.name,
+		Timestamp: time.Now().UnixMilli(),
+		Items:     items,
+	}
+
+	var buf bytes.Buffer
+	if settings.bodyTemplate != nil {
+		if err := settings.bodyTemplate.Execute(&buf, payload); err != nil {
+			return nil, err
+		}
+	} else if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+		return nil, err
+	}
+	if !settings.config.Gzip {
+		return buf.Bytes(), nil
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+func (a *HTTPAdapter) post(settings httpPushSettings, endpoint string, body []byte) error {
+	cfg := settings.config
+	req, err := http.NewRequest(cfg.Method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	switch cfg.AuthType {
+	case httpAuthBearer:
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	case httpAuthBasic:
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+	for key, value := range cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := settings.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &httpStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
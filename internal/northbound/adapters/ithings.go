@@ -67,6 +67,8 @@ type iThingsRequestState struct {
 	Message    string
 	FieldName  string
 	Value      string
+	// Fields 批量写的逐字段结果
+	Fields []models.NorthboundCommandFieldResult
 }
 
 // IThingsAdapter iThings 北向适配器
@@ -98,9 +100,11 @@ type IThingsAdapter struct {
 
 	realtimeQueue []*models.CollectData
 	dataMu        sync.RWMutex
+	dataInFlight  inFlightCounter
 
-	alarmQueue []*models.AlarmPayload
-	alarmMu    sync.RWMutex
+	alarmQueue    []*models.AlarmPayload
+	alarmMu       sync.RWMutex
+	alarmInFlight inFlightCounter
 
 	commandQueue []*models.NorthboundCommand
 	commandMu    sync.RWMutex
@@ -2,7 +2,6 @@ package adapters
 
 import (
 	"encoding/json"
-	"slices"
 	"strings"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -62,30 +61,8 @@ func buildIThingsCommands(requestID, topicType, method, actionID string, params
 	out := make([]*models.NorthboundCommand, 0)
 
 	appendPropertyCommands := func(values map[string]any) {
-		if len(values) == 0 {
-			return
-		}
-		keys := make([]string, 0, len(values))
-		for key := range values {
-			trimmed := strings.TrimSpace(key)
-			if trimmed == "" {
-				continue
-			}
-			keys = append(keys, trimmed)
-		}
-		if len(keys) == 0 {
-			return
-		}
-		slices.Sort(keys)
-		for _, key := range keys {
-			out = append(out, &models.NorthboundCommand{
-				RequestID:  requestID,
-				ProductKey: productID,
-				DeviceKey:  deviceName,
-				FieldName:  key,
-				Value:      stringifyAny(values[key]),
-				Source:     "ithings.down.property",
-			})
+		if command := buildBatchWriteCommand(requestID, productID, deviceName, "ithings.down.property", values); command != nil {
+			out = append(out, command)
 		}
 	}
 
@@ -130,6 +107,7 @@ func (a *IThingsAdapter) applyCommandResult(result *models.NorthboundCommandResu
 			Message:    strings.TrimSpace(result.Message),
 			FieldName:  strings.TrimSpace(result.FieldName),
 			Value:      result.Value,
+			Fields:     result.Fields,
 		}
 		return fallback, true
 	}
@@ -153,6 +131,7 @@ func (a *IThingsAdapter) applyCommandResult(result *models.NorthboundCommandResu
 	if strings.TrimSpace(result.Value) != "" {
 		state.Value = result.Value
 	}
+	state.Fields = append(state.Fields, result.Fields...)
 	if strings.TrimSpace(result.ProductKey) != "" {
 		state.ProductID = strings.TrimSpace(result.ProductKey)
 	}
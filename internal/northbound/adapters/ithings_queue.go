@@ -40,6 +40,7 @@ type iThingsAlarmPublishParams struct {
 	Operator    string  `json:"operator"`
 	Severity    string  `json:"severity"`
 	Message     string  `json:"message"`
+	State       string  `json:"state,omitempty"`
 }
 
 type iThingsAlarmPublishPayload struct {
@@ -81,6 +82,21 @@ func (a *IThingsAdapter) SendAlarm(alarm *models.AlarmPayload) error {
 	return nil
 }
 
+// PendingQueue 获取待发队列状态（含正在发送的批次）
+func (a *IThingsAdapter) PendingQueue() PendingQueueState {
+	a.dataMu.RLock()
+	data := len(a.realtimeQueue) + a.dataInFlight.load()
+	dataCap := queueCapOrDefault(a.realtimeCap, defaultRealtimeQueue)
+	a.dataMu.RUnlock()
+
+	a.alarmMu.RLock()
+	alarm := len(a.alarmQueue) + a.alarmInFlight.load()
+	alarmCap := queueCapOrDefault(a.alarmCap, defaultAlarmQueue)
+	a.alarmMu.RUnlock()
+
+	return PendingQueueState{Data: data, DataCap: dataCap, Alarm: alarm, AlarmCap: alarmCap}
+}
+
 func (a *IThingsAdapter) flushRealtime() error {
 	a.dataMu.Lock()
 	if len(a.realtimeQueue) == 0 {
@@ -89,7 +105,9 @@ func (a *IThingsAdapter) flushRealtime() error {
 	}
 	batch := a.realtimeQueue
 	a.realtimeQueue = nil
+	done := a.dataInFlight.begin(len(batch))
 	a.dataMu.Unlock()
+	defer done()
 
 	for _, item := range batch {
 		topic, body, err := a.buildRealtimePublish(item)
@@ -128,7 +146,9 @@ func (a *IThingsAdapter) flushAlarmBatch() error {
 	batch := a.alarmQueue[:count]
 	a.alarmQueue = a.alarmQueue[count:]
 	a.alarmQueue = a.alarmQueue[:len(a.alarmQueue):len(a.alarmQueue)]
+	done := a.alarmInFlight.begin(len(batch))
 	a.alarmMu.Unlock()
+	defer done()
 
 	for _, item := range batch {
 		topic, body, err := a.buildAlarmPublish(item)
@@ -242,7 +262,7 @@ func (a *IThingsAdapter) buildAlarmPublish(alarm *models.AlarmPayload) (string,
 	payload := iThingsAlarmPublishPayload{
 		Method:    "eventPost",
 		MsgToken:  a.nextID("alarm"),
-		Timestamp: time.Now().UnixMilli(),
+		Timestamp: alarm.TimestampOrNow().UnixMilli(),
 		EventID:   alarmEventID,
 		Type:      alarmEventType,
 		Params: iThingsAlarmPublishParams{
@@ -255,6 +275,7 @@ func (a *IThingsAdapter) buildAlarmPublish(alarm *models.AlarmPayload) (string,
 			Operator:    alarm.Operator,
 			Severity:    alarm.Severity,
 			Message:     alarm.Message,
+			State:       alarm.State,
 		},
 	}
 	body, _ := json.Marshal(payload)
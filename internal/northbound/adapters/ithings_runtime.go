@@ -10,11 +10,12 @@ import (
 )
 
 type iThingsControlReplyPayload struct {
-	Method    string `json:"method"`
-	MsgToken  string `json:"msgToken"`
-	Code      int    `json:"code"`
-	Msg       string `json:"msg"`
-	Timestamp int64  `json:"timestamp"`
+	Method    string                                `json:"method"`
+	MsgToken  string                                `json:"msgToken"`
+	Code      int                                   `json:"code"`
+	Msg       string                                `json:"msg"`
+	Timestamp int64                                 `json:"timestamp"`
+	Fields    []models.NorthboundCommandFieldResult `json:"fields,omitempty"`
 }
 
 type iThingsActionReplyPayload struct {
@@ -182,6 +183,7 @@ func (a *IThingsAdapter) ReportCommandResult(result *models.NorthboundCommandRes
 		Code:      code,
 		Msg:       message,
 		Timestamp: timestamp,
+		Fields:    state.Fields,
 	}
 	topic := renderIThingsTopic(a.upPropertyTopicTemplate, state.ProductID, state.DeviceName)
 	body, _ := json.Marshal(payload)
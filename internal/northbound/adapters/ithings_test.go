@@ -52,6 +52,32 @@ func TestIThingsPullCommands_PopsInBatch(t *testing.T) {
 	}
 }
 
+func TestBuildIThingsCommands_BatchesControlProperties(t *testing.T) {
+	commands, _, _ := buildIThingsCommands("tok-1", "property", "control", "", map[string]any{"sp": 21, "mode": "auto"}, "p1", "d1")
+	if len(commands) != 1 {
+		t.Fatalf("len(commands)=%d, want=1", len(commands))
+	}
+	command := commands[0]
+	if command.Source != "ithings.down.property" || len(command.Fields) != 2 || command.Fields[0].FieldName != "mode" || command.Fields[1].Value != "21" {
+		t.Fatalf("command mismatch: %+v", command)
+	}
+
+	adapter := NewIThingsAdapter("ithings-test")
+	adapter.requestStates["tok-1"] = &iThingsRequestState{RequestID: "tok-1", TopicType: "property", Method: "control", Pending: len(commands), Success: true}
+	state, ready := adapter.applyCommandResult(&models.NorthboundCommandResult{
+		RequestID: "tok-1",
+		Success:   false,
+		Partial:   true,
+		Fields: []models.NorthboundCommandFieldResult{
+			{FieldName: "mode", Value: "auto", Success: true},
+			{FieldName: "sp", Value: "21", Success: false, Message: "timeout"},
+		},
+	})
+	if !ready || state.Success || len(state.Fields) != 2 || state.Fields[1].Message != "timeout" {
+		t.Fatalf("state=%+v ready=%v", state, ready)
+	}
+}
+
 func TestIThingsBuildRealtimePublish(t *testing.T) {
 	adapter := NewIThingsAdapter("ithings-test")
 	adapter.config = &IThingsConfig{ProductKey: "gwpk", DeviceKey: "gwdk"}
@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/nbtype"
+)
+
+// MessagePreview 按当前配置渲染出的一条示例消息
+type MessagePreview struct {
+	Kind    string `json:"kind"` // data / alarm / command_result
+	Topic   string `json:"topic"`
+	Payload string `json:"payload"`
+}
+
+// PreviewMessages 用示例数据渲染北向的主题与载荷，便于对接新平台前核对模板
+func PreviewMessages(northboundType, name, configStr string) ([]MessagePreview, error) {
+	switch nbtype.Normalize(northboundType) {
+	case nbtype.TypeMQTT:
+		templates, err := parseMQTTTemplateConfig(configStr, name)
+		if err != nil {
+			return nil, err
+		}
+		return previewMQTTMessages(templates)
+	default:
+		return nil, fmt.Errorf("message preview is not supported for %s", nbtype.DisplayName(northboundType))
+	}
+}
+
+func previewMQTTMessages(templates *mqttMessageTemplates) ([]MessagePreview, error) {
+	now := time.Now()
+	data := &models.CollectData{
+		DeviceID:   1,
+		DeviceName: "demo-device",
+		ProductKey: "demo_product",
+		DeviceKey:  "demo_device",
+		Timestamp:  now,
+		Fields:     map[string]string{"temperature": "23.5", "humidity": "61"},
+	}
+	alarm := &models.AlarmPayload{
+		DeviceID:    data.DeviceID,
+		DeviceName:  data.DeviceName,
+		ProductKey:  data.ProductKey,
+		DeviceKey:   data.DeviceKey,
+		FieldName:   "temperature",
+		ActualValue: 85,
+		Threshold:   80,
+		Operator:    ">",
+		Severity:    "critical",
+		Message:     "temperature too high",
+		State:       models.AlarmStateActive,
+		Timestamp:   now,
+	}
+	result := &models.NorthboundCommandResult{
+		RequestID:  "req-1",
+		ProductKey: data.ProductKey,
+		DeviceKey:  data.DeviceKey,
+		FieldName:  "setpoint",
+		Value:      "25",
+		Success:    true,
+		Code:       200,
+		Message:    "success",
+	}
+
+	dataMessages, err := templates.renderData(data)
+	if err != nil {
+		return nil, fmt.Errorf("render data: %w", err)
+	}
+	previews := make([]MessagePreview, 0, len(dataMessages)+2)
+	for _, message := range dataMessages {
+		previews = append(previews, MessagePreview{Kind: "data", Topic: message.Topic, Payload: message.Payload})
+	}
+
+	alarmMessage, err := templates.renderAlarm(alarm)
+	if err != nil {
+		return nil, fmt.Errorf("render alarm: %w", err)
+	}
+	previews = append(previews, MessagePreview{Kind: "alarm", Topic: alarmMessage.Topic, Payload: alarmMessage.Payload})
+
+	resultMessage, err := templates.renderCommandResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("render command result: %w", err)
+	}
+	previews = append(previews, MessagePreview{Kind: "command_result", Topic: resultMessage.Topic, Payload: resultMessage.Payload})
+	return previews, nil
+}
@@ -0,0 +1,568 @@
+package adapters
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/nbtype"
+)
+
+const (
+	modbusSlaveCommandSource = "modbus_slave.write"
+	modbusSlaveIdleTimeout   = 2 * time.Minute
+
+	modbusSlaveMaxReadRegisters  = 125
+	modbusSlaveMaxWriteRegisters = 123
+
+	modbusExceptionIllegalFunction     = 0x01
+	modbusExceptionIllegalDataAddress  = 0x02
+	modbusExceptionIllegalDataValue    = 0x03
+	modbusExceptionServerDeviceFailure = 0x04
+	modbusExceptionGatewayTargetFailed = 0x0B
+)
+
+type modbusSlaveIdentity struct {
+	productKey string
+	deviceKey  string
+}
+
+// ModbusSlaveAdapter 网关作为 Modbus TCP 从站：按寄存器映射把实时缓存暴露给只会轮询 Modbus 的 PLC，
+// 主站写保持寄存器时转为设备写命令，由采集器设置的执行器直接调用驱动同步执行。
+type ModbusSlaveAdapter struct {
+	name     string
+	config   *ModbusSlaveConfig
+	interval time.Duration
+
+	image    *modbusSlaveImage
+	listener net.Listener
+	address  string
+
+	connMu     sync.Mutex
+	conns      map[net.Conn]struct{}
+	closing    bool
+	identities map[int64]modbusSlaveIdentity
+
+	commands *syncCommandRunner
+
+	stopChan    chan struct{}
+	serveWG     sync.WaitGroup
+	wg          sync.WaitGroup
+	mu          sync.RWMutex
+	initialized bool
+	enabled     bool
+	connected   bool
+	loopState   adapterLoopState
+}
+
+func NewModbusSlaveAdapter(name string) *ModbusSlaveAdapter {
+	return &ModbusSlaveAdapter{
+		name:       name,
+		interval:   defaultReportInterval,
+		conns:      make(map[net.Conn]struct{}),
+		identities: make(map[int64]modbusSlaveIdentity),
+		commands:   newSyncCommandRunner(modbusSlaveCommandSource),
+		stopChan:   make(chan struct{}),
+		loopState:  adapterLoopStopped,
+	}
+}
+
+func (a *ModbusSlaveAdapter) Name() string { return a.name }
+
+func (a *ModbusSlaveAdapter) Type() string { return nbtype.TypeModbusSlave }
+
+// Initialize 解析寄存器映射，用当前实时缓存填充镜像后开始监听
+func (a *ModbusSlaveAdapter) Initialize(configStr string) error {
+	cfg, err := parseModbusSlaveConfig(configStr)
+	if err != nil {
+		return err
+	}
+
+	devices, err := database.ListDevices()
+	if err != nil {
+		slog.Warn("Modbus slave device list load failed", "name", a.name, "error", err)
+	}
+	cache, err := database.GetAllDataCache()
+	if err != nil {
+		slog.Warn("Modbus slave data cache load failed", "name", a.name, "error", err)
+	}
+	return a.initializeWithSnapshot(cfg, devices, cache)
+}
+
+func (a *ModbusSlaveAdapter) initializeWithSnapshot(cfg *ModbusSlaveConfig, devices []*models.Device, cache []*models.DataCache) error {
+	image, err := newModbusSlaveImage(cfg.Registers)
+	if err != nil {
+		return err
+	}
+	image.applyCache(cache)
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen modbus slave: %w", err)
+	}
+
+	a.mu.Lock()
+	a.config = cfg
+	a.image = image
+	a.listener = listener
+	a.address = "tcp://" + listener.Addr().String()
+	a.initialized = true
+	a.connected = true
+	a.loopState = adapterLoopStopped
+	a.mu.Unlock()
+	a.applyIdentities(devices)
+
+	a.serveWG.Add(1)
+	go a.serve(listener)
+
+	slog.Info("Modbus slave adapter initialized", "name", a.name, "address", a.address, "registers", len(cfg.Registers), "write_enabled", cfg.WriteEnabled)
+	return nil
+}
+
+// SyncDevices 刷新设备身份（写命令按 product_key/device_key 定位设备）
+func (a *ModbusSlaveAdapter) SyncDevices() error {
+	devices, err := database.ListDevices()
+	if err != nil {
+		return fmt.Errorf("list devices: %w", err)
+	}
+	a.applyIdentities(devices)
+	return nil
+}
+
+func (a *ModbusSlaveAdapter) applyIdentities(devices []*models.Device) {
+	identities := make(map[int64]modbusSlaveIdentity, len(devices))
+	for _, device := range devices {
+		if device == nil {
+			continue
+		}
+		identities[device.ID] = modbusSlaveIdentity{productKey: device.ProductKey, deviceKey: device.DeviceKey}
+	}
+	a.connMu.Lock()
+	a.identities = identities
+	a.connMu.Unlock()
+}
+
+func (a *ModbusSlaveAdapter) identity(deviceID int64) (modbusSlaveIdentity, bool) {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	identity, ok := a.identities[deviceID]
+	return identity, ok && identity.productKey != "" && identity.deviceKey != ""
+}
+
+func (a *ModbusSlaveAdapter) lifecycleState() adapterLifecycleState {
+	return adapterLifecycleState{
+		adapterType: nbtype.TypeModbusSlave,
+		logLabel:    "Modbus slave",
+		adapterName: a.name,
+		mu:          &a.mu,
+		wg:          &a.wg,
+		initialized: &a.initialized,
+		enabled:     &a.enabled,
+		connected:   &a.connected,
+		loopState:   &a.loopState,
+		stopChan:    &a.stopChan,
+	}
+}
+
+func (a *ModbusSlaveAdapter) Start() {
+	a.lifecycleState().start(a.refreshLoop, nil)
+}
+
+func (a *ModbusSlaveAdapter) Stop() {
+	a.lifecycleState().stop()
+}
+
+func (a *ModbusSlaveAdapter) Close() error {
+	err := a.lifecycleState().close(nil, nil, nil)
+
+	a.mu.Lock()
+	listener := a.listener
+	a.listener = nil
+	a.mu.Unlock()
+	if listener != nil {
+		_ = listener.Close()
+	}
+
+	a.connMu.Lock()
+	a.closing = true
+	for conn := range a.conns {
+		_ = conn.Close()
+	}
+	a.connMu.Unlock()
+	a.serveWG.Wait()
+	return err
+}
+
+// refreshLoop 按刷新周期从实时缓存重建寄存器值
+func (a *ModbusSlaveAdapter) refreshLoop() {
+	defer func() {
+		a.mu.Lock()
+		transition := updateLoopState(&a.loopState, adapterLoopStopped)
+		a.mu.Unlock()
+		logLoopStateTransition(nbtype.TypeModbusSlave, a.name, transition)
+		a.wg.Done()
+	}()
+
+	a.mu.RLock()
+	stopChan := a.stopChan
+	interval := a.config.refreshInterval()
+	a.mu.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			a.refresh()
+		}
+	}
+}
+
+func (a *ModbusSlaveAdapter) refresh() {
+	image := a.currentImage()
+	if image == nil {
+		return
+	}
+	cache, err := database.GetAllDataCache()
+	if err != nil {
+		slog.Warn("Modbus slave data cache refresh failed", "name", a.name, "error", err)
+		return
+	}
+	image.applyCache(cache)
+}
+
+func (a *ModbusSlaveAdapter) currentImage() *modbusSlaveImage {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.image
+}
+
+func (a *ModbusSlaveAdapter) serve(listener net.Listener) {
+	defer a.serveWG.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Warn("Modbus slave accept failed", "name", a.name, "error", err)
+			}
+			return
+		}
+
+		a.mu.RLock()
+		maxConnections := a.config.MaxConnections
+		a.mu.RUnlock()
+
+		a.connMu.Lock()
+		if a.closing {
+			a.connMu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		if len(a.conns) >= maxConnections {
+			a.connMu.Unlock()
+			slog.Warn("Modbus slave connection rejected", "name", a.name, "remote", conn.RemoteAddr().String(), "max_connections", maxConnections)
+			_ = conn.Close()
+			continue
+		}
+		a.conns[conn] = struct{}{}
+		a.connMu.Unlock()
+
+		a.serveWG.Add(1)
+		go a.serveConn(conn)
+	}
+}
+
+// serveConn 处理一个主站连接：MBAP 头 7 字节（事务号、协议号、长度、单元标识）+ PDU
+func (a *ModbusSlaveAdapter) serveConn(conn net.Conn) {
+	defer func() {
+		a.connMu.Lock()
+		delete(a.conns, conn)
+		a.connMu.Unlock()
+		_ = conn.Close()
+		a.serveWG.Done()
+	}()
+
+	header := make([]byte, 7)
+	body := make([]byte, 256)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(modbusSlaveIdleTimeout))
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(header[4:6]))
+		if binary.BigEndian.Uint16(header[2:4]) != 0 || length < 2 || length > len(body) {
+			return
+		}
+		pdu := body[:length-1]
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		response := a.handlePDU(header[6], pdu)
+		frame := make([]byte, 7+len(response))
+		copy(frame, header[:4])
+		binary.BigEndian.PutUint16(frame[4:6], uint16(len(response)+1))
+		frame[6] = header[6]
+		copy(frame[7:], response)
+		if _, err := conn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+func (a *ModbusSlaveAdapter) handlePDU(unitID byte, pdu []byte) []byte {
+	function := pdu[0]
+
+	a.mu.RLock()
+	cfg := a.config
+	image := a.image
+	enabled := a.enabled
+	a.mu.RUnlock()
+	if cfg == nil || image == nil {
+		return modbusExceptionPDU(function, modbusExceptionServerDeviceFailure)
+	}
+	if cfg.UnitID != 0 && int(unitID) != cfg.UnitID {
+		return modbusExceptionPDU(function, modbusExceptionGatewayTargetFailed)
+	}
+
+	switch function {
+	case 0x03, 0x04:
+		if len(pdu) != 5 {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataValue)
+		}
+		start := int(binary.BigEndian.Uint16(pdu[1:3]))
+		quantity := int(binary.BigEndian.Uint16(pdu[3:5]))
+		if quantity < 1 || quantity > modbusSlaveMaxReadRegisters {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataValue)
+		}
+		if start+quantity > 65536 {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataAddress)
+		}
+		table := modbusSlaveTableHolding
+		if function == 0x04 {
+			table = modbusSlaveTableInput
+		}
+		data, err := image.read(table, start, quantity)
+		if err != nil {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataAddress)
+		}
+		return append([]byte{function, byte(len(data))}, data...)
+
+	case 0x06:
+		if len(pdu) != 5 {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataValue)
+		}
+		start := int(binary.BigEndian.Uint16(pdu[1:3]))
+		if code := a.writeRegisters(cfg, image, enabled, start, pdu[3:5]); code != 0 {
+			return modbusExceptionPDU(function, code)
+		}
+		return append([]byte(nil), pdu...)
+
+	case 0x10:
+		if len(pdu) < 6 {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataValue)
+		}
+		start := int(binary.BigEndian.Uint16(pdu[1:3]))
+		quantity := int(binary.BigEndian.Uint16(pdu[3:5]))
+		byteCount := int(pdu[5])
+		if quantity < 1 || quantity > modbusSlaveMaxWriteRegisters || byteCount != quantity*2 || len(pdu) != 6+byteCount {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataValue)
+		}
+		if start+quantity > 65536 {
+			return modbusExceptionPDU(function, modbusExceptionIllegalDataAddress)
+		}
+		if code := a.writeRegisters(cfg, image, enabled, start, pdu[6:]); code != 0 {
+			return modbusExceptionPDU(function, code)
+		}
+		return append([]byte(nil), pdu[:5]...)
+
+	default:
+		return modbusExceptionPDU(function, modbusExceptionIllegalFunction)
+	}
+}
+
+// modbusSlaveWriteResult 单个寄存器映射的写入结果
+type modbusSlaveWriteResult struct {
+	write   modbusSlaveWrite
+	success bool
+	timeout bool
+	message string
+}
+
+// writeRegisters 把主站写入转为设备写命令并等待结果，返回 0 表示成功，否则为 Modbus 异常码；
+// 写成功的映射立即更新镜像，部分失败时只有失败的映射保持原值
+func (a *ModbusSlaveAdapter) writeRegisters(cfg *ModbusSlaveConfig, image *modbusSlaveImage, enabled bool, start int, data []byte) byte {
+	if !cfg.WriteEnabled {
+		return modbusExceptionIllegalDataAddress
+	}
+	writes, err := image.resolveWrite(start, data)
+	if err != nil {
+		if errors.Is(err, errModbusSlaveIllegalValue) {
+			return modbusExceptionIllegalDataValue
+		}
+		return modbusExceptionIllegalDataAddress
+	}
+	if !enabled {
+		return modbusExceptionServerDeviceFailure
+	}
+
+	var code byte
+	for _, result := range a.executeWrites(writes, cfg.writeTimeout()) {
+		binding := result.write.binding
+		if result.success {
+			image.storeWrite(binding.start, data[(binding.start-start)*2:(binding.end-start)*2])
+			continue
+		}
+		slog.Warn("Modbus slave write failed", "name", a.name, "device_id", binding.deviceID, "field", binding.field,
+			"value", result.write.value, "timeout", result.timeout, "error", result.message)
+		if result.timeout {
+			code = modbusExceptionGatewayTargetFailed
+		} else if code == 0 {
+			code = modbusExceptionServerDeviceFailure
+		}
+	}
+	return code
+}
+
+// executeWrites 按设备合并为多字段写命令依次执行，返回与 writes 一一对应的结果；所有设备共用一个超时
+func (a *ModbusSlaveAdapter) executeWrites(writes []modbusSlaveWrite, timeout time.Duration) []modbusSlaveWriteResult {
+	results := make([]modbusSlaveWriteResult, len(writes))
+	byDevice := make(map[int64][]int)
+	var order []int64
+	for i, write := range writes {
+		results[i].write = write
+		deviceID := write.binding.deviceID
+		if _, ok := byDevice[deviceID]; !ok {
+			order = append(order, deviceID)
+		}
+		byDevice[deviceID] = append(byDevice[deviceID], i)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, deviceID := range order {
+		indexes := byDevice[deviceID]
+		identity, ok := a.identity(deviceID)
+		if !ok {
+			for _, i := range indexes {
+				results[i].message = "device has no product_key/device_key"
+			}
+			continue
+		}
+		command := models.NorthboundCommand{ProductKey: identity.productKey, DeviceKey: identity.deviceKey}
+		for _, i := range indexes {
+			command.Fields = append(command.Fields, models.NorthboundCommandField{FieldName: writes[i].binding.field, Value: writes[i].value})
+		}
+		command.FieldName, command.Value = command.Fields[0].FieldName, command.Fields[0].Value
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			for _, i := range indexes {
+				results[i].timeout = true
+				results[i].message = errCommandWaitTimeout.Error()
+			}
+			continue
+		}
+		result, err := a.commands.run(command, remaining)
+		for n, i := range indexes {
+			switch {
+			case err != nil:
+				results[i].timeout = errors.Is(err, errCommandWaitTimeout)
+				results[i].message = err.Error()
+			case len(result.Fields) == len(indexes):
+				results[i].success = result.Fields[n].Success
+				results[i].message = result.Fields[n].Message
+			default:
+				results[i].success = result.Success
+				results[i].message = result.Message
+			}
+		}
+	}
+	return results
+}
+
+func modbusExceptionPDU(function, code byte) []byte {
+	return []byte{function | 0x80, code}
+}
+
+// Send 从站数据来自实时缓存，不使用上报数据
+func (a *ModbusSlaveAdapter) Send(data *models.CollectData) error {
+	return nil
+}
+
+// SendAlarm Modbus 从站不提供报警
+func (a *ModbusSlaveAdapter) SendAlarm(alarm *models.AlarmPayload) error {
+	return nil
+}
+
+func (a *ModbusSlaveAdapter) SetCommandExecutor(executor CommandExecutor) {
+	a.commands.setExecutor(executor)
+}
+
+func (a *ModbusSlaveAdapter) PendingCommandCount() int {
+	return a.commands.pending()
+}
+
+func (a *ModbusSlaveAdapter) SetInterval(interval time.Duration) {
+	a.mu.Lock()
+	if interval < minUploadInterval {
+		interval = minUploadInterval
+	}
+	a.interval = interval
+	a.mu.Unlock()
+}
+
+func (a *ModbusSlaveAdapter) IsEnabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.enabled
+}
+
+func (a *ModbusSlaveAdapter) IsConnected() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.connected
+}
+
+func (a *ModbusSlaveAdapter) RuntimeStatsSnapshot() RuntimeStatsSnapshot {
+	a.mu.RLock()
+	snapshot := RuntimeStatsSnapshot{
+		Name:          a.name,
+		Type:          nbtype.TypeModbusSlave,
+		Enabled:       a.enabled,
+		Initialized:   a.initialized,
+		Connected:     a.connected,
+		LoopState:     a.loopState.String(),
+		IntervalMS:    a.interval.Milliseconds(),
+		ListenAddress: a.address,
+	}
+	a.mu.RUnlock()
+
+	a.connMu.Lock()
+	snapshot.Clients = len(a.conns)
+	a.connMu.Unlock()
+	snapshot.PendingCmd = a.PendingCommandCount()
+	return snapshot
+}
+
+func (a *ModbusSlaveAdapter) GetStats() map[string]any {
+	return a.RuntimeStatsSnapshot().ToMap()
+}
+
+// GetLastSendTime 返回寄存器镜像最近一次从实时缓存刷新的时间
+func (a *ModbusSlaveAdapter) GetLastSendTime() time.Time {
+	image := a.currentImage()
+	if image == nil {
+		return time.Time{}
+	}
+	return image.lastRefresh()
+}
@@ -0,0 +1,192 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/driver"
+)
+
+const (
+	defaultModbusSlaveHost            = "0.0.0.0"
+	defaultModbusSlavePort            = 502
+	defaultModbusSlaveRefreshInterval = time.Second
+	defaultModbusSlaveWriteTimeout    = 5 * time.Second
+	defaultModbusSlaveMaxConnections  = 8
+
+	modbusSlaveTableHolding = "holding"
+	modbusSlaveTableInput   = "input"
+)
+
+type ModbusSlaveConfig struct {
+	Host              string                `json:"host"`
+	Port              int                   `json:"port"`
+	UnitID            int                   `json:"unitId"`
+	WriteEnabled      bool                  `json:"writeEnabled"`
+	WriteTimeoutMs    int                   `json:"writeTimeoutMs"`
+	RefreshIntervalMs int                   `json:"refreshIntervalMs"`
+	MaxConnections    int                   `json:"maxConnections"`
+	Registers         []ModbusSlaveRegister `json:"registers"`
+}
+
+// ModbusSlaveRegister 把 (设备, 字段) 绑定到保持/输入寄存器，编码规则与内置点表一致
+type ModbusSlaveRegister struct {
+	DeviceID  int64   `json:"device_id"`
+	Field     string  `json:"field"`
+	Table     string  `json:"table"` // holding / input
+	Address   uint16  `json:"address"`
+	DataType  string  `json:"data_type"`
+	ByteOrder string  `json:"byte_order"`
+	WordOrder string  `json:"word_order"`
+	Scale     float64 `json:"scale"`
+	Offset    float64 `json:"offset"`
+	RW        string  `json:"rw"` // R / W / RW，仅保持寄存器可写
+}
+
+func parseModbusSlaveConfig(configStr string) (*ModbusSlaveConfig, error) {
+	raw, err := parseAdapterRawConfig(configStr)
+	if err != nil {
+		return nil, err
+	}
+
+	registers, err := parseModbusSlaveRegisters(raw.values["registers"])
+	if err != nil {
+		return nil, err
+	}
+	cfg := &ModbusSlaveConfig{
+		Host:              raw.pickString("host", "listenHost", "serverUrl", "server_url"),
+		Port:              raw.pickInt(0, "port"),
+		UnitID:            raw.pickInt(0, "unitId", "unit_id"),
+		WriteEnabled:      raw.pickBool(false, "writeEnabled", "write_enabled"),
+		WriteTimeoutMs:    raw.pickInt(0, "writeTimeoutMs", "write_timeout_ms"),
+		RefreshIntervalMs: raw.pickInt(0, "refreshIntervalMs", "refresh_interval_ms"),
+		MaxConnections:    raw.pickInt(0, "maxConnections", "max_connections"),
+		Registers:         registers,
+	}
+
+	if err := normalizeModbusSlaveConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// parseModbusSlaveRegisters 寄存器映射既可以是 JSON 数组，也可以是表单里填写的 JSON 字符串
+func parseModbusSlaveRegisters(value any) ([]ModbusSlaveRegister, error) {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, fmt.Errorf("registers is required")
+	case string:
+		data = []byte(strings.TrimSpace(v))
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registers: %w", err)
+		}
+		data = encoded
+	}
+	var registers []ModbusSlaveRegister
+	if err := json.Unmarshal(data, &registers); err != nil {
+		return nil, fmt.Errorf("invalid registers: %w", err)
+	}
+	return registers, nil
+}
+
+func normalizeModbusSlaveConfig(cfg *ModbusSlaveConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+	host, port := splitListenHostPort(cfg.Host)
+	cfg.Host = host
+	if cfg.Port <= 0 {
+		cfg.Port = port
+	}
+	applyDefaultString(&cfg.Host, defaultModbusSlaveHost)
+	applyDefaultPositiveInt(&cfg.Port, defaultModbusSlavePort)
+	if cfg.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if cfg.UnitID < 0 || cfg.UnitID > 255 {
+		return fmt.Errorf("unitId must be between 0 and 255")
+	}
+	applyDefaultPositiveInt(&cfg.WriteTimeoutMs, int(defaultModbusSlaveWriteTimeout.Milliseconds()))
+	applyDefaultPositiveInt(&cfg.RefreshIntervalMs, int(defaultModbusSlaveRefreshInterval.Milliseconds()))
+	applyDefaultPositiveInt(&cfg.MaxConnections, defaultModbusSlaveMaxConnections)
+
+	if len(cfg.Registers) == 0 {
+		return fmt.Errorf("registers is required")
+	}
+	for i := range cfg.Registers {
+		if err := normalizeModbusSlaveRegister(&cfg.Registers[i]); err != nil {
+			return fmt.Errorf("register #%d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func normalizeModbusSlaveRegister(register *ModbusSlaveRegister) error {
+	register.Field = strings.TrimSpace(register.Field)
+	if register.DeviceID <= 0 {
+		return fmt.Errorf("device_id is required")
+	}
+	if register.Field == "" {
+		return fmt.Errorf("field is required")
+	}
+	switch strings.ToLower(strings.TrimSpace(register.Table)) {
+	case "", modbusSlaveTableHolding:
+		register.Table = modbusSlaveTableHolding
+	case modbusSlaveTableInput:
+		register.Table = modbusSlaveTableInput
+	default:
+		return fmt.Errorf("unsupported table %q", register.Table)
+	}
+
+	point := register.point()
+	if err := driver.NormalizeModbusPoint(&point); err != nil {
+		return err
+	}
+	if int(point.Address)+driver.ModbusDataTypeRegisters(point.DataType) > 65536 {
+		return fmt.Errorf("address %d out of range for %s", point.Address, point.DataType)
+	}
+	register.DataType = point.DataType
+	register.ByteOrder = point.ByteOrder
+	register.WordOrder = point.WordOrder
+	register.Scale = point.Scale
+	register.RW = point.RW
+	return nil
+}
+
+// point 转为内置点表测点，复用其数据类型、字节序与缩放规则
+func (r ModbusSlaveRegister) point() driver.ModbusPoint {
+	function := 3
+	if r.Table == modbusSlaveTableInput {
+		function = 4
+	}
+	return driver.ModbusPoint{
+		Name:      r.Field,
+		Address:   r.Address,
+		Function:  function,
+		DataType:  r.DataType,
+		ByteOrder: r.ByteOrder,
+		WordOrder: r.WordOrder,
+		Scale:     r.Scale,
+		Offset:    r.Offset,
+		RW:        r.RW,
+	}
+}
+
+func (c *ModbusSlaveConfig) writeTimeout() time.Duration {
+	if c == nil || c.WriteTimeoutMs <= 0 {
+		return defaultModbusSlaveWriteTimeout
+	}
+	return time.Duration(c.WriteTimeoutMs) * time.Millisecond
+}
+
+func (c *ModbusSlaveConfig) refreshInterval() time.Duration {
+	if c == nil || c.RefreshIntervalMs <= 0 {
+		return defaultModbusSlaveRefreshInterval
+	}
+	return time.Duration(c.RefreshIntervalMs) * time.Millisecond
+}
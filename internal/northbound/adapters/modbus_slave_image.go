@@ -0,0 +1,220 @@
+package adapters
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/driver"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+var (
+	errModbusSlaveIllegalAddress = errors.New("illegal data address")
+	errModbusSlaveIllegalValue   = errors.New("illegal data value")
+)
+
+// modbusSlaveBinding 一条寄存器映射在寄存器表中占用的区间
+type modbusSlaveBinding struct {
+	deviceID int64
+	field    string
+	point    driver.ModbusPoint
+	start    int
+	end      int // 不含
+}
+
+func (b *modbusSlaveBinding) writable() bool {
+	return strings.Contains(b.point.RW, "W")
+}
+
+// modbusSlaveTable 单张寄存器表：映射按地址排序，寄存器值按地址存放
+type modbusSlaveTable struct {
+	bindings []*modbusSlaveBinding
+	values   map[int]uint16
+}
+
+// modbusSlaveImage 对外提供的寄存器镜像，由实时缓存刷新
+type modbusSlaveImage struct {
+	mu        sync.RWMutex
+	holding   modbusSlaveTable
+	input     modbusSlaveTable
+	byField   map[modbusSlaveFieldKey][]*modbusSlaveBinding
+	refreshed time.Time
+}
+
+type modbusSlaveFieldKey struct {
+	deviceID int64
+	field    string
+}
+
+// modbusSlaveWrite 主站写入解析出的单个设备写命令
+type modbusSlaveWrite struct {
+	binding *modbusSlaveBinding
+	value   string
+}
+
+func newModbusSlaveImage(registers []ModbusSlaveRegister) (*modbusSlaveImage, error) {
+	image := &modbusSlaveImage{
+		holding: modbusSlaveTable{values: make(map[int]uint16)},
+		input:   modbusSlaveTable{values: make(map[int]uint16)},
+		byField: make(map[modbusSlaveFieldKey][]*modbusSlaveBinding, len(registers)),
+	}
+	for _, register := range registers {
+		point := register.point()
+		binding := &modbusSlaveBinding{
+			deviceID: register.DeviceID,
+			field:    register.Field,
+			point:    point,
+			start:    int(point.Address),
+			end:      int(point.Address) + driver.ModbusDataTypeRegisters(point.DataType),
+		}
+		table := image.table(register.Table)
+		table.bindings = append(table.bindings, binding)
+		key := modbusSlaveFieldKey{deviceID: register.DeviceID, field: register.Field}
+		image.byField[key] = append(image.byField[key], binding)
+	}
+
+	for _, name := range []string{modbusSlaveTableHolding, modbusSlaveTableInput} {
+		table := image.table(name)
+		slices.SortFunc(table.bindings, func(a, b *modbusSlaveBinding) int {
+			return cmp.Compare(a.start, b.start)
+		})
+		for i := 1; i < len(table.bindings); i++ {
+			prev, cur := table.bindings[i-1], table.bindings[i]
+			if cur.start < prev.end {
+				return nil, fmt.Errorf("%s register %d overlaps %s/%s", name, cur.start, prev.field, cur.field)
+			}
+		}
+	}
+	return image, nil
+}
+
+func (img *modbusSlaveImage) table(name string) *modbusSlaveTable {
+	if name == modbusSlaveTableInput {
+		return &img.input
+	}
+	return &img.holding
+}
+
+// applyCache 用实时缓存刷新寄存器，返回更新的映射数量；无法编码的值保持上一次内容
+func (img *modbusSlaveImage) applyCache(items []*models.DataCache) int {
+	img.mu.Lock()
+	defer img.mu.Unlock()
+
+	updated := 0
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		bindings := img.byField[modbusSlaveFieldKey{deviceID: item.DeviceID, field: item.FieldName}]
+		for _, binding := range bindings {
+			raw, err := driver.EncodeModbusValue(binding.point, modbusSlaveNumericText(item.Value))
+			if err != nil {
+				continue
+			}
+			img.storeLocked(img.table(modbusSlaveBindingTable(binding)), binding.start, raw)
+			updated++
+		}
+	}
+	img.refreshed = time.Now()
+	return updated
+}
+
+// read 读取连续寄存器；区间内没有任何映射时视为非法地址，映射之间的空洞读为 0
+func (img *modbusSlaveImage) read(tableName string, start, quantity int) ([]byte, error) {
+	img.mu.RLock()
+	defer img.mu.RUnlock()
+
+	table := img.table(tableName)
+	if !table.overlaps(start, start+quantity) {
+		return nil, errModbusSlaveIllegalAddress
+	}
+	out := make([]byte, quantity*2)
+	for i := 0; i < quantity; i++ {
+		value := table.values[start+i]
+		out[i*2] = byte(value >> 8)
+		out[i*2+1] = byte(value)
+	}
+	return out, nil
+}
+
+// resolveWrite 把写入的保持寄存器解析为设备写命令：只能完整覆盖可写映射，不允许写空洞或半个多寄存器值
+func (img *modbusSlaveImage) resolveWrite(start int, data []byte) ([]modbusSlaveWrite, error) {
+	img.mu.RLock()
+	defer img.mu.RUnlock()
+
+	end := start + len(data)/2
+	writes := make([]modbusSlaveWrite, 0, 1)
+	covered := start
+	for _, binding := range img.holding.bindings {
+		if binding.end <= start || binding.start >= end {
+			continue
+		}
+		if binding.start < start || binding.end > end || binding.start != covered || !binding.writable() {
+			return nil, errModbusSlaveIllegalAddress
+		}
+		offset := (binding.start - start) * 2
+		value, err := driver.DecodeModbusValue(binding.point, data[offset:offset+(binding.end-binding.start)*2])
+		if err != nil {
+			return nil, errModbusSlaveIllegalValue
+		}
+		writes = append(writes, modbusSlaveWrite{binding: binding, value: models.CollectPointValueString(value)})
+		covered = binding.end
+	}
+	if covered != end {
+		return nil, errModbusSlaveIllegalAddress
+	}
+	return writes, nil
+}
+
+// storeWrite 写命令执行成功后立即更新镜像，主站回读不必等下次刷新
+func (img *modbusSlaveImage) storeWrite(start int, data []byte) {
+	img.mu.Lock()
+	defer img.mu.Unlock()
+	img.storeLocked(&img.holding, start, data)
+}
+
+func (img *modbusSlaveImage) storeLocked(table *modbusSlaveTable, start int, raw []byte) {
+	for i := 0; i+1 < len(raw); i += 2 {
+		table.values[start+i/2] = uint16(raw[i])<<8 | uint16(raw[i+1])
+	}
+}
+
+func (img *modbusSlaveImage) lastRefresh() time.Time {
+	img.mu.RLock()
+	defer img.mu.RUnlock()
+	return img.refreshed
+}
+
+func (t *modbusSlaveTable) overlaps(start, end int) bool {
+	for _, binding := range t.bindings {
+		if binding.start < end && binding.end > start {
+			return true
+		}
+	}
+	return false
+}
+
+func modbusSlaveBindingTable(binding *modbusSlaveBinding) string {
+	if binding.point.Function == 4 {
+		return modbusSlaveTableInput
+	}
+	return modbusSlaveTableHolding
+}
+
+// modbusSlaveNumericText 布尔量按 1/0 编码，其余原样交给点表编码
+func modbusSlaveNumericText(value string) string {
+	value = strings.TrimSpace(value)
+	if b, err := strconv.ParseBool(value); err == nil {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+	return value
+}
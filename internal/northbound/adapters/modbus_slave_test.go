@@ -0,0 +1,303 @@
+package adapters
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+const modbusSlaveTestRegisters = `[
+	{"device_id":1,"field":"temp","address":0,"data_type":"float32"},
+	{"device_id":1,"field":"setpoint","address":2,"data_type":"uint16","scale":0.1,"rw":"RW"},
+	{"device_id":2,"field":"running","table":"input","address":10,"data_type":"uint16"}
+]`
+
+func TestParseModbusSlaveConfig(t *testing.T) {
+	cfg, err := parseModbusSlaveConfig(`{"port":1502,"registers":` + modbusSlaveTestRegisters + `}`)
+	if err != nil {
+		t.Fatalf("parseModbusSlaveConfig() error = %v", err)
+	}
+	if cfg.Host != defaultModbusSlaveHost || cfg.Port != 1502 || cfg.MaxConnections != defaultModbusSlaveMaxConnections {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if got := cfg.Registers[0]; got.Table != modbusSlaveTableHolding || got.RW != "R" || got.Scale != 1 {
+		t.Fatalf("register defaults not applied: %+v", got)
+	}
+
+	// 表单里以字符串形式提交的映射同样可用
+	quoted := `{"registers":"[{\"device_id\":1,\"field\":\"temp\"}]"}`
+	if _, err := parseModbusSlaveConfig(quoted); err != nil {
+		t.Fatalf("string registers error = %v", err)
+	}
+
+	invalid := []string{
+		`{}`,
+		`{"registers":[]}`,
+		`{"registers":[{"field":"temp"}]}`,
+		`{"registers":[{"device_id":1,"field":"temp","table":"input","rw":"RW"}]}`,
+		`{"registers":[{"device_id":1,"field":"temp","table":"coil"}]}`,
+		`{"unitId":300,"registers":[{"device_id":1,"field":"temp"}]}`,
+	}
+	for _, raw := range invalid {
+		if _, err := parseModbusSlaveConfig(raw); err == nil {
+			t.Fatalf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestModbusSlaveImage_ReadAndResolveWrite(t *testing.T) {
+	image := newModbusSlaveTestImage(t)
+	image.applyCache([]*models.DataCache{
+		{DeviceID: 1, FieldName: "temp", Value: "21.5"},
+		{DeviceID: 1, FieldName: "setpoint", Value: "12.3"},
+		{DeviceID: 2, FieldName: "running", Value: "true"},
+		{DeviceID: 3, FieldName: "temp", Value: "99"},
+	})
+
+	data, err := image.read(modbusSlaveTableHolding, 0, 4)
+	if err != nil {
+		t.Fatalf("read holding error = %v", err)
+	}
+	if got := math.Float32frombits(binary.BigEndian.Uint32(data[0:4])); got != 21.5 {
+		t.Fatalf("temp=%v, want=21.5", got)
+	}
+	if got := binary.BigEndian.Uint16(data[4:6]); got != 123 {
+		t.Fatalf("setpoint raw=%d, want=123", got)
+	}
+	if got := binary.BigEndian.Uint16(data[6:8]); got != 0 {
+		t.Fatalf("gap register=%d, want=0", got)
+	}
+
+	// 超出 uint16 范围或为负的值无法编码，寄存器保持上一次内容而不是回绕
+	for _, value := range []string{"7000", "-5"} {
+		if updated := image.applyCache([]*models.DataCache{{DeviceID: 1, FieldName: "setpoint", Value: value}}); updated != 0 {
+			t.Fatalf("setpoint %s: updated=%d, want=0", value, updated)
+		}
+		kept, err := image.read(modbusSlaveTableHolding, 2, 1)
+		if err != nil || binary.BigEndian.Uint16(kept) != 123 {
+			t.Fatalf("setpoint %s: raw=%v err=%v, want=123 kept", value, kept, err)
+		}
+	}
+
+	input, err := image.read(modbusSlaveTableInput, 10, 1)
+	if err != nil || binary.BigEndian.Uint16(input) != 1 {
+		t.Fatalf("running=%v err=%v, want=1", input, err)
+	}
+	if _, err := image.read(modbusSlaveTableHolding, 100, 2); err == nil {
+		t.Fatalf("expected illegal address for unmapped range")
+	}
+
+	writes, err := image.resolveWrite(2, []byte{0x00, 0xFA})
+	if err != nil || len(writes) != 1 {
+		t.Fatalf("resolveWrite error = %v writes=%d", err, len(writes))
+	}
+	if writes[0].binding.field != "setpoint" || writes[0].value != "25" {
+		t.Fatalf("write=%s=%s, want setpoint=25", writes[0].binding.field, writes[0].value)
+	}
+
+	if _, err := image.resolveWrite(0, []byte{0x41, 0xAC, 0x00, 0x00}); err == nil {
+		t.Fatalf("expected read-only register write to fail")
+	}
+	if _, err := image.resolveWrite(1, []byte{0x00, 0x01}); err == nil {
+		t.Fatalf("expected partial multi-register write to fail")
+	}
+	if _, err := image.resolveWrite(2, []byte{0x00, 0x01, 0x00, 0x01}); err == nil {
+		t.Fatalf("expected write spanning unmapped register to fail")
+	}
+}
+
+func TestNewModbusSlaveImage_RejectsOverlap(t *testing.T) {
+	registers := []ModbusSlaveRegister{
+		{DeviceID: 1, Field: "a", Address: 0, DataType: "float32"},
+		{DeviceID: 1, Field: "b", Address: 1, DataType: "uint16"},
+	}
+	for i := range registers {
+		if err := normalizeModbusSlaveRegister(&registers[i]); err != nil {
+			t.Fatalf("normalize: %v", err)
+		}
+	}
+	if _, err := newModbusSlaveImage(registers); err == nil {
+		t.Fatalf("expected overlap error")
+	}
+}
+
+func TestModbusSlaveAdapter_ServeReadWrite(t *testing.T) {
+	cfg, err := parseModbusSlaveConfig(`{"host":"127.0.0.1","port":` + strconv.Itoa(freeTCPTestPort(t)) + `,"writeEnabled":true,"writeTimeoutMs":2000,"registers":` + modbusSlaveTestRegisters + `}`)
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	adapter := NewModbusSlaveAdapter("modbus-slave-test")
+	devices := []*models.Device{{ID: 1, Name: "boiler", ProductKey: "pk", DeviceKey: "dk"}}
+	cache := []*models.DataCache{{DeviceID: 1, FieldName: "temp", Value: "21.5"}}
+	if err := adapter.initializeWithSnapshot(cfg, devices, cache); err != nil {
+		t.Fatalf("initializeWithSnapshot() error = %v", err)
+	}
+	defer adapter.Close()
+	adapter.enabled = true
+
+	conn, err := net.Dial("tcp", adapter.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	read := modbusSlaveRoundTrip(t, conn, []byte{0x03, 0x00, 0x00, 0x00, 0x02})
+	if read[0] != 0x03 || read[1] != 4 || math.Float32frombits(binary.BigEndian.Uint32(read[2:6])) != 21.5 {
+		t.Fatalf("read response=% x", read)
+	}
+
+	if resp := modbusSlaveRoundTrip(t, conn, []byte{0x06, 0x00, 0x01, 0x00, 0x01}); resp[0] != 0x86 || resp[1] != modbusExceptionIllegalDataAddress {
+		t.Fatalf("half float write response=% x, want illegal address", resp)
+	}
+	if resp := modbusSlaveRoundTrip(t, conn, []byte{0x05, 0x00, 0x00, 0xFF, 0x00}); resp[0] != 0x85 || resp[1] != modbusExceptionIllegalFunction {
+		t.Fatalf("coil write response=% x, want illegal function", resp)
+	}
+
+	adapter.SetCommandExecutor(func(_ context.Context, command *models.NorthboundCommand) *models.NorthboundCommandResult {
+		success := command.Source == modbusSlaveCommandSource && command.ProductKey == "pk" && command.DeviceKey == "dk" &&
+			command.FieldName == "setpoint" && command.Value == "25"
+		return &models.NorthboundCommandResult{Success: success}
+	})
+	write := modbusSlaveRoundTrip(t, conn, []byte{0x10, 0x00, 0x02, 0x00, 0x01, 0x02, 0x00, 0xFA})
+	if write[0] != 0x10 || binary.BigEndian.Uint16(write[1:3]) != 2 || binary.BigEndian.Uint16(write[3:5]) != 1 {
+		t.Fatalf("write response=% x", write)
+	}
+
+	readBack := modbusSlaveRoundTrip(t, conn, []byte{0x03, 0x00, 0x02, 0x00, 0x01})
+	if binary.BigEndian.Uint16(readBack[2:4]) != 250 {
+		t.Fatalf("read back=% x, want 250", readBack)
+	}
+
+	stats := adapter.RuntimeStatsSnapshot()
+	if stats.Clients != 1 || stats.ListenAddress == "" {
+		t.Fatalf("stats clients=%d listen=%q", stats.Clients, stats.ListenAddress)
+	}
+}
+
+func TestModbusSlaveAdapter_WriteRegistersPerBinding(t *testing.T) {
+	cfg, err := parseModbusSlaveConfig(`{"writeEnabled":true,"writeTimeoutMs":1000,"registers":[
+		{"device_id":1,"field":"a","address":0,"data_type":"uint16","rw":"RW"},
+		{"device_id":1,"field":"b","address":1,"data_type":"uint16","rw":"RW"},
+		{"device_id":2,"field":"c","address":2,"data_type":"uint16","rw":"RW"}
+	]}`)
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	image, err := newModbusSlaveImage(cfg.Registers)
+	if err != nil {
+		t.Fatalf("newModbusSlaveImage() error = %v", err)
+	}
+	adapter := NewModbusSlaveAdapter("modbus-slave-test")
+	adapter.applyIdentities([]*models.Device{{ID: 1, ProductKey: "pk", DeviceKey: "d1"}, {ID: 2, ProductKey: "pk", DeviceKey: "d2"}})
+
+	// 未设置执行器：写入失败且镜像不变
+	data := []byte{0x00, 0x01, 0x00, 0x02, 0x00, 0x03}
+	if code := adapter.writeRegisters(cfg, image, true, 0, data); code != modbusExceptionServerDeviceFailure {
+		t.Fatalf("write without executor code=%#x", code)
+	}
+
+	var commands []*models.NorthboundCommand
+	adapter.SetCommandExecutor(func(_ context.Context, command *models.NorthboundCommand) *models.NorthboundCommandResult {
+		commands = append(commands, command)
+		if command.DeviceKey == "d2" {
+			return &models.NorthboundCommandResult{Success: true}
+		}
+		// 设备 1 的两个字段中 b 失败
+		return &models.NorthboundCommandResult{Success: false, Partial: true, Fields: []models.NorthboundCommandFieldResult{
+			{FieldName: "a", Success: true},
+			{FieldName: "b", Success: false, Message: "io error"},
+		}}
+	})
+	results := adapter.executeWrites(mustResolveModbusSlaveWrite(t, image, data), time.Second)
+	if len(commands) != 2 || len(commands[0].Fields) != 2 || commands[1].FieldName != "c" {
+		t.Fatalf("commands=%+v, want one multi-field command per device", commands)
+	}
+	for i, want := range []bool{true, false, true} {
+		if results[i].success != want {
+			t.Fatalf("binding %s success=%v, want %v (%s)", results[i].write.binding.field, results[i].success, want, results[i].message)
+		}
+	}
+
+	if code := adapter.writeRegisters(cfg, image, true, 0, data); code != modbusExceptionServerDeviceFailure {
+		t.Fatalf("partial write code=%#x, want server device failure", code)
+	}
+	got, _ := image.read(modbusSlaveTableHolding, 0, 3)
+	if a, b, c := binary.BigEndian.Uint16(got[0:2]), binary.BigEndian.Uint16(got[2:4]), binary.BigEndian.Uint16(got[4:6]); a != 1 || b != 0 || c != 3 {
+		t.Fatalf("image after partial write a=%d b=%d c=%d, want 1 0 3", a, b, c)
+	}
+
+	// 执行超时
+	adapter.SetCommandExecutor(func(ctx context.Context, _ *models.NorthboundCommand) *models.NorthboundCommandResult {
+		<-ctx.Done()
+		return &models.NorthboundCommandResult{Message: ctx.Err().Error()}
+	})
+	cfg.WriteTimeoutMs = 20
+	if code := adapter.writeRegisters(cfg, image, true, 2, []byte{0x00, 0x09}); code != modbusExceptionGatewayTargetFailed {
+		t.Fatalf("timeout code=%#x, want gateway target failed", code)
+	}
+}
+
+func mustResolveModbusSlaveWrite(t *testing.T, image *modbusSlaveImage, data []byte) []modbusSlaveWrite {
+	t.Helper()
+	writes, err := image.resolveWrite(0, data)
+	if err != nil {
+		t.Fatalf("resolveWrite() error = %v", err)
+	}
+	return writes
+}
+
+func newModbusSlaveTestImage(t *testing.T) *modbusSlaveImage {
+	t.Helper()
+	cfg, err := parseModbusSlaveConfig(`{"registers":` + modbusSlaveTestRegisters + `}`)
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	image, err := newModbusSlaveImage(cfg.Registers)
+	if err != nil {
+		t.Fatalf("newModbusSlaveImage() error = %v", err)
+	}
+	return image
+}
+
+func modbusSlaveRoundTrip(t *testing.T, conn net.Conn, pdu []byte) []byte {
+	t.Helper()
+	frame := make([]byte, 7+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:2], 0x1234)
+	binary.BigEndian.PutUint16(frame[4:6], uint16(len(pdu)+1))
+	frame[6] = 1
+	copy(frame[7:], pdu)
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	if binary.BigEndian.Uint16(header[0:2]) != 0x1234 || header[6] != 1 {
+		t.Fatalf("response header=% x", header)
+	}
+	body := make([]byte, int(binary.BigEndian.Uint16(header[4:6]))-1)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return body
+}
+
+func freeTCPTestPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
@@ -51,17 +51,27 @@ type MQTTAdapter struct {
 	keepAlive    time.Duration
 	interval     time.Duration
 	lastSend     time.Time
+	templates    *mqttMessageTemplates
+	commands     *mqttCommandConfig
 
 	// MQTT客户端
 	client mqtt.Client
 
 	// 数据缓冲
-	pendingData []*models.CollectData
-	pendingMu   sync.RWMutex
+	pendingData  []*models.CollectData
+	pendingMu    sync.RWMutex
+	dataInFlight inFlightCounter
 
 	// 报警缓冲
 	pendingAlarms []*models.AlarmPayload
 	alarmMu       sync.RWMutex
+	alarmInFlight inFlightCounter
+
+	// 命令下行
+	commandQueue     []*models.NorthboundCommand
+	inflightCommands map[string]mqttInflightCommand
+	commandSeq       uint64
+	commandMu        sync.RWMutex
 
 	// 控制通道
 	stopChan     chan struct{}
@@ -111,15 +121,34 @@ func (a *MQTTAdapter) Initialize(configStr string) error {
 		return err
 	}
 
+	raw, err := parseAdapterRawConfig(configStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse MQTT config: %w", err)
+	}
+	templates, err := parseMQTTMessageTemplates(raw, a.name)
+	if err != nil {
+		return err
+	}
+	commands, err := parseMQTTCommandConfig(raw)
+	if err != nil {
+		return err
+	}
 	settings := buildMQTTInitSettings(cfg)
 
+	// 连接回调里会订阅命令主题，订阅参数需在连接前就位
+	a.mu.Lock()
+	a.commands = commands
+	a.qos = settings.qos
+	a.timeout = settings.timeout
+	a.mu.Unlock()
+
 	// 连接MQTT
 	client, err := a.connectMQTT(settings, cfg.Username, cfg.Password)
 	if err != nil {
 		return fmt.Errorf("failed to connect MQTT: %w", err)
 	}
 
-	a.applyConfig(cfg, client, settings)
+	a.applyConfig(cfg, client, settings, templates)
 
 	slog.Info("MQTT adapter initialized", "name", a.name, "broker", settings.broker, "topic", settings.topic)
 	return nil
@@ -0,0 +1,155 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+const (
+	mqttCommandSource      = "mqtt.command"
+	mqttCommandInflightTTL = 10 * time.Minute
+)
+
+// mqttCommandRequestKeys 命令请求中可映射的标准字段
+var mqttCommandRequestKeys = []string{"request_id", "product_key", "device_key", "field_name", "value", "params", "response_topic"}
+
+// mqttCommandConfig 命令订阅配置：主题中 {product_key}/{device_key}/{field} 段订阅为 +，收到消息时从对应层级取值
+type mqttCommandConfig struct {
+	topic   string
+	filter  string
+	mapping map[string]string
+}
+
+// mqttCommandRequest 一条命令请求解析后的结果，params 中的多个字段合并为一条批量写命令
+type mqttCommandRequest struct {
+	requestID     string
+	responseTopic string
+	command       *models.NorthboundCommand
+}
+
+// mqttInflightCommand 已入队命令与原始请求的对应关系，用于回复结果
+type mqttInflightCommand struct {
+	requestID     string
+	responseTopic string
+	createdAt     time.Time
+}
+
+// parseMQTTCommandConfig commandTopic 为空时返回 nil，表示不订阅命令
+func parseMQTTCommandConfig(raw adapterRawConfig) (*mqttCommandConfig, error) {
+	topic := raw.pickString("commandTopic", "command_topic")
+	if topic == "" {
+		return nil, nil
+	}
+
+	segments := strings.Split(topic, "/")
+	for i, segment := range segments {
+		switch segment {
+		case mqttTopicProductKey, mqttTopicDeviceKey, mqttTopicField:
+			segments[i] = "+"
+		default:
+			if strings.Contains(segment, "{") {
+				return nil, fmt.Errorf("commandTopic placeholder must occupy a whole level: %s", segment)
+			}
+		}
+	}
+
+	mapping := make(map[string]string, len(mqttCommandRequestKeys))
+	for _, key := range mqttCommandRequestKeys {
+		mapping[key] = "$." + key
+	}
+	custom, err := parseMQTTPayloadMapping(raw.values["commandRequestMapping"], raw.values["command_request_mapping"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid commandRequestMapping: %w", err)
+	}
+	if custom != nil {
+		for key, value := range custom.(map[string]any) {
+			if !slices.Contains(mqttCommandRequestKeys, key) {
+				return nil, fmt.Errorf("invalid commandRequestMapping: unknown key %s", key)
+			}
+			path, ok := value.(string)
+			if !ok || !strings.HasPrefix(path, "$.") {
+				return nil, fmt.Errorf("invalid commandRequestMapping: %s must be a \"$.path\" string", key)
+			}
+			mapping[key] = path
+		}
+	}
+
+	return &mqttCommandConfig{topic: topic, filter: strings.Join(segments, "/"), mapping: mapping}, nil
+}
+
+// parseRequest 解析命令消息；主题占位符取值优先于载荷中的同名字段。
+// 解析失败时仍尽量带回请求 ID，便于回复失败结果。
+func (c *mqttCommandConfig) parseRequest(topic string, payload []byte) (mqttCommandRequest, error) {
+	var body map[string]any
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return mqttCommandRequest{}, fmt.Errorf("invalid command payload: %w", err)
+	}
+
+	fromTopic := c.topicValues(topic)
+	lookup := func(key string) any {
+		return lookupPayloadPath(body, strings.TrimPrefix(c.mapping[key], "$."))
+	}
+	lookupString := func(key string) string {
+		value := lookup(key)
+		if value == nil {
+			return ""
+		}
+		return strings.TrimSpace(stringifyAny(value))
+	}
+
+	request := mqttCommandRequest{
+		requestID:     lookupString("request_id"),
+		responseTopic: lookupString("response_topic"),
+	}
+	pk := pickFirstNonEmpty(fromTopic[mqttTopicProductKey], lookupString("product_key"))
+	dk := pickFirstNonEmpty(fromTopic[mqttTopicDeviceKey], lookupString("device_key"))
+	if pk == "" || dk == "" {
+		return request, fmt.Errorf("product_key and device_key are required")
+	}
+
+	values := make(map[string]any)
+	if params, ok := lookup("params").(map[string]any); ok {
+		maps.Copy(values, params)
+	}
+	if field := pickFirstNonEmpty(fromTopic[mqttTopicField], lookupString("field_name")); field != "" {
+		if value := lookup("value"); value != nil {
+			values[field] = value
+		}
+	}
+
+	request.command = buildBatchWriteCommand(request.requestID, pk, dk, mqttCommandSource, values)
+	if request.command == nil {
+		return request, fmt.Errorf("command has no field value")
+	}
+	return request, nil
+}
+
+// topicValues 按层级从实际主题中取出占位符对应的值
+func (c *mqttCommandConfig) topicValues(topic string) map[string]string {
+	out := make(map[string]string, 3)
+	patternParts := strings.Split(c.topic, "/")
+	topicParts := strings.Split(topic, "/")
+	for i, part := range patternParts {
+		if i >= len(topicParts) {
+			break
+		}
+		switch part {
+		case mqttTopicProductKey, mqttTopicDeviceKey, mqttTopicField:
+			out[part] = topicParts[i]
+		}
+	}
+	return out
+}
+
+// pruneMQTTInflightCommands 丢弃长时间没有回报结果的命令，避免映射无限增长
+func pruneMQTTInflightCommands(inflight map[string]mqttInflightCommand, now time.Time) {
+	maps.DeleteFunc(inflight, func(_ string, cmd mqttInflightCommand) bool {
+		return now.Sub(cmd.createdAt) > mqttCommandInflightTTL
+	})
+}
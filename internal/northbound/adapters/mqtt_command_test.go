@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"testing"
+)
+
+func TestParseMQTTCommandConfig(t *testing.T) {
+	cfg, err := parseMQTTCommandConfig(adapterRawConfig{values: map[string]any{"topic": "up"}})
+	if err != nil || cfg != nil {
+		t.Fatalf("empty commandTopic should disable commands, cfg=%+v err=%v", cfg, err)
+	}
+
+	raw, err := parseAdapterRawConfig(`{"commandTopic":"down/{product_key}/{device_key}/set"}`)
+	if err != nil {
+		t.Fatalf("parseAdapterRawConfig() error = %v", err)
+	}
+	cfg, err = parseMQTTCommandConfig(raw)
+	if err != nil {
+		t.Fatalf("parseMQTTCommandConfig() error = %v", err)
+	}
+	if cfg.filter != "down/+/+/set" {
+		t.Fatalf("filter=%q", cfg.filter)
+	}
+
+	invalid := []string{
+		`{"commandTopic":"down/dev-{device_key}"}`,
+		`{"commandTopic":"down","commandRequestMapping":{"unknown":"$.x"}}`,
+		`{"commandTopic":"down","commandRequestMapping":{"value":"x"}}`,
+		`{"commandTopic":"down","commandRequestMapping":"not-json"}`,
+	}
+	for _, item := range invalid {
+		raw, err := parseAdapterRawConfig(item)
+		if err != nil {
+			t.Fatalf("parseAdapterRawConfig(%s) error = %v", item, err)
+		}
+		if _, err := parseMQTTCommandConfig(raw); err == nil {
+			t.Fatalf("expected error for %s", item)
+		}
+	}
+}
+
+func TestMQTTCommandConfig_ParseRequest(t *testing.T) {
+	raw, _ := parseAdapterRawConfig(`{"commandTopic":"down/{product_key}/{device_key}"}`)
+	cfg, err := parseMQTTCommandConfig(raw)
+	if err != nil {
+		t.Fatalf("parseMQTTCommandConfig() error = %v", err)
+	}
+
+	request, err := cfg.parseRequest("down/pk/dk", []byte(`{"request_id":"r1","product_key":"other","params":{"sp":21.5,"mode":"auto"},"field_name":"on","value":true}`))
+	if err != nil {
+		t.Fatalf("parseRequest() error = %v", err)
+	}
+	cmd := request.command
+	if request.requestID != "r1" || cmd.ProductKey != "pk" || cmd.DeviceKey != "dk" || cmd.Source != mqttCommandSource || len(cmd.Fields) != 3 {
+		t.Fatalf("request=%+v command=%+v", request, cmd)
+	}
+	want := map[string]string{"mode": "auto", "on": "true", "sp": "21.5"}
+	for _, field := range cmd.Fields {
+		if want[field.FieldName] != field.Value {
+			t.Fatalf("unexpected field %+v", field)
+		}
+	}
+	if cmd.FieldName != "mode" || cmd.Value != "auto" {
+		t.Fatalf("first field should be mirrored for single-field consumers: %+v", cmd)
+	}
+
+	request, err = cfg.parseRequest("down/pk/dk", []byte(`{"request_id":"r2"}`))
+	if err == nil || request.requestID != "r2" {
+		t.Fatalf("expected error carrying request id, request=%+v err=%v", request, err)
+	}
+	if _, err := cfg.parseRequest("down/pk/dk", []byte(`not-json`)); err == nil {
+		t.Fatalf("expected error for invalid payload")
+	}
+}
+
+func TestMQTTCommandConfig_ParseRequestWithMapping(t *testing.T) {
+	raw, _ := parseAdapterRawConfig(`{
+		"commandTopic":"cmd/{field}",
+		"commandRequestMapping":"{\"request_id\":\"$.id\",\"product_key\":\"$.target.pk\",\"device_key\":\"$.target.dk\",\"value\":\"$.data.v\",\"response_topic\":\"$.reply\"}"
+	}`)
+	cfg, err := parseMQTTCommandConfig(raw)
+	if err != nil {
+		t.Fatalf("parseMQTTCommandConfig() error = %v", err)
+	}
+
+	request, err := cfg.parseRequest("cmd/setpoint", []byte(`{"id":7,"target":{"pk":"pk","dk":"dk"},"data":{"v":25},"reply":"resp/7"}`))
+	if err != nil {
+		t.Fatalf("parseRequest() error = %v", err)
+	}
+	if request.requestID != "7" || request.responseTopic != "resp/7" {
+		t.Fatalf("request=%+v", request)
+	}
+	if cmd := request.command; cmd.FieldName != "setpoint" || cmd.Value != "25" || cmd.DeviceKey != "dk" || cmd.Fields != nil {
+		t.Fatalf("command=%+v", cmd)
+	}
+}
@@ -3,7 +3,6 @@
 package adapters
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -24,11 +23,26 @@ type mqttInitSettings struct {
 	interval     time.Duration
 }
 
+// parseMQTTConfig 同时兼容模型生成的 snake_case 键与 Schema 表单的 camelCase 键
 func parseMQTTConfig(configStr string) (*MQTTConfig, error) {
-	cfg := &MQTTConfig{}
-	if err := json.Unmarshal([]byte(configStr), cfg); err != nil {
+	raw, err := parseAdapterRawConfig(configStr)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse MQTT config: %w", err)
 	}
+	cfg := &MQTTConfig{
+		Broker:         raw.pickString("broker"),
+		Topic:          raw.pickString("topic"),
+		AlarmTopic:     raw.pickString("alarm_topic", "alarmTopic"),
+		ClientID:       raw.pickString("client_id", "clientId"),
+		Username:       raw.pickString("username"),
+		Password:       raw.pickString("password"),
+		QOS:            raw.pickInt(0, "qos"),
+		Retain:         raw.pickBool(false, "retain"),
+		CleanSession:   raw.pickBool(false, "clean_session", "cleanSession"),
+		KeepAlive:      raw.pickInt(0, "keep_alive", "keepAlive"),
+		ConnectTimeout: raw.pickInt(0, "connect_timeout", "connectTimeout"),
+		UploadInterval: raw.pickInt(0, "upload_interval", "uploadIntervalMs"),
+	}
 	if err := normalizeMQTTConfig(cfg); err != nil {
 		return nil, err
 	}
@@ -85,9 +99,10 @@ func buildMQTTInitSettings(cfg *MQTTConfig) mqttInitSettings {
 	}
 }
 
-func (a *MQTTAdapter) applyConfig(cfg *MQTTConfig, client mqtt.Client, settings mqttInitSettings) {
+func (a *MQTTAdapter) applyConfig(cfg *MQTTConfig, client mqtt.Client, settings mqttInitSettings, templates *mqttMessageTemplates) {
 	a.mu.Lock()
 	a.config = cfg
+	a.templates = templates
 	a.broker = settings.broker
 	a.topic = settings.topic
 	a.alarmTopic = settings.alarmTopic
@@ -0,0 +1,150 @@
+//go:build !no_paho_mqtt
+
+package adapters
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// subscribeCommandTopic 订阅命令主题，每次(重新)连接后调用
+func (a *MQTTAdapter) subscribeCommandTopic(client mqtt.Client) {
+	a.mu.RLock()
+	commands := a.commands
+	qos := a.qos
+	timeout := a.timeout
+	a.mu.RUnlock()
+
+	if commands == nil || client == nil {
+		return
+	}
+
+	token := client.Subscribe(commands.filter, qos, a.handleCommandMessage)
+	if !token.WaitTimeout(timeout) {
+		slog.Warn("MQTT command subscribe timeout", "adapter", a.name, "topic", commands.filter)
+		return
+	}
+	if err := token.Error(); err != nil {
+		slog.Warn("MQTT command subscribe failed", "adapter", a.name, "topic", commands.filter, "error", err)
+	}
+}
+
+func (a *MQTTAdapter) handleCommandMessage(_ mqtt.Client, message mqtt.Message) {
+	a.mu.RLock()
+	commands := a.commands
+	a.mu.RUnlock()
+	if commands == nil {
+		return
+	}
+
+	request, err := commands.parseRequest(message.Topic(), message.Payload())
+	if err != nil {
+		slog.Warn("MQTT command rejected", "adapter", a.name, "topic", message.Topic(), "error", err)
+		if request.requestID != "" {
+			_ = a.publishCommandResult(request.responseTopic, &models.NorthboundCommandResult{
+				RequestID: request.requestID,
+				Source:    mqttCommandSource,
+				Success:   false,
+				Code:      400,
+				Message:   err.Error(),
+			})
+		}
+		return
+	}
+	a.enqueueCommandRequest(request)
+}
+
+// enqueueCommandRequest 命令使用适配器内唯一的 ID 入队，回报结果时再换回原始请求 ID
+func (a *MQTTAdapter) enqueueCommandRequest(request mqttCommandRequest) {
+	if request.requestID == "" {
+		request.requestID = nextPrefixedID("mqtt_req", &a.commandSeq)
+	}
+
+	now := time.Now()
+	a.commandMu.Lock()
+	defer a.commandMu.Unlock()
+
+	if a.inflightCommands == nil {
+		a.inflightCommands = make(map[string]mqttInflightCommand)
+	}
+	pruneMQTTInflightCommands(a.inflightCommands, now)
+	request.command.RequestID = nextPrefixedID("mqtt_cmd", &a.commandSeq)
+	a.inflightCommands[request.command.RequestID] = mqttInflightCommand{
+		requestID:     request.requestID,
+		responseTopic: request.responseTopic,
+		createdAt:     now,
+	}
+	a.commandQueue = appendCommandQueueWithCap(a.commandQueue, []*models.NorthboundCommand{request.command}, defaultRealtimeQueue)
+}
+
+// PullCommands 拉取待执行命令
+func (a *MQTTAdapter) PullCommands(limit int) ([]*models.NorthboundCommand, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	a.mu.RLock()
+	initialized := a.initialized
+	a.mu.RUnlock()
+	if !initialized {
+		return nil, fmt.Errorf("adapter not initialized")
+	}
+
+	a.commandMu.Lock()
+	defer a.commandMu.Unlock()
+
+	if len(a.commandQueue) == 0 {
+		return nil, nil
+	}
+	if limit > len(a.commandQueue) {
+		limit = len(a.commandQueue)
+	}
+
+	out := make([]*models.NorthboundCommand, limit)
+	copy(out, a.commandQueue[:limit])
+	clear(a.commandQueue[:limit])
+	a.commandQueue = a.commandQueue[limit:]
+	return out, nil
+}
+
+// ReportCommandResult 上报命令执行结果，只处理本适配器下发的命令
+func (a *MQTTAdapter) ReportCommandResult(result *models.NorthboundCommandResult) error {
+	if result == nil || result.Source != mqttCommandSource {
+		return nil
+	}
+
+	a.commandMu.Lock()
+	inflight, ok := a.inflightCommands[result.RequestID]
+	delete(a.inflightCommands, result.RequestID)
+	a.commandMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	reply := *result
+	reply.RequestID = inflight.requestID
+	return a.publishCommandResult(inflight.responseTopic, &reply)
+}
+
+// publishCommandResult 请求中带 response_topic 时优先回复到该主题
+func (a *MQTTAdapter) publishCommandResult(responseTopic string, result *models.NorthboundCommandResult) error {
+	message, err := a.messageTemplates().renderCommandResult(result)
+	if err != nil {
+		return fmt.Errorf("render command result: %w", err)
+	}
+	if responseTopic != "" {
+		message.Topic = responseTopic
+	}
+	return a.publish(message.Topic, []byte(message.Payload))
+}
+
+// PendingCommandCount 获取待处理命令数量
+func (a *MQTTAdapter) PendingCommandCount() int {
+	a.commandMu.RLock()
+	defer a.commandMu.RUnlock()
+	return len(a.commandQueue)
+}
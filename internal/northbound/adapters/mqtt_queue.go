@@ -38,6 +38,21 @@ func (a *MQTTAdapter) SendAlarm(alarm *models.AlarmPayload) error {
 	return nil
 }
 
+// PendingQueue 获取待发队列状态（含正在发送的批次）
+func (a *MQTTAdapter) PendingQueue() PendingQueueState {
+	a.pendingMu.RLock()
+	data := len(a.pendingData) + a.dataInFlight.load()
+	dataCap := mqttPendingDataCap
+	a.pendingMu.RUnlock()
+
+	a.alarmMu.RLock()
+	alarm := len(a.pendingAlarms) + a.alarmInFlight.load()
+	alarmCap := mqttPendingAlarmCap
+	a.alarmMu.RUnlock()
+
+	return PendingQueueState{Data: data, DataCap: dataCap, Alarm: alarm, AlarmCap: alarmCap}
+}
+
 // flushPendingData 发送待处理数据
 func (a *MQTTAdapter) flushPendingData() {
 	a.pendingMu.Lock()
@@ -47,10 +62,18 @@ func (a *MQTTAdapter) flushPendingData() {
 	}
 	batch := a.pendingData
 	a.pendingData = nil
+	done := a.dataInFlight.begin(len(batch))
 	a.pendingMu.Unlock()
+	defer done()
 
+	templates := a.messageTemplates()
 	for idx, data := range batch {
-		if err := a.publish(a.topic, data); err != nil {
+		messages, err := templates.renderData(data)
+		if err != nil {
+			slog.Warn("MQTT render data failed, dropped", "adapter", a.name, "device_id", data.DeviceID, "error", err)
+			continue
+		}
+		if err := a.publishMessages(messages); err != nil {
 			slog.Warn("MQTT send data failed", "adapter", a.name, "error", err)
 			remaining := batch[idx:]
 			a.pendingMu.Lock()
@@ -75,10 +98,18 @@ func (a *MQTTAdapter) flushAlarms() error {
 	}
 	batch := a.pendingAlarms
 	a.pendingAlarms = nil
+	done := a.alarmInFlight.begin(len(batch))
 	a.alarmMu.Unlock()
+	defer done()
 
+	templates := a.messageTemplates()
 	for idx, alarm := range batch {
-		if err := a.publish(a.alarmTopic, alarm); err != nil {
+		message, err := templates.renderAlarm(alarm)
+		if err != nil {
+			slog.Warn("MQTT render alarm failed, dropped", "adapter", a.name, "device_id", alarm.DeviceID, "error", err)
+			continue
+		}
+		if err := a.publishMessages([]mqttMessage{message}); err != nil {
 			slog.Warn("MQTT send alarm failed", "adapter", a.name, "error", err)
 			remaining := batch[idx:]
 			a.alarmMu.Lock()
@@ -95,3 +126,22 @@ func (a *MQTTAdapter) flushAlarms() error {
 	clear(batch)
 	return nil
 }
+
+// messageTemplates 当前主题/载荷模板；未配置模板时按固定主题与内置结构发送
+func (a *MQTTAdapter) messageTemplates() *mqttMessageTemplates {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.templates != nil {
+		return a.templates
+	}
+	return &mqttMessageTemplates{gateway: a.name, topic: a.topic, alarmTopic: a.alarmTopic, commandResultTopic: a.topic + "/command/result"}
+}
+
+func (a *MQTTAdapter) publishMessages(messages []mqttMessage) error {
+	for _, message := range messages {
+		if err := a.publish(message.Topic, []byte(message.Payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
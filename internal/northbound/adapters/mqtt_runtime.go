@@ -88,6 +88,8 @@ func (a *MQTTAdapter) Close() error {
 		func() disconnectableClient {
 			client := a.client
 			a.client = nil
+			a.commandQueue = nil
+			a.inflightCommands = nil
 			return client
 		},
 	)
@@ -182,8 +184,3 @@ func (a *MQTTAdapter) GetLastSendTime() time.Time {
 	defer a.mu.RUnlock()
 	return a.lastSend
 }
-
-// PendingCommandCount 获取待处理命令数量
-func (a *MQTTAdapter) PendingCommandCount() int {
-	return 0
-}
@@ -0,0 +1,405 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// 主题模板占位符
+const (
+	mqttTopicProductKey = "{product_key}"
+	mqttTopicDeviceKey  = "{device_key}"
+	mqttTopicDeviceName = "{device_name}"
+	mqttTopicDeviceID   = "{device_id}"
+	mqttTopicField      = "{field}"
+)
+
+// payloadTemplateFuncs 载荷模板可用的函数
+var payloadTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// mqttMessage 渲染后的一条待发布消息
+type mqttMessage struct {
+	Topic   string `json:"topic"`
+	Payload string `json:"payload"`
+}
+
+// mqttPayloadTemplate 载荷模板：text/template 文本或 JSON 映射（"$.path" 取值），都为空时使用内置结构
+type mqttPayloadTemplate struct {
+	text    *template.Template
+	mapping any
+}
+
+// mqttMessageTemplates MQTT 北向的主题与载荷模板
+type mqttMessageTemplates struct {
+	gateway              string
+	topic                string
+	alarmTopic           string
+	commandResultTopic   string
+	payload              mqttPayloadTemplate
+	alarmPayload         mqttPayloadTemplate
+	commandResultPayload mqttPayloadTemplate
+}
+
+func parseMQTTTemplateConfig(configStr, gateway string) (*mqttMessageTemplates, error) {
+	raw, err := parseAdapterRawConfig(configStr)
+	if err != nil {
+		return nil, err
+	}
+	return parseMQTTMessageTemplates(raw, gateway)
+}
+
+func parseMQTTMessageTemplates(raw adapterRawConfig, gateway string) (*mqttMessageTemplates, error) {
+	topic := raw.pickString("topic")
+	if topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+	templates := &mqttMessageTemplates{
+		gateway:            gateway,
+		topic:              topic,
+		alarmTopic:         raw.pickString("alarmTopic", "alarm_topic"),
+		commandResultTopic: raw.pickString("commandResultTopic", "command_result_topic"),
+	}
+	if templates.alarmTopic == "" {
+		templates.alarmTopic = topic + "/alarm"
+	}
+	if templates.commandResultTopic == "" {
+		templates.commandResultTopic = topic + "/command/result"
+	}
+
+	var err error
+	if templates.payload, err = parseMQTTPayloadTemplate(raw, "payload"); err != nil {
+		return nil, err
+	}
+	if templates.alarmPayload, err = parseMQTTPayloadTemplate(raw, "alarmPayload"); err != nil {
+		return nil, err
+	}
+	if templates.commandResultPayload, err = parseMQTTPayloadTemplate(raw, "commandResultPayload"); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// parseMQTTPayloadTemplate 读取 <prefix>Template / <prefix>Mapping，两者只能填一个
+func parseMQTTPayloadTemplate(raw adapterRawConfig, prefix string) (mqttPayloadTemplate, error) {
+	templateKey := prefix + "Template"
+	mappingKey := prefix + "Mapping"
+	text := raw.pickString(templateKey, camelToSnake(templateKey))
+	mapping, err := parseMQTTPayloadMapping(raw.values[mappingKey], raw.values[camelToSnake(mappingKey)])
+	if err != nil {
+		return mqttPayloadTemplate{}, fmt.Errorf("invalid %s: %w", mappingKey, err)
+	}
+	if text != "" && mapping != nil {
+		return mqttPayloadTemplate{}, fmt.Errorf("%s and %s are mutually exclusive", templateKey, mappingKey)
+	}
+
+	out := mqttPayloadTemplate{mapping: mapping}
+	if text != "" {
+		tmpl, err := template.New(prefix).Funcs(payloadTemplateFuncs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return mqttPayloadTemplate{}, fmt.Errorf("invalid %s: %w", templateKey, err)
+		}
+		out.text = tmpl
+	}
+	return out, nil
+}
+
+// parseMQTTPayloadMapping 映射既可以是 JSON 对象，也可以是表单里填写的 JSON 字符串
+func parseMQTTPayloadMapping(values ...any) (any, error) {
+	for _, value := range values {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			trimmed := strings.TrimSpace(v)
+			if trimmed == "" {
+				continue
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+				return nil, err
+			}
+			return decoded, nil
+		case map[string]any:
+			return v, nil
+		default:
+			return nil, fmt.Errorf("must be a JSON object")
+		}
+	}
+	return nil, nil
+}
+
+// renderData 渲染实时数据；主题含 {field} 时按字段拆成多条消息
+func (t *mqttMessageTemplates) renderData(data *models.CollectData) ([]mqttMessage, error) {
+	fields := data.EnsureFields()
+	view := map[string]any{
+		"gateway":      t.gateway,
+		"device_id":    data.DeviceID,
+		"device_name":  data.DeviceName,
+		"product_key":  data.ProductKey,
+		"device_key":   data.DeviceKey,
+		"timestamp":    data.Timestamp.Unix(),
+		"timestamp_ms": data.Timestamp.UnixMilli(),
+		"fields":       fields,
+	}
+	identity := mqttTopicIdentity{deviceID: data.DeviceID, deviceName: data.DeviceName, productKey: data.ProductKey, deviceKey: data.DeviceKey}
+
+	if !strings.Contains(t.topic, mqttTopicField) {
+		payload, err := t.payload.render(view, func() any {
+			return map[string]any{
+				"device_name": data.DeviceName,
+				"device_id":   data.DeviceID,
+				"timestamp":   data.Timestamp.Unix(),
+				"fields":      fields,
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []mqttMessage{{Topic: identity.expand(t.topic, ""), Payload: payload}}, nil
+	}
+
+	messages := make([]mqttMessage, 0, len(fields))
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		fieldView := maps.Clone(view)
+		fieldView["field"] = field
+		fieldView["value"] = fields[field]
+		payload, err := t.payload.render(fieldView, func() any {
+			return map[string]any{
+				"device_name": data.DeviceName,
+				"device_id":   data.DeviceID,
+				"timestamp":   data.Timestamp.Unix(),
+				"field":       field,
+				"value":       fields[field],
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, mqttMessage{Topic: identity.expand(t.topic, field), Payload: payload})
+	}
+	return messages, nil
+}
+
+// renderAlarm 渲染报警
+func (t *mqttMessageTemplates) renderAlarm(alarm *models.AlarmPayload) (mqttMessage, error) {
+	timestamp := alarm.TimestampOrNow()
+	view := map[string]any{
+		"gateway":      t.gateway,
+		"device_id":    alarm.DeviceID,
+		"device_name":  alarm.DeviceName,
+		"product_key":  alarm.ProductKey,
+		"device_key":   alarm.DeviceKey,
+		"field_name":   alarm.FieldName,
+		"actual_value": alarm.ActualValue,
+		"threshold":    alarm.Threshold,
+		"operator":     alarm.Operator,
+		"severity":     alarm.Severity,
+		"message":      alarm.Message,
+		"state":        alarm.State,
+		"timestamp":    timestamp.Unix(),
+		"timestamp_ms": timestamp.UnixMilli(),
+	}
+	payload, err := t.alarmPayload.render(view, func() any {
+		msg := map[string]any{
+			"device_id":    alarm.DeviceID,
+			"device_name":  alarm.DeviceName,
+			"field_name":   alarm.FieldName,
+			"actual_value": alarm.ActualValue,
+			"threshold":    alarm.Threshold,
+			"operator":     alarm.Operator,
+			"severity":     alarm.Severity,
+			"message":      alarm.Message,
+			"timestamp":    timestamp.Unix(),
+		}
+		if alarm.State != "" {
+			msg["state"] = alarm.State
+		}
+		return msg
+	})
+	if err != nil {
+		return mqttMessage{}, err
+	}
+	identity := mqttTopicIdentity{deviceID: alarm.DeviceID, deviceName: alarm.DeviceName, productKey: alarm.ProductKey, deviceKey: alarm.DeviceKey}
+	return mqttMessage{Topic: identity.expand(t.alarmTopic, alarm.FieldName), Payload: payload}, nil
+}
+
+// renderCommandResult 渲染命令执行结果
+func (t *mqttMessageTemplates) renderCommandResult(result *models.NorthboundCommandResult) (mqttMessage, error) {
+	now := time.Now()
+	view := map[string]any{
+		"gateway":      t.gateway,
+		"request_id":   result.RequestID,
+		"product_key":  result.ProductKey,
+		"device_key":   result.DeviceKey,
+		"field_name":   result.FieldName,
+		"value":        result.Value,
+		"success":      result.Success,
+		"partial":      result.Partial,
+		"code":         result.Code,
+		"message":      result.Message,
+		"fields":       commandResultFieldsView(result.Fields),
+		"timestamp":    now.Unix(),
+		"timestamp_ms": now.UnixMilli(),
+	}
+	payload, err := t.commandResultPayload.render(view, func() any {
+		out := map[string]any{
+			"request_id":  result.RequestID,
+			"product_key": result.ProductKey,
+			"device_key":  result.DeviceKey,
+			"field_name":  result.FieldName,
+			"value":       result.Value,
+			"success":     result.Success,
+			"code":        result.Code,
+			"message":     result.Message,
+			"timestamp":   now.Unix(),
+		}
+		if len(result.Fields) > 0 {
+			out["partial"] = result.Partial
+			out["fields"] = view["fields"]
+		}
+		return out
+	})
+	if err != nil {
+		return mqttMessage{}, err
+	}
+	identity := mqttTopicIdentity{productKey: result.ProductKey, deviceKey: result.DeviceKey}
+	return mqttMessage{Topic: identity.expand(t.commandResultTopic, result.FieldName), Payload: payload}, nil
+}
+
+// commandResultFieldsView 批量写逐字段结果，供模板与映射取值
+func commandResultFieldsView(fields []models.NorthboundCommandFieldResult) []any {
+	out := make([]any, 0, len(fields))
+	for _, field := range fields {
+		out = append(out, map[string]any{
+			"field_name": field.FieldName,
+			"value":      field.Value,
+			"success":    field.Success,
+			"message":    field.Message,
+		})
+	}
+	return out
+}
+
+func (p mqttPayloadTemplate) render(view map[string]any, fallback func() any) (string, error) {
+	switch {
+	case p.text != nil:
+		var buf bytes.Buffer
+		if err := p.text.Execute(&buf, view); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case p.mapping != nil:
+		body, err := json.Marshal(resolvePayloadMapping(p.mapping, view))
+		return string(body), err
+	default:
+		body, err := json.Marshal(fallback())
+		return string(body), err
+	}
+}
+
+// resolvePayloadMapping 把映射中 "$.a.b" 形式的字符串替换为消息里的对应值，其余原样保留
+func resolvePayloadMapping(node any, view map[string]any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, child := range v {
+			out[key] = resolvePayloadMapping(child, view)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, child := range v {
+			out[i] = resolvePayloadMapping(child, view)
+		}
+		return out
+	case string:
+		if v == "$" {
+			return view
+		}
+		if path, ok := strings.CutPrefix(v, "$."); ok {
+			return lookupPayloadPath(view, path)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func lookupPayloadPath(view map[string]any, path string) any {
+	var current any = view
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			current = node[part]
+		case map[string]string:
+			value, ok := node[part]
+			if !ok {
+				return nil
+			}
+			current = value
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// mqttTopicIdentity 主题占位符的取值
+type mqttTopicIdentity struct {
+	deviceID   int64
+	deviceName string
+	productKey string
+	deviceKey  string
+}
+
+// expand 替换主题占位符；取值中的 / + # 会改写为 _，避免意外增加层级或变成通配符
+func (id mqttTopicIdentity) expand(topic, field string) string {
+	if !strings.Contains(topic, "{") {
+		return topic
+	}
+	deviceID := ""
+	if id.deviceID > 0 {
+		deviceID = strconv.FormatInt(id.deviceID, 10)
+	}
+	return strings.NewReplacer(
+		mqttTopicProductKey, sanitizeTopicSegment(id.productKey),
+		mqttTopicDeviceKey, sanitizeTopicSegment(id.deviceKey),
+		mqttTopicDeviceName, sanitizeTopicSegment(id.deviceName),
+		mqttTopicDeviceID, deviceID,
+		mqttTopicField, sanitizeTopicSegment(field),
+	).Replace(topic)
+}
+
+var topicSegmentReplacer = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+
+func sanitizeTopicSegment(value string) string {
+	return topicSegmentReplacer.Replace(strings.TrimSpace(value))
+}
+
+func camelToSnake(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package adapters
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+func TestMQTTMessageTemplates_DefaultPayloadKeepsLegacyShape(t *testing.T) {
+	templates, err := parseMQTTTemplateConfig(`{"topic":"gw/data"}`, "gw")
+	if err != nil {
+		t.Fatalf("parseMQTTTemplateConfig() error = %v", err)
+	}
+	ts := time.Unix(1700000000, 0)
+	messages, err := templates.renderData(&models.CollectData{DeviceID: 3, DeviceName: "meter", Timestamp: ts, Fields: map[string]string{"v": "1"}})
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("renderData() messages=%d err=%v", len(messages), err)
+	}
+	want := `{"device_id":3,"device_name":"meter","fields":{"v":"1"},"timestamp":1700000000}`
+	if messages[0].Topic != "gw/data" || messages[0].Payload != want {
+		t.Fatalf("message=%+v, want payload %s", messages[0], want)
+	}
+
+	alarm, err := templates.renderAlarm(&models.AlarmPayload{DeviceID: 3, FieldName: "v", Timestamp: ts})
+	if err != nil || alarm.Topic != "gw/data/alarm" {
+		t.Fatalf("alarm=%+v err=%v", alarm, err)
+	}
+}
+
+func TestMQTTMessageTemplates_PerFieldTopicAndTextTemplate(t *testing.T) {
+	config := `{
+		"topic":"things/{product_key}/{device_name}/{field}",
+		"payloadTemplate":"{\"v\":{{.value}},\"dev\":\"{{.device_key}}\"}"
+	}`
+	templates, err := parseMQTTTemplateConfig(config, "gw")
+	if err != nil {
+		t.Fatalf("parseMQTTTemplateConfig() error = %v", err)
+	}
+	data := &models.CollectData{DeviceName: "line/1", ProductKey: "pk", DeviceKey: "dk", Fields: map[string]string{"temp": "21.5", "hum": "40"}}
+	messages, err := templates.renderData(data)
+	if err != nil {
+		t.Fatalf("renderData() error = %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("messages=%d, want one per field", len(messages))
+	}
+	if messages[0].Topic != "things/pk/line_1/hum" || messages[0].Payload != `{"v":40,"dev":"dk"}` {
+		t.Fatalf("first message=%+v", messages[0])
+	}
+	if messages[1].Topic != "things/pk/line_1/temp" || messages[1].Payload != `{"v":21.5,"dev":"dk"}` {
+		t.Fatalf("second message=%+v", messages[1])
+	}
+}
+
+func TestMQTTMessageTemplates_PayloadMapping(t *testing.T) {
+	config := `{
+		"topic":"up/{device_key}",
+		"alarmPayloadMapping":"{\"id\":\"$.device_key\",\"event\":{\"name\":\"$.field_name\",\"value\":\"$.actual_value\"},\"src\":\"fsu\"}",
+		"command_result_payload_mapping":{"rid":"$.request_id","ok":"$.success"}
+	}`
+	templates, err := parseMQTTTemplateConfig(config, "gw")
+	if err != nil {
+		t.Fatalf("parseMQTTTemplateConfig() error = %v", err)
+	}
+
+	alarm, err := templates.renderAlarm(&models.AlarmPayload{DeviceKey: "dk", FieldName: "temp", ActualValue: 88.5})
+	if err != nil {
+		t.Fatalf("renderAlarm() error = %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(alarm.Payload), &decoded); err != nil {
+		t.Fatalf("alarm payload %s: %v", alarm.Payload, err)
+	}
+	event, _ := decoded["event"].(map[string]any)
+	if alarm.Topic != "up/dk/alarm" || decoded["id"] != "dk" || decoded["src"] != "fsu" || event["name"] != "temp" || event["value"] != 88.5 {
+		t.Fatalf("alarm=%+v", alarm)
+	}
+
+	result, err := templates.renderCommandResult(&models.NorthboundCommandResult{RequestID: "r1", DeviceKey: "dk", Success: true})
+	if err != nil {
+		t.Fatalf("renderCommandResult() error = %v", err)
+	}
+	if result.Topic != "up/dk/command/result" || result.Payload != `{"ok":true,"rid":"r1"}` {
+		t.Fatalf("command result=%+v", result)
+	}
+}
+
+func TestParseMQTTTemplateConfig_Invalid(t *testing.T) {
+	invalid := []string{
+		`{}`,
+		`{"topic":"a","payloadTemplate":"{{.x"}`,
+		`{"topic":"a","payloadMapping":"[1,2]"}`,
+		`{"topic":"a","payloadTemplate":"{{.x}}","payloadMapping":{"a":"$.x"}}`,
+	}
+	for _, raw := range invalid {
+		if _, err := parseMQTTTemplateConfig(raw, "gw"); err == nil {
+			t.Fatalf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestPreviewMessages(t *testing.T) {
+	previews, err := PreviewMessages("MQTT", "gw", `{"topic":"t/{device_key}/{field}"}`)
+	if err != nil {
+		t.Fatalf("PreviewMessages() error = %v", err)
+	}
+	if len(previews) != 4 || previews[0].Kind != "data" || previews[0].Topic != "t/demo_device/humidity" {
+		t.Fatalf("previews=%+v", previews)
+	}
+	if previews[2].Kind != "alarm" || previews[3].Kind != "command_result" {
+		t.Fatalf("previews=%+v", previews)
+	}
+
+	if _, err := PreviewMessages("sagoo", "gw", `{}`); err == nil {
+		t.Fatalf("expected unsupported type error")
+	}
+}
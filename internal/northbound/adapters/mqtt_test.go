@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
@@ -124,6 +125,16 @@ func TestParseMQTTConfig_Defaults(t *testing.T) {
 	}
 }
 
+func TestParseMQTTConfig_AcceptsSchemaKeys(t *testing.T) {
+	cfg, err := parseMQTTConfig(`{"broker":"tcp://127.0.0.1:1883","topic":"t","alarmTopic":"t/a","clientId":"c1","cleanSession":true,"keepAlive":30,"uploadIntervalMs":1000}`)
+	if err != nil {
+		t.Fatalf("parseMQTTConfig() error = %v", err)
+	}
+	if cfg.AlarmTopic != "t/a" || cfg.ClientID != "c1" || !cfg.CleanSession || cfg.KeepAlive != 30 || cfg.UploadInterval != 1000 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+}
+
 func TestParseMQTTConfig_InvalidQOS(t *testing.T) {
 	_, err := parseMQTTConfig(`{"broker":"tcp://127.0.0.1:1883","topic":"test/topic","qos":3}`)
 	if err == nil {
@@ -139,7 +150,7 @@ func TestMQTTApplyConfig_SetsRuntimeFields(t *testing.T) {
 
 	adapter := NewMQTTAdapter("mqtt-test")
 	settings := buildMQTTInitSettings(cfg)
-	adapter.applyConfig(cfg, nil, settings)
+	adapter.applyConfig(cfg, nil, settings, nil)
 
 	if adapter.broker != "tcp://127.0.0.1:1883" {
 		t.Fatalf("broker=%q, want=tcp://127.0.0.1:1883", adapter.broker)
@@ -160,3 +171,83 @@ func TestMQTTApplyConfig_SetsRuntimeFields(t *testing.T) {
 		t.Fatalf("loopState=%s, want=stopped", adapter.loopState.String())
 	}
 }
+
+type fakeMQTTToken struct {
+	mqtt.Token
+}
+
+func (fakeMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (fakeMQTTToken) Error() error                   { return nil }
+
+type fakeMQTTClient struct {
+	mqtt.Client
+	published []mqttMessage
+}
+
+func (c *fakeMQTTClient) IsConnected() bool { return true }
+func (c *fakeMQTTClient) Publish(topic string, _ byte, _ bool, payload any) mqtt.Token {
+	c.published = append(c.published, mqttMessage{Topic: topic, Payload: string(payload.([]byte))})
+	return fakeMQTTToken{}
+}
+
+type fakeMQTTMessage struct {
+	mqtt.Message
+	topic   string
+	payload string
+}
+
+func (m fakeMQTTMessage) Topic() string   { return m.topic }
+func (m fakeMQTTMessage) Payload() []byte { return []byte(m.payload) }
+
+func TestMQTTAdapter_CommandRoundTrip(t *testing.T) {
+	config := `{"broker":"tcp://127.0.0.1:1883","topic":"up","commandTopic":"down/{product_key}/{device_key}"}`
+	raw, _ := parseAdapterRawConfig(config)
+	cfg, _ := parseMQTTConfig(config)
+	templates, _ := parseMQTTMessageTemplates(raw, "gw")
+	commands, err := parseMQTTCommandConfig(raw)
+	if err != nil {
+		t.Fatalf("parseMQTTCommandConfig() error = %v", err)
+	}
+
+	client := &fakeMQTTClient{}
+	a := NewMQTTAdapter("gw")
+	a.applyConfig(cfg, client, buildMQTTInitSettings(cfg), templates)
+	a.commands = commands
+	a.enabled = true
+
+	a.handleCommandMessage(nil, fakeMQTTMessage{topic: "down/pk/dk", payload: `{"request_id":"r1","params":{"a":1,"b":2}}`})
+	if got := a.PendingCommandCount(); got != 1 {
+		t.Fatalf("PendingCommandCount()=%d, want one batch command", got)
+	}
+	pulled, err := a.PullCommands(10)
+	if err != nil || len(pulled) != 1 || len(pulled[0].Fields) != 2 {
+		t.Fatalf("PullCommands() len=%d err=%v", len(pulled), err)
+	}
+
+	// 其他适配器的结果与未知请求不应回复
+	_ = a.ReportCommandResult(&models.NorthboundCommandResult{RequestID: pulled[0].RequestID, Source: "sagoo.property.set"})
+	_ = a.ReportCommandResult(&models.NorthboundCommandResult{RequestID: "r1", Source: mqttCommandSource})
+	if len(client.published) != 0 {
+		t.Fatalf("unexpected replies: %+v", client.published)
+	}
+
+	result := &models.NorthboundCommandResult{
+		RequestID: pulled[0].RequestID, ProductKey: "pk", DeviceKey: "dk", FieldName: "a", Value: "1", Source: mqttCommandSource,
+		Partial: true, Code: 207,
+		Fields: []models.NorthboundCommandFieldResult{{FieldName: "a", Value: "1", Success: true}, {FieldName: "b", Value: "2", Message: "busy"}},
+	}
+	if err := a.ReportCommandResult(result); err != nil {
+		t.Fatalf("ReportCommandResult() error = %v", err)
+	}
+	if err := a.ReportCommandResult(result); err != nil {
+		t.Fatalf("duplicate ReportCommandResult() error = %v", err)
+	}
+	if len(client.published) != 1 || client.published[0].Topic != "up/command/result" || !strings.Contains(client.published[0].Payload, `"request_id":"r1"`) || !strings.Contains(client.published[0].Payload, `"partial":true`) {
+		t.Fatalf("replies=%+v", client.published)
+	}
+
+	a.handleCommandMessage(nil, fakeMQTTMessage{topic: "down/pk/dk", payload: `{"request_id":"r2","response_topic":"resp/r2"}`})
+	if len(client.published) != 2 || client.published[1].Topic != "resp/r2" || !strings.Contains(client.published[1].Payload, `"success":false`) {
+		t.Fatalf("invalid request should be answered with a failure, replies=%+v", client.published)
+	}
+}
@@ -3,13 +3,11 @@
 package adapters
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
-	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
 // connectMQTT 创建并连接MQTT客户端
@@ -43,11 +41,12 @@ func (a *MQTTAdapter) connectMQTT(settings mqttInitSettings, username, password
 		}
 		a.markDisconnected()
 	}
-	opts.OnConnect = func(_ mqtt.Client) {
+	opts.OnConnect = func(client mqtt.Client) {
 		slog.Info("MQTT connected", "adapter", a.name, "broker", settings.broker)
 		a.mu.Lock()
 		a.connected = true
 		a.mu.Unlock()
+		a.subscribeCommandTopic(client)
 	}
 
 	client := mqtt.NewClient(opts)
@@ -105,7 +104,7 @@ func (a *MQTTAdapter) reconnectOnce() error {
 }
 
 // publish 发布消息
-func (a *MQTTAdapter) publish(topic string, payload any) error {
+func (a *MQTTAdapter) publish(topic string, body []byte) error {
 	a.mu.RLock()
 	if !a.initialized || !a.enabled {
 		a.mu.RUnlock()
@@ -121,32 +120,6 @@ func (a *MQTTAdapter) publish(topic string, payload any) error {
 		return fmt.Errorf("MQTT client not connected")
 	}
 
-	var body []byte
-	if data, ok := payload.(*models.CollectData); ok {
-		msg := map[string]any{
-			"device_name": data.DeviceName,
-			"device_id":   data.DeviceID,
-			"timestamp":   data.Timestamp.Unix(),
-			"fields":      data.Fields,
-		}
-		body, _ = json.Marshal(msg)
-	} else if alarm, ok := payload.(*models.AlarmPayload); ok {
-		msg := map[string]any{
-			"device_id":    alarm.DeviceID,
-			"device_name":  alarm.DeviceName,
-			"field_name":   alarm.FieldName,
-			"actual_value": alarm.ActualValue,
-			"threshold":    alarm.Threshold,
-			"operator":     alarm.Operator,
-			"severity":     alarm.Severity,
-			"message":      alarm.Message,
-			"timestamp":    time.Now().Unix(),
-		}
-		body, _ = json.Marshal(msg)
-	} else {
-		return fmt.Errorf("unknown payload type")
-	}
-
 	token := client.Publish(topic, qos, retain, body)
 	if !token.WaitTimeout(timeout) {
 		return fmt.Errorf("mqtt publish timeout")
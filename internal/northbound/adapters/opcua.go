@@ -0,0 +1,422 @@
+//go:build !no_opcua
+
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/nbtype"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+)
+
+const opcuaCommandSource = "opcua.write"
+
+// OPCUAAdapter 内置 OPC UA 服务端：把设备测点暴露为变量节点，可写测点的写值转为设备写命令。
+// 与 MQTT 类北向不同，它不主动上报，Send/ApplyLiveData 只刷新节点值。
+type OPCUAAdapter struct {
+	name     string
+	config   *OPCUAConfig
+	endpoint string
+	interval time.Duration
+	lastSend time.Time
+
+	srv    *server.Server
+	ns     *opcuaNamespace
+	cancel context.CancelFunc
+
+	// 待推送给订阅者的变更节点，由后台循环调用 ChangeNotification，避免阻塞采集
+	changeMu     sync.Mutex
+	changedNodes map[string]*ua.NodeID
+	changeSignal chan struct{}
+
+	commands     *syncCommandRunner
+	devicePoints DevicePointResolver
+
+	stopChan    chan struct{}
+	wg          sync.WaitGroup
+	mu          sync.RWMutex
+	initialized bool
+	enabled     bool
+	connected   bool
+	loopState   adapterLoopState
+}
+
+func NewOPCUAAdapter(name string) *OPCUAAdapter {
+	return &OPCUAAdapter{
+		name:         name,
+		interval:     defaultReportInterval,
+		changedNodes: make(map[string]*ua.NodeID),
+		changeSignal: make(chan struct{}, 1),
+		commands:     newSyncCommandRunner(opcuaCommandSource),
+		stopChan:     make(chan struct{}),
+		loopState:    adapterLoopStopped,
+	}
+}
+
+func (a *OPCUAAdapter) Name() string { return a.name }
+
+func (a *OPCUAAdapter) Type() string { return nbtype.TypeOPCUA }
+
+// Initialize 解析配置、按设备表与实时缓存建立地址空间并开始监听
+func (a *OPCUAAdapter) Initialize(configStr string) error {
+	cfg, err := parseOPCUAConfig(configStr)
+	if err != nil {
+		return err
+	}
+
+	devices, err := database.ListDevices()
+	if err != nil {
+		slog.Warn("OPC UA device list load failed", "name", a.name, "error", err)
+	}
+	latest, err := database.GetAllDevicesLatestData()
+	if err != nil {
+		slog.Warn("OPC UA latest data load failed", "name", a.name, "error", err)
+	}
+	return a.initializeWithSnapshot(cfg, devices, latest)
+}
+
+func (a *OPCUAAdapter) initializeWithSnapshot(cfg *OPCUAConfig, devices []*models.Device, latest []*database.LatestDeviceData) error {
+	serverName := cfg.ServerName
+	if serverName == "" {
+		serverName = a.name
+	}
+	opts := []server.Option{
+		server.ServerName(serverName),
+		server.ManufacturerName("xunjiFsu"),
+		server.ProductName("xunjiFsu gateway"),
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+		server.EndPoint(cfg.Host, cfg.Port),
+	}
+	if cfg.EndpointHost != "" && cfg.EndpointHost != cfg.Host {
+		opts = append(opts, server.EndPoint(cfg.EndpointHost, cfg.Port))
+	}
+	srv := server.New(opts...)
+
+	ns := newOPCUANamespace(srv, cfg.NamespaceURI, cfg.WriteEnabled, a.writePoint)
+	a.mu.RLock()
+	ns.setDevicePointResolver(a.devicePoints)
+	a.mu.RUnlock()
+	if root, err := srv.Namespace(0); err == nil && root.Objects() != nil {
+		root.Objects().AddRef(ns.Objects(), id.Organizes, true)
+	}
+	applyOPCUASnapshot(ns, devices, latest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := srv.Start(ctx); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start OPC UA server: %w", err)
+	}
+
+	a.mu.Lock()
+	a.config = cfg
+	a.endpoint = srv.URLs()[0]
+	a.srv = srv
+	a.ns = ns
+	a.cancel = cancel
+	a.initialized = true
+	a.connected = true
+	a.loopState = adapterLoopStopped
+	a.mu.Unlock()
+
+	slog.Warn("OPC UA adapter initialized without transport security", "name", a.name, "endpoint", a.endpoint, "namespace", cfg.NamespaceURI, "write_enabled", cfg.WriteEnabled)
+	return nil
+}
+
+// applyOPCUASnapshot 启动时按设备表与 data_cache 最新值建立节点；设备表加载失败（nil）时不限制设备范围
+func applyOPCUASnapshot(ns *opcuaNamespace, devices []*models.Device, latest []*database.LatestDeviceData) {
+	if devices != nil {
+		ns.applyDeviceCatalog(devices)
+	}
+	for _, item := range latest {
+		if item == nil || item.DeviceID == models.SystemStatsDeviceID {
+			continue
+		}
+		ns.applyCollectData(&models.CollectData{
+			DeviceID:   item.DeviceID,
+			DeviceName: item.DeviceName,
+			Timestamp:  item.CollectedAt,
+			Fields:     item.Fields,
+		})
+	}
+}
+
+// SyncDevices 按设备表补建新增设备与点表测点，并移除已删除的设备与测点
+func (a *OPCUAAdapter) SyncDevices() error {
+	ns := a.namespace()
+	if ns == nil {
+		return fmt.Errorf("adapter not initialized")
+	}
+	devices, err := database.ListDevices()
+	if err != nil {
+		return fmt.Errorf("list devices: %w", err)
+	}
+	if devices == nil {
+		devices = []*models.Device{}
+	}
+	ns.applyDeviceCatalog(devices)
+	return nil
+}
+
+// SetDevicePointResolver 设置设备预定义测点的来源；已初始化时按新来源重建点表
+func (a *OPCUAAdapter) SetDevicePointResolver(resolver DevicePointResolver) {
+	a.mu.Lock()
+	a.devicePoints = resolver
+	ns := a.ns
+	a.mu.Unlock()
+	if ns == nil {
+		return
+	}
+	ns.setDevicePointResolver(resolver)
+	if err := a.SyncDevices(); err != nil {
+		slog.Warn("OPC UA device sync failed", "name", a.name, "error", err)
+	}
+}
+
+// ApplyDeviceCatalog 按当前设备表同步地址空间
+func (a *OPCUAAdapter) ApplyDeviceCatalog(devices []*models.Device) {
+	ns := a.namespace()
+	if ns == nil {
+		return
+	}
+	if devices == nil {
+		devices = []*models.Device{}
+	}
+	ns.applyDeviceCatalog(devices)
+}
+
+func (a *OPCUAAdapter) lifecycleState() adapterLifecycleState {
+	return adapterLifecycleState{
+		adapterType:    nbtype.TypeOPCUA,
+		logLabel:       "OPC UA",
+		adapterName:    a.name,
+		mu:             &a.mu,
+		wg:             &a.wg,
+		initialized:    &a.initialized,
+		enabled:        &a.enabled,
+		connected:      &a.connected,
+		loopState:      &a.loopState,
+		stopChan:       &a.stopChan,
+		workSignalChan: &a.changeSignal,
+	}
+}
+
+func (a *OPCUAAdapter) Start() {
+	a.lifecycleState().start(a.executeLoop, nil)
+}
+
+func (a *OPCUAAdapter) Stop() {
+	a.lifecycleState().stop()
+}
+
+func (a *OPCUAAdapter) Close() error {
+	a.mu.RLock()
+	srv := a.srv
+	cancel := a.cancel
+	a.mu.RUnlock()
+
+	err := a.lifecycleState().close(nil, nil, nil)
+	if cancel != nil {
+		cancel()
+	}
+	if srv != nil {
+		if closeErr := srv.Close(); closeErr != nil {
+			slog.Warn("OPC UA server close failed", "name", a.name, "error", closeErr)
+		}
+	}
+
+	a.mu.Lock()
+	a.srv = nil
+	a.ns = nil
+	a.cancel = nil
+	a.mu.Unlock()
+	return err
+}
+
+// executeLoop 把节点变更推送给订阅者
+func (a *OPCUAAdapter) executeLoop() {
+	defer func() {
+		a.mu.Lock()
+		transition := updateLoopState(&a.loopState, adapterLoopStopped)
+		a.mu.Unlock()
+		logLoopStateTransition(nbtype.TypeOPCUA, a.name, transition)
+		a.wg.Done()
+	}()
+
+	a.mu.RLock()
+	stopChan := a.stopChan
+	changeSignal := a.changeSignal
+	a.mu.RUnlock()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-changeSignal:
+			a.notifyChangedNodes()
+		}
+	}
+}
+
+func (a *OPCUAAdapter) notifyChangedNodes() {
+	a.mu.RLock()
+	srv := a.srv
+	a.mu.RUnlock()
+
+	a.changeMu.Lock()
+	changed := make([]*ua.NodeID, 0, len(a.changedNodes))
+	for key, nodeID := range a.changedNodes {
+		changed = append(changed, nodeID)
+		delete(a.changedNodes, key)
+	}
+	a.changeMu.Unlock()
+
+	if srv == nil {
+		return
+	}
+	for _, nodeID := range changed {
+		srv.ChangeNotification(nodeID)
+	}
+}
+
+// Send 刷新节点值（系统状态及暂存补发走这里）
+func (a *OPCUAAdapter) Send(data *models.CollectData) error {
+	a.ApplyLiveData(data)
+	return nil
+}
+
+// ApplyLiveData 采集落库后刷新对应设备节点
+func (a *OPCUAAdapter) ApplyLiveData(data *models.CollectData) {
+	ns := a.namespace()
+	if ns == nil || data == nil {
+		return
+	}
+	changed := ns.applyCollectData(data)
+	if len(changed) == 0 {
+		return
+	}
+
+	a.changeMu.Lock()
+	for _, nodeID := range changed {
+		a.changedNodes[nodeID.String()] = nodeID
+	}
+	a.changeMu.Unlock()
+
+	a.mu.Lock()
+	a.lastSend = time.Now()
+	signal := a.changeSignal
+	a.mu.Unlock()
+	if signal != nil {
+		select {
+		case signal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SendAlarm OPC UA 暂不提供报警事件，直接忽略
+func (a *OPCUAAdapter) SendAlarm(alarm *models.AlarmPayload) error {
+	return nil
+}
+
+// writePoint 客户端写值：经采集器设置的执行器同步执行写命令，超时前返回执行结果
+func (a *OPCUAAdapter) writePoint(target opcuaWriteTarget, value string) ua.StatusCode {
+	a.mu.RLock()
+	enabled := a.enabled
+	timeout := a.config.writeTimeout()
+	a.mu.RUnlock()
+	if !enabled {
+		return ua.StatusBadOutOfService
+	}
+
+	result, err := a.commands.run(models.NorthboundCommand{
+		ProductKey: target.ProductKey,
+		DeviceKey:  target.DeviceKey,
+		FieldName:  target.FieldName,
+		Value:      value,
+	}, timeout)
+	if errors.Is(err, errCommandWaitTimeout) {
+		slog.Warn("OPC UA write timed out", "name", a.name, "device_id", target.DeviceID, "field", target.FieldName, "timeout", timeout)
+		return ua.StatusBadTimeout
+	}
+	if err != nil {
+		slog.Warn("OPC UA write failed", "name", a.name, "device_id", target.DeviceID, "field", target.FieldName, "error", err)
+		return ua.StatusBadDeviceFailure
+	}
+	if !result.Success {
+		slog.Warn("OPC UA write failed", "name", a.name, "device_id", target.DeviceID, "field", target.FieldName, "value", value, "error", result.Message)
+		return ua.StatusBadDeviceFailure
+	}
+	return ua.StatusOK
+}
+
+func (a *OPCUAAdapter) SetCommandExecutor(executor CommandExecutor) {
+	a.commands.setExecutor(executor)
+}
+
+func (a *OPCUAAdapter) PendingCommandCount() int {
+	return a.commands.pending()
+}
+
+func (a *OPCUAAdapter) namespace() *opcuaNamespace {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.ns
+}
+
+func (a *OPCUAAdapter) SetInterval(interval time.Duration) {
+	a.mu.Lock()
+	if interval < minUploadInterval {
+		interval = minUploadInterval
+	}
+	a.interval = interval
+	a.mu.Unlock()
+}
+
+func (a *OPCUAAdapter) IsEnabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.enabled
+}
+
+func (a *OPCUAAdapter) IsConnected() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.connected
+}
+
+func (a *OPCUAAdapter) RuntimeStatsSnapshot() RuntimeStatsSnapshot {
+	a.mu.RLock()
+	snapshot := RuntimeStatsSnapshot{
+		Name:          a.name,
+		Type:          nbtype.TypeOPCUA,
+		Enabled:       a.enabled,
+		Initialized:   a.initialized,
+		Connected:     a.connected,
+		LoopState:     a.loopState.String(),
+		IntervalMS:    a.interval.Milliseconds(),
+		ListenAddress: a.endpoint,
+	}
+	a.mu.RUnlock()
+	snapshot.PendingCmd = a.PendingCommandCount()
+	return snapshot
+}
+
+func (a *OPCUAAdapter) GetStats() map[string]any {
+	return a.RuntimeStatsSnapshot().ToMap()
+}
+
+func (a *OPCUAAdapter) GetLastSendTime() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.lastSend
+}
@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOPCUAHost         = "0.0.0.0"
+	defaultOPCUAPort         = 4840
+	defaultOPCUANamespaceURI = "urn:xunjifsu:gateway"
+	defaultOPCUAWriteTimeout = 5 * time.Second
+)
+
+type OPCUAConfig struct {
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	EndpointHost   string `json:"endpointHost"`
+	NamespaceURI   string `json:"namespaceUri"`
+	ServerName     string `json:"serverName"`
+	WriteEnabled   bool   `json:"writeEnabled"`
+	WriteTimeoutMs int    `json:"writeTimeoutMs"`
+	// AllowInsecure 显式确认以安全策略 None + 匿名登录对外提供服务（内置服务端不支持加密通道与用户认证）
+	AllowInsecure bool `json:"allowInsecure"`
+}
+
+func parseOPCUAConfig(configStr string) (*OPCUAConfig, error) {
+	raw, err := parseAdapterRawConfig(configStr)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &OPCUAConfig{
+		Host:           raw.pickString("host", "listenHost", "serverUrl", "server_url"),
+		Port:           raw.pickInt(0, "port"),
+		EndpointHost:   raw.pickString("endpointHost", "endpoint_host"),
+		NamespaceURI:   raw.pickString("namespaceUri", "namespace_uri"),
+		ServerName:     raw.pickString("serverName", "server_name"),
+		WriteEnabled:   raw.pickBool(false, "writeEnabled", "write_enabled"),
+		WriteTimeoutMs: raw.pickInt(int(defaultOPCUAWriteTimeout.Milliseconds()), "writeTimeoutMs", "write_timeout_ms"),
+		AllowInsecure:  raw.pickBool(false, "allowInsecure", "allow_insecure"),
+	}
+
+	if err := normalizeOPCUAConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func normalizeOPCUAConfig(cfg *OPCUAConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("config is nil")
+	}
+	host, port := splitListenHostPort(cfg.Host)
+	cfg.Host = host
+	if cfg.Port <= 0 {
+		cfg.Port = port
+	}
+	applyDefaultString(&cfg.Host, defaultOPCUAHost)
+	applyDefaultPositiveInt(&cfg.Port, defaultOPCUAPort)
+	if cfg.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	cfg.EndpointHost, _ = splitListenHostPort(cfg.EndpointHost)
+	applyDefaultString(&cfg.NamespaceURI, defaultOPCUANamespaceURI)
+	applyDefaultPositiveInt(&cfg.WriteTimeoutMs, int(defaultOPCUAWriteTimeout.Milliseconds()))
+	if !cfg.AllowInsecure {
+		return fmt.Errorf("opcua server only supports SecurityPolicy None with anonymous access; set allowInsecure=true to enable it")
+	}
+	return nil
+}
+
+// splitListenHostPort 兼容 scheme://host:port（如 opc.tcp://）、host:port 与纯 host 写法
+func splitListenHostPort(raw string) (string, int) {
+	raw = strings.TrimSpace(raw)
+	if idx := strings.Index(raw, "://"); idx >= 0 {
+		raw = raw[idx+3:]
+	}
+	raw = strings.TrimSuffix(raw, "/")
+	if host, portText, err := net.SplitHostPort(raw); err == nil {
+		port, _ := strconv.Atoi(portText)
+		return host, port
+	}
+	return raw, 0
+}
+
+func (c *OPCUAConfig) writeTimeout() time.Duration {
+	if c == nil || c.WriteTimeoutMs <= 0 {
+		return defaultOPCUAWriteTimeout
+	}
+	return time.Duration(c.WriteTimeoutMs) * time.Millisecond
+}
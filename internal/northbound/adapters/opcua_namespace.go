@@ -0,0 +1,698 @@
+//go:build !no_opcua
+
+package adapters
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+)
+
+// opcuaValueKind 变量节点的数据类型，节点创建后固定，避免客户端看到类型漂移
+type opcuaValueKind uint8
+
+const (
+	opcuaValueDouble opcuaValueKind = iota
+	opcuaValueBoolean
+	opcuaValueString
+)
+
+func (k opcuaValueKind) dataTypeID() *ua.NodeID {
+	switch k {
+	case opcuaValueBoolean:
+		return ua.NewNumericNodeID(0, id.Boolean)
+	case opcuaValueString:
+		return ua.NewNumericNodeID(0, id.String)
+	default:
+		return ua.NewNumericNodeID(0, id.Double)
+	}
+}
+
+// opcuaWriteTarget 客户端写值对应的设备测点
+type opcuaWriteTarget struct {
+	DeviceID   int64
+	ProductKey string
+	DeviceKey  string
+	FieldName  string
+}
+
+type opcuaWriteFunc func(target opcuaWriteTarget, value string) ua.StatusCode
+
+type opcuaDevice struct {
+	id         int64
+	name       string
+	productKey string
+	deviceKey  string
+	nodeID     *ua.NodeID
+	points     map[string]*opcuaPoint
+}
+
+type opcuaPoint struct {
+	device   *opcuaDevice
+	field    string
+	nodeID   *ua.NodeID
+	rw       string
+	kind     opcuaValueKind
+	value    *ua.Variant
+	status   ua.StatusCode
+	sourceAt time.Time
+}
+
+func (p *opcuaPoint) writable() bool {
+	return strings.Contains(p.rw, "W")
+}
+
+// opcuaNamespace 网关设备地址空间：Objects 下每个设备一个对象，测点为其变量节点。
+// 不复用 server.NodeNameSpace，是为了拦截客户端写值并转成设备写命令。
+type opcuaNamespace struct {
+	srv          *server.Server
+	uri          string
+	id           uint16
+	writeEnabled bool
+	write        opcuaWriteFunc
+
+	mu      sync.RWMutex
+	nodes   map[string]*server.Node
+	refs    map[string][]*ua.ReferenceDescription
+	points  map[string]*opcuaPoint
+	devices map[int64]*opcuaDevice
+	objects *server.Node
+
+	devicePoints DevicePointResolver
+	// catalog 最近一次设备表中的设备；非 nil 时不在其中的设备采集值被忽略，避免已删除设备的节点复活
+	catalog map[int64]struct{}
+}
+
+func newOPCUANamespace(srv *server.Server, uri string, writeEnabled bool, write opcuaWriteFunc) *opcuaNamespace {
+	ns := &opcuaNamespace{
+		srv:          srv,
+		uri:          uri,
+		writeEnabled: writeEnabled,
+		write:        write,
+		nodes:        make(map[string]*server.Node),
+		refs:         make(map[string][]*ua.ReferenceDescription),
+		points:       make(map[string]*opcuaPoint),
+		devices:      make(map[int64]*opcuaDevice),
+	}
+	if srv != nil {
+		srv.AddNamespace(ns)
+	}
+	ns.objects = ns.newObjectNode(ua.NewNumericNodeID(ns.id, id.ObjectsFolder), uri)
+	ns.nodes[ns.objects.ID().String()] = ns.objects
+	return ns
+}
+
+func (ns *opcuaNamespace) Name() string { return ns.uri }
+
+func (ns *opcuaNamespace) ID() uint16 { return ns.id }
+
+func (ns *opcuaNamespace) SetID(id uint16) { ns.id = id }
+
+func (ns *opcuaNamespace) Objects() *server.Node { return ns.objects }
+
+func (ns *opcuaNamespace) Root() *server.Node { return ns.Node(server.RootFolder) }
+
+func (ns *opcuaNamespace) AddNode(n *server.Node) *server.Node {
+	if n == nil {
+		return nil
+	}
+	ns.mu.Lock()
+	ns.nodes[n.ID().String()] = n
+	ns.mu.Unlock()
+	return n
+}
+
+func (ns *opcuaNamespace) Node(nodeID *ua.NodeID) *server.Node {
+	if nodeID == nil {
+		return nil
+	}
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	return ns.nodes[nodeID.String()]
+}
+
+func (ns *opcuaNamespace) Browse(bd *ua.BrowseDescription) *ua.BrowseResult {
+	if bd == nil || bd.NodeID == nil {
+		return &ua.BrowseResult{StatusCode: ua.StatusBadNodeIDInvalid}
+	}
+	key := bd.NodeID.String()
+
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if ns.nodes[key] == nil {
+		return &ua.BrowseResult{StatusCode: ua.StatusBadNodeIDUnknown}
+	}
+	refs := make([]*ua.ReferenceDescription, 0, len(ns.refs[key]))
+	for _, ref := range ns.refs[key] {
+		if !opcuaBrowseDirectionMatches(bd.BrowseDirection, ref.IsForward) {
+			continue
+		}
+		if !opcuaBrowseRefTypeMatches(bd.ReferenceTypeID, bd.IncludeSubtypes, ref.ReferenceTypeID) {
+			continue
+		}
+		if bd.NodeClassMask != 0 && bd.NodeClassMask&uint32(ref.NodeClass) == 0 {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return &ua.BrowseResult{StatusCode: ua.StatusGood, References: refs}
+}
+
+func (ns *opcuaNamespace) Attribute(nodeID *ua.NodeID, attr ua.AttributeID) *ua.DataValue {
+	if nodeID == nil {
+		return opcuaStatusDataValue(ua.StatusBadNodeIDInvalid)
+	}
+	key := nodeID.String()
+
+	ns.mu.RLock()
+	node := ns.nodes[key]
+	var dv *ua.DataValue
+	if point := ns.points[key]; point != nil {
+		switch attr {
+		case ua.AttributeIDValue:
+			dv = point.dataValue()
+		case ua.AttributeIDDataType:
+			dv = server.DataValueFromValue(point.kind.dataTypeID())
+		case ua.AttributeIDAccessLevel, ua.AttributeIDUserAccessLevel:
+			dv = server.DataValueFromValue(ns.accessLevel(point))
+		}
+	}
+	ns.mu.RUnlock()
+
+	if node == nil {
+		return opcuaStatusDataValue(ua.StatusBadNodeIDUnknown)
+	}
+	if dv != nil {
+		return dv
+	}
+	switch attr {
+	case ua.AttributeIDNodeID:
+		return server.DataValueFromValue(nodeID)
+	case ua.AttributeIDEventNotifier:
+		return server.DataValueFromValue(byte(0))
+	case ua.AttributeIDNodeClass:
+		return server.DataValueFromValue(int32(node.NodeClass()))
+	}
+	value, err := node.Attribute(attr)
+	if err != nil {
+		return opcuaStatusDataValue(ua.StatusBadAttributeIDInvalid)
+	}
+	return value.Value
+}
+
+// SetAttribute 仅接受可写测点的 Value 写入，写值经 write 回调下发到设备
+func (ns *opcuaNamespace) SetAttribute(nodeID *ua.NodeID, attr ua.AttributeID, value *ua.DataValue) ua.StatusCode {
+	if nodeID == nil {
+		return ua.StatusBadNodeIDInvalid
+	}
+	key := nodeID.String()
+
+	ns.mu.RLock()
+	_, exists := ns.nodes[key]
+	point := ns.points[key]
+	var target opcuaWriteTarget
+	writable := false
+	if point != nil {
+		writable = ns.writeEnabled && point.writable()
+		target = opcuaWriteTarget{
+			DeviceID:   point.device.id,
+			ProductKey: point.device.productKey,
+			DeviceKey:  point.device.deviceKey,
+			FieldName:  point.field,
+		}
+	}
+	ns.mu.RUnlock()
+
+	switch {
+	case !exists:
+		return ua.StatusBadNodeIDUnknown
+	case point == nil || attr != ua.AttributeIDValue:
+		return ua.StatusBadNotWritable
+	case !writable:
+		return ua.StatusBadUserAccessDenied
+	case target.ProductKey == "" || target.DeviceKey == "":
+		return ua.StatusBadConfigurationError
+	case ns.write == nil:
+		return ua.StatusBadNotWritable
+	}
+
+	if value == nil || value.Value == nil {
+		return ua.StatusBadTypeMismatch
+	}
+	text, ok := opcuaVariantText(value.Value.Value())
+	if !ok {
+		return ua.StatusBadTypeMismatch
+	}
+	return ns.write(target, text)
+}
+
+func (ns *opcuaNamespace) accessLevel(point *opcuaPoint) byte {
+	level := ua.AccessLevelTypeCurrentRead
+	if ns.writeEnabled && point.writable() {
+		level |= ua.AccessLevelTypeCurrentWrite
+	}
+	return byte(level)
+}
+
+// upsertDeviceLocked 创建或更新设备对象节点，调用方需持有写锁
+func (ns *opcuaNamespace) upsertDeviceLocked(deviceID int64, name, productKey, deviceKey string) *opcuaDevice {
+	device := ns.devices[deviceID]
+	if device == nil {
+		if strings.TrimSpace(name) == "" {
+			name = fmt.Sprintf("device_%d", deviceID)
+		}
+		device = &opcuaDevice{
+			id:     deviceID,
+			name:   name,
+			nodeID: ua.NewStringNodeID(ns.id, fmt.Sprintf("device_%d", deviceID)),
+			points: make(map[string]*opcuaPoint),
+		}
+		node := ns.newObjectNode(device.nodeID, name)
+		ns.nodes[device.nodeID.String()] = node
+		ns.addForwardRefLocked(ns.objects.ID(), node, id.Organizes, id.BaseObjectType)
+		ns.devices[deviceID] = device
+	}
+	if productKey = strings.TrimSpace(productKey); productKey != "" {
+		device.productKey = productKey
+	}
+	if deviceKey = strings.TrimSpace(deviceKey); deviceKey != "" {
+		device.deviceKey = deviceKey
+	}
+	return device
+}
+
+// upsertPointLocked 创建或更新测点变量节点，调用方需持有写锁
+func (ns *opcuaNamespace) upsertPointLocked(device *opcuaDevice, field, rw string, kind opcuaValueKind) *opcuaPoint {
+	point := device.points[field]
+	if point == nil {
+		point = &opcuaPoint{
+			device: device,
+			field:  field,
+			nodeID: ua.NewStringNodeID(ns.id, fmt.Sprintf("device_%d.%s", device.id, field)),
+			kind:   kind,
+			status: ua.StatusBadWaitingForInitialData,
+		}
+		key := point.nodeID.String()
+		node := ns.newVariableNode(point.nodeID, field, key)
+		ns.nodes[key] = node
+		ns.points[key] = point
+		ns.addForwardRefLocked(device.nodeID, node, id.HasComponent, id.BaseDataVariableType)
+		device.points[field] = point
+	}
+	if rw = strings.ToUpper(strings.TrimSpace(rw)); rw != "" {
+		point.rw = rw
+	}
+	return point
+}
+
+// applyValueLocked 写入测点新值；旧于当前值的数据（如暂存补发）被忽略
+func (p *opcuaPoint) applyValueLocked(value any, at time.Time) bool {
+	if !p.sourceAt.IsZero() && at.Before(p.sourceAt) {
+		return false
+	}
+	variant, ok := opcuaVariantFor(p.kind, value)
+	if !ok {
+		// 类型无法转换时保留上一次有效值
+		if p.value != nil {
+			p.status = ua.StatusUncertainLastUsableValue
+		} else {
+			p.status = ua.StatusBadTypeMismatch
+		}
+		p.sourceAt = at
+		return true
+	}
+	p.value = variant
+	p.status = ua.StatusOK
+	p.sourceAt = at
+	return true
+}
+
+func (p *opcuaPoint) dataValue() *ua.DataValue {
+	now := time.Now()
+	dv := &ua.DataValue{
+		EncodingMask:    ua.DataValueServerTimestamp | ua.DataValueStatusCode,
+		Status:          p.status,
+		ServerTimestamp: now,
+	}
+	if p.value != nil {
+		dv.EncodingMask |= ua.DataValueValue
+		dv.Value = p.value
+	}
+	if !p.sourceAt.IsZero() {
+		dv.EncodingMask |= ua.DataValueSourceTimestamp
+		dv.SourceTimestamp = p.sourceAt
+	}
+	return dv
+}
+
+func (ns *opcuaNamespace) setDevicePointResolver(resolver DevicePointResolver) {
+	ns.mu.Lock()
+	ns.devicePoints = resolver
+	ns.mu.Unlock()
+}
+
+// applyDevice 按设备配置建立对象节点；有预定义测点（如内置 Modbus 点表）时预先建立全部测点（含只写点）
+func (ns *opcuaNamespace) applyDevice(device *models.Device) {
+	if device == nil {
+		return
+	}
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.applyDeviceLocked(device)
+}
+
+// applyDeviceLocked 返回设备预定义的测点名集合，没有预定义测点时返回 nil
+func (ns *opcuaNamespace) applyDeviceLocked(device *models.Device) map[string]struct{} {
+	var points []models.DevicePoint
+	if ns.devicePoints != nil {
+		points = ns.devicePoints(device)
+	}
+	node := ns.upsertDeviceLocked(device.ID, device.Name, device.ProductKey, device.DeviceKey)
+	if points == nil {
+		return nil
+	}
+	defined := make(map[string]struct{}, len(points))
+	for _, point := range points {
+		name := strings.TrimSpace(point.Name)
+		if name == "" {
+			continue
+		}
+		kind := opcuaValueDouble
+		if strings.EqualFold(point.DataType, "bool") {
+			kind = opcuaValueBoolean
+		}
+		ns.upsertPointLocked(node, name, point.RW, kind)
+		defined[name] = struct{}{}
+	}
+	return defined
+}
+
+// applyDeviceCatalog 按设备表补建节点，并移除已删除的设备以及点表中已删除的测点
+func (ns *opcuaNamespace) applyDeviceCatalog(devices []*models.Device) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	catalog := make(map[int64]struct{}, len(devices))
+	for _, device := range devices {
+		if device == nil {
+			continue
+		}
+		catalog[device.ID] = struct{}{}
+		defined := ns.applyDeviceLocked(device)
+		if defined == nil {
+			continue
+		}
+		node := ns.devices[device.ID]
+		for field, point := range node.points {
+			if _, ok := defined[field]; !ok {
+				ns.removePointLocked(point)
+			}
+		}
+	}
+	for deviceID, device := range ns.devices {
+		if _, ok := catalog[deviceID]; !ok && deviceID != models.SystemStatsDeviceID {
+			ns.removeDeviceLocked(device)
+		}
+	}
+	ns.catalog = catalog
+}
+
+// removeDeviceLocked 移除设备对象及其全部测点节点，调用方需持有写锁
+func (ns *opcuaNamespace) removeDeviceLocked(device *opcuaDevice) {
+	for _, point := range device.points {
+		ns.removePointLocked(point)
+	}
+	key := device.nodeID.String()
+	delete(ns.nodes, key)
+	delete(ns.refs, key)
+	delete(ns.devices, device.id)
+	ns.removeForwardRefLocked(ns.objects.ID(), device.nodeID)
+}
+
+// removePointLocked 移除测点变量节点，调用方需持有写锁
+func (ns *opcuaNamespace) removePointLocked(point *opcuaPoint) {
+	key := point.nodeID.String()
+	delete(ns.nodes, key)
+	delete(ns.points, key)
+	delete(point.device.points, point.field)
+	ns.removeForwardRefLocked(point.device.nodeID, point.nodeID)
+}
+
+// applyCollectData 用一次采集结果刷新节点，返回值发生变化的节点
+func (ns *opcuaNamespace) applyCollectData(data *models.CollectData) []*ua.NodeID {
+	if data == nil || (len(data.Fields) == 0 && len(data.Points) == 0) {
+		return nil
+	}
+	at := data.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	if _, ok := ns.catalog[data.DeviceID]; !ok && ns.catalog != nil && data.DeviceID != models.SystemStatsDeviceID {
+		return nil
+	}
+	device := ns.upsertDeviceLocked(data.DeviceID, data.DeviceName, data.ProductKey, data.DeviceKey)
+	changed := make([]*ua.NodeID, 0, len(data.Fields)+len(data.Points))
+	seen := make(map[string]struct{}, len(data.Points))
+	for _, item := range data.Points {
+		field := strings.TrimSpace(item.FieldName)
+		if field == "" {
+			continue
+		}
+		seen[field] = struct{}{}
+		point := ns.upsertPointLocked(device, field, item.RW, opcuaValueKindOf(item.Value))
+		if point.applyValueLocked(item.Value, at) {
+			changed = append(changed, point.nodeID)
+		}
+	}
+	for field, text := range data.Fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		point := ns.upsertPointLocked(device, field, "", opcuaValueKindOf(text))
+		if point.applyValueLocked(text, at) {
+			changed = append(changed, point.nodeID)
+		}
+	}
+	return changed
+}
+
+func (ns *opcuaNamespace) newObjectNode(nodeID *ua.NodeID, name string) *server.Node {
+	return server.NewNode(
+		nodeID,
+		server.Attributes{
+			ua.AttributeIDNodeClass:   server.DataValueFromValue(uint32(ua.NodeClassObject)),
+			ua.AttributeIDBrowseName:  server.DataValueFromValue(&ua.QualifiedName{NamespaceIndex: ns.id, Name: name}),
+			ua.AttributeIDDisplayName: server.DataValueFromValue(opcuaLocalizedText(name)),
+			ua.AttributeIDDescription: server.DataValueFromValue(opcuaLocalizedText(name)),
+		},
+		nil,
+		nil,
+	)
+}
+
+func (ns *opcuaNamespace) newVariableNode(nodeID *ua.NodeID, name, key string) *server.Node {
+	return server.NewNode(
+		nodeID,
+		server.Attributes{
+			ua.AttributeIDNodeClass:   server.DataValueFromValue(uint32(ua.NodeClassVariable)),
+			ua.AttributeIDBrowseName:  server.DataValueFromValue(&ua.QualifiedName{NamespaceIndex: ns.id, Name: name}),
+			ua.AttributeIDDisplayName: server.DataValueFromValue(opcuaLocalizedText(name)),
+			ua.AttributeIDDescription: server.DataValueFromValue(opcuaLocalizedText(name)),
+			ua.AttributeIDValueRank:   server.DataValueFromValue(int32(-1)),
+		},
+		nil,
+		func() *ua.DataValue { return ns.Attribute(nodeID, ua.AttributeIDValue) },
+	)
+}
+
+func (ns *opcuaNamespace) addForwardRefLocked(parent *ua.NodeID, child *server.Node, refType, typeDef uint32) {
+	key := parent.String()
+	ns.refs[key] = append(ns.refs[key], &ua.ReferenceDescription{
+		ReferenceTypeID: ua.NewNumericNodeID(0, refType),
+		IsForward:       true,
+		NodeID:          ua.NewExpandedNodeID(child.ID(), "", 0),
+		BrowseName:      child.BrowseName(),
+		DisplayName:     child.DisplayName(),
+		NodeClass:       child.NodeClass(),
+		TypeDefinition:  ua.NewNumericExpandedNodeID(0, typeDef),
+	})
+}
+
+func (ns *opcuaNamespace) removeForwardRefLocked(parent, child *ua.NodeID) {
+	key := parent.String()
+	refs := ns.refs[key]
+	for i, ref := range refs {
+		if ref.NodeID != nil && ref.NodeID.NodeID.Equal(child) {
+			ns.refs[key] = append(refs[:i], refs[i+1:]...)
+			return
+		}
+	}
+}
+
+func opcuaBrowseDirectionMatches(direction ua.BrowseDirection, forward bool) bool {
+	switch direction {
+	case ua.BrowseDirectionBoth:
+		return true
+	case ua.BrowseDirectionInverse:
+		return !forward
+	default:
+		return forward
+	}
+}
+
+// opcuaBrowseRefTypeMatches 本命名空间只有 Organizes/HasComponent 两种层级引用
+func opcuaBrowseRefTypeMatches(want *ua.NodeID, includeSubtypes bool, got *ua.NodeID) bool {
+	if want == nil || (want.Namespace() == 0 && want.IntID() == 0) || want.Equal(got) {
+		return true
+	}
+	if !includeSubtypes || want.Namespace() != 0 {
+		return false
+	}
+	switch want.IntID() {
+	case id.References, id.HierarchicalReferences:
+		return true
+	case id.HasChild, id.Aggregates:
+		return got.IntID() == id.HasComponent
+	default:
+		return false
+	}
+}
+
+func opcuaStatusDataValue(status ua.StatusCode) *ua.DataValue {
+	return &ua.DataValue{
+		EncodingMask:    ua.DataValueServerTimestamp | ua.DataValueStatusCode,
+		ServerTimestamp: time.Now(),
+		Status:          status,
+	}
+}
+
+func opcuaLocalizedText(text string) *ua.LocalizedText {
+	lt := &ua.LocalizedText{Text: text}
+	lt.UpdateMask()
+	return lt
+}
+
+// opcuaValueKindOf 按首个采集值推断节点类型：布尔、数值，其余为字符串
+func opcuaValueKindOf(value any) opcuaValueKind {
+	switch v := value.(type) {
+	case bool:
+		return opcuaValueBoolean
+	case float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return opcuaValueDouble
+	case string:
+		text := strings.TrimSpace(v)
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return opcuaValueDouble
+		}
+		if text == "true" || text == "false" {
+			return opcuaValueBoolean
+		}
+		return opcuaValueString
+	default:
+		return opcuaValueString
+	}
+}
+
+// opcuaVariantFor 把采集值转换成节点类型对应的 Variant
+func opcuaVariantFor(kind opcuaValueKind, value any) (*ua.Variant, bool) {
+	switch kind {
+	case opcuaValueBoolean:
+		switch v := value.(type) {
+		case bool:
+			return ua.MustVariant(v), true
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return ua.MustVariant(b), true
+			}
+		}
+		if f, ok := opcuaFloat(value); ok {
+			return ua.MustVariant(f != 0), true
+		}
+		return nil, false
+	case opcuaValueString:
+		return ua.MustVariant(models.CollectPointValueString(value)), true
+	default:
+		if b, ok := value.(bool); ok {
+			if b {
+				return ua.MustVariant(float64(1)), true
+			}
+			return ua.MustVariant(float64(0)), true
+		}
+		f, ok := opcuaFloat(value)
+		if !ok {
+			return nil, false
+		}
+		return ua.MustVariant(f), true
+	}
+}
+
+func opcuaFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// opcuaVariantText 客户端写入值转为驱动 handle 命令使用的文本
+func opcuaVariantText(value any) (string, bool) {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return strings.TrimSpace(v), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
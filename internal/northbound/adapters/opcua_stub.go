@@ -0,0 +1,46 @@
+//go:build no_opcua
+
+package adapters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+type OPCUAAdapter struct {
+	name string
+}
+
+func NewOPCUAAdapter(name string) *OPCUAAdapter {
+	return &OPCUAAdapter{name: name}
+}
+
+func (a *OPCUAAdapter) Name() string { return a.name }
+func (a *OPCUAAdapter) Type() string { return "opcua" }
+func (a *OPCUAAdapter) Initialize(configStr string) error {
+	return fmt.Errorf("opcua adapter is disabled (build tag no_opcua)")
+}
+func (a *OPCUAAdapter) Start()       {}
+func (a *OPCUAAdapter) Stop()        {}
+func (a *OPCUAAdapter) Close() error { return nil }
+func (a *OPCUAAdapter) Send(data *models.CollectData) error {
+	return fmt.Errorf("opcua adapter is disabled (build tag no_opcua)")
+}
+func (a *OPCUAAdapter) SendAlarm(alarm *models.AlarmPayload) error {
+	return fmt.Errorf("opcua adapter is disabled (build tag no_opcua)")
+}
+func (a *OPCUAAdapter) SetInterval(interval time.Duration) {}
+func (a *OPCUAAdapter) IsEnabled() bool                    { return false }
+func (a *OPCUAAdapter) IsConnected() bool                  { return false }
+func (a *OPCUAAdapter) RuntimeStatsSnapshot() RuntimeStatsSnapshot {
+	return RuntimeStatsSnapshot{
+		Name:  a.name,
+		Type:  "opcua",
+		Error: "opcua adapter is disabled (build tag no_opcua)",
+	}
+}
+func (a *OPCUAAdapter) GetStats() map[string]any   { return a.RuntimeStatsSnapshot().ToMap() }
+func (a *OPCUAAdapter) GetLastSendTime() time.Time { return time.Time{} }
+func (a *OPCUAAdapter) PendingCommandCount() int   { return 0 }
@@ -0,0 +1,358 @@
+//go:build !no_opcua
+
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+func TestParseOPCUAConfig_Defaults(t *testing.T) {
+	cfg, err := parseOPCUAConfig(`{"serverUrl":"opc.tcp://127.0.0.1:14840","allowInsecure":true}`)
+	if err != nil {
+		t.Fatalf("parseOPCUAConfig() error = %v", err)
+	}
+	if cfg.Host != "127.0.0.1" || cfg.Port != 14840 {
+		t.Fatalf("host/port=%s:%d, want 127.0.0.1:14840", cfg.Host, cfg.Port)
+	}
+	if cfg.NamespaceURI != defaultOPCUANamespaceURI || cfg.WriteEnabled {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.writeTimeout() != defaultOPCUAWriteTimeout {
+		t.Fatalf("writeTimeout=%v", cfg.writeTimeout())
+	}
+
+	if _, err := parseOPCUAConfig(`{"port":70000,"allowInsecure":true}`); err == nil {
+		t.Fatalf("expected error for out of range port")
+	}
+}
+
+func TestParseOPCUAConfig_RequiresInsecureOptIn(t *testing.T) {
+	if _, err := parseOPCUAConfig(`{"port":4840}`); err == nil {
+		t.Fatalf("expected error without allowInsecure")
+	}
+	cfg, err := parseOPCUAConfig(`{"port":4840,"allow_insecure":true}`)
+	if err != nil || !cfg.AllowInsecure {
+		t.Fatalf("parseOPCUAConfig() cfg=%+v err=%v", cfg, err)
+	}
+}
+
+func TestOPCUANamespace_ApplyCollectDataTypesAndStaleness(t *testing.T) {
+	ns := newOPCUANamespace(nil, defaultOPCUANamespaceURI, false, nil)
+	at := time.Unix(1700000000, 0)
+
+	changed := ns.applyCollectData(&models.CollectData{
+		DeviceID:   7,
+		DeviceName: "pump",
+		Timestamp:  at,
+		Points: []models.CollectPoint{
+			{FieldName: "temp", Value: 21.5, RW: "R"},
+			{FieldName: "running", Value: true, RW: "RW"},
+		},
+		Fields: map[string]string{"temp": "21.5", "mode": "auto", "count": "3"},
+	})
+	if len(changed) != 4 {
+		t.Fatalf("changed=%d, want=4", len(changed))
+	}
+
+	assertOPCUAValue(t, ns, "device_7.temp", 21.5)
+	assertOPCUAValue(t, ns, "device_7.running", true)
+	assertOPCUAValue(t, ns, "device_7.mode", "auto")
+	assertOPCUAValue(t, ns, "device_7.count", float64(3))
+
+	// 旧数据（如暂存补发）不覆盖当前值
+	ns.applyCollectData(&models.CollectData{
+		DeviceID:  7,
+		Timestamp: at.Add(-time.Minute),
+		Fields:    map[string]string{"temp": "99"},
+	})
+	assertOPCUAValue(t, ns, "device_7.temp", 21.5)
+
+	// 类型不匹配时保留上次有效值并标记 Uncertain
+	ns.applyCollectData(&models.CollectData{
+		DeviceID:  7,
+		Timestamp: at.Add(time.Minute),
+		Fields:    map[string]string{"temp": "n/a"},
+	})
+	dv := ns.Attribute(opcuaTestNodeID(ns, "device_7.temp"), ua.AttributeIDValue)
+	if dv.Status != ua.StatusUncertainLastUsableValue {
+		t.Fatalf("status=%v, want=UncertainLastUsableValue", dv.Status)
+	}
+	if dv.Value.Value() != 21.5 {
+		t.Fatalf("value=%v, want=21.5", dv.Value.Value())
+	}
+}
+
+func TestOPCUANamespace_ApplyDevicePreCreatesPointTable(t *testing.T) {
+	ns := newOPCUANamespace(nil, defaultOPCUANamespaceURI, true, nil)
+	ns.setDevicePointResolver(func(*models.Device) []models.DevicePoint {
+		return []models.DevicePoint{{Name: "setpoint", RW: "W", DataType: "uint16"}, {Name: "alarm", RW: "R", DataType: "bool"}}
+	})
+	ns.applyDevice(&models.Device{ID: 3, Name: "meter", ProductKey: "pk", DeviceKey: "dk"})
+
+	dv := ns.Attribute(opcuaTestNodeID(ns, "device_3.setpoint"), ua.AttributeIDValue)
+	if dv.Status != ua.StatusBadWaitingForInitialData {
+		t.Fatalf("status=%v, want=BadWaitingForInitialData", dv.Status)
+	}
+	level := ns.Attribute(opcuaTestNodeID(ns, "device_3.setpoint"), ua.AttributeIDAccessLevel)
+	if level.Value.Value().(byte)&byte(ua.AccessLevelTypeCurrentWrite) == 0 {
+		t.Fatalf("setpoint should be writable, access level=%v", level.Value.Value())
+	}
+	dataType := ns.Attribute(opcuaTestNodeID(ns, "device_3.alarm"), ua.AttributeIDDataType)
+	if got := dataType.Value.Value().(*ua.NodeID); got.IntID() != id.Boolean {
+		t.Fatalf("alarm data type=%v, want=Boolean", got)
+	}
+}
+
+func TestOPCUANamespace_ApplyDeviceCatalogPrunes(t *testing.T) {
+	ns := newOPCUANamespace(nil, defaultOPCUANamespaceURI, false, nil)
+	points := map[int64][]models.DevicePoint{
+		1: {{Name: "a", RW: "R"}, {Name: "b", RW: "R"}},
+	}
+	ns.setDevicePointResolver(func(device *models.Device) []models.DevicePoint { return points[device.ID] })
+
+	ns.applyDeviceCatalog([]*models.Device{{ID: 1, Name: "meter"}, {ID: 2, Name: "pump"}})
+	ns.applyCollectData(&models.CollectData{DeviceID: 2, Timestamp: time.Now(), Fields: map[string]string{"temp": "20"}})
+	ns.applyCollectData(&models.CollectData{DeviceID: models.SystemStatsDeviceID, Timestamp: time.Now(), Fields: map[string]string{"cpu": "5"}})
+	if node := ns.Node(opcuaTestNodeID(ns, "device_1.b")); node == nil {
+		t.Fatalf("point table not created")
+	}
+
+	// 点表删除测点 b，设备 2 被删除
+	points[1] = []models.DevicePoint{{Name: "a", RW: "R"}}
+	ns.applyDeviceCatalog([]*models.Device{{ID: 1, Name: "meter"}})
+	for _, key := range []string{"device_1.b", "device_2", "device_2.temp"} {
+		if node := ns.Node(opcuaTestNodeID(ns, key)); node != nil {
+			t.Fatalf("%s should be pruned", key)
+		}
+	}
+	if node := ns.Node(opcuaTestNodeID(ns, "device_1.a")); node == nil {
+		t.Fatalf("device_1.a should be kept")
+	}
+	if _, ok := ns.devices[models.SystemStatsDeviceID]; !ok {
+		t.Fatalf("system stats device should be kept")
+	}
+	for _, ref := range ns.refs[ns.objects.ID().String()] {
+		if ref.NodeID.NodeID.StringID() == "device_2" {
+			t.Fatalf("objects folder still references deleted device")
+		}
+	}
+
+	// 已删除设备的迟到采集数据不会重建节点
+	ns.applyCollectData(&models.CollectData{DeviceID: 2, Timestamp: time.Now(), Fields: map[string]string{"temp": "21"}})
+	if _, ok := ns.devices[2]; ok {
+		t.Fatalf("late collect data recreated deleted device")
+	}
+}
+
+func TestOPCUANamespace_Browse(t *testing.T) {
+	ns := newOPCUANamespace(nil, defaultOPCUANamespaceURI, false, nil)
+	ns.applyCollectData(&models.CollectData{
+		DeviceID:   1,
+		DeviceName: "pump",
+		Fields:     map[string]string{"temp": "1", "pressure": "2"},
+	})
+
+	devices := ns.Browse(&ua.BrowseDescription{
+		NodeID:          ns.Objects().ID(),
+		BrowseDirection: ua.BrowseDirectionForward,
+		ReferenceTypeID: ua.NewNumericNodeID(0, id.HierarchicalReferences),
+		IncludeSubtypes: true,
+	})
+	if devices.StatusCode != ua.StatusOK || len(devices.References) != 1 {
+		t.Fatalf("objects browse=%v refs=%d, want 1 device", devices.StatusCode, len(devices.References))
+	}
+	if name := devices.References[0].BrowseName.Name; name != "pump" {
+		t.Fatalf("device browse name=%q, want=pump", name)
+	}
+
+	points := ns.Browse(&ua.BrowseDescription{
+		NodeID:          devices.References[0].NodeID.NodeID,
+		BrowseDirection: ua.BrowseDirectionForward,
+		ReferenceTypeID: ua.NewNumericNodeID(0, id.HasComponent),
+	})
+	if len(points.References) != 2 {
+		t.Fatalf("device browse refs=%d, want=2", len(points.References))
+	}
+
+	none := ns.Browse(&ua.BrowseDescription{
+		NodeID:          ns.Objects().ID(),
+		BrowseDirection: ua.BrowseDirectionForward,
+		ReferenceTypeID: ua.NewNumericNodeID(0, id.HasComponent),
+	})
+	if len(none.References) != 0 {
+		t.Fatalf("HasComponent browse from objects refs=%d, want=0", len(none.References))
+	}
+}
+
+func TestOPCUANamespace_SetAttributePermissions(t *testing.T) {
+	var got opcuaWriteTarget
+	var gotValue string
+	write := func(target opcuaWriteTarget, value string) ua.StatusCode {
+		got, gotValue = target, value
+		return ua.StatusOK
+	}
+	data := &models.CollectData{
+		DeviceID:   5,
+		ProductKey: "pk",
+		DeviceKey:  "dk",
+		Points: []models.CollectPoint{
+			{FieldName: "setpoint", Value: 10, RW: "RW"},
+			{FieldName: "temp", Value: 20, RW: "R"},
+		},
+	}
+	newValue := &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(float64(42.5))}
+
+	disabled := newOPCUANamespace(nil, defaultOPCUANamespaceURI, false, write)
+	disabled.applyCollectData(data)
+	if status := disabled.SetAttribute(opcuaTestNodeID(disabled, "device_5.setpoint"), ua.AttributeIDValue, newValue); status != ua.StatusBadUserAccessDenied {
+		t.Fatalf("write disabled status=%v, want=BadUserAccessDenied", status)
+	}
+
+	ns := newOPCUANamespace(nil, defaultOPCUANamespaceURI, true, write)
+	ns.applyCollectData(data)
+	if status := ns.SetAttribute(opcuaTestNodeID(ns, "device_5.temp"), ua.AttributeIDValue, newValue); status != ua.StatusBadUserAccessDenied {
+		t.Fatalf("read-only point status=%v, want=BadUserAccessDenied", status)
+	}
+	if status := ns.SetAttribute(opcuaTestNodeID(ns, "device_5"), ua.AttributeIDValue, newValue); status != ua.StatusBadNotWritable {
+		t.Fatalf("device node status=%v, want=BadNotWritable", status)
+	}
+	if status := ns.SetAttribute(opcuaTestNodeID(ns, "device_5.setpoint"), ua.AttributeIDValue, newValue); status != ua.StatusOK {
+		t.Fatalf("writable point status=%v, want=OK", status)
+	}
+	if got.ProductKey != "pk" || got.DeviceKey != "dk" || got.FieldName != "setpoint" || gotValue != "42.5" {
+		t.Fatalf("write target=%+v value=%q", got, gotValue)
+	}
+}
+
+func TestOPCUAAdapter_WritePointExecutesCommand(t *testing.T) {
+	adapter := NewOPCUAAdapter("opcua-test")
+	adapter.config = &OPCUAConfig{WriteTimeoutMs: 2000}
+	adapter.enabled = true
+	target := opcuaWriteTarget{DeviceID: 1, ProductKey: "pk", DeviceKey: "dk", FieldName: "setpoint"}
+
+	// 未设置执行器时写入失败
+	if status := adapter.writePoint(target, "12"); status != ua.StatusBadDeviceFailure {
+		t.Fatalf("no executor status=%v, want=BadDeviceFailure", status)
+	}
+
+	var got *models.NorthboundCommand
+	adapter.SetCommandExecutor(func(_ context.Context, command *models.NorthboundCommand) *models.NorthboundCommandResult {
+		got = command
+		return &models.NorthboundCommandResult{Success: false, Message: "io error"}
+	})
+	if status := adapter.writePoint(target, "12"); status != ua.StatusBadDeviceFailure {
+		t.Fatalf("status=%v, want=BadDeviceFailure", status)
+	}
+	if got == nil || got.Source != opcuaCommandSource || got.ProductKey != "pk" || got.FieldName != "setpoint" || got.Value != "12" {
+		t.Fatalf("unexpected command: %+v", got)
+	}
+	if pending := adapter.PendingCommandCount(); pending != 0 {
+		t.Fatalf("pending=%d, want=0 after write", pending)
+	}
+}
+
+func TestOPCUAAdapter_WritePointTimeoutAndDisabled(t *testing.T) {
+	adapter := NewOPCUAAdapter("opcua-test")
+	adapter.config = &OPCUAConfig{WriteTimeoutMs: 20}
+	adapter.SetCommandExecutor(func(ctx context.Context, _ *models.NorthboundCommand) *models.NorthboundCommandResult {
+		<-ctx.Done()
+		return &models.NorthboundCommandResult{Message: ctx.Err().Error()}
+	})
+	target := opcuaWriteTarget{DeviceID: 1, ProductKey: "pk", DeviceKey: "dk", FieldName: "setpoint"}
+
+	if status := adapter.writePoint(target, "1"); status != ua.StatusBadOutOfService {
+		t.Fatalf("disabled status=%v, want=BadOutOfService", status)
+	}
+
+	adapter.enabled = true
+	if status := adapter.writePoint(target, "1"); status != ua.StatusBadTimeout {
+		t.Fatalf("status=%v, want=BadTimeout", status)
+	}
+}
+
+func TestOPCUAAdapter_ClientReadWrite(t *testing.T) {
+	port := freeTCPTestPort(t)
+	adapter := NewOPCUAAdapter("opcua-e2e")
+	cfg := &OPCUAConfig{
+		Host:           "127.0.0.1",
+		Port:           port,
+		NamespaceURI:   defaultOPCUANamespaceURI,
+		WriteEnabled:   true,
+		WriteTimeoutMs: 3000,
+		AllowInsecure:  true,
+	}
+	devices := []*models.Device{{ID: 9, Name: "boiler", ProductKey: "pk", DeviceKey: "dk"}}
+	latest := []*database.LatestDeviceData{{DeviceID: 9, DeviceName: "boiler", Fields: map[string]string{"temp": "55.5"}, CollectedAt: time.Now()}}
+	if err := adapter.initializeWithSnapshot(cfg, devices, latest); err != nil {
+		t.Fatalf("initializeWithSnapshot() error = %v", err)
+	}
+	defer adapter.Close()
+	adapter.Start()
+	adapter.ApplyLiveData(&models.CollectData{
+		DeviceID:  9,
+		Timestamp: time.Now().Add(time.Second),
+		Points:    []models.CollectPoint{{FieldName: "setpoint", Value: 10.0, RW: "RW"}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := opcua.NewClient(adapter.endpoint, opcua.SecurityMode(ua.MessageSecurityModeNone), opcua.AuthAnonymous())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close(context.Background())
+
+	nsIndex := adapter.namespace().ID()
+	readResp, err := client.Read(ctx, &ua.ReadRequest{
+		NodesToRead: []*ua.ReadValueID{{NodeID: ua.NewStringNodeID(nsIndex, "device_9.temp"), AttributeID: ua.AttributeIDValue}},
+	})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got := readResp.Results[0]; got.Status != ua.StatusOK || got.Value.Value() != 55.5 {
+		t.Fatalf("read status=%v value=%v, want OK 55.5", got.Status, got.Value.Value())
+	}
+
+	adapter.SetCommandExecutor(func(_ context.Context, command *models.NorthboundCommand) *models.NorthboundCommandResult {
+		return &models.NorthboundCommandResult{Success: command.FieldName == "setpoint" && command.Value == "12"}
+	})
+	writeResp, err := client.Write(ctx, &ua.WriteRequest{
+		NodesToWrite: []*ua.WriteValue{{
+			NodeID:      ua.NewStringNodeID(nsIndex, "device_9.setpoint"),
+			AttributeID: ua.AttributeIDValue,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: ua.MustVariant(float64(12))},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if writeResp.Results[0] != ua.StatusOK {
+		t.Fatalf("write status=%v, want=OK", writeResp.Results[0])
+	}
+}
+
+func assertOPCUAValue(t *testing.T, ns *opcuaNamespace, key string, want any) {
+	t.Helper()
+	dv := ns.Attribute(opcuaTestNodeID(ns, key), ua.AttributeIDValue)
+	if dv.Status != ua.StatusOK {
+		t.Fatalf("%s status=%v, want=OK", key, dv.Status)
+	}
+	if dv.Value == nil || dv.Value.Value() != want {
+		t.Fatalf("%s value=%v, want=%v", key, dv.Value, want)
+	}
+}
+
+func opcuaTestNodeID(ns *opcuaNamespace, key string) *ua.NodeID {
+	return ua.NewStringNodeID(ns.ID(), key)
+}
@@ -95,11 +95,14 @@ type PandaXAdapter struct {
 	rpcRequestTopic        string
 	rpcResponseTopic       string
 
-	realtimeQueue []*models.CollectData
-	dataMu        sync.RWMutex
-
-	alarmQueue []*models.AlarmPayload
-	alarmMu    sync.RWMutex
+	realtimeQueue  []*models.CollectData
+	dataMu         sync.RWMutex
+	dataInFlight   inFlightCounter
+	uploadDeadband deadbandUploadFilter
+
+	alarmQueue    []*models.AlarmPayload
+	alarmMu       sync.RWMutex
+	alarmInFlight inFlightCounter
 
 	commandQueue []*models.NorthboundCommand
 	commandMu    sync.RWMutex
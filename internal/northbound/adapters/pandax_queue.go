@@ -23,6 +23,7 @@ type pandaXAlarmPublishPayload struct {
 	Operator    string  `json:"operator"`
 	Severity    string  `json:"severity"`
 	Message     string  `json:"message"`
+	State       string  `json:"state,omitempty"`
 	TS          int64   `json:"ts"`
 }
 
@@ -72,6 +73,21 @@ func (a *PandaXAdapter) SendAlarm(alarm *models.AlarmPayload) error {
 	return nil
 }
 
+// PendingQueue 获取待发队列状态（含正在发送的批次）
+func (a *PandaXAdapter) PendingQueue() PendingQueueState {
+	a.dataMu.RLock()
+	data := len(a.realtimeQueue) + a.dataInFlight.load()
+	dataCap := queueCapOrDefault(a.realtimeCap, defaultRealtimeQueue)
+	a.dataMu.RUnlock()
+
+	a.alarmMu.RLock()
+	alarm := len(a.alarmQueue) + a.alarmInFlight.load()
+	alarmCap := queueCapOrDefault(a.alarmCap, defaultAlarmQueue)
+	a.alarmMu.RUnlock()
+
+	return PendingQueueState{Data: data, DataCap: dataCap, Alarm: alarm, AlarmCap: alarmCap}
+}
+
 func (a *PandaXAdapter) flushRealtime() error {
 	a.dataMu.Lock()
 	if len(a.realtimeQueue) == 0 {
@@ -80,7 +96,9 @@ func (a *PandaXAdapter) flushRealtime() error {
 	}
 	batch := a.realtimeQueue
 	a.realtimeQueue = nil
+	done := a.dataInFlight.begin(len(batch))
 	a.dataMu.Unlock()
+	defer done()
 
 	slog.Info("PandaX realtime flush start", "adapter", a.name, "count", len(batch))
 
@@ -145,7 +163,9 @@ func (a *PandaXAdapter) flushAlarmBatch() error {
 	batch := a.alarmQueue[:count]
 	a.alarmQueue = a.alarmQueue[count:]
 	a.alarmQueue = a.alarmQueue[:len(a.alarmQueue):len(a.alarmQueue)]
+	done := a.alarmInFlight.begin(len(batch))
 	a.alarmMu.Unlock()
+	defer done()
 
 	slog.Info("PandaX alarm flush start", "adapter", a.name, "count", len(batch))
 
@@ -191,7 +211,8 @@ func (a *PandaXAdapter) buildAlarmPublish(alarm *models.AlarmPayload) (string, [
 		Operator:    alarm.Operator,
 		Severity:    alarm.Severity,
 		Message:     alarm.Message,
-		TS:          time.Now().UnixMilli(),
+		State:       alarm.State,
+		TS:          alarm.TimestampOrNow().UnixMilli(),
 	}
 	body, _ := json.Marshal(payload)
 	return topic, body
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"slices"
 	"strings"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -94,34 +93,11 @@ func (a *PandaXAdapter) buildRPCCommands(requestID, method string, params any) [
 func buildPandaXRPCCommands(requestID, method string, params any, defaultPK, defaultDK string) []*models.NorthboundCommand {
 	out := make([]*models.NorthboundCommand, 0)
 	appendProperties := func(pk, dk string, props map[string]any) {
-		if len(props) == 0 {
+		if strings.TrimSpace(pk) == "" || strings.TrimSpace(dk) == "" {
 			return
 		}
-		keys := make([]string, 0, len(props))
-		for key := range props {
-			trimmed := strings.TrimSpace(key)
-			if trimmed == "" {
-				continue
-			}
-			keys = append(keys, trimmed)
-		}
-		if len(keys) == 0 {
-			return
-		}
-		slices.Sort(keys)
-
-		for _, key := range keys {
-			item := &models.NorthboundCommand{
-				RequestID:  requestID,
-				ProductKey: pk,
-				DeviceKey:  dk,
-				FieldName:  key,
-				Value:      stringifyAny(props[key]),
-			}
-			if strings.TrimSpace(item.ProductKey) == "" || strings.TrimSpace(item.DeviceKey) == "" {
-				continue
-			}
-			out = append(out, item)
+		if command := buildBatchWriteCommand(requestID, pk, dk, "pandax.rpc.request", props); command != nil {
+			out = append(out, command)
 		}
 	}
 
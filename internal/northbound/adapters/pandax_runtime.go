@@ -20,6 +20,9 @@ type pandaXCommandResultParams struct {
 	DeviceKey  string             `json:"deviceKey"`
 	FieldName  string             `json:"fieldName"`
 	Value      jsonConvertedValue `json:"value"`
+	// 批量写时给出逐字段结果
+	Partial bool                                  `json:"partial,omitempty"`
+	Fields  []models.NorthboundCommandFieldResult `json:"fields,omitempty"`
 }
 
 type pandaXCommandResultPayload struct {
@@ -202,6 +205,8 @@ func (a *PandaXAdapter) ReportCommandResult(result *models.NorthboundCommandResu
 			DeviceKey:  result.DeviceKey,
 			FieldName:  result.FieldName,
 			Value:      jsonConvertedValue(result.Value),
+			Partial:    result.Partial,
+			Fields:     result.Fields,
 		},
 	}
 	body, _ := json.Marshal(resp)
@@ -379,6 +384,35 @@ func (a *PandaXAdapter) fetchAndPublishLatestData() error {
 
 	slog.Info("PandaX latest data loaded", "adapter", a.name, "count", len(devices))
 
+	successCount, systemStatsCount := a.enqueueLatestData(devices, loadDeviceDeadbands())
+
+	if systemStatsCount == 0 {
+		if sysData := a.fetchCurrentSystemStats(); sysData != nil {
+			a.dataMu.Lock()
+			a.enqueueRealtimeLocked(sysData)
+			queueLen := len(a.realtimeQueue)
+			a.dataMu.Unlock()
+			slog.Info("PandaX current system stats enqueued",
+				"adapter", a.name,
+				"fields", len(sysData.Fields),
+				"queue_len", queueLen)
+		}
+	}
+
+	if err := a.flushRealtime(); err != nil {
+		slog.Info("PandaX latest data flush failed", "adapter", a.name, "error", err)
+	}
+
+	slog.Info("PandaX latest data publish completed",
+		"adapter", a.name,
+		"devices", successCount,
+		"system_stats", systemStatsCount,
+		"deadband_suppressed", a.uploadDeadband.suppressedCount())
+	return nil
+}
+
+// enqueueLatestData 把各设备最新值按死区规则筛选后放入实时队列，返回入队的设备数与系统测点数
+func (a *PandaXAdapter) enqueueLatestData(devices []*database.LatestDeviceData, deadbands map[int64]string) (int, int) {
 	successCount := 0
 	systemStatsCount := 0
 
@@ -388,11 +422,14 @@ func (a *PandaXAdapter) fetchAndPublishLatestData() error {
 		}
 
 		isSystemStats := dev.DeviceID == models.SystemStatsDeviceID
-		data := &models.CollectData{
+		data := a.uploadDeadband.filter(&models.CollectData{
 			DeviceID:   dev.DeviceID,
 			DeviceName: dev.DeviceName,
 			Timestamp:  dev.CollectedAt,
 			Fields:     dev.Fields,
+		}, deadbands[dev.DeviceID])
+		if data == nil {
+			continue
 		}
 
 		a.dataMu.Lock()
@@ -404,40 +441,34 @@ func (a *PandaXAdapter) fetchAndPublishLatestData() error {
 				"adapter", a.name,
 				"device_id", dev.DeviceID,
 				"device_name", dev.DeviceName,
-				"fields", len(dev.Fields))
+				"fields", len(data.Fields))
 			systemStatsCount++
 		} else {
 			slog.Info("PandaX device data enqueued",
 				"adapter", a.name,
 				"device_id", dev.DeviceID,
 				"device_name", dev.DeviceName,
-				"fields", len(dev.Fields))
+				"fields", len(data.Fields))
 			successCount++
 		}
 	}
+	return successCount, systemStatsCount
+}
 
-	if systemStatsCount == 0 {
-		if sysData := a.fetchCurrentSystemStats(); sysData != nil {
-			a.dataMu.Lock()
-			a.enqueueRealtimeLocked(sysData)
-			queueLen := len(a.realtimeQueue)
-			a.dataMu.Unlock()
-			slog.Info("PandaX current system stats enqueued",
-				"adapter", a.name,
-				"fields", len(sysData.Fields),
-				"queue_len", queueLen)
-		}
+// loadDeviceDeadbands 读取各设备的死区配置，读取失败时不做死区筛选
+func loadDeviceDeadbands() map[int64]string {
+	devices, err := database.ListDevices()
+	if err != nil {
+		slog.Warn("Failed to load device deadbands for upload", "error", err)
+		return nil
 	}
-
-	if err := a.flushRealtime(); err != nil {
-		slog.Info("PandaX latest data flush failed", "adapter", a.name, "error", err)
+	deadbands := make(map[int64]string, len(devices))
+	for _, device := range devices {
+		if device != nil && device.Deadbands != "" {
+			deadbands[device.ID] = device.Deadbands
+		}
 	}
-
-	slog.Info("PandaX latest data publish completed",
-		"adapter", a.name,
-		"devices", successCount,
-		"system_stats", systemStatsCount)
-	return nil
+	return deadbands
 }
 
 func (a *PandaXAdapter) fetchCurrentSystemStats() *models.CollectData {
@@ -440,6 +440,47 @@ func TestPandaXBuildBatchRealtimePublish(t *testing.T) {
 	}
 }
 
+func TestPandaXEnqueueLatestData_AppliesDeviceDeadbands(t *testing.T) {
+	adapter := NewPandaXAdapter("pandax-test")
+	deadbands := map[int64]string{1: `[{"field_name":"temperature","deadband":0.5}]`}
+	start := time.Unix(1700000000, 0)
+	latest := func(temperature, running string, at time.Time) []*database.LatestDeviceData {
+		return []*database.LatestDeviceData{{DeviceID: 1, DeviceName: "pump-1", CollectedAt: at,
+			Fields: map[string]string{"temperature": temperature, "running": running}}}
+	}
+	drain := func() []*models.CollectData {
+		adapter.dataMu.Lock()
+		defer adapter.dataMu.Unlock()
+		queued := adapter.realtimeQueue
+		adapter.realtimeQueue = nil
+		return queued
+	}
+
+	adapter.enqueueLatestData(latest("23.5", "true", start), deadbands)
+	if queued := drain(); len(queued) != 1 || len(queued[0].Fields) != 2 {
+		t.Fatalf("first upload = %+v, want both fields", queued)
+	}
+
+	// 温度变化未超出死区：只上报没有死区规则的字段
+	adapter.enqueueLatestData(latest("23.8", "true", start.Add(time.Minute)), deadbands)
+	queued := drain()
+	if len(queued) != 1 || queued[0].Fields["running"] != "true" {
+		t.Fatalf("second upload = %+v, want running only", queued)
+	}
+	if _, ok := queued[0].Fields["temperature"]; ok {
+		t.Fatalf("suppressed temperature was uploaded: %+v", queued[0].Fields)
+	}
+
+	// 相对上次上报值 23.5 超出死区后恢复上报
+	adapter.enqueueLatestData(latest("24.1", "true", start.Add(2*time.Minute)), deadbands)
+	if queued := drain(); len(queued) != 1 || queued[0].Fields["temperature"] != "24.1" {
+		t.Fatalf("third upload = %+v, want temperature 24.1", queued)
+	}
+	if got := adapter.uploadDeadband.suppressedCount(); got != 1 {
+		t.Fatalf("suppressed = %d, want 1", got)
+	}
+}
+
 func TestBuildPandaXRPCCommands_UsesDefaultIdentity(t *testing.T) {
 	commands := buildPandaXRPCCommands("req-1", "set", map[string]any{
 		"properties": map[string]any{
@@ -458,6 +499,26 @@ func TestBuildPandaXRPCCommands_UsesDefaultIdentity(t *testing.T) {
 	}
 }
 
+func TestBuildPandaXRPCCommands_BatchesPropertiesPerDevice(t *testing.T) {
+	commands := buildPandaXRPCCommands("req-2", "set", map[string]any{
+		"properties": map[string]any{"sp": 21, "mode": "auto"},
+		"subDevices": []any{
+			map[string]any{"identity": map[string]any{"productKey": "pk2", "deviceKey": "dk2"}, "properties": map[string]any{"run": true}},
+		},
+	}, "pk1", "dk1")
+
+	if len(commands) != 2 {
+		t.Fatalf("len(commands)=%d, want=2 (one per device)", len(commands))
+	}
+	batch := commands[0]
+	if batch.DeviceKey != "dk1" || len(batch.Fields) != 2 || batch.Fields[0].FieldName != "mode" || batch.Fields[1].Value != "21" {
+		t.Fatalf("batched command mismatch: %+v", batch)
+	}
+	if single := commands[1]; single.DeviceKey != "dk2" || single.FieldName != "run" || len(single.Fields) != 0 {
+		t.Fatalf("sub device command mismatch: %+v", single)
+	}
+}
+
 func TestIsPandaXReservedRPCKey(t *testing.T) {
 	reserved := []string{"productKey", "device_key", "subDevices", "field_name", "value"}
 	for _, key := range reserved {
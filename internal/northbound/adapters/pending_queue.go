@@ -0,0 +1,57 @@
+package adapters
+
+import "sync/atomic"
+
+// PendingQueueState 内存待发队列状态；Data/Alarm 包含已出队、正在发送的条数
+type PendingQueueState struct {
+	Data     int
+	DataCap  int
+	Alarm    int
+	AlarmCap int
+}
+
+// Drained 队列（含正在发送的批次）已全部发送完成
+func (s PendingQueueState) Drained() bool {
+	return s.Data == 0 && s.Alarm == 0
+}
+
+// DataSpace 数据队列剩余容量
+func (s PendingQueueState) DataSpace() int {
+	return max(s.DataCap-s.Data, 0)
+}
+
+// AlarmSpace 报警队列剩余容量
+func (s PendingQueueState) AlarmSpace() int {
+	return max(s.AlarmCap-s.Alarm, 0)
+}
+
+// NorthboundAdapterWithPendingQueue Send/SendAlarm 只进入有上限内存队列的适配器接口；
+// 北向暂存据此按剩余容量补发，并在队列排空后才删除已补发的暂存
+type NorthboundAdapterWithPendingQueue interface {
+	NorthboundAdapter
+	// PendingQueue 获取待发队列状态
+	PendingQueue() PendingQueueState
+}
+
+// inFlightCounter 已出队但尚未发送完成的条数
+// begin 需在持有队列锁时调用，读者在同一把锁下读取“队列长度 + 在途数”才不会漏计；
+// 发送失败的批次先放回队列再递减，只会短暂多计
+type inFlightCounter struct {
+	n atomic.Int64
+}
+
+func (c *inFlightCounter) begin(n int) func() {
+	c.n.Add(int64(n))
+	return func() { c.n.Add(-int64(n)) }
+}
+
+func (c *inFlightCounter) load() int {
+	return int(c.n.Load())
+}
+
+func queueCapOrDefault(capLimit, fallback int) int {
+	if capLimit <= 0 {
+		return fallback
+	}
+	return capLimit
+}
@@ -59,12 +59,14 @@ type SagooAdapter struct {
 	commandCap  int
 
 	// 数据缓冲
-	latestData []*models.CollectData
-	dataMu     sync.RWMutex
+	latestData   []*models.CollectData
+	dataMu       sync.RWMutex
+	dataInFlight inFlightCounter
 
 	// 报警缓冲
-	alarmQueue []*models.AlarmPayload
-	alarmMu    sync.RWMutex
+	alarmQueue    []*models.AlarmPayload
+	alarmMu       sync.RWMutex
+	alarmInFlight inFlightCounter
 
 	// 命令队列
 	commandQueue []*models.NorthboundCommand
@@ -2,7 +2,6 @@ package adapters
 
 import (
 	"encoding/json"
-	"slices"
 	"strings"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -108,33 +107,12 @@ func (a *SagooAdapter) enqueueCommandFromPropertySet(defaultPK, defaultDK, reque
 		return
 	}
 
-	keys := make([]string, 0, len(properties))
-	for key := range properties {
-		trimmed := strings.TrimSpace(key)
-		if trimmed == "" {
-			continue
-		}
-		keys = append(keys, trimmed)
-	}
-	if len(keys) == 0 {
+	command := buildBatchWriteCommand(requestID, pk, dk, "sagoo.property.set", properties)
+	if command == nil {
 		return
 	}
-	slices.Sort(keys)
 
 	a.commandMu.Lock()
 	defer a.commandMu.Unlock()
-
-	commands := make([]*models.NorthboundCommand, 0, len(keys))
-	for _, key := range keys {
-		raw := properties[key]
-		commands = append(commands, &models.NorthboundCommand{
-			RequestID:  requestID,
-			ProductKey: pk,
-			DeviceKey:  dk,
-			FieldName:  key,
-			Value:      stringifyAny(raw),
-			Source:     "sagoo.property.set",
-		})
-	}
-	a.commandQueue = appendCommandQueueWithCap(a.commandQueue, commands, a.commandCap)
+	a.commandQueue = appendCommandQueueWithCap(a.commandQueue, []*models.NorthboundCommand{command}, a.commandCap)
 }
@@ -2,7 +2,6 @@ package adapters
 
 import (
 	"encoding/json"
-	"time"
 
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
@@ -42,6 +41,7 @@ type sagooAlarmValue struct {
 	Threshold   float64 `json:"threshold"`
 	Operator    string  `json:"operator"`
 	Message     string  `json:"message"`
+	State       string  `json:"state,omitempty"`
 }
 
 type sagooAlarmEvent struct {
@@ -105,6 +105,21 @@ func (a *SagooAdapter) SendAlarm(alarm *models.AlarmPayload) error {
 	return nil
 }
 
+// PendingQueue 获取待发队列状态（含正在发送的批次）
+func (a *SagooAdapter) PendingQueue() PendingQueueState {
+	a.dataMu.RLock()
+	data := len(a.latestData) + a.dataInFlight.load()
+	dataCap := queueCapOrDefault(a.realtimeCap, defaultRealtimeQueue)
+	a.dataMu.RUnlock()
+
+	a.alarmMu.RLock()
+	alarm := len(a.alarmQueue) + a.alarmInFlight.load()
+	alarmCap := queueCapOrDefault(a.alarmCap, defaultAlarmQueue)
+	a.alarmMu.RUnlock()
+
+	return PendingQueueState{Data: data, DataCap: dataCap, Alarm: alarm, AlarmCap: alarmCap}
+}
+
 // flushLatestData 发送实时数据
 func (a *SagooAdapter) flushLatestData() error {
 	a.dataMu.Lock()
@@ -115,7 +130,9 @@ func (a *SagooAdapter) flushLatestData() error {
 	batch := a.latestData
 	a.latestData = nil
 	topic := a.topic
+	done := a.dataInFlight.begin(len(batch))
 	a.dataMu.Unlock()
+	defer done()
 
 	for _, data := range batch {
 		message := a.buildMessage(data)
@@ -147,7 +164,9 @@ func (a *SagooAdapter) flushAlarmBatch() error {
 	a.alarmQueue = a.alarmQueue[count:]
 	a.alarmQueue = a.alarmQueue[:len(a.alarmQueue):len(a.alarmQueue)]
 	topic := a.alarmTopic
+	done := a.alarmInFlight.begin(len(batch))
 	a.alarmMu.Unlock()
+	defer done()
 
 	for _, alarm := range batch {
 		message := a.buildAlarmMessage(alarm)
@@ -236,8 +255,9 @@ func (a *SagooAdapter) buildAlarmMessage(alarm *models.AlarmPayload) []byte {
 								Threshold:   alarm.Threshold,
 								Operator:    alarm.Operator,
 								Message:     alarm.Message,
+								State:       alarm.State,
 							},
-							Time: time.Now().UnixMilli(),
+							Time: alarm.TimestampOrNow().UnixMilli(),
 						},
 					},
 				},
@@ -10,11 +10,11 @@ import (
 )
 
 type sagooCommandReplyPayload struct {
-	Code    int                `json:"code"`
-	ID      string             `json:"id"`
-	Message string             `json:"message"`
-	Version string             `json:"version"`
-	Data    jsonSingleRawField `json:"data"`
+	Code    int    `json:"code"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Version string `json:"version"`
+	Data    any    `json:"data"`
 }
 
 func (a *SagooAdapter) lifecycleState() adapterLifecycleState {
@@ -145,10 +145,11 @@ func (a *SagooAdapter) ReportCommandResult(result *models.NorthboundCommandResul
 		ID:      result.RequestID,
 		Message: msg,
 		Version: "1.0.0",
-		Data: jsonSingleRawField{
-			Key:   result.FieldName,
-			Value: result.Value,
-		},
+	}
+	if len(result.Fields) > 0 {
+		resp.Data = commandResultWrittenValues(result)
+	} else {
+		resp.Data = jsonSingleRawField{Key: result.FieldName, Value: result.Value}
 	}
 	body, _ := json.Marshal(resp)
 
@@ -408,3 +408,18 @@ func TestSagooSingleLoop_StopThenCloseSafe(t *testing.T) {
 		t.Fatalf("loopState=%s, want=stopped", adapter.loopState.String())
 	}
 }
+
+func TestEnqueueCommandFromPropertySet_MergesFieldsIntoBatch(t *testing.T) {
+	adapter := NewSagooAdapter("sagoo-test")
+	adapter.commandCap = 10
+
+	adapter.enqueueCommandFromPropertySet("pk", "dk", "req-3", map[string]any{"b": 2, "a": "on"}, "", "")
+
+	if len(adapter.commandQueue) != 1 {
+		t.Fatalf("expected 1 batch command, got %d", len(adapter.commandQueue))
+	}
+	command := adapter.commandQueue[0]
+	if len(command.Fields) != 2 || command.Fields[0].FieldName != "a" || command.Fields[1].Value != "2" || command.FieldName != "a" {
+		t.Fatalf("unexpected batch command: %+v", command)
+	}
+}
@@ -54,11 +54,13 @@ type XunjiAdapter struct {
 
 	client mqtt.Client
 
-	pendingData []*models.CollectData
-	pendingMu   sync.RWMutex
+	pendingData  []*models.CollectData
+	pendingMu    sync.RWMutex
+	dataInFlight inFlightCounter
 
 	pendingAlarms []*models.AlarmPayload
 	alarmMu       sync.RWMutex
+	alarmInFlight inFlightCounter
 
 	stopChan     chan struct{}
 	dataChan     chan struct{}
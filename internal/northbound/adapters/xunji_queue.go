@@ -38,6 +38,21 @@ func (a *XunjiAdapter) SendAlarm(alarm *models.AlarmPayload) error {
 	return nil
 }
 
+// PendingQueue 获取待发队列状态（含正在发送的批次）
+func (a *XunjiAdapter) PendingQueue() PendingQueueState {
+	a.pendingMu.RLock()
+	data := len(a.pendingData) + a.dataInFlight.load()
+	dataCap := xunjiPendingDataCap
+	a.pendingMu.RUnlock()
+
+	a.alarmMu.RLock()
+	alarm := len(a.pendingAlarms) + a.alarmInFlight.load()
+	alarmCap := xunjiPendingAlarmCap
+	a.alarmMu.RUnlock()
+
+	return PendingQueueState{Data: data, DataCap: dataCap, Alarm: alarm, AlarmCap: alarmCap}
+}
+
 func (a *XunjiAdapter) flushPendingData() {
 	a.pendingMu.Lock()
 	if len(a.pendingData) == 0 {
@@ -46,7 +61,9 @@ func (a *XunjiAdapter) flushPendingData() {
 	}
 	batch := a.pendingData
 	a.pendingData = nil
+	done := a.dataInFlight.begin(len(batch))
 	a.pendingMu.Unlock()
+	defer done()
 
 	body := a.buildBatchRealtimePayload(batch)
 	topic := a.currentTopic()
@@ -73,7 +90,9 @@ func (a *XunjiAdapter) flushAlarms() error {
 	}
 	batch := a.pendingAlarms
 	a.pendingAlarms = nil
+	done := a.alarmInFlight.begin(len(batch))
 	a.alarmMu.Unlock()
+	defer done()
 
 	topic := a.currentAlarmTopic()
 	for idx, alarm := range batch {
@@ -122,6 +141,7 @@ type xunjiAlarmMessage struct {
 	Operator    string  `json:"operator"`
 	Severity    string  `json:"severity"`
 	Message     string  `json:"message"`
+	State       string  `json:"state,omitempty"`
 	Timestamp   int64   `json:"timestamp"`
 }
 
@@ -169,7 +189,8 @@ func (a *XunjiAdapter) buildAlarmMessage(alarm *models.AlarmPayload) []byte {
 		Operator:    alarm.Operator,
 		Severity:    alarm.Severity,
 		Message:     alarm.Message,
-		Timestamp:   time.Now().UnixMilli(),
+		State:       alarm.State,
+		Timestamp:   alarm.TimestampOrNow().UnixMilli(),
 	}
 	body, _ := json.Marshal(msg)
 	return body
@@ -34,8 +34,17 @@ type NorthboundManager struct {
 	wg          sync.WaitGroup
 	running     bool
 
-	// 熔断器（可选，由适配器自己管理）
+	// 熔断器（启用暂存时用于判定是否直发）
 	breakers map[string]*circuit.CircuitBreaker
+
+	// 断线/熔断暂存（可选，为 nil 时保持直发）
+	spool       SpoolStore
+	spoolConfig SpoolConfig
+	spoolStates map[string]*spoolState
+
+	// 服务端型北向客户端写入的同步执行器（由采集器设置）
+	commandExecutor adapters.CommandExecutor
+	devicePoints    adapters.DevicePointResolver
 }
 
 type adapterRuntimeRef struct {
@@ -52,6 +61,10 @@ type RuntimeStatus struct {
 	Pending          bool
 	BreakerState     string
 	LastSentAt       time.Time
+	SpoolDepth       int
+	SpoolReplayed    int64
+	ListenAddress    string
+	Clients          int
 }
 
 // DefaultBreakerConfig 默认熔断器配置
@@ -72,6 +85,8 @@ func NewNorthboundManager() *NorthboundManager {
 		intervals:   make(map[string]time.Duration),
 		stopChan:    make(chan struct{}),
 		breakers:    make(map[string]*circuit.CircuitBreaker),
+		spoolConfig: DefaultSpoolConfig,
+		spoolStates: make(map[string]*spoolState),
 	}
 }
 
@@ -84,8 +99,16 @@ func (m *NorthboundManager) Start() {
 	}
 	m.running = true
 	m.stopChan = make(chan struct{})
+	stopChan := m.stopChan
+	spoolEnabled := m.spool != nil
+	replayInterval := m.spoolConfig.ReplayInterval
 	m.mu.Unlock()
 
+	if spoolEnabled {
+		m.wg.Add(1)
+		go m.runSpoolReplayLoop(stopChan, replayInterval)
+	}
+
 	slog.Info("Northbound manager started")
 }
 
@@ -124,10 +147,49 @@ func (m *NorthboundManager) RegisterAdapter(name string, adapter adapters.Northb
 	m.adapters[name] = adapter
 	m.selfManaged[name] = true // 内置适配器都是 self-managed
 	m.enabled[name] = true
+	if execAdapter, ok := adapter.(adapters.NorthboundAdapterWithCommandExecutor); ok && m.commandExecutor != nil {
+		execAdapter.SetCommandExecutor(m.commandExecutor)
+	}
+	if catalogAdapter, ok := adapter.(adapters.NorthboundAdapterWithDeviceCatalog); ok && m.devicePoints != nil {
+		catalogAdapter.SetDevicePointResolver(m.devicePoints)
+	}
 
 	slog.Info("Northbound adapter registered", "name", name)
 }
 
+// SetCommandExecutor 设置服务端型北向的同步写命令执行器，并应用到已注册与之后注册的适配器
+func (m *NorthboundManager) SetCommandExecutor(executor adapters.CommandExecutor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commandExecutor = executor
+	for _, adapter := range m.adapters {
+		if execAdapter, ok := adapter.(adapters.NorthboundAdapterWithCommandExecutor); ok {
+			execAdapter.SetCommandExecutor(executor)
+		}
+	}
+}
+
+// SetDevicePointResolver 设置设备测点解析器，并应用到已注册与之后注册的适配器
+func (m *NorthboundManager) SetDevicePointResolver(resolver adapters.DevicePointResolver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.devicePoints = resolver
+	for _, adapter := range m.adapters {
+		if catalogAdapter, ok := adapter.(adapters.NorthboundAdapterWithDeviceCatalog); ok {
+			catalogAdapter.SetDevicePointResolver(resolver)
+		}
+	}
+}
+
+// ApplyDeviceCatalog 把当前设备表推给按设备表维护地址空间的北向，用于增删设备与测点节点
+func (m *NorthboundManager) ApplyDeviceCatalog(devices []*models.Device) {
+	for _, ref := range m.enabledAdapterRefs() {
+		if catalogAdapter, ok := ref.adapter.(adapters.NorthboundAdapterWithDeviceCatalog); ok {
+			catalogAdapter.ApplyDeviceCatalog(devices)
+		}
+	}
+}
+
 // UnregisterAdapter 注销适配器
 func (m *NorthboundManager) UnregisterAdapter(name string) {
 	m.mu.Lock()
@@ -142,6 +204,8 @@ func (m *NorthboundManager) UnregisterAdapter(name string) {
 	delete(m.enabled, name)
 	delete(m.intervals, name)
 	delete(m.breakers, name)
+	// 暂存数据保留在磁盘，同名北向重新注册后继续补发
+	delete(m.spoolStates, name)
 
 	slog.Info("Northbound adapter unregistered", "name", name)
 }
@@ -249,6 +313,7 @@ func (m *NorthboundManager) RuntimeStatus(name string) RuntimeStatus {
 	interval := m.intervals[name]
 	breaker := m.breakers[name]
 	m.mu.RUnlock()
+	spool, _ := m.spoolSnapshotFor(name)
 
 	status := RuntimeStatus{
 		Name:             name,
@@ -256,6 +321,9 @@ func (m *NorthboundManager) RuntimeStatus(name string) RuntimeStatus {
 		Enabled:          enabled,
 		UploadIntervalMS: interval.Milliseconds(),
 		BreakerState:     circuit.Closed.String(),
+		SpoolDepth:       spool.depth,
+		SpoolReplayed:    spool.replayed,
+		Pending:          spool.depth > 0,
 	}
 	if breaker != nil {
 		status.BreakerState = breaker.State().String()
@@ -267,7 +335,9 @@ func (m *NorthboundManager) RuntimeStatus(name string) RuntimeStatus {
 	if runtimeStats, ok := adapter.(adapters.NorthboundAdapterWithRuntimeStats); ok {
 		snapshot := runtimeStats.RuntimeStatsSnapshot()
 		status.Connected = enabled && snapshot.Connected
-		status.Pending = snapshot.HasPending()
+		status.Pending = status.Pending || snapshot.HasPending()
+		status.ListenAddress = snapshot.ListenAddress
+		status.Clients = snapshot.Clients
 	} else {
 		if enabled {
 			status.Connected = adapter.IsConnected()
@@ -342,17 +412,38 @@ func (m *NorthboundManager) SendData(data *models.CollectData) {
 		data.EnsureFields()
 	}
 	for _, ref := range m.enabledAdapterRefs() {
-		// 内置适配器自己管理发送，不需要通过熔断器
-		if err := ref.adapter.Send(data); err != nil {
+		// 未启用暂存时内置适配器自己管理发送；启用后断线/熔断期间写入暂存
+		adapter := ref.adapter
+		if err := m.deliverOrSpool(ref, SpoolKindData, data, collectDataTimestamp(data), func() error {
+			return adapter.Send(data)
+		}); err != nil {
 			slog.Error("Failed to send data", "adapter", ref.name, "error", err)
 		}
 	}
 }
 
+// ApplyLiveData 把设备实时采集值推给需要实时值的北向（如 OPC UA 服务端），不经过暂存与熔断
+func (m *NorthboundManager) ApplyLiveData(data *models.CollectData) {
+	if data == nil {
+		return
+	}
+	for _, ref := range m.enabledAdapterRefs() {
+		if liveAdapter, ok := ref.adapter.(adapters.NorthboundAdapterWithLiveData); ok {
+			liveAdapter.ApplyLiveData(data)
+		}
+	}
+}
+
 // SendAlarm 发送报警到所有启用的北向
 func (m *NorthboundManager) SendAlarm(alarm *models.AlarmPayload) {
+	if alarm != nil && alarm.Timestamp.IsZero() {
+		alarm.Timestamp = time.Now()
+	}
 	for _, ref := range m.enabledAdapterRefs() {
-		if err := ref.adapter.SendAlarm(alarm); err != nil {
+		adapter := ref.adapter
+		if err := m.deliverOrSpool(ref, SpoolKindAlarm, alarm, alarm.TimestampOrNow(), func() error {
+			return adapter.SendAlarm(alarm)
+		}); err != nil {
 			slog.Error("Failed to send alarm", "adapter", ref.name, "error", err)
 		}
 	}
@@ -430,10 +521,22 @@ func (m *NorthboundManager) GetStats() map[string]map[string]any {
 	result := make(map[string]map[string]any)
 	for name, adapter := range m.adapters {
 		var stats map[string]any
+		var spool spoolSnapshot
+		if st := m.spoolStates[name]; st != nil {
+			spool = st.snapshot()
+		}
 		if runtimeStats, ok := adapter.(adapters.NorthboundAdapterWithRuntimeStats); ok {
-			stats = runtimeStats.RuntimeStatsSnapshot().ToMap()
+			snapshot := runtimeStats.RuntimeStatsSnapshot()
+			if m.spool != nil {
+				applySpoolSnapshot(&snapshot, spool)
+			}
+			stats = snapshot.ToMap()
 		} else {
 			stats = adapter.GetStats()
+			if m.spool != nil {
+				stats["spool_depth"] = spool.depth
+				stats["spool_replayed"] = spool.replayed
+			}
 		}
 		stats["manager_enabled"] = m.enabled[name]
 		stats["manager_interval_ms"] = m.intervals[name].Milliseconds()
@@ -441,3 +544,10 @@ func (m *NorthboundManager) GetStats() map[string]map[string]any {
 	}
 	return result
 }
+
+func collectDataTimestamp(data *models.CollectData) time.Time {
+	if data == nil || data.Timestamp.IsZero() {
+		return time.Now()
+	}
+	return data.Timestamp
+}
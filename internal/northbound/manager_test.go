@@ -1,6 +1,7 @@
 package northbound
 
 import (
+	"context"
 	"errors"
 	"sync/atomic"
 	"testing"
@@ -167,6 +168,113 @@ func TestNorthboundManager_SendAlarm(t *testing.T) {
 	}
 }
 
+// liveFakeAdapter 额外实现实时值接口
+type liveFakeAdapter struct {
+	fakeAdapter
+	liveCalls int32
+}
+
+func (f *liveFakeAdapter) ApplyLiveData(data *models.CollectData) {
+	atomic.AddInt32(&f.liveCalls, 1)
+}
+
+func TestNorthboundManager_ApplyLiveData(t *testing.T) {
+	mgr := NewNorthboundManager()
+	live := &liveFakeAdapter{fakeAdapter: fakeAdapter{name: "live", enabled: true}}
+	plain := &fakeAdapter{name: "plain", enabled: true}
+	mgr.RegisterAdapter("live", live)
+	mgr.RegisterAdapter("plain", plain)
+
+	mgr.ApplyLiveData(&models.CollectData{DeviceID: 1})
+	mgr.ApplyLiveData(nil)
+
+	if got := atomic.LoadInt32(&live.liveCalls); got != 1 {
+		t.Fatalf("expected ApplyLiveData to be called once, got %d", got)
+	}
+	if atomic.LoadInt32(&live.sendCalls) != 0 || atomic.LoadInt32(&plain.sendCalls) != 0 {
+		t.Fatalf("ApplyLiveData should not go through Send")
+	}
+
+	mgr.SetEnabled("live", false)
+	mgr.ApplyLiveData(&models.CollectData{DeviceID: 1})
+	if got := atomic.LoadInt32(&live.liveCalls); got != 1 {
+		t.Fatalf("disabled adapter should be skipped, got %d calls", got)
+	}
+}
+
+type execFakeAdapter struct {
+	fakeAdapter
+	executor adapters.CommandExecutor
+}
+
+func (f *execFakeAdapter) SetCommandExecutor(executor adapters.CommandExecutor) {
+	f.executor = executor
+}
+
+func TestNorthboundManager_SetCommandExecutor(t *testing.T) {
+	mgr := NewNorthboundManager()
+	before := &execFakeAdapter{fakeAdapter: fakeAdapter{name: "before"}}
+	mgr.RegisterAdapter("before", before)
+
+	var calls int32
+	mgr.SetCommandExecutor(func(context.Context, *models.NorthboundCommand) *models.NorthboundCommandResult {
+		atomic.AddInt32(&calls, 1)
+		return &models.NorthboundCommandResult{Success: true}
+	})
+	after := &execFakeAdapter{fakeAdapter: fakeAdapter{name: "after"}}
+	mgr.RegisterAdapter("after", after)
+
+	for _, adapter := range []*execFakeAdapter{before, after} {
+		if adapter.executor == nil {
+			t.Fatalf("%s: executor not set", adapter.name)
+		}
+		if result := adapter.executor(context.Background(), &models.NorthboundCommand{}); result == nil || !result.Success {
+			t.Fatalf("%s: executor result = %+v", adapter.name, result)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("executor calls = %d, want 2", got)
+	}
+}
+
+type catalogFakeAdapter struct {
+	fakeAdapter
+	resolver adapters.DevicePointResolver
+	catalog  []*models.Device
+}
+
+func (f *catalogFakeAdapter) SetDevicePointResolver(resolver adapters.DevicePointResolver) {
+	f.resolver = resolver
+}
+
+func (f *catalogFakeAdapter) ApplyDeviceCatalog(devices []*models.Device) {
+	f.catalog = devices
+}
+
+func TestNorthboundManager_DeviceCatalog(t *testing.T) {
+	mgr := NewNorthboundManager()
+	mgr.SetDevicePointResolver(func(*models.Device) []models.DevicePoint {
+		return []models.DevicePoint{{Name: "v"}}
+	})
+	enabled := &catalogFakeAdapter{fakeAdapter: fakeAdapter{name: "enabled", enabled: true}}
+	disabled := &catalogFakeAdapter{fakeAdapter: fakeAdapter{name: "disabled"}}
+	mgr.RegisterAdapter("enabled", enabled)
+	mgr.RegisterAdapter("disabled", disabled)
+	mgr.SetEnabled("disabled", false)
+
+	if enabled.resolver == nil || len(enabled.resolver(&models.Device{})) != 1 {
+		t.Fatalf("resolver not applied on register")
+	}
+	devices := []*models.Device{{ID: 1}}
+	mgr.ApplyDeviceCatalog(devices)
+	if len(enabled.catalog) != 1 {
+		t.Fatalf("catalog not forwarded to enabled adapter")
+	}
+	if disabled.catalog != nil {
+		t.Fatalf("disabled adapter should be skipped")
+	}
+}
+
 func TestNorthboundManager_Intervals(t *testing.T) {
 	mgr := NewNorthboundManager()
 	adapter := &fakeAdapter{name: "a1"}
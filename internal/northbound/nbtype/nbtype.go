@@ -8,9 +8,14 @@ const (
 	TypePandaX  = "pandax"
 	TypeIThings = "ithings"
 	TypeSagoo   = "sagoo"
+	TypeOPCUA   = "opcua"
+	// TypeModbusSlave 网关作为 Modbus TCP 从站对外提供采集数据
+	TypeModbusSlave = "modbus_slave"
+	// TypeHTTP 以 HTTP/HTTPS 请求推送数据与报警
+	TypeHTTP = "http"
 )
 
-var supportedTypes = []string{TypeMQTT, TypeXunji, TypePandaX, TypeIThings, TypeSagoo}
+var supportedTypes = []string{TypeMQTT, TypeXunji, TypePandaX, TypeIThings, TypeSagoo, TypeOPCUA, TypeModbusSlave, TypeHTTP}
 
 func Normalize(raw string) string {
 	return strings.ToLower(strings.TrimSpace(raw))
@@ -22,7 +27,7 @@ func SupportedTypes() []string {
 
 func IsSupported(raw string) bool {
 	switch Normalize(raw) {
-	case TypeMQTT, TypeXunji, TypePandaX, TypeIThings, TypeSagoo:
+	case TypeMQTT, TypeXunji, TypePandaX, TypeIThings, TypeSagoo, TypeOPCUA, TypeModbusSlave, TypeHTTP:
 		return true
 	default:
 		return false
@@ -41,6 +46,12 @@ func DisplayName(raw string) string {
 		return "iThings"
 	case TypeSagoo:
 		return "Sagoo"
+	case TypeOPCUA:
+		return "OPC UA"
+	case TypeModbusSlave:
+		return "Modbus TCP Slave"
+	case TypeHTTP:
+		return "HTTP"
 	default:
 		return strings.TrimSpace(raw)
 	}
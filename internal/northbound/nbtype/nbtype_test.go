@@ -29,6 +29,15 @@ func TestIsSupported(t *testing.T) {
 	if !IsSupported(TypeXunji) {
 		t.Fatal("expected xunji to be supported")
 	}
+	if !IsSupported(" OPCUA ") {
+		t.Fatal("expected opcua to be supported")
+	}
+	if !IsSupported("Modbus_Slave") {
+		t.Fatal("expected modbus_slave to be supported")
+	}
+	if !IsSupported("HTTP") {
+		t.Fatal("expected http to be supported")
+	}
 	if IsSupported("unknown") {
 		t.Fatal("expected unknown to be unsupported")
 	}
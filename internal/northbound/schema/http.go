@@ -0,0 +1,21 @@
+package schema
+
+// HTTPConfigSchema is the schema source for HTTP/HTTPS push northbound config.
+var HTTPConfigSchema = []Field{
+	{Key: "url", Label: "推送地址", Type: FieldTypeString, Required: true, Default: "", Description: "例如 https://example.com/api/telemetry"},
+	{Key: "alarmUrl", Label: "报警推送地址", Type: FieldTypeString, Optional: true, Default: "", Description: "为空时报警推送到推送地址"},
+	{Key: "method", Label: "请求方法", Type: FieldTypeString, Optional: true, Default: "POST", Description: "POST / PUT / PATCH"},
+	{Key: "headers", Label: "自定义请求头", Type: FieldTypeString, Optional: true, Default: "", Description: `JSON 对象，例如 {"X-Api-Key":"abc"}`},
+	{Key: "authType", Label: "认证方式", Type: FieldTypeString, Optional: true, Default: "none", Description: "none / bearer / basic"},
+	{Key: "token", Label: "Bearer Token", Type: FieldTypeString, Optional: true, Default: "", Description: "authType 为 bearer 时必填"},
+	{Key: "username", Label: "用户名", Type: FieldTypeString, Optional: true, Default: "", Description: "authType 为 basic 时必填"},
+	{Key: "password", Label: "密码", Type: FieldTypeString, Optional: true, Default: "", Description: "Basic 认证密码"},
+	{Key: "bodyTemplate", Label: "请求体模板", Type: FieldTypeString, Optional: true, Default: "", Description: "Go text/template，可用 .Type/.Gateway/.Timestamp/.Items 与 json 函数；为空时发送默认 JSON"},
+	{Key: "gzip", Label: "Gzip 压缩", Type: FieldTypeBool, Optional: true, Default: false, Description: "请求体 gzip 压缩并带 Content-Encoding: gzip"},
+	{Key: "batchSize", Label: "批量条数", Type: FieldTypeInt, Optional: true, Default: 50, Description: "单次请求携带的最大数据/报警条数"},
+	{Key: "uploadIntervalMs", Label: "上报周期(ms)", Type: FieldTypeInt, Optional: true, Default: 5000, Description: "采集数据批量推送周期，报警会尽快推送"},
+	{Key: "timeoutMs", Label: "请求超时(ms)", Type: FieldTypeInt, Optional: true, Default: 10000, Description: "单次请求超时"},
+	{Key: "maxRetries", Label: "重试次数", Type: FieldTypeInt, Optional: true, Default: 3, Description: "失败后的重试次数，仍失败则保留到下个周期"},
+	{Key: "retryBackoffMs", Label: "重试退避(ms)", Type: FieldTypeInt, Optional: true, Default: 500, Description: "首次重试等待时间，之后按倍数递增"},
+	{Key: "insecureSkipVerify", Label: "跳过证书校验", Type: FieldTypeBool, Optional: true, Default: false, Description: "HTTPS 自签名证书时使用"},
+}
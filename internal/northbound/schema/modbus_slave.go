@@ -0,0 +1,13 @@
+package schema
+
+// ModbusSlaveConfigSchema is the schema source for Modbus TCP slave northbound config.
+var ModbusSlaveConfigSchema = []Field{
+	{Key: "host", Label: "监听地址", Type: FieldTypeString, Optional: true, Default: "0.0.0.0", Description: "Modbus TCP 服务监听地址"},
+	{Key: "port", Label: "监听端口", Type: FieldTypeInt, Optional: true, Default: 502, Description: "主站连接端口"},
+	{Key: "unitId", Label: "从站地址", Type: FieldTypeInt, Optional: true, Default: 0, Description: "0 表示响应任意单元标识"},
+	{Key: "registers", Label: "寄存器映射", Type: FieldTypeString, Required: true, Default: "", Description: `JSON 数组，例如 [{"device_id":1,"field":"temp","table":"holding","address":0,"data_type":"float32"}]`},
+	{Key: "refreshIntervalMs", Label: "刷新周期(ms)", Type: FieldTypeInt, Optional: true, Default: 1000, Description: "从实时缓存刷新寄存器的周期"},
+	{Key: "writeEnabled", Label: "允许写入", Type: FieldTypeBool, Optional: true, Default: false, Description: "开启后 rw 为 W/RW 的保持寄存器接受主站写入"},
+	{Key: "writeTimeoutMs", Label: "写入超时(ms)", Type: FieldTypeInt, Optional: true, Default: 5000, Description: "等待设备写入结果的最长时间"},
+	{Key: "maxConnections", Label: "最大连接数", Type: FieldTypeInt, Optional: true, Default: 8, Description: "同时在线的主站连接上限"},
+}
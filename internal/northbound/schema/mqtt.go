@@ -3,8 +3,17 @@ package schema
 // MQTTConfigSchema is the schema source for MQTT northbound config.
 var MQTTConfigSchema = []Field{
 	{Key: "broker", Label: "Broker 地址", Type: FieldTypeString, Required: true, Default: "", Description: "例如 tcp://127.0.0.1:1883"},
-	{Key: "topic", Label: "数据 Topic", Type: FieldTypeString, Required: true, Default: "", Description: "实时数据上报主题"},
-	{Key: "alarmTopic", Label: "报警 Topic", Type: FieldTypeString, Optional: true, Default: "", Description: "报警数据上报主题，为空时默认在数据 Topic 后加 /alarm"},
+	{Key: "topic", Label: "数据 Topic", Type: FieldTypeString, Required: true, Default: "", Description: "实时数据上报主题，支持 {product_key}/{device_key}/{device_name}/{device_id}/{field} 占位符，含 {field} 时按字段拆分发布"},
+	{Key: "alarmTopic", Label: "报警 Topic", Type: FieldTypeString, Optional: true, Default: "", Description: "报警数据上报主题，为空时默认在数据 Topic 后加 /alarm，占位符同数据 Topic"},
+	{Key: "commandResultTopic", Label: "命令结果 Topic", Type: FieldTypeString, Optional: true, Default: "", Description: "命令执行结果主题，为空时默认在数据 Topic 后加 /command/result"},
+	{Key: "commandTopic", Label: "命令 Topic", Type: FieldTypeString, Optional: true, Default: "", Description: "订阅的命令下发主题，为空时不接收命令；{product_key}/{device_key}/{field} 需独占一级，按 + 订阅并从主题取值"},
+	{Key: "commandRequestMapping", Label: "命令请求映射", Type: FieldTypeString, Optional: true, Default: "", Description: `JSON 对象，指定 request_id/product_key/device_key/field_name/value/params/response_topic 在请求中的 "$.path"，为空时按同名字段读取`},
+	{Key: "payloadTemplate", Label: "数据载荷模板", Type: FieldTypeString, Optional: true, Default: "", Description: `Go text/template，例如 {"ts":{{.timestamp_ms}},"values":{{json .fields}}}；为空时使用内置结构`},
+	{Key: "payloadMapping", Label: "数据载荷映射", Type: FieldTypeString, Optional: true, Default: "", Description: `JSON 对象，"$.path" 取值，例如 {"id":"$.device_key","data":"$.fields"}；与模板二选一`},
+	{Key: "alarmPayloadTemplate", Label: "报警载荷模板", Type: FieldTypeString, Optional: true, Default: "", Description: "Go text/template，可用 .field_name/.actual_value/.threshold/.severity/.state 等"},
+	{Key: "alarmPayloadMapping", Label: "报警载荷映射", Type: FieldTypeString, Optional: true, Default: "", Description: "JSON 对象映射，与报警载荷模板二选一"},
+	{Key: "commandResultPayloadTemplate", Label: "命令结果载荷模板", Type: FieldTypeString, Optional: true, Default: "", Description: "Go text/template，可用 .request_id/.success/.code/.message 等"},
+	{Key: "commandResultPayloadMapping", Label: "命令结果载荷映射", Type: FieldTypeString, Optional: true, Default: "", Description: "JSON 对象映射，与命令结果载荷模板二选一"},
 	{Key: "clientId", Label: "Client ID", Type: FieldTypeString, Optional: true, Default: "", Description: "为空时自动生成"},
 	{Key: "username", Label: "用户名", Type: FieldTypeString, Optional: true, Default: "", Description: "MQTT 用户名（可选）"},
 	{Key: "password", Label: "密码", Type: FieldTypeString, Optional: true, Default: "", Description: "MQTT 密码（可选）"},
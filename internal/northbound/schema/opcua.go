@@ -0,0 +1,13 @@
+package schema
+
+// OPCUAConfigSchema is the schema source for OPC UA server northbound config.
+var OPCUAConfigSchema = []Field{
+	{Key: "host", Label: "监听地址", Type: FieldTypeString, Optional: true, Default: "0.0.0.0", Description: "OPC UA 服务监听地址"},
+	{Key: "port", Label: "监听端口", Type: FieldTypeInt, Optional: true, Default: 4840, Description: "客户端连接 opc.tcp://<host>:<port>"},
+	{Key: "endpointHost", Label: "对外地址", Type: FieldTypeString, Optional: true, Default: "", Description: "客户端可达的主机名/IP，为空时使用监听地址"},
+	{Key: "namespaceUri", Label: "命名空间 URI", Type: FieldTypeString, Optional: true, Default: "urn:xunjifsu:gateway", Description: "设备节点所在命名空间"},
+	{Key: "serverName", Label: "服务名称", Type: FieldTypeString, Optional: true, Default: "", Description: "为空时使用北向名称"},
+	{Key: "allowInsecure", Label: "允许不加密访问", Type: FieldTypeBool, Optional: true, Default: false, Description: "内置服务端仅支持安全策略 None + 匿名登录，须开启此项确认后才能启用"},
+	{Key: "writeEnabled", Label: "允许写入", Type: FieldTypeBool, Optional: true, Default: false, Description: "开启后可写测点(RW 为 W/RW)接受客户端写值"},
+	{Key: "writeTimeoutMs", Label: "写入超时(ms)", Type: FieldTypeInt, Optional: true, Default: 5000, Description: "等待设备写入结果的最长时间"},
+}
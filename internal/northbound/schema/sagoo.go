@@ -19,6 +19,9 @@ var SupportedNorthboundSchemaTypes = []string{
 	nbtype.TypePandaX,
 	nbtype.TypeIThings,
 	nbtype.TypeSagoo,
+	nbtype.TypeOPCUA,
+	nbtype.TypeModbusSlave,
+	nbtype.TypeHTTP,
 }
 
 // Field describes one config field in Terraform SDK Schema-like style.
@@ -54,6 +57,12 @@ func FieldsByType(nbType string) ([]Field, bool) {
 		return cloneFields(PandaXConfigSchema), true
 	case nbtype.TypeIThings:
 		return cloneFields(IThingsConfigSchema), true
+	case nbtype.TypeOPCUA:
+		return cloneFields(OPCUAConfigSchema), true
+	case nbtype.TypeModbusSlave:
+		return cloneFields(ModbusSlaveConfigSchema), true
+	case nbtype.TypeHTTP:
+		return cloneFields(HTTPConfigSchema), true
 	default:
 		return nil, false
 	}
@@ -35,7 +35,7 @@ func TestSupportedTypes(t *testing.T) {
 	types := append([]string(nil), SupportedNorthboundSchemaTypes...)
 	sort.Strings(types)
 
-	expected := map[string]bool{"ithings": true, "mqtt": true, "pandax": true, "sagoo": true, "xunji": true}
+	expected := map[string]bool{"http": true, "ithings": true, "mqtt": true, "modbus_slave": true, "opcua": true, "pandax": true, "sagoo": true, "xunji": true}
 	if len(types) != len(expected) {
 		t.Fatalf("unexpected supported types len, got: %v", types)
 	}
@@ -0,0 +1,429 @@
+package northbound
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/circuit"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/adapters"
+)
+
+// 暂存消息类型
+const (
+	SpoolKindData  = "data"
+	SpoolKindAlarm = "alarm"
+)
+
+// SpoolStore 北向暂存存储（由 database 包实现，北向包不直接依赖数据库）
+type SpoolStore interface {
+	Append(adapter, kind string, payload []byte, createdAt time.Time) (int64, error)
+	List(adapter string, limit int) ([]*models.NorthboundSpoolEntry, error)
+	DeleteUpTo(adapter string, maxID int64) (int64, error)
+	Count(adapter string) (int, error)
+	Trim(adapter string, maxEntries int, maxAge time.Duration) (int64, error)
+}
+
+// SpoolConfig 北向暂存配置
+type SpoolConfig struct {
+	MaxEntries     int           // 每个北向最多暂存条数，<=0 不限制
+	MaxAge         time.Duration // 暂存最长保留时长，<=0 不限制
+	ReplayBatch    int           // 每轮补发条数
+	ReplayInterval time.Duration // 补发轮询间隔
+}
+
+// DefaultSpoolConfig 默认暂存配置；暂存本身默认关闭，由 northbound.spool_enabled 开启
+var DefaultSpoolConfig = SpoolConfig{
+	MaxEntries:     10000,
+	MaxAge:         24 * time.Hour,
+	ReplayBatch:    100,
+	ReplayInterval: time.Second,
+}
+
+// spoolState 单个北向的暂存运行状态
+// mu 串行化“判断是否直发 + 写暂存”与补发，保证补发期间新消息排在积压之后
+type spoolState struct {
+	mu     sync.Mutex
+	loaded bool
+	depth  int
+	// 已交给适配器内存队列、尚未确认发送的暂存（ID 上限、条数及所交给的适配器实例）
+	handoffID      int64
+	handoffCount   int64
+	handoffAdapter adapters.NorthboundAdapter
+	spooled        int64
+	replayed       int64
+	dropped        int64
+	lastReplayAt   time.Time
+	lastError      string
+}
+
+type spoolSnapshot struct {
+	depth        int
+	spooled      int64
+	replayed     int64
+	dropped      int64
+	lastReplayAt time.Time
+	lastError    string
+}
+
+func normalizeSpoolConfig(cfg SpoolConfig) SpoolConfig {
+	if cfg.ReplayBatch <= 0 {
+		cfg.ReplayBatch = DefaultSpoolConfig.ReplayBatch
+	}
+	if cfg.ReplayInterval <= 0 {
+		cfg.ReplayInterval = DefaultSpoolConfig.ReplayInterval
+	}
+	return cfg
+}
+
+// SetSpool 设置北向暂存存储；store 为 nil 时关闭暂存（需在 Start 前调用以启动补发循环）
+func (m *NorthboundManager) SetSpool(store SpoolStore, cfg SpoolConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spool = store
+	m.spoolConfig = normalizeSpoolConfig(cfg)
+	m.spoolStates = make(map[string]*spoolState)
+}
+
+func (m *NorthboundManager) spoolSettings() (SpoolStore, SpoolConfig) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.spool, m.spoolConfig
+}
+
+func (m *NorthboundManager) spoolStateFor(name string) *spoolState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.spoolStates[name]
+	if !ok {
+		st = &spoolState{}
+		m.spoolStates[name] = st
+	}
+	return st
+}
+
+func (m *NorthboundManager) breakerFor(name string) *circuit.CircuitBreaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	breaker, ok := m.breakers[name]
+	if !ok {
+		breakerConfig := DefaultBreakerConfig
+		breaker = circuit.NewCircuitBreaker(&breakerConfig)
+		m.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// loadSpoolDepthLocked 首次使用时从存储加载积压条数（重启后继续补发）
+func (st *spoolState) loadSpoolDepthLocked(store SpoolStore, name string) {
+	if st.loaded {
+		return
+	}
+	count, err := store.Count(name)
+	if err != nil {
+		st.lastError = err.Error()
+		return
+	}
+	st.depth = count
+	st.loaded = true
+}
+
+func (st *spoolState) snapshot() spoolSnapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return spoolSnapshot{
+		depth:        st.depth,
+		spooled:      st.spooled,
+		replayed:     st.replayed,
+		dropped:      st.dropped,
+		lastReplayAt: st.lastReplayAt,
+		lastError:    st.lastError,
+	}
+}
+
+// deliverOrSpool 北向可用且无积压时直接发送，否则（断线/熔断/发送失败/已有积压）写入暂存
+func (m *NorthboundManager) deliverOrSpool(ref adapterRuntimeRef, kind string, value any, createdAt time.Time, send func() error) error {
+	store, cfg := m.spoolSettings()
+	if store == nil {
+		return send()
+	}
+
+	st := m.spoolStateFor(ref.name)
+	breaker := m.breakerFor(ref.name)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.loadSpoolDepthLocked(store, ref.name)
+
+	if st.depth == 0 && ref.adapter.IsConnected() && breaker.State() != circuit.Open && hasQueueSpace(ref.adapter, kind) {
+		err := breaker.Execute(send)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("Northbound send failed, spooling", "adapter", ref.name, "kind", kind, "error", err)
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal spool payload: %w", err)
+	}
+	if _, err := store.Append(ref.name, kind, payload, createdAt); err != nil {
+		st.lastError = err.Error()
+		return fmt.Errorf("append spool: %w", err)
+	}
+	st.depth++
+	st.spooled++
+	if cfg.MaxEntries > 0 && st.depth > cfg.MaxEntries {
+		m.trimSpoolLocked(store, cfg, ref.name, st)
+	}
+	return nil
+}
+
+func (m *NorthboundManager) trimSpoolLocked(store SpoolStore, cfg SpoolConfig, name string, st *spoolState) {
+	if cfg.MaxEntries <= 0 && cfg.MaxAge <= 0 {
+		return
+	}
+	deleted, err := store.Trim(name, cfg.MaxEntries, cfg.MaxAge)
+	if err != nil {
+		st.lastError = err.Error()
+		slog.Warn("Failed to trim northbound spool", "adapter", name, "error", err)
+		return
+	}
+	if deleted <= 0 {
+		return
+	}
+	st.dropped += deleted
+	st.depth -= int(deleted)
+	if st.depth < 0 {
+		st.depth = 0
+	}
+	slog.Warn("Northbound spool trimmed", "adapter", name, "dropped", deleted)
+}
+
+// hasQueueSpace 适配器内存队列已满时不再直发，避免队列丢弃最早的消息
+func hasQueueSpace(adapter adapters.NorthboundAdapter, kind string) bool {
+	queued, ok := adapter.(adapters.NorthboundAdapterWithPendingQueue)
+	if !ok {
+		return true
+	}
+	state := queued.PendingQueue()
+	if kind == SpoolKindAlarm {
+		return state.AlarmSpace() > 0
+	}
+	return state.DataSpace() > 0
+}
+
+// runSpoolReplayLoop 周期性补发各北向的暂存消息
+func (m *NorthboundManager) runSpoolReplayLoop(stopChan <-chan struct{}, interval time.Duration) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			m.ReplaySpool()
+		}
+	}
+}
+
+// ReplaySpool 对所有启用的北向执行一轮暂存补发
+func (m *NorthboundManager) ReplaySpool() {
+	store, cfg := m.spoolSettings()
+	if store == nil {
+		return
+	}
+	for _, ref := range m.enabledAdapterRefs() {
+		m.replayAdapterSpool(store, cfg, ref)
+	}
+}
+
+// replayAdapterSpool 补发一个北向的暂存
+// 对只入内存队列的适配器（NorthboundAdapterWithPendingQueue），按队列剩余容量交付一批，
+// 暂存保留到后续轮次确认队列已排空后才删除；适配器重建时未确认的暂存重新补发
+func (m *NorthboundManager) replayAdapterSpool(store SpoolStore, cfg SpoolConfig, ref adapterRuntimeRef) {
+	st := m.spoolStateFor(ref.name)
+	breaker := m.breakerFor(ref.name)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.loadSpoolDepthLocked(store, ref.name)
+	if st.depth == 0 {
+		return
+	}
+
+	queued, hasQueue := ref.adapter.(adapters.NorthboundAdapterWithPendingQueue)
+	if st.handoffID > 0 {
+		if st.handoffAdapter != ref.adapter {
+			st.resetHandoffLocked()
+		} else {
+			if !hasQueue || !queued.PendingQueue().Drained() {
+				return
+			}
+			if !m.ackSpoolLocked(store, ref.name, st, st.handoffID, st.handoffCount) {
+				return
+			}
+			st.resetHandoffLocked()
+			if st.depth == 0 {
+				return
+			}
+		}
+	}
+
+	m.trimSpoolLocked(store, cfg, ref.name, st)
+	if st.depth == 0 || !ref.adapter.IsConnected() || breaker.State() == circuit.Open {
+		return
+	}
+
+	limit := cfg.ReplayBatch
+	var queue adapters.PendingQueueState
+	if hasQueue {
+		queue = queued.PendingQueue()
+		limit = min(limit, queue.DataSpace()+queue.AlarmSpace())
+		if limit <= 0 {
+			return
+		}
+	}
+
+	entries, err := store.List(ref.name, limit)
+	if err != nil {
+		st.lastError = err.Error()
+		slog.Warn("Failed to list northbound spool", "adapter", ref.name, "error", err)
+		return
+	}
+	if len(entries) == 0 {
+		st.depth = 0
+		return
+	}
+
+	var lastID int64
+	var replayed int64
+	for _, entry := range entries {
+		send, err := spoolEntrySender(ref.adapter, entry)
+		if err != nil {
+			// 无法解析的消息直接丢弃，避免阻塞后续补发
+			slog.Warn("Dropping invalid northbound spool entry", "adapter", ref.name, "id", entry.ID, "error", err)
+			st.dropped++
+			lastID = entry.ID
+			continue
+		}
+		if hasQueue && !reserveQueueSlot(&queue, entry.Kind) {
+			// 保持顺序：队列放不下当前这条就停止，剩余的等下一轮
+			break
+		}
+		if err := breaker.Execute(send); err != nil {
+			st.lastError = err.Error()
+			slog.Warn("Northbound spool replay paused", "adapter", ref.name, "id", entry.ID, "error", err)
+			break
+		}
+		lastID = entry.ID
+		replayed++
+	}
+	if lastID == 0 {
+		return
+	}
+	st.lastReplayAt = time.Now()
+
+	if hasQueue {
+		st.handoffID = lastID
+		st.handoffCount = replayed
+		st.handoffAdapter = ref.adapter
+		return
+	}
+	m.ackSpoolLocked(store, ref.name, st, lastID, replayed)
+}
+
+// reserveQueueSlot 在队列状态中为一条暂存预留位置，没有空间时返回 false
+func reserveQueueSlot(queue *adapters.PendingQueueState, kind string) bool {
+	if kind == SpoolKindAlarm {
+		if queue.AlarmSpace() == 0 {
+			return false
+		}
+		queue.Alarm++
+		return true
+	}
+	if queue.DataSpace() == 0 {
+		return false
+	}
+	queue.Data++
+	return true
+}
+
+// ackSpoolLocked 删除已确认发送的暂存
+func (m *NorthboundManager) ackSpoolLocked(store SpoolStore, name string, st *spoolState, maxID, replayed int64) bool {
+	deleted, err := store.DeleteUpTo(name, maxID)
+	if err != nil {
+		st.lastError = err.Error()
+		slog.Warn("Failed to delete replayed northbound spool entries", "adapter", name, "error", err)
+		st.loaded = false
+		return false
+	}
+	st.replayed += replayed
+	st.depth -= int(deleted)
+	if st.depth < 0 {
+		st.depth = 0
+	}
+	st.lastError = ""
+	if st.depth == 0 {
+		slog.Info("Northbound spool drained", "adapter", name, "replayed", st.replayed)
+	}
+	return true
+}
+
+func (st *spoolState) resetHandoffLocked() {
+	st.handoffID = 0
+	st.handoffCount = 0
+	st.handoffAdapter = nil
+}
+
+// spoolEntrySender 还原暂存消息（保留原始时间戳）并返回发送函数
+func spoolEntrySender(adapter adapters.NorthboundAdapter, entry *models.NorthboundSpoolEntry) (func() error, error) {
+	switch entry.Kind {
+	case SpoolKindData:
+		data := &models.CollectData{}
+		if err := json.Unmarshal(entry.Payload, data); err != nil {
+			return nil, err
+		}
+		if data.Timestamp.IsZero() {
+			data.Timestamp = entry.CreatedAt
+		}
+		data.EnsureFields()
+		return func() error { return adapter.Send(data) }, nil
+	case SpoolKindAlarm:
+		alarm := &models.AlarmPayload{}
+		if err := json.Unmarshal(entry.Payload, alarm); err != nil {
+			return nil, err
+		}
+		if alarm.Timestamp.IsZero() {
+			alarm.Timestamp = entry.CreatedAt
+		}
+		return func() error { return adapter.SendAlarm(alarm) }, nil
+	default:
+		return nil, fmt.Errorf("unknown spool kind %q", entry.Kind)
+	}
+}
+
+func (m *NorthboundManager) spoolSnapshotFor(name string) (spoolSnapshot, bool) {
+	m.mu.RLock()
+	enabled := m.spool != nil
+	st := m.spoolStates[name]
+	m.mu.RUnlock()
+	if !enabled || st == nil {
+		return spoolSnapshot{}, enabled
+	}
+	return st.snapshot(), true
+}
+
+func applySpoolSnapshot(stats *adapters.RuntimeStatsSnapshot, spool spoolSnapshot) {
+	stats.SpoolDepth = spool.depth
+	stats.SpoolSpooled = spool.spooled
+	stats.SpoolReplayed = spool.replayed
+	stats.SpoolDropped = spool.dropped
+	stats.SpoolLastReplayAt = spool.lastReplayAt
+	stats.SpoolError = spool.lastError
+}
@@ -0,0 +1,327 @@
+package northbound
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/adapters"
+)
+
+// memorySpoolStore 内存版暂存存储
+type memorySpoolStore struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries []*models.NorthboundSpoolEntry
+}
+
+func (s *memorySpoolStore) Append(adapter, kind string, payload []byte, createdAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.entries = append(s.entries, &models.NorthboundSpoolEntry{ID: s.nextID, Adapter: adapter, Kind: kind, Payload: payload, CreatedAt: createdAt})
+	return s.nextID, nil
+}
+
+func (s *memorySpoolStore) List(adapter string, limit int) ([]*models.NorthboundSpoolEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*models.NorthboundSpoolEntry, 0, limit)
+	for _, entry := range s.entries {
+		if entry.Adapter == adapter && len(out) < limit {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+func (s *memorySpoolStore) DeleteUpTo(adapter string, maxID int64) (int64, error) {
+	return s.filter(func(entry *models.NorthboundSpoolEntry) bool {
+		return entry.Adapter == adapter && entry.ID <= maxID
+	}), nil
+}
+
+func (s *memorySpoolStore) Count(adapter string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, entry := range s.entries {
+		if entry.Adapter == adapter {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memorySpoolStore) Trim(adapter string, maxEntries int, maxAge time.Duration) (int64, error) {
+	count, _ := s.Count(adapter)
+	excess := count - maxEntries
+	if maxEntries <= 0 || excess <= 0 {
+		return 0, nil
+	}
+	return s.filter(func(entry *models.NorthboundSpoolEntry) bool {
+		if entry.Adapter != adapter || excess <= 0 {
+			return false
+		}
+		excess--
+		return true
+	}), nil
+}
+
+func (s *memorySpoolStore) filter(drop func(*models.NorthboundSpoolEntry) bool) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	var dropped int64
+	for _, entry := range s.entries {
+		if drop(entry) {
+			dropped++
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	s.entries = kept
+	return dropped
+}
+
+// recordingAdapter 记录发送顺序
+type recordingAdapter struct {
+	fakeAdapter
+	mu     sync.Mutex
+	data   []*models.CollectData
+	alarms []*models.AlarmPayload
+	order  []string
+}
+
+func (r *recordingAdapter) Send(data *models.CollectData) error {
+	if err := r.fakeAdapter.Send(data); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data = append(r.data, data)
+	r.order = append(r.order, "data:"+data.Fields["seq"])
+	return nil
+}
+
+func (r *recordingAdapter) SendAlarm(alarm *models.AlarmPayload) error {
+	if err := r.fakeAdapter.SendAlarm(alarm); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alarms = append(r.alarms, alarm)
+	r.order = append(r.order, "alarm:"+alarm.FieldName)
+	return nil
+}
+
+func TestNorthboundManager_SpoolWhileDisconnectedAndReplayInOrder(t *testing.T) {
+	store := &memorySpoolStore{}
+	mgr := NewNorthboundManager()
+	mgr.SetSpool(store, SpoolConfig{ReplayBatch: 2})
+	adapter := &recordingAdapter{fakeAdapter: fakeAdapter{name: "a1", enabled: true}}
+	mgr.RegisterAdapter("a1", adapter)
+
+	ts := time.Now().Add(-time.Hour).Truncate(time.Second)
+	mgr.SendData(&models.CollectData{DeviceID: 1, Timestamp: ts, Fields: map[string]string{"seq": "1"}})
+	mgr.SendAlarm(&models.AlarmPayload{DeviceID: 1, FieldName: "Ua", Timestamp: ts.Add(time.Second)})
+	mgr.SendData(&models.CollectData{DeviceID: 1, Timestamp: ts.Add(2 * time.Second), Fields: map[string]string{"seq": "2"}})
+
+	if atomic.LoadInt32(&adapter.sendCalls) != 0 || atomic.LoadInt32(&adapter.alarmCalls) != 0 {
+		t.Fatal("disconnected adapter should not be called directly")
+	}
+	if status := mgr.RuntimeStatus("a1"); status.SpoolDepth != 3 || !status.Pending {
+		t.Fatalf("unexpected status while disconnected: %+v", status)
+	}
+
+	// 恢复连接后，积压未清空前新消息也要排在积压之后
+	adapter.connected = true
+	mgr.SendData(&models.CollectData{DeviceID: 1, Timestamp: time.Now(), Fields: map[string]string{"seq": "3"}})
+	for i := 0; i < 3 && mgr.RuntimeStatus("a1").SpoolDepth > 0; i++ {
+		mgr.ReplaySpool()
+	}
+
+	want := []string{"data:1", "alarm:Ua", "data:2", "data:3"}
+	if len(adapter.order) != len(want) {
+		t.Fatalf("order = %v, want %v", adapter.order, want)
+	}
+	for i := range want {
+		if adapter.order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", adapter.order, want)
+		}
+	}
+	if !adapter.data[0].Timestamp.Equal(ts) || !adapter.alarms[0].Timestamp.Equal(ts.Add(time.Second)) {
+		t.Fatalf("original timestamps not preserved: data=%v alarm=%v", adapter.data[0].Timestamp, adapter.alarms[0].Timestamp)
+	}
+
+	status := mgr.RuntimeStatus("a1")
+	if status.SpoolDepth != 0 || status.SpoolReplayed != 4 {
+		t.Fatalf("unexpected status after replay: %+v", status)
+	}
+	stats := mgr.GetStats()["a1"]
+	if stats["spool_replayed"] != int64(4) || stats["spool_depth"] != 0 {
+		t.Fatalf("unexpected spool stats: %v", stats)
+	}
+
+	// 积压清空后恢复直发
+	mgr.SendData(&models.CollectData{DeviceID: 1, Fields: map[string]string{"seq": "4"}})
+	if got := adapter.order[len(adapter.order)-1]; got != "data:4" {
+		t.Fatalf("expected direct send after drain, got %v", adapter.order)
+	}
+}
+
+func TestNorthboundManager_SpoolWhenSendFailsAndBreakerOpens(t *testing.T) {
+	store := &memorySpoolStore{}
+	mgr := NewNorthboundManager()
+	mgr.SetSpool(store, SpoolConfig{})
+	adapter := &recordingAdapter{fakeAdapter: fakeAdapter{name: "a1", enabled: true, connected: true, fail: true}}
+	mgr.RegisterAdapter("a1", adapter)
+
+	for i := 0; i < DefaultBreakerConfig.FailureThreshold+2; i++ {
+		mgr.SendData(&models.CollectData{DeviceID: 1, Fields: map[string]string{"seq": "x"}})
+	}
+	if got := atomic.LoadInt32(&adapter.sendCalls); got != 1 {
+		t.Fatalf("sendCalls = %d, want 1 (later messages queue behind the spool)", got)
+	}
+	if depth := mgr.RuntimeStatus("a1").SpoolDepth; depth != DefaultBreakerConfig.FailureThreshold+2 {
+		t.Fatalf("spool depth = %d", depth)
+	}
+
+	// 补发持续失败直到熔断打开，之后不再调用适配器
+	for i := 0; i < DefaultBreakerConfig.FailureThreshold+2; i++ {
+		mgr.ReplaySpool()
+	}
+	if state := mgr.GetBreakerState("a1").String(); state != "open" {
+		t.Fatalf("breaker state = %s, want open", state)
+	}
+	if got := atomic.LoadInt32(&adapter.sendCalls); got != int32(DefaultBreakerConfig.FailureThreshold) {
+		t.Fatalf("sendCalls = %d, want %d", got, DefaultBreakerConfig.FailureThreshold)
+	}
+}
+
+func TestNorthboundManager_SpoolTrimsToMaxEntries(t *testing.T) {
+	store := &memorySpoolStore{}
+	mgr := NewNorthboundManager()
+	mgr.SetSpool(store, SpoolConfig{MaxEntries: 2})
+	mgr.RegisterAdapter("a1", &fakeAdapter{name: "a1", enabled: true})
+
+	for i := 0; i < 5; i++ {
+		mgr.SendAlarm(&models.AlarmPayload{DeviceID: 1})
+	}
+	if count, _ := store.Count("a1"); count != 2 {
+		t.Fatalf("stored = %d, want 2", count)
+	}
+	stats := mgr.GetStats()["a1"]
+	if stats["spool_depth"] != 2 || stats["spool_dropped"] != int64(3) {
+		t.Fatalf("unexpected stats: %v", stats)
+	}
+}
+
+func TestNorthboundManager_SpoolResumesDepthFromStore(t *testing.T) {
+	store := &memorySpoolStore{}
+	_, _ = store.Append("a1", SpoolKindData, []byte(`{"device_id":1,"fields":{"seq":"1"}}`), time.Now())
+	_, _ = store.Append("a1", "bogus", []byte(`{}`), time.Now())
+
+	mgr := NewNorthboundManager()
+	mgr.SetSpool(store, SpoolConfig{})
+	adapter := &recordingAdapter{fakeAdapter: fakeAdapter{name: "a1", enabled: true, connected: true}}
+	mgr.RegisterAdapter("a1", adapter)
+
+	mgr.ReplaySpool()
+	if len(adapter.order) != 1 || adapter.order[0] != "data:1" {
+		t.Fatalf("order = %v", adapter.order)
+	}
+	if count, _ := store.Count("a1"); count != 0 {
+		t.Fatalf("stored = %d, want 0", count)
+	}
+}
+
+// queuedAdapter 模拟 Send 只入有上限内存队列的适配器，flush 表示队列已发送完成
+type queuedAdapter struct {
+	recordingAdapter
+	queueCap int
+	queued   int
+}
+
+func (q *queuedAdapter) Send(data *models.CollectData) error {
+	q.queued++
+	return q.recordingAdapter.Send(data)
+}
+
+func (q *queuedAdapter) PendingQueue() adapters.PendingQueueState {
+	return adapters.PendingQueueState{Data: q.queued, DataCap: q.queueCap, AlarmCap: q.queueCap}
+}
+
+func (q *queuedAdapter) flush() { q.queued = 0 }
+
+func TestNorthboundManager_SpoolReplayWaitsForQueueDrain(t *testing.T) {
+	store := &memorySpoolStore{}
+	for i := 1; i <= 5; i++ {
+		_, _ = store.Append("a1", SpoolKindData, []byte(`{"device_id":1,"fields":{"seq":"`+string(rune('0'+i))+`"}}`), time.Now())
+	}
+	mgr := NewNorthboundManager()
+	mgr.SetSpool(store, SpoolConfig{ReplayBatch: 10})
+	adapter := &queuedAdapter{recordingAdapter: recordingAdapter{fakeAdapter: fakeAdapter{name: "a1", enabled: true, connected: true}}, queueCap: 2}
+	mgr.RegisterAdapter("a1", adapter)
+
+	// 每轮只交付队列剩余容量，且队列排空前不删除暂存
+	mgr.ReplaySpool()
+	mgr.ReplaySpool()
+	if len(adapter.order) != 2 {
+		t.Fatalf("order = %v, want 2 handed off", adapter.order)
+	}
+	if count, _ := store.Count("a1"); count != 5 {
+		t.Fatalf("stored = %d, want 5 until the queue drains", count)
+	}
+
+	// 队列满时新消息进入暂存而不是挤掉队列中的补发数据
+	mgr.SendData(&models.CollectData{DeviceID: 1, Fields: map[string]string{"seq": "6"}})
+	if count, _ := store.Count("a1"); count != 6 {
+		t.Fatalf("stored = %d, want 6", count)
+	}
+
+	for i := 0; i < 10 && mgr.RuntimeStatus("a1").SpoolDepth > 0; i++ {
+		adapter.flush()
+		mgr.ReplaySpool()
+	}
+	want := []string{"data:1", "data:2", "data:3", "data:4", "data:5", "data:6"}
+	if len(adapter.order) != len(want) {
+		t.Fatalf("order = %v, want %v", adapter.order, want)
+	}
+	for i := range want {
+		if adapter.order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", adapter.order, want)
+		}
+	}
+	if status := mgr.RuntimeStatus("a1"); status.SpoolDepth != 0 || status.SpoolReplayed != 6 {
+		t.Fatalf("unexpected status after replay: %+v", status)
+	}
+}
+
+func TestNorthboundManager_SpoolReplayResendsAfterAdapterReplaced(t *testing.T) {
+	store := &memorySpoolStore{}
+	_, _ = store.Append("a1", SpoolKindData, []byte(`{"device_id":1,"fields":{"seq":"1"}}`), time.Now())
+	mgr := NewNorthboundManager()
+	mgr.SetSpool(store, SpoolConfig{})
+	first := &queuedAdapter{recordingAdapter: recordingAdapter{fakeAdapter: fakeAdapter{name: "a1", enabled: true, connected: true}}, queueCap: 10}
+	mgr.RegisterAdapter("a1", first)
+	mgr.ReplaySpool()
+
+	// 交付后适配器被重建，旧队列中的消息未确认发送，暂存需重新补发
+	second := &queuedAdapter{recordingAdapter: recordingAdapter{fakeAdapter: fakeAdapter{name: "a1", enabled: true, connected: true}}, queueCap: 10}
+	mgr.RegisterAdapter("a1", second)
+	mgr.ReplaySpool()
+	if len(second.order) != 1 || second.order[0] != "data:1" {
+		t.Fatalf("order = %v, want the unacked entry resent", second.order)
+	}
+	if count, _ := store.Count("a1"); count != 1 {
+		t.Fatalf("stored = %d, want 1 until the new queue drains", count)
+	}
+	second.flush()
+	mgr.ReplaySpool()
+	if count, _ := store.Count("a1"); count != 0 {
+		t.Fatalf("stored = %d, want 0", count)
+	}
+}
@@ -0,0 +1,85 @@
+package parquet
+
+import "encoding/binary"
+
+// Thrift compact protocol 类型编号
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter 只实现文件元数据用到的 compact protocol 子集
+type thriftWriter struct {
+	buf       []byte
+	lastField int16
+	stack     []int16
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	delta := id - t.lastField
+	if delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	t.lastField = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.elemBinary(s)
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.elemBegin()
+}
+
+// elemBegin 开始一个列表中的结构体元素（无字段头）
+func (t *thriftWriter) elemBegin() {
+	t.stack = append(t.stack, t.lastField)
+	t.lastField = 0
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf = append(t.buf, 0)
+	t.lastField = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftWriter) listBegin(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elemType)
+		return
+	}
+	t.buf = append(t.buf, 0xF0|elemType)
+	t.buf = binary.AppendUvarint(t.buf, uint64(size))
+}
+
+func (t *thriftWriter) elemI32(v int32) {
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) elemBinary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
@@ -0,0 +1,373 @@
+// Package parquet 提供按行追加、按行组落盘的最小 Parquet 写入器（扁平 schema、PLAIN 编码）。
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var magic = []byte("PAR1")
+
+// DefaultRowGroupSize 每个行组缓存的行数，决定写入时的内存上限
+const DefaultRowGroupSize = 32768
+
+// Type 物理类型
+type Type int32
+
+const (
+	TypeInt64     Type = 2
+	TypeDouble    Type = 5
+	TypeByteArray Type = 6
+)
+
+// ConvertedType 逻辑注解（旧式 converted_type）
+type ConvertedType int32
+
+const (
+	ConvertedNone            ConvertedType = -1
+	ConvertedUTF8            ConvertedType = 0
+	ConvertedTimestampMillis ConvertedType = 9
+)
+
+// Codec 页压缩方式
+type Codec int32
+
+const (
+	CodecUncompressed Codec = 0
+	CodecGzip         Codec = 2
+)
+
+const (
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	pageTypeData = 0
+)
+
+// Column 列定义
+type Column struct {
+	Name      string
+	Type      Type
+	Converted ConvertedType
+	Optional  bool
+}
+
+type columnBuffer struct {
+	values    []byte
+	defLevels []bool
+}
+
+type columnChunkMeta struct {
+	offset            int64
+	numValues         int64
+	uncompressedBytes int64
+	compressedBytes   int64
+}
+
+type rowGroupMeta struct {
+	columns   []columnChunkMeta
+	numRows   int64
+	totalSize int64
+}
+
+// Writer 顺序写出 Parquet 文件，无需 Seek；Close 时写入文件尾
+type Writer struct {
+	w            io.Writer
+	offset       int64
+	columns      []Column
+	codec        Codec
+	rowGroupSize int
+	buffers      []columnBuffer
+	pending      int
+	rowGroups    []rowGroupMeta
+	numRows      int64
+	closed       bool
+}
+
+// NewWriter 写出文件头并返回写入器，rowGroupSize <= 0 时使用 DefaultRowGroupSize
+func NewWriter(w io.Writer, columns []Column, codec Codec, rowGroupSize int) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+	for _, col := range columns {
+		switch col.Type {
+		case TypeInt64, TypeDouble, TypeByteArray:
+		default:
+			return nil, fmt.Errorf("parquet: column %s has unsupported type %d", col.Name, col.Type)
+		}
+	}
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+	pw := &Writer{
+		w:            w,
+		columns:      columns,
+		codec:        codec,
+		rowGroupSize: rowGroupSize,
+		buffers:      make([]columnBuffer, len(columns)),
+	}
+	if err := pw.write(magic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// WriteRow 追加一行：int64 / float64 / string，可选列可传 nil
+func (pw *Writer) WriteRow(values ...any) error {
+	if pw.closed {
+		return errors.New("parquet: writer closed")
+	}
+	if len(values) != len(pw.columns) {
+		return fmt.Errorf("parquet: got %d values, want %d", len(values), len(pw.columns))
+	}
+	// 先整体校验，避免半行写入导致各列行数不一致
+	for i, col := range pw.columns {
+		if err := checkValue(col, values[i]); err != nil {
+			return err
+		}
+	}
+	for i, col := range pw.columns {
+		buf := &pw.buffers[i]
+		v := values[i]
+		if col.Optional {
+			buf.defLevels = append(buf.defLevels, v != nil)
+		}
+		if v != nil {
+			appendPlain(buf, col, v)
+		}
+	}
+	pw.pending++
+	pw.numRows++
+	if pw.pending >= pw.rowGroupSize {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+func checkValue(col Column, v any) error {
+	if v == nil {
+		if !col.Optional {
+			return fmt.Errorf("parquet: column %s is required", col.Name)
+		}
+		return nil
+	}
+	ok := false
+	switch col.Type {
+	case TypeInt64:
+		_, ok = v.(int64)
+	case TypeDouble:
+		_, ok = v.(float64)
+	case TypeByteArray:
+		_, ok = v.(string)
+	}
+	if !ok {
+		return fmt.Errorf("parquet: column %s does not accept %T", col.Name, v)
+	}
+	return nil
+}
+
+func appendPlain(buf *columnBuffer, col Column, v any) {
+	switch col.Type {
+	case TypeInt64:
+		buf.values = binary.LittleEndian.AppendUint64(buf.values, uint64(v.(int64)))
+	case TypeDouble:
+		buf.values = binary.LittleEndian.AppendUint64(buf.values, math.Float64bits(v.(float64)))
+	case TypeByteArray:
+		s := v.(string)
+		buf.values = binary.LittleEndian.AppendUint32(buf.values, uint32(len(s)))
+		buf.values = append(buf.values, s...)
+	}
+}
+
+// Close 写出剩余行组与文件尾；不关闭底层 io.Writer
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	if pw.pending > 0 {
+		if err := pw.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	footer := pw.encodeFileMetaData()
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	if err := pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	return pw.write(magic)
+}
+
+func (pw *Writer) flushRowGroup() error {
+	group := rowGroupMeta{
+		columns: make([]columnChunkMeta, len(pw.columns)),
+		numRows: int64(pw.pending),
+	}
+	for i, col := range pw.columns {
+		buf := &pw.buffers[i]
+		body := buildPageBody(col, buf)
+		data := body
+		if pw.codec == CodecGzip {
+			compressed, err := gzipBytes(body)
+			if err != nil {
+				return err
+			}
+			data = compressed
+		}
+		header := encodePageHeader(len(body), len(data), pw.pending)
+
+		meta := columnChunkMeta{
+			offset:            pw.offset,
+			numValues:         int64(pw.pending),
+			uncompressedBytes: int64(len(header) + len(body)),
+			compressedBytes:   int64(len(header) + len(data)),
+		}
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+		group.columns[i] = meta
+		group.totalSize += meta.uncompressedBytes
+
+		buf.values = buf.values[:0]
+		buf.defLevels = buf.defLevels[:0]
+	}
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.pending = 0
+	return nil
+}
+
+func (pw *Writer) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	return err
+}
+
+// buildPageBody 可选列先写定义级别（4 字节长度 + RLE），再写非空值
+func buildPageBody(col Column, buf *columnBuffer) []byte {
+	if !col.Optional {
+		return buf.values
+	}
+	levels := encodeRLEBits(buf.defLevels)
+	body := make([]byte, 0, 4+len(levels)+len(buf.values))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(levels)))
+	body = append(body, levels...)
+	return append(body, buf.values...)
+}
+
+// encodeRLEBits 位宽为 1 的 RLE 游程编码（RLE/bit-packed hybrid 的 RLE 分支）
+func encodeRLEBits(levels []bool) []byte {
+	var out []byte
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if levels[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+func gzipBytes(p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodePageHeader(uncompressed, compressed, numValues int) []byte {
+	var t thriftWriter
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(uncompressed))
+	t.i32(3, int32(compressed))
+	t.structBegin(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.structEnd()
+	t.stop()
+	return t.buf
+}
+
+func (pw *Writer) encodeFileMetaData() []byte {
+	var t thriftWriter
+	t.i32(1, 1)
+
+	t.listBegin(2, thriftStruct, len(pw.columns)+1)
+	t.elemBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.structEnd()
+	for _, col := range pw.columns {
+		t.elemBegin()
+		t.i32(1, int32(col.Type))
+		repetition := int32(repetitionRequired)
+		if col.Optional {
+			repetition = repetitionOptional
+		}
+		t.i32(3, repetition)
+		t.binary(4, col.Name)
+		if col.Converted != ConvertedNone {
+			t.i32(6, int32(col.Converted))
+		}
+		t.structEnd()
+	}
+
+	t.i64(3, pw.numRows)
+
+	t.listBegin(4, thriftStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		t.elemBegin()
+		t.listBegin(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			col := pw.columns[i]
+			t.elemBegin()
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, int32(col.Type))
+			t.listBegin(2, thriftI32, 2)
+			t.elemI32(encodingPlain)
+			t.elemI32(encodingRLE)
+			t.listBegin(3, thriftBinary, 1)
+			t.elemBinary(col.Name)
+			t.i32(4, int32(pw.codec))
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.uncompressedBytes)
+			t.i64(7, chunk.compressedBytes)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.totalSize)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+
+	t.binary(6, "xunjiFsu")
+	t.stop()
+	return t.buf
+}
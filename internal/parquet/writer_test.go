@@ -0,0 +1,171 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+)
+
+// thriftReader 测试用的 compact protocol 通用解码器，结构体解为 map[字段ID]值
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		items := make([]any, size)
+		for i := range items {
+			items[i] = r.value(header & 0x0F)
+		}
+		return items
+	case thriftStruct:
+		fields := map[int16]any{}
+		var last int16
+		for {
+			header := r.buf[r.pos]
+			r.pos++
+			if header == 0 {
+				return fields
+			}
+			id := last + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(r.varint())
+			}
+			fields[id] = r.value(header & 0x0F)
+			last = id
+		}
+	}
+	panic("unsupported thrift type")
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	for _, codec := range []Codec{CodecUncompressed, CodecGzip} {
+		var out bytes.Buffer
+		w, err := NewWriter(&out, []Column{
+			{Name: "id", Type: TypeInt64, Converted: ConvertedNone},
+			{Name: "name", Type: TypeByteArray, Converted: ConvertedUTF8},
+			{Name: "num", Type: TypeDouble, Converted: ConvertedNone, Optional: true},
+		}, codec, 2)
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		rows := [][]any{{int64(1), "a", 1.5}, {int64(2), "bb", nil}, {int64(3), "", 3.0}}
+		for _, row := range rows {
+			if err := w.WriteRow(row...); err != nil {
+				t.Fatalf("WriteRow() error = %v", err)
+			}
+		}
+		if err := w.WriteRow(int64(4), nil, nil); err == nil {
+			t.Fatalf("expected required column error")
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		file := out.Bytes()
+		if !bytes.HasPrefix(file, magic) || !bytes.HasSuffix(file, magic) {
+			t.Fatalf("missing magic")
+		}
+		footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+		footer := (&thriftReader{buf: file[len(file)-8-footerLen:]}).value(thriftStruct).(map[int16]any)
+		if footer[3].(int64) != 3 || len(footer[2].([]any)) != 4 {
+			t.Fatalf("footer num_rows=%v schema=%v", footer[3], footer[2])
+		}
+		groups := footer[4].([]any)
+		if len(groups) != 2 {
+			t.Fatalf("row groups=%d, want 2", len(groups))
+		}
+
+		var ids []int64
+		var names []string
+		var nums []any
+		for _, g := range groups {
+			group := g.(map[int16]any)
+			numRows := int(group[3].(int64))
+			for i, c := range group[1].([]any) {
+				meta := c.(map[int16]any)[3].(map[int16]any)
+				if meta[4].(int64) != int64(codec) {
+					t.Fatalf("codec=%v, want %d", meta[4], codec)
+				}
+				page := &thriftReader{buf: file, pos: int(meta[9].(int64))}
+				header := page.value(thriftStruct).(map[int16]any)
+				body := file[page.pos : page.pos+int(header[3].(int64))]
+				if codec == CodecGzip {
+					zr, err := gzip.NewReader(bytes.NewReader(body))
+					if err != nil {
+						t.Fatalf("gzip page: %v", err)
+					}
+					body, _ = io.ReadAll(zr)
+				}
+				if len(body) != int(header[2].(int64)) {
+					t.Fatalf("page size=%d, want %v", len(body), header[2])
+				}
+				switch i {
+				case 0:
+					for j := 0; j < numRows; j++ {
+						ids = append(ids, int64(binary.LittleEndian.Uint64(body[j*8:])))
+					}
+				case 1:
+					for len(body) > 0 {
+						n := int(binary.LittleEndian.Uint32(body))
+						names = append(names, string(body[4:4+n]))
+						body = body[4+n:]
+					}
+				case 2:
+					levelsLen := int(binary.LittleEndian.Uint32(body))
+					levels := &thriftReader{buf: body[4 : 4+levelsLen]}
+					values := body[4+levelsLen:]
+					for levels.pos < len(levels.buf) {
+						run := int(levels.uvarint() >> 1)
+						defined := levels.buf[levels.pos] == 1
+						levels.pos++
+						for k := 0; k < run; k++ {
+							if !defined {
+								nums = append(nums, nil)
+								continue
+							}
+							nums = append(nums, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+							values = values[8:]
+						}
+					}
+				}
+			}
+		}
+
+		for i, row := range rows {
+			if ids[i] != row[0] || names[i] != row[1] || nums[i] != row[2] {
+				t.Fatalf("codec %d row %d = (%v, %q, %v), want %v", codec, i, ids[i], names[i], nums[i], row)
+			}
+		}
+	}
+}
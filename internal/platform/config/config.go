@@ -53,6 +53,11 @@ type Config struct {
 	// 北向插件目录
 	NorthboundPluginsDir            string        `json:"northbound_plugins_dir"`
 	NorthboundMQTTReconnectInterval time.Duration `json:"northbound_mqtt_reconnect_interval"`
+	// 北向断线/熔断暂存（落盘 data.db，恢复后按序补发）
+	NorthboundSpoolEnabled     bool          `json:"northbound_spool_enabled"`
+	NorthboundSpoolMaxEntries  int           `json:"northbound_spool_max_entries"`
+	NorthboundSpoolMaxAge      time.Duration `json:"northbound_spool_max_age"`
+	NorthboundSpoolReplayBatch int           `json:"northbound_spool_replay_batch"`
 
 	// 驱动执行配置
 	DriverCallTimeout       time.Duration `json:"driver_call_timeout"`
@@ -63,6 +68,14 @@ type Config struct {
 	DriverTCPDialRetries    int           `json:"driver_tcp_dial_retries"`
 	DriverTCPDialBackoff    time.Duration `json:"driver_tcp_dial_backoff"`
 	DriverTCPReadTimeout    time.Duration `json:"driver_tcp_read_timeout"`
+	// 驱动按资源创建的插件实例空闲回收时间（0 表示不回收）
+	DriverInstanceIdleTimeout time.Duration `json:"driver_instance_idle_timeout"`
+	// 驱动沙箱：线性内存页上限（64KiB/页）与单次调用的 wasm 函数调用预算，0 表示不限制
+	DriverMaxMemoryPages int `json:"driver_max_memory_pages"`
+	DriverMaxCallBudget  int `json:"driver_max_call_budget"`
+	// 驱动在窗口期内崩溃（trap/超限）达到次数后隔离，重载驱动后解除；次数为 0 表示不隔离
+	DriverQuarantineCrashes int           `json:"driver_quarantine_crashes"`
+	DriverQuarantineWindow  time.Duration `json:"driver_quarantine_window"`
 
 	// 阈值缓存配置
 	ThresholdCacheEnabled bool          `json:"threshold_cache_enabled"`
@@ -71,6 +84,26 @@ type Config struct {
 	// 内存数据库限制
 	MaxDataPoints int `json:"max_data_points"`
 	MaxDataCache  int `json:"max_data_cache"`
+
+	// 降采样汇总保留天数（1 分钟 / 1 小时层级）
+	RollupMinuteRetentionDays int `json:"rollup_minute_retention_days"`
+	RollupHourRetentionDays   int `json:"rollup_hour_retention_days"`
+
+	// data.db 磁盘保护：字节预算（MB，0 表示不限制）与紧急模式磁盘使用率阈值
+	DataMaxDiskMB            int `json:"data_max_disk_mb"`
+	DataDiskEmergencyPercent int `json:"data_disk_emergency_percent"`
+	DataDiskResumePercent    int `json:"data_disk_resume_percent"`
+
+	// 历史写入日志：两次落盘之间的历史行追加到 fsync 日志，断电后重启回放；
+	// fsync 间隔为 0 表示每次写入都 fsync，否则按间隔或累计条数批量 fsync
+	DataJournalEnabled       bool          `json:"data_journal_enabled"`
+	DataJournalFsyncInterval time.Duration `json:"data_journal_fsync_interval"`
+	DataJournalFsyncBatch    int           `json:"data_journal_fsync_batch"`
+
+	// 定时备份：目录（可指向 U 盘挂载点）、周期（0 表示关闭）与保留份数
+	BackupDir      string        `json:"backup_dir"`
+	BackupInterval time.Duration `json:"backup_interval"`
+	BackupKeep     int           `json:"backup_keep"`
 }
 
 // DefaultConfig 返回默认配置
@@ -100,6 +133,10 @@ func DefaultConfig() *Config {
 		DriversDir:                      "drivers",
 		NorthboundPluginsDir:            "plugin_north",
 		NorthboundMQTTReconnectInterval: 5 * time.Second,
+		NorthboundSpoolEnabled:          false,
+		NorthboundSpoolMaxEntries:       10000,
+		NorthboundSpoolMaxAge:           24 * time.Hour,
+		NorthboundSpoolReplayBatch:      100,
 		DriverCallTimeout:               0,
 		DriverSerialReadTimeout:         0,
 		DriverSerialOpenRetries:         0,
@@ -108,10 +145,26 @@ func DefaultConfig() *Config {
 		DriverTCPDialRetries:            0,
 		DriverTCPDialBackoff:            0,
 		DriverTCPReadTimeout:            0,
+		DriverInstanceIdleTimeout:       10 * time.Minute,
+		DriverMaxMemoryPages:            0,
+		DriverMaxCallBudget:             0,
+		DriverQuarantineCrashes:         5,
+		DriverQuarantineWindow:          10 * time.Minute,
 		ThresholdCacheEnabled:           true,
 		ThresholdCacheTTL:               time.Minute,
 		MaxDataPoints:                   20000,
 		MaxDataCache:                    15000,
+		RollupMinuteRetentionDays:       90,
+		RollupHourRetentionDays:         730,
+		DataMaxDiskMB:                   0,
+		DataDiskEmergencyPercent:        95,
+		DataDiskResumePercent:           90,
+		DataJournalEnabled:              false,
+		DataJournalFsyncInterval:        time.Second,
+		DataJournalFsyncBatch:           256,
+		BackupDir:                       "backups",
+		BackupInterval:                  0,
+		BackupKeep:                      7,
 	}
 }
 
@@ -153,6 +206,7 @@ func applyFileConfig(cfg *Config) error {
 	applyNorthboundFileConfig(cfg, flatCfg)
 	applyCollectorFileConfig(cfg, flatCfg)
 	applyDataLimitFileConfig(cfg, flatCfg)
+	applyBackupFileConfig(cfg, flatCfg)
 
 	return nil
 }
@@ -197,6 +251,11 @@ func applyDriverFileConfig(cfg *Config, flatCfg map[string]string) {
 	applyPositiveIntText(&cfg.DriverTCPDialRetries, flatCfg["drivers.tcp_dial_retries"])
 	applyDurationText(&cfg.DriverTCPDialBackoff, flatCfg["drivers.tcp_dial_backoff"])
 	applyDurationText(&cfg.DriverTCPReadTimeout, flatCfg["drivers.tcp_read_timeout"])
+	applyDurationText(&cfg.DriverInstanceIdleTimeout, flatCfg["drivers.instance_idle_timeout"])
+	applyPositiveIntText(&cfg.DriverMaxMemoryPages, flatCfg["drivers.max_memory_pages"])
+	applyPositiveIntText(&cfg.DriverMaxCallBudget, flatCfg["drivers.max_call_budget"])
+	applyPositiveIntText(&cfg.DriverQuarantineCrashes, flatCfg["drivers.quarantine_crashes"])
+	applyDurationText(&cfg.DriverQuarantineWindow, flatCfg["drivers.quarantine_window"])
 }
 
 func applyNorthboundFileConfig(cfg *Config, flatCfg map[string]string) {
@@ -206,6 +265,10 @@ func applyNorthboundFileConfig(cfg *Config, flatCfg map[string]string) {
 
 	setStringIfNotEmpty(&cfg.NorthboundPluginsDir, flatCfg["northbound.plugins_dir"])
 	applyDurationText(&cfg.NorthboundMQTTReconnectInterval, flatCfg["northbound.mqtt_reconnect_interval"])
+	applyBoolText(&cfg.NorthboundSpoolEnabled, flatCfg["northbound.spool_enabled"])
+	applyPositiveIntText(&cfg.NorthboundSpoolMaxEntries, flatCfg["northbound.spool_max_entries"])
+	applyDurationText(&cfg.NorthboundSpoolMaxAge, flatCfg["northbound.spool_max_age"])
+	applyPositiveIntText(&cfg.NorthboundSpoolReplayBatch, flatCfg["northbound.spool_replay_batch"])
 }
 
 func applyCollectorFileConfig(cfg *Config, flatCfg map[string]string) {
@@ -225,6 +288,24 @@ func applyDataLimitFileConfig(cfg *Config, flatCfg map[string]string) {
 
 	applyPositiveIntText(&cfg.MaxDataPoints, flatCfg["data.max_data_points"])
 	applyPositiveIntText(&cfg.MaxDataCache, flatCfg["data.max_data_cache"])
+	applyPositiveIntText(&cfg.RollupMinuteRetentionDays, flatCfg["data.rollup_minute_retention_days"])
+	applyPositiveIntText(&cfg.RollupHourRetentionDays, flatCfg["data.rollup_hour_retention_days"])
+	applyPositiveIntText(&cfg.DataMaxDiskMB, flatCfg["data.max_disk_mb"])
+	applyPositiveIntText(&cfg.DataDiskEmergencyPercent, flatCfg["data.disk_emergency_percent"])
+	applyPositiveIntText(&cfg.DataDiskResumePercent, flatCfg["data.disk_resume_percent"])
+	applyBoolText(&cfg.DataJournalEnabled, flatCfg["data.journal_enabled"])
+	applyDurationText(&cfg.DataJournalFsyncInterval, flatCfg["data.journal_fsync_interval"])
+	applyPositiveIntText(&cfg.DataJournalFsyncBatch, flatCfg["data.journal_fsync_batch"])
+}
+
+func applyBackupFileConfig(cfg *Config, flatCfg map[string]string) {
+	if cfg == nil {
+		return
+	}
+
+	setStringIfNotEmpty(&cfg.BackupDir, flatCfg["backup.dir"])
+	applyDurationText(&cfg.BackupInterval, flatCfg["backup.interval"])
+	applyPositiveIntText(&cfg.BackupKeep, flatCfg["backup.keep"])
 }
 
 func parseFlatYAML(data []byte) (map[string]string, error) {
@@ -344,6 +425,13 @@ func applyDurationText(dst *time.Duration, value string) {
 	}
 }
 
+func applyBoolText(dst *bool, value string) {
+	if dst == nil || value == "" {
+		return
+	}
+	*dst = parseBoolAcceptingOne(value)
+}
+
 func applyPositiveIntText(dst *int, value string) {
 	if dst == nil || value == "" {
 		return
@@ -373,6 +461,7 @@ func applyEnvConfig(cfg *Config) {
 	applyNorthboundEnvConfig(cfg, defaults)
 	applyThresholdEnvConfig(cfg)
 	applyDataLimitEnvConfig(cfg)
+	applyBackupEnvConfig(cfg)
 }
 
 func applyServerEnvConfig(cfg, defaults *Config) {
@@ -424,11 +513,20 @@ func applyDriverEnvConfig(cfg *Config) {
 	applyEnvInt(&cfg.DriverTCPDialRetries, "DRIVER_TCP_DIAL_RETRIES")
 	applyEnvDuration(&cfg.DriverTCPDialBackoff, "DRIVER_TCP_DIAL_BACKOFF")
 	applyEnvDuration(&cfg.DriverTCPReadTimeout, "DRIVER_TCP_READ_TIMEOUT")
+	applyEnvDuration(&cfg.DriverInstanceIdleTimeout, "DRIVER_INSTANCE_IDLE_TIMEOUT")
+	applyEnvInt(&cfg.DriverMaxMemoryPages, "DRIVER_MAX_MEMORY_PAGES")
+	applyEnvInt(&cfg.DriverMaxCallBudget, "DRIVER_MAX_CALL_BUDGET")
+	applyEnvInt(&cfg.DriverQuarantineCrashes, "DRIVER_QUARANTINE_CRASHES")
+	applyEnvDuration(&cfg.DriverQuarantineWindow, "DRIVER_QUARANTINE_WINDOW")
 }
 
 func applyNorthboundEnvConfig(cfg, defaults *Config) {
 	applyEnvString(&cfg.NorthboundPluginsDir, "NORTHBOUND_PLUGINS_DIR")
 	applyEnvDurationWithFallback(&cfg.NorthboundMQTTReconnectInterval, "NORTHBOUND_MQTT_RECONNECT_INTERVAL", defaults.NorthboundMQTTReconnectInterval, true)
+	applyEnvBool(&cfg.NorthboundSpoolEnabled, "NORTHBOUND_SPOOL_ENABLED")
+	applyEnvIntWithFallback(&cfg.NorthboundSpoolMaxEntries, "NORTHBOUND_SPOOL_MAX_ENTRIES", defaults.NorthboundSpoolMaxEntries)
+	applyEnvDurationWithFallback(&cfg.NorthboundSpoolMaxAge, "NORTHBOUND_SPOOL_MAX_AGE", defaults.NorthboundSpoolMaxAge, true)
+	applyEnvIntWithFallback(&cfg.NorthboundSpoolReplayBatch, "NORTHBOUND_SPOOL_REPLAY_BATCH", defaults.NorthboundSpoolReplayBatch)
 }
 
 func applyThresholdEnvConfig(cfg *Config) {
@@ -439,6 +537,20 @@ func applyThresholdEnvConfig(cfg *Config) {
 func applyDataLimitEnvConfig(cfg *Config) {
 	applyEnvInt(&cfg.MaxDataPoints, "MAX_DATA_POINTS")
 	applyEnvInt(&cfg.MaxDataCache, "MAX_DATA_CACHE")
+	applyEnvInt(&cfg.RollupMinuteRetentionDays, "ROLLUP_MINUTE_RETENTION_DAYS")
+	applyEnvInt(&cfg.RollupHourRetentionDays, "ROLLUP_HOUR_RETENTION_DAYS")
+	applyEnvInt(&cfg.DataMaxDiskMB, "DATA_MAX_DISK_MB")
+	applyEnvInt(&cfg.DataDiskEmergencyPercent, "DATA_DISK_EMERGENCY_PERCENT")
+	applyEnvInt(&cfg.DataDiskResumePercent, "DATA_DISK_RESUME_PERCENT")
+	applyEnvBool(&cfg.DataJournalEnabled, "DATA_JOURNAL_ENABLED")
+	applyEnvDuration(&cfg.DataJournalFsyncInterval, "DATA_JOURNAL_FSYNC_INTERVAL")
+	applyEnvInt(&cfg.DataJournalFsyncBatch, "DATA_JOURNAL_FSYNC_BATCH")
+}
+
+func applyBackupEnvConfig(cfg *Config) {
+	applyEnvString(&cfg.BackupDir, "BACKUP_DIR")
+	applyEnvDuration(&cfg.BackupInterval, "BACKUP_INTERVAL")
+	applyEnvInt(&cfg.BackupKeep, "BACKUP_KEEP")
 }
 
 func applyEnvString(dst *string, key string) {
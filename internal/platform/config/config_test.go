@@ -85,6 +85,12 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.MaxDataCache != 15000 {
 		t.Errorf("MaxDataCache = %d, want 15000", cfg.MaxDataCache)
 	}
+	if cfg.NorthboundSpoolEnabled {
+		t.Errorf("NorthboundSpoolEnabled = true, want false")
+	}
+	if cfg.NorthboundSpoolMaxEntries != 10000 || cfg.NorthboundSpoolMaxAge != 24*time.Hour {
+		t.Errorf("spool limits = %d/%v, want 10000/24h", cfg.NorthboundSpoolMaxEntries, cfg.NorthboundSpoolMaxAge)
+	}
 }
 
 func TestGetAllowedOrigins_Default(t *testing.T) {
@@ -376,10 +382,18 @@ drivers:
   tcp_dial_retries: 5
   tcp_dial_backoff: 700ms
   tcp_read_timeout: 8s
+  instance_idle_timeout: 0s
+  max_memory_pages: 256
+  max_call_budget: 100000
+  quarantine_crashes: 3
+  quarantine_window: 5m
 
 northbound:
   plugins_dir: "plugin_custom"
   mqtt_reconnect_interval: 9s
+  spool_enabled: false
+  spool_max_entries: 5000
+  spool_max_age: 48h
 
 collector:
   workers: 6
@@ -423,6 +437,15 @@ data:
 	if cfg.DriverCallTimeout != 2*time.Second {
 		t.Fatalf("DriverCallTimeout=%v, want 2s", cfg.DriverCallTimeout)
 	}
+	if cfg.DriverInstanceIdleTimeout != 0 {
+		t.Fatalf("DriverInstanceIdleTimeout=%v, want 0", cfg.DriverInstanceIdleTimeout)
+	}
+	if cfg.DriverMaxMemoryPages != 256 || cfg.DriverMaxCallBudget != 100000 {
+		t.Fatalf("driver limits pages=%d calls=%d, want 256/100000", cfg.DriverMaxMemoryPages, cfg.DriverMaxCallBudget)
+	}
+	if cfg.DriverQuarantineCrashes != 3 || cfg.DriverQuarantineWindow != 5*time.Minute {
+		t.Fatalf("driver quarantine crashes=%d window=%v, want 3/5m", cfg.DriverQuarantineCrashes, cfg.DriverQuarantineWindow)
+	}
 	if cfg.CollectorWorkers != 6 {
 		t.Fatalf("CollectorWorkers=%d, want 6", cfg.CollectorWorkers)
 	}
@@ -432,4 +455,10 @@ data:
 	if cfg.MaxDataCache != 120000 {
 		t.Fatalf("MaxDataCache=%d, want 120000", cfg.MaxDataCache)
 	}
+	if cfg.NorthboundSpoolEnabled || cfg.NorthboundSpoolMaxEntries != 5000 || cfg.NorthboundSpoolMaxAge != 48*time.Hour {
+		t.Fatalf("spool config = enabled:%v entries:%d age:%v", cfg.NorthboundSpoolEnabled, cfg.NorthboundSpoolMaxEntries, cfg.NorthboundSpoolMaxAge)
+	}
+	if cfg.NorthboundSpoolReplayBatch != 100 {
+		t.Fatalf("NorthboundSpoolReplayBatch=%d, want default 100", cfg.NorthboundSpoolReplayBatch)
+	}
 }
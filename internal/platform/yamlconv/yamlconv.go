@@ -0,0 +1,590 @@
+// Package yamlconv 在 JSON 与 YAML 块格式之间转换。
+//
+// 只覆盖配置文件常用的子集：块映射、块序列、纯量/单双引号字符串、| 与 |- 字面块、
+// 空的 [] 与 {}，以及整行或纯量后的 # 注释；不支持锚点、标签、多文档和非空流式集合。
+// 键顺序保持不变，便于导出后人工编辑再导入。
+package yamlconv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// FromJSON 把 JSON 文档转换为 YAML
+func FromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("unexpected trailing JSON data")
+	}
+
+	var out bytes.Buffer
+	switch v := value.(type) {
+	case orderedMap:
+		if len(v) == 0 {
+			out.WriteString("{}\n")
+		} else {
+			writeYAMLMap(&out, v, 0)
+		}
+	case []any:
+		if len(v) == 0 {
+			out.WriteString("[]\n")
+		} else {
+			writeYAMLSeq(&out, v, 0)
+		}
+	default:
+		out.WriteString(yamlScalar(v))
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// ToJSON 把 YAML 文档转换为 JSON
+func ToJSON(data []byte) ([]byte, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimLeft(raw, " "), "\t") {
+			return nil, fmt.Errorf("yaml line %d: tabs are not allowed for indentation", i+1)
+		}
+		content := strings.TrimLeft(raw, " ")
+		p.lines = append(p.lines, yamlLine{no: i + 1, indent: len(raw) - len(content), text: strings.TrimRight(content, " \t")})
+	}
+
+	p.skipBlank()
+	if p.pos < len(p.lines) && p.lines[p.pos].text == "---" {
+		p.pos++
+		p.skipBlank()
+	}
+	var value any
+	if p.pos < len(p.lines) {
+		var err error
+		value, err = p.parseNode(p.lines[p.pos].indent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.skipBlank()
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected content %q", p.lines[p.pos].text)
+	}
+	return json.Marshal(value)
+}
+
+// ==================== JSON -> YAML ====================
+
+type orderedEntry struct {
+	key   string
+	value any
+}
+
+// orderedMap 保持键顺序的映射
+type orderedMap []orderedEntry
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, entry := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(entry.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(entry.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			m := orderedMap{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				m = append(m, orderedEntry{key: keyTok.(string), value: value})
+			}
+			_, err := dec.Token()
+			return m, err
+		case '[':
+			list := []any{}
+			for dec.More() {
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err := dec.Token()
+			return list, err
+		}
+		return nil, fmt.Errorf("unexpected JSON delimiter %v", t)
+	default:
+		return tok, nil
+	}
+}
+
+func writeYAMLMap(out *bytes.Buffer, m orderedMap, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, entry := range m {
+		out.WriteString(pad)
+		out.WriteString(yamlKey(entry.key))
+		out.WriteByte(':')
+		writeYAMLValue(out, entry.value, indent)
+	}
+}
+
+func writeYAMLSeq(out *bytes.Buffer, list []any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, item := range list {
+		// 子节点按 indent+2 写出，再把首行缩进替换为 "- "
+		var child bytes.Buffer
+		switch v := item.(type) {
+		case orderedMap:
+			if len(v) > 0 {
+				writeYAMLMap(&child, v, indent+2)
+			}
+		case []any:
+			if len(v) > 0 {
+				writeYAMLSeq(&child, v, indent+2)
+			}
+		}
+		if child.Len() == 0 {
+			out.WriteString(pad)
+			out.WriteString("- ")
+			out.WriteString(yamlScalar(item))
+			out.WriteByte('\n')
+			continue
+		}
+		out.WriteString(pad)
+		out.WriteString("- ")
+		out.Write(child.Bytes()[indent+2:])
+	}
+}
+
+func writeYAMLValue(out *bytes.Buffer, value any, indent int) {
+	switch v := value.(type) {
+	case orderedMap:
+		if len(v) > 0 {
+			out.WriteByte('\n')
+			writeYAMLMap(out, v, indent+2)
+			return
+		}
+	case []any:
+		if len(v) > 0 {
+			out.WriteByte('\n')
+			writeYAMLSeq(out, v, indent+2)
+			return
+		}
+	}
+	out.WriteByte(' ')
+	out.WriteString(yamlScalar(value))
+	out.WriteByte('\n')
+}
+
+var (
+	plainKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.\-]*$`)
+	numberPattern   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+	// numericLike 其它 YAML 实现可能按数字解析（八进制、下划线分隔等），输出时加引号
+	numericLike = regexp.MustCompile(`^[-+.]?[0-9][0-9_.:eExXoO+-]*$`)
+)
+
+func yamlKey(key string) string {
+	if plainKeyPattern.MatchString(key) && !isReservedPlain(key) {
+		return key
+	}
+	return quoteYAML(key)
+}
+
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	case string:
+		if isSafePlainString(v) {
+			return v
+		}
+		return quoteYAML(v)
+	case orderedMap:
+		return "{}"
+	case []any:
+		return "[]"
+	}
+	return quoteYAML(fmt.Sprint(value))
+}
+
+// quoteYAML JSON 字符串转义是 YAML 双引号转义的子集，可直接复用
+func quoteYAML(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func isReservedPlain(s string) bool {
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	return numberPattern.MatchString(s) || numericLike.MatchString(s)
+}
+
+func isSafePlainString(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || isReservedPlain(s) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			continue
+		}
+		if i == 0 && r != '/' {
+			return false
+		}
+		switch r {
+		case ' ', '_', '-', '.', '/', ':', '(', ')', '+', '@':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ==================== YAML -> JSON ====================
+
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	line := 0
+	if p.pos < len(p.lines) {
+		line = p.lines[p.pos].no
+	} else if len(p.lines) > 0 {
+		line = p.lines[len(p.lines)-1].no
+	}
+	return fmt.Errorf("yaml line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.pos < len(p.lines) {
+		text := p.lines[p.pos].text
+		if text != "" && !strings.HasPrefix(text, "#") {
+			return
+		}
+		p.pos++
+	}
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseNode(indent int) (any, error) {
+	p.skipBlank()
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	line := p.lines[p.pos]
+	if isSeqItem(line.text) {
+		return p.parseSeq(line.indent)
+	}
+	if _, _, ok, err := splitMappingEntry(line.text); err != nil {
+		return nil, p.errorf("%v", err)
+	} else if ok {
+		return p.parseMap(line.indent)
+	}
+	p.pos++
+	return parseScalar(line.text)
+}
+
+func (p *yamlParser) parseSeq(indent int) (any, error) {
+	list := []any{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return list, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			return list, nil
+		}
+		if line.indent > indent || !isSeqItem(line.text) {
+			return nil, p.errorf("bad indentation of a sequence entry")
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			p.pos++
+			p.skipBlank()
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				list = append(list, nil)
+				continue
+			}
+			value, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+
+		// "- key: v" / "- - v"：把本行改写为更深缩进的子节点
+		childIndent := line.indent + len(line.text) - len(rest)
+		_, _, isMap, _ := splitMappingEntry(rest)
+		if isMap || isSeqItem(rest) {
+			p.lines[p.pos] = yamlLine{no: line.no, indent: childIndent, text: rest}
+			value, err := p.parseNode(childIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+		value, err := p.parseInlineValue(rest, indent)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+}
+
+func (p *yamlParser) parseMap(indent int) (any, error) {
+	m := orderedMap{}
+	seen := map[string]bool{}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.lines) {
+			return m, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			return m, nil
+		}
+		if line.indent > indent || isSeqItem(line.text) {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		key, rest, ok, err := splitMappingEntry(line.text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if !ok {
+			return nil, p.errorf("expected a mapping entry, got %q", line.text)
+		}
+		if seen[key] {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		seen[key] = true
+
+		var value any
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			p.pos++
+			p.skipBlank()
+			if p.pos < len(p.lines) {
+				next := p.lines[p.pos]
+				// 允许 "key:" 下方同缩进的序列
+				if next.indent > indent || (next.indent == indent && isSeqItem(next.text)) {
+					value, err = p.parseNode(next.indent)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		} else {
+			value, err = p.parseInlineValue(rest, indent)
+			if err != nil {
+				return nil, err
+			}
+		}
+		m = append(m, orderedEntry{key: key, value: value})
+	}
+}
+
+// parseInlineValue 解析与键或 "- " 同行的值，处理 | / |- 字面块
+func (p *yamlParser) parseInlineValue(rest string, indent int) (any, error) {
+	if rest == "|" || rest == "|-" {
+		p.pos++
+		return p.parseLiteralBlock(indent, rest == "|-"), nil
+	}
+	value, err := parseScalar(rest)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	p.pos++
+	return value, nil
+}
+
+func (p *yamlParser) parseLiteralBlock(indent int, strip bool) string {
+	var lines []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.text == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			break
+		}
+		lines = append(lines, strings.Repeat(" ", line.indent-blockIndent)+line.text)
+		p.pos++
+	}
+	text := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if !strip && text != "" {
+		text += "\n"
+	}
+	return text
+}
+
+// splitMappingEntry 拆分 "key: value"，key 可为引号字符串
+func splitMappingEntry(text string) (string, string, bool, error) {
+	if text == "" || isSeqItem(text) {
+		return "", "", false, nil
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		end := quotedEnd(text)
+		if end < 0 {
+			return "", "", false, nil
+		}
+		after := text[end:]
+		if after != ":" && !strings.HasPrefix(after, ": ") {
+			return "", "", false, nil
+		}
+		key, err := parseScalar(text[:end])
+		if err != nil {
+			return "", "", false, err
+		}
+		return key.(string), strings.TrimSpace(strings.TrimPrefix(after, ":")), true, nil
+	}
+	idx := strings.Index(text, ": ")
+	if idx < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false, nil
+		}
+		idx = len(text) - 1
+	}
+	key := text[:idx]
+	if strings.Contains(key, " #") || strings.HasPrefix(key, "#") {
+		return "", "", false, nil
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(text[idx+1:]), true, nil
+}
+
+// quotedEnd 返回引号字符串结束后的下标，未闭合时返回 -1
+func quotedEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote:
+			if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func parseScalar(text string) (any, error) {
+	if text == "" {
+		return nil, nil
+	}
+	switch text[0] {
+	case '"', '\'':
+		end := quotedEnd(text)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string %s", text)
+		}
+		if tail := strings.TrimSpace(text[end:]); tail != "" && !strings.HasPrefix(tail, "#") {
+			return nil, fmt.Errorf("unexpected content after string: %s", tail)
+		}
+		if text[0] == '\'' {
+			return strings.ReplaceAll(text[1:end-1], "''", "'"), nil
+		}
+		var s string
+		if err := json.Unmarshal([]byte(text[:end]), &s); err != nil {
+			return nil, fmt.Errorf("invalid double-quoted string %s: %w", text[:end], err)
+		}
+		return s, nil
+	}
+
+	if idx := strings.Index(text, " #"); idx >= 0 {
+		text = strings.TrimSpace(text[:idx])
+	}
+	switch text {
+	case "[]":
+		return []any{}, nil
+	case "{}":
+		return orderedMap{}, nil
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if text[0] == '[' || text[0] == '{' {
+		return nil, fmt.Errorf("flow collections are not supported: %s", text)
+	}
+	if text[0] == '&' || text[0] == '*' || text[0] == '!' {
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported: %s", text)
+	}
+	if numberPattern.MatchString(text) {
+		return json.Number(text), nil
+	}
+	return text, nil
+}
@@ -0,0 +1,81 @@
+package yamlconv
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON_RoundTrip(t *testing.T) {
+	src := `{"format":"bundle","version":1,"gateway":{"name":"站点 A","retention":30},` +
+		`"resources":[{"name":"com1","path":"/dev/ttyUSB0","enabled":1},{"name":"no","path":"","enabled":0}],` +
+		`"empty_list":[],"empty_map":{},"nested":[[1,2],[]],"nil":null,"flag":true,` +
+		`"text":"line1\nline2: x # y","number_like":"0123","bool_like":"yes","schema":"{\"a\":[1]}"}`
+
+	out, err := FromJSON([]byte(src))
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+	if !strings.Contains(string(out), "resources:\n  - name: com1\n    path: /dev/ttyUSB0\n") {
+		t.Fatalf("unexpected yaml layout:\n%s", out)
+	}
+
+	back, err := ToJSON(out)
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v\n%s", err, out)
+	}
+	var want, got any
+	_ = json.Unmarshal([]byte(src), &want)
+	if err := json.Unmarshal(back, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("round trip mismatch\nyaml:\n%s\njson: %s", out, back)
+	}
+	if !strings.HasPrefix(string(back), `{"format":"bundle","version":1,"gateway"`) {
+		t.Fatalf("key order not preserved: %s", back)
+	}
+}
+
+func TestToJSON_HandWritten(t *testing.T) {
+	src := `# 模板
+---
+gateway:
+  name: 'it''s'   # 注释
+devices:
+- name: meter-1
+  point_table: |
+    {"a": 1}
+      indented
+  tags:
+    - x
+    -
+      k: v
+- name: "meter-2"
+  note: |-
+    last
+`
+	out, err := ToJSON([]byte(src))
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	want := `{"gateway":{"name":"it's"},"devices":[{"name":"meter-1","point_table":"{\"a\": 1}\n  indented\n","tags":["x",{"k":"v"}]},{"name":"meter-2","note":"last"}]}`
+	if string(out) != want {
+		t.Fatalf("ToJSON() = %s\nwant %s", out, want)
+	}
+}
+
+func TestToJSON_Errors(t *testing.T) {
+	for name, src := range map[string]string{
+		"tab":        "a:\n\tb: 1\n",
+		"duplicate":  "a: 1\na: 2\n",
+		"flow":       "a: [1, 2]\n",
+		"bad indent": "a:\n    b: 1\n  c: 2\n",
+		"unclosed":   "a: \"x\n",
+	} {
+		if _, err := ToJSON([]byte(src)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
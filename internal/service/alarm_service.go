@@ -15,6 +15,10 @@ func (s *AlarmService) ListRecentAlarmLogs(limit int) ([]*models.AlarmLog, error
 	return database.ListRecentAlarmLogs(limit)
 }
 
+func (s *AlarmService) ListActiveAlarms() ([]*models.ActiveAlarm, error) {
+	return database.ListActiveAlarms()
+}
+
 func (s *AlarmService) LoadAlarm(id int64) (*models.AlarmLog, error) {
 	return database.LoadAlarmLog(id)
 }
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+)
+
+// BackupRuntimeHooks 恢复备份时需要的运行时钩子
+type BackupRuntimeHooks struct {
+	// StopNorthbound 恢复前停止北向（含暂存补发）
+	StopNorthbound func()
+	// ReloadSchema 恢复后补齐旧备份中缺失的表结构与默认数据
+	ReloadSchema func() error
+}
+
+// BackupRestoreResult 恢复备份结果
+type BackupRestoreResult struct {
+	Name              string                   `json:"name"`
+	Manifest          *database.BackupManifest `json:"manifest"`
+	CollectorStopped  bool                     `json:"collector_stopped"`
+	NorthboundStopped bool                     `json:"northbound_stopped"`
+	RestartRequired   bool                     `json:"restart_required"`
+}
+
+type BackupService struct {
+	collector CollectorController
+	hooks     BackupRuntimeHooks
+}
+
+func NewBackupService(collector CollectorController, hooks BackupRuntimeHooks) *BackupService {
+	return &BackupService{collector: collector, hooks: hooks}
+}
+
+func (s *BackupService) List() ([]*database.BackupInfo, error) {
+	return database.ListBackups()
+}
+
+func (s *BackupService) Create() (*database.BackupInfo, error) {
+	return database.CreateBackup()
+}
+
+// Open 打开备份归档供下载，调用方负责关闭
+func (s *BackupService) Open(name string) (*os.File, *database.BackupInfo, error) {
+	return database.OpenBackup(name)
+}
+
+// Restore 校验归档后停止采集与北向，再替换数据库文件。
+// 驱动、北向适配器与设备任务仍是恢复前的内存状态，需重启网关进程才能完整加载恢复后的配置
+func (s *BackupService) Restore(name string) (*BackupRestoreResult, error) {
+	result := &BackupRestoreResult{Name: name}
+	manifest, err := database.RestoreBackup(name, func() error {
+		if s.collector != nil && s.collector.IsRunning() {
+			if err := s.collector.Stop(); err != nil {
+				return fmt.Errorf("failed to stop collector: %w", err)
+			}
+			result.CollectorStopped = true
+		}
+		if s.hooks.StopNorthbound != nil {
+			s.hooks.StopNorthbound()
+			result.NorthboundStopped = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Manifest = manifest
+	result.RestartRequired = true
+
+	if s.hooks.ReloadSchema != nil {
+		if err := s.hooks.ReloadSchema(); err != nil {
+			return nil, fmt.Errorf("failed to reload schema after restore: %w", err)
+		}
+	}
+	slog.Warn("Backup restored, restart gateway to reload drivers and northbound", "name", name)
+	return result, nil
+}
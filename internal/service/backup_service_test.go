@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+type fakeBackupCollector struct {
+	running bool
+	stops   int
+}
+
+func (c *fakeBackupCollector) Start() error    { c.running = true; return nil }
+func (c *fakeBackupCollector) Stop() error     { c.running = false; c.stops++; return nil }
+func (c *fakeBackupCollector) IsRunning() bool { return c.running }
+
+func TestBackupService_RestoreStopsRuntimeAfterVerification(t *testing.T) {
+	setupConfigBundleTestDB(t)
+	oldDataDB := database.DataDB
+	if err := database.InitDataDBWithPath(filepath.Join(t.TempDir(), "data.db")); err != nil {
+		t.Fatalf("InitDataDBWithPath: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = database.DataDB.Close()
+		database.DataDB = oldDataDB
+	})
+	dir := t.TempDir()
+	database.ApplyBackupConfig(dir, 3)
+	t.Cleanup(func() { database.ApplyBackupConfig(database.DefaultBackupDir, database.DefaultBackupKeep) })
+
+	if _, err := database.CreateResource(&models.Resource{Name: "com1", Type: "serial", Path: "/dev/ttyS0", Enabled: 1}); err != nil {
+		t.Fatalf("CreateResource: %v", err)
+	}
+	collector := &fakeBackupCollector{running: true}
+	northboundStops, reloads := 0, 0
+	svc := NewBackupService(collector, BackupRuntimeHooks{
+		StopNorthbound: func() { northboundStops++ },
+		ReloadSchema:   func() error { reloads++; return nil },
+	})
+
+	info, err := svc.Create()
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := database.CreateResource(&models.Resource{Name: "com2", Type: "serial", Path: "/dev/ttyS1", Enabled: 1}); err != nil {
+		t.Fatalf("CreateResource: %v", err)
+	}
+
+	if _, err := svc.Restore("fsu-backup-missing.tar.gz"); !errors.Is(err, database.ErrBackupNotFound) {
+		t.Fatalf("Restore(missing) error = %v", err)
+	}
+	if collector.stops != 0 || northboundStops != 0 {
+		t.Fatalf("runtime stopped for a missing backup")
+	}
+
+	result, err := svc.Restore(info.Name)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if !result.CollectorStopped || !result.NorthboundStopped || !result.RestartRequired || collector.running || reloads != 1 {
+		t.Fatalf("Restore() result = %+v, reloads = %d", result, reloads)
+	}
+	if resources, _ := database.ListResources(); len(resources) != 1 || resources[0].Name != "com1" {
+		t.Fatalf("resources after restore = %+v", resources)
+	}
+
+	backups, err := svc.List()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("List() = %d, %v", len(backups), err)
+	}
+	file, _, err := svc.Open(info.Name)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	_ = file.Close()
+	if _, err := os.Stat(filepath.Join(dir, info.Name+".sha256")); err != nil {
+		t.Fatalf("checksum file missing: %v", err)
+	}
+}
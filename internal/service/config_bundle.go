@@ -0,0 +1,544 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	collectorpkg "github.com/gonglijing/xunjiFsu/internal/collector"
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+const (
+	// ConfigBundleFormat 配置包格式标识
+	ConfigBundleFormat = "xunji-fsu-config"
+	// ConfigBundleVersion 当前配置包版本，结构不兼容变化时递增
+	ConfigBundleVersion = 1
+	// RedactedSecret 导出时替换敏感字段的占位符；导入时遇到占位符保留本机原值
+	RedactedSecret = "__REDACTED__"
+)
+
+// ErrConfigBundleInvalid 配置包格式、版本或内容不合法
+var ErrConfigBundleInvalid = errors.New("invalid config bundle")
+
+// secretKeyPattern 北向 config/ext_config JSON 中按键名识别的敏感字段
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|private_?key|access_?key|authorization|api[-_]?key|cookie)`)
+
+// secretContainerPattern 其下所有字符串值均视为敏感的键（如 HTTP 自定义请求头）
+var secretContainerPattern = regexp.MustCompile(`(?i)^headers$`)
+
+// ConfigBundle param.db 配置包；实体之间按名称引用，导入时映射为本机 ID
+type ConfigBundle struct {
+	Format            string                   `json:"format"`
+	Version           int                      `json:"version"`
+	ExportedAt        string                   `json:"exported_at,omitempty"`
+	Redacted          bool                     `json:"redacted"`
+	Gateway           *BundleGateway           `json:"gateway,omitempty"`
+	Resources         []*BundleResource        `json:"resources"`
+	Drivers           []*BundleDriver          `json:"drivers"`
+	Devices           []*BundleDevice          `json:"devices"`
+	Thresholds        []*BundleThreshold       `json:"thresholds"`
+	Northbound        []*BundleNorthbound      `json:"northbound"`
+	RetentionPolicies []*BundleRetentionPolicy `json:"retention_policies"`
+}
+
+// BundleGateway 网关级配置（不含 product_key/device_key 等每台网关唯一的身份）
+type BundleGateway struct {
+	GatewayName                string `json:"gateway_name"`
+	DataRetentionDays          int    `json:"data_retention_days"`
+	AlarmRepeatIntervalSeconds int    `json:"alarm_repeat_interval_seconds"`
+}
+
+// BundleResource 资源
+type BundleResource struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Path    string `json:"path"`
+	Enabled int    `json:"enabled"`
+}
+
+// BundleDriver 驱动；wasm 为 base64 编码的驱动文件（导出时可选）
+type BundleDriver struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Version      string `json:"version"`
+	ConfigSchema string `json:"config_schema"`
+	Enabled      int    `json:"enabled"`
+	FileName     string `json:"file_name"`
+	SHA256       string `json:"sha256,omitempty"`
+	Wasm         string `json:"wasm,omitempty"`
+}
+
+// BundleDevice 设备；driver/resource 为驱动名与资源名
+type BundleDevice struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	ProductKey      string `json:"product_key"`
+	DeviceKey       string `json:"device_key"`
+	DriverType      string `json:"driver_type"`
+	SerialPort      string `json:"serial_port"`
+	BaudRate        int    `json:"baud_rate"`
+	DataBits        int    `json:"data_bits"`
+	StopBits        int    `json:"stop_bits"`
+	Parity          string `json:"parity"`
+	IPAddress       string `json:"ip_address"`
+	PortNum         int    `json:"port_num"`
+	DeviceAddress   string `json:"device_address"`
+	CollectInterval int    `json:"collect_interval"`
+	StorageInterval int    `json:"storage_interval"`
+	Timeout         int    `json:"timeout"`
+	PointTable      string `json:"point_table,omitempty"`
+	Deadbands       string `json:"deadbands,omitempty"`
+	DeviceConfig    string `json:"device_config,omitempty"`
+	Driver          string `json:"driver,omitempty"`
+	Resource        string `json:"resource,omitempty"`
+	Enabled         int    `json:"enabled"`
+}
+
+// BundleThreshold 阈值；device 为设备名，系统属性设备为 __system__
+type BundleThreshold struct {
+	Device       string  `json:"device"`
+	FieldName    string  `json:"field_name"`
+	Operator     string  `json:"operator"`
+	Value        float64 `json:"value"`
+	Severity     string  `json:"severity"`
+	Shielded     int     `json:"shielded"`
+	Message      string  `json:"message"`
+	Expression   string  `json:"expression,omitempty"`
+	Deadband     float64 `json:"deadband"`
+	TriggerDelay int     `json:"trigger_delay_seconds"`
+	ClearDelay   int     `json:"clear_delay_seconds"`
+}
+
+// BundleNorthbound 北向配置
+type BundleNorthbound struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Enabled        int    `json:"enabled"`
+	UploadInterval int    `json:"upload_interval"`
+	ServerURL      string `json:"server_url"`
+	Port           int    `json:"port"`
+	Path           string `json:"path"`
+	Username       string `json:"username"`
+	Password       string `json:"password"`
+	ClientID       string `json:"client_id"`
+	Topic          string `json:"topic"`
+	AlarmTopic     string `json:"alarm_topic"`
+	QOS            int    `json:"qos"`
+	Retain         bool   `json:"retain"`
+	KeepAlive      int    `json:"keep_alive"`
+	Timeout        int    `json:"timeout"`
+	ProductKey     string `json:"product_key"`
+	DeviceKey      string `json:"device_key"`
+	ExtConfig      string `json:"ext_config"`
+	Config         string `json:"config"`
+}
+
+// BundleRetentionPolicy 设备历史保留策略
+type BundleRetentionPolicy struct {
+	Device      string `json:"device"`
+	StorageDays int    `json:"storage_days"`
+	Enabled     int    `json:"enabled"`
+}
+
+// ConfigExportOptions 导出选项
+type ConfigExportOptions struct {
+	IncludeSecrets bool
+	IncludeDrivers bool
+}
+
+// ConfigBundleService 配置导出/导入
+type ConfigBundleService struct {
+	driversDir string
+	collector  *collectorpkg.Collector
+}
+
+func NewConfigBundleService(driversDir string, collector *collectorpkg.Collector) *ConfigBundleService {
+	return &ConfigBundleService{driversDir: driversDir, collector: collector}
+}
+
+// Export 导出当前 param.db 配置
+func (s *ConfigBundleService) Export(opts ConfigExportOptions) (*ConfigBundle, error) {
+	bundle, err := s.snapshot(opts.IncludeDrivers)
+	if err != nil {
+		return nil, err
+	}
+	bundle.ExportedAt = time.Now().UTC().Format(time.RFC3339)
+	if !opts.IncludeSecrets {
+		redactConfigBundle(bundle)
+	}
+	return bundle, nil
+}
+
+// snapshot 读取本机配置（含敏感字段），includeWasm 时附带驱动文件内容
+func (s *ConfigBundleService) snapshot(includeWasm bool) (*ConfigBundle, error) {
+	bundle := &ConfigBundle{Format: ConfigBundleFormat, Version: ConfigBundleVersion}
+
+	gateway, err := database.GetGatewayConfig()
+	if err != nil {
+		return nil, err
+	}
+	repeatInterval, err := database.GetAlarmRepeatIntervalSeconds()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Gateway = &BundleGateway{
+		GatewayName:                gateway.GatewayName,
+		DataRetentionDays:          gateway.DataRetentionDays,
+		AlarmRepeatIntervalSeconds: repeatInterval,
+	}
+
+	resources, err := database.ListResources()
+	if err != nil {
+		return nil, err
+	}
+	resourceNames := make(map[int64]string, len(resources))
+	bundle.Resources = make([]*BundleResource, 0, len(resources))
+	for _, r := range resources {
+		resourceNames[r.ID] = r.Name
+		bundle.Resources = append(bundle.Resources, &BundleResource{Name: r.Name, Type: r.Type, Path: r.Path, Enabled: r.Enabled})
+	}
+
+	drivers, err := database.ListDrivers()
+	if err != nil {
+		return nil, err
+	}
+	driverNames := make(map[int64]string, len(drivers))
+	bundle.Drivers = make([]*BundleDriver, 0, len(drivers))
+	for _, d := range drivers {
+		driverNames[d.ID] = d.Name
+		item := &BundleDriver{
+			Name:         d.Name,
+			Description:  d.Description,
+			Version:      d.Version,
+			ConfigSchema: d.ConfigSchema,
+			Enabled:      d.Enabled,
+			FileName:     filepath.Base(driverPath(s.driversDir, d.Name, d.FilePath)),
+		}
+		if data, err := os.ReadFile(driverPath(s.driversDir, d.Name, d.FilePath)); err == nil {
+			item.SHA256 = sha256Hex(data)
+			if includeWasm {
+				item.Wasm = base64.StdEncoding.EncodeToString(data)
+			}
+		}
+		bundle.Drivers = append(bundle.Drivers, item)
+	}
+
+	devices, err := database.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	deviceNames := map[int64]string{models.SystemStatsDeviceID: models.SystemStatsDeviceName}
+	bundle.Devices = make([]*BundleDevice, 0, len(devices))
+	for _, d := range devices {
+		deviceNames[d.ID] = d.Name
+		item := bundleDeviceFromModel(d)
+		if d.DriverID != nil {
+			item.Driver = driverNames[*d.DriverID]
+		}
+		if d.ResourceID != nil {
+			item.Resource = resourceNames[*d.ResourceID]
+		}
+		bundle.Devices = append(bundle.Devices, item)
+	}
+
+	thresholds, err := database.ListThresholds()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Thresholds = make([]*BundleThreshold, 0, len(thresholds))
+	for _, t := range thresholds {
+		name, ok := deviceNames[t.DeviceID]
+		if !ok {
+			continue
+		}
+		bundle.Thresholds = append(bundle.Thresholds, bundleThresholdFromModel(name, t))
+	}
+
+	northboundConfigs, err := database.ListNorthboundConfigs()
+	if err != nil {
+		return nil, err
+	}
+	bundle.Northbound = make([]*BundleNorthbound, 0, len(northboundConfigs))
+	for _, cfg := range northboundConfigs {
+		password, err := database.LoadNorthboundPassword(cfg.ID)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Password = password
+		bundle.Northbound = append(bundle.Northbound, bundleNorthboundFromModel(cfg))
+	}
+
+	policies, err := database.ListDeviceRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+	bundle.RetentionPolicies = make([]*BundleRetentionPolicy, 0, len(policies))
+	for _, p := range policies {
+		name, ok := deviceNames[p.DeviceID]
+		if !ok {
+			continue
+		}
+		bundle.RetentionPolicies = append(bundle.RetentionPolicies, &BundleRetentionPolicy{Device: name, StorageDays: p.StorageDays, Enabled: p.Enabled})
+	}
+	return bundle, nil
+}
+
+// redactConfigBundle 以占位符替换北向密码及 config/ext_config 中的敏感键
+func redactConfigBundle(bundle *ConfigBundle) {
+	bundle.Redacted = true
+	for _, nb := range bundle.Northbound {
+		if nb.Password != "" {
+			nb.Password = RedactedSecret
+		}
+		nb.Config = redactSecretJSON(nb.Config)
+		nb.ExtConfig = redactSecretJSON(nb.ExtConfig)
+	}
+}
+
+func redactSecretJSON(raw string) string {
+	value, ok := parseJSONObject(raw)
+	if !ok || !rewriteSecretJSON(value, nil, false, func(_ []string, s string) (string, bool) {
+		return RedactedSecret, s != ""
+	}) {
+		return raw
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return raw
+	}
+	return string(out)
+}
+
+// restoreSecretJSON 把 incoming 中的占位符替换为 local 同路径的值，找不到时置空并返回 false
+func restoreSecretJSON(incoming, local string) (string, bool) {
+	value, ok := parseJSONObject(incoming)
+	if !ok {
+		return incoming, true
+	}
+	localValue, _ := parseJSONObject(local)
+
+	resolved := true
+	if !rewriteSecretJSON(value, nil, false, func(path []string, s string) (string, bool) {
+		if s != RedactedSecret {
+			return s, false
+		}
+		if v, ok := lookupJSONPath(localValue, path).(string); ok && v != RedactedSecret {
+			return v, true
+		}
+		resolved = false
+		return "", true
+	}) {
+		return incoming, true
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return incoming, false
+	}
+	return string(out), resolved
+}
+
+func parseJSONObject(raw string) (map[string]any, bool) {
+	var obj map[string]any
+	if raw == "" || json.Unmarshal([]byte(raw), &obj) != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+// rewriteSecretJSON 对敏感键下的字符串值调用 fn，fn 返回 true 时替换；返回是否发生替换。
+// all 为 true 时 obj 下所有字符串值均视为敏感；以 JSON 字符串保存的嵌套对象会先解码再遍历。
+func rewriteSecretJSON(obj map[string]any, path []string, all bool, fn func(path []string, s string) (string, bool)) bool {
+	changed := false
+	for key, child := range obj {
+		childPath := append(append([]string(nil), path...), key)
+		secret := all || secretKeyPattern.MatchString(key)
+		childAll := secret || secretContainerPattern.MatchString(key)
+		switch v := child.(type) {
+		case map[string]any:
+			if rewriteSecretJSON(v, childPath, childAll, fn) {
+				changed = true
+			}
+		case string:
+			if nested, ok := parseJSONObject(v); ok {
+				if !rewriteSecretJSON(nested, childPath, childAll, fn) {
+					continue
+				}
+				if out, err := json.Marshal(nested); err == nil {
+					obj[key] = string(out)
+					changed = true
+				}
+				continue
+			}
+			if !secret {
+				continue
+			}
+			if replaced, ok := fn(childPath, v); ok {
+				obj[key] = replaced
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func lookupJSONPath(obj map[string]any, path []string) any {
+	var value any = obj
+	for _, key := range path {
+		if s, ok := value.(string); ok {
+			if nested, ok := parseJSONObject(s); ok {
+				value = nested
+			}
+		}
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func bundleDeviceFromModel(d *models.Device) *BundleDevice {
+	return &BundleDevice{
+		Name:            d.Name,
+		Description:     d.Description,
+		ProductKey:      d.ProductKey,
+		DeviceKey:       d.DeviceKey,
+		DriverType:      d.DriverType,
+		SerialPort:      d.SerialPort,
+		BaudRate:        d.BaudRate,
+		DataBits:        d.DataBits,
+		StopBits:        d.StopBits,
+		Parity:          d.Parity,
+		IPAddress:       d.IPAddress,
+		PortNum:         d.PortNum,
+		DeviceAddress:   d.DeviceAddress,
+		CollectInterval: d.CollectInterval,
+		StorageInterval: d.StorageInterval,
+		Timeout:         d.Timeout,
+		PointTable:      d.PointTable,
+		Deadbands:       d.Deadbands,
+		DeviceConfig:    d.DeviceConfig,
+		Enabled:         d.Enabled,
+	}
+}
+
+func (d *BundleDevice) toModel() *models.Device {
+	return &models.Device{
+		Name:            d.Name,
+		Description:     d.Description,
+		ProductKey:      d.ProductKey,
+		DeviceKey:       d.DeviceKey,
+		DriverType:      d.DriverType,
+		SerialPort:      d.SerialPort,
+		BaudRate:        d.BaudRate,
+		DataBits:        d.DataBits,
+		StopBits:        d.StopBits,
+		Parity:          d.Parity,
+		IPAddress:       d.IPAddress,
+		PortNum:         d.PortNum,
+		DeviceAddress:   d.DeviceAddress,
+		CollectInterval: d.CollectInterval,
+		StorageInterval: d.StorageInterval,
+		Timeout:         d.Timeout,
+		PointTable:      d.PointTable,
+		Deadbands:       d.Deadbands,
+		DeviceConfig:    d.DeviceConfig,
+		Enabled:         d.Enabled,
+	}
+}
+
+func bundleThresholdFromModel(device string, t *models.Threshold) *BundleThreshold {
+	return &BundleThreshold{
+		Device:       device,
+		FieldName:    t.FieldName,
+		Operator:     t.Operator,
+		Value:        t.Value,
+		Severity:     t.Severity,
+		Shielded:     t.Shielded,
+		Message:      t.Message,
+		Expression:   t.Expression,
+		Deadband:     t.Deadband,
+		TriggerDelay: t.TriggerDelay,
+		ClearDelay:   t.ClearDelay,
+	}
+}
+
+func (t *BundleThreshold) toModel(deviceID int64) *models.Threshold {
+	return &models.Threshold{
+		DeviceID:     deviceID,
+		FieldName:    t.FieldName,
+		Operator:     t.Operator,
+		Value:        t.Value,
+		Severity:     t.Severity,
+		Shielded:     t.Shielded,
+		Message:      t.Message,
+		Expression:   t.Expression,
+		Deadband:     t.Deadband,
+		TriggerDelay: t.TriggerDelay,
+		ClearDelay:   t.ClearDelay,
+	}
+}
+
+func bundleNorthboundFromModel(cfg *models.NorthboundConfig) *BundleNorthbound {
+	return &BundleNorthbound{
+		Name:           cfg.Name,
+		Type:           cfg.Type,
+		Enabled:        cfg.Enabled,
+		UploadInterval: cfg.UploadInterval,
+		ServerURL:      cfg.ServerURL,
+		Port:           cfg.Port,
+		Path:           cfg.Path,
+		Username:       cfg.Username,
+		Password:       cfg.Password,
+		ClientID:       cfg.ClientID,
+		Topic:          cfg.Topic,
+		AlarmTopic:     cfg.AlarmTopic,
+		QOS:            cfg.QOS,
+		Retain:         cfg.Retain,
+		KeepAlive:      cfg.KeepAlive,
+		Timeout:        cfg.Timeout,
+		ProductKey:     cfg.ProductKey,
+		DeviceKey:      cfg.DeviceKey,
+		ExtConfig:      cfg.ExtConfig,
+		Config:         cfg.Config,
+	}
+}
+
+func (n *BundleNorthbound) toModel() *models.NorthboundConfig {
+	return &models.NorthboundConfig{
+		Name:           n.Name,
+		Type:           n.Type,
+		Enabled:        n.Enabled,
+		UploadInterval: n.UploadInterval,
+		ServerURL:      n.ServerURL,
+		Port:           n.Port,
+		Path:           n.Path,
+		Username:       n.Username,
+		Password:       n.Password,
+		ClientID:       n.ClientID,
+		Topic:          n.Topic,
+		AlarmTopic:     n.AlarmTopic,
+		QOS:            n.QOS,
+		Retain:         n.Retain,
+		KeepAlive:      n.KeepAlive,
+		Timeout:        n.Timeout,
+		ProductKey:     n.ProductKey,
+		DeviceKey:      n.DeviceKey,
+		ExtConfig:      n.ExtConfig,
+		Config:         n.Config,
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// setupConfigBundleTestDB 打开一个全新的 param.db，模拟一台网关
+func setupConfigBundleTestDB(t *testing.T) {
+	t.Helper()
+	oldDB := database.ParamDB
+	if err := database.InitParamDBWithPath(filepath.Join(t.TempDir(), "param.db")); err != nil {
+		t.Fatalf("InitParamDBWithPath: %v", err)
+	}
+	db := database.ParamDB
+	t.Cleanup(func() {
+		_ = db.Close()
+		database.ParamDB = oldDB
+	})
+	for _, init := range []func() error{
+		database.InitParamSchema,
+		database.InitGatewayConfigTable,
+	} {
+		if err := init(); err != nil {
+			t.Fatalf("init param db: %v", err)
+		}
+	}
+}
+
+func seedConfigBundleSource(t *testing.T, driversDir string) {
+	t.Helper()
+	wasmPath := filepath.Join(driversDir, "meter.wasm")
+	if err := os.WriteFile(wasmPath, []byte("\x00asm-meter"), 0o644); err != nil {
+		t.Fatalf("write wasm: %v", err)
+	}
+	resourceID, err := database.CreateResource(&models.Resource{Name: "com1", Type: "serial", Path: "/dev/ttyUSB0", Enabled: 1})
+	if err != nil {
+		t.Fatalf("CreateResource: %v", err)
+	}
+	driverID, err := database.CreateDriver(&models.Driver{Name: "meter", FilePath: wasmPath, ConfigSchema: `{"a": 1}`, Enabled: 1})
+	if err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+	deviceID, err := database.CreateDevice(&models.Device{Name: "m1", DriverType: "wasm", SerialPort: "/dev/ttyUSB0", BaudRate: 9600,
+		Parity: "N", CollectInterval: 5000, StorageInterval: 300, DriverID: &driverID, ResourceID: &resourceID, Enabled: 1})
+	if err != nil {
+		t.Fatalf("CreateDevice: %v", err)
+	}
+	if _, err := database.CreateThreshold(&models.Threshold{DeviceID: deviceID, FieldName: "Ua", Operator: ">", Value: 240, Severity: "warning"}); err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+	if _, err := database.CreateNorthboundConfig(&models.NorthboundConfig{Name: "cloud", Type: "mqtt", Enabled: 1, ServerURL: "tcp://broker:1883",
+		Password: "nb-secret", Topic: "t", Config: `{"broker":"tcp://broker:1883","auth":{"token":"tk"}}`}); err != nil {
+		t.Fatalf("CreateNorthboundConfig: %v", err)
+	}
+	if err := database.UpsertDeviceRetentionPolicy(&database.DeviceRetentionPolicy{DeviceID: deviceID, StorageDays: 7, Enabled: 1}); err != nil {
+		t.Fatalf("UpsertDeviceRetentionPolicy: %v", err)
+	}
+}
+
+func TestConfigBundle_ExportImportRoundTrip(t *testing.T) {
+	setupConfigBundleTestDB(t)
+	sourceDrivers := t.TempDir()
+	seedConfigBundleSource(t, sourceDrivers)
+
+	bundle, err := NewConfigBundleService(sourceDrivers, nil).Export(ConfigExportOptions{IncludeDrivers: true})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	nb := bundle.Northbound[0]
+	if !bundle.Redacted || nb.Password != RedactedSecret || strings.Contains(nb.Config, "tk") || !strings.Contains(nb.Config, "broker") {
+		t.Fatalf("secrets not redacted: %+v", nb)
+	}
+	if d := bundle.Drivers[0]; d.Wasm != base64.StdEncoding.EncodeToString([]byte("\x00asm-meter")) || d.SHA256 == "" {
+		t.Fatalf("driver file not exported: %+v", d)
+	}
+	if dev := bundle.Devices[0]; dev.Driver != "meter" || dev.Resource != "com1" {
+		t.Fatalf("device references = %+v", dev)
+	}
+
+	// 目标网关：空库 + 不同的串口路径
+	setupConfigBundleTestDB(t)
+	targetDrivers := t.TempDir()
+	if _, err := database.CreateResource(&models.Resource{Name: "spare", Type: "do", Enabled: 1}); err != nil {
+		t.Fatalf("CreateResource: %v", err)
+	}
+	svc := NewConfigBundleService(targetDrivers, nil)
+	opts := ConfigImportOptions{DryRun: true, PathMap: map[string]string{"/dev/ttyUSB0": "/dev/ttyS1"}}
+
+	result, err := svc.Import(bundle, opts)
+	if err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	if result.Applied || result.Summary[configKindDevice].Create != 1 || result.Summary[configKindThreshold].Create != 1 {
+		t.Fatalf("dry run result = %+v", result)
+	}
+	if devices, _ := database.ListDevices(); len(devices) != 0 {
+		t.Fatalf("dry run must not write, got %d devices", len(devices))
+	}
+
+	opts.DryRun = false
+	result, err = svc.Import(bundle, opts)
+	if err != nil || !result.Applied || !result.RestartRequired {
+		t.Fatalf("Import() = %+v, %v", result, err)
+	}
+	if len(result.Warnings) == 0 {
+		t.Fatalf("expected warning for unresolved redacted secrets")
+	}
+	devices, _ := database.ListDevices()
+	resources, _ := database.ListResources()
+	drivers, _ := database.ListDrivers()
+	if len(devices) != 1 || len(resources) != 2 || len(drivers) != 1 {
+		t.Fatalf("devices=%d resources=%d drivers=%d", len(devices), len(resources), len(drivers))
+	}
+	dev := devices[0]
+	if dev.SerialPort != "/dev/ttyS1" || dev.DriverID == nil || *dev.DriverID != drivers[0].ID || dev.ResourceID == nil || *dev.ResourceID != resources[1].ID {
+		t.Fatalf("device not remapped: %+v", dev)
+	}
+	if resources[1].Path != "/dev/ttyS1" {
+		t.Fatalf("resource path = %q", resources[1].Path)
+	}
+	if data, err := os.ReadFile(filepath.Join(targetDrivers, "meter.wasm")); err != nil || string(data) != "\x00asm-meter" {
+		t.Fatalf("driver file = %q, %v", data, err)
+	}
+	if thresholds, _ := database.ListThresholdsByDevice(dev.ID); len(thresholds) != 1 {
+		t.Fatalf("thresholds = %d", len(thresholds))
+	}
+	if policy, err := database.GetDeviceRetentionPolicy(dev.ID); err != nil || policy.StorageDays != 7 {
+		t.Fatalf("retention policy = %+v, %v", policy, err)
+	}
+
+	// 本机设置密码后再次导入脱敏包：保留本机密码，且无任何变更
+	configs, _ := database.ListNorthboundConfigs()
+	configs[0].Password = "local-secret"
+	if err := database.UpdateNorthboundConfig(configs[0]); err != nil {
+		t.Fatalf("UpdateNorthboundConfig: %v", err)
+	}
+	result, err = svc.Import(bundle, opts)
+	if err != nil {
+		t.Fatalf("re-import error = %v", err)
+	}
+	if len(result.Changes) != 0 || result.Applied {
+		t.Fatalf("re-import should be a no-op, changes=%+v", result.Changes)
+	}
+	if password, _ := database.LoadNorthboundPassword(configs[0].ID); password != "local-secret" {
+		t.Fatalf("password = %q, want local value kept", password)
+	}
+
+	// replace：配置包中没有的设备及其阈值、保留策略和本机多余资源被删除
+	bundle.Devices, bundle.Thresholds, bundle.RetentionPolicies = nil, nil, nil
+	opts.Mode = ConfigImportModeReplace
+	result, err = svc.Import(bundle, opts)
+	if err != nil {
+		t.Fatalf("replace error = %v", err)
+	}
+	if result.Summary[configKindDevice].Delete != 1 || result.Summary[configKindResource].Delete != 1 || result.Summary[configKindThreshold].Delete != 1 {
+		t.Fatalf("replace summary = %+v", result.Summary)
+	}
+	if devices, _ := database.ListDevices(); len(devices) != 0 {
+		t.Fatalf("devices left = %d", len(devices))
+	}
+	if thresholds, _ := database.ListThresholds(); len(thresholds) != 0 {
+		t.Fatalf("thresholds left = %d", len(thresholds))
+	}
+}
+
+func TestConfigBundle_ExportRedactsHTTPHeaders(t *testing.T) {
+	setupConfigBundleTestDB(t)
+	for name, config := range map[string]string{
+		"object": `{"url":"https://api.example.com","headers":{"Authorization":"Bearer abc123","X-API-Key":"k-456","X-Site":"s-789"}}`,
+		"string": `{"url":"https://api.example.com","headers":"{\"Authorization\":\"Bearer abc123\",\"X-API-Key\":\"k-456\",\"X-Site\":\"s-789\"}"}`,
+	} {
+		if _, err := database.CreateNorthboundConfig(&models.NorthboundConfig{Name: name, Type: "http", Enabled: 1, Config: config}); err != nil {
+			t.Fatalf("CreateNorthboundConfig: %v", err)
+		}
+	}
+
+	svc := NewConfigBundleService(t.TempDir(), nil)
+	bundle, err := svc.Export(ConfigExportOptions{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(bundle.Northbound) != 2 {
+		t.Fatalf("northbound = %d", len(bundle.Northbound))
+	}
+	for _, nb := range bundle.Northbound {
+		for _, secret := range []string{"abc123", "k-456", "s-789"} {
+			if strings.Contains(nb.Config, secret) {
+				t.Fatalf("%s: header value %q not redacted: %s", nb.Name, secret, nb.Config)
+			}
+		}
+		if !strings.Contains(nb.Config, "Authorization") || !strings.Contains(nb.Config, "api.example.com") {
+			t.Fatalf("%s: non-secret content lost: %s", nb.Name, nb.Config)
+		}
+	}
+
+	// 重新导入脱敏包时恢复本机请求头，不产生变更
+	result, err := svc.Import(bundle, ConfigImportOptions{})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if len(result.Changes) != 0 || len(result.Warnings) != 0 {
+		t.Fatalf("re-import changes=%+v warnings=%v", result.Changes, result.Warnings)
+	}
+}
+
+func TestConfigBundle_ImportRejectsInvalidBundle(t *testing.T) {
+	setupConfigBundleTestDB(t)
+	svc := NewConfigBundleService(t.TempDir(), nil)
+
+	if _, err := svc.Import(&ConfigBundle{Format: ConfigBundleFormat, Version: ConfigBundleVersion + 1}, ConfigImportOptions{}); err == nil {
+		t.Fatalf("expected newer version to be rejected")
+	}
+
+	bundle := &ConfigBundle{
+		Format:     ConfigBundleFormat,
+		Version:    ConfigBundleVersion,
+		Resources:  []*BundleResource{{Name: "r", Type: "usb"}},
+		Devices:    []*BundleDevice{{Name: "d", Driver: "missing"}, {Name: "d"}},
+		Thresholds: []*BundleThreshold{{Device: "ghost", FieldName: "x", Operator: ">"}},
+	}
+	_, err := svc.Import(bundle, ConfigImportOptions{DryRun: true})
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{`invalid type "usb"`, `driver "missing" not found`, "device d: duplicate name", "threshold ghost.x: device not found"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestConfigBundle_ImportRollsBackOnFailure(t *testing.T) {
+	setupConfigBundleTestDB(t)
+	sourceDrivers := t.TempDir()
+	seedConfigBundleSource(t, sourceDrivers)
+	bundle, err := NewConfigBundleService(sourceDrivers, nil).Export(ConfigExportOptions{IncludeSecrets: true, IncludeDrivers: true})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	setupConfigBundleTestDB(t)
+	targetDrivers := t.TempDir()
+	svc := NewConfigBundleService(targetDrivers, nil)
+
+	// 驱动文件无法解码：写库前即失败
+	broken, _ := cloneConfigBundle(bundle)
+	broken.Drivers[0].Wasm = "not base64!"
+	if _, err := svc.Import(broken, ConfigImportOptions{}); err == nil {
+		t.Fatalf("expected invalid wasm to fail the import")
+	}
+	if resources, _ := database.ListResources(); len(resources) != 0 {
+		t.Fatalf("resources written before driver decode failed: %d", len(resources))
+	}
+
+	// 资源、驱动、设备、阈值写入后北向写入失败：全部回退，驱动文件不落盘
+	if _, err := database.ParamDB.Exec(`CREATE TRIGGER fail_northbound BEFORE INSERT ON northbound_configs
+		BEGIN SELECT RAISE(ABORT, 'northbound rejected'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	result, err := svc.Import(bundle, ConfigImportOptions{})
+	if err == nil || !strings.Contains(err.Error(), "northbound rejected") || result.Applied {
+		t.Fatalf("Import() = %+v, %v, want northbound failure", result, err)
+	}
+	resources, _ := database.ListResources()
+	drivers, _ := database.ListDrivers()
+	devices, _ := database.ListDevices()
+	thresholds, _ := database.ListThresholds()
+	if len(resources)+len(drivers)+len(devices)+len(thresholds) != 0 {
+		t.Fatalf("partial import left resources=%d drivers=%d devices=%d thresholds=%d",
+			len(resources), len(drivers), len(devices), len(thresholds))
+	}
+	if entries, _ := os.ReadDir(targetDrivers); len(entries) != 0 {
+		t.Fatalf("driver dir after failed import = %v", entries)
+	}
+
+	// 回退后库仍可正常使用
+	if _, err := database.ParamDB.Exec(`DROP TRIGGER fail_northbound`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	if result, err := svc.Import(bundle, ConfigImportOptions{}); err != nil || !result.Applied {
+		t.Fatalf("Import() after rollback = %+v, %v", result, err)
+	}
+	if devices, _ := database.ListDevices(); len(devices) != 1 {
+		t.Fatalf("devices = %d, want 1", len(devices))
+	}
+}
+
+func TestConfigBundle_ImportShieldReleasesActiveAlarms(t *testing.T) {
+	setupConfigBundleTestDB(t)
+	seedConfigBundleSource(t, t.TempDir())
+	devices, _ := database.ListDevices()
+	deviceID := devices[0].ID
+	if _, err := database.CreateThreshold(&models.Threshold{DeviceID: deviceID, FieldName: "Ub", Operator: ">", Value: 240, Severity: "warning"}); err != nil {
+		t.Fatalf("CreateThreshold: %v", err)
+	}
+	thresholds, _ := database.ListThresholds()
+	logIDs := map[int64]int64{}
+	for _, th := range thresholds {
+		thresholdID := th.ID
+		logID, err := database.CreateAlarmLog(&models.AlarmLog{DeviceID: deviceID, ThresholdID: &thresholdID, FieldName: th.FieldName,
+			ActualValue: 250, ThresholdValue: th.Value, Operator: th.Operator, Severity: th.Severity})
+		if err != nil {
+			t.Fatalf("CreateAlarmLog: %v", err)
+		}
+		if err := database.UpsertActiveAlarm(&models.ActiveAlarm{DeviceID: deviceID, ThresholdID: th.ID, AlarmLogID: logID, FieldName: th.FieldName,
+			ActualValue: 250, ThresholdValue: th.Value, Operator: th.Operator, Severity: th.Severity}); err != nil {
+			t.Fatalf("UpsertActiveAlarm: %v", err)
+		}
+		logIDs[th.ID] = logID
+	}
+
+	svc := NewConfigBundleService(t.TempDir(), nil)
+	bundle, err := svc.Export(ConfigExportOptions{IncludeSecrets: true})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	for _, th := range bundle.Thresholds {
+		if th.FieldName == "Ua" {
+			th.Shielded = 1
+		}
+	}
+	if result, err := svc.Import(bundle, ConfigImportOptions{}); err != nil || !result.Applied {
+		t.Fatalf("Import() = %+v, %v", result, err)
+	}
+
+	// 被屏蔽的 Ua 告警恢复，条件未变的 Ub 告警保持
+	for _, th := range thresholds {
+		active, _ := database.ListActiveAlarmsByThreshold(th.ID)
+		log, err := database.LoadAlarmLog(logIDs[th.ID])
+		if err != nil {
+			t.Fatalf("LoadAlarmLog: %v", err)
+		}
+		released := th.FieldName == "Ua"
+		if (len(active) == 0) != released || (log.ClearedAt != nil) != released {
+			t.Fatalf("threshold %s: active=%d cleared_at=%v, want released=%v", th.FieldName, len(active), log.ClearedAt, released)
+		}
+	}
+}
@@ -0,0 +1,973 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gonglijing/xunjiFsu/internal/collector"
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/northbound/nbtype"
+)
+
+const (
+	// ConfigImportModeMerge 新增/更新配置包中的条目，保留本机其它条目
+	ConfigImportModeMerge = "merge"
+	// ConfigImportModeReplace 额外删除本机有而配置包中没有的条目
+	ConfigImportModeReplace = "replace"
+)
+
+const (
+	configKindGateway    = "gateway"
+	configKindResource   = "resource"
+	configKindDriver     = "driver"
+	configKindDevice     = "device"
+	configKindThreshold  = "threshold"
+	configKindNorthbound = "northbound"
+	configKindRetention  = "retention_policy"
+
+	configActionCreate = "create"
+	configActionUpdate = "update"
+	configActionDelete = "delete"
+)
+
+// ConfigImportOptions 导入选项
+type ConfigImportOptions struct {
+	Mode   string
+	DryRun bool
+	// PathMap 资源路径与设备串口的重映射（旧路径 -> 新路径）
+	PathMap map[string]string
+}
+
+// ConfigImportChange 单个条目的变更
+type ConfigImportChange struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ConfigImportCount 按类型统计的变更数
+type ConfigImportCount struct {
+	Create    int `json:"create"`
+	Update    int `json:"update"`
+	Delete    int `json:"delete"`
+	Unchanged int `json:"unchanged"`
+}
+
+// ConfigImportResult 导入结果；dry_run 时只有差异，不写库
+type ConfigImportResult struct {
+	Mode            string                        `json:"mode"`
+	DryRun          bool                          `json:"dry_run"`
+	Applied         bool                          `json:"applied"`
+	Changes         []ConfigImportChange          `json:"changes"`
+	Summary         map[string]*ConfigImportCount `json:"summary"`
+	Warnings        []string                      `json:"warnings"`
+	RestartRequired bool                          `json:"restart_required"`
+}
+
+// configBundleIDs 本机快照中各条目对应的 ID
+type configBundleIDs struct {
+	resources   map[string]int64
+	drivers     map[string]int64
+	driverPaths map[string]string
+	devices     map[string]int64
+	thresholds  []int64
+	northbound  map[string]int64
+}
+
+type bundleEntry[T any] struct {
+	key  string
+	name string
+	item T
+	id   int64
+}
+
+type bundleOp[T any] struct {
+	action string
+	entry  bundleEntry[T]
+}
+
+type configImportPlan struct {
+	gateway    *BundleGateway
+	resources  []bundleOp[*BundleResource]
+	drivers    []bundleOp[*BundleDriver]
+	devices    []bundleOp[*BundleDevice]
+	thresholds []bundleOp[*BundleThreshold]
+	northbound []bundleOp[*BundleNorthbound]
+	retention  []bundleOp[*BundleRetentionPolicy]
+}
+
+// Import 校验配置包并计算与本机配置的差异；非 dry_run 时按依赖顺序写入 param.db
+func (s *ConfigBundleService) Import(bundle *ConfigBundle, opts ConfigImportOptions) (*ConfigImportResult, error) {
+	mode := strings.ToLower(strings.TrimSpace(opts.Mode))
+	if mode == "" {
+		mode = ConfigImportModeMerge
+	}
+	if mode != ConfigImportModeMerge && mode != ConfigImportModeReplace {
+		return nil, fmt.Errorf("%w: unsupported mode %q", ErrConfigBundleInvalid, opts.Mode)
+	}
+	if bundle == nil {
+		return nil, fmt.Errorf("%w: empty bundle", ErrConfigBundleInvalid)
+	}
+	if bundle.Format != ConfigBundleFormat {
+		return nil, fmt.Errorf("%w: format must be %q", ErrConfigBundleInvalid, ConfigBundleFormat)
+	}
+	if bundle.Version <= 0 || bundle.Version > ConfigBundleVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d, this gateway supports up to %d", ErrConfigBundleInvalid, bundle.Version, ConfigBundleVersion)
+	}
+	// 规范化、重映射和还原密钥都在副本上进行，不修改调用方的配置包
+	bundle, err := cloneConfigBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	local, ids, err := s.snapshotWithIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConfigImportResult{
+		Mode:     mode,
+		DryRun:   opts.DryRun,
+		Changes:  []ConfigImportChange{},
+		Summary:  map[string]*ConfigImportCount{},
+		Warnings: []string{},
+	}
+	applyConfigPathMap(bundle, opts.PathMap)
+	if err := s.prepareIncoming(bundle, local, result); err != nil {
+		return nil, err
+	}
+	if err := validateConfigBundle(bundle, local, mode == ConfigImportModeReplace); err != nil {
+		return nil, err
+	}
+
+	plan := planConfigImport(bundle, local, ids, mode == ConfigImportModeReplace, result)
+	if opts.DryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+	if err := s.applyConfigImport(plan, ids, result); err != nil {
+		return result, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+func cloneConfigBundle(bundle *ConfigBundle) (*ConfigBundle, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	clone := &ConfigBundle{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// snapshotWithIDs 读取本机快照及名称到 ID 的映射
+func (s *ConfigBundleService) snapshotWithIDs() (*ConfigBundle, *configBundleIDs, error) {
+	local, err := s.snapshot(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, err := loadConfigBundleIDs()
+	if err != nil {
+		return nil, nil, err
+	}
+	return local, ids, nil
+}
+
+func loadConfigBundleIDs() (*configBundleIDs, error) {
+	ids := &configBundleIDs{
+		resources:   map[string]int64{},
+		drivers:     map[string]int64{},
+		driverPaths: map[string]string{},
+		devices:     map[string]int64{models.SystemStatsDeviceName: models.SystemStatsDeviceID},
+		northbound:  map[string]int64{},
+	}
+	resources, err := database.ListResources()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range resources {
+		ids.resources[r.Name] = r.ID
+	}
+	drivers, err := database.ListDrivers()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range drivers {
+		ids.drivers[d.Name] = d.ID
+		ids.driverPaths[d.Name] = d.FilePath
+	}
+	devices, err := database.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		ids.devices[d.Name] = d.ID
+	}
+	// 与 snapshot 相同的过滤规则，保证与 local.Thresholds 一一对应
+	thresholds, err := database.ListThresholds()
+	if err != nil {
+		return nil, err
+	}
+	known := map[int64]bool{models.SystemStatsDeviceID: true}
+	for _, id := range ids.devices {
+		known[id] = true
+	}
+	for _, t := range thresholds {
+		if known[t.DeviceID] {
+			ids.thresholds = append(ids.thresholds, t.ID)
+		}
+	}
+	configs, err := database.ListNorthboundConfigs()
+	if err != nil {
+		return nil, err
+	}
+	for _, cfg := range configs {
+		ids.northbound[cfg.Name] = cfg.ID
+	}
+	return ids, nil
+}
+
+func applyConfigPathMap(bundle *ConfigBundle, pathMap map[string]string) {
+	if len(pathMap) == 0 {
+		return
+	}
+	for _, r := range bundle.Resources {
+		if r == nil {
+			continue
+		}
+		if mapped, ok := pathMap[r.Path]; ok {
+			r.Path = mapped
+		}
+	}
+	for _, d := range bundle.Devices {
+		if d == nil {
+			continue
+		}
+		if mapped, ok := pathMap[d.SerialPort]; ok {
+			d.SerialPort = mapped
+		}
+	}
+}
+
+// prepareIncoming 规范化配置包：还原脱敏字段、校验驱动文件摘要
+func (s *ConfigBundleService) prepareIncoming(bundle *ConfigBundle, local *ConfigBundle, result *ConfigImportResult) error {
+	localDrivers := map[string]*BundleDriver{}
+	for _, d := range local.Drivers {
+		localDrivers[d.Name] = d
+	}
+	for _, d := range bundle.Drivers {
+		if d == nil {
+			continue
+		}
+		d.Name = strings.TrimSpace(d.Name)
+		if d.FileName == "" {
+			d.FileName = d.Name + ".wasm"
+		}
+		if d.Wasm != "" {
+			data, err := base64.StdEncoding.DecodeString(d.Wasm)
+			if err != nil {
+				return fmt.Errorf("%w: driver %s: invalid wasm encoding", ErrConfigBundleInvalid, d.Name)
+			}
+			sum := sha256Hex(data)
+			if d.SHA256 != "" && !strings.EqualFold(d.SHA256, sum) {
+				return fmt.Errorf("%w: driver %s: wasm sha256 mismatch", ErrConfigBundleInvalid, d.Name)
+			}
+			d.SHA256 = sum
+			continue
+		}
+		existing := localDrivers[d.Name]
+		if existing == nil || existing.SHA256 == "" {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("driver %s: wasm file not included and not present on this gateway", d.Name))
+			d.SHA256 = ""
+			continue
+		}
+		if d.SHA256 != "" && !strings.EqualFold(d.SHA256, existing.SHA256) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("driver %s: local wasm differs from the exported one and was not included", d.Name))
+		}
+		// 未携带文件时沿用本机文件
+		d.SHA256 = existing.SHA256
+	}
+
+	localNorthbound := map[string]*BundleNorthbound{}
+	for _, nb := range local.Northbound {
+		localNorthbound[nb.Name] = nb
+	}
+	for _, nb := range bundle.Northbound {
+		if nb == nil {
+			continue
+		}
+		nb.Name = strings.TrimSpace(nb.Name)
+		existing := localNorthbound[nb.Name]
+		if existing == nil {
+			existing = &BundleNorthbound{}
+		}
+		unresolved := false
+		if nb.Password == RedactedSecret {
+			nb.Password = existing.Password
+			unresolved = existing.Password == ""
+		}
+		var ok bool
+		if nb.Config, ok = restoreSecretJSON(nb.Config, existing.Config); !ok {
+			unresolved = true
+		}
+		if nb.ExtConfig, ok = restoreSecretJSON(nb.ExtConfig, existing.ExtConfig); !ok {
+			unresolved = true
+		}
+		if unresolved {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("northbound %s: redacted secrets have no local value and were left empty", nb.Name))
+		}
+	}
+	return nil
+}
+
+// validateConfigBundle 校验条目内容、名称唯一性以及按名称的引用
+func validateConfigBundle(bundle *ConfigBundle, local *ConfigBundle, replace bool) error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	unique := func(kind string, names []string) map[string]bool {
+		seen := map[string]bool{}
+		for _, name := range names {
+			if strings.TrimSpace(name) == "" {
+				addf("%s: name is required", kind)
+				continue
+			}
+			if seen[name] {
+				addf("%s %s: duplicate name", kind, name)
+			}
+			seen[name] = true
+		}
+		return seen
+	}
+	// available 导入后存在的名称；合并模式下包括本机已有条目
+	available := func(incoming map[string]bool, localNames []string) map[string]bool {
+		out := map[string]bool{}
+		for name := range incoming {
+			out[name] = true
+		}
+		if !replace {
+			for _, name := range localNames {
+				out[name] = true
+			}
+		}
+		return out
+	}
+
+	if g := bundle.Gateway; g != nil {
+		if g.DataRetentionDays <= 0 || g.DataRetentionDays > MaxRetentionStorageDays {
+			addf("gateway: data_retention_days must be between 1 and %d", MaxRetentionStorageDays)
+		}
+		if err := ValidateAlarmRepeatIntervalSeconds(g.AlarmRepeatIntervalSeconds); err != nil {
+			addf("gateway: %v", err)
+		}
+	}
+
+	var names []string
+	for _, r := range bundle.Resources {
+		if r == nil {
+			addf("resource: empty entry")
+			continue
+		}
+		r.Name = strings.TrimSpace(r.Name)
+		switch r.Type {
+		case "serial", "net", "di", "do":
+		default:
+			addf("resource %s: invalid type %q", r.Name, r.Type)
+		}
+		names = append(names, r.Name)
+	}
+	resources := available(unique(configKindResource, names), bundleNames(local.Resources, func(r *BundleResource) string { return r.Name }))
+
+	names = names[:0]
+	for _, d := range bundle.Drivers {
+		if d == nil {
+			addf("driver: empty entry")
+			continue
+		}
+		if d.FileName != filepath.Base(d.FileName) || !IsWasmFileName(d.FileName) {
+			addf("driver %s: invalid file_name %q", d.Name, d.FileName)
+		}
+		names = append(names, d.Name)
+	}
+	drivers := available(unique(configKindDriver, names), bundleNames(local.Drivers, func(d *BundleDriver) string { return d.Name }))
+
+	names = names[:0]
+	for _, d := range bundle.Devices {
+		if d == nil {
+			addf("device: empty entry")
+			continue
+		}
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == models.SystemStatsDeviceName {
+			addf("device %s: reserved name", d.Name)
+		}
+		if d.Driver != "" && !drivers[d.Driver] {
+			addf("device %s: driver %q not found", d.Name, d.Driver)
+		}
+		if d.Resource != "" && !resources[d.Resource] {
+			addf("device %s: resource %q not found", d.Name, d.Resource)
+		}
+		names = append(names, d.Name)
+	}
+	devices := available(unique(configKindDevice, names), bundleNames(local.Devices, func(d *BundleDevice) string { return d.Name }))
+	devices[models.SystemStatsDeviceName] = true
+
+	for _, t := range bundle.Thresholds {
+		if t == nil {
+			addf("threshold: empty entry")
+			continue
+		}
+		if !devices[t.Device] {
+			addf("threshold %s.%s: device not found", t.Device, t.FieldName)
+			continue
+		}
+		model := t.toModel(0)
+		NormalizeThresholdInput(model)
+		if err := ValidateThresholdExpression(model); err != nil {
+			addf("threshold %s.%s: %v", t.Device, t.FieldName, err)
+			continue
+		}
+		*t = *bundleThresholdFromModel(t.Device, model)
+	}
+
+	names = names[:0]
+	for _, nb := range bundle.Northbound {
+		if nb == nil {
+			addf("northbound: empty entry")
+			continue
+		}
+		nb.Type = nbtype.Normalize(nb.Type)
+		if !nbtype.IsSupported(nb.Type) {
+			addf("northbound %s: unsupported type %q", nb.Name, nb.Type)
+		}
+		names = append(names, nb.Name)
+	}
+	unique(configKindNorthbound, names)
+
+	names = names[:0]
+	for _, p := range bundle.RetentionPolicies {
+		if p == nil {
+			addf("retention_policy: empty entry")
+			continue
+		}
+		if !devices[p.Device] {
+			addf("retention_policy %s: device not found", p.Device)
+		}
+		policy := &database.DeviceRetentionPolicy{StorageDays: p.StorageDays, Enabled: p.Enabled}
+		if err := ValidateDeviceRetentionPolicy(policy); err != nil {
+			addf("retention_policy %s: %v", p.Device, err)
+		}
+		p.Enabled = policy.Enabled
+		names = append(names, p.Device)
+	}
+	unique(configKindRetention, names)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrConfigBundleInvalid, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func bundleNames[T any](items []T, name func(T) string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, name(item))
+	}
+	return out
+}
+
+func planConfigImport(bundle, local *ConfigBundle, ids *configBundleIDs, replace bool, result *ConfigImportResult) *configImportPlan {
+	plan := &configImportPlan{}
+
+	if bundle.Gateway != nil {
+		if fields := diffBundleFields(local.Gateway, bundle.Gateway); len(fields) > 0 {
+			plan.gateway = bundle.Gateway
+			result.record(configKindGateway, bundle.Gateway.GatewayName, configActionUpdate, fields)
+		} else {
+			result.record(configKindGateway, bundle.Gateway.GatewayName, "", nil)
+		}
+	}
+
+	byName := func(m map[string]int64) func(string) int64 {
+		return func(name string) int64 { return m[name] }
+	}
+	plan.resources = planBundleKind(result, configKindResource,
+		namedEntries(local.Resources, func(r *BundleResource) string { return r.Name }, byName(ids.resources)),
+		namedEntries(bundle.Resources, func(r *BundleResource) string { return r.Name }, nil), replace)
+	plan.drivers = planBundleKind(result, configKindDriver,
+		namedEntries(local.Drivers, func(d *BundleDriver) string { return d.Name }, byName(ids.drivers)),
+		namedEntries(bundle.Drivers, func(d *BundleDriver) string { return d.Name }, nil), replace)
+	plan.devices = planBundleKind(result, configKindDevice,
+		namedEntries(local.Devices, func(d *BundleDevice) string { return d.Name }, byName(ids.devices)),
+		namedEntries(bundle.Devices, func(d *BundleDevice) string { return d.Name }, nil), replace)
+
+	localThresholds := thresholdEntries(local.Thresholds)
+	for i := range localThresholds {
+		if i < len(ids.thresholds) {
+			localThresholds[i].id = ids.thresholds[i]
+		}
+	}
+	plan.thresholds = planBundleKind(result, configKindThreshold, localThresholds, thresholdEntries(bundle.Thresholds), replace)
+
+	plan.northbound = planBundleKind(result, configKindNorthbound,
+		namedEntries(local.Northbound, func(n *BundleNorthbound) string { return n.Name }, byName(ids.northbound)),
+		namedEntries(bundle.Northbound, func(n *BundleNorthbound) string { return n.Name }, nil), replace)
+	plan.retention = planBundleKind(result, configKindRetention,
+		namedEntries(local.RetentionPolicies, func(p *BundleRetentionPolicy) string { return p.Device }, byName(ids.devices)),
+		namedEntries(bundle.RetentionPolicies, func(p *BundleRetentionPolicy) string { return p.Device }, nil), replace)
+	return plan
+}
+
+func namedEntries[T any](items []T, name func(T) string, id func(string) int64) []bundleEntry[T] {
+	entries := make([]bundleEntry[T], 0, len(items))
+	for _, item := range items {
+		n := name(item)
+		entry := bundleEntry[T]{key: n, name: n, item: item}
+		if id != nil {
+			entry.id = id(n)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// thresholdEntries 阈值按 设备/字段/运算符/等级 及其出现序号匹配
+func thresholdEntries(items []*BundleThreshold) []bundleEntry[*BundleThreshold] {
+	seen := map[string]int{}
+	entries := make([]bundleEntry[*BundleThreshold], 0, len(items))
+	for _, t := range items {
+		base := strings.Join([]string{t.Device, t.FieldName, t.Operator, t.Severity}, "\x00")
+		seen[base]++
+		entries = append(entries, bundleEntry[*BundleThreshold]{
+			key:  fmt.Sprintf("%s#%d", base, seen[base]),
+			name: fmt.Sprintf("%s.%s %s %s", t.Device, t.FieldName, t.Operator, t.Severity),
+			item: t,
+		})
+	}
+	return entries
+}
+
+func planBundleKind[T any](result *ConfigImportResult, kind string, local, incoming []bundleEntry[T], replace bool) []bundleOp[T] {
+	localByKey := make(map[string]bundleEntry[T], len(local))
+	for _, entry := range local {
+		localByKey[entry.key] = entry
+	}
+
+	var ops []bundleOp[T]
+	matched := map[string]bool{}
+	for _, entry := range incoming {
+		existing, ok := localByKey[entry.key]
+		if !ok {
+			ops = append(ops, bundleOp[T]{action: configActionCreate, entry: entry})
+			result.record(kind, entry.name, configActionCreate, nil)
+			continue
+		}
+		matched[entry.key] = true
+		fields := diffBundleFields(existing.item, entry.item)
+		if len(fields) == 0 {
+			result.record(kind, entry.name, "", nil)
+			continue
+		}
+		entry.id = existing.id
+		ops = append(ops, bundleOp[T]{action: configActionUpdate, entry: entry})
+		result.record(kind, entry.name, configActionUpdate, fields)
+	}
+	if replace {
+		for _, entry := range local {
+			if matched[entry.key] {
+				continue
+			}
+			ops = append(ops, bundleOp[T]{action: configActionDelete, entry: entry})
+			result.record(kind, entry.name, configActionDelete, nil)
+		}
+	}
+	return ops
+}
+
+// record 记录变更；action 为空表示无变化，只计数
+func (r *ConfigImportResult) record(kind, name, action string, fields []string) {
+	count := r.Summary[kind]
+	if count == nil {
+		count = &ConfigImportCount{}
+		r.Summary[kind] = count
+	}
+	switch action {
+	case configActionCreate:
+		count.Create++
+	case configActionUpdate:
+		count.Update++
+	case configActionDelete:
+		count.Delete++
+	default:
+		count.Unchanged++
+		return
+	}
+	r.Changes = append(r.Changes, ConfigImportChange{Kind: kind, Name: name, Action: action, Fields: fields})
+}
+
+// diffBundleFields 比较两个条目的 JSON 字段，JSON 文本字段按语义比较；wasm 内容以 sha256 比较
+func diffBundleFields(local, incoming any) []string {
+	a, b := bundleFieldMap(local), bundleFieldMap(incoming)
+	var fields []string
+	for key, value := range b {
+		if key == "wasm" {
+			continue
+		}
+		if !sameBundleValue(a[key], value) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok && key != "wasm" {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func bundleFieldMap(v any) map[string]any {
+	out := map[string]any{}
+	if data, err := json.Marshal(v); err == nil {
+		_ = json.Unmarshal(data, &out)
+	}
+	return out
+}
+
+func sameBundleValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	sa, okA := a.(string)
+	sb, okB := b.(string)
+	if !okA || !okB {
+		return false
+	}
+	var ja, jb any
+	if json.Unmarshal([]byte(sa), &ja) != nil || json.Unmarshal([]byte(sb), &jb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(ja, jb)
+}
+
+// applyConfigImport 先解码并暂存配置包中的驱动文件，再在一个 param.db 事务中依次写入
+// 网关/资源/驱动/设备/阈值/北向/保留策略并按反向依赖删除，最后启用暂存的驱动文件；
+// 任一步失败时回滚事务、丢弃暂存文件，不会留下只导入了一半的配置
+func (s *ConfigBundleService) applyConfigImport(plan *configImportPlan, ids *configBundleIDs, result *ConfigImportResult) error {
+	staged, err := s.stageBundleDriverFiles(plan.drivers)
+	if err != nil {
+		return err
+	}
+	defer staged.discard()
+
+	var touchedDevices []int64
+	// releaseThresholds 提交后需要释放活动告警的阈值：被删除或判定条件已变化
+	var releaseThresholds []bundleEntry[*BundleThreshold]
+	err = database.WithParamTx(func(tx *database.ParamTx) error {
+		if err := s.writeConfigImport(tx, plan, ids, staged, result, &touchedDevices, &releaseThresholds); err != nil {
+			return err
+		}
+		if err := applyConfigDeletes(tx, plan, result, &touchedDevices, &releaseThresholds); err != nil {
+			return err
+		}
+		return staged.install()
+	})
+	// 无论成功还是回退，缓存都要按库中最终内容重新加载
+	if plan.gateway != nil {
+		collector.InvalidateAlarmRepeatIntervalCache()
+	}
+	for _, deviceID := range touchedDevices {
+		collector.InvalidateDeviceCache(deviceID)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range releaseThresholds {
+		if err := s.collector.ReleaseThresholdAlarms(entry.id); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("threshold %s: release active alarms: %v", entry.name, err))
+		}
+	}
+	return nil
+}
+
+// writeConfigImport 在 tx 中写入新增与更新的条目，touchedDevices 收集需要刷新阈值缓存的设备，
+// releaseThresholds 收集判定条件变化、需要释放活动告警的阈值
+func (s *ConfigBundleService) writeConfigImport(tx *database.ParamTx, plan *configImportPlan, ids *configBundleIDs, staged stagedDriverFiles, result *ConfigImportResult,
+	touchedDevices *[]int64, releaseThresholds *[]bundleEntry[*BundleThreshold]) error {
+	fail := func(kind, name string, err error) error {
+		return fmt.Errorf("import %s %s: %w", kind, name, err)
+	}
+
+	if g := plan.gateway; g != nil {
+		cfg, err := tx.GetGatewayConfig()
+		if err != nil {
+			return fail(configKindGateway, g.GatewayName, err)
+		}
+		cfg.GatewayName = g.GatewayName
+		cfg.DataRetentionDays = g.DataRetentionDays
+		if err := tx.UpdateGatewayConfig(cfg); err != nil {
+			return fail(configKindGateway, g.GatewayName, err)
+		}
+		if err := tx.UpdateAlarmRepeatIntervalSeconds(g.AlarmRepeatIntervalSeconds); err != nil {
+			return fail(configKindGateway, g.GatewayName, err)
+		}
+	}
+
+	for _, op := range plan.resources {
+		r := op.entry.item
+		model := &models.Resource{ID: op.entry.id, Name: r.Name, Type: r.Type, Path: r.Path, Enabled: r.Enabled}
+		var err error
+		switch op.action {
+		case configActionCreate:
+			model.ID, err = tx.CreateResource(model)
+			ids.resources[r.Name] = model.ID
+		case configActionUpdate:
+			err = tx.UpdateResource(model)
+		}
+		if err != nil {
+			return fail(configKindResource, r.Name, err)
+		}
+		result.RestartRequired = true
+	}
+
+	for _, op := range plan.drivers {
+		if op.action == configActionDelete {
+			continue
+		}
+		d := op.entry.item
+		model := &models.Driver{ID: op.entry.id, Name: d.Name, FilePath: s.bundleDriverFilePath(staged, d, ids.driverPaths[d.Name]), Description: d.Description,
+			Version: d.Version, ConfigSchema: d.ConfigSchema, Enabled: d.Enabled}
+		var err error
+		if op.action == configActionCreate {
+			model.ID, err = tx.CreateDriver(model)
+			ids.drivers[d.Name] = model.ID
+		} else {
+			err = tx.UpdateDriver(model)
+		}
+		if err != nil {
+			return fail(configKindDriver, d.Name, err)
+		}
+		result.RestartRequired = true
+	}
+
+	for _, op := range plan.devices {
+		if op.action == configActionDelete {
+			continue
+		}
+		d := op.entry.item
+		model := d.toModel()
+		model.ID = op.entry.id
+		if d.Driver != "" {
+			id := ids.drivers[d.Driver]
+			model.DriverID = &id
+		}
+		if d.Resource != "" {
+			id := ids.resources[d.Resource]
+			model.ResourceID = &id
+		}
+		var err error
+		if op.action == configActionCreate {
+			model.ID, err = tx.CreateDevice(model)
+			ids.devices[d.Name] = model.ID
+		} else {
+			err = tx.UpdateDevice(model)
+		}
+		if err != nil {
+			return fail(configKindDevice, d.Name, err)
+		}
+		*touchedDevices = append(*touchedDevices, model.ID)
+		result.RestartRequired = true
+	}
+
+	for _, op := range plan.thresholds {
+		if op.action == configActionDelete {
+			continue
+		}
+		model := op.entry.item.toModel(ids.devices[op.entry.item.Device])
+		model.ID = op.entry.id
+		var old *models.Threshold
+		var err error
+		if op.action == configActionCreate {
+			_, err = tx.CreateThreshold(model)
+		} else {
+			old, _ = tx.LoadThreshold(model.ID)
+			err = tx.UpdateThreshold(model)
+		}
+		if err != nil {
+			return fail(configKindThreshold, op.entry.name, err)
+		}
+		*touchedDevices = append(*touchedDevices, BuildThresholdCacheDeviceIDs(model, old)...)
+		if old != nil && thresholdConditionChanged(old, model) {
+			*releaseThresholds = append(*releaseThresholds, op.entry)
+		}
+	}
+
+	for _, op := range plan.northbound {
+		if op.action == configActionDelete {
+			continue
+		}
+		model := op.entry.item.toModel()
+		model.ID = op.entry.id
+		var err error
+		if op.action == configActionCreate {
+			_, err = tx.CreateNorthboundConfig(model)
+		} else {
+			err = tx.UpdateNorthboundConfig(model)
+		}
+		if err != nil {
+			return fail(configKindNorthbound, model.Name, err)
+		}
+		result.RestartRequired = true
+	}
+
+	for _, op := range plan.retention {
+		if op.action == configActionDelete {
+			continue
+		}
+		p := op.entry.item
+		policy := &database.DeviceRetentionPolicy{DeviceID: ids.devices[p.Device], StorageDays: p.StorageDays, Enabled: p.Enabled}
+		if err := tx.UpsertDeviceRetentionPolicy(policy); err != nil {
+			return fail(configKindRetention, p.Device, err)
+		}
+	}
+
+	return nil
+}
+
+// applyConfigDeletes replace 模式下在 tx 中删除多余条目，先删引用方；驱动文件保留在磁盘上
+func applyConfigDeletes(tx *database.ParamTx, plan *configImportPlan, result *ConfigImportResult, touchedDevices *[]int64, releaseThresholds *[]bundleEntry[*BundleThreshold]) error {
+	for _, op := range plan.thresholds {
+		if op.action != configActionDelete {
+			continue
+		}
+		old, _ := tx.LoadThreshold(op.entry.id)
+		if err := tx.DeleteThreshold(op.entry.id); err != nil {
+			return fmt.Errorf("delete threshold %s: %w", op.entry.name, err)
+		}
+		*touchedDevices = append(*touchedDevices, BuildThresholdCacheDeviceIDs(nil, old)...)
+		*releaseThresholds = append(*releaseThresholds, op.entry)
+	}
+	for _, op := range plan.retention {
+		if op.action == configActionDelete {
+			if err := tx.DeleteDeviceRetentionPolicy(op.entry.id); err != nil {
+				return fmt.Errorf("delete retention_policy %s: %w", op.entry.name, err)
+			}
+		}
+	}
+	for _, op := range plan.northbound {
+		if op.action == configActionDelete {
+			if err := tx.DeleteNorthboundConfig(op.entry.id); err != nil {
+				return fmt.Errorf("delete northbound %s: %w", op.entry.name, err)
+			}
+			result.RestartRequired = true
+		}
+	}
+	for _, op := range plan.devices {
+		if op.action == configActionDelete {
+			if err := tx.DeleteDevice(op.entry.id); err != nil {
+				return fmt.Errorf("delete device %s: %w", op.entry.name, err)
+			}
+			result.RestartRequired = true
+		}
+	}
+	for _, op := range plan.drivers {
+		if op.action == configActionDelete {
+			if err := tx.DeleteDriver(op.entry.id); err != nil {
+				return fmt.Errorf("delete driver %s: %w", op.entry.name, err)
+			}
+			result.RestartRequired = true
+		}
+	}
+	for _, op := range plan.resources {
+		if op.action == configActionDelete {
+			if err := tx.DeleteResource(op.entry.id); err != nil {
+				return fmt.Errorf("delete resource %s: %w", op.entry.name, err)
+			}
+			result.RestartRequired = true
+		}
+	}
+	return nil
+}
+
+// stagedDriverFile 已解码、尚未启用的驱动文件
+type stagedDriverFile struct {
+	tmpPath  string
+	destPath string
+}
+
+// stagedDriverFiles 按驱动名索引的暂存驱动文件
+type stagedDriverFiles map[string]stagedDriverFile
+
+// stageBundleDriverFiles 写库前解码配置包携带的 wasm 并写入 <文件名>.tmp，失败时不改动驱动目录中的现有文件
+func (s *ConfigBundleService) stageBundleDriverFiles(ops []bundleOp[*BundleDriver]) (stagedDriverFiles, error) {
+	staged := stagedDriverFiles{}
+	for _, op := range ops {
+		d := op.entry.item
+		if op.action == configActionDelete || d.Wasm == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(d.Wasm)
+		if err != nil {
+			staged.discard()
+			return nil, fmt.Errorf("import %s %s: %w", configKindDriver, d.Name, err)
+		}
+		dir := strings.TrimSpace(s.driversDir)
+		if dir == "" {
+			dir = "drivers"
+		}
+		destPath := filepath.Join(dir, d.FileName)
+		tmpPath := destPath + ".tmp"
+		if err := os.MkdirAll(dir, 0o755); err == nil {
+			err = os.WriteFile(tmpPath, data, 0o644)
+		}
+		if err != nil {
+			staged.discard()
+			return nil, fmt.Errorf("import %s %s: %w", configKindDriver, d.Name, err)
+		}
+		staged[d.Name] = stagedDriverFile{tmpPath: tmpPath, destPath: destPath}
+	}
+	return staged, nil
+}
+
+// bundleDriverFilePath 配置包携带 wasm 时为驱动目录中的文件，否则沿用本机驱动文件路径
+func (s *ConfigBundleService) bundleDriverFilePath(staged stagedDriverFiles, d *BundleDriver, localPath string) string {
+	if file, ok := staged[d.Name]; ok {
+		return file.destPath
+	}
+	if localPath != "" {
+		return localPath
+	}
+	return driverPath(s.driversDir, d.Name, "")
+}
+
+// install 把暂存文件改名为正式驱动文件
+func (f stagedDriverFiles) install() error {
+	for name, file := range f {
+		if err := os.Rename(file.tmpPath, file.destPath); err != nil {
+			return fmt.Errorf("import %s %s: %w", configKindDriver, name, err)
+		}
+		delete(f, name)
+	}
+	return nil
+}
+
+// discard 删除尚未启用的暂存文件
+func (f stagedDriverFiles) discard() {
+	for name, file := range f {
+		_ = os.Remove(file.tmpPath)
+		delete(f, name)
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+	"github.com/gonglijing/xunjiFsu/internal/parquet"
+)
+
+// 历史导出格式与布局
+const (
+	HistoryExportFormatCSV     = "csv"
+	HistoryExportFormatParquet = "parquet"
+
+	HistoryExportLayoutLong = "long"
+	HistoryExportLayoutWide = "wide"
+)
+
+// HistoryExportRequest 历史导出参数
+type HistoryExportRequest struct {
+	Query    database.HistoryExportQuery
+	Format   string
+	Layout   string
+	Gzip     bool
+	Location *time.Location
+}
+
+// historyExportEncoder 逐页写出导出数据
+type historyExportEncoder interface {
+	WritePage(page []*database.DataPoint) error
+	Close() error
+}
+
+// ValidateHistoryExport 校验格式与布局组合，返回规范化后的请求
+func ValidateHistoryExport(req HistoryExportRequest) (HistoryExportRequest, error) {
+	if req.Format == "" {
+		req.Format = HistoryExportFormatCSV
+	}
+	if req.Layout == "" {
+		req.Layout = HistoryExportLayoutLong
+	}
+	if req.Location == nil {
+		req.Location = time.UTC
+	}
+	switch req.Format {
+	case HistoryExportFormatCSV, HistoryExportFormatParquet:
+	default:
+		return req, fmt.Errorf("unsupported format %q", req.Format)
+	}
+	switch req.Layout {
+	case HistoryExportLayoutLong:
+	case HistoryExportLayoutWide:
+		if req.Format != HistoryExportFormatCSV {
+			return req, fmt.Errorf("wide layout is only supported for csv")
+		}
+	default:
+		return req, fmt.Errorf("unsupported layout %q", req.Layout)
+	}
+	if len(req.Query.DeviceIDs) == 0 {
+		return req, fmt.Errorf("device_id is required")
+	}
+	return req, nil
+}
+
+// ExportHistoryData 把历史数据流式写入 w，每写完一页调用 flush（可为 nil）
+func (s *DataService) ExportHistoryData(ctx context.Context, w io.Writer, req HistoryExportRequest, flush func()) error {
+	req, err := ValidateHistoryExport(req)
+	if err != nil {
+		return err
+	}
+
+	out := w
+	var gz *gzip.Writer
+	if req.Gzip && req.Format == HistoryExportFormatCSV {
+		gz = gzip.NewWriter(w)
+		out = gz
+	}
+
+	encoder, err := newHistoryExportEncoder(out, req)
+	if err != nil {
+		return err
+	}
+	err = database.StreamDataPoints(req.Query, func(page []*database.DataPoint) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := encoder.WritePage(page); err != nil {
+			return err
+		}
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		if flush != nil {
+			flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+func newHistoryExportEncoder(w io.Writer, req HistoryExportRequest) (historyExportEncoder, error) {
+	if req.Format == HistoryExportFormatParquet {
+		return newParquetHistoryEncoder(w, req.Gzip)
+	}
+	if req.Layout == HistoryExportLayoutWide {
+		fields := req.Query.FieldNames
+		if len(fields) == 0 {
+			var err error
+			fields, err = database.ListHistoryFieldNames(req.Query.DeviceIDs, req.Query.StartTime, req.Query.EndTime)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return newWideCSVHistoryEncoder(w, fields, req.Location)
+	}
+	return newLongCSVHistoryEncoder(w, req.Location)
+}
+
+func formatExportTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// longCSVHistoryEncoder 每行一个（设备, 字段, 时间）值
+type longCSVHistoryEncoder struct {
+	w   *csv.Writer
+	loc *time.Location
+}
+
+func newLongCSVHistoryEncoder(w io.Writer, loc *time.Location) (*longCSVHistoryEncoder, error) {
+	e := &longCSVHistoryEncoder{w: csv.NewWriter(w), loc: loc}
+	if err := e.w.Write([]string{"device_id", "device_name", "field_name", "collected_at", "value"}); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *longCSVHistoryEncoder) WritePage(page []*database.DataPoint) error {
+	record := make([]string, 5)
+	for _, point := range page {
+		record[0] = strconv.FormatInt(point.DeviceID, 10)
+		record[1] = point.DeviceName
+		record[2] = point.FieldName
+		record[3] = formatExportTime(point.CollectedAt, e.loc)
+		record[4] = point.Value
+		if err := e.w.Write(record); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *longCSVHistoryEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// wideCSVHistoryEncoder 每行一个（设备, 时间），字段按列展开；同一时刻的行可能跨页
+type wideCSVHistoryEncoder struct {
+	w          *csv.Writer
+	loc        *time.Location
+	fieldIndex map[string]int
+	record     []string
+	deviceID   int64
+	at         time.Time
+	pending    bool
+}
+
+func newWideCSVHistoryEncoder(w io.Writer, fields []string, loc *time.Location) (*wideCSVHistoryEncoder, error) {
+	e := &wideCSVHistoryEncoder{
+		w:          csv.NewWriter(w),
+		loc:        loc,
+		fieldIndex: make(map[string]int, len(fields)),
+		record:     make([]string, 3+len(fields)),
+	}
+	header := append([]string{"device_id", "device_name", "collected_at"}, fields...)
+	for i, field := range fields {
+		e.fieldIndex[field] = 3 + i
+	}
+	if err := e.w.Write(header); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *wideCSVHistoryEncoder) WritePage(page []*database.DataPoint) error {
+	for _, point := range page {
+		col, ok := e.fieldIndex[point.FieldName]
+		if !ok {
+			continue
+		}
+		if e.pending && (point.DeviceID != e.deviceID || !point.CollectedAt.Equal(e.at)) {
+			if err := e.flushRecord(); err != nil {
+				return err
+			}
+		}
+		if !e.pending {
+			e.deviceID = point.DeviceID
+			e.at = point.CollectedAt
+			e.record[0] = strconv.FormatInt(point.DeviceID, 10)
+			e.record[1] = point.DeviceName
+			e.record[2] = formatExportTime(point.CollectedAt, e.loc)
+			e.pending = true
+		}
+		e.record[col] = point.Value
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *wideCSVHistoryEncoder) flushRecord() error {
+	err := e.w.Write(e.record)
+	clear(e.record)
+	e.pending = false
+	return err
+}
+
+func (e *wideCSVHistoryEncoder) Close() error {
+	if e.pending {
+		if err := e.flushRecord(); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// parquetHistoryEncoder 长表布局；collected_at 为 UTC 毫秒时间戳，value_num 仅数值/布尔行有值
+type parquetHistoryEncoder struct {
+	w *parquet.Writer
+}
+
+var parquetHistoryColumns = []parquet.Column{
+	{Name: "device_id", Type: parquet.TypeInt64, Converted: parquet.ConvertedNone},
+	{Name: "device_name", Type: parquet.TypeByteArray, Converted: parquet.ConvertedUTF8},
+	{Name: "field_name", Type: parquet.TypeByteArray, Converted: parquet.ConvertedUTF8},
+	{Name: "collected_at", Type: parquet.TypeInt64, Converted: parquet.ConvertedTimestampMillis},
+	{Name: "value", Type: parquet.TypeByteArray, Converted: parquet.ConvertedUTF8},
+	{Name: "value_num", Type: parquet.TypeDouble, Converted: parquet.ConvertedNone, Optional: true},
+	{Name: "value_type", Type: parquet.TypeByteArray, Converted: parquet.ConvertedUTF8},
+}
+
+func newParquetHistoryEncoder(w io.Writer, compress bool) (*parquetHistoryEncoder, error) {
+	codec := parquet.CodecUncompressed
+	if compress {
+		codec = parquet.CodecGzip
+	}
+	pw, err := parquet.NewWriter(w, parquetHistoryColumns, codec, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &parquetHistoryEncoder{w: pw}, nil
+}
+
+func (e *parquetHistoryEncoder) WritePage(page []*database.DataPoint) error {
+	for _, point := range page {
+		var num any
+		if v, ok := exportNumericValue(point); ok {
+			num = v
+		}
+		if err := e.w.WriteRow(
+			point.DeviceID,
+			point.DeviceName,
+			point.FieldName,
+			point.CollectedAt.UnixMilli(),
+			point.Value,
+			num,
+			point.ValueType,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *parquetHistoryEncoder) Close() error {
+	return e.w.Close()
+}
+
+func exportNumericValue(point *database.DataPoint) (float64, bool) {
+	switch point.ValueType {
+	case models.ValueTypeInt, models.ValueTypeFloat, models.ValueTypeBool:
+		num, _, ok := models.NumericCollectValue(point.Value)
+		return num, ok
+	}
+	return 0, false
+}
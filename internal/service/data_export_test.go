@@ -0,0 +1,58 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+)
+
+func TestWideCSVHistoryEncoder_GroupsRowsAcrossPages(t *testing.T) {
+	at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+	e, err := newWideCSVHistoryEncoder(&out, []string{"Ia", "Ua"}, time.FixedZone("+08:00", 8*3600))
+	if err != nil {
+		t.Fatalf("newWideCSVHistoryEncoder() error = %v", err)
+	}
+	pages := [][]*database.DataPoint{
+		{{DeviceID: 1, DeviceName: "m1", FieldName: "Ia", Value: "1.5", CollectedAt: at}},
+		{
+			{DeviceID: 1, DeviceName: "m1", FieldName: "Ua", Value: "220", CollectedAt: at},
+			{DeviceID: 1, DeviceName: "m1", FieldName: "Ua", Value: "221", CollectedAt: at.Add(time.Minute)},
+			{DeviceID: 1, DeviceName: "m1", FieldName: "other", Value: "x", CollectedAt: at.Add(2 * time.Minute)},
+		},
+	}
+	for _, page := range pages {
+		if err := e.WritePage(page); err != nil {
+			t.Fatalf("WritePage() error = %v", err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "device_id,device_name,collected_at,Ia,Ua\n" +
+		"1,m1,2026-10-01T08:00:00+08:00,1.5,220\n" +
+		"1,m1,2026-10-01T08:01:00+08:00,,221\n"
+	if out.String() != want {
+		t.Fatalf("csv=\n%s\nwant=\n%s", out.String(), want)
+	}
+}
+
+func TestValidateHistoryExport(t *testing.T) {
+	req, err := ValidateHistoryExport(HistoryExportRequest{Query: database.HistoryExportQuery{DeviceIDs: []int64{1}}})
+	if err != nil || req.Format != HistoryExportFormatCSV || req.Layout != HistoryExportLayoutLong || req.Location != time.UTC {
+		t.Fatalf("defaults = %+v, %v", req, err)
+	}
+	if _, err := ValidateHistoryExport(HistoryExportRequest{
+		Query:  database.HistoryExportQuery{DeviceIDs: []int64{1}},
+		Format: HistoryExportFormatParquet,
+		Layout: HistoryExportLayoutWide,
+	}); err == nil {
+		t.Fatalf("expected wide parquet to be rejected")
+	}
+	if _, err := ValidateHistoryExport(HistoryExportRequest{Format: "xlsx", Query: database.HistoryExportQuery{DeviceIDs: []int64{1}}}); err == nil {
+		t.Fatalf("expected unsupported format error")
+	}
+}
@@ -8,10 +8,11 @@ import (
 )
 
 type HistoryDataQuery struct {
-	DeviceID  *int64
-	FieldName string
-	StartTime time.Time
-	EndTime   time.Time
+	DeviceID    *int64
+	FieldName   string
+	StartTime   time.Time
+	EndTime     time.Time
+	Aggregation database.HistoryAggregation
 }
 
 type DataService struct{}
@@ -31,7 +32,7 @@ func (s *DataService) LoadDeviceDataCache(deviceID int64) ([]*models.DataCache,
 func (s *DataService) QueryHistoryData(query HistoryDataQuery) ([]*database.DataPoint, error) {
 	if query.DeviceID != nil {
 		if query.FieldName != "" {
-			return database.GetDataPointsByDeviceFieldAndTime(*query.DeviceID, query.FieldName, query.StartTime, query.EndTime, 2000)
+			return database.GetDataPointsByDeviceFieldAndTime(*query.DeviceID, query.FieldName, query.StartTime, query.EndTime, 2000, query.Aggregation)
 		}
 		if !query.StartTime.IsZero() || !query.EndTime.IsZero() {
 			return database.GetDataPointsByDeviceAndTimeLimit(*query.DeviceID, query.StartTime, query.EndTime, 2000)
@@ -45,3 +46,8 @@ func (s *DataService) QueryHistoryData(query HistoryDataQuery) ([]*database.Data
 func (s *DataService) ClearHistoryPoint(deviceID int64, fieldName string) (int64, error) {
 	return database.DeleteHistoryDataByPoint(deviceID, fieldName)
 }
+
+// QueryHistorySeries 多序列历史查询（聚合、补齐与游标分页）
+func (s *DataService) QueryHistorySeries(query database.HistorySeriesQuery) (*database.HistorySeriesResult, error) {
+	return database.QueryHistorySeries(query)
+}
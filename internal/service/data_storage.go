@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// MaxRetentionStorageDays 设备保留天数上限
+const MaxRetentionStorageDays = 3650
+
+// ErrRetentionDeviceNotFound 设备保留策略指向的设备不存在
+var ErrRetentionDeviceNotFound = errors.New("device not found")
+
+// DataStorageView 磁盘保护状态与设备保留策略
+type DataStorageView struct {
+	Status               *database.DataStorageStatus       `json:"status"`
+	DefaultRetentionDays int                               `json:"default_retention_days"`
+	Policies             []*database.DeviceRetentionPolicy `json:"policies"`
+}
+
+// LoadDataStorage 返回 data.db 磁盘保护状态及全部设备保留策略
+func (s *DataService) LoadDataStorage() (*DataStorageView, error) {
+	policies, err := database.ListDeviceRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+	if policies == nil {
+		policies = []*database.DeviceRetentionPolicy{}
+	}
+	return &DataStorageView{
+		Status:               database.GetDataStorageStatus(),
+		DefaultRetentionDays: database.GetGatewayDataRetentionDays(),
+		Policies:             policies,
+	}, nil
+}
+
+// ValidateDeviceRetentionPolicy 校验设备保留策略
+func ValidateDeviceRetentionPolicy(policy *database.DeviceRetentionPolicy) error {
+	if policy == nil {
+		return fmt.Errorf("policy is required")
+	}
+	if policy.StorageDays <= 0 || policy.StorageDays > MaxRetentionStorageDays {
+		return fmt.Errorf("storage_days must be between 1 and %d", MaxRetentionStorageDays)
+	}
+	if policy.Enabled != 0 {
+		policy.Enabled = 1
+	}
+	return nil
+}
+
+// SaveDeviceRetentionPolicy 新增或更新设备保留策略；系统属性设备（ID -1）不在设备表中，直接允许
+func (s *DataService) SaveDeviceRetentionPolicy(policy *database.DeviceRetentionPolicy) (*database.DeviceRetentionPolicy, error) {
+	if err := ValidateDeviceRetentionPolicy(policy); err != nil {
+		return nil, err
+	}
+	if policy.DeviceID != models.SystemStatsDeviceID {
+		if _, err := database.LoadDevice(policy.DeviceID); err != nil {
+			return nil, ErrRetentionDeviceNotFound
+		}
+	}
+	if err := database.UpsertDeviceRetentionPolicy(policy); err != nil {
+		return nil, err
+	}
+	return database.GetDeviceRetentionPolicy(policy.DeviceID)
+}
+
+// DeleteDeviceRetentionPolicy 删除设备保留策略，设备回退到网关全局保留天数
+func (s *DataService) DeleteDeviceRetentionPolicy(deviceID int64) error {
+	return database.DeleteDeviceRetentionPolicy(deviceID)
+}
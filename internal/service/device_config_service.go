@@ -8,10 +8,11 @@ import (
 
 type DeviceService struct {
 	collector *collectorpkg.Collector
+	manifests DriverManifestReader
 }
 
-func NewDeviceService(collector *collectorpkg.Collector) *DeviceService {
-	return &DeviceService{collector: collector}
+func NewDeviceService(collector *collectorpkg.Collector, manifests DriverManifestReader) *DeviceService {
+	return &DeviceService{collector: collector, manifests: manifests}
 }
 
 func (s *DeviceService) LoadDevice(id int64) (*models.Device, error) {
@@ -22,6 +23,9 @@ func (s *DeviceService) CreateDevice(device *models.Device) (*models.Device, err
 	if device == nil {
 		return nil, nil
 	}
+	if err := validateDeviceAgainstManifest(s.manifests, device); err != nil {
+		return nil, err
+	}
 
 	id, err := database.CreateDevice(device)
 	if err != nil {
@@ -35,6 +39,9 @@ func (s *DeviceService) UpdateDevice(device *models.Device) (*models.Device, err
 	if device == nil {
 		return nil, nil
 	}
+	if err := validateDeviceAgainstManifest(s.manifests, device); err != nil {
+		return nil, err
+	}
 	if err := database.UpdateDevice(device); err != nil {
 		return nil, err
 	}
@@ -76,6 +76,27 @@ func (s *DeviceExecService) ExecuteDriverFunction(driverID int64, pluginFunc str
 	return s.driverManager.ExecuteDriver(driverID, pluginFunc, ctx)
 }
 
+// SupportsBatchWrite 驱动清单是否声明支持一次调用写入多个字段
+func (s *DeviceExecService) SupportsBatchWrite(driverModel *models.Driver) bool {
+	reader, _ := s.driverManager.(DriverManifestReader)
+	manifest, err := resolveDriverManifest(reader, driverModel)
+	return err == nil && manifest != nil && manifest.BatchWrite
+}
+
+// ResolveDriverWritables 优先返回驱动清单中的可写点位，驱动未提供点位目录时回退到 config_schema 的 writable 声明
+func (s *DeviceExecService) ResolveDriverWritables(driverModel *models.Driver) ([]any, error) {
+	reader, _ := s.driverManager.(DriverManifestReader)
+	if manifest, err := resolveDriverManifest(reader, driverModel); err == nil && manifest != nil && len(manifest.Points) > 0 {
+		points := manifest.WritablePoints()
+		writables := make([]any, 0, len(points))
+		for _, point := range points {
+			writables = append(writables, point)
+		}
+		return writables, nil
+	}
+	return ParseDriverWritables(driverModel.ConfigSchema)
+}
+
 func ParseDriverWritables(configSchema string) ([]any, error) {
 	if configSchema == "" {
 		return nil, nil
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	driverpkg "github.com/gonglijing/xunjiFsu/internal/driver"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+// ErrDeviceConfigInvalid 设备配置不符合驱动 manifest 声明
+var ErrDeviceConfigInvalid = errors.New("invalid device config")
+
+type DriverManifestReader interface {
+	GetDriverManifest(id int64) (*driverpkg.DriverManifest, error)
+}
+
+// resolveDriverManifest 优先取已加载驱动的清单，未加载时从 wasm 文件读取
+func resolveDriverManifest(reader DriverManifestReader, driverModel *models.Driver) (*driverpkg.DriverManifest, error) {
+	if driverModel == nil {
+		return nil, nil
+	}
+	if reader != nil {
+		manifest, err := reader.GetDriverManifest(driverModel.ID)
+		if err == nil {
+			return manifest, nil
+		}
+		if !errors.Is(err, driverpkg.ErrDriverNotLoaded) {
+			return nil, err
+		}
+	}
+	if driverModel.FilePath == "" {
+		return nil, nil
+	}
+	wasmData, err := os.ReadFile(driverModel.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return driverpkg.ExtractDriverManifest(wasmData)
+}
+
+// validateDeviceAgainstManifest 按绑定驱动的清单校验 device_config 与资源类型，内置 Modbus 设备校验点表与从站地址
+// 驱动清单不可用时跳过校验，不阻塞设备保存
+func validateDeviceAgainstManifest(reader DriverManifestReader, device *models.Device) error {
+	if err := driverpkg.ValidateNativeModbusDevice(device); err != nil {
+		return fmt.Errorf("%w: %v", ErrDeviceConfigInvalid, err)
+	}
+	if device == nil || device.DriverID == nil {
+		return nil
+	}
+	driverModel, err := database.LoadDriver(*device.DriverID)
+	if err != nil {
+		return nil
+	}
+	manifest, err := resolveDriverManifest(reader, driverModel)
+	if err != nil {
+		slog.Warn("Load driver manifest failed, skip device config validation", "driver", driverModel.Name, "error", err)
+		return nil
+	}
+	if manifest == nil {
+		return nil
+	}
+
+	if err := manifest.ValidateDeviceConfig(device.DeviceConfig); err != nil {
+		return fmt.Errorf("%w: %v", ErrDeviceConfigInvalid, err)
+	}
+	if device.ResourceID != nil && len(manifest.ResourceTypes) > 0 {
+		resource, err := database.LoadResource(*device.ResourceID)
+		if err == nil && !manifest.SupportsResourceType(resource.Type) {
+			return fmt.Errorf("%w: driver %s does not support resource type %s", ErrDeviceConfigInvalid, driverModel.Name, resource.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonglijing/xunjiFsu/internal/database"
+	driverpkg "github.com/gonglijing/xunjiFsu/internal/driver"
+	"github.com/gonglijing/xunjiFsu/internal/models"
+)
+
+type stubManifestReader struct {
+	manifests map[int64]*driverpkg.DriverManifest
+}
+
+func (s stubManifestReader) GetDriverManifest(id int64) (*driverpkg.DriverManifest, error) {
+	manifest, ok := s.manifests[id]
+	if !ok {
+		return nil, driverpkg.ErrDriverNotLoaded
+	}
+	return manifest, nil
+}
+
+type stubManifestExecutor struct {
+	stubManifestReader
+	DeviceDriverExecutor
+}
+
+func mustParseManifest(t *testing.T, output string) *driverpkg.DriverManifest {
+	t.Helper()
+	manifest, err := driverpkg.ParseDriverManifest([]byte(output))
+	if err != nil {
+		t.Fatalf("ParseDriverManifest: %v", err)
+	}
+	return manifest
+}
+
+func TestDeviceService_ValidatesDeviceConfigAgainstManifest(t *testing.T) {
+	setupConfigBundleTestDB(t)
+
+	driverID, err := database.CreateDriver(&models.Driver{Name: "meter", Enabled: 1})
+	if err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+	netID, err := database.CreateResource(&models.Resource{Name: "lan", Type: "net", Path: "10.0.0.2:502", Enabled: 1})
+	if err != nil {
+		t.Fatalf("CreateResource: %v", err)
+	}
+	manifest := mustParseManifest(t, `{"resource_types":["serial"],"config_schema":{"type":"object","required":["slave_id"],
+		"properties":{"slave_id":{"type":"integer","minimum":1}}}}`)
+	svc := NewDeviceService(nil, stubManifestReader{manifests: map[int64]*driverpkg.DriverManifest{driverID: manifest}})
+
+	device := &models.Device{Name: "m1", DriverType: "wasm", Parity: "N", DriverID: &driverID, DeviceConfig: `{"slave_id":0}`}
+	if _, err := svc.CreateDevice(device); !errors.Is(err, ErrDeviceConfigInvalid) {
+		t.Fatalf("CreateDevice invalid config err = %v, want ErrDeviceConfigInvalid", err)
+	}
+
+	device.DeviceConfig = `{"slave_id":2}`
+	created, err := svc.CreateDevice(device)
+	if err != nil {
+		t.Fatalf("CreateDevice: %v", err)
+	}
+	loaded, err := database.LoadDevice(created.ID)
+	if err != nil || loaded.DeviceConfig != `{"slave_id":2}` {
+		t.Fatalf("LoadDevice = %+v, %v", loaded, err)
+	}
+
+	created.ResourceID = &netID
+	if _, err := svc.UpdateDevice(created); !errors.Is(err, ErrDeviceConfigInvalid) {
+		t.Fatalf("UpdateDevice unsupported resource err = %v, want ErrDeviceConfigInvalid", err)
+	}
+
+	// 驱动清单不可用时不阻塞保存
+	other := &models.Device{Name: "m2", DriverType: "wasm", Parity: "N", DeviceConfig: `{"x":1}`}
+	if _, err := NewDeviceService(nil, nil).CreateDevice(other); err != nil {
+		t.Fatalf("CreateDevice without driver: %v", err)
+	}
+
+	// 内置 Modbus 设备的从站地址无法解析时拒绝保存
+	native := &models.Device{Name: "m3", DriverType: "modbus_rtu", Parity: "N", DeviceAddress: "abc",
+		PointTable: `[{"name":"Ua","address":0}]`}
+	if _, err := NewDeviceService(nil, nil).CreateDevice(native); !errors.Is(err, ErrDeviceConfigInvalid) {
+		t.Fatalf("CreateDevice invalid unit id err = %v, want ErrDeviceConfigInvalid", err)
+	}
+}
+
+func TestDeviceExecService_ResolveDriverWritables(t *testing.T) {
+	driverModel := &models.Driver{ID: 7, ConfigSchema: `{"writable":["legacy"]}`}
+
+	legacy, err := NewDeviceExecService(nil).ResolveDriverWritables(driverModel)
+	if err != nil || len(legacy) != 1 || legacy[0] != "legacy" {
+		t.Fatalf("legacy writables = %#v, %v", legacy, err)
+	}
+
+	manifest := mustParseManifest(t, `{"points":[{"name":"Ua","rw":"R"},{"name":"sp","rw":"RW","min":0,"max":10}]}`)
+	svc := NewDeviceExecService(stubManifestExecutor{
+		stubManifestReader: stubManifestReader{manifests: map[int64]*driverpkg.DriverManifest{7: manifest}},
+	})
+	writables, err := svc.ResolveDriverWritables(driverModel)
+	if err != nil || len(writables) != 1 {
+		t.Fatalf("manifest writables = %#v, %v", writables, err)
+	}
+	if point, ok := writables[0].(driverpkg.ManifestPoint); !ok || point.Name != "sp" {
+		t.Fatalf("writable point = %#v", writables[0])
+	}
+}
@@ -23,6 +23,10 @@ type NorthboundStatusItem struct {
 	DBEnabled        bool   `json:"db_enabled,omitempty"`
 	DBUploadInterval int    `json:"db_upload_interval,omitempty"`
 	LastSentAt       string `json:"last_sent_at,omitempty"`
+	SpoolDepth       int    `json:"spool_depth,omitempty"`
+	SpoolReplayed    int64  `json:"spool_replayed,omitempty"`
+	ListenAddress    string `json:"listen_address,omitempty"`
+	Clients          int    `json:"clients,omitempty"`
 }
 
 func (s *NorthboundService) ListStatusItems() ([]NorthboundStatusItem, error) {
@@ -97,6 +101,10 @@ func buildNorthboundStatusItem(name string, cfg *models.NorthboundConfig, runtim
 		UploadInterval: runtimeStatus.UploadIntervalMS,
 		Pending:        runtimeStatus.Pending,
 		BreakerState:   runtimeStatus.BreakerState,
+		SpoolDepth:     runtimeStatus.SpoolDepth,
+		SpoolReplayed:  runtimeStatus.SpoolReplayed,
+		ListenAddress:  runtimeStatus.ListenAddress,
+		Clients:        runtimeStatus.Clients,
 	}
 	if cfg != nil {
 		item.ID = cfg.ID
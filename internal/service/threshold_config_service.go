@@ -1,15 +1,19 @@
 package service
 
 import (
-	"github.com/gonglijing/xunjiFsu/internal/collector"
+	"log/slog"
+
+	collectorpkg "github.com/gonglijing/xunjiFsu/internal/collector"
 	"github.com/gonglijing/xunjiFsu/internal/database"
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
-type ThresholdService struct{}
+type ThresholdService struct {
+	collector *collectorpkg.Collector
+}
 
-func NewThresholdService() *ThresholdService {
-	return &ThresholdService{}
+func NewThresholdService(collector *collectorpkg.Collector) *ThresholdService {
+	return &ThresholdService{collector: collector}
 }
 
 func (s *ThresholdService) ListThresholds() ([]*models.Threshold, error) {
@@ -42,6 +46,11 @@ func (s *ThresholdService) UpdateThreshold(threshold *models.Threshold) (*models
 		return nil, err
 	}
 	InvalidateThresholdDeviceCaches(threshold, oldThreshold)
+	if thresholdConditionChanged(oldThreshold, threshold) {
+		if err := s.collector.ReleaseThresholdAlarms(threshold.ID); err != nil {
+			slog.Warn("Failed to release active alarms of updated threshold", "threshold_id", threshold.ID, "error", err)
+		}
+	}
 	return threshold, nil
 }
 
@@ -51,6 +60,9 @@ func (s *ThresholdService) DeleteThreshold(id int64) error {
 		return err
 	}
 	InvalidateThresholdDeviceCaches(nil, threshold)
+	if err := s.collector.ReleaseThresholdAlarms(id); err != nil {
+		slog.Warn("Failed to release active alarms of deleted threshold", "threshold_id", id, "error", err)
+	}
 	return nil
 }
 
@@ -65,6 +77,6 @@ func (s *ThresholdService) UpdateAlarmRepeatInterval(seconds int) error {
 	if err := database.UpdateAlarmRepeatIntervalSeconds(seconds); err != nil {
 		return err
 	}
-	collector.InvalidateAlarmRepeatIntervalCache()
+	collectorpkg.InvalidateAlarmRepeatIntervalCache()
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/gonglijing/xunjiFsu/internal/collector"
 	"github.com/gonglijing/xunjiFsu/internal/models"
 )
 
@@ -23,7 +24,49 @@ func NormalizeThresholdInput(threshold *models.Threshold) {
 	threshold.Operator = strings.TrimSpace(threshold.Operator)
 	threshold.Severity = strings.TrimSpace(threshold.Severity)
 	threshold.Message = strings.TrimSpace(threshold.Message)
+	threshold.Expression = strings.TrimSpace(threshold.Expression)
 	if threshold.Shielded != 1 {
 		threshold.Shielded = 0
 	}
+	if threshold.Deadband < 0 {
+		threshold.Deadband = -threshold.Deadband
+	}
+	if threshold.TriggerDelay < 0 {
+		threshold.TriggerDelay = 0
+	}
+	if threshold.ClearDelay < 0 {
+		threshold.ClearDelay = 0
+	}
+}
+
+// thresholdConditionChanged 判断报警条件是否变化（变化后需结束该阈值的当前报警）
+func thresholdConditionChanged(oldThreshold, newThreshold *models.Threshold) bool {
+	if oldThreshold == nil || newThreshold == nil {
+		return true
+	}
+	return oldThreshold.DeviceID != newThreshold.DeviceID ||
+		oldThreshold.FieldName != newThreshold.FieldName ||
+		oldThreshold.Operator != newThreshold.Operator ||
+		oldThreshold.Value != newThreshold.Value ||
+		oldThreshold.Expression != newThreshold.Expression ||
+		oldThreshold.Shielded != newThreshold.Shielded
+}
+
+// ValidateThresholdExpression 校验表达式阈值；field_name 为空时取表达式首个引用字段
+func ValidateThresholdExpression(threshold *models.Threshold) error {
+	if threshold == nil {
+		return nil
+	}
+	if threshold.Operator != collector.ThresholdOperatorExpr {
+		threshold.Expression = ""
+		return nil
+	}
+	primaryField, err := collector.ValidateThresholdExpression(threshold.Expression)
+	if err != nil {
+		return fmt.Errorf("invalid threshold expression: %w", err)
+	}
+	if threshold.FieldName == "" {
+		threshold.FieldName = primaryField
+	}
+	return nil
 }
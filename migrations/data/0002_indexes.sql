@@ -0,0 +1,2 @@
+-- data.db 内存库高频查询索引
+CREATE INDEX IF NOT EXISTS idx_data_points_device_field ON data_points(device_id, field_name);
@@ -0,0 +1,2 @@
+-- storage_config 已迁至 param.db（设备级保留策略），移除早期版本遗留的同名表
+DROP TABLE IF EXISTS storage_config;
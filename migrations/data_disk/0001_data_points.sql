@@ -0,0 +1,16 @@
+-- data.db 磁盘文件 Schema - 内存库定期落盘的历史数据
+-- 旧版文件的 value_num 补列见 0003，降采样汇总表见 0004
+
+CREATE TABLE IF NOT EXISTS data_points (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id INTEGER NOT NULL,
+    device_name TEXT NOT NULL,
+    field_name TEXT NOT NULL,
+    value TEXT NOT NULL,
+    value_num REAL,
+    value_type TEXT DEFAULT 'string',
+    collected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(device_id, field_name, collected_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_points_device_time ON data_points(device_id, collected_at DESC);
@@ -0,0 +1,5 @@
+-- 历史写入日志回放水位：已随同步事务落盘的最大日志段号
+CREATE TABLE IF NOT EXISTS data_journal_state (
+    name TEXT PRIMARY KEY,
+    seq INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,25 @@
+-- 旧版 data.db 补充 value_num 列（由迁移执行器按 legacySchemaColumns 补齐），并把能无损还原的文本数值（整数、小数、true/false）迁移为数值存储；
+-- 已按数值写入过的文件（存在非空 value_num）不做转换
+CREATE TEMP TABLE value_num_backfill AS
+SELECT NOT EXISTS (SELECT 1 FROM data_points WHERE value_num IS NOT NULL) AS pending;
+
+UPDATE data_points SET value_num = CAST(value AS INTEGER),
+    value_type = CASE WHEN value_type = 'float' THEN 'float' ELSE 'int' END, value = ''
+WHERE (SELECT pending FROM value_num_backfill)
+    AND IFNULL(value_type, 'string') IN ('string', 'int', 'float')
+    AND CAST(CAST(value AS INTEGER) AS TEXT) = value
+    AND ABS(CAST(value AS INTEGER)) <= 9007199254740992;
+
+UPDATE data_points SET value_num = CAST(value AS REAL), value_type = 'float', value = ''
+WHERE (SELECT pending FROM value_num_backfill)
+    AND value_num IS NULL AND IFNULL(value_type, 'string') IN ('string', 'float')
+    AND value GLOB '*[0-9]*' AND CAST(CAST(value AS REAL) AS TEXT) = value;
+
+UPDATE data_points SET value_num = (value = 'true'), value_type = 'bool', value = ''
+WHERE (SELECT pending FROM value_num_backfill)
+    AND value_num IS NULL AND IFNULL(value_type, 'string') IN ('string', 'bool') AND value IN ('true', 'false');
+
+DROP TABLE value_num_backfill;
+
+-- FSU 系统属性（device_id = -1）统一使用固定设备名
+UPDATE data_points SET device_name = '__system__' WHERE device_id = -1 AND device_name <> '__system__';
@@ -0,0 +1,32 @@
+-- 降采样汇总层级（分钟 / 小时），由 RefreshDataRollups 按 data_points.id 水位增量维护
+CREATE TABLE IF NOT EXISTS data_rollup_1m (
+    device_id INTEGER NOT NULL,
+    device_name TEXT NOT NULL,
+    field_name TEXT NOT NULL,
+    bucket DATETIME NOT NULL,
+    min_value REAL NOT NULL,
+    max_value REAL NOT NULL,
+    avg_value REAL NOT NULL,
+    last_value REAL NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (device_id, field_name, bucket)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS data_rollup_1h (
+    device_id INTEGER NOT NULL,
+    device_name TEXT NOT NULL,
+    field_name TEXT NOT NULL,
+    bucket DATETIME NOT NULL,
+    min_value REAL NOT NULL,
+    max_value REAL NOT NULL,
+    avg_value REAL NOT NULL,
+    last_value REAL NOT NULL,
+    count INTEGER NOT NULL,
+    PRIMARY KEY (device_id, field_name, bucket)
+) WITHOUT ROWID;
+
+-- 汇总水位：已汇总的最大 data_points.id
+CREATE TABLE IF NOT EXISTS data_rollup_state (
+    name TEXT PRIMARY KEY,
+    source_id INTEGER NOT NULL DEFAULT 0
+);
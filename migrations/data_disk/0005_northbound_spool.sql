@@ -0,0 +1,10 @@
+-- 北向暂存：断线或熔断期间的消息先落盘，恢复后按 id 顺序补发
+CREATE TABLE IF NOT EXISTS northbound_spool (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    adapter TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    payload BLOB NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_northbound_spool_adapter ON northbound_spool(adapter, id);
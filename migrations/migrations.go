@@ -0,0 +1,15 @@
+// Package migrations 内嵌各数据库的版本化 SQL 迁移脚本。
+//
+// 目录对应数据库：param/ → param.db，data/ → data.db 内存库，data_disk/ → data.db 磁盘文件。
+// 文件名格式为 NNNN_说明.sql，版本号从 1 开始连续递增；已发布的脚本不可修改，结构变更只能追加新版本。
+//
+// 版本化之前的旧库由运行时按需补列，结构不确定；这些旧列不写成 ADD COLUMN 语句，
+// 而是登记在 internal/database 的 legacySchemaColumns 中，由迁移执行器在执行对应版本前逐列检查补齐。
+package migrations
+
+import "embed"
+
+// FS 内嵌的迁移脚本
+//
+//go:embed param/*.sql data/*.sql data_disk/*.sql
+var FS embed.FS
@@ -1,8 +1,6 @@
 -- param.db Schema - 配置数据库
 -- 所有配置信息、用户、报警日志等存储在此数据库
 
-PRAGMA foreign_keys = ON;
-
 -- 用户表
 CREATE TABLE IF NOT EXISTS users (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -65,6 +63,8 @@ CREATE TABLE IF NOT EXISTS devices (
     ip_address TEXT,
     port_num INTEGER,
     device_address TEXT,
+    point_table TEXT,
+    deadbands TEXT,
     protocol TEXT DEFAULT 'tcp' CHECK(protocol IN ('tcp', 'udp')),
     storage_interval INTEGER DEFAULT 300,
     enabled INTEGER DEFAULT 1,
@@ -91,11 +91,15 @@ CREATE TABLE IF NOT EXISTS thresholds (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     device_id INTEGER NOT NULL,
     field_name TEXT NOT NULL,
-    operator TEXT NOT NULL CHECK(operator IN ('>', '<', '>=', '<=', '==', '!=')),
+    operator TEXT NOT NULL CHECK(operator IN ('>', '<', '>=', '<=', '==', '!=', 'expr')),
     value REAL NOT NULL,
     severity TEXT DEFAULT 'warning' CHECK(severity IN ('info', 'warning', 'error', 'critical')),
     shielded INTEGER DEFAULT 0,
     message TEXT,
+    expression TEXT,
+    deadband REAL DEFAULT 0,
+    trigger_delay_seconds INTEGER DEFAULT 0,
+    clear_delay_seconds INTEGER DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (device_id) REFERENCES devices(id) ON DELETE CASCADE
@@ -116,10 +120,26 @@ CREATE TABLE IF NOT EXISTS alarm_logs (
     acknowledged INTEGER DEFAULT 0,
     acknowledged_by TEXT,
     acknowledged_at DATETIME,
+    cleared_at DATETIME,
     FOREIGN KEY (device_id) REFERENCES devices(id) ON DELETE CASCADE,
     FOREIGN KEY (threshold_id) REFERENCES thresholds(id) ON DELETE SET NULL
 );
 
+-- 当前报警表（每个设备 + 阈值最多一条，恢复后删除）
+CREATE TABLE IF NOT EXISTS active_alarms (
+    device_id INTEGER NOT NULL,
+    threshold_id INTEGER NOT NULL,
+    alarm_log_id INTEGER,
+    field_name TEXT,
+    actual_value REAL,
+    threshold_value REAL,
+    operator TEXT,
+    severity TEXT,
+    message TEXT,
+    triggered_at DATETIME NOT NULL,
+    PRIMARY KEY (device_id, threshold_id)
+);
+
 -- 采集数据缓存表（用于存储最新的采集数据）
 CREATE TABLE IF NOT EXISTS data_cache (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
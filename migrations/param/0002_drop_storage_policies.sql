@@ -0,0 +1,2 @@
+-- 移除早期版本遗留、从未使用的 storage_policies 表
+DROP TABLE IF EXISTS storage_policies;
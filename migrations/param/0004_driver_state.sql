@@ -0,0 +1,8 @@
+-- 驱动按设备持久化的键值状态（WASM host API kv_get / kv_set / kv_delete）
+CREATE TABLE IF NOT EXISTS driver_state (
+    device_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value BLOB NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (device_id, key)
+);
@@ -0,0 +1,2 @@
+-- 设备级驱动配置（按驱动清单 device_config_schema 校验的 JSON），早期版本的设备表没有该列；
+-- devices.device_config 由迁移执行器按 legacySchemaColumns 补齐，本脚本无需其他变更
@@ -0,0 +1,6 @@
+-- 补齐版本化之前旧库缺失的列（原由运行时按需 ALTER）：设备、阈值、报警日志、北向配置的列
+-- 由迁移执行器按 legacySchemaColumns 逐列检查后补齐，本脚本只做数据修正
+
+-- 阈值表：enabled 由 shielded 取代，旧列随 0007 重建阈值表移除
+UPDATE thresholds SET shielded = 0 WHERE shielded IS NULL;
+DROP INDEX IF EXISTS idx_thresholds_device_enabled;
@@ -0,0 +1,87 @@
+-- 重建设备表：移除旧版 upload_interval / protocol 列（device_config 须保留）
+CREATE TABLE devices_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT,
+    product_key TEXT,
+    device_key TEXT,
+    driver_type TEXT DEFAULT 'modbus_rtu',
+    serial_port TEXT,
+    resource_id INTEGER,
+    driver_id INTEGER,
+    collect_interval INTEGER DEFAULT 5000,
+    storage_interval INTEGER DEFAULT 300,
+    timeout INTEGER DEFAULT 1000,
+    baud_rate INTEGER DEFAULT 9600,
+    data_bits INTEGER DEFAULT 8,
+    stop_bits INTEGER DEFAULT 1,
+    parity TEXT DEFAULT 'N' CHECK(parity IN ('N', 'O', 'E')),
+    ip_address TEXT,
+    port_num INTEGER DEFAULT 502,
+    device_address TEXT,
+    point_table TEXT,
+    deadbands TEXT,
+    device_config TEXT,
+    enabled INTEGER DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (resource_id) REFERENCES resources(id) ON DELETE SET NULL,
+    FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE SET NULL
+);
+
+INSERT INTO devices_new (
+    id, name, description, product_key, device_key, driver_type,
+    serial_port, resource_id, driver_id,
+    collect_interval, storage_interval, timeout,
+    baud_rate, data_bits, stop_bits, parity,
+    ip_address, port_num, device_address, point_table, deadbands, device_config,
+    enabled, created_at, updated_at
+)
+SELECT
+    id, name, description, product_key, device_key, COALESCE(driver_type, 'modbus_rtu'),
+    serial_port, resource_id, driver_id,
+    COALESCE(collect_interval, 5000), COALESCE(storage_interval, 300), COALESCE(timeout, 1000),
+    COALESCE(baud_rate, 9600), COALESCE(data_bits, 8), COALESCE(stop_bits, 1), COALESCE(parity, 'N'),
+    ip_address, COALESCE(port_num, 502), device_address, point_table, deadbands, device_config,
+    COALESCE(enabled, 1), created_at, updated_at
+FROM devices;
+
+DROP TABLE devices;
+ALTER TABLE devices_new RENAME TO devices;
+
+CREATE INDEX IF NOT EXISTS idx_devices_enabled ON devices(enabled);
+CREATE INDEX IF NOT EXISTS idx_devices_resource ON devices(resource_id);
+CREATE INDEX IF NOT EXISTS idx_devices_driver ON devices(driver_id);
+
+-- 重建阈值表：旧库 operator CHECK 约束不含 expr
+CREATE TABLE thresholds_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id INTEGER NOT NULL,
+    field_name TEXT NOT NULL,
+    operator TEXT NOT NULL CHECK(operator IN ('>', '<', '>=', '<=', '==', '!=', 'expr')),
+    value REAL NOT NULL,
+    severity TEXT DEFAULT 'warning' CHECK(severity IN ('info', 'warning', 'error', 'critical')),
+    shielded INTEGER DEFAULT 0,
+    message TEXT,
+    expression TEXT,
+    deadband REAL DEFAULT 0,
+    trigger_delay_seconds INTEGER DEFAULT 0,
+    clear_delay_seconds INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (device_id) REFERENCES devices(id) ON DELETE CASCADE
+);
+
+INSERT INTO thresholds_new (
+    id, device_id, field_name, operator, value, severity, shielded, message, expression,
+    deadband, trigger_delay_seconds, clear_delay_seconds, created_at, updated_at
+)
+SELECT
+    id, device_id, field_name, operator, value, COALESCE(severity, 'warning'), COALESCE(shielded, 0), message, expression,
+    COALESCE(deadband, 0), COALESCE(trigger_delay_seconds, 0), COALESCE(clear_delay_seconds, 0), created_at, updated_at
+FROM thresholds;
+
+DROP TABLE thresholds;
+ALTER TABLE thresholds_new RENAME TO thresholds;
+
+CREATE INDEX IF NOT EXISTS idx_thresholds_device ON thresholds(device_id);
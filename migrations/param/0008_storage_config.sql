@@ -0,0 +1,9 @@
+-- 设备级历史保留策略（覆盖网关全局 data_retention_days）
+CREATE TABLE IF NOT EXISTS storage_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id INTEGER UNIQUE NOT NULL,
+    storage_days INTEGER NOT NULL DEFAULT 30,
+    enabled INTEGER DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
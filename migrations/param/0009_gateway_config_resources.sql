@@ -0,0 +1,40 @@
+-- 网关配置表（原由运行时创建并按需补列），旧库缺失的 data_retention_days / alarm_repeat_interval_seconds
+-- 由迁移执行器按 legacySchemaColumns 补齐
+CREATE TABLE IF NOT EXISTS gateway_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_key TEXT NOT NULL,
+    device_key TEXT NOT NULL,
+    gateway_name TEXT DEFAULT 'HuShu智能网关',
+    data_retention_days INTEGER DEFAULT 30,
+    alarm_repeat_interval_seconds INTEGER DEFAULT 60,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+UPDATE gateway_config SET data_retention_days = 30
+WHERE data_retention_days IS NULL OR data_retention_days <= 0;
+UPDATE gateway_config SET alarm_repeat_interval_seconds = 60
+WHERE alarm_repeat_interval_seconds IS NULL OR alarm_repeat_interval_seconds <= 0;
+
+-- 重建资源表：串口路径统一存 path（旧版存 port），移除旧版 port / address 列，
+-- 旧版 network / tcp 类型归一为 net
+CREATE TABLE resources_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK(type IN ('serial', 'net', 'di', 'do')),
+    path TEXT,
+    enabled INTEGER DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO resources_new (id, name, type, path, enabled, created_at, updated_at)
+SELECT id, name,
+    CASE WHEN type IN ('network', 'tcp') THEN 'net' ELSE type END,
+    COALESCE(path, port, ''),
+    enabled, created_at, updated_at
+FROM resources;
+
+DROP TABLE resources;
+ALTER TABLE resources_new RENAME TO resources;
+
+CREATE INDEX IF NOT EXISTS idx_resources_enabled ON resources(enabled);
@@ -19,6 +19,10 @@ export async function createNorthboundConfig(payload) {
   return postJSON('/api/northbound', payload);
 }
 
+export async function previewNorthboundConfig(payload) {
+  return postJSON('/api/northbound/preview', payload);
+}
+
 export async function updateNorthboundConfig(id, payload) {
   return putJSON(`/api/northbound/${id}`, payload);
 }
@@ -78,7 +78,7 @@ function DeviceDetailDrawer(props) {
                           {(p) => (
                             <tr>
                               <td>{p.field_name || p.FieldName}</td>
-                              <td>{p.value || p.Value}</td>
+                              <td>{String(p.value ?? p.Value ?? '')}</td>
                               <td>{formatDateTime(p.collected_at || p.CollectedAt)}</td>
                             </tr>
                           )}
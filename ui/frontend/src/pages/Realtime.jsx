@@ -143,7 +143,7 @@ function Realtime() {
   const series = () => historyData()
     .map((p) => ({
       t: new Date(p.collected_at || p.CollectedAt || 0).getTime(),
-      v: Number.parseFloat(p.value || p.Value),
+      v: Number.parseFloat(p.value ?? p.Value),
     }))
     .filter((p) => !Number.isNaN(p.t) && !Number.isNaN(p.v))
     .sort((a, b) => a.t - b.t);
@@ -304,7 +304,7 @@ function Realtime() {
                     <td>{formatDateTime(p.collected_at || p.CollectedAt)}</td>
                     <td>{deviceName}</td>
                     <td>{p.field_name || ''}</td>
-                    <td>{p.value}</td>
+                    <td>{String(p.value ?? '')}</td>
                     <td>
                       <div class="table-actions">
                         <button class="btn btn-soft-primary btn-sm" onClick={() => openHistory(p)}>历史数据</button>
@@ -431,7 +431,7 @@ function Realtime() {
                     {historyData().map((p) => (
                       <tr key={p.id || `${p.device_id}-${p.field_name}-${p.collected_at}`}>
                         <td>{formatDateTime(p.collected_at || p.CollectedAt)}</td>
-                        <td>{p.value || p.Value}</td>
+                        <td>{String(p.value ?? p.Value ?? '')}</td>
                       </tr>
                     ))}
                     {historyData().length === 0 && (
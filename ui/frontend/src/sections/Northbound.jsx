@@ -415,6 +415,9 @@ export function Northbound() {
     if (type === NORTHBOUND_TYPE.PANDAX) return 'PandaX Schema 配置';
     if (type === NORTHBOUND_TYPE.ITHINGS) return 'iThings Schema 配置';
     if (type === NORTHBOUND_TYPE.MQTT) return 'MQTT Schema 配置';
+    if (type === NORTHBOUND_TYPE.OPCUA) return 'OPC UA Schema 配置';
+    if (type === NORTHBOUND_TYPE.MODBUS_SLAVE) return 'Modbus TCP Slave Schema 配置';
+    if (type === NORTHBOUND_TYPE.HTTP) return 'HTTP Schema 配置';
     return '配置';
   };
 
@@ -556,6 +559,9 @@ export function Northbound() {
                     <option value={NORTHBOUND_TYPE.PANDAX}>PandaX</option>
                     <option value={NORTHBOUND_TYPE.ITHINGS}>iThings</option>
                     <option value={NORTHBOUND_TYPE.SAGOO}>Sagoo</option>
+                    <option value={NORTHBOUND_TYPE.OPCUA}>OPC UA</option>
+                    <option value={NORTHBOUND_TYPE.MODBUS_SLAVE}>Modbus TCP Slave</option>
+                    <option value={NORTHBOUND_TYPE.HTTP}>HTTP</option>
                   </select>
                 </div>
                 <div class="form-group">
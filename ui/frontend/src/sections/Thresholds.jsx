@@ -17,6 +17,10 @@ const empty = {
   value: 0,
   severity: 'warning',
   message: '',
+  expression: '',
+  deadband: 0,
+  trigger_delay_seconds: 0,
+  clear_delay_seconds: 0,
   enabled: 1,
   shielded: 0,
 };
@@ -123,6 +127,10 @@ export function Thresholds() {
       severity: threshold.severity,
       enabled: threshold.enabled,
       message: threshold.message,
+      expression: threshold.expression || '',
+      deadband: threshold.deadband || 0,
+      trigger_delay_seconds: threshold.trigger_delay_seconds || 0,
+      clear_delay_seconds: threshold.clear_delay_seconds || 0,
       shielded: nextShielded,
     };
 
@@ -216,7 +224,7 @@ export function Thresholds() {
                       <div class="text-muted text-xs">ID: {item.device_id}</div>
                     </td>
                     <td>{item.field_name}</td>
-                    <td>{item.operator} {item.value}</td>
+                    <td>{item.operator === 'expr' ? item.expression : `${item.operator} ${item.value}`}</td>
                     <td>{item.severity}</td>
                     <td>
                       <span class={`badge ${item.shielded === 1 ? 'badge-stopped' : 'badge-running'}`}>
@@ -276,7 +284,8 @@ export function Thresholds() {
                 class="form-input"
                 value={form().field_name}
                 onInput={(e) => setForm({ ...form(), field_name: e.target.value })}
-                required
+                placeholder={form().operator === 'expr' ? '留空则取表达式中第一个字段' : ''}
+                required={form().operator !== 'expr'}
               />
             </div>
             <div class="grid" style={{ gridTemplateColumns: '1fr 1fr', gap: '12px' }}>
@@ -292,18 +301,66 @@ export function Thresholds() {
                   <option value="<">小于 &lt;</option>
                   <option value="<=">小于等于 &lt;=</option>
                   <option value="==">等于 ==</option>
+                  <option value="expr">表达式</option>
                 </select>
               </div>
+              <Show when={form().operator !== 'expr'}>
+                <div class="form-group">
+                  <label class="form-label">阈值</label>
+                  <input
+                    class="form-input"
+                    type="number"
+                    value={form().value}
+                    onInput={(e) => setForm({ ...form(), value: +e.target.value })}
+                    required
+                  />
+                </div>
+              </Show>
+            </div>
+            <Show when={form().operator === 'expr'}>
               <div class="form-group">
-                <label class="form-label">阈值</label>
+                <label class="form-label">表达式</label>
                 <input
                   class="form-input"
-                  type="number"
-                  value={form().value}
-                  onInput={(e) => setForm({ ...form(), value: +e.target.value })}
+                  value={form().expression}
+                  onInput={(e) => setForm({ ...form(), expression: e.target.value })}
+                  placeholder="Ua < 180 && Ia > 5"
                   required
                 />
               </div>
+            </Show>
+            <div class="grid" style={{ gridTemplateColumns: '1fr 1fr 1fr', gap: '12px' }}>
+              <div class="form-group">
+                <label class="form-label">回差</label>
+                <input
+                  class="form-input"
+                  type="number"
+                  min="0"
+                  step="any"
+                  value={form().deadband}
+                  onInput={(e) => setForm({ ...form(), deadband: +e.target.value })}
+                />
+              </div>
+              <div class="form-group">
+                <label class="form-label">触发延时(秒)</label>
+                <input
+                  class="form-input"
+                  type="number"
+                  min="0"
+                  value={form().trigger_delay_seconds}
+                  onInput={(e) => setForm({ ...form(), trigger_delay_seconds: +e.target.value })}
+                />
+              </div>
+              <div class="form-group">
+                <label class="form-label">恢复延时(秒)</label>
+                <input
+                  class="form-input"
+                  type="number"
+                  min="0"
+                  value={form().clear_delay_seconds}
+                  onInput={(e) => setForm({ ...form(), clear_delay_seconds: +e.target.value })}
+                />
+              </div>
             </div>
             <div class="form-group">
               <label class="form-label">严重程度</label>
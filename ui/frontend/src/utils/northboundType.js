@@ -3,6 +3,9 @@ export const NORTHBOUND_TYPE = Object.freeze({
   PANDAX: 'pandax',
   ITHINGS: 'ithings',
   SAGOO: 'sagoo',
+  OPCUA: 'opcua',
+  MODBUS_SLAVE: 'modbus_slave',
+  HTTP: 'http',
 });
 
 export function normalizeNorthboundType(type) {
@@ -13,7 +16,10 @@ export function isSchemaDrivenType(type) {
   const normalized = normalizeNorthboundType(type);
   return normalized === NORTHBOUND_TYPE.SAGOO
     || normalized === NORTHBOUND_TYPE.PANDAX
-    || normalized === NORTHBOUND_TYPE.ITHINGS;
+    || normalized === NORTHBOUND_TYPE.ITHINGS
+    || normalized === NORTHBOUND_TYPE.OPCUA
+    || normalized === NORTHBOUND_TYPE.MODBUS_SLAVE
+    || normalized === NORTHBOUND_TYPE.HTTP;
 }
 
 export function getNorthboundTypeLabel(type) {
@@ -26,6 +32,12 @@ export function getNorthboundTypeLabel(type) {
       return 'iThings';
     case NORTHBOUND_TYPE.SAGOO:
       return 'Sagoo';
+    case NORTHBOUND_TYPE.OPCUA:
+      return 'OPC UA';
+    case NORTHBOUND_TYPE.MODBUS_SLAVE:
+      return 'Modbus TCP Slave';
+    case NORTHBOUND_TYPE.HTTP:
+      return 'HTTP';
     default:
       return `${type ?? ''}`.trim().toUpperCase();
   }